@@ -0,0 +1,449 @@
+// Package asm assembles a textual subset of ARM64 assembly into machine code.
+//
+// The syntax is the GNU syntax printed by insts.Disassemble, so every
+// instruction the decoder supports can be written by hand, including the
+// preferred aliases (MOV, CMP, LSL, CSET, ...). Source holds one statement per
+// line, or several separated by ";". Comments start with "//".
+//
+// A statement is an instruction, a directive or a label definition ("loop:").
+// Numeric labels ("1:") may be defined more than once and are referenced as
+// "1b" (the nearest preceding definition) or "1f" (the nearest following
+// one). Branch targets and data values are expressions of numbers, labels
+// and "." (the address of the current statement) joined with "+" and "-".
+//
+// Supported directives:
+//
+//	.word, .inst   32-bit values
+//	.quad          64-bit values
+//	.hword, .byte  16-bit and 8-bit values
+//	.ascii, .asciz quoted strings, without or with a terminating NUL
+//	.space n       n zero bytes
+//	.align p       pad to a 2^p byte boundary (with NOPs in code)
+//
+// Usage:
+//
+//	code, err := asm.AssembleAt(`
+//		mov x0, #10
+//	loop:
+//		subs x0, x0, #1
+//		b.ne loop
+//		ret
+//	`, 0x1000)
+package asm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Error describes a statement that could not be assembled.
+type Error struct {
+	Line int    // 1-based source line
+	Text string // The statement
+	Msg  string // What is wrong with it
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s: %q", e.Line, e.Msg, e.Text)
+}
+
+// Assemble assembles src for a program loaded at address 0.
+func Assemble(src string) ([]byte, error) {
+	return AssembleAt(src, 0)
+}
+
+// AssembleAt assembles src for a program loaded at base. The base address
+// only matters for ADRP and for absolute branch targets; label references
+// are PC-relative.
+func AssembleAt(src string, base uint64) ([]byte, error) {
+	a := &assembler{
+		base:   base,
+		labels: make(map[string]uint64),
+		locals: make(map[string][]localLabel),
+	}
+	if err := a.layout(src); err != nil {
+		return nil, err
+	}
+	return a.emit()
+}
+
+// MustAssemble is like Assemble but panics if src cannot be assembled. It
+// simplifies building fixed test programs.
+func MustAssemble(src string) []byte {
+	code, err := Assemble(src)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// statement is one instruction or directive.
+type statement struct {
+	line     int
+	text     string
+	mnemonic string // Lower case
+	operands []string
+	addr     uint64
+	size     uint64
+}
+
+// localLabel is one definition of a numeric label. index is the number of
+// statements that precede it.
+type localLabel struct {
+	index int
+	addr  uint64
+}
+
+// assembler holds the state of one assembly.
+type assembler struct {
+	base   uint64
+	stmts  []*statement
+	labels map[string]uint64
+	locals map[string][]localLabel
+}
+
+// bailout carries an assembly error message out of the encoders.
+type bailout string
+
+// fail aborts the current statement with an error message.
+func fail(format string, args ...any) {
+	panic(bailout(fmt.Sprintf(format, args...)))
+}
+
+// catch converts a bailout from f into an Error for s.
+func catch(s *statement, f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			err = &Error{Line: s.line, Text: s.text, Msg: string(msg)}
+		}
+	}()
+	f()
+	return nil
+}
+
+var labelPattern = regexp.MustCompile(`^([A-Za-z_.$][\w.$]*|[0-9]+)\s*:`)
+
+// layout parses src, assigns an address to every statement and records the
+// label definitions.
+func (a *assembler) layout(src string) error {
+	addr := a.base
+	for n, line := range strings.Split(src, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		for _, text := range splitStatements(line) {
+			text = strings.TrimSpace(text)
+			for {
+				m := labelPattern.FindStringSubmatch(text)
+				if m == nil {
+					break
+				}
+				if err := a.define(m[1], addr, n+1, text); err != nil {
+					return err
+				}
+				text = strings.TrimSpace(text[len(m[0]):])
+			}
+			if text == "" {
+				continue
+			}
+
+			s := parseStatement(n+1, text)
+			s.addr = addr
+			if err := catch(s, func() { s.size = a.sizeOf(s) }); err != nil {
+				return err
+			}
+			a.stmts = append(a.stmts, s)
+			addr += s.size
+		}
+	}
+	return nil
+}
+
+// define records a label at addr.
+func (a *assembler) define(name string, addr uint64, line int, text string) error {
+	if _, err := strconv.Atoi(name); err == nil {
+		a.locals[name] = append(a.locals[name], localLabel{index: len(a.stmts), addr: addr})
+		return nil
+	}
+	if _, ok := a.labels[name]; ok {
+		return &Error{Line: line, Text: text, Msg: fmt.Sprintf("label %s redefined", name)}
+	}
+	a.labels[name] = addr
+	return nil
+}
+
+// splitStatements splits a line at the ";" separators outside string
+// literals.
+func splitStatements(line string) []string {
+	var parts []string
+	inString := false
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && inString:
+			i++
+		case line[i] == '"':
+			inString = !inString
+		case line[i] == ';' && !inString:
+			parts = append(parts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, line[start:])
+}
+
+// parseStatement splits a statement into its mnemonic and operands.
+func parseStatement(line int, text string) *statement {
+	s := &statement{line: line, text: text}
+	mnemonic, rest := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		mnemonic, rest = text[:i], text[i+1:]
+	}
+	s.mnemonic = strings.ToLower(mnemonic)
+	s.operands = splitOperands(rest)
+	return s
+}
+
+// splitOperands splits an operand list at the commas outside brackets,
+// braces and string literals.
+func splitOperands(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	var ops []string
+	depth := 0
+	inString := false
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\' && inString:
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			ops = append(ops, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	return append(ops, strings.TrimSpace(text[start:]))
+}
+
+// sizeOf returns the number of bytes statement s assembles to.
+func (a *assembler) sizeOf(s *statement) uint64 {
+	switch s.mnemonic {
+	case ".word", ".inst":
+		return 4 * uint64(len(s.operands))
+	case ".quad":
+		return 8 * uint64(len(s.operands))
+	case ".hword":
+		return 2 * uint64(len(s.operands))
+	case ".byte":
+		return uint64(len(s.operands))
+	case ".ascii", ".asciz":
+		return uint64(len(stringData(s)))
+	case ".space":
+		if len(s.operands) != 1 {
+			fail(".space takes one operand")
+		}
+		n, err := a.constant(s.operands[0])
+		if err != nil || n < 0 {
+			fail("invalid size %s", s.operands[0])
+		}
+		return uint64(n)
+	case ".align":
+		if len(s.operands) != 1 {
+			fail(".align takes one operand")
+		}
+		p, err := a.constant(s.operands[0])
+		if err != nil || p < 0 || p > 16 {
+			fail("invalid alignment %s", s.operands[0])
+		}
+		align := uint64(1) << p
+		return (align - s.addr%align) % align
+	}
+	if strings.HasPrefix(s.mnemonic, ".") {
+		fail("unknown directive %s", s.mnemonic)
+	}
+	if _, ok := lookupEncoder(s.mnemonic); !ok {
+		fail("unknown instruction %s", s.mnemonic)
+	}
+	return 4
+}
+
+// stringData returns the bytes of a .ascii or .asciz statement.
+func stringData(s *statement) []byte {
+	var data []byte
+	for _, op := range s.operands {
+		str, err := strconv.Unquote(op)
+		if err != nil || !strings.HasPrefix(op, `"`) {
+			fail("invalid string %s", op)
+		}
+		data = append(data, str...)
+		if s.mnemonic == ".asciz" {
+			data = append(data, 0)
+		}
+	}
+	return data
+}
+
+// nop is the encoding of NOP, used to pad code.
+const nop = 0xD503201F
+
+// emit encodes every statement.
+func (a *assembler) emit() ([]byte, error) {
+	var out []byte
+	for i, s := range a.stmts {
+		err := catch(s, func() {
+			out = a.encode(out, s, i)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// encode appends the bytes of statement s, the index-th statement, to out.
+func (a *assembler) encode(out []byte, s *statement, index int) []byte {
+	value := func(op string) uint64 {
+		v, err := a.eval(op, index, s.addr)
+		if err != nil {
+			fail("%v", err)
+		}
+		return uint64(v)
+	}
+
+	switch s.mnemonic {
+	case ".word", ".inst":
+		for _, op := range s.operands {
+			out = binary.LittleEndian.AppendUint32(out, uint32(value(op)))
+		}
+	case ".quad":
+		for _, op := range s.operands {
+			out = binary.LittleEndian.AppendUint64(out, value(op))
+		}
+	case ".hword":
+		for _, op := range s.operands {
+			out = binary.LittleEndian.AppendUint16(out, uint16(value(op)))
+		}
+	case ".byte":
+		for _, op := range s.operands {
+			out = append(out, byte(value(op)))
+		}
+	case ".ascii", ".asciz":
+		out = append(out, stringData(s)...)
+	case ".space":
+		out = append(out, make([]byte, s.size)...)
+	case ".align":
+		if s.addr%4 == 0 && s.size%4 == 0 {
+			for n := uint64(0); n < s.size; n += 4 {
+				out = binary.LittleEndian.AppendUint32(out, nop)
+			}
+		} else {
+			out = append(out, make([]byte, s.size)...)
+		}
+	default:
+		enc, _ := lookupEncoder(s.mnemonic)
+		e := &encoder{a: a, s: s, index: index, ops: s.operands}
+		out = binary.LittleEndian.AppendUint32(out, enc(e))
+	}
+	return out
+}
+
+// constant evaluates an expression that may not refer to labels or ".".
+func (a *assembler) constant(expr string) (int64, error) {
+	return (&assembler{}).eval(strings.TrimPrefix(expr, "#"), 0, 0)
+}
+
+var localRefPattern = regexp.MustCompile(`^([0-9]+)([bf])$`)
+
+// eval evaluates an expression in the index-th statement, located at pc.
+func (a *assembler) eval(expr string, index int, pc uint64) (int64, error) {
+	rest := strings.TrimSpace(expr)
+	if rest == "" {
+		return 0, fmt.Errorf("missing expression")
+	}
+	var total int64
+	for rest != "" {
+		sign := int64(1)
+		for rest != "" && (rest[0] == '+' || rest[0] == '-') {
+			if rest[0] == '-' {
+				sign = -sign
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+		end := strings.IndexAny(rest, "+-")
+		if end < 0 {
+			end = len(rest)
+		}
+		term := strings.TrimSpace(rest[:end])
+		if term == "" {
+			return 0, fmt.Errorf("invalid expression %s", expr)
+		}
+		v, err := a.term(term, index, pc)
+		if err != nil {
+			return 0, err
+		}
+		total += sign * v
+		rest = rest[end:]
+	}
+	return total, nil
+}
+
+// term evaluates a number, a label or ".".
+func (a *assembler) term(term string, index int, pc uint64) (int64, error) {
+	if term == "." {
+		return int64(pc), nil
+	}
+	if term[0] >= '0' && term[0] <= '9' {
+		if m := localRefPattern.FindStringSubmatch(term); m != nil {
+			return a.local(m[1], m[2] == "b", index)
+		}
+		v, err := strconv.ParseUint(strings.ReplaceAll(term, "_", ""), 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %s", term)
+		}
+		return int64(v), nil
+	}
+	if addr, ok := a.labels[term]; ok {
+		return int64(addr), nil
+	}
+	return 0, fmt.Errorf("undefined label %s", term)
+}
+
+// local resolves a reference to numeric label name from the index-th
+// statement, searching backward or forward.
+func (a *assembler) local(name string, backward bool, index int) (int64, error) {
+	defs := a.locals[name]
+	if backward {
+		for i := len(defs) - 1; i >= 0; i-- {
+			if defs[i].index <= index {
+				return int64(defs[i].addr), nil
+			}
+		}
+	} else {
+		for _, def := range defs {
+			if def.index > index {
+				return int64(def.addr), nil
+			}
+		}
+	}
+	dir := "f"
+	if backward {
+		dir = "b"
+	}
+	return 0, fmt.Errorf("undefined label %s%s", name, dir)
+}
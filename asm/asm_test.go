@@ -0,0 +1,208 @@
+package asm_test
+
+import (
+	"encoding/binary"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/asm"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// words splits code into little-endian instruction words.
+func words(code []byte) []uint32 {
+	out := make([]uint32, 0, len(code)/4)
+	for i := 0; i+4 <= len(code); i += 4 {
+		out = append(out, binary.LittleEndian.Uint32(code[i:]))
+	}
+	return out
+}
+
+var _ = Describe("Assembler", func() {
+	decoder := insts.NewDecoder()
+
+	It("should resolve backward and forward label references", func() {
+		code, err := asm.Assemble(`
+			mov x0, #3
+		loop:
+			subs x0, x0, #1
+			b.ne loop
+			cbz x0, done
+			nop
+		done:
+			ret
+		`)
+
+		Expect(err).NotTo(HaveOccurred())
+		w := words(code)
+		Expect(w).To(HaveLen(6))
+		Expect(decoder.Decode(w[2]).BranchOffset).To(Equal(int64(-4)))
+		Expect(decoder.Decode(w[3]).BranchOffset).To(Equal(int64(8)))
+		Expect(w[5]).To(Equal(uint32(0xd65f03c0)))
+	})
+
+	It("should resolve numeric local labels to the nearest definition", func() {
+		code, err := asm.Assemble(`
+		1:	b 1f
+			b 1b
+		1:	b 1b
+			b 1f
+		1:
+			nop
+		`)
+
+		Expect(err).NotTo(HaveOccurred())
+		var offsets []int64
+		for _, w := range words(code)[:4] {
+			offsets = append(offsets, decoder.Decode(w).BranchOffset)
+		}
+		Expect(offsets).To(Equal([]int64{8, -4, 0, 4}))
+	})
+
+	It("should emit data directives", func() {
+		code, err := asm.Assemble(`
+			.word 0x12345678, -1
+			.quad table
+			.hword 0xBEEF
+			.byte 1, 2
+		table:
+			.ascii "ab"
+			.asciz "c\n"
+		`)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(code).To(Equal([]byte{
+			0x78, 0x56, 0x34, 0x12, 0xff, 0xff, 0xff, 0xff,
+			20, 0, 0, 0, 0, 0, 0, 0,
+			0xef, 0xbe, 1, 2,
+			'a', 'b', 'c', '\n', 0,
+		}))
+	})
+
+	It("should pad code with NOPs and data with zeros", func() {
+		code, err := asm.Assemble(`
+			ret
+			.align 3
+			.byte 1
+			.align 2
+			.space 4
+			.inst 0xd503201f
+		`)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(words(code)).To(Equal([]uint32{0xd65f03c0, 0xd503201f, 0x00000001, 0x00000000, 0xd503201f}))
+	})
+
+	It("should evaluate expressions with labels and the current address", func() {
+		code, err := asm.AssembleAt(`
+		start:
+			adr x0, end - 4
+			b . + 8
+			.quad end - start
+		end:
+		`, 0x1000)
+
+		Expect(err).NotTo(HaveOccurred())
+		w := words(code)
+		Expect(decoder.Decode(w[0]).BranchOffset).To(Equal(int64(12)))
+		Expect(decoder.Decode(w[1]).BranchOffset).To(Equal(int64(8)))
+		Expect(binary.LittleEndian.Uint64(code[8:])).To(Equal(uint64(16)))
+	})
+
+	It("should use the base address for ADRP and absolute targets", func() {
+		code, err := asm.AssembleAt("adrp x0, 0x3000; b 0x2ffc", 0x2ffc)
+
+		Expect(err).NotTo(HaveOccurred())
+		w := words(code)
+		Expect(decoder.Decode(w[0]).BranchOffset).To(Equal(int64(0x1000)))
+		Expect(decoder.Decode(w[1]).BranchOffset).To(Equal(int64(-4)))
+	})
+
+	It("should choose the encoding of MOV immediates", func() {
+		for text, word := range map[string]uint32{
+			"mov x0, #0x10000":            0xd2a00020,
+			"mov x0, #-2":                 0x92800020,
+			"mov w0, #0xffff0000":         0x52bfffe0,
+			"mov w0, #-1":                 0x12800000,
+			"mov x0, #0x5555555555555555": 0xb200f3e0,
+			"mov sp, #0xff":               0xb2401fff,
+		} {
+			code, err := asm.Assemble(text)
+			Expect(err).NotTo(HaveOccurred(), text)
+			Expect(words(code)).To(Equal([]uint32{word}), text)
+		}
+	})
+
+	It("should accept REV64 as a name for the 64-bit REV", func() {
+		code, err := asm.Assemble("rev64 x0, x1; rev x0, x1")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(words(code)).To(Equal([]uint32{0xdac00c20, 0xdac00c20}))
+	})
+
+	It("should accept comments, tabs and register aliases", func() {
+		code, err := asm.Assemble("\tstp\tfp, lr, [sp, #-16]! // save\n\tLDR X0, [SP]")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(words(code)).To(Equal([]uint32{0xa9bf7bfd, 0xf94003e0}))
+	})
+
+	It("should use the unscaled form for offsets that cannot be scaled", func() {
+		code, err := asm.Assemble("ldr x0, [x1, #-8]; str w2, [x3, #3]; ldur x4, [x5, #8]")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(words(code)).To(Equal([]uint32{0xf85f8020, 0xb8003062, 0xf84080a4}))
+	})
+
+	DescribeTable("should report errors with the source line",
+		func(src string, line int, msg string) {
+			_, err := asm.Assemble(src)
+
+			var asmErr *asm.Error
+			Expect(err).To(BeAssignableToTypeOf(asmErr))
+			asmErr = err.(*asm.Error)
+			Expect(asmErr.Line).To(Equal(line))
+			Expect(asmErr.Msg).To(Equal(msg))
+		},
+		Entry("unknown instruction", "nop\nfrob x0", 2, "unknown instruction frob"),
+		Entry("unknown directive", ".text", 1, "unknown directive .text"),
+		Entry("undefined label", "nop\n\nb missing", 3, "undefined label missing"),
+		Entry("undefined local label", "b 1f\n1:\nb 2b", 3, "undefined label 2b"),
+		Entry("redefined label", "a: nop\na: nop", 2, "label a redefined"),
+		Entry("operand count", "add x0, x1", 1, "expected 3 to 4 operands, got 2"),
+		Entry("width mismatch", "add x0, w1, x2", 1, "register width mismatch: w1"),
+		Entry("immediate range", "add x0, x1, #0x1001", 1, "immediate #0x1001 out of range"),
+		Entry("bitmask", "and x0, x1, #5", 1, "immediate #5 is not a valid bitmask"),
+		Entry("branch range", "b.eq . + 0x100000", 1, ". + 0x100000 out of range"),
+		Entry("misaligned branch", "b . + 2", 1, "misaligned target . + 2"),
+		Entry("system register", "mrs x0, foo_el0", 1, "unknown system register foo_el0"),
+		Entry("32-bit REV32", "rev32 w0, w1", 1, "register width mismatch: w0"),
+		Entry("CRC32X data width", "crc32x w0, w1, w2", 1, "register width mismatch: w2"),
+		Entry("prefetch operation", "prfm pldl4keep, [x0]", 1, "unknown prefetch operation pldl4keep"),
+		Entry("unprivileged writeback", "ldtr x0, [x1, #8]!", 1, "unprivileged accesses take an immediate offset"),
+		Entry("non-temporal writeback", "ldnp x0, x1, [x2, #16]!", 1, "non-temporal pairs take an offset address"),
+		Entry("32-bit LDPSW", "ldpsw w0, w1, [x2]", 1, "sign-extending word loads need a 64-bit register"),
+		Entry("structure size", "ld2 {v0.4s}, [x0]", 1, "expected 2 registers in {v0.4s}"),
+		Entry("register list gap", "ld1 {v0.4s, v2.4s}, [x0]", 1, "registers in {v0.4s, v2.4s} must be consecutive"),
+		Entry("structure post-index", "ld1 {v0.4s, v1.4s}, [x0], #16", 1, "post-index immediate must be #32"),
+		Entry("lane index", "ld1 {v0.s}[4], [x0]", 1, "lane index 4 out of range"),
+		Entry("vector 2D", "smax v0.2d, v1.2d, v2.2d", 1, "invalid arrangement 2d"),
+		Entry("narrow half", "xtn2 v0.8b, v1.8h", 1, "invalid arrangement 8b"),
+		Entry("shift range", "ushr v0.4s, v1.4s, #33", 1, "shift amount #33 out of range"),
+		Entry("byte mask immediate", "movi v0.2d, #0x1234", 1, "immediate #0x1234 cannot be encoded"),
+		Entry("UMOV width", "umov w0, v1.d[1]", 1, "invalid element size in v1.d[1]"),
+		Entry("element index", "dup v0.4s, v1.s[4]", 1, "lane index out of range in v1.s[4]"),
+	)
+
+	It("should format errors with the statement", func() {
+		_, err := asm.Assemble("frob x0")
+
+		Expect(err).To(MatchError(`line 1: unknown instruction frob: "frob x0"`))
+	})
+
+	It("should panic in MustAssemble on errors", func() {
+		Expect(asm.MustAssemble("ret")).To(Equal([]byte{0xc0, 0x03, 0x5f, 0xd6}))
+		Expect(func() { asm.MustAssemble("frob") }).To(Panic())
+	})
+})
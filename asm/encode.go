@@ -0,0 +1,923 @@
+package asm
+
+import (
+	"math/bits"
+	"strings"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// encodeFunc encodes an instruction statement into its 32-bit word.
+type encodeFunc func(e *encoder) uint32
+
+// encoders maps mnemonics to their encoders. Mnemonics with size and
+// ordering suffixes (the exclusives and atomics) are added by init.
+var encoders = map[string]encodeFunc{
+	"add":  addSub(0, 0),
+	"adds": addSub(0, 1),
+	"sub":  addSub(1, 0),
+	"subs": addSub(1, 1),
+	"cmp":  alias(addSub(1, 1), zeroDest),
+	"cmn":  alias(addSub(0, 1), zeroDest),
+	"neg":  alias(addSub(1, 0), zeroSource),
+	"negs": alias(addSub(1, 1), zeroSource),
+
+	"and":  logical(0, 0),
+	"bic":  logical(0, 1),
+	"orr":  logical(1, 0),
+	"orn":  logical(1, 1),
+	"eor":  logical(2, 0),
+	"eon":  logical(2, 1),
+	"ands": logical(3, 0),
+	"bics": logical(3, 1),
+	"tst":  alias(logical(3, 0), zeroDest),
+	"mvn":  alias(logical(1, 1), zeroSource),
+	"mov":  encodeMov,
+
+	"movn": moveWide(0),
+	"movz": moveWide(2),
+	"movk": moveWide(3),
+
+	"sbfm":  bitfield(0, bitfieldRaw),
+	"bfm":   bitfield(1, bitfieldRaw),
+	"ubfm":  bitfield(2, bitfieldRaw),
+	"sbfiz": bitfield(0, bitfieldInsert),
+	"bfi":   bitfield(1, bitfieldInsert),
+	"ubfiz": bitfield(2, bitfieldInsert),
+	"sbfx":  bitfield(0, bitfieldExtract),
+	"bfxil": bitfield(1, bitfieldExtract),
+	"ubfx":  bitfield(2, bitfieldExtract),
+	"bfc":   encodeBFC,
+	"sxtb":  extend(0, 7),
+	"sxth":  extend(0, 15),
+	"sxtw":  extend(0, 31),
+	"uxtb":  extend(2, 7),
+	"uxth":  extend(2, 15),
+	"asr":   shift(2, 0b1010),
+	"lsl":   shift(0, 0b1000),
+	"lsr":   shift(1, 0b1001),
+	"ror":   shift(3, 0b1011),
+	"extr":  encodeExtr,
+
+	"rbit":  dataProc1Src(0b000, false),
+	"rev16": dataProc1Src(0b001, false),
+	"rev32": dataProc1Src(0b010, true),
+	"rev":   encodeRev,
+	"rev64": dataProc1Src(0b011, true),
+	"clz":   dataProc1Src(0b100, false),
+	"cls":   dataProc1Src(0b101, false),
+
+	"adc":  addSubCarry(0, 0),
+	"adcs": addSubCarry(0, 1),
+	"sbc":  addSubCarry(1, 0),
+	"sbcs": addSubCarry(1, 1),
+	"ngc":  alias(addSubCarry(1, 0), zeroSource),
+	"ngcs": alias(addSubCarry(1, 1), zeroSource),
+
+	"udiv": dataProc2Src(0b0010),
+	"sdiv": dataProc2Src(0b0011),
+	"lslv": dataProc2Src(0b1000),
+	"lsrv": dataProc2Src(0b1001),
+	"asrv": dataProc2Src(0b1010),
+	"rorv": dataProc2Src(0b1011),
+	"madd": dataProc3Src(0),
+	"msub": dataProc3Src(1),
+	"mul":  encodeMul(0),
+	"mneg": encodeMul(1),
+
+	"smaddl": longMul(0b001, 0),
+	"smsubl": longMul(0b001, 1),
+	"umaddl": longMul(0b101, 0),
+	"umsubl": longMul(0b101, 1),
+	"smull":  alias(longMul(0b001, 0), appendZero),
+	"smnegl": alias(longMul(0b001, 1), appendZero),
+	"umull":  alias(longMul(0b101, 0), appendZero),
+	"umnegl": alias(longMul(0b101, 1), appendZero),
+	"smulh":  mulHigh(0b010),
+	"umulh":  mulHigh(0b110),
+
+	"csel":  condSelect(0, 0),
+	"csinc": condSelect(0, 1),
+	"csinv": condSelect(1, 0),
+	"csneg": condSelect(1, 1),
+	"cset":  condSet(0, 1),
+	"csetm": condSet(1, 0),
+	"cinc":  condIncrement(0, 1),
+	"cinv":  condIncrement(1, 0),
+	"cneg":  condIncrement(1, 1),
+	"ccmn":  condCompare(0),
+	"ccmp":  condCompare(1),
+
+	"adr":  pcRel(0),
+	"adrp": pcRel(1),
+
+	"b":    encodeB(0x14000000),
+	"bl":   encodeB(0x94000000),
+	"br":   branchReg(0xD61F0000),
+	"blr":  branchReg(0xD63F0000),
+	"ret":  branchReg(0xD65F0000),
+	"cbz":  compareBranch(0x34000000),
+	"cbnz": compareBranch(0x35000000),
+	"tbz":  testBranch(0x36000000),
+	"tbnz": testBranch(0x37000000),
+
+	"svc": exception(0xD4000001),
+	"brk": exception(0xD4200000),
+
+	"mrs":   encodeMRS,
+	"msr":   encodeMSR,
+	"hint":  encodeHint,
+	"nop":   hint(0),
+	"bti":   encodeBTI,
+	"dmb":   barrier(0xD50330BF, true),
+	"dsb":   barrier(0xD503309F, true),
+	"isb":   barrier(0xD50330DF, false),
+	"clrex": barrier(0xD503305F, false),
+	"dc":    cacheMaint(dcOperations),
+	"ic":    cacheMaint(icOperations),
+}
+
+// hintNumbers are the allocated hints that take no operands, by name.
+var hintNumbers = map[string]uint32{
+	"yield": 1, "wfe": 2, "wfi": 3, "sev": 4, "sevl": 5,
+	"xpaclri": 7, "pacia1716": 8, "pacib1716": 10, "autia1716": 12, "autib1716": 14,
+	"esb": 16, "csdb": 20,
+	"paciaz": 24, "paciasp": 25, "pacibz": 26, "pacibsp": 27,
+	"autiaz": 28, "autiasp": 29, "autibz": 30, "autibsp": 31,
+}
+
+func init() {
+	for name, n := range hintNumbers {
+		encoders[name] = hint(n)
+	}
+	for i, sz := range []string{"b", "h", "w", "x"} {
+		encoders["crc32"+sz] = crc32(0, uint32(i))
+		encoders["crc32c"+sz] = crc32(1, uint32(i))
+	}
+	addMemoryEncoders()
+	addFPEncoders()
+	addSIMDEncoders()
+	addSIMDFPEncoders()
+	addCryptoEncoders()
+	addPointerAuthEncoders()
+}
+
+// lookupEncoder returns the encoder for a mnemonic.
+func lookupEncoder(mnemonic string) (encodeFunc, bool) {
+	if enc, ok := encoders[mnemonic]; ok {
+		return enc, true
+	}
+	if c, ok := strings.CutPrefix(mnemonic, "b."); ok {
+		if cond, ok := condCodes[c]; ok {
+			return branchCond(cond), true
+		}
+	}
+	return nil, false
+}
+
+// alias encodes an alias by rewriting its operands into those of the
+// underlying instruction.
+func alias(enc encodeFunc, rewrite func(ops []string) []string) encodeFunc {
+	return func(e *encoder) uint32 {
+		if len(e.ops) == 0 {
+			fail("missing operands")
+		}
+		return enc(&encoder{a: e.a, s: e.s, index: e.index, ops: rewrite(e.ops)})
+	}
+}
+
+// zeroReg names the zero register of the same width as the register in op.
+func zeroReg(op string) string {
+	if r, ok := parseRegister(op); ok && r.kind == kindW {
+		return "wzr"
+	}
+	return "xzr"
+}
+
+// zeroDest inserts the zero register as the destination (CMP, CMN, TST).
+func zeroDest(ops []string) []string {
+	return append([]string{zeroReg(ops[0])}, ops...)
+}
+
+// zeroSource inserts the zero register as the first source (NEG, MVN).
+func zeroSource(ops []string) []string {
+	return append([]string{ops[0], zeroReg(ops[0])}, ops[1:]...)
+}
+
+// appendZero appends the 64-bit zero register as the accumulator (SMULL,
+// UMULL, SMNEGL, UMNEGL).
+func appendZero(ops []string) []string {
+	return append(ops, "xzr")
+}
+
+// sf returns the sf bit for a register width.
+func sf(is64 bool) uint32 {
+	if is64 {
+		return 1
+	}
+	return 0
+}
+
+// width returns the register width in bits.
+func width(is64 bool) int64 {
+	if is64 {
+		return 64
+	}
+	return 32
+}
+
+// gpAny parses operand i as a general-purpose register, the zero register or
+// the stack pointer.
+func (e *encoder) gpAny(i int) register {
+	r := e.reg(i)
+	if r.kind != kindX && r.kind != kindW {
+		fail("expected a general-purpose register, got %s", e.ops[i])
+	}
+	return r
+}
+
+// addSub encodes ADD, ADDS, SUB and SUBS with an immediate, shifted register
+// or extended register operand, and the vector and scalar ADD and SUB.
+func addSub(op, s uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 4)
+		if k := e.reg(0).kind; (k == kindV || k == kindD) && s == 0 {
+			return vectorInt(op, 0b10000)(e)
+		}
+		rd, rn := e.gpAny(0), e.gpAny(1)
+		is64 := rd.is64()
+		if rn.is64() != is64 {
+			fail("register width mismatch: %s", e.ops[1])
+		}
+		if rd.sp && s == 1 {
+			fail("%s is not allowed here", e.ops[0])
+		}
+		base := sf(is64)<<31 | op<<30 | s<<29 | rn.num<<5 | rd.num
+
+		if !e.isReg(2) {
+			if (rd.num == 31 && !rd.sp && s == 0) || (rn.num == 31 && !rn.sp) {
+				fail("the zero register is not allowed here")
+			}
+			imm := e.imm(2)
+			var sh uint32
+			if len(e.ops) == 4 {
+				name, amount, _ := e.modifier(3)
+				if name != "lsl" || (amount != 0 && amount != 12) {
+					fail("invalid immediate shift %s", e.ops[3])
+				}
+				if amount == 12 {
+					sh = 1
+				}
+			} else {
+				if imm < 0 && imm > -0x1000000 {
+					imm, base = -imm, base^1<<30
+				}
+				if imm > 0xFFF && imm&0xFFF == 0 {
+					imm, sh = imm>>12, 1
+				}
+			}
+			if imm < 0 || imm > 0xFFF {
+				fail("immediate %s out of range", e.ops[2])
+			}
+			return 0x11000000 | base | sh<<22 | uint32(imm)<<10
+		}
+
+		rm := e.gpReg(2, false)
+		name, amount := "", int64(0)
+		if len(e.ops) == 4 {
+			name, amount, _ = e.modifier(3)
+		}
+		option, extended := extendTypes[name]
+		if extended || rd.sp || rn.sp {
+			if !extended {
+				if name != "" && name != "lsl" {
+					fail("invalid shift %s", e.ops[3])
+				}
+				option = 2 | sf(is64)
+			}
+			if (rd.num == 31 && !rd.sp && s == 0) || (rn.num == 31 && !rn.sp) {
+				fail("the zero register is not allowed here")
+			}
+			if rm.is64() != (is64 && option&3 == 3) {
+				fail("register width mismatch: %s", e.ops[2])
+			}
+			if amount < 0 || amount > 4 {
+				fail("extend amount %s out of range", e.ops[3])
+			}
+			return 0x0B200000 | base | rm.num<<16 | option<<13 | uint32(amount)<<10
+		}
+
+		if rm.is64() != is64 {
+			fail("register width mismatch: %s", e.ops[2])
+		}
+		shiftType := shiftTypes[name]
+		if shiftType == 3 || amount < 0 || amount >= width(is64) {
+			fail("invalid shift %s", e.ops[3])
+		}
+		return 0x0B000000 | base | shiftType<<22 | rm.num<<16 | uint32(amount)<<10
+	}
+}
+
+// shiftedOperand parses the optional shift in operand i of a logical
+// instruction.
+func (e *encoder) shiftedOperand(i int, is64 bool) (uint32, uint32) {
+	if i >= len(e.ops) {
+		return 0, 0
+	}
+	name, amount, _ := e.modifier(i)
+	shiftType, ok := shiftTypes[name]
+	if !ok || amount < 0 || amount >= width(is64) {
+		fail("invalid shift %s", e.ops[i])
+	}
+	return shiftType, uint32(amount)
+}
+
+// logical encodes AND, ORR, EOR and ANDS (opc 0-3) with a shifted register
+// or bitmask immediate operand, and the inverting BIC, ORN, EON and BICS.
+func logical(opc, invert uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 4)
+		if !e.isReg(2) {
+			e.want(3, 3)
+			var rd uint32
+			var is64 bool
+			if opc == 3 {
+				rd, is64 = e.gp(0)
+			} else {
+				rd, is64 = e.gpSP(0)
+			}
+			rn := e.sameWidth(1, is64)
+			imm := e.logicalImm(2, is64)
+			if invert == 1 {
+				imm = ^imm
+			}
+			n, immr, imms, ok := bitmask(imm, is64)
+			if !ok {
+				fail("immediate %s is not a valid bitmask", e.ops[2])
+			}
+			return 0x12000000 | sf(is64)<<31 | opc<<29 | n<<22 | immr<<16 | imms<<10 | rn<<5 | rd
+		}
+
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		rm := e.sameWidth(2, is64)
+		shiftType, amount := e.shiftedOperand(3, is64)
+		return 0x0A000000 | sf(is64)<<31 | opc<<29 | shiftType<<22 | invert<<21 |
+			rm<<16 | amount<<10 | rn<<5 | rd
+	}
+}
+
+// logicalImm evaluates operand i as an immediate of the register width,
+// accepting negative values.
+func (e *encoder) logicalImm(i int, is64 bool) uint64 {
+	v := uint64(e.imm(i))
+	if !is64 {
+		if high := v >> 32; high != 0 && high != 0xFFFFFFFF {
+			fail("immediate %s out of range", e.ops[i])
+		}
+		v &= 0xFFFFFFFF
+	}
+	return v
+}
+
+// bitmask encodes v as a logical immediate: a rotated run of ones
+// replicated across the register.
+func bitmask(v uint64, is64 bool) (n, immr, imms uint32, ok bool) {
+	if !is64 {
+		v = v&0xFFFFFFFF | v<<32
+	}
+	if v == 0 || v == ^uint64(0) {
+		return 0, 0, 0, false
+	}
+
+	size := uint(64)
+	for size > 2 {
+		half := size / 2
+		mask := uint64(1)<<half - 1
+		if v&mask != (v>>half)&mask {
+			break
+		}
+		size = half
+	}
+
+	mask := ^uint64(0) >> (64 - size)
+	elem := v & mask
+	ones := bits.OnesCount64(elem)
+	pattern := uint64(1)<<ones - 1
+	for r := uint(0); r < size; r++ {
+		if (pattern>>r|pattern<<(size-r))&mask == elem {
+			if size == 64 {
+				n = 1
+			}
+			imms = ^(uint32(size)*2-1)&0x3F | uint32(ones-1)
+			return n, uint32(r), imms, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// moveWide encodes MOVN, MOVZ and MOVK (opc 0, 2, 3).
+func moveWide(opc uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 3)
+		rd, is64 := e.gp(0)
+		imm := e.uimm(1, 16)
+		var hw uint32
+		if len(e.ops) == 3 {
+			name, amount, _ := e.modifier(2)
+			if name != "lsl" || amount%16 != 0 || amount < 0 || amount >= width(is64) {
+				fail("invalid shift %s", e.ops[2])
+			}
+			hw = uint32(amount / 16)
+		}
+		return 0x12800000 | sf(is64)<<31 | opc<<29 | hw<<21 | imm<<5 | rd
+	}
+}
+
+// encodeMov encodes MOV between registers (ADD or ORR) and of an immediate
+// (MOVZ, MOVN or ORR), picking the first encoding that fits.
+func encodeMov(e *encoder) uint32 {
+	e.want(2, 2)
+	rd := e.gpAny(0)
+	is64 := rd.is64()
+	if e.isReg(1) {
+		rn := e.gpAny(1)
+		if rn.is64() != is64 {
+			fail("register width mismatch: %s", e.ops[1])
+		}
+		if rd.sp || rn.sp {
+			return 0x11000000 | sf(is64)<<31 | rn.num<<5 | rd.num
+		}
+		return 0x2A0003E0 | sf(is64)<<31 | rn.num<<16 | rd.num
+	}
+
+	v := e.logicalImm(1, is64)
+	if !rd.sp {
+		inverted := ^v
+		if !is64 {
+			inverted &= 0xFFFFFFFF
+		}
+		for hw := uint32(0); hw < uint32(width(is64))/16; hw++ {
+			if v&^(0xFFFF<<(16*hw)) == 0 {
+				return 0x52800000 | sf(is64)<<31 | hw<<21 | uint32(v>>(16*hw))<<5 | rd.num
+			}
+		}
+		for hw := uint32(0); hw < uint32(width(is64))/16; hw++ {
+			if inverted&^(0xFFFF<<(16*hw)) == 0 {
+				return 0x12800000 | sf(is64)<<31 | hw<<21 | uint32(inverted>>(16*hw))<<5 | rd.num
+			}
+		}
+	}
+	if n, immr, imms, ok := bitmask(v, is64); ok {
+		return 0x320003E0 | sf(is64)<<31 | n<<22 | immr<<16 | imms<<10 | rd.num
+	}
+	fail("immediate %s cannot be moved in one instruction", e.ops[1])
+	return 0
+}
+
+// Bitfield operand forms.
+const (
+	bitfieldRaw     = iota // Rd, Rn, #immr, #imms
+	bitfieldInsert         // Rd, Rn, #lsb, #width (SBFIZ, BFI, UBFIZ)
+	bitfieldExtract        // Rd, Rn, #lsb, #width (SBFX, BFXIL, UBFX)
+)
+
+// bitfieldWord encodes SBFM, BFM and UBFM (opc 0-2).
+func bitfieldWord(opc uint32, is64 bool, rd, rn, immr, imms uint32) uint32 {
+	return 0x13000000 | sf(is64)<<31 | opc<<29 | sf(is64)<<22 | immr<<16 | imms<<10 | rn<<5 | rd
+}
+
+// lsbWidth checks a bitfield lsb and width for a register of size bits.
+func (e *encoder) lsbWidth(i int, size int64) (int64, int64) {
+	lsb, w := e.imm(i), e.imm(i+1)
+	if lsb < 0 || lsb >= size || w < 1 || w > size-lsb {
+		fail("invalid bitfield #%d, #%d", lsb, w)
+	}
+	return lsb, w
+}
+
+// bitfield encodes SBFM, BFM and UBFM and their insert and extract aliases.
+func bitfield(opc uint32, form int) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		size := width(is64)
+		var immr, imms int64
+		switch form {
+		case bitfieldRaw:
+			immr, imms = e.imm(2), e.imm(3)
+			if immr < 0 || immr >= size || imms < 0 || imms >= size {
+				fail("bitfield immediate out of range")
+			}
+		case bitfieldInsert:
+			lsb, w := e.lsbWidth(2, size)
+			immr, imms = (size-lsb)%size, w-1
+		default:
+			lsb, w := e.lsbWidth(2, size)
+			immr, imms = lsb, lsb+w-1
+		}
+		return bitfieldWord(opc, is64, rd, rn, uint32(immr), uint32(imms))
+	}
+}
+
+// encodeBFC encodes BFC, a BFI from the zero register.
+func encodeBFC(e *encoder) uint32 {
+	e.want(3, 3)
+	rd, is64 := e.gp(0)
+	size := width(is64)
+	lsb, w := e.lsbWidth(1, size)
+	return bitfieldWord(1, is64, rd, 31, uint32((size-lsb)%size), uint32(w-1))
+}
+
+// extend encodes the SXTB, SXTH, SXTW, UXTB and UXTH aliases, which read a
+// 32-bit source register.
+func extend(opc, imms uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, false)
+		if (imms == 31 || opc == 2) && is64 != (imms == 31) {
+			fail("register width mismatch: %s", e.ops[0])
+		}
+		return bitfieldWord(opc, is64, rd, rn, 0, imms)
+	}
+}
+
+// shift encodes ASR, LSL, LSR and ROR with an immediate (as a bitfield move
+// or EXTR) or register (as a variable shift) amount.
+func shift(shiftType, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if e.isReg(2) {
+			return dataProc2Src(opcode)(e)
+		}
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		size := width(is64)
+		sh := e.imm(2)
+		if sh < 0 || sh >= size {
+			fail("shift amount %s out of range", e.ops[2])
+		}
+		switch shiftType {
+		case 0:
+			return bitfieldWord(2, is64, rd, rn, uint32((size-sh)%size), uint32(size-1-sh))
+		case 1:
+			return bitfieldWord(2, is64, rd, rn, uint32(sh), uint32(size-1))
+		case 2:
+			return bitfieldWord(0, is64, rd, rn, uint32(sh), uint32(size-1))
+		default:
+			return extrWord(is64, rd, rn, rn, uint32(sh))
+		}
+	}
+}
+
+// extrWord encodes EXTR.
+func extrWord(is64 bool, rd, rn, rm, lsb uint32) uint32 {
+	return 0x13800000 | sf(is64)<<31 | sf(is64)<<22 | rm<<16 | lsb<<10 | rn<<5 | rd
+}
+
+// encodeExtr encodes EXTR.
+func encodeExtr(e *encoder) uint32 {
+	e.want(4, 4)
+	rd, is64 := e.gp(0)
+	rn := e.sameWidth(1, is64)
+	rm := e.sameWidth(2, is64)
+	lsb := e.imm(3)
+	if lsb < 0 || lsb >= width(is64) {
+		fail("lsb %s out of range", e.ops[3])
+	}
+	return extrWord(is64, rd, rn, rm, uint32(lsb))
+}
+
+// dataProc1Src encodes RBIT, REV16, REV32, REV64, CLZ and CLS. REV32 and
+// REV64 exist only for 64-bit registers.
+func dataProc1Src(opcode uint32, only64 bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd, is64 := e.gp(0)
+		if only64 && !is64 {
+			fail("register width mismatch: %s", e.ops[0])
+		}
+		rn := e.sameWidth(1, is64)
+		return 0x5AC00000 | sf(is64)<<31 | opcode<<10 | rn<<5 | rd
+	}
+}
+
+// encodeRev encodes REV, whose opcode depends on the register width.
+func encodeRev(e *encoder) uint32 {
+	e.want(2, 2)
+	if _, is64 := e.gp(0); is64 {
+		return dataProc1Src(0b011, true)(e)
+	}
+	return dataProc1Src(0b010, false)(e)
+}
+
+// addSubCarry encodes ADC, ADCS, SBC and SBCS.
+func addSubCarry(op, s uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		rm := e.sameWidth(2, is64)
+		return 0x1A000000 | sf(is64)<<31 | op<<30 | s<<29 | rm<<16 | rn<<5 | rd
+	}
+}
+
+// crc32 encodes CRC32{B,H,W,X} and CRC32C{B,H,W,X}. The data register is
+// 64-bit for the X forms.
+func crc32(c, sz uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd := e.sameWidth(0, false)
+		rn := e.sameWidth(1, false)
+		rm := e.sameWidth(2, sz == 3)
+		return 0x1AC04000 | sf(sz == 3)<<31 | rm<<16 | c<<12 | sz<<10 | rn<<5 | rd
+	}
+}
+
+// dataProc2Src encodes the divides and variable shifts.
+func dataProc2Src(opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		rm := e.sameWidth(2, is64)
+		return 0x1AC00000 | sf(is64)<<31 | rm<<16 | opcode<<10 | rn<<5 | rd
+	}
+}
+
+// dataProc3Src encodes MADD and MSUB.
+func dataProc3Src(o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		rm := e.sameWidth(2, is64)
+		ra := e.sameWidth(3, is64)
+		return 0x1B000000 | sf(is64)<<31 | rm<<16 | o0<<15 | ra<<10 | rn<<5 | rd
+	}
+}
+
+// longMul encodes SMADDL, SMSUBL, UMADDL and UMSUBL, which multiply two
+// 32-bit registers into a 64-bit accumulator.
+func longMul(op31, o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rd := e.x64(0)
+		rn := e.sameWidth(1, false)
+		rm := e.sameWidth(2, false)
+		ra := e.x64(3)
+		return 0x9B000000 | op31<<21 | rm<<16 | o0<<15 | ra<<10 | rn<<5 | rd
+	}
+}
+
+// mulHigh encodes SMULH and UMULH.
+func mulHigh(op31 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd := e.x64(0)
+		rn := e.x64(1)
+		rm := e.x64(2)
+		return 0x9B000000 | op31<<21 | rm<<16 | 31<<10 | rn<<5 | rd
+	}
+}
+
+// encodeMul encodes MUL and MNEG, which accumulate into the zero register,
+// and the vector MUL (also by element).
+func encodeMul(o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if e.reg(0).kind == kindV && o0 == 0 {
+			return elementOr(vectorInt(0, 0b10011), intByElement(0, 0b1000, false))(e)
+		}
+		return alias(dataProc3Src(o0), func(ops []string) []string {
+			return append(ops, zeroReg(ops[0]))
+		})(e)
+	}
+}
+
+// condSelect encodes CSEL, CSINC, CSINV and CSNEG.
+func condSelect(op, o2 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		rm := e.sameWidth(2, is64)
+		return condSelectWord(op, o2, is64, rd, rn, rm, e.cond(3))
+	}
+}
+
+// condSelectWord encodes a conditional select.
+func condSelectWord(op, o2 uint32, is64 bool, rd, rn, rm, cond uint32) uint32 {
+	return 0x1A800000 | sf(is64)<<31 | op<<30 | rm<<16 | cond<<12 | o2<<10 | rn<<5 | rd
+}
+
+// invertibleCond parses operand i as a condition other than AL and NV and
+// returns its inverse.
+func (e *encoder) invertibleCond(i int) uint32 {
+	c := e.cond(i)
+	if c >= 14 {
+		fail("condition %s cannot be inverted", e.ops[i])
+	}
+	return c ^ 1
+}
+
+// condSet encodes CSET and CSETM.
+func condSet(op, o2 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd, is64 := e.gp(0)
+		return condSelectWord(op, o2, is64, rd, 31, 31, e.invertibleCond(1))
+	}
+}
+
+// condIncrement encodes CINC, CINV and CNEG.
+func condIncrement(op, o2 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd, is64 := e.gp(0)
+		rn := e.sameWidth(1, is64)
+		return condSelectWord(op, o2, is64, rd, rn, rn, e.invertibleCond(2))
+	}
+}
+
+// condCompare encodes CCMN and CCMP with a register or immediate operand.
+func condCompare(op uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rn, is64 := e.gp(0)
+		word := 0x3A400000 | sf(is64)<<31 | op<<30 | e.uimm(2, 4) | e.cond(3)<<12 | rn<<5
+		if e.isReg(1) {
+			return word | e.sameWidth(1, is64)<<16
+		}
+		return word | 1<<11 | e.uimm(1, 5)<<16
+	}
+}
+
+// pcRel encodes ADR and ADRP.
+func pcRel(op uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd := e.x64(0)
+		var imm uint32
+		if op == 0 {
+			imm = e.offset(1, 21, 1)
+		} else {
+			imm = signedField(e.target(1)>>12-int64(e.pc()>>12), 21, e.ops[1])
+		}
+		return 0x10000000 | op<<31 | (imm&3)<<29 | (imm>>2)<<5 | rd
+	}
+}
+
+// encodeB encodes B and BL.
+func encodeB(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(1, 1)
+		return base | e.offset(0, 26, 4)
+	}
+}
+
+// branchCond encodes B.cond.
+func branchCond(cond uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(1, 1)
+		return 0x54000000 | e.offset(0, 19, 4)<<5 | cond
+	}
+}
+
+// branchReg encodes BR, BLR and RET; RET defaults to X30.
+func branchReg(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		if base == 0xD65F0000 && len(e.ops) == 0 {
+			return base | 30<<5
+		}
+		e.want(1, 1)
+		return base | e.x64(0)<<5
+	}
+}
+
+// compareBranch encodes CBZ and CBNZ.
+func compareBranch(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rt, is64 := e.gp(0)
+		return base | sf(is64)<<31 | e.offset(1, 19, 4)<<5 | rt
+	}
+}
+
+// testBranch encodes TBZ and TBNZ.
+func testBranch(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rt, is64 := e.gp(0)
+		bit := e.imm(1)
+		if bit < 0 || bit >= width(is64) {
+			fail("bit number %s out of range", e.ops[1])
+		}
+		b := uint32(bit)
+		return base | (b>>5)<<31 | (b&0x1F)<<19 | e.offset(2, 14, 4)<<5 | rt
+	}
+}
+
+// exception encodes SVC and BRK.
+func exception(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(1, 1)
+		return base | e.uimm(0, 16)<<5
+	}
+}
+
+// encodeMRS encodes MRS.
+func encodeMRS(e *encoder) uint32 {
+	e.want(2, 2)
+	return 0xD5300000 | e.sysReg(1)<<5 | e.x64(0)
+}
+
+// encodeMSR encodes MSR (register).
+func encodeMSR(e *encoder) uint32 {
+	e.want(2, 2)
+	return 0xD5100000 | e.sysReg(0)<<5 | e.x64(1)
+}
+
+// hint encodes a hint that takes no operands.
+func hint(n uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(0, 0)
+		return 0xD503201F | n<<5
+	}
+}
+
+// encodeHint encodes HINT #imm.
+func encodeHint(e *encoder) uint32 {
+	e.want(1, 1)
+	return 0xD503201F | e.uimm(0, 7)<<5
+}
+
+// btiTargets are the BTI target operands and their hint numbers.
+var btiTargets = map[string]uint32{"": 32, "c": 34, "j": 36, "jc": 38}
+
+// encodeBTI encodes BTI with an optional target.
+func encodeBTI(e *encoder) uint32 {
+	e.want(0, 1)
+	target := ""
+	if len(e.ops) == 1 {
+		target = strings.ToLower(e.ops[0])
+	}
+	n, ok := btiTargets[target]
+	if !ok {
+		fail("invalid BTI target %s", target)
+	}
+	return 0xD503201F | n<<5
+}
+
+// dcOperations and icOperations are the DC and IC operations by name.
+var (
+	dcOperations = map[string]uint16{
+		"zva": insts.DCZVA, "cvac": insts.DCCVAC, "cvau": insts.DCCVAU,
+		"cvap": insts.DCCVAP, "cvadp": insts.DCCVADP, "civac": insts.DCCIVAC,
+	}
+	icOperations = map[string]uint16{"ivau": insts.ICIVAU}
+)
+
+// cacheMaint encodes DC or IC with an operation from ops and an address
+// register.
+func cacheMaint(ops map[string]uint16) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		op, ok := ops[strings.ToLower(strings.TrimSpace(e.ops[0]))]
+		if !ok {
+			fail("unknown cache operation %s", e.ops[0])
+		}
+		return 0xD5080000 | uint32(op)<<5 | e.x64(1)
+	}
+}
+
+// barrierOptions are the DMB and DSB options by name.
+var barrierOptions = map[string]uint32{
+	"oshld": 1, "oshst": 2, "osh": 3,
+	"nshld": 5, "nshst": 6, "nsh": 7,
+	"ishld": 9, "ishst": 10, "ish": 11,
+	"ld": 13, "st": 14, "sy": 15,
+}
+
+// barrier encodes DMB and DSB, which need an option, and ISB and CLREX,
+// which default to 15 (SY).
+func barrier(base uint32, needsOption bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		if needsOption {
+			e.want(1, 1)
+		} else {
+			e.want(0, 1)
+		}
+		crm := uint32(15)
+		if len(e.ops) == 1 {
+			if option, ok := barrierOptions[strings.ToLower(e.ops[0])]; ok {
+				crm = option
+			} else {
+				crm = e.uimm(0, 4)
+			}
+		}
+		return base&^(0xF<<8) | crm<<8
+	}
+}
@@ -0,0 +1,110 @@
+package asm
+
+// addCryptoEncoders registers the cryptographic extension instructions.
+func addCryptoEncoders() {
+	for name, enc := range map[string]encodeFunc{
+		"aese":   cryptoTwoReg(0x4E284800, "16b", "16b"),
+		"aesd":   cryptoTwoReg(0x4E285800, "16b", "16b"),
+		"aesmc":  cryptoTwoReg(0x4E286800, "16b", "16b"),
+		"aesimc": cryptoTwoReg(0x4E287800, "16b", "16b"),
+
+		"sha1c":     cryptoThreeReg(0x5E000000, "q", "s", "4s"),
+		"sha1p":     cryptoThreeReg(0x5E001000, "q", "s", "4s"),
+		"sha1m":     cryptoThreeReg(0x5E002000, "q", "s", "4s"),
+		"sha1su0":   cryptoThreeReg(0x5E003000, "4s", "4s", "4s"),
+		"sha1h":     cryptoTwoReg(0x5E280800, "s", "s"),
+		"sha1su1":   cryptoTwoReg(0x5E281800, "4s", "4s"),
+		"sha256h":   cryptoThreeReg(0x5E004000, "q", "q", "4s"),
+		"sha256h2":  cryptoThreeReg(0x5E005000, "q", "q", "4s"),
+		"sha256su0": cryptoTwoReg(0x5E282800, "4s", "4s"),
+		"sha256su1": cryptoThreeReg(0x5E006000, "4s", "4s", "4s"),
+		"sha512h":   cryptoThreeReg(0xCE608000, "q", "q", "2d"),
+		"sha512h2":  cryptoThreeReg(0xCE608400, "q", "q", "2d"),
+		"sha512su0": cryptoTwoReg(0xCEC08000, "2d", "2d"),
+		"sha512su1": cryptoThreeReg(0xCE608800, "2d", "2d", "2d"),
+
+		"eor3": cryptoFourReg(0xCE000000),
+		"bcax": cryptoFourReg(0xCE200000),
+		"rax1": cryptoThreeReg(0xCE608C00, "2d", "2d", "2d"),
+		"xar":  encodeXAR,
+
+		"pmull":  pmull(false),
+		"pmull2": pmull(true),
+	} {
+		encoders[name] = enc
+	}
+}
+
+// cryptoRegs parses the operands as registers of the given shapes: "q" or
+// "s" for a scalar SIMD&FP register, or a vector arrangement.
+func (e *encoder) cryptoRegs(shapes ...string) []uint32 {
+	e.want(len(shapes), len(shapes))
+	nums := make([]uint32, len(shapes))
+	for i, shape := range shapes {
+		r := e.reg(i)
+		switch {
+		case len(shape) == 1 && r.kind != shape[0]:
+			fail("expected a %s register, got %s", shape, e.ops[i])
+		case len(shape) > 1 && (r.kind != kindV || r.lane >= 0 || r.arr != shape):
+			fail("expected a vector register with arrangement %s, got %s", shape, e.ops[i])
+		}
+		nums[i] = r.num
+	}
+	return nums
+}
+
+// cryptoTwoReg encodes the AES and two-register SHA instructions.
+func cryptoTwoReg(word uint32, rd, rn string) encodeFunc {
+	return func(e *encoder) uint32 {
+		r := e.cryptoRegs(rd, rn)
+		return word | r[1]<<5 | r[0]
+	}
+}
+
+// cryptoThreeReg encodes the three-register SHA instructions and RAX1.
+func cryptoThreeReg(word uint32, rd, rn, rm string) encodeFunc {
+	return func(e *encoder) uint32 {
+		r := e.cryptoRegs(rd, rn, rm)
+		return word | r[2]<<16 | r[1]<<5 | r[0]
+	}
+}
+
+// cryptoFourReg encodes EOR3 and BCAX.
+func cryptoFourReg(word uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		r := e.cryptoRegs("16b", "16b", "16b", "16b")
+		return word | r[2]<<16 | r[3]<<10 | r[1]<<5 | r[0]
+	}
+}
+
+// encodeXAR encodes XAR, which rotates right by 0 to 63.
+func encodeXAR(e *encoder) uint32 {
+	e.want(4, 4)
+	rd, arr := e.vector(0)
+	rn, arrN := e.vector(1)
+	rm, arrM := e.vector(2)
+	if arr != "2d" || arrN != arr || arrM != arr {
+		fail("invalid arrangement %s", arr)
+	}
+	return 0xCE800000 | rm<<16 | e.uimm(3, 6)<<10 | rn<<5 | rd
+}
+
+// pmull encodes PMULL and PMULL2, which multiply bytes into halfwords
+// (8H) or doublewords into a quadword (1Q).
+func pmull(upper bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		var size uint32
+		switch e.reg(0).arr {
+		case "8h":
+			size = 0
+		case "1q":
+			size = 3
+		default:
+			fail("invalid arrangement in %s", e.ops[0])
+		}
+		narrow := [2][2]string{{"8b", "16b"}, {"1d", "2d"}}[size/3][sf(upper)]
+		r := e.cryptoRegs(e.reg(0).arr, narrow, narrow)
+		return 0x0E20E000 | sf(upper)<<30 | size<<22 | r[2]<<16 | r[1]<<5 | r[0]
+	}
+}
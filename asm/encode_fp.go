@@ -0,0 +1,315 @@
+package asm
+
+// addFPEncoders registers the floating-point and SIMD instructions.
+func addFPEncoders() {
+	for name, enc := range map[string]encodeFunc{
+		"fmul": elementOr(fpBinary(0b0000, vectorFloat(1, 0, 0b011)), byElement(0b1001)),
+		"fdiv": fpBinary(0b0001, vectorFloat(1, 0, 0b111)),
+		"fadd": fpBinary(0b0010, vectorFloat(0, 0, 0b010)),
+		"fsub": fpBinary(0b0011, vectorFloat(0, 1, 0b010)),
+
+		"fmax":   fpBinary(0b0100, vectorFloat(0, 0, 0b110)),
+		"fmin":   fpBinary(0b0101, vectorFloat(0, 1, 0b110)),
+		"fmaxnm": fpBinary(0b0110, vectorFloat(0, 0, 0b000)),
+		"fminnm": fpBinary(0b0111, vectorFloat(0, 1, 0b000)),
+		"fnmul":  fpBinary(0b1000, nil),
+
+		"fabs":   fpUnary(0b000001),
+		"fneg":   fpUnary(0b000010),
+		"fsqrt":  fpUnary(0b000011),
+		"frintn": fpUnary(0b001000),
+		"frintp": fpUnary(0b001001),
+		"frintm": fpUnary(0b001010),
+		"frintz": fpUnary(0b001011),
+		"frinta": fpUnary(0b001100),
+		"frintx": fpUnary(0b001110),
+		"frinti": fpUnary(0b001111),
+		"fcvt":   encodeFCVT,
+		"fmov":   encodeFMOV,
+
+		"fmadd":  fpTernary(0, 0),
+		"fmsub":  fpTernary(0, 1),
+		"fnmadd": fpTernary(1, 0),
+		"fnmsub": fpTernary(1, 1),
+
+		"fcmp":   fpCompare(0),
+		"fcmpe":  fpCompare(1),
+		"fccmp":  fpCondCompare(0),
+		"fccmpe": fpCondCompare(1),
+		"fcsel":  encodeFCSEL,
+
+		"scvtf":  intToFP(0b010),
+		"ucvtf":  intToFP(0b011),
+		"fcvtns": fpToInt(0, 0b000, false),
+		"fcvtnu": fpToInt(0, 0b001, false),
+		"fcvtps": fpToInt(1, 0b000, false),
+		"fcvtpu": fpToInt(1, 0b001, false),
+		"fcvtms": fpToInt(2, 0b000, false),
+		"fcvtmu": fpToInt(2, 0b001, false),
+		"fcvtzs": fpToInt(3, 0b000, true),
+		"fcvtzu": fpToInt(3, 0b001, true),
+		"fcvtas": fpToInt(0, 0b100, false),
+		"fcvtau": fpToInt(0, 0b101, false),
+
+		"dup": encodeDUP,
+	} {
+		encoders[name] = enc
+	}
+}
+
+// vectorOperands parses three vector registers with the same arrangement.
+func (e *encoder) vectorOperands() (rd, rn, rm uint32, arr string) {
+	e.want(3, 3)
+	rd, arr = e.vector(0)
+	rn, arrN := e.vector(1)
+	rm, arrM := e.vector(2)
+	if arrN != arr || arrM != arr {
+		fail("arrangement mismatch")
+	}
+	return rd, rn, rm, arr
+}
+
+// threeSameWord encodes an Advanced SIMD three-same instruction.
+func threeSameWord(q, u, size, opcode, rd, rn, rm uint32) uint32 {
+	return 0x0E200400 | q<<30 | u<<29 | size<<22 | rm<<16 | opcode<<11 | rn<<5 | rd
+}
+
+// vectorInt encodes the integer three-same instructions (ADD, SUB, MUL,
+// SQADD, CMEQ, ...), or their Advanced SIMD scalar form when the operands
+// are scalar registers. Only some opcodes have a 2D form.
+func vectorInt(u, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if e.reg(0).kind != kindV {
+			return e.scalarInt(u, opcode)
+		}
+		rd, rn, rm, arr := e.vectorOperands()
+		f := arrangements[arr]
+		if arr == "1d" || (f.size == 3 && !vector2D[opcode]) {
+			fail("invalid arrangement %s", arr)
+		}
+		return threeSameWord(f.q, u, f.size, opcode, rd, rn, rm)
+	}
+}
+
+// scalarIntSizes holds the integer three-same opcodes that have an Advanced
+// SIMD scalar form, with a mask of the size fields each allows: any for the
+// saturating adds and subtracts, halfwords and words for SQDMULH and
+// SQRDMULH, and otherwise doublewords only.
+var scalarIntSizes = map[uint32]uint32{
+	0b00001: 0b1111, 0b00101: 0b1111,
+	0b00110: 0b1000, 0b00111: 0b1000, 0b01000: 0b1000, 0b10000: 0b1000, 0b10001: 0b1000,
+	0b10110: 0b0110,
+}
+
+// scalarInt encodes the Advanced SIMD scalar form of an integer three-same
+// instruction ("cmeq d0, d1, d2").
+func (e *encoder) scalarInt(u, opcode uint32) uint32 {
+	rd, rn, rm := e.reg(0), e.reg(1), e.reg(2)
+	size, ok := scalarSizes[rd.kind]
+	if !ok || scalarIntSizes[opcode]>>size&1 == 0 {
+		fail("invalid operand %s", e.ops[0])
+	}
+	if rn.kind != rd.kind || rm.kind != rd.kind {
+		fail("register width mismatch")
+	}
+	return 0x5E200400 | u<<29 | size<<22 | rm.num<<16 | opcode<<11 | rn.num<<5 | rd.num
+}
+
+// fpBinary encodes the scalar two-source arithmetic, or the vector form
+// when the operands are vectors and there is one.
+func fpBinary(opcode uint32, vector encodeFunc) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if vector != nil && e.reg(0).kind == kindV {
+			return vector(e)
+		}
+		rd := e.fpReg(0, 0)
+		rn := e.fpReg(1, rd.kind)
+		rm := e.fpReg(2, rd.kind)
+		return 0x1E200800 | fpType(rd)<<22 | rm.num<<16 | opcode<<12 | rn.num<<5 | rd.num
+	}
+}
+
+// fpUnaryWord encodes a scalar one-source instruction.
+func fpUnaryWord(ftype, opcode, rd, rn uint32) uint32 {
+	return 0x1E204000 | ftype<<22 | opcode<<15 | rn<<5 | rd
+}
+
+// fpUnary encodes FABS, FNEG, FSQRT and FRINT*.
+func fpUnary(opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd := e.fpReg(0, 0)
+		rn := e.fpReg(1, rd.kind)
+		return fpUnaryWord(fpType(rd), opcode, rd.num, rn.num)
+	}
+}
+
+// encodeFCVT encodes conversions between precisions.
+func encodeFCVT(e *encoder) uint32 {
+	e.want(2, 2)
+	rd := e.fpReg(0, 0)
+	rn := e.fpReg(1, 0)
+	if rd.kind == rn.kind {
+		fail("FCVT needs different precisions")
+	}
+	return fpUnaryWord(fpType(rn), 0b100|fpType(rd), rd.num, rn.num)
+}
+
+// encodeFMOV encodes FMOV between floating-point registers, of an
+// immediate to a scalar or vector, and between general-purpose and floating-point registers
+// (including the upper half of a vector).
+func encodeFMOV(e *encoder) uint32 {
+	e.want(2, 2)
+	rd := e.reg(0)
+	if !e.isReg(1) {
+		if rd.kind == kindV {
+			return e.vectorFMOV()
+		}
+		d := e.fpReg(0, 0)
+		imm8, ok := fpImm8(e.fpImm(1))
+		if !ok {
+			fail("%s cannot be encoded as an FMOV immediate", e.ops[1])
+		}
+		return 0x1E201000 | fpType(d)<<22 | imm8<<13 | d.num
+	}
+	rn := e.reg(1)
+
+	switch {
+	case rd.kind == kindV || rn.kind == kindV:
+		upper := func(r register) bool { return r.kind == kindV && r.arr == "d" && r.lane == 1 }
+		if upper(rn) {
+			return 0x9EAE0000 | rn.num<<5 | e.x64(0)
+		}
+		if upper(rd) {
+			return 0x9EAF0000 | e.x64(1)<<5 | rd.num
+		}
+		fail("FMOV only moves the upper half of a vector")
+	case rd.kind == kindX || rd.kind == kindW:
+		gp, is64 := e.gp(0)
+		fp := e.fpReg(1, 0)
+		checkFMOVWidth(is64, fp)
+		return 0x1E260000 | sf(is64)<<31 | fpType(fp)<<22 | fp.num<<5 | gp
+	case rn.kind == kindX || rn.kind == kindW:
+		gp, is64 := e.gp(1)
+		fp := e.fpReg(0, 0)
+		checkFMOVWidth(is64, fp)
+		return 0x1E270000 | sf(is64)<<31 | fpType(fp)<<22 | gp<<5 | fp.num
+	}
+	d := e.fpReg(0, 0)
+	n := e.fpReg(1, d.kind)
+	return fpUnaryWord(fpType(d), 0, d.num, n.num)
+}
+
+// checkFMOVWidth checks the register pairing of FMOV (general): W with S,
+// X with D, and either with H.
+func checkFMOVWidth(is64 bool, fp register) {
+	if (fp.kind == kindS && is64) || (fp.kind == kindD && !is64) {
+		fail("register width mismatch")
+	}
+}
+
+// fpTernary encodes FMADD, FMSUB, FNMADD and FNMSUB.
+func fpTernary(o1, o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rd := e.fpReg(0, 0)
+		rn := e.fpReg(1, rd.kind)
+		rm := e.fpReg(2, rd.kind)
+		ra := e.fpReg(3, rd.kind)
+		return 0x1F000000 | fpType(rd)<<22 | o1<<21 | rm.num<<16 | o0<<15 |
+			ra.num<<10 | rn.num<<5 | rd.num
+	}
+}
+
+// fpCompare encodes FCMP and FCMPE against a register or zero.
+func fpCompare(signaling uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rn := e.fpReg(0, 0)
+		word := 0x1E202000 | fpType(rn)<<22 | rn.num<<5 | signaling<<4
+		if !e.isReg(1) {
+			if e.fpImm(1) != 0 {
+				fail("FCMP compares against a register or #0.0")
+			}
+			return word | 0b1000
+		}
+		return word | e.fpReg(1, rn.kind).num<<16
+	}
+}
+
+// fpCondCompare encodes FCCMP and FCCMPE.
+func fpCondCompare(signaling uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rn := e.fpReg(0, 0)
+		rm := e.fpReg(1, rn.kind)
+		return 0x1E200400 | fpType(rn)<<22 | rm.num<<16 | e.cond(3)<<12 | rn.num<<5 |
+			signaling<<4 | e.uimm(2, 4)
+	}
+}
+
+// encodeFCSEL encodes FCSEL.
+func encodeFCSEL(e *encoder) uint32 {
+	e.want(4, 4)
+	rd := e.fpReg(0, 0)
+	rn := e.fpReg(1, rd.kind)
+	rm := e.fpReg(2, rd.kind)
+	return 0x1E200C00 | fpType(rd)<<22 | rm.num<<16 | e.cond(3)<<12 | rn.num<<5 | rd.num
+}
+
+// convertWord encodes a conversion between floating-point and integer
+// values, or fixed-point values with fbits fraction bits.
+func (e *encoder) convertWord(is64 bool, fp register, rmode, opcode, rd, rn uint32, fbitsOp int) uint32 {
+	word := 0x1E200000 | sf(is64)<<31 | fpType(fp)<<22 | rmode<<19 | opcode<<16 | rn<<5 | rd
+	if fbitsOp >= len(e.ops) {
+		return word
+	}
+	fbits := e.imm(fbitsOp)
+	if fbits < 1 || fbits > width(is64) {
+		fail("fraction bits %s out of range", e.ops[fbitsOp])
+	}
+	return word&^(1<<21) | uint32(64-fbits)<<10
+}
+
+// intToFP encodes SCVTF and UCVTF.
+func intToFP(opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 3)
+		fp := e.fpReg(0, 0)
+		rn, is64 := e.gp(1)
+		return e.convertWord(is64, fp, 0, opcode, fp.num, rn, 2)
+	}
+}
+
+// fpToInt encodes the FCVT<mode><S|U> conversions; the round-toward-zero
+// forms also take fixed-point fraction bits.
+func fpToInt(rmode, opcode uint32, fixed bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		if fixed {
+			e.want(2, 3)
+		} else {
+			e.want(2, 2)
+		}
+		rd, is64 := e.gp(0)
+		fp := e.fpReg(1, 0)
+		return e.convertWord(is64, fp, rmode, opcode, rd, fp.num, 2)
+	}
+}
+
+// encodeDUP encodes DUP (general), or DUP (element) when the source is a
+// vector element.
+func encodeDUP(e *encoder) uint32 {
+	e.want(2, 2)
+	if e.reg(1).kind == kindV {
+		return encodeDUPElem(e)
+	}
+	rd, arr := e.vector(0)
+	f := arrangements[arr]
+	if arr == "1d" {
+		fail("invalid arrangement %s", arr)
+	}
+	rn := e.sameWidth(1, f.size == 3)
+	return 0x0E000C00 | f.q<<30 | (1<<f.size)<<16 | rn<<5 | rd
+}
@@ -0,0 +1,565 @@
+package asm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// addMemoryEncoders registers the loads and stores.
+func addMemoryEncoders() {
+	for name, enc := range map[string]encodeFunc{
+		"ldr":   loadStore(1, -1, false, false),
+		"str":   loadStore(0, -1, false, false),
+		"ldrb":  loadStore(1, 0, false, false),
+		"strb":  loadStore(0, 0, false, false),
+		"ldrsb": loadStore(1, 0, true, false),
+		"ldrh":  loadStore(1, 1, false, false),
+		"strh":  loadStore(0, 1, false, false),
+		"ldrsh": loadStore(1, 1, true, false),
+		"ldrsw": loadStore(1, 2, true, false),
+
+		"ldur":   loadStore(1, -1, false, true),
+		"stur":   loadStore(0, -1, false, true),
+		"ldurb":  loadStore(1, 0, false, true),
+		"sturb":  loadStore(0, 0, false, true),
+		"ldursb": loadStore(1, 0, true, true),
+		"ldurh":  loadStore(1, 1, false, true),
+		"sturh":  loadStore(0, 1, false, true),
+		"ldursh": loadStore(1, 1, true, true),
+		"ldursw": loadStore(1, 2, true, true),
+
+		"ldtr":   loadStoreUnprivileged(1, -1, false),
+		"sttr":   loadStoreUnprivileged(0, -1, false),
+		"ldtrb":  loadStoreUnprivileged(1, 0, false),
+		"sttrb":  loadStoreUnprivileged(0, 0, false),
+		"ldtrsb": loadStoreUnprivileged(1, 0, true),
+		"ldtrh":  loadStoreUnprivileged(1, 1, false),
+		"sttrh":  loadStoreUnprivileged(0, 1, false),
+		"ldtrsh": loadStoreUnprivileged(1, 1, true),
+		"ldtrsw": loadStoreUnprivileged(1, 2, true),
+
+		"prfm":  prefetch(false),
+		"prfum": prefetch(true),
+
+		"ldp":   loadStorePair(1, false, false),
+		"stp":   loadStorePair(0, false, false),
+		"ldnp":  loadStorePair(1, true, false),
+		"stnp":  loadStorePair(0, true, false),
+		"ldpsw": loadStorePair(1, false, true),
+	} {
+		encoders[name] = enc
+	}
+
+	sizes := map[string]int{"": -1, "b": 0, "h": 1}
+	for suffix, size := range sizes {
+		encoders["ldxr"+suffix] = loadExclusive(size, 0)
+		encoders["ldaxr"+suffix] = loadExclusive(size, 1)
+		encoders["stxr"+suffix] = storeExclusive(size, 0)
+		encoders["stlxr"+suffix] = storeExclusive(size, 1)
+		encoders["ldar"+suffix] = loadAcquire(size, 1)
+		encoders["stlr"+suffix] = loadAcquire(size, 0)
+		encoders["ldapr"+suffix] = atomic(size, 1, 0, 1, 4, false)
+		for order, ar := range orderings {
+			encoders["cas"+order+suffix] = compareSwap(size, ar[0], ar[1])
+			encoders["swp"+order+suffix] = atomic(size, ar[0], ar[1], 1, 0, false)
+			for op, opc := range atomicOps {
+				encoders["ld"+op+order+suffix] = atomic(size, ar[0], ar[1], 0, opc, false)
+				if ar[0] == 0 {
+					encoders["st"+op+order+suffix] = atomic(size, 0, ar[1], 0, opc, true)
+				}
+			}
+		}
+	}
+	for order, ar := range orderings {
+		encoders["casp"+order] = compareSwapPair(ar[0], ar[1])
+	}
+	for n := 1; n <= 4; n++ {
+		encoders["ld"+strconv.Itoa(n)] = loadStoreStruct(1, n)
+		encoders["st"+strconv.Itoa(n)] = loadStoreStruct(0, n)
+		encoders["ld"+strconv.Itoa(n)+"r"] = loadReplicate(n)
+	}
+	encoders["ldxp"] = loadExclusivePair(0)
+	encoders["ldaxp"] = loadExclusivePair(1)
+	encoders["stxp"] = storeExclusivePair(0)
+	encoders["stlxp"] = storeExclusivePair(1)
+}
+
+// orderings maps the acquire/release mnemonic suffixes to their A and R
+// bits.
+var orderings = map[string][2]uint32{"": {0, 0}, "a": {1, 0}, "l": {0, 1}, "al": {1, 1}}
+
+// atomicOps maps the LSE atomic operations to their opc fields.
+var atomicOps = map[string]uint32{
+	"add": 0, "clr": 1, "eor": 2, "set": 3,
+	"smax": 4, "smin": 5, "umax": 6, "umin": 7,
+}
+
+// transfer describes the register of a single-register load or store.
+type transfer struct {
+	size  uint32 // log2 of the access size, except for Q registers
+	opc   uint32
+	v     uint32 // 1 for SIMD&FP registers
+	scale uint32 // log2 of the access size
+}
+
+// fpSizes maps SIMD&FP register kinds to their access sizes.
+var fpSizes = map[byte]uint32{kindB: 0, kindH: 1, kindS: 2, kindD: 3, kindQ: 4}
+
+// transferFor returns the transfer of register rt for a load or store of
+// the given fixed size (-1 if set by the register), optionally
+// sign-extending.
+func transferFor(rt register, load uint32, size int, signed bool) transfer {
+	if scale, ok := fpSizes[rt.kind]; ok {
+		if size >= 0 || signed {
+			fail("invalid register for this access size")
+		}
+		if scale == 4 {
+			return transfer{size: 0, opc: 2 | load, v: 1, scale: 4}
+		}
+		return transfer{size: scale, opc: load, v: 1, scale: scale}
+	}
+	if rt.kind != kindX && rt.kind != kindW {
+		fail("expected a general-purpose or floating-point register")
+	}
+
+	switch {
+	case size < 0:
+		s := 2 | sf(rt.is64())
+		return transfer{size: s, opc: load, scale: s}
+	case signed && size == 2:
+		if !rt.is64() {
+			fail("sign-extending word loads need a 64-bit register")
+		}
+		return transfer{size: 2, opc: 2, scale: 2}
+	case signed:
+		return transfer{size: uint32(size), opc: 3 - sf(rt.is64()), scale: uint32(size)}
+	default:
+		if rt.is64() {
+			fail("byte and halfword accesses need a 32-bit register")
+		}
+		return transfer{size: uint32(size), opc: load, scale: uint32(size)}
+	}
+}
+
+// regOffsetOptions maps register-offset extends to their option fields.
+var regOffsetOptions = map[string]uint32{"uxtw": 0b010, "lsl": 0b011, "sxtw": 0b110, "sxtx": 0b111}
+
+// loadStore encodes the single-register loads and stores in every addressing
+// mode. Unscaled mnemonics (LDUR, ...) only accept a 9-bit signed offset;
+// the others use it when the offset cannot be scaled.
+func loadStore(load uint32, size int, signed, unscaled bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 3)
+		r := e.reg(0)
+		if r.kind == kindX || r.kind == kindW {
+			r = e.gpReg(0, false)
+		}
+		t := transferFor(r, load, size, signed)
+		rt := r.num
+
+		if !strings.HasPrefix(strings.TrimSpace(e.ops[1]), "[") {
+			if load == 0 || unscaled || (size >= 0 && !(signed && size == 2)) {
+				fail("expected a memory operand, got %s", e.ops[1])
+			}
+			e.want(2, 2)
+			opc := t.size & 1
+			switch {
+			case signed:
+				opc = 2
+			case t.scale == 4:
+				opc = 2
+			case t.v == 1:
+				opc = t.scale - 2
+				if t.scale < 2 {
+					fail("invalid register for a literal load")
+				}
+			}
+			return 0x18000000 | opc<<30 | t.v<<26 | e.offset(1, 19, 4)<<5 | rt
+		}
+
+		return e.singleTransfer(1, e.address(1), t, rt, unscaled)
+	}
+}
+
+// singleTransfer encodes a single-register load, store or prefetch of
+// transfer t with the address addr parsed from operand i.
+func (e *encoder) singleTransfer(i int, addr address, t transfer, rt uint32, unscaled bool) uint32 {
+	base := t.size<<30 | t.v<<26 | t.opc<<22 | addr.base<<5 | rt
+	unit := int64(1) << t.scale
+	switch addr.mode {
+	case addrPre, addrPost:
+		if unscaled {
+			fail("unscaled accesses take an offset address")
+		}
+		mode := uint32(1)
+		if addr.mode == addrPre {
+			mode = 3
+		}
+		return 0x38000000 | base | signedField(addr.imm, 9, e.ops[i])<<12 | mode<<10
+	case addrReg:
+		if unscaled {
+			fail("unscaled accesses take an immediate offset")
+		}
+		var s uint32
+		if addr.hasAmount {
+			if addr.amount != 0 && addr.amount != int64(t.scale) {
+				fail("shift amount must be 0 or %d", t.scale)
+			}
+			if addr.amount == int64(t.scale) {
+				s = 1
+			}
+		}
+		return 0x38200800 | base | addr.rm.num<<16 | regOffsetOptions[addr.extend]<<13 | s<<12
+	}
+
+	imm := addr.imm
+	if !unscaled && imm >= 0 && imm%unit == 0 && imm/unit < 4096 {
+		return 0x39000000 | base | uint32(imm/unit)<<10
+	}
+	return 0x38000000 | base | signedField(imm, 9, e.ops[i])<<12
+}
+
+// loadStoreUnprivileged encodes LDTR, STTR and their byte, halfword and
+// sign-extending forms, which take a 9-bit signed offset.
+func loadStoreUnprivileged(load uint32, size int, signed bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		r := e.gpReg(0, false)
+		t := transferFor(r, load, size, signed)
+		addr := e.address(1)
+		if addr.mode != addrOffset {
+			fail("unprivileged accesses take an immediate offset")
+		}
+		return 0x38000800 | t.size<<30 | t.opc<<22 | signedField(addr.imm, 9, e.ops[1])<<12 |
+			addr.base<<5 | r.num
+	}
+}
+
+// prefetchTypes, prefetchTargets and prefetchPolicies map the parts of a
+// named prefetch operation ("pldl1keep") to their prfop fields.
+var (
+	prefetchTypes    = map[string]uint32{"pld": 0, "pli": 1, "pst": 2}
+	prefetchTargets  = map[string]uint32{"l1": 0, "l2": 1, "l3": 2}
+	prefetchPolicies = map[string]uint32{"keep": 0, "strm": 1}
+)
+
+// prfop parses operand i as a named or numeric prefetch operation.
+func (e *encoder) prfop(i int) uint32 {
+	name := strings.ToLower(strings.TrimSpace(e.ops[i]))
+	if len(name) == 9 {
+		typ, ok1 := prefetchTypes[name[:3]]
+		target, ok2 := prefetchTargets[name[3:5]]
+		policy, ok3 := prefetchPolicies[name[5:]]
+		if ok1 && ok2 && ok3 {
+			return typ<<3 | target<<1 | policy
+		}
+	}
+	if strings.HasPrefix(name, "#") {
+		return e.uimm(i, 5)
+	}
+	fail("unknown prefetch operation %s", e.ops[i])
+	return 0
+}
+
+// prefetch encodes PRFM (literal, unsigned offset and register offset) and
+// PRFUM (unscaled offset).
+func prefetch(unscaled bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		op := e.prfop(0)
+		if !strings.HasPrefix(strings.TrimSpace(e.ops[1]), "[") {
+			if unscaled {
+				fail("expected a memory operand, got %s", e.ops[1])
+			}
+			return 0xD8000000 | e.offset(1, 19, 4)<<5 | op
+		}
+		addr := e.address(1)
+		if addr.mode == addrPre || addr.mode == addrPost {
+			fail("prefetches take an offset address")
+		}
+		return e.singleTransfer(1, addr, transfer{size: 3, opc: 2, scale: 3}, op, unscaled)
+	}
+}
+
+// loadStorePair encodes LDP and STP of general-purpose and SIMD&FP
+// registers, their non-temporal LDNP/STNP forms, and LDPSW (signed).
+func loadStorePair(load uint32, nonTemporal, signed bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 4)
+		r1, r2 := e.reg(0), e.reg(1)
+		if r1.kind != r2.kind {
+			fail("register width mismatch: %s", e.ops[1])
+		}
+		var opc, v, scale uint32
+		switch r1.kind {
+		case kindX, kindW:
+			r1, r2 = e.gpReg(0, false), e.gpReg(1, false)
+			opc, scale = 2*sf(r1.is64()), 2|sf(r1.is64())
+			if signed {
+				if !r1.is64() {
+					fail("sign-extending word loads need a 64-bit register")
+				}
+				opc, scale = 1, 2
+			}
+		case kindS, kindD, kindQ:
+			if signed {
+				fail("invalid register for a pair: %s", e.ops[0])
+			}
+			scale = fpSizes[r1.kind]
+			opc, v = scale-2, 1
+		default:
+			fail("invalid register for a pair: %s", e.ops[0])
+		}
+
+		addr := e.address(2)
+		modes := map[int]uint32{addrPost: 1, addrOffset: 2, addrPre: 3}
+		if nonTemporal {
+			modes = map[int]uint32{addrOffset: 0}
+		}
+		mode, ok := modes[addr.mode]
+		if !ok {
+			if nonTemporal {
+				fail("non-temporal pairs take an offset address")
+			}
+			fail("pairs take an immediate offset")
+		}
+		if addr.imm%(1<<scale) != 0 {
+			fail("offset %d is not a multiple of %d", addr.imm, 1<<scale)
+		}
+		imm7 := signedField(addr.imm>>scale, 7, e.ops[2])
+		return 0x28000000 | opc<<30 | v<<26 | mode<<23 | load<<22 | imm7<<15 |
+			r2.num<<10 | addr.base<<5 | r1.num
+	}
+}
+
+// baseOnly parses operand i as a memory operand without an offset.
+func (e *encoder) baseOnly(i int) uint32 {
+	addr := e.address(i)
+	if addr.mode != addrOffset || addr.imm != 0 {
+		fail("expected [Xn], got %s", e.ops[i])
+	}
+	return addr.base
+}
+
+// accessSize returns the size field for a register of a byte, halfword or
+// register-sized (-1) access.
+func accessSize(is64 bool, size int) uint32 {
+	if size < 0 {
+		return 2 | sf(is64)
+	}
+	if is64 {
+		fail("byte and halfword accesses need a 32-bit register")
+	}
+	return uint32(size)
+}
+
+// exclusiveWord encodes the load/store exclusive and ordered instructions.
+func exclusiveWord(size, o2, l, o1, rs, o0, rt2, rn, rt uint32) uint32 {
+	return 0x08000000 | size<<30 | o2<<23 | l<<22 | o1<<21 | rs<<16 | o0<<15 | rt2<<10 | rn<<5 | rt
+}
+
+// loadExclusive encodes LDXR and LDAXR.
+func loadExclusive(size int, o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rt, is64 := e.gp(0)
+		return exclusiveWord(accessSize(is64, size), 0, 1, 0, 31, o0, 31, e.baseOnly(1), rt)
+	}
+}
+
+// storeExclusive encodes STXR and STLXR.
+func storeExclusive(size int, o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rs := e.sameWidth(0, false)
+		rt, is64 := e.gp(1)
+		return exclusiveWord(accessSize(is64, size), 0, 0, 0, rs, o0, 31, e.baseOnly(2), rt)
+	}
+}
+
+// loadExclusivePair encodes LDXP and LDAXP.
+func loadExclusivePair(o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rt, is64 := e.gp(0)
+		rt2 := e.sameWidth(1, is64)
+		return exclusiveWord(2|sf(is64), 0, 1, 1, 31, o0, rt2, e.baseOnly(2), rt)
+	}
+}
+
+// storeExclusivePair encodes STXP and STLXP.
+func storeExclusivePair(o0 uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(4, 4)
+		rs := e.sameWidth(0, false)
+		rt, is64 := e.gp(1)
+		rt2 := e.sameWidth(2, is64)
+		return exclusiveWord(2|sf(is64), 0, 0, 1, rs, o0, rt2, e.baseOnly(3), rt)
+	}
+}
+
+// loadAcquire encodes LDAR (l=1) and STLR (l=0).
+func loadAcquire(size int, l uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rt, is64 := e.gp(0)
+		return exclusiveWord(accessSize(is64, size), 1, l, 0, 31, 1, 31, e.baseOnly(1), rt)
+	}
+}
+
+// compareSwap encodes CAS with its ordering and size variants.
+func compareSwap(size int, a, r uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rs, is64 := e.gp(0)
+		rt := e.sameWidth(1, is64)
+		return exclusiveWord(accessSize(is64, size), 1, a, 1, rs, r, 31, e.baseOnly(2), rt)
+	}
+}
+
+// compareSwapPair encodes CASP, whose register pairs must start at even
+// registers.
+func compareSwapPair(a, r uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(5, 5)
+		rs, is64 := e.gp(0)
+		rs1 := e.sameWidth(1, is64)
+		rt := e.sameWidth(2, is64)
+		rt1 := e.sameWidth(3, is64)
+		if rs%2 != 0 || rt%2 != 0 || rs1 != rs+1 || rt1 != rt+1 {
+			fail("CASP needs consecutive register pairs starting at even registers")
+		}
+		return exclusiveWord(sf(is64), 0, a, 1, rs, r, 31, e.baseOnly(4), rt)
+	}
+}
+
+// atomic encodes the LSE atomic memory operations, SWP and LDAPR. The
+// ST<op> aliases discard the loaded value into the zero register; LDAPR
+// takes only Rt.
+func atomic(size int, a, r, o3, opc uint32, store bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		var rs, rt, rn uint32
+		var is64 bool
+		switch {
+		case o3 == 1 && opc == 4:
+			e.want(2, 2)
+			rt, is64 = e.gp(0)
+			rs, rn = 31, e.baseOnly(1)
+		case store:
+			e.want(2, 2)
+			rs, is64 = e.gp(0)
+			rt, rn = 31, e.baseOnly(1)
+		default:
+			e.want(3, 3)
+			rs, is64 = e.gp(0)
+			rt = e.sameWidth(1, is64)
+			rn = e.baseOnly(2)
+		}
+		return 0x38200000 | accessSize(is64, size)<<30 | a<<23 | r<<22 | rs<<16 |
+			o3<<15 | opc<<12 | rn<<5 | rt
+	}
+}
+
+// multipleOpcodes maps the register count of LD1/ST1 (selem 1), or the
+// structure size of LD2-LD4/ST2-ST4, to the multiple-structures opcode.
+var multipleOpcodes = [2]map[int]uint32{
+	{1: 0b0111, 2: 0b1010, 3: 0b0110, 4: 0b0010},
+	{2: 0b1000, 3: 0b0100, 4: 0b0000},
+}
+
+// laneSizes maps the element size of a lane list to its log2 byte size.
+var laneSizes = map[string]uint32{"b": 0, "h": 1, "s": 2, "d": 3}
+
+// loadStoreStruct encodes LD1-LD4 and ST1-ST4 of selem-element structures,
+// in both the multiple-structures and the single-lane forms.
+func loadStoreStruct(load uint32, selem int) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 3)
+		list := e.regList(0)
+		if list.lane < 0 {
+			f, ok := arrangements[list.arr]
+			opcode, okCount := multipleOpcodes[min(selem-1, 1)][list.count]
+			switch {
+			case !ok:
+				fail("invalid arrangement %s", list.arr)
+			case !okCount && selem == 1:
+				fail("expected 1 to 4 registers in %s", e.ops[0])
+			case !okCount || list.count != selem && selem > 1:
+				fail("expected %d registers in %s", selem, e.ops[0])
+			case selem > 1 && list.arr == "1d":
+				fail("invalid arrangement %s", list.arr)
+			}
+			rn, rm, post := e.structAddress(1, int64(list.count)<<(3+f.q))
+			return 0x0C000000 | f.q<<30 | post<<23 | load<<22 | rm<<16 |
+				opcode<<12 | f.size<<10 | rn<<5 | list.first
+		}
+
+		esize, ok := laneSizes[list.arr]
+		switch {
+		case !ok:
+			fail("invalid lane element size %s", list.arr)
+		case list.count != selem:
+			fail("expected %d registers in %s", selem, e.ops[0])
+		case list.lane >= 16>>esize:
+			fail("lane index %d out of range", list.lane)
+		}
+		// The lane index is split across Q, S and size, above the bits
+		// of size that encode the element size.
+		lane := uint32(list.lane) << esize
+		scale := min(esize, 2)
+		size := lane & 0x3
+		if esize == 3 {
+			size = 1
+		}
+		rn, rm, post := e.structAddress(1, int64(selem)<<esize)
+		return singleStructWord(lane>>3, post, load, uint32(selem), scale, rm) |
+			(lane>>2&0x1)<<12 | size<<10 | rn<<5 | list.first
+	}
+}
+
+// loadReplicate encodes LD1R-LD4R.
+func loadReplicate(selem int) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 3)
+		list := e.regList(0)
+		f, ok := arrangements[list.arr]
+		switch {
+		case !ok || list.lane >= 0:
+			fail("invalid arrangement in %s", e.ops[0])
+		case list.count != selem:
+			fail("expected %d registers in %s", selem, e.ops[0])
+		}
+		rn, rm, post := e.structAddress(1, int64(selem)<<f.size)
+		return singleStructWord(f.q, post, 1, uint32(selem), 0b11, rm) |
+			f.size<<10 | rn<<5 | list.first
+	}
+}
+
+// singleStructWord encodes the fields of a single-structure load or store
+// above S.
+func singleStructWord(q, post, load, selem, scale, rm uint32) uint32 {
+	return 0x0D000000 | q<<30 | post<<23 | load<<22 | ((selem-1)&0x1)<<21 |
+		rm<<16 | (scale<<1|(selem-1)>>1)<<13
+}
+
+// structAddress parses the address of a structure load or store at operand
+// i, returning the base, the Rm field and the post-index bit. A post-index
+// immediate must equal the transfer size; register post-indexes cannot use
+// XZR, whose encoding selects the immediate form.
+func (e *encoder) structAddress(i int, size int64) (rn, rm, post uint32) {
+	sub := &encoder{a: e.a, s: e.s, index: e.index, ops: e.ops[i : i+1]}
+	rn = sub.baseOnly(0)
+	switch {
+	case i+1 >= len(e.ops):
+		return rn, 0, 0
+	case e.isReg(i + 1):
+		rm = e.x64(i + 1)
+		if rm == 31 {
+			fail("%s is not allowed here", e.ops[i+1])
+		}
+		return rn, rm, 1
+	case e.imm(i+1) != size:
+		fail("post-index immediate must be #%d", size)
+	}
+	return rn, 31, 1
+}
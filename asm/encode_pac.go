@@ -0,0 +1,80 @@
+package asm
+
+// addPointerAuthEncoders registers the pointer authentication instructions
+// outside the hint space. The hint forms (PACIASP, AUTIASP, XPACLRI, ...)
+// are in hintNumbers.
+func addPointerAuthEncoders() {
+	for i, key := range []string{"ia", "ib", "da", "db"} {
+		op := uint32(i)
+		encoders["pac"+key] = pointerAuth(op, false)
+		encoders["aut"+key] = pointerAuth(0b100|op, false)
+		encoders["pac"+key[:1]+"z"+key[1:]] = pointerAuth(0b1000|op, true)
+		encoders["aut"+key[:1]+"z"+key[1:]] = pointerAuth(0b1100|op, true)
+	}
+	encoders["xpaci"] = pointerAuth(0b010000, true)
+	encoders["xpacd"] = pointerAuth(0b010001, true)
+	encoders["pacga"] = encodePACGA
+
+	for i, key := range []string{"a", "b"} {
+		m := uint32(i)
+		encoders["bra"+key] = branchRegAuth(0xD71F0800 | m<<10)
+		encoders["blra"+key] = branchRegAuth(0xD73F0800 | m<<10)
+		encoders["bra"+key+"z"] = branchRegAuthZero(0xD61F081F | m<<10)
+		encoders["blra"+key+"z"] = branchRegAuthZero(0xD63F081F | m<<10)
+		encoders["reta"+key] = noOperands(0xD65F0BFF | m<<10)
+	}
+}
+
+// xSP parses operand i as a 64-bit general-purpose register or SP.
+func (e *encoder) xSP(i int) uint32 {
+	n, is64 := e.gpSP(i)
+	if !is64 {
+		fail("register width mismatch: %s", e.ops[i])
+	}
+	return n
+}
+
+// pointerAuth encodes PAC, AUT and XPAC. The zero-modifier forms and XPAC
+// take only the pointer register; the others take a modifier register or
+// SP.
+func pointerAuth(opcode uint32, zero bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		if zero {
+			e.want(1, 1)
+			return 0xDAC10000 | opcode<<10 | 31<<5 | e.x64(0)
+		}
+		e.want(2, 2)
+		return 0xDAC10000 | opcode<<10 | e.xSP(1)<<5 | e.x64(0)
+	}
+}
+
+// encodePACGA encodes PACGA, whose modifier may be SP.
+func encodePACGA(e *encoder) uint32 {
+	e.want(3, 3)
+	return 0x9AC03000 | e.xSP(2)<<16 | e.x64(1)<<5 | e.x64(0)
+}
+
+// branchRegAuth encodes BRAA, BRAB, BLRAA and BLRAB, whose modifier may be
+// SP.
+func branchRegAuth(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		return base | e.x64(0)<<5 | e.xSP(1)
+	}
+}
+
+// branchRegAuthZero encodes BRAAZ, BRABZ, BLRAAZ and BLRABZ.
+func branchRegAuthZero(base uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(1, 1)
+		return base | e.x64(0)<<5
+	}
+}
+
+// noOperands encodes an instruction that takes no operands, such as RETAA.
+func noOperands(word uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(0, 0)
+		return word
+	}
+}
@@ -0,0 +1,727 @@
+package asm
+
+import "strings"
+
+// addSIMDEncoders registers the Advanced SIMD integer instructions and the
+// vector forms of mnemonics shared with the general-purpose instructions.
+func addSIMDEncoders() {
+	for name, enc := range map[string]encodeFunc{
+		"sqadd": vectorInt(0, 0b00001),
+		"uqadd": vectorInt(1, 0b00001),
+		"sqsub": vectorInt(0, 0b00101),
+		"uqsub": vectorInt(1, 0b00101),
+		"cmgt":  vectorCompare(0, 0b00110, 0, 0b01000),
+		"cmhi":  vectorInt(1, 0b00110),
+		"cmge":  vectorCompare(0, 0b00111, 1, 0b01000),
+		"cmhs":  vectorInt(1, 0b00111),
+		"cmtst": vectorInt(0, 0b10001),
+		"cmeq":  vectorCompare(1, 0b10001, 0, 0b01001),
+		"cmle":  compareZero(1, 0b01001),
+		"cmlt":  compareZero(0, 0b01010),
+		"smax":  vectorInt(0, 0b01100),
+		"umax":  vectorInt(1, 0b01100),
+		"smin":  vectorInt(0, 0b01101),
+		"umin":  vectorInt(1, 0b01101),
+		"sabd":  vectorInt(0, 0b01110),
+		"uabd":  vectorInt(1, 0b01110),
+		"saba":  vectorInt(0, 0b01111),
+		"uaba":  vectorInt(1, 0b01111),
+		"mla":   elementOr(vectorInt(0, 0b10010), intByElement(1, 0b0000, false)),
+		"mls":   elementOr(vectorInt(1, 0b10010), intByElement(1, 0b0100, false)),
+		"addp":  vectorOr(encodeScalarADDP, vectorInt(0, 0b10111)),
+		"smaxp": vectorInt(0, 0b10100),
+		"umaxp": vectorInt(1, 0b10100),
+		"sminp": vectorInt(0, 0b10101),
+		"uminp": vectorInt(1, 0b10101),
+		"sshl":  vectorInt(0, 0b01000),
+		"ushl":  vectorInt(1, 0b01000),
+
+		"sqdmulh":  elementOr(vectorInt(0, 0b10110), intByElement(0, 0b1100, true)),
+		"sqrdmulh": elementOr(vectorInt(1, 0b10110), intByElement(0, 0b1101, true)),
+
+		"and": vectorOr(encoders["and"], vectorLogical(0, 0)),
+		"bic": vectorOr(encoders["bic"], orImmediate(vectorLogical(0, 1), modImm(1, true))),
+		"orr": vectorOr(encoders["orr"], orImmediate(vectorLogical(0, 2), modImm(0, true))),
+		"orn": vectorOr(encoders["orn"], vectorLogical(0, 3)),
+		"eor": vectorOr(encoders["eor"], vectorLogical(1, 0)),
+		"bsl": vectorLogical(1, 1),
+		"bit": vectorLogical(1, 2),
+		"bif": vectorLogical(1, 3),
+		"mov": vectorMov(encoders["mov"]),
+
+		"abs":   vectorTwoReg(0, 0b01011, false),
+		"neg":   simdOr(encoders["neg"], vectorTwoReg(1, 0b01011, false)),
+		"cnt":   vectorTwoReg(0, 0b00101, true),
+		"not":   vectorTwoReg(1, 0b00101, true),
+		"mvn":   vectorOr(encoders["mvn"], vectorTwoReg(1, 0b00101, true)),
+		"rev16": vectorOr(encoders["rev16"], vectorRev(0, 0b00001, 0)),
+		"rev32": vectorOr(encoders["rev32"], vectorRev(1, 0b00000, 1)),
+		"rev64": vectorOr(encoders["rev64"], vectorRev(0, 0b00000, 2)),
+
+		"saddlp": pairLong(0, 0b00010),
+		"uaddlp": pairLong(1, 0b00010),
+		"sadalp": pairLong(0, 0b00110),
+		"uadalp": pairLong(1, 0b00110),
+
+		"movi": modImm(0, false),
+		"mvni": modImm(1, false),
+
+		"ins":  encodeINS,
+		"umov": moveElement(0b0111, false),
+		"smov": moveElement(0b0101, false),
+
+		"addv":   across(0, 0b11011, false),
+		"saddlv": across(0, 0b00011, true),
+		"uaddlv": across(1, 0b00011, true),
+		"smaxv":  across(0, 0b01010, false),
+		"umaxv":  across(1, 0b01010, false),
+		"sminv":  across(0, 0b11010, false),
+		"uminv":  across(1, 0b11010, false),
+
+		"uzp1": permute(0b001),
+		"uzp2": permute(0b101),
+		"zip1": permute(0b011),
+		"zip2": permute(0b111),
+		"trn1": permute(0b010),
+		"trn2": permute(0b110),
+		"ext":  encodeEXT,
+		"tbl":  tableLookup(0),
+		"tbx":  tableLookup(1),
+
+		"shl":  shiftImm(0, 0b01010, false),
+		"sshr": shiftImm(0, 0b00000, true),
+		"ushr": shiftImm(1, 0b00000, true),
+		"ssra": shiftImm(0, 0b00010, true),
+		"usra": shiftImm(1, 0b00010, true),
+	} {
+		encoders[name] = enc
+	}
+
+	// Mnemonics with a "2" variant that uses the upper half of the narrow
+	// vector.
+	for i, suffix := range []string{"", "2"} {
+		upper := i == 1
+		for name, enc := range map[string]encodeFunc{
+			"xtn":    narrow(0, 0b10010, upper),
+			"sqxtun": narrow(1, 0b10010, upper),
+			"sqxtn":  narrow(0, 0b10100, upper),
+			"uqxtn":  narrow(1, 0b10100, upper),
+			"shrn":   shiftNarrow(0b10000, upper),
+			"rshrn":  shiftNarrow(0b10001, upper),
+			"sshll":  shiftLong(0, upper),
+			"ushll":  shiftLong(1, upper),
+			"sxtl":   alias(shiftLong(0, upper), appendZeroShift),
+			"uxtl":   alias(shiftLong(1, upper), appendZeroShift),
+			"saddl":  long(0, 0b0000, upper, false),
+			"uaddl":  long(1, 0b0000, upper, false),
+			"saddw":  long(0, 0b0001, upper, true),
+			"uaddw":  long(1, 0b0001, upper, true),
+			"ssubl":  long(0, 0b0010, upper, false),
+			"usubl":  long(1, 0b0010, upper, false),
+			"ssubw":  long(0, 0b0011, upper, true),
+			"usubw":  long(1, 0b0011, upper, true),
+			"sabal":  long(0, 0b0101, upper, false),
+			"uabal":  long(1, 0b0101, upper, false),
+			"sabdl":  long(0, 0b0111, upper, false),
+			"uabdl":  long(1, 0b0111, upper, false),
+			"smlal":  long(0, 0b1000, upper, false),
+			"umlal":  long(1, 0b1000, upper, false),
+			"smlsl":  long(0, 0b1010, upper, false),
+			"umlsl":  long(1, 0b1010, upper, false),
+		} {
+			encoders[name+suffix] = enc
+		}
+	}
+	encoders["smull"] = vectorOr(encoders["smull"], long(0, 0b1100, false, false))
+	encoders["umull"] = vectorOr(encoders["umull"], long(1, 0b1100, false, false))
+	encoders["smull2"] = long(0, 0b1100, true, false)
+	encoders["umull2"] = long(1, 0b1100, true, false)
+}
+
+// vector2D holds the integer three-same opcodes that have a 2D form.
+var vector2D = map[uint32]bool{
+	0b00001: true, 0b00101: true, 0b00110: true, 0b00111: true,
+	0b01000: true, 0b10000: true, 0b10001: true, 0b10111: true,
+}
+
+// vectorOr encodes the vector form with vec when the first operand is a
+// vector register, and the general-purpose form with gp otherwise.
+func vectorOr(gp, vec encodeFunc) encodeFunc {
+	return func(e *encoder) uint32 {
+		if len(e.ops) > 0 && e.isReg(0) && e.reg(0).kind == kindV {
+			return vec(e)
+		}
+		return gp(e)
+	}
+}
+
+// byteArrangement parses operand i as a vector with an 8B or 16B
+// arrangement and returns its number and Q bit.
+func (e *encoder) byteArrangement(i int) (uint32, uint32) {
+	n, arr := e.vector(i)
+	if arr != "8b" && arr != "16b" {
+		fail("invalid arrangement %s", arr)
+	}
+	return n, arrangements[arr].q
+}
+
+// orImmediate encodes with imm when the second operand is an immediate
+// (the vector ORR and BIC), and with reg otherwise.
+func orImmediate(reg, imm encodeFunc) encodeFunc {
+	return func(e *encoder) uint32 {
+		if len(e.ops) >= 2 && !e.isReg(1) {
+			return imm(e)
+		}
+		return reg(e)
+	}
+}
+
+// vectorLogical encodes the vector bitwise operations, where u and size
+// select the operation.
+func vectorLogical(u, size uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd, q := e.byteArrangement(0)
+		rn, qn := e.byteArrangement(1)
+		rm, qm := e.byteArrangement(2)
+		if qn != q || qm != q {
+			fail("arrangement mismatch")
+		}
+		return threeSameWord(q, u, size, 0b00011, rd, rn, rm)
+	}
+}
+
+// vectorMov encodes the vector forms of MOV (ORR with identical sources,
+// INS (general), UMOV of a word or doubleword and DUP (element) to a
+// scalar), falling back to gp.
+func vectorMov(gp encodeFunc) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		switch {
+		case e.reg(0).kind == kindV && e.reg(0).lane >= 0:
+			return encodeINS(e)
+		case e.reg(0).kind == kindV:
+			return alias(vectorLogical(0, 2), func(ops []string) []string {
+				return []string{ops[0], ops[1], ops[1]}
+			})(e)
+		case e.isReg(1) && e.reg(1).kind == kindV && isScalarReg(e.reg(0)):
+			return encodeDUPElem(e)
+		case e.isReg(1) && e.reg(1).kind == kindV:
+			return moveElement(0b0111, true)(e)
+		}
+		return gp(e)
+	}
+}
+
+// twoRegWord encodes an Advanced SIMD two-register miscellaneous
+// instruction.
+func twoRegWord(q, u, size, opcode, rd, rn uint32) uint32 {
+	return 0x0E200800 | q<<30 | u<<29 | size<<22 | opcode<<12 | rn<<5 | rd
+}
+
+// vectorTwoReg encodes the two-register operations on a single
+// arrangement. bytes restricts the arrangement to 8B and 16B (CNT, NOT),
+// whose size field is part of the opcode.
+func vectorTwoReg(u, opcode uint32, bytes bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		return e.twoReg(u, opcode, bytes)
+	}
+}
+
+// twoReg encodes the first two operands as a two-register operation, or
+// its Advanced SIMD scalar form when they are d registers ("abs d0, d1").
+func (e *encoder) twoReg(u, opcode uint32, bytes bool) uint32 {
+	if r := e.reg(0); r.kind != kindV {
+		rn := e.reg(1)
+		if r.kind != kindD || bytes {
+			fail("invalid operand %s", e.ops[0])
+		}
+		if rn.kind != kindD {
+			fail("register width mismatch: %s", e.ops[1])
+		}
+		return 0x5E200800 | u<<29 | 3<<22 | opcode<<12 | rn.num<<5 | r.num
+	}
+	rd, arr := e.vector(0)
+	rn, arrN := e.vector(1)
+	f := arrangements[arr]
+	switch {
+	case arrN != arr:
+		fail("arrangement mismatch")
+	case arr == "1d", bytes && f.size != 0:
+		fail("invalid arrangement %s", arr)
+	}
+	return twoRegWord(f.q, u, f.size, opcode, rd, rn)
+}
+
+// compareZero encodes the compares against zero ("cmle v0.4s, v1.4s, #0").
+func compareZero(u, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if e.imm(2) != 0 {
+			fail("expected #0, got %s", e.ops[2])
+		}
+		return e.twoReg(u, opcode, false)
+	}
+}
+
+// vectorCompare encodes a register compare, or its compare against zero
+// when the last operand is an immediate.
+func vectorCompare(u, opcode, zeroU, zeroOpcode uint32) encodeFunc {
+	reg, zero := vectorInt(u, opcode), compareZero(zeroU, zeroOpcode)
+	return func(e *encoder) uint32 {
+		if len(e.ops) == 3 && !e.isReg(2) {
+			return zero(e)
+		}
+		return reg(e)
+	}
+}
+
+// narrowOperands parses a narrow vector at operand narrowOp and a 128-bit
+// vector of twice the element size at wideOp. The narrow vector must be
+// 128-bit for the "2" variants (upper) and 64-bit otherwise. It returns
+// the narrow element size.
+func (e *encoder) narrowOperands(narrowOp, wideOp int, upper bool) uint32 {
+	_, arr := e.vector(narrowOp)
+	_, wideArr := e.vector(wideOp)
+	f, w := arrangements[arr], arrangements[wideArr]
+	if f.size == 3 || (f.q == 1) != upper {
+		fail("invalid arrangement %s", arr)
+	}
+	if w.q != 1 || w.size != f.size+1 {
+		fail("invalid arrangement %s", wideArr)
+	}
+	return f.size
+}
+
+// pairLong encodes SADDLP, UADDLP, SADALP and UADALP, whose destination
+// elements are twice the size of the source's.
+func pairLong(u, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd, arr := e.vector(0)
+		rn, arrN := e.vector(1)
+		f, n := arrangements[arr], arrangements[arrN]
+		if n.size == 3 || n.q != f.q || f.size != n.size+1 {
+			fail("invalid arrangement %s", arrN)
+		}
+		return twoRegWord(n.q, u, n.size, opcode, rd, rn)
+	}
+}
+
+// narrow encodes XTN, SQXTN, UQXTN and SQXTUN.
+func narrow(u, opcode uint32, upper bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		size := e.narrowOperands(0, 1, upper)
+		rd, _ := e.vector(0)
+		rn, _ := e.vector(1)
+		return twoRegWord(sf(upper), u, size, opcode, rd, rn)
+	}
+}
+
+// long encodes the three-different instructions. wide selects the forms
+// whose first source has full-size elements (SADDW, USUBW, ...).
+func long(u, opcode uint32, upper, wide bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		size := e.narrowOperands(2, 0, upper)
+		rd, _ := e.vector(0)
+		rn, arrN := e.vector(1)
+		rm, arrM := e.vector(2)
+		_, arrD := e.vector(0)
+		if (wide && arrN != arrD) || (!wide && arrN != arrM) {
+			fail("arrangement mismatch")
+		}
+		return 0x0E200000 | sf(upper)<<30 | u<<29 | size<<22 | rm<<16 | opcode<<12 | rn<<5 | rd
+	}
+}
+
+// shiftWord encodes an Advanced SIMD shift by immediate instruction.
+func shiftWord(q, u, immhb, opcode, rd, rn uint32) uint32 {
+	return 0x0F000400 | q<<30 | u<<29 | immhb<<16 | opcode<<11 | rn<<5 | rd
+}
+
+// shiftImm encodes SHL and the right shifts (and accumulates) on a single
+// arrangement, or in the Advanced SIMD scalar form on d registers ("ushr
+// d0, d1, #3"). Right shifts range from 1 to the element size, left shifts
+// from 0 to one less.
+func shiftImm(u, opcode uint32, right bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		var rd, rn, q, size, scalar uint32
+		if r := e.reg(0); r.kind == kindD {
+			if e.reg(1).kind != kindD {
+				fail("register width mismatch: %s", e.ops[1])
+			}
+			rd, rn, q, size, scalar = r.num, e.reg(1).num, 1, 3, 1
+		} else {
+			var arr, arrN string
+			rd, arr = e.vector(0)
+			rn, arrN = e.vector(1)
+			if arrN != arr {
+				fail("arrangement mismatch")
+			}
+			if arr == "1d" {
+				fail("invalid arrangement %s", arr)
+			}
+			q, size = arrangements[arr].q, arrangements[arr].size
+		}
+		esize := uint32(8) << size
+		if right {
+			shift := e.shiftAmount(2, 1, esize)
+			return shiftWord(q, u, 2*esize-shift, opcode, rd, rn) | scalar<<28
+		}
+		return shiftWord(q, u, esize+e.shiftAmount(2, 0, esize-1), opcode, rd, rn) | scalar<<28
+	}
+}
+
+// shiftAmount parses operand i as a shift amount between lo and hi.
+func (e *encoder) shiftAmount(i int, lo, hi uint32) uint32 {
+	v := e.imm(i)
+	if v < int64(lo) || v > int64(hi) {
+		fail("shift amount %s out of range", e.ops[i])
+	}
+	return uint32(v)
+}
+
+// shiftNarrow encodes SHRN (opcode 10000), RSHRN (opcode 10001) and their
+// "2" variants.
+func shiftNarrow(opcode uint32, upper bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		size := e.narrowOperands(0, 1, upper)
+		rd, _ := e.vector(0)
+		rn, _ := e.vector(1)
+		esize := uint32(8) << size
+		shift := e.shiftAmount(2, 1, esize)
+		return shiftWord(sf(upper), 0, 2*esize-shift, opcode, rd, rn)
+	}
+}
+
+// shiftLong encodes SSHLL and USHLL, and their "2" variants.
+func shiftLong(u uint32, upper bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		size := e.narrowOperands(1, 0, upper)
+		rd, _ := e.vector(0)
+		rn, _ := e.vector(1)
+		esize := uint32(8) << size
+		return shiftWord(sf(upper), u, esize+e.shiftAmount(2, 0, esize-1), 0b10100, rd, rn)
+	}
+}
+
+// appendZeroShift appends a zero shift amount (SXTL, UXTL).
+func appendZeroShift(ops []string) []string {
+	return append(ops, "#0")
+}
+
+// modImm encodes MOVI and MVNI, and with logical set the vector ORR and
+// BIC (immediate). op selects MVNI or BIC. The immediate is imm8 with an
+// optional "lsl #n" or "msl #n", or for the 2D and scalar D forms of MOVI a
+// 64-bit value whose bytes are each 0x00 or 0xff.
+func modImm(op uint32, logical bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 3)
+		var rd uint32
+		var arr string
+		if r := e.reg(0); r.kind == kindD && op == 0 && !logical {
+			rd, arr = r.num, "1d"
+		} else {
+			rd, arr = e.vector(0)
+		}
+		f := arrangements[arr]
+
+		mod, amount := "lsl", int64(0)
+		if len(e.ops) == 3 {
+			name, text, _ := strings.Cut(strings.TrimSpace(e.ops[2]), " ")
+			v, err := e.a.constant(strings.TrimSpace(text))
+			if err != nil {
+				fail("%v", err)
+			}
+			mod, amount = strings.ToLower(name), v
+		}
+
+		var cmode uint32
+		switch {
+		case f.size == 3:
+			if logical || op == 1 || len(e.ops) == 3 {
+				fail("invalid arrangement %s", arr)
+			}
+			imm8, ok := byteMask(uint64(e.imm(1)))
+			if !ok {
+				fail("immediate %s cannot be encoded", e.ops[1])
+			}
+			return modImmWord(f.q, 1, 0b1110, imm8, rd)
+		case f.size == 0:
+			if logical || op == 1 || len(e.ops) == 3 {
+				fail("invalid arrangement %s", arr)
+			}
+			cmode = 0b1110
+		case mod == "msl" && f.size == 2 && !logical && (amount == 8 || amount == 16):
+			cmode = 0b1100 | uint32(amount/8-1)
+		case mod != "lsl" || amount%8 != 0 || amount < 0 || amount >= 8<<f.size:
+			fail("invalid shift %s", e.ops[2])
+		case f.size == 1:
+			cmode = 0b1000 | uint32(amount/8)<<1
+		default:
+			cmode = uint32(amount/8) << 1
+		}
+		if logical {
+			cmode |= 1
+		}
+		return modImmWord(f.q, op, cmode, e.uimm(1, 8), rd)
+	}
+}
+
+// modImmWord encodes an Advanced SIMD modified immediate instruction.
+func modImmWord(q, op, cmode, imm8, rd uint32) uint32 {
+	return 0x0F000400 | q<<30 | op<<29 | (imm8>>5)<<16 | cmode<<12 | (imm8&0x1F)<<5 | rd
+}
+
+// byteMask encodes a 64-bit value whose bytes are each 0x00 or 0xff as the
+// imm8 of MOVI, one bit per byte.
+func byteMask(v uint64) (uint32, bool) {
+	var imm8 uint32
+	for i := 0; i < 8; i++ {
+		switch v >> (8 * i) & 0xFF {
+		case 0xFF:
+			imm8 |= 1 << i
+		case 0:
+		default:
+			return 0, false
+		}
+	}
+	return imm8, true
+}
+
+// elementSizes maps vector element specifiers to their size fields.
+var elementSizes = map[string]uint32{"b": 0, "h": 1, "s": 2, "d": 3}
+
+// element parses operand i as a vector element ("v1.s[2]") and returns the
+// register number, element size field and imm5 encoding of the size and
+// index.
+func (e *encoder) element(i int) (num, size, imm5 uint32) {
+	r := e.reg(i)
+	size, ok := elementSizes[r.arr]
+	if r.kind != kindV || r.lane < 0 || !ok {
+		fail("expected a vector element, got %s", e.ops[i])
+	}
+	if r.lane >= 16>>size {
+		fail("lane index out of range in %s", e.ops[i])
+	}
+	return r.num, size, (uint32(r.lane)<<1 | 1) << size
+}
+
+// copyWord encodes an Advanced SIMD copy instruction.
+func copyWord(q, imm5, imm4, rd, rn uint32) uint32 {
+	return 0x0E000400 | q<<30 | imm5<<16 | imm4<<11 | rn<<5 | rd
+}
+
+// encodeDUPElem encodes DUP (element), to a vector or to a scalar of the
+// element size ("dup d0, v1.d[1]").
+func encodeDUPElem(e *encoder) uint32 {
+	e.want(2, 2)
+	if r := e.reg(0); r.kind != kindV {
+		rn, size, imm5 := e.element(1)
+		if want, ok := scalarSizes[r.kind]; !ok || want != size {
+			fail("invalid destination %s", e.ops[0])
+		}
+		return 0x5E000400 | imm5<<16 | rn<<5 | r.num
+	}
+	rd, arr := e.vector(0)
+	rn, size, imm5 := e.element(1)
+	f := arrangements[arr]
+	if f.size != size || arr == "1d" {
+		fail("invalid arrangement %s", arr)
+	}
+	return copyWord(f.q, imm5, 0b0000, rd, rn)
+}
+
+// encodeINS encodes INS (general) and INS (element), also written MOV.
+func encodeINS(e *encoder) uint32 {
+	e.want(2, 2)
+	rd, size, imm5 := e.element(0)
+	if r := e.reg(1); r.kind == kindV {
+		rn, sizeN, _ := e.element(1)
+		if sizeN != size {
+			fail("element size mismatch")
+		}
+		return 0x6E000400 | imm5<<16 | uint32(r.lane)<<size<<11 | rn<<5 | rd
+	}
+	rn := e.sameWidth(1, size == 3)
+	return copyWord(1, imm5, 0b0011, rd, rn)
+}
+
+// moveElement encodes UMOV (imm4 0111) and SMOV (imm4 0101). The
+// destination is an X register for UMOV of doublewords and for SMOV to 64
+// bits. mov restricts UMOV to the word and doubleword elements of its MOV
+// alias.
+func moveElement(imm4 uint32, mov bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd, is64 := e.gp(0)
+		rn, size, imm5 := e.element(1)
+		switch {
+		case imm4 == 0b0111 && (size == 3) != is64,
+			imm4 == 0b0111 && mov && size < 2,
+			imm4 == 0b0101 && size >= 2+sf(is64):
+			fail("invalid element size in %s", e.ops[1])
+		}
+		return copyWord(sf(is64), imm5, imm4, rd, rn)
+	}
+}
+
+// scalarSizes maps SIMD&FP scalar register kinds to their size fields.
+var scalarSizes = map[byte]uint32{kindB: 0, kindH: 1, kindS: 2, kindD: 3}
+
+// isScalarReg reports whether r is a b, h, s or d register.
+func isScalarReg(r register) bool {
+	_, ok := scalarSizes[r.kind]
+	return ok
+}
+
+// encodeScalarADDP encodes the scalar ADDP, which adds the two elements of
+// a 2D vector ("addp d0, v1.2d").
+func encodeScalarADDP(e *encoder) uint32 {
+	e.want(2, 2)
+	rd := e.reg(0)
+	rn, arr := e.vector(1)
+	if rd.kind != kindD || arr != "2d" {
+		fail("invalid arrangement %s", arr)
+	}
+	return 0x5EF1B800 | rn<<5 | rd.num
+}
+
+// intByElement encodes MUL, MLA, MLS, SQDMULH and SQRDMULH (by element)
+// on halfword or word elements, on a vector ("mul v0.4s, v1.4s, v2.s[1]")
+// or, if scalar is set, also in the scalar form ("sqdmulh s0, s1,
+// v2.s[1]"). The index is split across H, L and M; halfword elements come
+// from V0-V15.
+func intByElement(u, opcode uint32, scalar bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		var rd, rn, q, size uint32
+		word := uint32(0x0F000000)
+		if r := e.reg(0); r.kind == kindV {
+			var arr, arrN string
+			rd, arr = e.vector(0)
+			rn, arrN = e.vector(1)
+			if arrN != arr {
+				fail("arrangement mismatch")
+			}
+			q, size = arrangements[arr].q, arrangements[arr].size
+		} else {
+			if !scalar || !isScalarReg(r) || e.reg(1).kind != r.kind {
+				fail("invalid operand %s", e.ops[0])
+			}
+			rd, rn, size, word = r.num, e.reg(1).num, scalarSizes[r.kind], 0x5F000000
+		}
+		if size != 1 && size != 2 {
+			fail("invalid arrangement %s", e.ops[0])
+		}
+		m := e.reg(2)
+		if elemSize, ok := elementSizes[m.arr]; m.kind != kindV || !ok || elemSize != size {
+			fail("invalid element %s", e.ops[2])
+		}
+		lane := uint32(m.lane)
+		var h, l, rm uint32
+		if size == 1 {
+			if m.num >= 16 || lane >= 8 {
+				fail("invalid element %s", e.ops[2])
+			}
+			h, l, rm = lane>>2, lane>>1&1, lane&1<<4|m.num
+		} else {
+			if lane >= 4 {
+				fail("lane index out of range in %s", e.ops[2])
+			}
+			h, l, rm = lane>>1, lane&1, m.num
+		}
+		return word | q<<30 | u<<29 | size<<22 | l<<21 | rm<<16 | opcode<<12 | h<<11 | rn<<5 | rd
+	}
+}
+
+// across encodes the reductions across lanes. The scalar destination has
+// the element size or, for the long forms, twice it.
+func across(u, opcode uint32, isLong bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd := e.reg(0)
+		rn, arr := e.vector(1)
+		f := arrangements[arr]
+		if f.size == 3 || (f.size == 2 && f.q == 0) {
+			fail("invalid arrangement %s", arr)
+		}
+		want := f.size
+		if isLong {
+			want++
+		}
+		if size, ok := scalarSizes[rd.kind]; !ok || size != want {
+			fail("invalid destination %s", e.ops[0])
+		}
+		return 0x0E300800 | f.q<<30 | u<<29 | f.size<<22 | opcode<<12 | rn<<5 | rd.num
+	}
+}
+
+// permute encodes UZP1, UZP2, ZIP1, ZIP2, TRN1 and TRN2.
+func permute(opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		rd, rn, rm, arr := e.vectorOperands()
+		f := arrangements[arr]
+		if arr == "1d" {
+			fail("invalid arrangement %s", arr)
+		}
+		return 0x0E000800 | f.q<<30 | f.size<<22 | rm<<16 | opcode<<12 | rn<<5 | rd
+	}
+}
+
+// vectorRev encodes the vector forms of REV16, REV32 and REV64, whose
+// elements must be smaller than the container of 2<<maxSize bytes.
+func vectorRev(u, opcode, maxSize uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		_, arr := e.vector(0)
+		if arrangements[arr].size > maxSize {
+			fail("invalid arrangement %s", arr)
+		}
+		return e.twoReg(u, opcode, false)
+	}
+}
+
+// encodeEXT encodes EXT, whose byte index must lie within the vector.
+func encodeEXT(e *encoder) uint32 {
+	e.want(4, 4)
+	rd, arr := e.vector(0)
+	rn, arrN := e.vector(1)
+	rm, arrM := e.vector(2)
+	f := arrangements[arr]
+	switch {
+	case arrN != arr || arrM != arr:
+		fail("arrangement mismatch")
+	case f.size != 0:
+		fail("invalid arrangement %s", arr)
+	}
+	imm4 := e.uimm(3, 3+uint(f.q))
+	return 0x2E000000 | f.q<<30 | rm<<16 | imm4<<11 | rn<<5 | rd
+}
+
+// tableLookup encodes TBL (op 0) and TBX (op 1) with a list of one to four
+// 16B table registers.
+func tableLookup(op uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		rd, arr := e.vector(0)
+		table := e.regList(1)
+		rm, arrM := e.vector(2)
+		switch {
+		case table.arr != "16b":
+			fail("invalid arrangement in %s", e.ops[1])
+		case arrM != arr:
+			fail("arrangement mismatch")
+		case arrangements[arr].size != 0:
+			fail("invalid arrangement %s", arr)
+		}
+		return 0x0E000000 | arrangements[arr].q<<30 | rm<<16 | uint32(table.count-1)<<13 |
+			op<<12 | table.first<<5 | rd
+	}
+}
@@ -0,0 +1,342 @@
+package asm
+
+// addSIMDFPEncoders registers the Advanced SIMD floating-point
+// instructions, and the vector forms of mnemonics shared with the scalar
+// floating-point instructions.
+func addSIMDFPEncoders() {
+	for name, enc := range map[string]encodeFunc{
+		"fmla":    elementOr(vectorFloat(0, 0, 0b001), byElement(0b0001)),
+		"fmls":    elementOr(vectorFloat(0, 1, 0b001), byElement(0b0101)),
+		"fabd":    floatThreeSame(1, 1, 0b010),
+		"faddp":   vectorOr(scalarPairwise(0, 0b01101), vectorFloat(1, 0, 0b010)),
+		"fmaxp":   vectorOr(scalarPairwise(0, 0b01111), vectorFloat(1, 0, 0b110)),
+		"fminp":   vectorOr(scalarPairwise(1, 0b01111), vectorFloat(1, 1, 0b110)),
+		"fmaxnmp": vectorOr(scalarPairwise(0, 0b01100), vectorFloat(1, 0, 0b000)),
+		"fminnmp": vectorOr(scalarPairwise(1, 0b01100), vectorFloat(1, 1, 0b000)),
+		"facge":   floatThreeSame(1, 0, 0b101),
+		"facgt":   floatThreeSame(1, 1, 0b101),
+		"fcmeq":   floatCompare(0, 0, 0b100, 0, 0b01101),
+		"fcmge":   floatCompare(1, 0, 0b100, 1, 0b01100),
+		"fcmgt":   floatCompare(1, 1, 0b100, 0, 0b01100),
+		"fcmle":   floatCompareZero(1, 0b01101),
+		"fcmlt":   floatCompareZero(0, 0b01110),
+		"frecps":  floatThreeSame(0, 0, 0b111),
+		"frsqrts": floatThreeSame(0, 1, 0b111),
+		"frecpe":  floatTwoReg(0, 1, 0b11101),
+		"frsqrte": floatTwoReg(1, 1, 0b11101),
+
+		"fabs":  vectorOr(encoders["fabs"], floatTwoReg(0, 1, 0b01111)),
+		"fneg":  vectorOr(encoders["fneg"], floatTwoReg(1, 1, 0b01111)),
+		"fsqrt": vectorOr(encoders["fsqrt"], floatTwoReg(1, 1, 0b11111)),
+
+		"frintn": vectorOr(encoders["frintn"], floatTwoReg(0, 0, 0b11000)),
+		"frinta": vectorOr(encoders["frinta"], floatTwoReg(1, 0, 0b11000)),
+		"frintp": vectorOr(encoders["frintp"], floatTwoReg(0, 1, 0b11000)),
+		"frintm": vectorOr(encoders["frintm"], floatTwoReg(0, 0, 0b11001)),
+		"frintx": vectorOr(encoders["frintx"], floatTwoReg(1, 0, 0b11001)),
+		"frintz": vectorOr(encoders["frintz"], floatTwoReg(0, 1, 0b11001)),
+		"frinti": vectorOr(encoders["frinti"], floatTwoReg(1, 1, 0b11001)),
+
+		"scvtf":  simdOr(encoders["scvtf"], floatTwoReg(0, 0, 0b11101)),
+		"ucvtf":  simdOr(encoders["ucvtf"], floatTwoReg(1, 0, 0b11101)),
+		"fcvtns": simdOr(encoders["fcvtns"], floatTwoReg(0, 0, 0b11010)),
+		"fcvtnu": simdOr(encoders["fcvtnu"], floatTwoReg(1, 0, 0b11010)),
+		"fcvtps": simdOr(encoders["fcvtps"], floatTwoReg(0, 1, 0b11010)),
+		"fcvtpu": simdOr(encoders["fcvtpu"], floatTwoReg(1, 1, 0b11010)),
+		"fcvtms": simdOr(encoders["fcvtms"], floatTwoReg(0, 0, 0b11011)),
+		"fcvtmu": simdOr(encoders["fcvtmu"], floatTwoReg(1, 0, 0b11011)),
+		"fcvtzs": simdOr(encoders["fcvtzs"], floatTwoReg(0, 1, 0b11011)),
+		"fcvtzu": simdOr(encoders["fcvtzu"], floatTwoReg(1, 1, 0b11011)),
+		"fcvtas": simdOr(encoders["fcvtas"], floatTwoReg(0, 0, 0b11100)),
+		"fcvtau": simdOr(encoders["fcvtau"], floatTwoReg(1, 0, 0b11100)),
+
+		"fmaxv":   floatAcross(0, 0b01111),
+		"fminv":   floatAcross(1, 0b01111),
+		"fmaxnmv": floatAcross(0, 0b01100),
+		"fminnmv": floatAcross(1, 0b01100),
+
+		"fcvtn":  floatNarrow(false),
+		"fcvtn2": floatNarrow(true),
+		"fcvtl":  floatLong(false),
+		"fcvtl2": floatLong(true),
+	} {
+		encoders[name] = enc
+	}
+}
+
+// floatSize returns the Q bit, the sz bit and whether arr has
+// half-precision elements, failing for arrangements without
+// floating-point elements.
+func floatSize(arr string) (q, sz uint32, half bool) {
+	f := arrangements[arr]
+	if f.size == 0 || arr == "1d" {
+		fail("invalid arrangement %s", arr)
+	}
+	return f.q, f.size & 1, f.size == 1
+}
+
+// vectorFloat encodes the floating-point three-same instructions, where
+// u, a (size[1]) and the low three bits of the opcode select the
+// operation. The half-precision forms have their own encoding group.
+func vectorFloat(u, a, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		rd, rn, rm, arr := e.vectorOperands()
+		q, sz, half := floatSize(arr)
+		if half {
+			return 0x0E400400 | q<<30 | u<<29 | a<<23 | rm<<16 | opcode<<11 | rn<<5 | rd
+		}
+		return threeSameWord(q, u, a<<1|sz, 0b11000|opcode, rd, rn, rm)
+	}
+}
+
+// floatThreeSame encodes a floating-point three-same instruction that
+// also has an Advanced SIMD scalar form ("fabd d0, d1, d2").
+func floatThreeSame(u, a, opcode uint32) encodeFunc {
+	vector := vectorFloat(u, a, opcode)
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if e.reg(0).kind == kindV {
+			return vector(e)
+		}
+		rd := e.fpReg(0, 0)
+		rn := e.fpReg(1, rd.kind)
+		rm := e.fpReg(2, rd.kind)
+		size := a<<1 | scalarFloatSize(rd)
+		return 0x5E200400 | u<<29 | size<<22 | rm.num<<16 | (0b11000|opcode)<<11 | rn.num<<5 | rd.num
+	}
+}
+
+// scalarFloatSize returns the sz bit of an Advanced SIMD scalar
+// floating-point operand, which has no half-precision form here.
+func scalarFloatSize(r register) uint32 {
+	if r.kind == kindH {
+		fail("expected an s or d register")
+	}
+	return fpType(r)
+}
+
+// floatTwoRegWord encodes a floating-point two-register miscellaneous
+// instruction on arrangement arr.
+func floatTwoRegWord(arr string, u, a, opcode, rd, rn uint32) uint32 {
+	q, sz, half := floatSize(arr)
+	if half {
+		return 0x0E780800 | q<<30 | u<<29 | a<<23 | opcode<<12 | rn<<5 | rd
+	}
+	return twoRegWord(q, u, a<<1|sz, opcode, rd, rn)
+}
+
+// floatTwoReg encodes the floating-point two-register operations on a
+// single arrangement.
+func floatTwoReg(u, a, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		return e.floatTwoReg(u, a, opcode)
+	}
+}
+
+// floatTwoReg encodes the first two operands as a floating-point
+// two-register operation, or its Advanced SIMD scalar form when they are
+// s or d registers.
+func (e *encoder) floatTwoReg(u, a, opcode uint32) uint32 {
+	if e.reg(0).kind != kindV {
+		rd := e.fpReg(0, 0)
+		rn := e.fpReg(1, rd.kind)
+		return 0x5E200800 | u<<29 | (a<<1|scalarFloatSize(rd))<<22 | opcode<<12 | rn.num<<5 | rd.num
+	}
+	rd, arr := e.vector(0)
+	rn, arrN := e.vector(1)
+	if arrN != arr {
+		fail("arrangement mismatch")
+	}
+	return floatTwoRegWord(arr, u, a, opcode, rd, rn)
+}
+
+// floatCompareZero encodes the floating-point compares against zero
+// ("fcmle v0.4s, v1.4s, #0.0").
+func floatCompareZero(u, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		if e.fpImm(2) != 0 {
+			fail("expected #0.0, got %s", e.ops[2])
+		}
+		return e.floatTwoReg(u, 1, opcode)
+	}
+}
+
+// floatCompare encodes a floating-point register compare, or its compare
+// against zero when the last operand is an immediate.
+func floatCompare(u, a, opcode, zeroU, zeroOpcode uint32) encodeFunc {
+	reg, zero := floatThreeSame(u, a, opcode), floatCompareZero(zeroU, zeroOpcode)
+	return func(e *encoder) uint32 {
+		if len(e.ops) == 3 && !e.isReg(2) {
+			return zero(e)
+		}
+		return reg(e)
+	}
+}
+
+// simdOr encodes with simd when the first two operands are SIMD&FP
+// registers (the vector and Advanced SIMD scalar forms), and with gp
+// otherwise.
+func simdOr(gp, simd encodeFunc) encodeFunc {
+	return func(e *encoder) uint32 {
+		if len(e.ops) == 2 && e.isReg(0) && e.isReg(1) && isSIMDReg(e.reg(0)) && isSIMDReg(e.reg(1)) {
+			return simd(e)
+		}
+		return gp(e)
+	}
+}
+
+// isSIMDReg reports whether r is an h, s, d or vector register.
+func isSIMDReg(r register) bool {
+	switch r.kind {
+	case kindH, kindS, kindD, kindV:
+		return true
+	}
+	return false
+}
+
+// elementOr encodes with elem when the last operand is a vector element
+// (the by element forms), and with vec otherwise.
+func elementOr(vec, elem encodeFunc) encodeFunc {
+	return func(e *encoder) uint32 {
+		if len(e.ops) == 3 && e.isReg(2) && e.reg(2).lane >= 0 {
+			return elem(e)
+		}
+		return vec(e)
+	}
+}
+
+// byElement encodes FMLA, FMLS and FMUL (by element), on a vector
+// ("fmla v0.4s, v1.4s, v2.s[1]") or, in the scalar form, on element 0
+// ("fmla s0, s1, v2.s[1]"). The index is split across H, L and M;
+// half-precision elements come from V0-V15.
+func byElement(opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(3, 3)
+		var rd, rn, q, elemSize uint32
+		word := uint32(0x0F000000)
+		if e.reg(0).kind == kindV {
+			var arr, arrN string
+			rd, arr = e.vector(0)
+			rn, arrN = e.vector(1)
+			if arrN != arr {
+				fail("arrangement mismatch")
+			}
+			q, _, _ = floatSize(arr)
+			elemSize = arrangements[arr].size
+		} else {
+			d := e.fpReg(0, 0)
+			rd, rn, elemSize = d.num, e.fpReg(1, d.kind).num, [4]uint32{2, 3, 0, 1}[fpType(d)]
+			word = 0x5F000000
+		}
+		m := e.reg(2)
+		if size, ok := elementSizes[m.arr]; m.kind != kindV || !ok || size != elemSize {
+			fail("invalid element %s", e.ops[2])
+		}
+		lane := uint32(m.lane)
+
+		var size, h, l, rm uint32
+		switch {
+		case elemSize == 1:
+			if m.num >= 16 || lane >= 8 {
+				fail("invalid element %s", e.ops[2])
+			}
+			size, h, l, rm = 0b00, lane>>2, lane>>1&1, lane&1<<4|m.num
+		case elemSize == 2:
+			if lane >= 4 {
+				fail("lane index out of range in %s", e.ops[2])
+			}
+			size, h, l, rm = 0b10, lane>>1, lane&1, m.num
+		case q == 0 && word == 0x0F000000:
+			fail("invalid arrangement %s", e.ops[0])
+		default:
+			if lane >= 2 {
+				fail("lane index out of range in %s", e.ops[2])
+			}
+			size, h, rm = 0b11, lane, m.num
+		}
+		return word | q<<30 | size<<22 | l<<21 | rm<<16 | opcode<<12 | h<<11 | rn<<5 | rd
+	}
+}
+
+// scalarPairwise encodes the scalar pairwise forms of FADDP, FMAXP,
+// FMINP, FMAXNMP and FMINNMP ("faddp s0, v1.2s").
+func scalarPairwise(a, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd := e.fpReg(0, 0)
+		rn, arr := e.vector(1)
+		sz := fpType(rd)
+		if want := [2]string{"2s", "2d"}; sz > 1 || arr != want[sz] {
+			fail("invalid arrangement %s", arr)
+		}
+		return 0x7E300800 | a<<23 | sz<<22 | opcode<<12 | rn<<5 | rd.num
+	}
+}
+
+// floatAcross encodes FMAXV, FMINV, FMAXNMV and FMINNMV, which reduce 4H,
+// 8H or 4S vectors.
+func floatAcross(a, opcode uint32) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		rd := e.fpReg(0, 0)
+		rn, arr := e.vector(1)
+		var u uint32
+		switch {
+		case arr == "4s" && rd.kind == kindS:
+			u = 1
+		case (arr == "4h" || arr == "8h") && rd.kind == kindH:
+		default:
+			fail("invalid arrangement %s", arr)
+		}
+		return 0x0E300800 | arrangements[arr].q<<30 | u<<29 | a<<23 | opcode<<12 | rn<<5 | rd.num
+	}
+}
+
+// floatNarrow encodes FCVTN and FCVTN2, which convert single to half or
+// double to single precision.
+func floatNarrow(upper bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		size := e.narrowOperands(0, 1, upper)
+		if size == 0 {
+			fail("invalid arrangement %s", e.ops[0])
+		}
+		rd, _ := e.vector(0)
+		rn, _ := e.vector(1)
+		return twoRegWord(sf(upper), 0, size-1, 0b10110, rd, rn)
+	}
+}
+
+// floatLong encodes FCVTL and FCVTL2, which convert half to single or
+// single to double precision.
+func floatLong(upper bool) encodeFunc {
+	return func(e *encoder) uint32 {
+		e.want(2, 2)
+		size := e.narrowOperands(1, 0, upper)
+		if size == 0 {
+			fail("invalid arrangement %s", e.ops[1])
+		}
+		rd, _ := e.vector(0)
+		rn, _ := e.vector(1)
+		return twoRegWord(sf(upper), 0, size-1, 0b10111, rd, rn)
+	}
+}
+
+// vectorFMOV encodes FMOV (vector, immediate).
+func (e *encoder) vectorFMOV() uint32 {
+	rd, arr := e.vector(0)
+	q, sz, half := floatSize(arr)
+	imm8, ok := fpImm8(e.fpImm(1))
+	if !ok {
+		fail("%s cannot be encoded as an FMOV immediate", e.ops[1])
+	}
+	switch {
+	case half:
+		return modImmWord(q, 0, 0b1111, imm8, rd) | 1<<11
+	case sz == 1 && q == 0:
+		fail("invalid arrangement %s", arr)
+	}
+	return modImmWord(q, sz, 0b1111, imm8, rd)
+}
@@ -0,0 +1,499 @@
+package asm
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// encoder encodes one instruction statement.
+type encoder struct {
+	a     *assembler
+	s     *statement
+	index int
+	ops   []string
+}
+
+// pc returns the address of the instruction.
+func (e *encoder) pc() uint64 {
+	return e.s.addr
+}
+
+// want checks that the instruction has between min and max operands.
+func (e *encoder) want(min, max int) {
+	n := len(e.ops)
+	switch {
+	case n >= min && n <= max:
+	case min == max:
+		fail("expected %d operands, got %d", min, n)
+	default:
+		fail("expected %d to %d operands, got %d", min, max, n)
+	}
+}
+
+// register kinds.
+const (
+	kindX = 'x' // 64-bit general-purpose register
+	kindW = 'w' // 32-bit general-purpose register
+	kindB = 'b' // 8-bit SIMD&FP register
+	kindH = 'h' // 16-bit SIMD&FP register
+	kindS = 's' // 32-bit SIMD&FP register
+	kindD = 'd' // 64-bit SIMD&FP register
+	kindQ = 'q' // 128-bit SIMD&FP register
+	kindV = 'v' // Vector register with an arrangement or a lane
+)
+
+// register is a parsed register operand.
+type register struct {
+	kind byte
+	num  uint32
+	sp   bool   // SP or WSP rather than XZR or WZR
+	arr  string // Arrangement ("4s") or lane element size ("d") of a vector
+	lane int    // Lane index, or -1
+}
+
+// is64 reports whether r is a 64-bit general-purpose register.
+func (r register) is64() bool {
+	return r.kind == kindX
+}
+
+var (
+	registerPattern = regexp.MustCompile(`^([xwbhsdq])([0-9]+)$`)
+	vectorPattern   = regexp.MustCompile(`^v([0-9]+)\.([0-9]*[bhsdq])(?:\[([0-9]+)\])?$`)
+)
+
+// parseRegister parses a register name.
+func parseRegister(text string) (register, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	switch text {
+	case "sp":
+		return register{kind: kindX, num: 31, sp: true, lane: -1}, true
+	case "wsp":
+		return register{kind: kindW, num: 31, sp: true, lane: -1}, true
+	case "xzr":
+		return register{kind: kindX, num: 31, lane: -1}, true
+	case "wzr":
+		return register{kind: kindW, num: 31, lane: -1}, true
+	case "fp":
+		return register{kind: kindX, num: 29, lane: -1}, true
+	case "lr":
+		return register{kind: kindX, num: 30, lane: -1}, true
+	}
+	if m := registerPattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		gp := m[1] == "x" || m[1] == "w"
+		if n > 31 || (gp && n == 31) {
+			return register{}, false
+		}
+		return register{kind: m[1][0], num: uint32(n), lane: -1}, true
+	}
+	if m := vectorPattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n > 31 {
+			return register{}, false
+		}
+		r := register{kind: kindV, num: uint32(n), arr: m[2], lane: -1}
+		if m[3] != "" {
+			r.lane, _ = strconv.Atoi(m[3])
+		}
+		return r, true
+	}
+	return register{}, false
+}
+
+// reg parses operand i as any register.
+func (e *encoder) reg(i int) register {
+	r, ok := parseRegister(e.ops[i])
+	if !ok {
+		fail("expected a register, got %s", e.ops[i])
+	}
+	return r
+}
+
+// isReg reports whether operand i is a register.
+func (e *encoder) isReg(i int) bool {
+	if i >= len(e.ops) {
+		return false
+	}
+	_, ok := parseRegister(e.ops[i])
+	return ok
+}
+
+// gpReg parses operand i as a general-purpose register, where register 31
+// is the stack pointer if sp is set and the zero register otherwise.
+func (e *encoder) gpReg(i int, sp bool) register {
+	r := e.reg(i)
+	if r.kind != kindX && r.kind != kindW {
+		fail("expected a general-purpose register, got %s", e.ops[i])
+	}
+	if r.num == 31 && r.sp != sp {
+		fail("%s is not allowed here", e.ops[i])
+	}
+	return r
+}
+
+// gp parses operand i as a general-purpose or zero register and returns its
+// number and width.
+func (e *encoder) gp(i int) (uint32, bool) {
+	r := e.gpReg(i, false)
+	return r.num, r.is64()
+}
+
+// gpSP parses operand i as a general-purpose register or the stack pointer.
+func (e *encoder) gpSP(i int) (uint32, bool) {
+	r := e.gpReg(i, true)
+	return r.num, r.is64()
+}
+
+// sameWidth parses operand i as a general-purpose or zero register of the
+// given width.
+func (e *encoder) sameWidth(i int, is64 bool) uint32 {
+	n, w := e.gp(i)
+	if w != is64 {
+		fail("register width mismatch: %s", e.ops[i])
+	}
+	return n
+}
+
+// x64 parses operand i as a 64-bit general-purpose or zero register.
+func (e *encoder) x64(i int) uint32 {
+	return e.sameWidth(i, true)
+}
+
+// immText strips the optional "#" from an immediate operand.
+func immText(op string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(op), "#"))
+}
+
+// imm evaluates operand i as an immediate expression.
+func (e *encoder) imm(i int) int64 {
+	v, err := e.a.eval(immText(e.ops[i]), e.index, e.pc())
+	if err != nil {
+		fail("%v", err)
+	}
+	return v
+}
+
+// uimm evaluates operand i as an unsigned immediate of at most bits bits.
+func (e *encoder) uimm(i int, bits uint) uint32 {
+	v := e.imm(i)
+	if v < 0 || uint64(v) >= 1<<bits {
+		fail("immediate %s out of range", e.ops[i])
+	}
+	return uint32(v)
+}
+
+// fpImm parses operand i as a floating-point immediate.
+func (e *encoder) fpImm(i int) float64 {
+	v, err := strconv.ParseFloat(immText(e.ops[i]), 64)
+	if err != nil {
+		fail("expected a floating-point immediate, got %s", e.ops[i])
+	}
+	return v
+}
+
+// target evaluates operand i as a branch or PC-relative target. A target
+// written as an immediate ("#0x10") is an offset from the instruction.
+func (e *encoder) target(i int) int64 {
+	op := strings.TrimSpace(e.ops[i])
+	if strings.HasPrefix(op, "#") {
+		return int64(e.pc()) + e.imm(i)
+	}
+	target, err := e.a.eval(op, e.index, e.pc())
+	if err != nil {
+		fail("%v", err)
+	}
+	return target
+}
+
+// offset returns the distance from the instruction to the target in operand
+// i, checking that it is a multiple of align and fits in a signed field of
+// bits bits once scaled.
+func (e *encoder) offset(i int, bits uint, align int64) uint32 {
+	off := e.target(i) - int64(e.pc())
+	if off%align != 0 {
+		fail("misaligned target %s", e.ops[i])
+	}
+	return signedField(off/align, bits, e.ops[i])
+}
+
+// signedField checks that v fits in a signed field of bits bits and returns
+// its two's-complement encoding.
+func signedField(v int64, bits uint, text string) uint32 {
+	if v < -(1<<(bits-1)) || v >= 1<<(bits-1) {
+		fail("%s out of range", text)
+	}
+	return uint32(v) & (1<<bits - 1)
+}
+
+// condCodes maps condition names, including the HS and LO aliases, to their
+// encodings.
+var condCodes = map[string]uint32{
+	"eq": 0, "ne": 1, "cs": 2, "hs": 2, "cc": 3, "lo": 3, "mi": 4, "pl": 5,
+	"vs": 6, "vc": 7, "hi": 8, "ls": 9, "ge": 10, "lt": 11, "gt": 12, "le": 13,
+	"al": 14, "nv": 15,
+}
+
+// cond parses operand i as a condition.
+func (e *encoder) cond(i int) uint32 {
+	c, ok := condCodes[strings.ToLower(e.ops[i])]
+	if !ok {
+		fail("expected a condition, got %s", e.ops[i])
+	}
+	return c
+}
+
+// shiftTypes maps shift names to their encodings.
+var shiftTypes = map[string]uint32{"lsl": 0, "lsr": 1, "asr": 2, "ror": 3}
+
+// extendTypes maps extend names to their option encodings.
+var extendTypes = map[string]uint32{
+	"uxtb": 0, "uxth": 1, "uxtw": 2, "uxtx": 3,
+	"sxtb": 4, "sxth": 5, "sxtw": 6, "sxtx": 7,
+}
+
+// modifier parses operand i as a shift or extend ("lsl #3", "sxtw") and
+// returns its name, amount and whether the amount was given.
+func (e *encoder) modifier(i int) (string, int64, bool) {
+	name, amount, _ := strings.Cut(strings.TrimSpace(e.ops[i]), " ")
+	name = strings.ToLower(name)
+	_, shift := shiftTypes[name]
+	_, extend := extendTypes[name]
+	if !shift && !extend {
+		fail("expected a shift or extend, got %s", e.ops[i])
+	}
+	if strings.TrimSpace(amount) == "" {
+		if shift {
+			fail("missing shift amount in %s", e.ops[i])
+		}
+		return name, 0, false
+	}
+	v, err := e.a.constant(amount)
+	if err != nil {
+		fail("%v", err)
+	}
+	return name, v, true
+}
+
+// isModifier reports whether operand i is a shift or extend.
+func (e *encoder) isModifier(i int) bool {
+	if i >= len(e.ops) {
+		return false
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(e.ops[i]), " ")
+	_, shift := shiftTypes[strings.ToLower(name)]
+	_, extend := extendTypes[strings.ToLower(name)]
+	return shift || extend
+}
+
+// Addressing modes.
+const (
+	addrOffset = iota // [Xn{, #imm}]
+	addrPre           // [Xn, #imm]!
+	addrPost          // [Xn], #imm
+	addrReg           // [Xn, Xm{, extend {#amount}}]
+)
+
+// address is a parsed memory operand.
+type address struct {
+	mode      int
+	base      uint32
+	imm       int64
+	rm        register
+	extend    string // "lsl", "uxtw", "sxtw" or "sxtx" for register offsets
+	amount    int64
+	hasAmount bool
+}
+
+// address parses the memory operand starting at operand i, including the
+// post-index immediate that follows it.
+func (e *encoder) address(i int) address {
+	op := strings.TrimSpace(e.ops[i])
+	var addr address
+	if strings.HasSuffix(op, "!") {
+		addr.mode = addrPre
+		op = strings.TrimSpace(strings.TrimSuffix(op, "!"))
+	}
+	if !strings.HasPrefix(op, "[") || !strings.HasSuffix(op, "]") {
+		fail("expected a memory operand, got %s", e.ops[i])
+	}
+	parts := splitOperands(op[1 : len(op)-1])
+	if len(parts) == 0 || len(parts) > 3 {
+		fail("invalid memory operand %s", e.ops[i])
+	}
+	sub := &encoder{a: e.a, s: e.s, index: e.index, ops: parts}
+	base := sub.gpReg(0, true)
+	addr.base = base.num
+	if !base.is64() {
+		fail("base register must be 64-bit: %s", e.ops[i])
+	}
+
+	switch {
+	case len(parts) == 1:
+	case sub.isReg(1):
+		if addr.mode == addrPre {
+			fail("invalid memory operand %s", e.ops[i])
+		}
+		addr.mode = addrReg
+		addr.rm = sub.gpReg(1, false)
+		addr.extend = "lsl"
+		if len(parts) == 3 {
+			addr.extend, addr.amount, addr.hasAmount = sub.modifier(2)
+		}
+		if addr.rm.is64() != (addr.extend == "lsl" || addr.extend == "sxtx") {
+			fail("invalid offset register in %s", e.ops[i])
+		}
+		if _, ok := map[string]bool{"lsl": true, "uxtw": true, "sxtw": true, "sxtx": true}[addr.extend]; !ok {
+			fail("invalid extend in %s", e.ops[i])
+		}
+	case len(parts) == 2:
+		addr.imm = sub.imm(1)
+	default:
+		fail("invalid memory operand %s", e.ops[i])
+	}
+
+	if i+1 < len(e.ops) {
+		if addr.mode != addrOffset || len(parts) != 1 {
+			fail("invalid post-index operand %s", e.ops[i+1])
+		}
+		addr.mode = addrPost
+		addr.imm = e.imm(i + 1)
+	}
+	return addr
+}
+
+// fpType returns the ftype field for a scalar floating-point register.
+func fpType(r register) uint32 {
+	switch r.kind {
+	case kindS:
+		return 0
+	case kindD:
+		return 1
+	case kindH:
+		return 3
+	}
+	fail("expected an h, s or d register")
+	return 0
+}
+
+// fpReg parses operand i as a scalar floating-point register of the same
+// precision as ref, or any precision if ref is zero.
+func (e *encoder) fpReg(i int, ref byte) register {
+	r := e.reg(i)
+	if r.kind != kindH && r.kind != kindS && r.kind != kindD {
+		fail("expected a floating-point register, got %s", e.ops[i])
+	}
+	if ref != 0 && r.kind != ref {
+		fail("register precision mismatch: %s", e.ops[i])
+	}
+	return r
+}
+
+// fpImm8 encodes value as an 8-bit floating-point immediate.
+func fpImm8(value float64) (uint32, bool) {
+	var sign uint32
+	if math.Signbit(value) {
+		sign, value = 1, -value
+	}
+	for exp := -3; exp <= 4; exp++ {
+		for frac := 0; frac < 16; frac++ {
+			if math.Ldexp(float64(16+frac)/16, exp) != value {
+				continue
+			}
+			var b, cd uint32
+			if exp <= 0 {
+				b, cd = 1, uint32(exp+3)
+			} else {
+				cd = uint32(exp - 1)
+			}
+			return sign<<7 | b<<6 | cd<<4 | uint32(frac), true
+		}
+	}
+	return 0, false
+}
+
+// vector parses operand i as a vector register with an arrangement.
+func (e *encoder) vector(i int) (uint32, string) {
+	r := e.reg(i)
+	if r.kind != kindV || r.lane >= 0 {
+		fail("expected a vector register, got %s", e.ops[i])
+	}
+	if _, ok := arrangements[r.arr]; !ok {
+		fail("invalid arrangement in %s", e.ops[i])
+	}
+	return r.num, r.arr
+}
+
+// arrangements maps arrangement specifiers to their Q and size fields.
+var arrangements = map[string]struct{ q, size uint32 }{
+	"8b": {0, 0}, "16b": {1, 0}, "4h": {0, 1}, "8h": {1, 1},
+	"2s": {0, 2}, "4s": {1, 2}, "1d": {0, 3}, "2d": {1, 3},
+}
+
+// regList is a parsed vector register list.
+type regList struct {
+	first uint32
+	count int
+	arr   string // Arrangement, or the element size of a lane list
+	lane  int    // Lane index, or -1
+}
+
+// regList parses operand i as a list of consecutive vector registers, such
+// as "{v0.4s, v1.4s}", "{v0.16b-v3.16b}" or "{v0.s, v1.s}[1]". Register
+// numbers wrap from v31 to v0.
+func (e *encoder) regList(i int) regList {
+	op := strings.TrimSpace(e.ops[i])
+	end := strings.Index(op, "}")
+	if !strings.HasPrefix(op, "{") || end < 0 {
+		fail("expected a register list, got %s", e.ops[i])
+	}
+	list := regList{lane: -1}
+	if index := strings.TrimSpace(op[end+1:]); index != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(index, "["), "]"))
+		if err != nil || !strings.HasPrefix(index, "[") || !strings.HasSuffix(index, "]") {
+			fail("invalid lane index in %s", e.ops[i])
+		}
+		list.lane = n
+	}
+
+	var regs []register
+	for _, item := range splitOperands(op[1:end]) {
+		lo, hi, isRange := strings.Cut(item, "-")
+		sub := &encoder{a: e.a, s: e.s, index: e.index, ops: []string{lo, hi}}
+		r := sub.reg(0)
+		regs = append(regs, r)
+		if isRange {
+			last := sub.reg(1)
+			for n := (r.num + 1) % 32; n != (last.num+1)%32 && len(regs) <= 4; n = (n + 1) % 32 {
+				regs = append(regs, register{kind: last.kind, num: n, arr: last.arr, lane: last.lane})
+			}
+		}
+	}
+	for k, r := range regs {
+		switch {
+		case r.kind != kindV || r.lane >= 0:
+			fail("expected a vector register in %s", e.ops[i])
+		case r.arr != regs[0].arr:
+			fail("arrangement mismatch in %s", e.ops[i])
+		case r.num != (regs[0].num+uint32(k))%32:
+			fail("registers in %s must be consecutive", e.ops[i])
+		}
+	}
+	if len(regs) == 0 || len(regs) > 4 {
+		fail("expected 1 to 4 registers in %s", e.ops[i])
+	}
+	list.first, list.count, list.arr = regs[0].num, len(regs), regs[0].arr
+	return list
+}
+
+// sysReg parses operand i as a system register name.
+func (e *encoder) sysReg(i int) uint32 {
+	enc, ok := insts.ParseSysReg(e.ops[i])
+	if !ok {
+		fail("unknown system register %s", e.ops[i])
+	}
+	return uint32(enc)
+}
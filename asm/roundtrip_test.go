@@ -0,0 +1,680 @@
+package asm_test
+
+import (
+	"encoding/binary"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/asm"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// forms maps one instance of every instruction form the decoder supports, as
+// printed by the disassembler for an instruction at 0x1000, to its encoding.
+var forms = map[string]uint32{
+	"add x0, x1, #0x10":                   0x91004020,
+	"add x0, x1, #0x1, lsl #12":           0x91400420,
+	"adds w0, w1, #0x3":                   0x31000c20,
+	"sub sp, sp, #0x20":                   0xd10083ff,
+	"cmp x0, #0x5":                        0xf100141f,
+	"cmn w1, #0x1":                        0x3100043f,
+	"mov x29, sp":                         0x910003fd,
+	"mov sp, x1":                          0x9100003f,
+	"add x0, x1, x2":                      0x8b020020,
+	"add x0, x1, x2, lsl #3":              0x8b020c20,
+	"subs x0, x1, x2, asr #2":             0xeb820820,
+	"cmp x0, x1":                          0xeb01001f,
+	"cmn w0, w1, lsl #2":                  0x2b01081f,
+	"neg x0, x1":                          0xcb0103e0,
+	"negs w0, w1":                         0x6b0103e0,
+	"and x0, x1, x2":                      0x8a020020,
+	"ands x0, x1, x2":                     0xea020020,
+	"tst x0, x1":                          0xea01001f,
+	"bic w0, w1, w2, lsr #4":              0x0a621020,
+	"bics x0, x1, x2":                     0xea220020,
+	"orr x0, x1, x2":                      0xaa020020,
+	"mov x0, x1":                          0xaa0103e0,
+	"mov w0, w1":                          0x2a0103e0,
+	"mvn x0, x1":                          0xaa2103e0,
+	"orn x0, x1, x2":                      0xaa220020,
+	"eor x0, x1, x2, ror #5":              0xcac21420,
+	"eon x0, x1, x2":                      0xca220020,
+	"and x0, x1, #0xff":                   0x92401c20,
+	"ands w0, w1, #0xf0":                  0x721c0c20,
+	"tst x0, #0x1":                        0xf240001f,
+	"orr x0, x1, #0xffff0000":             0xb2703c20,
+	"eor sp, x1, #0x1":                    0xd240003f,
+	"mov x0, #0x2a":                       0xd2800540,
+	"mov w0, #0x10000":                    0x52a00020,
+	"movz x0, #0x0, lsl #16":              0xd2a00000,
+	"mov x0, #0xffffffffffffffff":         0x92800000,
+	"mov w0, #0xfffeffff":                 0x12a00020,
+	"movn w0, #0xffff":                    0x129fffe0,
+	"movk x0, #0x1234, lsl #16":           0xf2a24680,
+	"movk w0, #0x1":                       0x72800020,
+	"asr x0, x1, #3":                      0x9343fc20,
+	"sxtb x0, w1":                         0x93401c20,
+	"sxth w0, w1":                         0x13003c20,
+	"sxtw x0, w1":                         0x93407c20,
+	"sbfiz x0, x1, #4, #8":                0x937c1c20,
+	"sbfx x0, x1, #4, #8":                 0x93442c20,
+	"lsl x0, x1, #3":                      0xd37df020,
+	"lsl w0, w1, #31":                     0x53010020,
+	"lsr w0, w1, #5":                      0x53057c20,
+	"uxtb w0, w1":                         0x53001c20,
+	"uxth w0, w1":                         0x53003c20,
+	"ubfiz x0, x1, #2, #10":               0xd37e2420,
+	"ubfx x0, x1, #4, #8":                 0xd3442c20,
+	"bfi x0, x1, #8, #4":                  0xb3780c20,
+	"bfxil w0, w1, #3, #5":                0x33031c20,
+	"bfc x0, #8, #4":                      0xb3780fe0,
+	"extr x0, x1, x2, #12":                0x93c23020,
+	"ror x0, x1, #12":                     0x93c13020,
+	"adr x0, 0x1040":                      0x10000200,
+	"adrp x0, 0x4000":                     0xf0000000,
+	"b 0x1100":                            0x14000040,
+	"bl 0xff8":                            0x97fffffe,
+	"b.ne 0x1020":                         0x54000101,
+	"b.eq 0xffc":                          0x54ffffe0,
+	"br x16":                              0xd61f0200,
+	"blr x8":                              0xd63f0100,
+	"ret":                                 0xd65f03c0,
+	"ret x1":                              0xd65f0020,
+	"cbz x0, 0x1010":                      0xb4000080,
+	"cbnz w3, 0xff0":                      0x35ffff83,
+	"tbz w0, #3, 0x1008":                  0x36180040,
+	"tbnz x0, #40, 0x1008":                0xb7400040,
+	"svc #0x0":                            0xd4000001,
+	"brk #0x3e8":                          0xd4207d00,
+	"csel x0, x1, x2, eq":                 0x9a820020,
+	"csinc x0, x1, x2, ne":                0x9a821420,
+	"cset w0, eq":                         0x1a9f17e0,
+	"cinc x0, x1, lt":                     0x9a81a420,
+	"csetm x0, hi":                        0xda9f93e0,
+	"cinv w0, w1, ge":                     0x5a81b020,
+	"csinv x0, x1, x2, ge":                0xda82a020,
+	"cneg x0, x1, mi":                     0xda815420,
+	"csneg x0, x1, x2, le":                0xda82d420,
+	"ccmp x0, #0x3, #0x4, ne":             0xfa431804,
+	"ccmn w0, w1, #0x0, eq":               0x3a410000,
+	"udiv x0, x1, x2":                     0x9ac20820,
+	"sdiv w0, w1, w2":                     0x1ac20c20,
+	"lsl x0, x1, x2":                      0x9ac22020,
+	"lsr x0, x1, x2":                      0x9ac22420,
+	"asr w0, w1, w2":                      0x1ac22820,
+	"ror x0, x1, x2":                      0x9ac22c20,
+	"mul x0, x1, x2":                      0x9b027c20,
+	"mneg x0, x1, x2":                     0x9b02fc20,
+	"madd x0, x1, x2, x3":                 0x9b020c20,
+	"msub w0, w1, w2, w3":                 0x1b028c20,
+	"ldr x0, [x1]":                        0xf9400020,
+	"ldr x0, [x1, #16]":                   0xf9400820,
+	"ldr w0, [sp, #4]":                    0xb94007e0,
+	"str x0, [sp, #8]":                    0xf90007e0,
+	"ldrsw x0, [x1, #4]":                  0xb9800420,
+	"ldr x0, [x1, #8]!":                   0xf8408c20,
+	"str x0, [sp, #-16]!":                 0xf81f0fe0,
+	"ldr x0, [x1], #8":                    0xf8408420,
+	"ldrb w0, [x1], #1":                   0x38401420,
+	"strb w0, [x1, #-1]!":                 0x381ffc20,
+	"ldrsb x0, [x1], #1":                  0x38801420,
+	"ldrsh w0, [x1], #2":                  0x78c02420,
+	"strh w0, [x1], #2":                   0x78002420,
+	"ldur x0, [x1, #-8]":                  0xf85f8020,
+	"sturb w0, [x1, #3]":                  0x38003020,
+	"ldursw x0, [x1, #-4]":                0xb89fc020,
+	"ldr x0, [x1, x2]":                    0xf8626820,
+	"ldr x0, [x1, x2, lsl #3]":            0xf8627820,
+	"ldr w0, [x1, w2, uxtw]":              0xb8624820,
+	"ldr w0, [x1, w2, sxtw #2]":           0xb862d820,
+	"ldrb w0, [x1, x2]":                   0x38626820,
+	"strh w0, [x1, x2, lsl #1]":           0x78227820,
+	"ldrsw x0, [x1, x2, sxtx #2]":         0xb8a2f820,
+	"ldr x0, 0x1040":                      0x58000200,
+	"ldr w0, 0xffc":                       0x18ffffe0,
+	"ldr d0, 0x1008":                      0x5c000040,
+	"ldp x29, x30, [sp], #16":             0xa8c17bfd,
+	"stp x29, x30, [sp, #-32]!":           0xa9be7bfd,
+	"ldp w0, w1, [x2, #8]":                0x29410440,
+	"stp x0, x1, [x2]":                    0xa9000440,
+	"ldtr x0, [x1, #-8]":                  0xf85f8820,
+	"sttrh w0, [x1, #-2]":                 0x781fe820,
+	"ldtrsb x7, [x8, #255]":               0x388ff907,
+	"prfm pldl1keep, [x0]":                0xf9800000,
+	"prfm plil3keep, [x2, x3, lsl #3]":    0xf8a3784c,
+	"prfum pldl2keep, [x0, #-8]":          0xf89f8002,
+	"prfm pldl1strm, 0x1010":              0xd8000081,
+	"ldrsw x0, 0x1008":                    0x98000040,
+	"ldr q1, 0x1020":                      0x9c000101,
+	"ldnp x0, x1, [sp, #16]":              0xa84107e0,
+	"stnp d2, d3, [x5]":                   0x6c000ca2,
+	"ldpsw x0, x1, [x2], #8":              0x68c10440,
+	"ldp s0, s1, [x0, #4]":                0x2d408400,
+	"stp d0, d1, [sp, #-16]!":             0x6dbf07e0,
+	"ldr b0, [x1, #1]":                    0x3d400420,
+	"str h1, [x2, #-2]!":                  0x7c1fec41,
+	"ldr d3, [x4, x5, lsl #3]":            0xfc657883,
+	"ldur q0, [x1, #-16]":                 0x3cdf0020,
+	"ldrsh w0, [x1, #2]":                  0x79c00420,
+	"add v0.4s, v1.4s, v2.4s":             0x4ea28420,
+	"sub v0.8b, v1.8b, v2.8b":             0x2e228420,
+	"mul v0.8h, v1.8h, v2.8h":             0x4e629c20,
+	"ldr q0, [x1, #32]":                   0x3dc00820,
+	"str q1, [sp]":                        0x3d8003e1,
+	"ld1 { v0.16b, v1.16b }, [x0], #32":   0x4cdfa000,
+	"ld3 { v0.4s, v1.4s, v2.4s }, [x1]":   0x4c404820,
+	"st1 { v5.2d, v6.2d }, [x9], x10":     0x4c8aad25,
+	"ld1 { v31.1d, v0.1d, v1.1d }, [x2]":  0x0c406c5f,
+	"ld1 { v2.s }[3], [x3], x4":           0x4dc49062,
+	"st2 { v0.h, v1.h }[7], [sp]":         0x4d205be0,
+	"ld2 { v0.d, v1.d }[1], [x0], #16":    0x4dff8400,
+	"ld1r { v0.4s }, [x0]":                0x4d40c800,
+	"ld3r { v0.1d, v1.1d, v2.1d }, [x0]":  0x0d40ec00,
+	"dup v0.4s, w1":                       0x4e040c20,
+	"dup v0.2d, x1":                       0x4e080c20,
+	"dup v0.16b, w2":                      0x4e010c40,
+	"sqadd v0.16b, v1.16b, v2.16b":        0x4e220c20,
+	"uqadd v0.2d, v1.2d, v2.2d":           0x6ee20c20,
+	"sqsub v0.4h, v1.4h, v2.4h":           0x0e622c20,
+	"uqsub v0.4s, v1.4s, v2.4s":           0x6ea22c20,
+	"cmeq v0.4s, v1.4s, v2.4s":            0x6ea28c20,
+	"cmgt v0.2d, v1.2d, v2.2d":            0x4ee23420,
+	"cmge v0.8h, v1.8h, v2.8h":            0x4e623c20,
+	"cmhi v0.8b, v1.8b, v2.8b":            0x2e223420,
+	"cmhs v0.2s, v1.2s, v2.2s":            0x2ea23c20,
+	"cmtst v0.16b, v1.16b, v2.16b":        0x4e228c20,
+	"smax v0.4s, v1.4s, v2.4s":            0x4ea26420,
+	"umax v0.8h, v1.8h, v2.8h":            0x6e626420,
+	"smin v0.16b, v1.16b, v2.16b":         0x4e226c20,
+	"umin v0.2s, v1.2s, v2.2s":            0x2ea26c20,
+	"sabd v0.4s, v1.4s, v2.4s":            0x4ea27420,
+	"uabd v0.16b, v1.16b, v2.16b":         0x6e227420,
+	"saba v0.8h, v1.8h, v2.8h":            0x4e627c20,
+	"uaba v0.4s, v1.4s, v2.4s":            0x6ea27c20,
+	"mla v0.4s, v1.4s, v2.4s":             0x4ea29420,
+	"mls v0.8h, v1.8h, v2.8h":             0x6e629420,
+	"addp v0.2d, v1.2d, v2.2d":            0x4ee2bc20,
+	"smaxp v0.4s, v1.4s, v2.4s":           0x4ea2a420,
+	"umaxp v0.16b, v1.16b, v2.16b":        0x6e22a420,
+	"sminp v0.8h, v1.8h, v2.8h":           0x4e62ac20,
+	"uminp v0.2s, v1.2s, v2.2s":           0x2ea2ac20,
+	"and v0.16b, v1.16b, v2.16b":          0x4e221c20,
+	"bic v0.8b, v1.8b, v2.8b":             0x0e621c20,
+	"orr v0.16b, v1.16b, v2.16b":          0x4ea21c20,
+	"mov v0.16b, v1.16b":                  0x4ea11c20,
+	"orn v0.16b, v1.16b, v2.16b":          0x4ee21c20,
+	"eor v0.8b, v1.8b, v2.8b":             0x2e221c20,
+	"bsl v0.16b, v1.16b, v2.16b":          0x6e621c20,
+	"bit v0.16b, v1.16b, v2.16b":          0x6ea21c20,
+	"bif v0.8b, v1.8b, v2.8b":             0x2ee21c20,
+	"cmeq v0.4s, v1.4s, #0":               0x4ea09820,
+	"cmgt v0.8h, v1.8h, #0":               0x4e608820,
+	"cmge v0.2d, v1.2d, #0":               0x6ee08820,
+	"cmle v0.16b, v1.16b, #0":             0x6e209820,
+	"cmlt v0.2s, v1.2s, #0":               0x0ea0a820,
+	"abs v0.4s, v1.4s":                    0x4ea0b820,
+	"neg v0.2d, v1.2d":                    0x6ee0b820,
+	"mvn v0.16b, v1.16b":                  0x6e205820,
+	"cnt v0.8b, v1.8b":                    0x0e205820,
+	"xtn v0.8b, v1.8h":                    0x0e212820,
+	"xtn2 v0.8h, v1.4s":                   0x4e612820,
+	"sqxtn v0.2s, v1.2d":                  0x0ea14820,
+	"sqxtn2 v0.16b, v1.8h":                0x4e214820,
+	"uqxtn v0.4h, v1.4s":                  0x2e614820,
+	"sqxtun v0.8b, v1.8h":                 0x2e212820,
+	"saddl v0.8h, v1.8b, v2.8b":           0x0e220020,
+	"uaddl2 v0.4s, v1.8h, v2.8h":          0x6e620020,
+	"saddw v0.2d, v1.2d, v2.2s":           0x0ea21020,
+	"uaddw2 v0.8h, v1.8h, v2.16b":         0x6e221020,
+	"ssubl v0.4s, v1.4h, v2.4h":           0x0e622020,
+	"usubl v0.2d, v1.2s, v2.2s":           0x2ea22020,
+	"ssubw2 v0.4s, v1.4s, v2.8h":          0x4e623020,
+	"usubw v0.8h, v1.8h, v2.8b":           0x2e223020,
+	"sabal v0.4s, v1.4h, v2.4h":           0x0e625020,
+	"uabal2 v0.8h, v1.16b, v2.16b":        0x6e225020,
+	"sabdl v0.2d, v1.2s, v2.2s":           0x0ea27020,
+	"uabdl v0.8h, v1.8b, v2.8b":           0x2e227020,
+	"smlal v0.4s, v1.4h, v2.4h":           0x0e628020,
+	"umlal2 v0.2d, v1.4s, v2.4s":          0x6ea28020,
+	"smlsl v0.8h, v1.8b, v2.8b":           0x0e22a020,
+	"umlsl v0.4s, v1.4h, v2.4h":           0x2e62a020,
+	"smull v0.2d, v1.2s, v2.2s":           0x0ea2c020,
+	"umull2 v0.8h, v1.16b, v2.16b":        0x6e22c020,
+	"shl v0.4s, v1.4s, #3":                0x4f235420,
+	"shl v0.2d, v1.2d, #63":               0x4f7f5420,
+	"sshr v0.16b, v1.16b, #8":             0x4f080420,
+	"ushr v0.4s, v1.4s, #2":               0x6f3e0420,
+	"ssra v0.8h, v1.8h, #1":               0x4f1f1420,
+	"usra v0.2d, v1.2d, #64":              0x6f401420,
+	"shrn v0.8b, v1.8h, #4":               0x0f0c8420,
+	"shrn2 v0.8h, v1.4s, #16":             0x4f108420,
+	"sshll v0.8h, v1.8b, #3":              0x0f0ba420,
+	"ushll2 v0.2d, v1.4s, #31":            0x6f3fa420,
+	"sxtl v0.4s, v1.4h":                   0x0f10a420,
+	"uxtl2 v0.8h, v1.16b":                 0x6f08a420,
+	"movi v0.4s, #0x1":                    0x4f000420,
+	"movi v0.2s, #0xff, lsl #24":          0x0f0767e0,
+	"movi v0.8h, #0x12, lsl #8":           0x4f00a640,
+	"movi v0.16b, #0x80":                  0x4f04e400,
+	"movi v0.4s, #0x21, msl #16":          0x4f01d420,
+	"movi v0.2d, #0xff00ff00ff00ff00":     0x6f05e540,
+	"movi d0, #0xffffffffff":              0x2f00e7e0,
+	"mvni v0.4h, #0x1":                    0x2f008420,
+	"mvni v0.4s, #0x7f, msl #8":           0x6f03c7e0,
+	"orr v0.4s, #0x3f, lsl #16":           0x4f0157e0,
+	"orr v0.8h, #0x1":                     0x4f009420,
+	"bic v0.2s, #0xf, lsl #8":             0x2f0035e0,
+	"bic v0.8h, #0xff, lsl #8":            0x6f07b7e0,
+	"dup v0.4s, v1.s[1]":                  0x4e0c0420,
+	"dup v0.16b, v1.b[15]":                0x4e1f0420,
+	"dup v0.2d, v1.d[1]":                  0x4e180420,
+	"mov v0.s[1], w1":                     0x4e0c1c20,
+	"mov v0.d[0], x1":                     0x4e081c20,
+	"mov v0.b[15], w1":                    0x4e1f1c20,
+	"mov w0, v1.s[1]":                     0x0e0c3c20,
+	"mov x0, v1.d[1]":                     0x4e183c20,
+	"umov w0, v1.b[3]":                    0x0e073c20,
+	"umov w0, v1.h[7]":                    0x0e1e3c20,
+	"smov x0, v1.h[2]":                    0x4e0a2c20,
+	"smov w0, v1.b[0]":                    0x0e012c20,
+	"smov x0, v1.s[3]":                    0x4e1c2c20,
+	"addv s0, v1.4s":                      0x4eb1b820,
+	"addv b0, v1.8b":                      0x0e31b820,
+	"saddlv d0, v1.4s":                    0x4eb03820,
+	"uaddlv h0, v1.16b":                   0x6e303820,
+	"smaxv h0, v1.8h":                     0x4e70a820,
+	"umaxv b0, v1.16b":                    0x6e30a820,
+	"sminv s0, v1.4s":                     0x4eb1a820,
+	"uminv h0, v1.4h":                     0x2e71a820,
+	"uzp1 v0.4s, v1.4s, v2.4s":            0x4e821820,
+	"uzp2 v0.16b, v1.16b, v2.16b":         0x4e025820,
+	"nop":                                 0xd503201f,
+	"yield":                               0xd503203f,
+	"wfe":                                 0xd503205f,
+	"bti c":                               0xd503245f,
+	"paciasp":                             0xd503233f,
+	"hint #0x7f":                          0xd5032fff,
+	"autiasp":                             0xd50323bf,
+	"pacibsp":                             0xd503237f,
+	"autibsp":                             0xd50323ff,
+	"paciaz":                              0xd503231f,
+	"pacia1716":                           0xd503211f,
+	"autia1716":                           0xd503219f,
+	"xpaclri":                             0xd50320ff,
+	"bti":                                 0xd503241f,
+	"bti j":                               0xd503249f,
+	"bti jc":                              0xd50324df,
+	"pacia x1, x2":                        0xdac10041,
+	"pacib x1, sp":                        0xdac107e1,
+	"pacda x1, x2":                        0xdac10841,
+	"autia x1, x2":                        0xdac11041,
+	"autdb x1, x2":                        0xdac11c41,
+	"paciza x3":                           0xdac123e3,
+	"autdzb x3":                           0xdac13fe3,
+	"xpaci x4":                            0xdac143e4,
+	"xpacd x4":                            0xdac147e4,
+	"pacga x1, x2, sp":                    0x9adf3041,
+	"braaz x5":                            0xd61f08bf,
+	"blrabz x5":                           0xd63f0cbf,
+	"retaa":                               0xd65f0bff,
+	"retab":                               0xd65f0fff,
+	"braa x5, x6":                         0xd71f08a6,
+	"brab x5, sp":                         0xd71f0cbf,
+	"blraa x5, x6":                        0xd73f08a6,
+	"blrab x5, x6":                        0xd73f0ca6,
+	"dmb ish":                             0xd5033bbf,
+	"dsb sy":                              0xd5033f9f,
+	"dmb ishld":                           0xd50339bf,
+	"isb":                                 0xd5033fdf,
+	"clrex":                               0xd5033f5f,
+	"mrs x0, tpidr_el0":                   0xd53bd040,
+	"msr fpcr, x1":                        0xd51b4401,
+	"mrs x0, s3_3_c15_c0_1":               0xd53bf020,
+	"mrs x1, dczid_el0":                   0xd53b00e1,
+	"mrs x0, id_aa64isar0_el1":            0xd5380600,
+	"mrs x1, id_aa64pfr0_el1":             0xd5380401,
+	"mrs x2, id_aa64mmfr2_el1":            0xd5380742,
+	"mrs x3, revidr_el1":                  0xd53800c3,
+	"mrs x4, cntvct_el0":                  0xd53be044,
+	"msr nzcv, x5":                        0xd51b4205,
+	"msr tpidr_el0, x6":                   0xd51bd046,
+	"mrs x7, ctr_el0":                     0xd53b0027,
+	"dc zva, x0":                          0xd50b7420,
+	"dc cvau, x1":                         0xd50b7b21,
+	"ic ivau, x2":                         0xd50b7522,
+	"dc civac, x3":                        0xd50b7e23,
+	"dc cvac, x4":                         0xd50b7a24,
+	"dc cvap, x5":                         0xd50b7c25,
+	"dc cvadp, x6":                        0xd50b7d26,
+	"fadd d0, d1, d2":                     0x1e622820,
+	"fsub s0, s1, s2":                     0x1e223820,
+	"fmul h0, h1, h2":                     0x1ee20820,
+	"fdiv d0, d1, d2":                     0x1e621820,
+	"fsqrt d0, d1":                        0x1e61c020,
+	"fabs s0, s1":                         0x1e20c020,
+	"fneg d0, d1":                         0x1e614020,
+	"fmov d0, d1":                         0x1e604020,
+	"fcvt d0, s1":                         0x1e22c020,
+	"fcvt s0, d1":                         0x1e624020,
+	"fcvt h0, d1":                         0x1e63c020,
+	"fnmul d0, d1, d2":                    0x1e628820,
+	"fmax d0, d1, d2":                     0x1e624820,
+	"fmin s0, s1, s2":                     0x1e225820,
+	"fmaxnm d0, d1, d2":                   0x1e626820,
+	"fminnm d0, d1, d2":                   0x1e627820,
+	"frintn d0, d1":                       0x1e644020,
+	"frintp d0, d1":                       0x1e64c020,
+	"frintm d0, d1":                       0x1e654020,
+	"frintz s0, s1":                       0x1e25c020,
+	"frinta d0, d1":                       0x1e664020,
+	"frintx d0, d1":                       0x1e674020,
+	"frinti h0, h1":                       0x1ee7c020,
+	"fmadd d0, d1, d2, d3":                0x1f420c20,
+	"fnmsub s0, s1, s2, s3":               0x1f228c20,
+	"fcmp d0, d1":                         0x1e612000,
+	"fcmpe s0, #0.0":                      0x1e202018,
+	"fccmp d0, d1, #0x4, ne":              0x1e611404,
+	"fcsel d0, d1, d2, gt":                0x1e62cc20,
+	"fmov d0, #1.000000000000000000e+00":  0x1e6e1000,
+	"fmov s0, #-2.500000000000000000e+00": 0x1e309000,
+	"fmov h0, #1.500000000000000000e+00":  0x1eef1000,
+	"scvtf d0, x1":                        0x9e620020,
+	"ucvtf s0, w1":                        0x1e230020,
+	"scvtf d0, w1, #16":                   0x1e42c020,
+	"fcvtzs x0, d1":                       0x9e780020,
+	"fcvtzu w0, s1, #8":                   0x1e19e020,
+	"fcvtns x0, d1":                       0x9e600020,
+	"fcvtms w0, d1":                       0x1e700020,
+	"fcvtas x0, d1":                       0x9e640020,
+	"fmov x0, d1":                         0x9e660020,
+	"fmov d0, x1":                         0x9e670020,
+	"fmov w0, s1":                         0x1e260020,
+	"fmov x0, v1.d[1]":                    0x9eae0020,
+	"fmov v0.d[1], x1":                    0x9eaf0020,
+	"ldxr x0, [x1]":                       0xc85f7c20,
+	"ldaxr w2, [sp]":                      0x885fffe2,
+	"ldxrb w0, [x1]":                      0x085f7c20,
+	"stxr w3, x0, [x1]":                   0xc8037c20,
+	"stlxrh w3, w0, [x1]":                 0x4803fc20,
+	"ldxp x0, x1, [x2]":                   0xc87f0440,
+	"stlxp w4, x0, x1, [x2]":              0xc8248440,
+	"ldar x0, [x1]":                       0xc8dffc20,
+	"stlrb w0, [x1]":                      0x089ffc20,
+	"cas x0, x1, [x2]":                    0xc8a07c41,
+	"casal w0, w1, [x2]":                  0x88e0fc41,
+	"casab w0, w1, [x2]":                  0x08e07c41,
+	"casp x0, x1, x2, x3, [x4]":           0x48207c82,
+	"caspl w0, w1, w2, w3, [x4]":          0x0820fc82,
+	"ldadd x0, x1, [x2]":                  0xf8200041,
+	"ldaddal w0, w1, [x2]":                0xb8e00041,
+	"ldaddalb w0, w1, [x2]":               0x38e00041,
+	"stadd x0, [x2]":                      0xf820005f,
+	"staddl w0, [x2]":                     0xb860005f,
+	"ldclrh w0, w1, [x2]":                 0x78201041,
+	"ldsmax x0, x1, [x2]":                 0xf8204041,
+	"stumin x0, [x2]":                     0xf820705f,
+	"swp x0, x1, [x2]":                    0xf8208041,
+	"swpal w0, w1, [x2]":                  0xb8e08041,
+	"swpb w0, w1, [x2]":                   0x38208041,
+	"ldapr x0, [x1]":                      0xf8bfc020,
+	"ldaprb w0, [x1]":                     0x38bfc020,
+	"add x0, sp, w1, uxtw #2":             0x8b214be0,
+	"add sp, sp, x1":                      0x8b2163ff,
+	"add x0, x1, x2, uxtx #1":             0x8b226420,
+	"add w0, w1, w2, uxtb":                0x0b220020,
+	"adds x0, sp, x1, lsl #3":             0xab216fe0,
+	"cmp sp, x1":                          0xeb2163ff,
+	"cmp x0, w1, sxtw":                    0xeb21c01f,
+	"cmn w0, w1, uxth #4":                 0x2b21301f,
+	"sub sp, sp, x2":                      0xcb2263ff,
+	"subs x0, x1, w2, sxtb":               0xeb228020,
+	"add w0, wsp, w1":                     0x0b2143e0,
+	"add x0, x1, x2, sxtx":                0x8b22e020,
+	"ngc x0, x1":                          0xda0103e0,
+	"ngcs w0, w1":                         0x7a0103e0,
+	"sbc x0, x1, x2":                      0xda020020,
+	"adcs w0, w1, w2":                     0x3a020020,
+	"smull x0, w1, w2":                    0x9b227c20,
+	"smnegl x0, w1, w2":                   0x9b22fc20,
+	"umull x0, w1, w2":                    0x9ba27c20,
+	"umnegl x0, w1, w2":                   0x9ba2fc20,
+	"umaddl x0, w1, w2, x3":               0x9ba20c20,
+	"smsubl x0, w1, w2, x3":               0x9b228c20,
+	"smulh x0, x1, x2":                    0x9b427c20,
+	"rev x0, x1":                          0xdac00c20,
+	"rev32 x0, x1":                        0xdac00820,
+	"rev16 w0, w1":                        0x5ac00420,
+	"rev w0, w1":                          0x5ac00820,
+	"rbit x0, x1":                         0xdac00020,
+	"cls w0, w1":                          0x5ac01420,
+	"crc32cx w0, w1, x2":                  0x9ac25c20,
+	"crc32h w0, w1, w2":                   0x1ac24420,
+
+	// Advanced SIMD floating point
+	"fadd v0.4s, v1.4s, v2.4s":               0x4e22d420,
+	"fsub v0.2d, v1.2d, v2.2d":               0x4ee2d420,
+	"fmul v0.8h, v1.8h, v2.8h":               0x6e421c20,
+	"fdiv v0.2s, v1.2s, v2.2s":               0x2e22fc20,
+	"fmla v0.2d, v1.2d, v2.2d":               0x4e62cc20,
+	"fmls v0.4h, v1.4h, v2.4h":               0x0ec20c20,
+	"fmax v0.4s, v1.4s, v2.4s":               0x4e22f420,
+	"fmin v0.2d, v1.2d, v2.2d":               0x4ee2f420,
+	"fmaxnm v0.8h, v1.8h, v2.8h":             0x4e420420,
+	"fminnm v0.2s, v1.2s, v2.2s":             0x0ea2c420,
+	"fabd v0.4s, v1.4s, v2.4s":               0x6ea2d420,
+	"faddp v0.2d, v1.2d, v2.2d":              0x6e62d420,
+	"fmaxp v0.4s, v1.4s, v2.4s":              0x6e22f420,
+	"fminp v0.2s, v1.2s, v2.2s":              0x2ea2f420,
+	"fmaxnmp v0.4h, v1.4h, v2.4h":            0x2e420420,
+	"fminnmp v0.2d, v1.2d, v2.2d":            0x6ee2c420,
+	"fcmeq v0.4s, v1.4s, v2.4s":              0x4e22e420,
+	"fcmge v0.2d, v1.2d, v2.2d":              0x6e62e420,
+	"fcmgt v0.8h, v1.8h, v2.8h":              0x6ec22420,
+	"facge v0.4s, v1.4s, v2.4s":              0x6e22ec20,
+	"facgt v0.2d, v1.2d, v2.2d":              0x6ee2ec20,
+	"fcmeq v0.4s, v1.4s, #0.0":               0x4ea0d820,
+	"fcmge v0.2d, v1.2d, #0.0":               0x6ee0c820,
+	"fcmgt v0.4h, v1.4h, #0.0":               0x0ef8c820,
+	"fcmle v0.2s, v1.2s, #0.0":               0x2ea0d820,
+	"fcmlt v0.8h, v1.8h, #0.0":               0x4ef8e820,
+	"fabs v0.4s, v1.4s":                      0x4ea0f820,
+	"fneg v0.2d, v1.2d":                      0x6ee0f820,
+	"fsqrt v0.8h, v1.8h":                     0x6ef9f820,
+	"frintn v0.2d, v1.2d":                    0x4e618820,
+	"frinta v0.4s, v1.4s":                    0x6e218820,
+	"frintp v0.2s, v1.2s":                    0x0ea18820,
+	"frintm v0.4h, v1.4h":                    0x0e799820,
+	"frintz v0.4s, v1.4s":                    0x4ea19820,
+	"frintx v0.2d, v1.2d":                    0x6e619820,
+	"frinti v0.4s, v1.4s":                    0x6ea19820,
+	"scvtf v0.4s, v1.4s":                     0x4e21d820,
+	"ucvtf v0.8h, v1.8h":                     0x6e79d820,
+	"fcvtns v0.2d, v1.2d":                    0x4e61a820,
+	"fcvtnu v0.4s, v1.4s":                    0x6e21a820,
+	"fcvtps v0.2s, v1.2s":                    0x0ea1a820,
+	"fcvtpu v0.4h, v1.4h":                    0x2ef9a820,
+	"fcvtms v0.4s, v1.4s":                    0x4e21b820,
+	"fcvtmu v0.2d, v1.2d":                    0x6e61b820,
+	"fcvtzs v0.4s, v1.4s":                    0x4ea1b820,
+	"fcvtzu v0.2d, v1.2d":                    0x6ee1b820,
+	"fcvtas v0.8h, v1.8h":                    0x4e79c820,
+	"fcvtau v0.4s, v1.4s":                    0x6e21c820,
+	"fcvtl v0.2d, v1.2s":                     0x0e617820,
+	"fcvtl2 v0.4s, v1.8h":                    0x4e217820,
+	"fcvtn v0.4h, v1.4s":                     0x0e216820,
+	"fcvtn2 v0.4s, v1.2d":                    0x4e616820,
+	"fmla v0.4s, v1.4s, v2.s[3]":             0x4fa21820,
+	"fmls v0.8h, v1.8h, v15.h[7]":            0x4f3f5820,
+	"fmul v0.2d, v1.2d, v17.d[1]":            0x4fd19820,
+	"fmla s0, s1, v2.s[2]":                   0x5f821820,
+	"fmls s3, s4, v5.s[1]":                   0x5fa55083,
+	"fmul d0, d1, v2.d[1]":                   0x5fc29820,
+	"scvtf d0, d1":                           0x5e61d820,
+	"ucvtf s0, s1":                           0x7e21d820,
+	"fcvtzs d0, d1":                          0x5ee1b820,
+	"fcvtzs s0, s1":                          0x5ea1b820,
+	"fcvtau d0, d1":                          0x7e61c820,
+	"fcmeq s0, s1, #0.0":                     0x5ea0d820,
+	"fcmgt d0, d1, d2":                       0x7ee2e420,
+	"facge s0, s1, s2":                       0x7e22ec20,
+	"fabd d0, d1, d2":                        0x7ee2d420,
+	"frecpe v0.4s, v1.4s":                    0x4ea1d820,
+	"frecpe d0, d1":                          0x5ee1d820,
+	"frsqrte v0.2d, v1.2d":                   0x6ee1d820,
+	"frsqrte s0, s1":                         0x7ea1d820,
+	"frecps v0.4s, v1.4s, v2.4s":             0x4e22fc20,
+	"frecps d0, d1, d2":                      0x5e62fc20,
+	"frsqrts v0.2d, v1.2d, v2.2d":            0x4ee2fc20,
+	"frsqrts s0, s1, s2":                     0x5ea2fc20,
+	"faddp s0, v1.2s":                        0x7e30d820,
+	"fmaxp d0, v1.2d":                        0x7e70f820,
+	"fminp s0, v1.2s":                        0x7eb0f820,
+	"fmaxnmp d0, v1.2d":                      0x7e70c820,
+	"fminnmp s0, v1.2s":                      0x7eb0c820,
+	"fmaxv h0, v1.8h":                        0x4e30f820,
+	"fminv s0, v1.4s":                        0x6eb0f820,
+	"fmaxnmv s0, v1.4s":                      0x6e30c820,
+	"fminnmv h0, v1.4h":                      0x0eb0c820,
+	"fmov v0.4s, #1.000000000000000000e+00":  0x4f03f600,
+	"fmov v0.2d, #-5.000000000000000000e-01": 0x6f07f400,
+	"fmov v0.8h, #2.000000000000000000e+00":  0x4f00fc00,
+	// Advanced SIMD permute, extract and table lookup
+	"zip1 v0.4s, v1.4s, v2.4s":                             0x4e823820,
+	"zip2 v0.8b, v1.8b, v2.8b":                             0x0e027820,
+	"trn1 v0.2d, v1.2d, v2.2d":                             0x4ec22820,
+	"trn2 v0.8h, v1.8h, v2.8h":                             0x4e426820,
+	"ext v0.16b, v1.16b, v2.16b, #3":                       0x6e021820,
+	"ext v0.8b, v1.8b, v2.8b, #7":                          0x2e023820,
+	"rev64 v0.4s, v1.4s":                                   0x4ea00820,
+	"rev32 v0.8h, v1.8h":                                   0x6e600820,
+	"rev16 v0.16b, v1.16b":                                 0x4e201820,
+	"rev64 v0.8b, v1.8b":                                   0x0e200820,
+	"tbl v0.16b, { v1.16b }, v2.16b":                       0x4e020020,
+	"tbl v0.8b, { v1.16b, v2.16b }, v3.8b":                 0x0e032020,
+	"tbx v0.16b, { v30.16b, v31.16b, v0.16b }, v4.16b":     0x4e0453c0,
+	"tbx v0.8b, { v1.16b, v2.16b, v3.16b, v4.16b }, v5.8b": 0x0e057020,
+	"mov v0.s[1], v1.s[3]":                                 0x6e0c6420,
+	"mov v0.b[15], v1.b[0]":                                0x6e1f0420,
+	"mov v0.d[1], v1.d[0]":                                 0x6e180420,
+
+	// Advanced SIMD integer scalar, by element and pairwise long forms
+	"addp d0, v1.2d":                 0x5ef1b820,
+	"mul v0.4s, v1.4s, v2.s[1]":      0x4fa28020,
+	"mul v3.8h, v4.8h, v15.h[7]":     0x4f7f8883,
+	"mla v0.4s, v1.4s, v2.s[3]":      0x6fa20820,
+	"mls v0.4h, v1.4h, v2.h[2]":      0x2f624020,
+	"sqdmulh v0.4s, v1.4s, v2.s[1]":  0x4fa2c020,
+	"sqrdmulh v0.8h, v1.8h, v2.h[5]": 0x4f52d820,
+	"sqdmulh s0, s1, v2.s[1]":        0x5fa2c020,
+	"sqrdmulh h0, h1, v2.h[3]":       0x5f72d020,
+	"sqdmulh v0.4s, v1.4s, v2.4s":    0x4ea2b420,
+	"sqrdmulh v0.4h, v1.4h, v2.4h":   0x2e62b420,
+	"sqdmulh s0, s1, s2":             0x5ea2b420,
+	"sqrdmulh h0, h1, h2":            0x7e62b420,
+	"cmeq d0, d1, d2":                0x7ee28c20,
+	"cmeq d0, d1, #0":                0x5ee09820,
+	"cmgt d3, d4, d5":                0x5ee53483,
+	"cmhi d3, d4, d5":                0x7ee53483,
+	"cmtst d3, d4, d5":               0x5ee58c83,
+	"add d0, d1, d2":                 0x5ee28420,
+	"sub d0, d1, d2":                 0x7ee28420,
+	"sqadd b0, b1, b2":               0x5e220c20,
+	"uqsub h0, h1, h2":               0x7e622c20,
+	"abs d0, d1":                     0x5ee0b820,
+	"neg d0, d1":                     0x7ee0b820,
+	"cmlt d0, d1, #0":                0x5ee0a820,
+	"ushr d0, d1, #3":                0x7f7d0420,
+	"ushr d0, d1, #64":               0x7f400420,
+	"sshr d0, d1, #1":                0x5f7f0420,
+	"shl d0, d1, #63":                0x5f7f5420,
+	"ssra d0, d1, #8":                0x5f781420,
+	"usra d0, d1, #8":                0x7f781420,
+	"ushl v0.4s, v1.4s, v2.4s":       0x6ea24420,
+	"sshl v0.2d, v1.2d, v2.2d":       0x4ee24420,
+	"ushl d0, d1, d2":                0x7ee24420,
+	"sshl d0, d1, d2":                0x5ee24420,
+	"rshrn v0.8b, v1.8h, #3":         0x0f0d8c20,
+	"rshrn2 v0.4s, v1.2d, #32":       0x4f208c20,
+	"uaddlp v0.8h, v1.16b":           0x6e202820,
+	"saddlp v0.1d, v1.2s":            0x0ea02820,
+	"sadalp v0.4s, v1.8h":            0x4e606820,
+	"uadalp v0.2s, v1.4h":            0x2e606820,
+	"mov d0, v1.d[1]":                0x5e180420,
+	"mov s0, v1.s[3]":                0x5e1c0420,
+	"mov b0, v1.b[15]":               0x5e1f0420,
+	"mov h0, v1.h[2]":                0x5e0a0420,
+
+	// Cryptographic extension
+	"aese v0.16b, v1.16b":                 0x4e284820,
+	"aesd v2.16b, v3.16b":                 0x4e285862,
+	"aesmc v4.16b, v5.16b":                0x4e2868a4,
+	"aesimc v6.16b, v7.16b":               0x4e2878e6,
+	"sha1c q0, s1, v2.4s":                 0x5e020020,
+	"sha1p q0, s1, v2.4s":                 0x5e021020,
+	"sha1m q0, s1, v2.4s":                 0x5e022020,
+	"sha1su0 v0.4s, v1.4s, v2.4s":         0x5e023020,
+	"sha256h q0, q1, v2.4s":               0x5e024020,
+	"sha256h2 q0, q1, v2.4s":              0x5e025020,
+	"sha256su1 v0.4s, v1.4s, v2.4s":       0x5e026020,
+	"sha1h s0, s1":                        0x5e280820,
+	"sha1su1 v0.4s, v1.4s":                0x5e281820,
+	"sha256su0 v0.4s, v1.4s":              0x5e282820,
+	"sha512h q0, q1, v2.2d":               0xce628020,
+	"sha512h2 q0, q1, v2.2d":              0xce628420,
+	"sha512su0 v0.2d, v1.2d":              0xcec08020,
+	"sha512su1 v0.2d, v1.2d, v2.2d":       0xce628820,
+	"rax1 v0.2d, v1.2d, v2.2d":            0xce628c20,
+	"eor3 v0.16b, v1.16b, v2.16b, v3.16b": 0xce020c20,
+	"bcax v0.16b, v1.16b, v2.16b, v3.16b": 0xce220c20,
+	"xar v0.2d, v1.2d, v2.2d, #10":        0xce822820,
+	"pmull v0.8h, v1.8b, v2.8b":           0x0e22e020,
+	"pmull2 v0.8h, v1.16b, v2.16b":        0x4e22e020,
+	"pmull v0.1q, v1.1d, v2.1d":           0x0ee2e020,
+	"pmull2 v0.1q, v1.2d, v2.2d":          0x4ee2e020,
+}
+
+var _ = Describe("Round trip", func() {
+	const pc = 0x1000
+
+	decoder := insts.NewDecoder()
+
+	It("should assemble every decoder form to its reference encoding", func() {
+		for text, word := range forms {
+			code, err := asm.AssembleAt(text, pc)
+			Expect(err).NotTo(HaveOccurred(), text)
+			Expect(binary.LittleEndian.Uint32(code)).To(Equal(word), text)
+		}
+	})
+
+	It("should decode and disassemble back to the source", func() {
+		for text := range forms {
+			code, err := asm.AssembleAt(text, pc)
+			Expect(err).NotTo(HaveOccurred(), text)
+			inst := decoder.Decode(binary.LittleEndian.Uint32(code))
+			Expect(inst.Op).NotTo(Equal(insts.OpUnknown), text)
+			Expect(insts.Disassemble(inst, pc)).To(Equal(text))
+		}
+	})
+
+	It("should accept targets written as offsets", func() {
+		for text, word := range map[string]uint32{
+			"b #0x100":          0x14000040,
+			"bl #-0x8":          0x97fffffe,
+			"b.eq #-0x4":        0x54ffffe0,
+			"cbnz w3, #-0x10":   0x35ffff83,
+			"tbz w0, #3, #0x8":  0x36180040,
+			"adr x0, #0x40":     0x10000200,
+			"adrp x0, #0x3000":  0xf0000000,
+			"ldr x0, #0x40":     0x58000200,
+			"ldr w0, . - 4":     0x18ffffe0,
+			"b.ne . + 0x20":     0x54000101,
+			"add x0, x1, #-0x8": 0xd1002020,
+			"cmp x0, #-1":       0xb100041f,
+		} {
+			code, err := asm.AssembleAt(text, pc)
+			Expect(err).NotTo(HaveOccurred(), text)
+			Expect(binary.LittleEndian.Uint32(code)).To(Equal(word), text)
+		}
+	})
+})
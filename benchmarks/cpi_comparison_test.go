@@ -20,7 +20,9 @@ type CPIComparison struct {
 }
 
 // runFastTimingBenchmark runs a single benchmark through the fast timing engine.
-func runFastTimingBenchmark(bench Benchmark) (cycles uint64, instructions uint64) {
+func runFastTimingBenchmark(t *testing.T, bench Benchmark) (cycles uint64, instructions uint64) {
+	t.Helper()
+
 	regFile := &emu.RegFile{}
 	memory := emu.NewMemory()
 	regFile.SP = 0x10000
@@ -30,7 +32,11 @@ func runFastTimingBenchmark(bench Benchmark) (cycles uint64, instructions uint64
 	}
 
 	programAddr := uint64(0x1000)
-	for i, b := range bench.Program {
+	program, err := bench.Code(programAddr)
+	if err != nil {
+		t.Fatalf("%s: %v", bench.Name, err)
+	}
+	for i, b := range program {
 		memory.Write8(programAddr+uint64(i), b)
 	}
 
@@ -70,7 +76,7 @@ func TestCPIComparison_FastVsFullPipeline(t *testing.T) {
 	t.Logf("%-30s %12s %12s %12s", "---", "---", "---", "---")
 
 	for i, bench := range benchmarks {
-		ftCycles, ftInstrs := runFastTimingBenchmark(bench)
+		ftCycles, ftInstrs := runFastTimingBenchmark(t, bench)
 
 		var ftCPI float64
 		if ftInstrs > 0 {
@@ -189,7 +195,7 @@ func TestCPIComparison_ThreeWay(t *testing.T) {
 		m2CPI := m2Baselines[calibName]
 		fullCPI := fullResults[i].CPI
 
-		ftCycles, ftInstrs := runFastTimingBenchmark(bench)
+		ftCycles, ftInstrs := runFastTimingBenchmark(t, bench)
 		var ftCPI float64
 		if ftInstrs > 0 {
 			ftCPI = float64(ftCycles) / float64(ftInstrs)
@@ -11,44 +11,6 @@ import (
 	"github.com/sarchlab/m2sim/timing/pipeline"
 )
 
-// encodeMADD encodes MADD: Rd = Ra + Rn * Rm (64-bit)
-func encodeMADD(rd, rn, rm, ra uint8) uint32 {
-	var inst uint32
-	inst |= 1 << 31          // sf = 1 (64-bit)
-	inst |= 0b00 << 29       // op54 = 00
-	inst |= 0b11011 << 24    // fixed
-	inst |= 0b000 << 21      // op31 = 000
-	inst |= uint32(rm) << 16 // Rm
-	inst |= 0 << 15          // o0 = 0 (MADD)
-	inst |= uint32(ra) << 10 // Ra
-	inst |= uint32(rn) << 5  // Rn
-	inst |= uint32(rd)       // Rd
-	return inst
-}
-
-// encodeMUL encodes MUL: Rd = Rn * Rm (alias: MADD Rd, Rn, Rm, XZR)
-func encodeMUL(rd, rn, rm uint8) uint32 {
-	return encodeMADD(rd, rn, rm, 31) // Ra = XZR
-}
-
-// encodeLSLImm encodes LSL (logical shift left) immediate.
-// LSL is an alias for UBFM: Rd = Rn << shift
-// Encoded as: UBFM Rd, Rn, #(-shift mod 64), #(63 - shift)
-func encodeLSLImm(rd, rn uint8, shift uint8) uint32 {
-	immr := (64 - uint32(shift)) & 0x3F
-	imms := (63 - uint32(shift)) & 0x3F
-	var inst uint32
-	inst |= 1 << 31         // sf = 1 (64-bit)
-	inst |= 0b10 << 29      // opc = 10 (UBFM)
-	inst |= 0b100110 << 23  // fixed
-	inst |= 1 << 22         // N = 1 (64-bit)
-	inst |= immr << 16      // immr
-	inst |= imms << 10      // imms
-	inst |= uint32(rn) << 5 // Rn
-	inst |= uint32(rd)      // Rd
-	return inst
-}
-
 // buildMatmul4x4 builds a 4x4 integer matrix multiply C = A * B using
 // triple-nested loops with real branch instructions.
 //
@@ -70,82 +32,6 @@ func encodeLSLImm(rd, rn uint8, shift uint8) uint32 {
 //	X13 = temp: address calculation
 //	X14 = temp: row offset (i*N or k*N)
 func buildMatmul4x4() Benchmark {
-	instrs := []uint32{
-		// === Initialization ===
-		EncodeADDImm(4, 31, 0, false), // X4 = 0 (i)            [0]
-
-		// === Outer loop (i_loop): offset 1 ===
-		EncodeADDImm(5, 31, 0, false), // X5 = 0 (j)            [1]
-
-		// === Middle loop (j_loop): offset 2 ===
-		EncodeADDImm(11, 31, 0, false), // X11 = 0 (accum)      [2]
-		EncodeADDImm(6, 31, 0, false),  // X6 = 0 (k)           [3]
-
-		// === Inner loop (k_loop): offset 4 ===
-		// Compute addr of A[i][k]: A + (i*4 + k) * 8
-		encodeMUL(14, 4, 7),            // X14 = i * N           [4]
-		EncodeADDReg(12, 14, 6, false), // X12 = i*N + k         [5]
-		encodeLSLImm(12, 12, 3),        // X12 = (i*N+k) * 8     [6]
-		EncodeADDReg(12, 1, 12, false), // X12 = &A[i][k]        [7]
-		EncodeLDR64(9, 12, 0),          // X9 = A[i][k]           [8]
-
-		// Compute addr of B[k][j]: B + (k*4 + j) * 8
-		encodeMUL(14, 6, 7),            // X14 = k * N           [9]
-		EncodeADDReg(13, 14, 5, false), // X13 = k*N + j         [10]
-		encodeLSLImm(13, 13, 3),        // X13 = (k*N+j) * 8     [11]
-		EncodeADDReg(13, 2, 13, false), // X13 = &B[k][j]        [12]
-		EncodeLDR64(10, 13, 0),         // X10 = B[k][j]          [13]
-
-		// C[i][j] += A[i][k] * B[k][j]
-		encodeMADD(11, 9, 10, 11), // X11 += X9*X10         [14]
-
-		// k++; if k < N goto k_loop
-		EncodeADDImm(6, 6, 1, false), // k++                  [15]
-		EncodeCMPReg(6, 7),           // CMP k, N             [16]
-		// B.LT k_loop: target=4, branch=17
-		// offset = (4 - 17) * 4 = -52
-		EncodeBCond(-52, 11), // B.LT k_loop           [17]
-
-		// Store C[i][j]: C + (i*4 + j) * 8
-		encodeMUL(14, 4, 7),            // X14 = i * N           [18]
-		EncodeADDReg(12, 14, 5, false), // X12 = i*N + j         [19]
-		encodeLSLImm(12, 12, 3),        // X12 = (i*N+j) * 8     [20]
-		EncodeADDReg(12, 3, 12, false), // X12 = &C[i][j]        [21]
-		EncodeSTR64(11, 12, 0),         // C[i][j] = X11          [22]
-
-		// j++; if j < N goto j_loop
-		EncodeADDImm(5, 5, 1, false), // j++                  [23]
-		EncodeCMPReg(5, 7),           // CMP j, N             [24]
-		// B.LT j_loop: target=2, branch=25
-		// offset = (2 - 25) * 4 = -92
-		EncodeBCond(-92, 11), // B.LT j_loop           [25]
-
-		// i++; if i < N goto i_loop
-		EncodeADDImm(4, 4, 1, false), // i++                  [26]
-		EncodeCMPReg(4, 7),           // CMP i, N             [27]
-		// B.LT i_loop: target=1, branch=28
-		// offset = (1 - 28) * 4 = -108
-		EncodeBCond(-108, 11), // B.LT i_loop           [28]
-
-		// Sum all C elements into X0 for exit code verification
-		EncodeADDImm(0, 31, 0, false),   // X0 = 0               [29]
-		EncodeADDImm(4, 31, 0, false),   // i = 0                [30]
-		EncodeADDImm(15, 31, 16, false), // X15 = 16             [31]
-
-		// sum_loop: offset 32
-		encodeLSLImm(12, 4, 3),         // X12 = i * 8           [32]
-		EncodeADDReg(12, 3, 12, false), // X12 = &C[i]           [33]
-		EncodeLDR64(9, 12, 0),          // X9 = C[i]             [34]
-		EncodeADDReg(0, 0, 9, false),   // X0 += C[i]            [35]
-		EncodeADDImm(4, 4, 1, false),   // i++                   [36]
-		EncodeCMPReg(4, 15),            // CMP i, 16             [37]
-		// B.LT sum_loop: target=32, branch=38
-		// offset = (32 - 38) * 4 = -24
-		EncodeBCond(-24, 11), // B.LT sum_loop         [38]
-
-		EncodeSVC(0), // exit with X0          [39]
-	}
-
 	return Benchmark{
 		Name:        "matmul_4x4",
 		Description: "4x4 integer matrix multiply with triple-nested loops - realistic workload",
@@ -173,7 +59,65 @@ func buildMatmul4x4() Benchmark {
 			// Matrix C at 0x8200 (result buffer)
 			regFile.WriteReg(3, 0x8200)
 		},
-		Program: BuildProgram(instrs...),
+		Source: `
+			mov x4, #0              // i = 0
+		i_loop:
+			mov x5, #0              // j = 0
+		j_loop:
+			mov x11, #0             // accumulator = 0
+			mov x6, #0              // k = 0
+		k_loop:
+			// Compute addr of A[i][k]: A + (i*4 + k) * 8
+			mul x14, x4, x7         // X14 = i * N
+			add x12, x14, x6        // X12 = i*N + k
+			lsl x12, x12, #3        // X12 = (i*N+k) * 8
+			add x12, x1, x12        // X12 = &A[i][k]
+			ldr x9, [x12]           // X9 = A[i][k]
+
+			// Compute addr of B[k][j]: B + (k*4 + j) * 8
+			mul x14, x6, x7         // X14 = k * N
+			add x13, x14, x5        // X13 = k*N + j
+			lsl x13, x13, #3        // X13 = (k*N+j) * 8
+			add x13, x2, x13        // X13 = &B[k][j]
+			ldr x10, [x13]          // X10 = B[k][j]
+
+			// C[i][j] += A[i][k] * B[k][j]
+			madd x11, x9, x10, x11
+
+			add x6, x6, #1          // k++
+			cmp x6, x7
+			b.lt k_loop
+
+			// Store C[i][j]: C + (i*4 + j) * 8
+			mul x14, x4, x7         // X14 = i * N
+			add x12, x14, x5        // X12 = i*N + j
+			lsl x12, x12, #3        // X12 = (i*N+j) * 8
+			add x12, x3, x12        // X12 = &C[i][j]
+			str x11, [x12]          // C[i][j] = X11
+
+			add x5, x5, #1          // j++
+			cmp x5, x7
+			b.lt j_loop
+
+			add x4, x4, #1          // i++
+			cmp x4, x7
+			b.lt i_loop
+
+			// Sum all C elements into X0 for exit code verification
+			mov x0, #0
+			mov x4, #0              // i = 0
+			mov x15, #16
+		sum_loop:
+			lsl x12, x4, #3         // X12 = i * 8
+			add x12, x3, x12        // X12 = &C[i]
+			ldr x9, [x12]           // X9 = C[i]
+			add x0, x0, x9          // X0 += C[i]
+			add x4, x4, #1          // i++
+			cmp x4, x15
+			b.lt sum_loop
+
+			svc #0                  // exit with X0
+		`,
 		// C = A * I = A, so sum(C) = sum(1..16) = 136
 		ExpectedExit: 136,
 	}
@@ -204,7 +148,11 @@ func TestMatmulCalibration(t *testing.T) {
 	}
 
 	programAddr := uint64(0x1000)
-	for i, b := range bench.Program {
+	program, err := bench.Code(programAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range program {
 		memory.Write8(programAddr+uint64(i), b)
 	}
 
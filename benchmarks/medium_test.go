@@ -24,15 +24,7 @@ func TestMatrixMultiplyBenchmark(t *testing.T) {
 
 	// Create memory and load program segments
 	memory := emu.NewMemory()
-	for _, seg := range prog.Segments {
-		for i, b := range seg.Data {
-			memory.Write8(seg.VirtAddr+uint64(i), b)
-		}
-		// Zero-fill BSS (memsize > filesize)
-		for i := uint64(len(seg.Data)); i < seg.MemSize; i++ {
-			memory.Write8(seg.VirtAddr+i, 0)
-		}
-	}
+	prog.LoadInto(memory)
 
 	// Capture stdout
 	var stdout bytes.Buffer
@@ -1,7 +1,12 @@
 // Package benchmarks provides timing benchmark infrastructure for M2Sim calibration.
 package benchmarks
 
-import "github.com/sarchlab/m2sim/emu"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/m2sim/emu"
+)
 
 // GetMicrobenchmarks returns the standard set of microbenchmarks for M2 calibration.
 // Each benchmark targets a specific CPU characteristic.
@@ -50,6 +55,16 @@ func GetCoreBenchmarks() []Benchmark {
 	}
 }
 
+// repeat formats one line of assembly per index in [0, n).
+func repeat(n int, line func(i int) string) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(line(i))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
 // 1. Arithmetic Sequential - Tests ALU throughput with independent operations
 func arithmeticSequential() Benchmark {
 	const numInstructions = 200
@@ -60,19 +75,15 @@ func arithmeticSequential() Benchmark {
 		Setup: func(regFile *emu.RegFile, memory *emu.Memory) {
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 		},
-		Program:      buildArithmeticSequential(numInstructions, numRegisters),
+		Source:       buildArithmeticSequential(numInstructions, numRegisters),
 		ExpectedExit: int64(numInstructions / numRegisters), // X0 incremented once per register cycle
 	}
 }
 
-func buildArithmeticSequential(n, numRegs int) []byte {
-	instrs := make([]uint32, 0, n+1)
-	for i := 0; i < n; i++ {
-		reg := uint8(i % numRegs)
-		instrs = append(instrs, EncodeADDImm(reg, reg, 1, false))
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+func buildArithmeticSequential(n, numRegs int) string {
+	return repeat(n, func(i int) string {
+		return fmt.Sprintf("add x%[1]d, x%[1]d, #1", i%numRegs)
+	}) + "svc #0\n"
 }
 
 // 1b. Arithmetic 6-Wide - Tests full 6-wide superscalar throughput
@@ -84,38 +95,10 @@ func arithmetic6Wide() Benchmark {
 		Setup: func(regFile *emu.RegFile, memory *emu.Memory) {
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 		},
-		Program: BuildProgram(
-			// 24 ADDs using 6 registers (X0-X5) - allows full 6-wide issue
-			// Group 1: instructions 0-5 (all independent, can issue together)
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			// Group 2: instructions 6-11 (RAW hazards with group 1, but forwarding allows issue)
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			// Group 3
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			// Group 4
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			EncodeSVC(0),
-		),
+		// 24 ADDs using 6 registers (X0-X5) - allows full 6-wide issue.
+		// Each group of 6 is independent; later groups have RAW hazards with
+		// the previous group, but forwarding allows issue.
+		Source:       buildArithmeticSequential(24, 6),
 		ExpectedExit: 4, // X0 = 0 + 4*1 = 4
 	}
 }
@@ -131,46 +114,10 @@ func arithmetic8Wide() Benchmark {
 			// Note: X8 is used as syscall number in ARM64 Linux convention
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 		},
-		Program: BuildProgram(
-			// 32 ADDs using 8 registers (X0-X7) - allows full 8-wide issue
-			// Group 1: instructions 0-7 (all independent, can issue together)
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			EncodeADDImm(6, 6, 1, false),
-			EncodeADDImm(7, 7, 1, false),
-			// Group 2: instructions 8-15 (RAW hazards with group 1, but forwarding allows issue)
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			EncodeADDImm(6, 6, 1, false),
-			EncodeADDImm(7, 7, 1, false),
-			// Group 3
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			EncodeADDImm(6, 6, 1, false),
-			EncodeADDImm(7, 7, 1, false),
-			// Group 4
-			EncodeADDImm(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-			EncodeADDImm(2, 2, 1, false),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(4, 4, 1, false),
-			EncodeADDImm(5, 5, 1, false),
-			EncodeADDImm(6, 6, 1, false),
-			EncodeADDImm(7, 7, 1, false),
-			EncodeSVC(0),
-		),
+		// 32 ADDs using 8 registers (X0-X7) - allows full 8-wide issue.
+		// Each group of 8 is independent; later groups have RAW hazards with
+		// the previous group, but forwarding allows issue.
+		Source:       buildArithmeticSequential(32, 8),
 		ExpectedExit: 4, // X0 = 0 + 4*1 = 4
 	}
 }
@@ -188,18 +135,13 @@ func dependencyChain() Benchmark {
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 			regFile.WriteReg(0, 0)  // X0 = 0 (start value)
 		},
-		Program:      buildDependencyChain(200),
+		Source:       buildDependencyChain(200),
 		ExpectedExit: 200, // X0 = 0 + 200*1 = 200
 	}
 }
 
-func buildDependencyChain(n int) []byte {
-	instrs := make([]uint32, 0, n+1)
-	for i := 0; i < n; i++ {
-		instrs = append(instrs, EncodeADDImm(0, 0, 1, false))
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+func buildDependencyChain(n int) string {
+	return repeat(n, func(int) string { return "add x0, x0, #1" }) + "svc #0\n"
 }
 
 // 3. Memory Sequential - Tests cache/memory performance
@@ -212,22 +154,10 @@ func memorySequential() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = base address
 			regFile.WriteReg(0, 42)     // X0 = value to store/load
 		},
-		Program: BuildProgram(
-			// Store X0 to [X1], load back, repeat at different offsets
-			// Note: Between pairs (e.g., LDR X0 then STR X0), there's a load-use
-			// hazard that requires a stall to ensure correct behavior.
-			EncodeSTR64(0, 1, 0), EncodeLDR64(0, 1, 0),
-			EncodeSTR64(0, 1, 1), EncodeLDR64(0, 1, 1), // offset = 8 bytes
-			EncodeSTR64(0, 1, 2), EncodeLDR64(0, 1, 2),
-			EncodeSTR64(0, 1, 3), EncodeLDR64(0, 1, 3),
-			EncodeSTR64(0, 1, 4), EncodeLDR64(0, 1, 4),
-			EncodeSTR64(0, 1, 5), EncodeLDR64(0, 1, 5),
-			EncodeSTR64(0, 1, 6), EncodeLDR64(0, 1, 6),
-			EncodeSTR64(0, 1, 7), EncodeLDR64(0, 1, 7),
-			EncodeSTR64(0, 1, 8), EncodeLDR64(0, 1, 8),
-			EncodeSTR64(0, 1, 9), EncodeLDR64(0, 1, 9),
-			EncodeSVC(0),
-		),
+		// Store X0 to [X1], load back, repeat at different offsets
+		// Note: Between pairs (e.g., LDR X0 then STR X0), there's a load-use
+		// hazard that requires a stall to ensure correct behavior.
+		Source: buildMemorySequentialScaled(10),
 		// X0 starts at 42, and with proper load-use hazard handling for stores,
 		// the value is preserved through all store-load pairs.
 		ExpectedExit: 42,
@@ -244,19 +174,19 @@ func functionCalls() Benchmark {
 			regFile.WriteReg(0, 0)  // X0 = 0 (result)
 			regFile.SP = 0x10000    // Stack pointer
 		},
-		Program: BuildProgram(
+		Source: `
 			// main: call add_one 5 times
-			EncodeBL(24), // BL add_one (offset = 6 instrs = 24 bytes)
-			EncodeBL(20), // BL add_one
-			EncodeBL(16), // BL add_one
-			EncodeBL(12), // BL add_one
-			EncodeBL(8),  // BL add_one
-			EncodeSVC(0), // exit with X0
-
-			// add_one function (at offset 24)
-			EncodeADDImm(0, 0, 1, false), // X0 += 1
-			EncodeRET(),                  // return
-		),
+			bl add_one
+			bl add_one
+			bl add_one
+			bl add_one
+			bl add_one
+			svc #0              // exit with X0
+
+		add_one:
+			add x0, x0, #1      // X0 += 1
+			ret
+		`,
 		ExpectedExit: 5, // 5 calls * 1 add = 5
 	}
 }
@@ -270,30 +200,10 @@ func branchTaken() Benchmark {
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 			regFile.WriteReg(0, 0)  // X0 = 0 (result)
 		},
-		Program: BuildProgram(
-			// Jump over NOP-like instructions
-			EncodeB(8),                    // B +8 (skip next instr)
-			EncodeADDImm(1, 1, 99, false), // skipped
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-
-			EncodeB(8),                    // B +8
-			EncodeADDImm(1, 1, 99, false), // skipped
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-
-			EncodeB(8),                    // B +8
-			EncodeADDImm(1, 1, 99, false), // skipped
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-
-			EncodeB(8),                    // B +8
-			EncodeADDImm(1, 1, 99, false), // skipped
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-
-			EncodeB(8),                    // B +8
-			EncodeADDImm(1, 1, 99, false), // skipped
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-
-			EncodeSVC(0), // exit with X0 = 5
-		),
+		// Jump over NOP-like instructions
+		Source: repeat(5, func(int) string {
+			return "b 1f; add x1, x1, #99; 1: add x0, x0, #1" // skip the X1 update, X0 += 1
+		}) + "svc #0\n", // exit with X0 = 5
 		ExpectedExit: 5,
 	}
 }
@@ -309,24 +219,17 @@ func branchTakenConditional() Benchmark {
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 			regFile.WriteReg(0, 0)  // X0 = 0 (result, always >= 0)
 		},
-		Program:      buildBranchConditionalChain(numBranches),
+		Source:       buildBranchConditionalChain(numBranches),
 		ExpectedExit: int64(numBranches),
 	}
 }
 
-func buildBranchConditionalChain(n int) []byte {
-	// Each branch pattern: CMP X0, #0; B.GE +8 (skip 1); ADD X1 (skipped); ADD X0 += 1
-	instrs := make([]uint32, 0, n*4+1)
-	for i := 0; i < n; i++ {
-		instrs = append(instrs,
-			EncodeCMPImm(0, 0),            // CMP X0, #0
-			EncodeBCond(8, 10),            // B.GE +8 (CondGE = 10, always taken)
-			EncodeADDImm(1, 1, 99, false), // skipped
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-		)
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+func buildBranchConditionalChain(n int) string {
+	// Each branch pattern: CMP X0, #0; B.GE +8 (always taken, skips the
+	// X1 update); X0 += 1
+	return repeat(n, func(int) string {
+		return "cmp x0, #0; b.ge 1f; add x1, x1, #99; 1: add x0, x0, #1"
+	}) + "svc #0\n"
 }
 
 // 5c. Branch Hot Loop - Tests zero-cycle branch folding with hot branches
@@ -348,15 +251,13 @@ func branchHotLoop() Benchmark {
 			regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
 			regFile.WriteReg(0, 4)  // X0 = 4 (loop counter, reduced for CI)
 		},
-		Program: BuildProgram(
-			// loop:
-			EncodeSUBImm(0, 0, 1, false), // X0 = X0 - 1
-			EncodeCMPImm(0, 0),           // CMP X0, #0
-			EncodeBCond(-8, 1),           // B.NE loop (-8 bytes = -2 instructions), CondNE = 1
-
-			// exit: After loop, X0 = 0
-			EncodeSVC(0), // exit with X0 = 0
-		),
+		Source: `
+		loop:
+			sub x0, x0, #1
+			cmp x0, #0
+			b.ne loop
+			svc #0              // exit with X0 = 0
+		`,
 		ExpectedExit: 0,
 	}
 }
@@ -372,33 +273,33 @@ func mixedOperations() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = buffer address
 			regFile.SP = 0x10000
 		},
-		Program: BuildProgram(
+		Source: `
 			// Iteration 1: compute, store, load, call
-			EncodeADDImm(2, 0, 10, false), // X2 = X0 + 10 = 10
-			EncodeSTR64(2, 1, 0),          // [X1] = X2
-			EncodeLDR64(3, 1, 0),          // X3 = [X1]
-			EncodeADDReg(0, 0, 3, false),  // X0 += X3
-			EncodeBL(44),                  // BL add_five
+			add x2, x0, #10     // X2 = X0 + 10 = 10
+			str x2, [x1]        // [X1] = X2
+			ldr x3, [x1]        // X3 = [X1]
+			add x0, x0, x3      // X0 += X3
+			bl add_five
 
 			// Iteration 2
-			EncodeADDImm(2, 0, 10, false),
-			EncodeSTR64(2, 1, 1),
-			EncodeLDR64(3, 1, 1),
-			EncodeADDReg(0, 0, 3, false),
-			EncodeBL(24),
+			add x2, x0, #10
+			str x2, [x1, #8]
+			ldr x3, [x1, #8]
+			add x0, x0, x3
+			bl add_five
 
 			// Iteration 3
-			EncodeADDImm(2, 0, 10, false),
-			EncodeSTR64(2, 1, 2),
-			EncodeLDR64(3, 1, 2),
-			EncodeADDReg(0, 0, 3, false),
+			add x2, x0, #10
+			str x2, [x1, #16]
+			ldr x3, [x1, #16]
+			add x0, x0, x3
 
-			EncodeSVC(0), // exit with X0
+			svc #0              // exit with X0
 
-			// add_five function
-			EncodeADDImm(0, 0, 5, false),
-			EncodeRET(),
-		),
+		add_five:
+			add x0, x0, #5
+			ret
+		`,
 		// iter1: X0=0, X2=10, X3=10, X0=10, call +5 → X0=15
 		// iter2: X0=15, X2=25, X3=25, X0=40, call +5 → X0=45
 		// iter3: X0=45, X2=55, X3=55, X0=100
@@ -406,15 +307,6 @@ func mixedOperations() Benchmark {
 	}
 }
 
-// EncodeB encodes unconditional branch: B offset
-func EncodeB(offset int32) uint32 {
-	var inst uint32 = 0
-	inst |= 0b000101 << 26 // B opcode
-	imm26 := uint32(offset/4) & 0x3FFFFFF
-	inst |= imm26
-	return inst
-}
-
 // 7. Matrix Operations - Tests computation with memory access pattern
 // Loads values from memory, performs computations, stores results
 // Note: Uses ADD instead of MUL since scalar MUL isn't implemented yet
@@ -444,38 +336,38 @@ func matrixMultiply2x2() Benchmark {
 		// Compute C[i] = A[i] + B[i] for i = 0..3
 		// C = [11, 22, 33, 44]
 		// Return sum of C = 11 + 22 + 33 + 44 = 110
-		Program: BuildProgram(
+		Source: `
 			// Load A array into X10-X13
-			EncodeLDR64(10, 1, 0), // X10 = A[0] = 10
-			EncodeLDR64(11, 1, 1), // X11 = A[1] = 20
-			EncodeLDR64(12, 1, 2), // X12 = A[2] = 30
-			EncodeLDR64(13, 1, 3), // X13 = A[3] = 40
+			ldr x10, [x1]           // X10 = A[0] = 10
+			ldr x11, [x1, #8]       // X11 = A[1] = 20
+			ldr x12, [x1, #16]      // X12 = A[2] = 30
+			ldr x13, [x1, #24]      // X13 = A[3] = 40
 
 			// Load B array into X14-X17
-			EncodeLDR64(14, 2, 0), // X14 = B[0] = 1
-			EncodeLDR64(15, 2, 1), // X15 = B[1] = 2
-			EncodeLDR64(16, 2, 2), // X16 = B[2] = 3
-			EncodeLDR64(17, 2, 3), // X17 = B[3] = 4
+			ldr x14, [x2]           // X14 = B[0] = 1
+			ldr x15, [x2, #8]       // X15 = B[1] = 2
+			ldr x16, [x2, #16]      // X16 = B[2] = 3
+			ldr x17, [x2, #24]      // X17 = B[3] = 4
 
 			// Compute C[i] = A[i] + B[i]
-			EncodeADDReg(20, 10, 14, false), // X20 = 10 + 1 = 11
-			EncodeADDReg(21, 11, 15, false), // X21 = 20 + 2 = 22
-			EncodeADDReg(22, 12, 16, false), // X22 = 30 + 3 = 33
-			EncodeADDReg(23, 13, 17, false), // X23 = 40 + 4 = 44
+			add x20, x10, x14       // X20 = 10 + 1 = 11
+			add x21, x11, x15       // X21 = 20 + 2 = 22
+			add x22, x12, x16       // X22 = 30 + 3 = 33
+			add x23, x13, x17       // X23 = 40 + 4 = 44
 
 			// Store C array
-			EncodeSTR64(20, 3, 0), // C[0] = 11
-			EncodeSTR64(21, 3, 1), // C[1] = 22
-			EncodeSTR64(22, 3, 2), // C[2] = 33
-			EncodeSTR64(23, 3, 3), // C[3] = 44
+			str x20, [x3]           // C[0] = 11
+			str x21, [x3, #8]       // C[1] = 22
+			str x22, [x3, #16]      // C[2] = 33
+			str x23, [x3, #24]      // C[3] = 44
 
 			// Sum all C elements for exit code: 11 + 22 + 33 + 44 = 110
-			EncodeADDReg(0, 20, 21, false), // X0 = 11 + 22 = 33
-			EncodeADDReg(0, 0, 22, false),  // X0 = 33 + 33 = 66
-			EncodeADDReg(0, 0, 23, false),  // X0 = 66 + 44 = 110
+			add x0, x20, x21        // X0 = 11 + 22 = 33
+			add x0, x0, x22         // X0 = 33 + 33 = 66
+			add x0, x0, x23         // X0 = 66 + 44 = 110
 
-			EncodeSVC(0),
-		),
+			svc #0
+		`,
 		ExpectedExit: 110,
 	}
 }
@@ -493,49 +385,9 @@ func loopSimulation() Benchmark {
 		},
 		// Simulate: for i := 0; i < 10; i++ { sum += i }
 		// Result: 0 + 1 + 2 + 3 + 4 + 5 + 6 + 7 + 8 + 9 = 45
-		Program: BuildProgram(
-			// Iteration 0: sum += 0, i++
-			EncodeADDReg(0, 0, 1, false), // sum += i
-			EncodeADDImm(1, 1, 1, false), // i++
-
-			// Iteration 1: sum += 1, i++
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 2
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 3
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 4
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 5
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 6
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 7
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 8
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			// Iteration 9
-			EncodeADDReg(0, 0, 1, false),
-			EncodeADDImm(1, 1, 1, false),
-
-			EncodeSVC(0),
-		),
+		Source: repeat(10, func(int) string {
+			return "add x0, x0, x1; add x1, x1, #1" // sum += i; i++
+		}) + "svc #0\n",
 		ExpectedExit: 45,
 	}
 }
@@ -553,16 +405,9 @@ func memoryStrided() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = base address
 			regFile.WriteReg(0, 0)      // X0 = initial value
 		},
-		Program: BuildProgram(
-			// 5 chains of STR X0 → LDR X2 → ADD X0, X2, #1
-			// Matches native: str x0,[sp,#off]; ldr x2,[sp,#off]; add x0,x2,#1
-			EncodeSTR64(0, 1, 0), EncodeLDR64(2, 1, 0), EncodeADDImm(0, 2, 1, false),
-			EncodeSTR64(0, 1, 4), EncodeLDR64(2, 1, 4), EncodeADDImm(0, 2, 1, false),
-			EncodeSTR64(0, 1, 8), EncodeLDR64(2, 1, 8), EncodeADDImm(0, 2, 1, false),
-			EncodeSTR64(0, 1, 12), EncodeLDR64(2, 1, 12), EncodeADDImm(0, 2, 1, false),
-			EncodeSTR64(0, 1, 16), EncodeLDR64(2, 1, 16), EncodeADDImm(0, 2, 1, false),
-			EncodeSVC(0),
-		),
+		// 5 chains of STR X0 → LDR X2 → ADD X0, X2, #1
+		// Matches native: str x0,[sp,#off]; ldr x2,[sp,#off]; add x0,x2,#1
+		Source:       buildMemoryStridedScaled(5),
 		ExpectedExit: 5, // X0 = 0 + 5 increments = 5
 	}
 }
@@ -579,22 +424,16 @@ func memorySequentialScaled() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = base address
 			regFile.WriteReg(0, 42)     // X0 = value to store/load
 		},
-		Program:      buildMemorySequentialScaled(numPairs),
+		Source:       buildMemorySequentialScaled(numPairs),
 		ExpectedExit: 42,
 	}
 }
 
-func buildMemorySequentialScaled(numPairs int) []byte {
-	instrs := make([]uint32, 0, numPairs*2+1)
-	for i := 0; i < numPairs; i++ {
-		offset := uint16(i) // sequential offsets: 0, 1, 2, ... (each 8 bytes)
-		instrs = append(instrs,
-			EncodeSTR64(0, 1, offset),
-			EncodeLDR64(0, 1, offset),
-		)
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+func buildMemorySequentialScaled(numPairs int) string {
+	// Sequential offsets: 0, 8, 16, ...
+	return repeat(numPairs, func(i int) string {
+		return fmt.Sprintf("str x0, [x1, #%[1]d]; ldr x0, [x1, #%[1]d]", i*8)
+	}) + "svc #0\n"
 }
 
 // 9c. Memory Strided Scaled - 200 strided store/load/add chains (stride = 4 elements = 32 bytes)
@@ -609,23 +448,16 @@ func memoryStridedScaled() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = base address
 			regFile.WriteReg(0, 0)      // X0 = initial value
 		},
-		Program:      buildMemoryStridedScaled(numChains),
+		Source:       buildMemoryStridedScaled(numChains),
 		ExpectedExit: int64(numChains), // X0 incremented once per chain
 	}
 }
 
-func buildMemoryStridedScaled(numChains int) []byte {
-	instrs := make([]uint32, 0, numChains*3+1)
-	for i := 0; i < numChains; i++ {
-		offset := uint16(i * 4) // stride-4 offsets: 0, 4, 8, 12, ... (each unit = 8 bytes)
-		instrs = append(instrs,
-			EncodeSTR64(0, 1, offset),    // STR X0, [X1, #offset]
-			EncodeLDR64(2, 1, offset),    // LDR X2, [X1, #offset]
-			EncodeADDImm(0, 2, 1, false), // ADD X0, X2, #1
-		)
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+func buildMemoryStridedScaled(numChains int) string {
+	// Stride-4 offsets: 0, 32, 64, ...
+	return repeat(numChains, func(i int) string {
+		return fmt.Sprintf("str x0, [x1, #%[1]d]; ldr x2, [x1, #%[1]d]; add x0, x2, #1", i*32)
+	}) + "svc #0\n"
 }
 
 // 9d. Memory Random Access - 200 store/load pairs with pseudo-random offsets
@@ -641,31 +473,21 @@ func memoryRandomAccess() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = base address
 			regFile.WriteReg(0, 13)     // X0 = value to store/load
 		},
-		Program:      buildMemoryRandomAccess(numPairs),
+		Source:       buildMemoryRandomAccess(numPairs),
 		ExpectedExit: 13,
 	}
 }
 
-func buildMemoryRandomAccess(numPairs int) []byte {
+func buildMemoryRandomAccess(numPairs int) string {
 	// Generate pseudo-random offsets using a simple LCG permutation.
 	// Offsets span a 3200-element range (25600 bytes) to create
 	// scattered access across multiple cache lines.
-	offsets := make([]uint16, numPairs)
 	x := uint32(7) // seed
-	for i := 0; i < numPairs; i++ {
+	return repeat(numPairs, func(int) string {
 		x = (x*1103515245 + 12345) & 0x7FFFFFFF
-		offsets[i] = uint16(x % 3200) // max offset 3200 * 8 = 25600 bytes
-	}
-
-	instrs := make([]uint32, 0, numPairs*2+1)
-	for i := 0; i < numPairs; i++ {
-		instrs = append(instrs,
-			EncodeSTR64(0, 1, offsets[i]),
-			EncodeLDR64(0, 1, offsets[i]),
-		)
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+		offset := x % 3200 * 8 // max offset 3200 * 8 = 25600 bytes
+		return fmt.Sprintf("str x0, [x1, #%[1]d]; ldr x0, [x1, #%[1]d]", offset)
+	}) + "svc #0\n"
 }
 
 // 10. Load Heavy - Instruction mix dominated by loads
@@ -675,6 +497,9 @@ func buildMemoryRandomAccess(numPairs int) []byte {
 // 20 LDR + 3-instruction loop overhead, instructions_per_iter=23.
 // Total retired: 10*23 = 230 instructions (SVC terminates, not retired).
 func loadHeavy() Benchmark {
+	// 20 loads to distinct registers (no WAW/RAW hazards); the last one
+	// loads X0 = 20, the exit code after the last iteration.
+	destRegs := []int{22, 2, 3, 4, 5, 6, 7, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 0}
 	return Benchmark{
 		Name:        "load_heavy",
 		Description: "20 loads from sequential addresses - measures load throughput",
@@ -687,33 +512,14 @@ func loadHeavy() Benchmark {
 				memory.Write64(0x8000+i*8, i+1)
 			}
 		},
-		Program: BuildProgram(
-			// loop: 20 loads to distinct registers (no WAW/RAW hazards)
-			EncodeLDR64(22, 1, 0),
-			EncodeLDR64(2, 1, 1),
-			EncodeLDR64(3, 1, 2),
-			EncodeLDR64(4, 1, 3),
-			EncodeLDR64(5, 1, 4),
-			EncodeLDR64(6, 1, 5),
-			EncodeLDR64(7, 1, 6),
-			EncodeLDR64(9, 1, 7),
-			EncodeLDR64(10, 1, 8),
-			EncodeLDR64(11, 1, 9),
-			EncodeLDR64(12, 1, 10),
-			EncodeLDR64(13, 1, 11),
-			EncodeLDR64(14, 1, 12),
-			EncodeLDR64(15, 1, 13),
-			EncodeLDR64(16, 1, 14),
-			EncodeLDR64(17, 1, 15),
-			EncodeLDR64(18, 1, 16),
-			EncodeLDR64(19, 1, 17),
-			EncodeLDR64(20, 1, 18),
-			EncodeLDR64(0, 1, 19),          // X0 = 20 (exit code after last iter)
-			EncodeSUBImm(21, 21, 1, false), // X21 = X21 - 1
-			EncodeCMPImm(21, 0),            // CMP X21, #0
-			EncodeBCond(-88, 1),            // B.NE loop (-88 = -22 instructions * 4)
-			EncodeSVC(0),
-		),
+		Source: "loop:\n" + repeat(len(destRegs), func(i int) string {
+			return fmt.Sprintf("ldr x%d, [x1, #%d]", destRegs[i], i*8)
+		}) + `
+			sub x21, x21, #1
+			cmp x21, #0
+			b.ne loop
+			svc #0
+		`,
 		ExpectedExit: 20,
 	}
 }
@@ -735,33 +541,15 @@ func storeHeavy() Benchmark {
 			regFile.WriteReg(2, 99)     // X2 = value to store
 			regFile.WriteReg(21, 10)    // X21 = loop counter (10 iterations)
 		},
-		Program: BuildProgram(
-			// loop: 20 stores to sequential addresses (no data dependencies)
-			EncodeSTR64(2, 1, 0),
-			EncodeSTR64(2, 1, 1),
-			EncodeSTR64(2, 1, 2),
-			EncodeSTR64(2, 1, 3),
-			EncodeSTR64(2, 1, 4),
-			EncodeSTR64(2, 1, 5),
-			EncodeSTR64(2, 1, 6),
-			EncodeSTR64(2, 1, 7),
-			EncodeSTR64(2, 1, 8),
-			EncodeSTR64(2, 1, 9),
-			EncodeSTR64(2, 1, 10),
-			EncodeSTR64(2, 1, 11),
-			EncodeSTR64(2, 1, 12),
-			EncodeSTR64(2, 1, 13),
-			EncodeSTR64(2, 1, 14),
-			EncodeSTR64(2, 1, 15),
-			EncodeSTR64(2, 1, 16),
-			EncodeSTR64(2, 1, 17),
-			EncodeSTR64(2, 1, 18),
-			EncodeSTR64(2, 1, 19),
-			EncodeSUBImm(21, 21, 1, false), // X21 = X21 - 1
-			EncodeCMPImm(21, 0),            // CMP X21, #0
-			EncodeBCond(-88, 1),            // B.NE loop (-88 = -22 instructions * 4)
-			EncodeSVC(0),
-		),
+		// 20 stores to sequential addresses (no data dependencies)
+		Source: "loop:\n" + repeat(20, func(i int) string {
+			return fmt.Sprintf("str x2, [x1, #%d]", i*8)
+		}) + `
+			sub x21, x21, #1
+			cmp x21, #0
+			b.ne loop
+			svc #0
+		`,
 		ExpectedExit: 3,
 	}
 }
@@ -779,23 +567,21 @@ func loadHeavyScaled() Benchmark {
 				memory.Write64(0x8000+i*8, i+1)
 			}
 		},
-		Program:      buildLoadHeavyScaled(numLoads),
+		Source:       buildLoadHeavyScaled(numLoads),
 		ExpectedExit: int64(numLoads),
 	}
 }
 
-func buildLoadHeavyScaled(n int) []byte {
+func buildLoadHeavyScaled(n int) string {
 	// Use registers X0, X2-X7, X9-X20 (avoid X1=base, X8=syscall)
-	destRegs := []uint8{0, 2, 3, 4, 5, 6, 7, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
-	instrs := make([]uint32, 0, n+1)
-	for i := 0; i < n; i++ {
+	destRegs := []int{0, 2, 3, 4, 5, 6, 7, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	return repeat(n, func(i int) string {
 		reg := destRegs[i%len(destRegs)]
-		instrs = append(instrs, EncodeLDR64(reg, 1, uint16(i)))
-	}
-	// Final load into X0 for exit code
-	instrs[n-1] = EncodeLDR64(0, 1, uint16(n-1))
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+		if i == n-1 {
+			reg = 0 // Final load into X0 for exit code
+		}
+		return fmt.Sprintf("ldr x%d, [x1, #%d]", reg, i*8)
+	}) + "svc #0\n"
 }
 
 // 11b. Store Heavy Scaled - 200 independent stores to amortize cold miss overhead
@@ -810,18 +596,15 @@ func storeHeavyScaled() Benchmark {
 			regFile.WriteReg(1, 0x8000) // X1 = base address
 			regFile.WriteReg(2, 99)     // X2 = value to store
 		},
-		Program:      buildStoreHeavyScaled(numStores),
+		Source:       buildStoreHeavyScaled(numStores),
 		ExpectedExit: 3,
 	}
 }
 
-func buildStoreHeavyScaled(n int) []byte {
-	instrs := make([]uint32, 0, n+1)
-	for i := 0; i < n; i++ {
-		instrs = append(instrs, EncodeSTR64(2, 1, uint16(i)))
-	}
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
+func buildStoreHeavyScaled(n int) string {
+	return repeat(n, func(i int) string {
+		return fmt.Sprintf("str x2, [x1, #%d]", i*8)
+	}) + "svc #0\n"
 }
 
 // 12. Branch Heavy - High branch density to stress branch prediction
@@ -835,72 +618,15 @@ func branchHeavy() Benchmark {
 			regFile.WriteReg(0, 0)  // X0 = 0 (result counter)
 			regFile.WriteReg(1, 5)  // X1 = 5 (comparison value)
 		},
-		Program: BuildProgram(
-			// Pattern: CMP X0, X1; B.LT +8 (taken while X0 < 5)
-			// Then increment X0, so first 5 branches taken, last 5 not taken
-
-			// Branch 1: X0=0 < 5, taken (skip ADD X1)
-			EncodeCMPReg(0, 1),            // CMP X0, X1
-			EncodeBCond(8, 11),            // B.LT +8 (CondLT = 11)
-			EncodeADDImm(1, 1, 99, false), // skipped (would corrupt X1)
-			EncodeADDImm(0, 0, 1, false),  // X0 += 1
-
-			// Branch 2: X0=1 < 5, taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(1, 1, 99, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 3: X0=2 < 5, taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(1, 1, 99, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 4: X0=3 < 5, taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(1, 1, 99, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 5: X0=4 < 5, taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(1, 1, 99, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 6: X0=5 >= 5, NOT taken (falls through to corrupt + add)
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(3, 3, 1, false), // X3 += 1 (not-taken counter)
-			EncodeADDImm(0, 0, 1, false), // X0 += 1
-
-			// Branch 7: X0=6 >= 5, NOT taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 8: X0=7 >= 5, NOT taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 9: X0=8 >= 5, NOT taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			// Branch 10: X0=9 >= 5, NOT taken
-			EncodeCMPReg(0, 1),
-			EncodeBCond(8, 11),
-			EncodeADDImm(3, 3, 1, false),
-			EncodeADDImm(0, 0, 1, false),
-
-			EncodeSVC(0), // exit with X0 = 10
-		),
+		// Pattern: CMP X0, X1; B.LT +8 (taken while X0 < 5)
+		// Then increment X0, so first 5 branches taken, last 5 not taken.
+		// Taken branches skip an update of X1; not-taken branches fall
+		// through to X3 += 1 (the not-taken counter).
+		Source: repeat(5, func(int) string {
+			return "cmp x0, x1; b.lt 1f; add x1, x1, #99; 1: add x0, x0, #1"
+		}) + repeat(5, func(int) string {
+			return "cmp x0, x1; b.lt 1f; add x3, x3, #1; 1: add x0, x0, #1"
+		}) + "svc #0\n", // exit with X0 = 10
 		ExpectedExit: 10,
 	}
 }
@@ -925,17 +651,16 @@ func vectorSum() Benchmark {
 			}
 		},
 		// sum = 1+2+3+...+16 = 136; exit code = 136
-		Program: BuildProgram(
-			// loop:
-			EncodeLDR64(4, 1, 0),         // X4 = A[X1] (load current element)
-			EncodeADDReg(0, 0, 4, false), // sum += X4
-			EncodeADDImm(1, 1, 8, false), // X1 += 8 (advance pointer)
-			EncodeADDImm(2, 2, 1, false), // i++
-			EncodeCMPReg(2, 3),           // CMP i, N
-			EncodeBCond(-20, 11),         // B.LT loop (-20 bytes = -5 instructions)
-			// exit:
-			EncodeSVC(0), // exit with X0 = 136
-		),
+		Source: `
+		loop:
+			ldr x4, [x1]        // X4 = A[X1] (load current element)
+			add x0, x0, x4      // sum += X4
+			add x1, x1, #8      // X1 += 8 (advance pointer)
+			add x2, x2, #1      // i++
+			cmp x2, x3          // CMP i, N
+			b.lt loop
+			svc #0              // exit with X0 = 136
+		`,
 		ExpectedExit: 136, // sum(1..16) = 136
 	}
 }
@@ -963,23 +688,24 @@ func vectorAdd() Benchmark {
 		},
 		// C[i] = A[i]+B[i] = 3*(i+1)
 		// Verify: load C[0] = 3 as exit code
-		Program: BuildProgram(
-			// loop:
-			EncodeLDR64(6, 1, 0),         // X6 = A[i]
-			EncodeLDR64(7, 2, 0),         // X7 = B[i]
-			EncodeADDReg(9, 6, 7, false), // X9 = A[i] + B[i]
-			EncodeSTR64(9, 3, 0),         // C[i] = X9
-			EncodeADDImm(1, 1, 8, false), // A ptr += 8
-			EncodeADDImm(2, 2, 8, false), // B ptr += 8
-			EncodeADDImm(3, 3, 8, false), // C ptr += 8
-			EncodeADDImm(4, 4, 1, false), // i++
-			EncodeCMPReg(4, 5),           // CMP i, N
-			EncodeBCond(-36, 11),         // B.LT loop (-36 bytes = -9 instructions)
+		Source: `
+		loop:
+			ldr x6, [x1]        // X6 = A[i]
+			ldr x7, [x2]        // X7 = B[i]
+			add x9, x6, x7      // X9 = A[i] + B[i]
+			str x9, [x3]        // C[i] = X9
+			add x1, x1, #8      // A ptr += 8
+			add x2, x2, #8      // B ptr += 8
+			add x3, x3, #8      // C ptr += 8
+			add x4, x4, #1      // i++
+			cmp x4, x5          // CMP i, N
+			b.lt loop
+
 			// Verify: load C[0] as exit code
-			EncodeSUBImm(3, 3, 128, false), // X3 -= 128 (back to C base: 16*8=128)
-			EncodeLDR64(0, 3, 0),           // X0 = C[0] = 3
-			EncodeSVC(0),
-		),
+			sub x3, x3, #128    // back to C base: 16*8=128
+			ldr x0, [x3]        // X0 = C[0] = 3
+			svc #0
+		`,
 		ExpectedExit: 3, // C[0] = A[0]+B[0] = 1+2 = 3
 	}
 }
@@ -1000,50 +726,50 @@ func reductionTree() Benchmark {
 			}
 		},
 		// Tree reduction: sum(1..16) = 136
-		Program: BuildProgram(
+		Source: `
 			// Load all 16 elements into registers (level 0)
-			EncodeLDR64(0, 1, 0),   // r0 = 1
-			EncodeLDR64(2, 1, 1),   // r2 = 2
-			EncodeLDR64(3, 1, 2),   // r3 = 3
-			EncodeLDR64(4, 1, 3),   // r4 = 4
-			EncodeLDR64(5, 1, 4),   // r5 = 5
-			EncodeLDR64(6, 1, 5),   // r6 = 6
-			EncodeLDR64(7, 1, 6),   // r7 = 7
-			EncodeLDR64(9, 1, 7),   // r9 = 8
-			EncodeLDR64(10, 1, 8),  // r10 = 9
-			EncodeLDR64(11, 1, 9),  // r11 = 10
-			EncodeLDR64(12, 1, 10), // r12 = 11
-			EncodeLDR64(13, 1, 11), // r13 = 12
-			EncodeLDR64(14, 1, 12), // r14 = 13
-			EncodeLDR64(15, 1, 13), // r15 = 14
-			EncodeLDR64(16, 1, 14), // r16 = 15
-			EncodeLDR64(17, 1, 15), // r17 = 16
+			ldr x0, [x1]            // r0 = 1
+			ldr x2, [x1, #8]        // r2 = 2
+			ldr x3, [x1, #16]       // r3 = 3
+			ldr x4, [x1, #24]       // r4 = 4
+			ldr x5, [x1, #32]       // r5 = 5
+			ldr x6, [x1, #40]       // r6 = 6
+			ldr x7, [x1, #48]       // r7 = 7
+			ldr x9, [x1, #56]       // r9 = 8
+			ldr x10, [x1, #64]      // r10 = 9
+			ldr x11, [x1, #72]      // r11 = 10
+			ldr x12, [x1, #80]      // r12 = 11
+			ldr x13, [x1, #88]      // r13 = 12
+			ldr x14, [x1, #96]      // r14 = 13
+			ldr x15, [x1, #104]     // r15 = 14
+			ldr x16, [x1, #112]     // r16 = 15
+			ldr x17, [x1, #120]     // r17 = 16
 
 			// Level 1: 8 independent pairwise sums
-			EncodeADDReg(0, 0, 2, false),    // r0 = 1+2 = 3
-			EncodeADDReg(3, 3, 4, false),    // r3 = 3+4 = 7
-			EncodeADDReg(5, 5, 6, false),    // r5 = 5+6 = 11
-			EncodeADDReg(7, 7, 9, false),    // r7 = 7+8 = 15
-			EncodeADDReg(10, 10, 11, false), // r10 = 9+10 = 19
-			EncodeADDReg(12, 12, 13, false), // r12 = 11+12 = 23
-			EncodeADDReg(14, 14, 15, false), // r14 = 13+14 = 27
-			EncodeADDReg(16, 16, 17, false), // r16 = 15+16 = 31
+			add x0, x0, x2          // r0 = 1+2 = 3
+			add x3, x3, x4          // r3 = 3+4 = 7
+			add x5, x5, x6          // r5 = 5+6 = 11
+			add x7, x7, x9          // r7 = 7+8 = 15
+			add x10, x10, x11       // r10 = 9+10 = 19
+			add x12, x12, x13       // r12 = 11+12 = 23
+			add x14, x14, x15       // r14 = 13+14 = 27
+			add x16, x16, x17       // r16 = 15+16 = 31
 
 			// Level 2: 4 independent sums
-			EncodeADDReg(0, 0, 3, false),    // r0 = 3+7 = 10
-			EncodeADDReg(5, 5, 7, false),    // r5 = 11+15 = 26
-			EncodeADDReg(10, 10, 12, false), // r10 = 19+23 = 42
-			EncodeADDReg(14, 14, 16, false), // r14 = 27+31 = 58
+			add x0, x0, x3          // r0 = 3+7 = 10
+			add x5, x5, x7          // r5 = 11+15 = 26
+			add x10, x10, x12       // r10 = 19+23 = 42
+			add x14, x14, x16       // r14 = 27+31 = 58
 
 			// Level 3: 2 independent sums
-			EncodeADDReg(0, 0, 5, false),    // r0 = 10+26 = 36
-			EncodeADDReg(10, 10, 14, false), // r10 = 42+58 = 100
+			add x0, x0, x5          // r0 = 10+26 = 36
+			add x10, x10, x14       // r10 = 42+58 = 100
 
 			// Level 4: final sum
-			EncodeADDReg(0, 0, 10, false), // r0 = 36+100 = 136
+			add x0, x0, x10         // r0 = 36+100 = 136
 
-			EncodeSVC(0), // exit with X0 = 136
-		),
+			svc #0                  // exit with X0 = 136
+		`,
 		ExpectedExit: 136, // sum(1..16) = 136
 	}
 }
@@ -1074,43 +800,22 @@ func strideIndirect() Benchmark {
 			memory.Write64(0x8000+4*8, 6)
 			memory.Write64(0x8000+6*8, 0)
 		},
-		Program: buildStrideIndirect(n),
+		// The loop body is 6 instructions per hop, matching the native LSL
+		// encoding; ADD Rd, Rn, XZR is the register move.
+		Source: `
+		loop:
+			add x5, xzr, x2, lsl #3 // X5 = X2 * 8
+			add x5, x1, x5          // X5 = base + offset*8
+			ldr x2, [x5]            // X2 = [X5] (next index)
+			add x3, x3, #1          // X3++ (hop count)
+			cmp x3, x4              // CMP X3, N
+			b.lt loop
+			add x0, x3, xzr         // X0 = X3 (hop count)
+			svc #0
+		`,
 		// After 8 hops: 0→3→1→5→2→7→4→6, X2 ends at 6; but final LDR loads A[6]=0
 		// Actually, after 8 hops: hop 1: load A[0]=3, hop 2: load A[3]=1, ...
 		// hop 8: load A[6]=0. So X2 = 0 at exit. Exit code = hop count = 8.
 		ExpectedExit: int64(n),
 	}
 }
-
-func buildStrideIndirect(n int) []byte {
-	// loop body (6 instructions per hop, matching native LSL encoding):
-	//   X5 = X2 << 3 (shift left by 3 for 8-byte elements) — ADD X5, XZR, X2, LSL #3
-	//   X5 = X1 + X5 (compute address)
-	//   X2 = [X5] (load next offset, encoded as LDR X2, [X5, #0])
-	//   X3++ (hop count)
-	//   CMP X3, X4
-	//   B.LT loop
-	instrs := []uint32{
-		// loop:
-		EncodeADDRegShifted(5, 31, 2, 3, 0, false), // X5 = XZR + (X2 << 3) = X2 * 8
-		EncodeADDReg(5, 1, 5, false),               // X5 = base + offset*8
-		EncodeLDR64(2, 5, 0),                       // X2 = [X5] (next index)
-		EncodeADDImm(3, 3, 1, false),               // X3++ (hop count)
-		EncodeCMPReg(3, 4),                         // CMP X3, N
-		EncodeBCond(-20, 11),                       // B.LT loop (-20 bytes = -5 instructions)
-		// exit:
-		EncodeADDReg(0, 3, 31, false), // X0 = X3 (hop count), XZR add
-	}
-	// Actually, ADD Rd, Rn, XZR is equivalent to MOV Rd, Rn
-	// X31 = XZR = 0 in register reads, so EncodeADDReg(0, 3, 31) = X0 = X3 + 0 = X3
-	instrs = append(instrs, EncodeSVC(0))
-	return BuildProgram(instrs...)
-}
-
-// EncodeCMPReg encodes compare register: CMP Xn, Xm
-// This is an alias for SUBS XZR, Xn, Xm (sets flags, discards result)
-func EncodeCMPReg(rn, rm uint8) uint32 {
-	return EncodeSUBReg(31, rn, rm, true)
-}
-
-// Note: encodeMUL removed - scalar MUL/MADD not yet implemented in simulator
@@ -4,6 +4,7 @@ package benchmarks
 import (
 	"testing"
 
+	"github.com/sarchlab/m2sim/asm"
 	"github.com/sarchlab/m2sim/emu"
 	"github.com/sarchlab/m2sim/timing/pipeline"
 )
@@ -28,11 +29,11 @@ func TestSimplePipeline(t *testing.T) {
 }
 
 func TestBenchmarkEncoding(t *testing.T) {
-	// Test the encoding functions
-	program := BuildProgram(
-		EncodeADDImm(8, 31, 93, false), // X8 = 93
-		EncodeSVC(0),
-	)
+	// Test a program built by the assembler
+	program := asm.MustAssemble(`
+		mov x8, #93
+		svc #0
+	`)
 
 	// Load and run
 	regFile := &emu.RegFile{}
@@ -60,11 +61,12 @@ func TestBenchmarkEncoding(t *testing.T) {
 func TestCountdownLoop(t *testing.T) {
 	// t.Skip("Skipped: timing pipeline doesn't update PSTATE flags, causing infinite loop")
 	// Simple countdown: X0 = 5, loop decrement until 0
-	program := BuildProgram(
-		EncodeSUBImm(0, 0, 1, true), // SUBS X0, X0, #1
-		EncodeBCond(-4, 1),          // B.NE -4 (back to SUBS)
-		EncodeSVC(0),                // exit with X0 = 0
-	)
+	program := asm.MustAssemble(`
+	loop:
+		subs x0, x0, #1
+		b.ne loop
+		svc #0              // exit with X0 = 0
+	`)
 
 	regFile := &emu.RegFile{}
 	regFile.WriteReg(8, 93) // X8 = 93 (exit syscall)
@@ -47,11 +47,13 @@ func specRoot() string {
 	return ""
 }
 
-// loadAndRunSPEC loads a SPEC benchmark binary and runs it in emulation mode.
+// loadAndRunSPEC loads a SPEC benchmark binary and runs it in emulation mode
+// with the given command-line arguments.
 // Returns (exitCode, instructionCount, error).
 func loadAndRunSPEC(
 	binaryPath string,
 	workDir string,
+	args []string,
 	maxInstructions uint64,
 ) (int64, uint64, error) {
 	prog, err := loader.Load(binaryPath)
@@ -62,14 +64,10 @@ func loadAndRunSPEC(
 	memory := emu.NewMemory()
 
 	// Load segments into memory
-	for _, seg := range prog.Segments {
-		for i, b := range seg.Data {
-			memory.Write8(seg.VirtAddr+uint64(i), b)
-		}
-		for i := uint64(len(seg.Data)); i < seg.MemSize; i++ {
-			memory.Write8(seg.VirtAddr+i, 0)
-		}
-	}
+	prog.LoadInto(memory)
+
+	// Build argc/argv/auxv on the initial stack
+	sp := prog.SetupStack(memory, append([]string{binaryPath}, args...), nil)
 
 	// Change to working directory so file I/O finds input files
 	if workDir != "" {
@@ -84,7 +82,7 @@ func loadAndRunSPEC(
 	stderrBuf := &bytes.Buffer{}
 
 	emulator := emu.NewEmulator(
-		emu.WithStackPointer(prog.InitialSP),
+		emu.WithStackPointer(sp),
 		emu.WithStdout(stdoutBuf),
 		emu.WithStderr(stderrBuf),
 		emu.WithMaxInstructions(maxInstructions),
@@ -137,7 +135,7 @@ func TestSPECExchange2Emulation(t *testing.T) {
 	// First pass: try with a small instruction limit to check for
 	// immediate failures (unsupported instructions).
 	const probeLimit = 100_000
-	exitCode, count, err := loadAndRunSPEC(binaryPath, workDir, probeLimit)
+	exitCode, count, err := loadAndRunSPEC(binaryPath, workDir, bench.TestArgs, probeLimit)
 	if err != nil {
 		t.Fatalf("Failed to load/run exchange2_r: %v", err)
 	}
@@ -184,7 +182,7 @@ func TestSPECAllBenchmarksProbe(t *testing.T) {
 				t.Skipf("run directory not set up: %s", workDir)
 			}
 
-			exitCode, count, err := loadAndRunSPEC(binaryPath, workDir, probeLimit)
+			exitCode, count, err := loadAndRunSPEC(binaryPath, workDir, bench.TestArgs, probeLimit)
 			if err != nil {
 				t.Fatalf("Failed to load: %v", err)
 			}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/sarchlab/m2sim/emu"
 )
 
 // SPECBenchmark represents a SPEC CPU 2017 benchmark configuration.
@@ -121,6 +123,17 @@ func (r *SPECRunner) GetWorkingDir(bench SPECBenchmark) string {
 	return filepath.Join(r.SPECRoot, bench.WorkingDir)
 }
 
+// InputFileSystem returns an in-memory filesystem holding the benchmark's
+// test input files, read from its run directory. The benchmark runs in it
+// with / as its working directory, and its writes never reach the SPEC tree.
+func (r *SPECRunner) InputFileSystem(bench SPECBenchmark) (*emu.MemFS, error) {
+	manifest := make(emu.Manifest)
+	for _, name := range bench.TestInputFiles {
+		manifest[name] = filepath.Join(r.GetWorkingDir(bench), name)
+	}
+	return emu.NewMemFS(manifest)
+}
+
 // ValidateSetup checks if SPEC is properly set up for running benchmarks.
 func (r *SPECRunner) ValidateSetup() error {
 	// Check for required SPEC structure
@@ -117,6 +117,25 @@ var _ = Describe("SPEC Runner", func() {
 			// Now should exist
 			Expect(runner.BinaryExists(bench)).To(BeTrue())
 		})
+
+		It("should stage the test input files in memory", func() {
+			bench := benchmarks.GetSPECBenchmarks()[1]
+			workDir := runner.GetWorkingDir(bench)
+			Expect(os.MkdirAll(workDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workDir, "inp.in"), []byte("input"), 0644)).To(Succeed())
+
+			fsys, err := runner.InputFileSystem(bench)
+			Expect(err).NotTo(HaveOccurred())
+			info, err := fsys.Stat("inp.in")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(Equal(int64(len("input"))))
+
+			_, err = fsys.OpenFile("inp.in", os.O_WRONLY|os.O_TRUNC, 0)
+			Expect(err).NotTo(HaveOccurred())
+			data, err := os.ReadFile(filepath.Join(workDir, "inp.in"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("input"))
+		})
 	})
 })
 
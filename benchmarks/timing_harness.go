@@ -2,13 +2,13 @@
 package benchmarks
 
 import (
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"time"
 
+	"github.com/sarchlab/m2sim/asm"
 	"github.com/sarchlab/m2sim/emu"
 	"github.com/sarchlab/m2sim/loader"
 	"github.com/sarchlab/m2sim/timing/cache"
@@ -68,6 +68,9 @@ type BenchmarkResult struct {
 	// ExitCode is the program's exit code
 	ExitCode int64 `json:"exit_code"`
 
+	// Error is why the benchmark could not be loaded, if it never ran
+	Error string `json:"error,omitempty"`
+
 	// WallTime is the actual time taken to run the simulation
 	WallTime time.Duration `json:"wall_time_ns"`
 }
@@ -83,10 +86,16 @@ type Benchmark struct {
 	// Setup prepares the emulator state (e.g., initialize registers, memory)
 	Setup func(regFile *emu.RegFile, memory *emu.Memory)
 
-	// Program is the ARM64 machine code to execute (used when ELFPath is empty)
+	// Program is the ARM64 machine code to execute (used when ELFPath and
+	// Source are empty)
 	Program []byte
 
-	// ELFPath is the path to an ARM64 ELF binary to load (overrides Program)
+	// Source is ARM64 assembly for the asm package, assembled at the load
+	// address (used when ELFPath is empty; overrides Program)
+	Source string
+
+	// ELFPath is the path to an ARM64 ELF binary to load (overrides Program
+	// and Source)
 	ELFPath string
 
 	// ExpectedExit is the expected exit code (for validation)
@@ -102,6 +111,15 @@ func BenchmarkFromELF(name, description, elfPath string) Benchmark {
 	}
 }
 
+// Code returns the machine code of an inline benchmark loaded at base,
+// assembling Source when it is set.
+func (b Benchmark) Code(base uint64) ([]byte, error) {
+	if b.Source == "" {
+		return b.Program, nil
+	}
+	return asm.AssembleAt(b.Source, base)
+}
+
 // HarnessConfig configures the benchmark harness.
 type HarnessConfig struct {
 	// EnableICache enables instruction cache simulation
@@ -211,24 +229,27 @@ func (h *Harness) runBenchmark(bench Benchmark) BenchmarkResult {
 				Name:        bench.Name,
 				Description: bench.Description,
 				ExitCode:    -1,
+				Error:       err.Error(),
 			}
 		}
 
-		for _, seg := range prog.Segments {
-			for i, b := range seg.Data {
-				memory.Write8(seg.VirtAddr+uint64(i), b)
-			}
-			for i := uint64(len(seg.Data)); i < seg.MemSize; i++ {
-				memory.Write8(seg.VirtAddr+i, 0)
-			}
-		}
+		prog.LoadInto(memory)
 
 		programAddr = prog.EntryPoint
 		regFile.SP = prog.InitialSP
 	} else {
 		// Load inline program at 0x1000
 		programAddr = uint64(0x1000)
-		for i, b := range bench.Program {
+		program, err := bench.Code(programAddr)
+		if err != nil {
+			return BenchmarkResult{
+				Name:        bench.Name,
+				Description: bench.Description,
+				ExitCode:    -1,
+				Error:       err.Error(),
+			}
+		}
+		for i, b := range program {
 			memory.Write8(programAddr+uint64(i), b)
 		}
 	}
@@ -312,6 +333,9 @@ func (h *Harness) PrintResults(results []BenchmarkResult) {
 		_, _ = fmt.Fprintf(h.config.Output, "Benchmark: %s\n", r.Name)
 		_, _ = fmt.Fprintf(h.config.Output, "  Description: %s\n", r.Description)
 		_, _ = fmt.Fprintf(h.config.Output, "  Exit Code: %d\n", r.ExitCode)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(h.config.Output, "  Error: %s\n", r.Error)
+		}
 		_, _ = fmt.Fprintln(h.config.Output, "  --- Timing ---")
 		_, _ = fmt.Fprintf(h.config.Output, "  Simulated Cycles:     %d\n", r.SimulatedCycles)
 		_, _ = fmt.Fprintf(h.config.Output, "  Instructions Retired: %d\n", r.InstructionsRetired)
@@ -375,190 +399,6 @@ func (h *Harness) PrintCSV(results []BenchmarkResult) {
 	}
 }
 
-// Helper functions for building ARM64 programs
-
-// BuildProgram assembles instruction words into a byte slice.
-func BuildProgram(instrs ...uint32) []byte {
-	program := make([]byte, 0, len(instrs)*4)
-	for _, inst := range instrs {
-		buf := make([]byte, 4)
-		binary.LittleEndian.PutUint32(buf, inst)
-		program = append(program, buf...)
-	}
-	return program
-}
-
-// Instruction encoding helpers (64-bit only for simplicity)
-
-// EncodeADDImm encodes ADD/ADDS immediate: Rd = Rn + imm12
-func EncodeADDImm(rd, rn uint8, imm uint16, setFlags bool) uint32 {
-	var inst uint32 = 0
-	inst |= 1 << 31 // sf = 1 (64-bit)
-	inst |= 0 << 30 // op = 0 (ADD)
-	if setFlags {
-		inst |= 1 << 29 // S = 1 (set flags)
-	}
-	inst |= 0b100010 << 23 // opc
-	inst |= 0 << 22        // sh = 0
-	inst |= uint32(imm&0xFFF) << 10
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rd & 0x1F)
-	return inst
-}
-
-// EncodeSUBImm encodes SUB/SUBS immediate: Rd = Rn - imm12
-func EncodeSUBImm(rd, rn uint8, imm uint16, setFlags bool) uint32 {
-	var inst uint32 = 0
-	inst |= 1 << 31 // sf = 1 (64-bit)
-	inst |= 1 << 30 // op = 1 (SUB)
-	if setFlags {
-		inst |= 1 << 29 // S = 1 (set flags)
-	}
-	inst |= 0b100010 << 23 // opc
-	inst |= 0 << 22        // sh = 0
-	inst |= uint32(imm&0xFFF) << 10
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rd & 0x1F)
-	return inst
-}
-
-// EncodeCMPImm encodes compare immediate: CMP Xn, #imm
-// This is an alias for SUBS XZR, Xn, #imm (sets flags, discards result)
-func EncodeCMPImm(rn uint8, imm uint16) uint32 {
-	// CMP is SUBS with Rd = XZR (31) and setFlags = true
-	return EncodeSUBImm(31, rn, imm, true)
-}
-
-// EncodeADDReg encodes ADD/ADDS register: Rd = Rn + Rm
-func EncodeADDReg(rd, rn, rm uint8, setFlags bool) uint32 {
-	var inst uint32 = 0
-	inst |= 1 << 31 // sf = 1 (64-bit)
-	inst |= 0 << 30 // op = 0 (ADD)
-	if setFlags {
-		inst |= 1 << 29 // S = 1 (set flags)
-	}
-	inst |= 0b01011 << 24
-	inst |= 0 << 22 // shift type
-	inst |= 0 << 21 // 0
-	inst |= uint32(rm&0x1F) << 16
-	inst |= 0 << 10 // imm6
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rd & 0x1F)
-	return inst
-}
-
-// EncodeADDRegShifted encodes ADD/ADDS with shifted register: Rd = Rn + (Rm << shift)
-// shiftType: 0=LSL, 1=LSR, 2=ASR
-func EncodeADDRegShifted(rd, rn, rm, shift, shiftType uint8, setFlags bool) uint32 {
-	var inst uint32 = 0
-	inst |= 1 << 31 // sf = 1 (64-bit)
-	inst |= 0 << 30 // op = 0 (ADD)
-	if setFlags {
-		inst |= 1 << 29 // S = 1 (set flags)
-	}
-	inst |= 0b01011 << 24
-	inst |= uint32(shiftType&0x3) << 22
-	inst |= 0 << 21 // 0
-	inst |= uint32(rm&0x1F) << 16
-	inst |= uint32(shift&0x3F) << 10 // imm6 = shift amount
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rd & 0x1F)
-	return inst
-}
-
-// EncodeSUBReg encodes SUB/SUBS register: Rd = Rn - Rm
-func EncodeSUBReg(rd, rn, rm uint8, setFlags bool) uint32 {
-	var inst uint32 = 0
-	inst |= 1 << 31 // sf = 1 (64-bit)
-	inst |= 1 << 30 // op = 1 (SUB)
-	if setFlags {
-		inst |= 1 << 29 // S = 1 (set flags)
-	}
-	inst |= 0b01011 << 24
-	inst |= 0 << 22 // shift type
-	inst |= 0 << 21 // 0
-	inst |= uint32(rm&0x1F) << 16
-	inst |= 0 << 10 // imm6
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rd & 0x1F)
-	return inst
-}
-
-// EncodeBCond encodes conditional branch: B.cond offset
-func EncodeBCond(offset int32, cond uint8) uint32 {
-	var inst uint32 = 0
-	inst |= 0b0101010 << 25
-	inst |= 0 << 24
-	imm19 := uint32(offset/4) & 0x7FFFF
-	inst |= imm19 << 5
-	inst |= 0 << 4
-	inst |= uint32(cond & 0xF)
-	return inst
-}
-
-// EncodeBL encodes branch with link: BL offset
-func EncodeBL(offset int32) uint32 {
-	var inst uint32 = 0
-	inst |= 0b100101 << 26
-	imm26 := uint32(offset/4) & 0x3FFFFFF
-	inst |= imm26
-	return inst
-}
-
-// EncodeRET encodes return: RET (X30)
-func EncodeRET() uint32 {
-	var inst uint32 = 0
-	inst |= 0b1101011 << 25
-	inst |= 0 << 24
-	inst |= 0 << 23
-	inst |= 0b10 << 21
-	inst |= 0b11111 << 16
-	inst |= 0b0000 << 12
-	inst |= 0 << 11
-	inst |= 0 << 10
-	inst |= uint32(30) << 5 // X30 (LR)
-	inst |= 0b00000
-	return inst
-}
-
-// EncodeSVC encodes syscall: SVC #imm
-func EncodeSVC(imm uint16) uint32 {
-	var inst uint32 = 0
-	inst |= 0b11010100 << 24
-	inst |= 0b000 << 21
-	inst |= uint32(imm) << 5
-	inst |= 0b00001
-	return inst
-}
-
-// EncodeSTR64 encodes STR (64-bit) with unsigned immediate offset
-func EncodeSTR64(rt, rn uint8, imm12 uint16) uint32 {
-	var inst uint32 = 0
-	inst |= 0b11 << 30  // size = 64-bit
-	inst |= 0b111 << 27 // op1
-	inst |= 0 << 26     // V = 0
-	inst |= 0b01 << 24  // op2
-	inst |= 0b00 << 22  // opc = STR
-	inst |= uint32(imm12&0xFFF) << 10
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rt & 0x1F)
-	return inst
-}
-
-// EncodeLDR64 encodes LDR (64-bit) with unsigned immediate offset
-func EncodeLDR64(rt, rn uint8, imm12 uint16) uint32 {
-	var inst uint32 = 0
-	inst |= 0b11 << 30  // size = 64-bit
-	inst |= 0b111 << 27 // op1
-	inst |= 0 << 26     // V = 0
-	inst |= 0b01 << 24  // op2
-	inst |= 0b01 << 22  // opc = LDR
-	inst |= uint32(imm12&0xFFF) << 10
-	inst |= uint32(rn&0x1F) << 5
-	inst |= uint32(rt & 0x1F)
-	return inst
-}
-
 // BenchmarkReport is the complete output format for benchmark results.
 type BenchmarkReport struct {
 	// Metadata about the benchmark run
@@ -419,3 +419,33 @@ func TestWithoutCaches(t *testing.T) {
 	t.Logf("arithmetic_sequential (no cache): cycles=%d, insts=%d, CPI=%.3f",
 		r.SimulatedCycles, r.InstructionsRetired, r.CPI)
 }
+
+func TestSourceAssemblyError(t *testing.T) {
+	config := DefaultConfig()
+	config.Output = &bytes.Buffer{}
+	config.EnableICache = false
+	config.EnableDCache = false
+
+	harness := NewHarness(config)
+	harness.AddBenchmark(Benchmark{Name: "bad_source", Source: "frob x0"})
+
+	results := harness.RunAll()
+
+	if results[0].ExitCode != -1 {
+		t.Errorf("expected exit code -1 for invalid source, got %d", results[0].ExitCode)
+	}
+	if results[0].Error == "" {
+		t.Error("expected the assembly error in the result")
+	}
+	if _, err := (Benchmark{Source: "frob x0"}).Code(0x1000); err == nil {
+		t.Error("expected an assembly error")
+	}
+}
+
+func TestAllBenchmarksAssemble(t *testing.T) {
+	for _, b := range GetMicrobenchmarks() {
+		if _, err := b.Code(0x1000); err != nil {
+			t.Errorf("benchmark %s does not assemble: %v", b.Name, err)
+		}
+	}
+}
@@ -155,4 +155,16 @@ func main() {
 			fmt.Fprintf(os.Stderr, "%d benchmark(s) failed validation.\n", failed)
 		}
 	}
+
+	// A benchmark that could not be loaded is a broken build, not a result
+	broken := false
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(os.Stderr, "ERROR: %s: %s\n", r.Name, r.Error)
+			broken = true
+		}
+	}
+	if broken {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarchlab/m2sim/insts"
+	"github.com/sarchlab/m2sim/loader"
+)
+
+// runDisasm implements "m2sim disasm <program.elf>".
+func runDisasm(args []string) int64 {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: m2sim disasm <program.elf>\n")
+		return 1
+	}
+	if err := disassembleELF(os.Stdout, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// disassembleELF writes an objdump-style listing of the executable sections
+// of the ELF file at path to w, labeling symbols and the targets of direct
+// branches.
+func disassembleELF(w io.Writer, path string) error {
+	prog, err := loader.Load(path)
+	if err != nil {
+		return err
+	}
+	f, err := elf.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	decoder := insts.NewDecoder()
+	var inst insts.Instruction
+	for _, section := range f.Sections {
+		if section.Type != elf.SHT_PROGBITS || section.Flags&elf.SHF_EXECINSTR == 0 {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			return fmt.Errorf("reading section %s: %w", section.Name, err)
+		}
+
+		_, _ = fmt.Fprintf(w, "\nDisassembly of section %s:\n", section.Name)
+		for off := 0; off+4 <= len(data); off += 4 {
+			pc := section.Addr + uint64(off)
+			if sym, ok := prog.SymbolAt(pc); ok && sym.Addr == pc {
+				_, _ = fmt.Fprintf(w, "\n%016x <%s>:\n", pc, sym.Name)
+			}
+
+			word := binary.LittleEndian.Uint32(data[off:])
+			decoder.DecodeInto(word, &inst)
+			text := insts.Disassemble(&inst, pc)
+			if target, ok := branchTarget(&inst, pc); ok {
+				if name := prog.Symbolize(target); name != "" {
+					text += " <" + name + ">"
+				}
+			}
+			_, _ = fmt.Fprintf(w, "  %x:\t%08x\t%s\n", pc, word, text)
+		}
+	}
+	return nil
+}
+
+// branchTarget returns the destination of a direct branch.
+func branchTarget(inst *insts.Instruction, pc uint64) (uint64, bool) {
+	switch inst.Format {
+	case insts.FormatBranch, insts.FormatBranchCond,
+		insts.FormatCompareBranch, insts.FormatTestBranch:
+		return uint64(int64(pc) + inst.BranchOffset), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Disasm", func() {
+	It("should list the executable sections with symbols", func() {
+		var out bytes.Buffer
+		Expect(disassembleELF(&out, "../../benchmarks/crc32-m2sim/crc32_m2sim.elf")).To(Succeed())
+
+		listing := out.String()
+		Expect(listing).To(ContainSubstring("Disassembly of section .text:\n"))
+		Expect(listing).To(ContainSubstring("\n0000000000080000 <_start>:\n"))
+		Expect(listing).To(ContainSubstring("  80008:\t9100001f\tmov sp, x0\n"))
+		Expect(listing).To(ContainSubstring("  80030:\t94000036\tbl 0x80108 <initialise_benchmark>\n"))
+	})
+
+	It("should fail for a missing file", func() {
+		Expect(disassembleELF(&bytes.Buffer{}, "does-not-exist.elf")).NotTo(Succeed())
+	})
+})
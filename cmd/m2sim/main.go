@@ -5,7 +5,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/sarchlab/m2sim/emu"
 	"github.com/sarchlab/m2sim/loader"
@@ -14,22 +17,58 @@ import (
 )
 
 var (
-	timing     = flag.Bool("timing", false, "Enable timing simulation mode")
-	configPath = flag.String("config", "", "Path to timing configuration JSON file")
-	verbose    = flag.Bool("v", false, "Verbose output")
+	timing              = flag.Bool("timing", false, "Enable timing simulation mode")
+	configPath          = flag.String("config", "", "Path to timing configuration JSON file")
+	lockstep            = flag.Bool("lockstep", false, "Check the timing pipeline against the functional emulator")
+	protect             = flag.Bool("protect", false, "Enforce memory protection and report segmentation faults")
+	reportUnimplemented = flag.Bool("report-unimplemented", false,
+		"Execute unimplemented instructions as NOPs and print a histogram of them at exit")
+	pac = flag.String("pac", "nop",
+		"Pointer authentication: nop (execute as NOPs) or enabled (sign and authenticate)")
+	bti     = flag.Bool("bti", false, "Enforce branch target identification")
+	coreMHz = flag.Uint64("core-mhz", emu.CoreFrequency/1_000_000,
+		"Simulated core frequency in MHz, from which the program's clock advances "+
+			"(one cycle per instruction in functional mode)")
+	fsRoot     = flag.String("fs-root", "", "Confine the program's file access to this host directory, seen as /")
+	fsManifest = flag.String("fs-manifest", "",
+		"Run the program in an in-memory filesystem preloaded from this manifest of guest and host paths")
+	fsOverlay = flag.Bool("fs-overlay", false, "Keep the program's file writes in memory instead of the host")
+	strace    = flag.Bool("strace", false,
+		"Log every syscall to stderr in strace format, with the instruction count and cycle it happened at")
+	verbose = flag.Bool("v", false, "Verbose output")
+	envVars envList
 )
 
+// envList collects the repeatable -env flag.
+type envList []string
+
+func (l *envList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *envList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
+	flag.Var(&envVars, "env", "Set an environment variable NAME=VALUE for the program (repeatable)")
 	flag.Parse()
 
+	if flag.Arg(0) == "disasm" {
+		os.Exit(int(runDisasm(flag.Args()[1:])))
+	}
+
 	if flag.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: m2sim [options] <program.elf>\n")
+		fmt.Fprintf(os.Stderr, "Usage: m2sim [options] <program.elf> [args...]\n")
+		fmt.Fprintf(os.Stderr, "       m2sim disasm <program.elf>\n")
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
 	programPath := flag.Arg(0)
+	pacMode := pointerAuthMode()
 
 	// Load the ELF program
 	prog, err := loader.Load(programPath)
@@ -38,49 +77,187 @@ func main() {
 		os.Exit(1)
 	}
 
+	if pacMode == emu.PointerAuthEnabled {
+		prog.HWCap |= loader.HWCapPACA | loader.HWCapPACG
+	}
+
 	if *verbose {
 		fmt.Printf("Loaded: %s\n", programPath)
 		fmt.Printf("Entry point: 0x%X\n", prog.EntryPoint)
 		fmt.Printf("Segments: %d\n", len(prog.Segments))
 	}
 
-	if *timing {
-		exitCode := runTiming(prog, programPath)
+	if *lockstep {
+		exitCode := runLockstep(prog, programPath, pacMode)
+		os.Exit(int(exitCode))
+	} else if *timing {
+		exitCode := runTiming(prog, programPath, pacMode)
 		os.Exit(int(exitCode))
 	} else {
-		exitCode := runEmulation(prog, programPath)
+		exitCode := runEmulation(prog, programPath, pacMode)
 		os.Exit(int(exitCode))
 	}
 }
 
-// loadSegments loads program segments into memory.
-func loadSegments(memory *emu.Memory, prog *loader.Program) {
-	for _, seg := range prog.Segments {
-		for i, b := range seg.Data {
-			memory.Write8(seg.VirtAddr+uint64(i), b)
+// newMemory creates the memory for the program, enforcing page protection
+// if -protect is set.
+func newMemory() *emu.Memory {
+	if *protect {
+		return emu.NewMemory(emu.WithProtection())
+	}
+	return emu.NewMemory()
+}
+
+// pointerAuthMode parses the -pac flag.
+func pointerAuthMode() emu.PointerAuthMode {
+	switch *pac {
+	case "nop":
+		return emu.PointerAuthNOP
+	case "enabled":
+		return emu.PointerAuthEnabled
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid -pac mode %q: want nop or enabled\n", *pac)
+		os.Exit(1)
+		return emu.PointerAuthNOP
+	}
+}
+
+// coreFrequency returns the core frequency in Hz set by -core-mhz.
+func coreFrequency() uint64 {
+	if *coreMHz == 0 {
+		fmt.Fprintf(os.Stderr, "Error: -core-mhz must be > 0\n")
+		os.Exit(1)
+	}
+	return *coreMHz * 1_000_000
+}
+
+// newFileSystem returns the filesystem set by the -fs-root, -fs-manifest
+// and -fs-overlay flags. Without them, the program sees the host's files.
+func newFileSystem() emu.FileSystem {
+	if *fsManifest != "" {
+		if *fsRoot != "" || *fsOverlay {
+			fmt.Fprintf(os.Stderr, "Error: -fs-manifest cannot be combined with -fs-root or -fs-overlay\n")
+			os.Exit(1)
 		}
-		// Zero-fill BSS (memsize > filesize)
-		for i := uint64(len(seg.Data)); i < seg.MemSize; i++ {
-			memory.Write8(seg.VirtAddr+i, 0)
+		manifest, err := emu.LoadManifest(*fsManifest)
+		if err == nil {
+			var fsys *emu.MemFS
+			if fsys, err = emu.NewMemFS(manifest); err == nil {
+				return fsys
+			}
 		}
+		fmt.Fprintf(os.Stderr, "Error loading filesystem manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	var fsys emu.FileSystem = emu.HostFS{}
+	if *fsRoot != "" {
+		root, err := emu.NewRootFS(*fsRoot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening filesystem root: %v\n", err)
+			os.Exit(1)
+		}
+		fsys = root
+	}
+	if *fsOverlay {
+		fsys = emu.NewOverlayFS(fsys)
+	}
+	return fsys
+}
+
+// branchProtectionOptions returns the emulator options for the -pac and
+// -bti flags.
+func branchProtectionOptions(pacMode emu.PointerAuthMode) []emu.EmulatorOption {
+	opts := []emu.EmulatorOption{emu.WithPointerAuth(pacMode)}
+	if *bti {
+		opts = append(opts, emu.WithBTI())
+	}
+	return opts
+}
+
+// branchProtectionPipelineOptions returns the pipeline options for the
+// -pac and -bti flags.
+func branchProtectionPipelineOptions(pacMode emu.PointerAuthMode) []pipeline.PipelineOption {
+	opts := []pipeline.PipelineOption{pipeline.WithPointerAuth(pacMode)}
+	if *bti {
+		opts = append(opts, pipeline.WithBTI())
+	}
+	return opts
+}
+
+// newUnimplementedReport returns the report for -report-unimplemented, or
+// nil if the flag is not set.
+func newUnimplementedReport() *emu.UnimplementedReport {
+	if *reportUnimplemented {
+		return emu.NewUnimplementedReport()
+	}
+	return nil
+}
+
+// printUnimplementedReport prints the histogram of unimplemented
+// instructions, if one was collected.
+func printUnimplementedReport(report *emu.UnimplementedReport) {
+	if report != nil {
+		report.Print(os.Stderr)
+	}
+}
+
+// loadProcess loads the program segments, maps them and the stack in the
+// handler's address space, and builds the initial process stack. The
+// program receives the ELF path and the arguments after it as argv. It
+// returns the initial stack pointer.
+func loadProcess(memory *emu.Memory, handler *emu.DefaultSyscallHandler, prog *loader.Program) uint64 {
+	prog.LoadInto(memory)
+	prog.MapInto(handler.AddressSpace())
+	return prog.SetupStack(memory, flag.Args(), envVars)
+}
+
+// newSyscallHandler creates the syscall handler for the program at
+// programPath, which /proc/self/exe names. Each handler gets its own
+// filesystem, so that a lockstep reference never sees the writes of the
+// pipeline's program in memory.
+func newSyscallHandler(regFile *emu.RegFile, memory *emu.Memory, stdout, stderr io.Writer,
+	programPath string) *emu.DefaultSyscallHandler {
+	handler := emu.NewDefaultSyscallHandler(regFile, memory, stdout, stderr)
+	handler.SetFileSystem(newFileSystem())
+	if *strace {
+		handler.SetTrace(os.Stderr)
+	}
+	if path, err := filepath.Abs(programPath); err == nil {
+		handler.SetExecutablePath(path)
 	}
+	return handler
 }
 
 // runEmulation runs the program in functional emulation mode.
-func runEmulation(prog *loader.Program, programPath string) int64 {
-	memory := emu.NewMemory()
+func runEmulation(prog *loader.Program, programPath string, pacMode emu.PointerAuthMode) int64 {
+	memory := newMemory()
+	regFile := &emu.RegFile{}
 
-	// Load all segments into memory
-	loadSegments(memory, prog)
+	// Load all segments into memory and set up the stack
+	syscallHandler := newSyscallHandler(regFile, memory, os.Stdout, os.Stderr, programPath)
+	regFile.SP = loadProcess(memory, syscallHandler, prog)
 
 	// Create emulator with loaded memory
-	emulator := emu.NewEmulator(
-		emu.WithStackPointer(prog.InitialSP),
-	)
-	emulator.LoadProgram(prog.EntryPoint, memory)
+	opts := []emu.EmulatorOption{
+		emu.WithRegFile(regFile),
+		emu.WithMemory(memory),
+		emu.WithSyscallHandler(syscallHandler),
+		emu.WithSymbolizer(prog),
+		emu.WithCoreFrequency(coreFrequency()),
+	}
+	opts = append(opts, branchProtectionOptions(pacMode)...)
+	report := newUnimplementedReport()
+	if report != nil {
+		opts = append(opts, emu.WithUnimplementedReport(report))
+	}
+	emulator := emu.NewEmulator(opts...)
+	regFile.PC = prog.EntryPoint
 
 	// Run
 	exitCode := emulator.Run()
+	printUnimplementedReport(report)
+	syscallHandler.PrintUnknownSyscalls(os.Stderr)
 
 	if *verbose {
 		fmt.Printf("\nProgram: %s\n", programPath)
@@ -91,9 +268,8 @@ func runEmulation(prog *loader.Program, programPath string) int64 {
 	return exitCode
 }
 
-// runTiming runs the program in timing simulation mode.
-func runTiming(prog *loader.Program, programPath string) int64 {
-	// Set up timing configuration
+// newLatencyTable builds the latency table from the -config flag.
+func newLatencyTable() *latency.Table {
 	var timingConfig *latency.TimingConfig
 	if *configPath != "" {
 		var err error
@@ -106,28 +282,44 @@ func runTiming(prog *loader.Program, programPath string) int64 {
 		timingConfig = latency.DefaultTimingConfig()
 	}
 
-	latencyTable := latency.NewTableWithConfig(timingConfig)
+	return latency.NewTableWithConfig(timingConfig)
+}
+
+// runTiming runs the program in timing simulation mode.
+func runTiming(prog *loader.Program, programPath string, pacMode emu.PointerAuthMode) int64 {
+	// Set up timing configuration
+	latencyTable := newLatencyTable()
 
 	// Set up memory and register file
-	memory := emu.NewMemory()
+	memory := newMemory()
 	regFile := &emu.RegFile{}
-	regFile.SP = prog.InitialSP
 
-	// Load all segments into memory
-	loadSegments(memory, prog)
+	// Load all segments into memory and set up the stack
+	syscallHandler := newSyscallHandler(regFile, memory, os.Stdout, os.Stderr, programPath)
+	regFile.SP = loadProcess(memory, syscallHandler, prog)
 
 	// Create pipeline with timing
-	syscallHandler := emu.NewDefaultSyscallHandler(regFile, memory, os.Stdout, os.Stderr)
-	pipe := pipeline.NewPipeline(
-		regFile,
-		memory,
+	opts := []pipeline.PipelineOption{
 		pipeline.WithSyscallHandler(syscallHandler),
 		pipeline.WithLatencyTable(latencyTable),
-	)
+		pipeline.WithSymbolizer(prog),
+		pipeline.WithCoreFrequency(coreFrequency()),
+	}
+	opts = append(opts, branchProtectionPipelineOptions(pacMode)...)
+	report := newUnimplementedReport()
+	if report != nil {
+		opts = append(opts, pipeline.WithUnimplementedReport(report))
+	}
+	pipe := pipeline.NewPipeline(regFile, memory, opts...)
 	pipe.SetPC(prog.EntryPoint)
 
 	// Run the pipeline
 	exitCode := pipe.Run()
+	if err := pipe.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Emulation error: %v\n", err)
+	}
+	printUnimplementedReport(report)
+	syscallHandler.PrintUnknownSyscalls(os.Stderr)
 
 	// Get statistics
 	stats := pipe.Stats()
@@ -161,7 +353,8 @@ func runTiming(prog *loader.Program, programPath string) int64 {
 	fmt.Printf("Program: %s\n", programPath)
 	fmt.Printf("Exit code: %d\n", exitCode)
 	fmt.Printf("Total Instructions: %d\n", stats.Instructions)
-	fmt.Printf("Virtual Time Cycles: %d (simulated M2 @ 3.5 GHz)\n", stats.Cycles)
+	fmt.Printf("Virtual Time Cycles: %d (simulated M2 @ %.2f GHz)\n", stats.Cycles,
+		float64(coreFrequency())/1e9)
 	fmt.Printf("Virtual Time CPI: %.2f\n", stats.CPI())
 	fmt.Printf("\n")
 	fmt.Printf("Virtual Time Breakdown:\n")
@@ -180,3 +373,70 @@ func runTiming(prog *loader.Program, programPath string) int64 {
 
 	return exitCode
 }
+
+// runLockstep runs the timing pipeline and checks every committed
+// instruction against the functional emulator.
+func runLockstep(prog *loader.Program, programPath string, pacMode emu.PointerAuthMode) int64 {
+	latencyTable := newLatencyTable()
+
+	// The pipeline and the reference emulator each get their own copy of
+	// the program.
+	memory := newMemory()
+	regFile := &emu.RegFile{}
+	syscallHandler := newSyscallHandler(regFile, memory, os.Stdout, os.Stderr, programPath)
+	regFile.SP = loadProcess(memory, syscallHandler, prog)
+
+	refMemory := newMemory()
+	refRegFile := &emu.RegFile{}
+	refHandler := newSyscallHandler(refRegFile, refMemory, io.Discard, io.Discard, programPath)
+	refHandler.SetTrace(nil) // only the pipeline's syscalls are traced
+	refRegFile.SP = loadProcess(refMemory, refHandler, prog)
+	refOpts := []emu.EmulatorOption{
+		emu.WithRegFile(refRegFile),
+		emu.WithMemory(refMemory),
+		emu.WithSyscallHandler(refHandler),
+		emu.WithCoreFrequency(coreFrequency()),
+	}
+
+	opts := []pipeline.PipelineOption{
+		pipeline.WithSyscallHandler(syscallHandler),
+		pipeline.WithLatencyTable(latencyTable),
+		pipeline.WithSymbolizer(prog),
+		pipeline.WithCoreFrequency(coreFrequency()),
+	}
+	refOpts = append(refOpts, branchProtectionOptions(pacMode)...)
+	opts = append(opts, branchProtectionPipelineOptions(pacMode)...)
+
+	// Both sides skip unimplemented instructions; the report is the
+	// pipeline's.
+	report := newUnimplementedReport()
+	if report != nil {
+		opts = append(opts, pipeline.WithUnimplementedReport(report))
+		refOpts = append(refOpts, emu.WithUnimplementedReport(emu.NewUnimplementedReport()))
+	}
+
+	ref := emu.NewEmulator(refOpts...)
+	refRegFile.PC = prog.EntryPoint
+
+	pipe := pipeline.NewPipeline(regFile, memory, opts...)
+	pipe.SetPC(prog.EntryPoint)
+
+	checker := pipeline.NewLockstep(pipe, ref)
+	exitCode := checker.Run()
+	printUnimplementedReport(report)
+	syscallHandler.PrintUnknownSyscalls(os.Stderr)
+
+	if d := checker.Divergence(); d != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", d)
+		return exitCode
+	}
+
+	if *verbose {
+		fmt.Printf("\nProgram: %s\n", programPath)
+		fmt.Printf("Exit code: %d\n", exitCode)
+		fmt.Printf("Instructions checked: %d\n", checker.Committed())
+		fmt.Printf("Cycles: %d\n", pipe.Stats().Cycles)
+	}
+
+	return exitCode
+}
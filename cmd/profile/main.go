@@ -112,25 +112,12 @@ func main() {
 	}
 }
 
-// loadSegments loads program segments into memory.
-func loadSegments(memory *emu.Memory, prog *loader.Program) {
-	for _, seg := range prog.Segments {
-		for i, b := range seg.Data {
-			memory.Write8(seg.VirtAddr+uint64(i), b)
-		}
-		// Zero-fill BSS (memsize > filesize)
-		for i := uint64(len(seg.Data)); i < seg.MemSize; i++ {
-			memory.Write8(seg.VirtAddr+i, 0)
-		}
-	}
-}
-
 // runEmulationProfile runs the program in functional emulation mode with profiling.
 func runEmulationProfile(prog *loader.Program, programPath string) (int64, uint64) {
 	memory := emu.NewMemory()
 
 	// Load all segments into memory
-	loadSegments(memory, prog)
+	prog.LoadInto(memory)
 
 	// Create emulator options
 	opts := []emu.EmulatorOption{
@@ -165,7 +152,7 @@ func runTimingProfile(prog *loader.Program, programPath string) (int64, uint64,
 	regFile.SP = prog.InitialSP
 
 	// Load all segments into memory
-	loadSegments(memory, prog)
+	prog.LoadInto(memory)
 
 	// Create pipeline with timing
 	syscallHandler := emu.NewDefaultSyscallHandler(regFile, memory, os.Stdout, os.Stderr)
@@ -200,7 +187,7 @@ func runFastTimingProfile(prog *loader.Program, programPath string) (int64, uint
 	regFile.SP = prog.InitialSP
 
 	// Load all segments into memory
-	loadSegments(memory, prog)
+	prog.LoadInto(memory)
 
 	// Create fast timing simulation
 	syscallHandler := emu.NewDefaultSyscallHandler(regFile, memory, os.Stdout, os.Stderr)
@@ -125,9 +125,7 @@ func (h *SyscallHandler) handleWrite() emu.SyscallResult {
 
 	// Read buffer from memory
 	buf := make([]byte, count)
-	for i := uint64(0); i < count; i++ {
-		buf[i] = h.memory.Read8(bufPtr + i)
-	}
+	h.memory.ReadBytes(bufPtr, buf)
 
 	// Write to output
 	n, err := writer.Write(buf)
@@ -0,0 +1,217 @@
+package emu_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Load/Store Addressing Forms", func() {
+	var e *emu.Emulator
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+		e.RegFile().WriteReg(1, 0x8000)
+	})
+
+	Describe("Unscaled and unprivileged offsets", func() {
+		It("should apply negative LDUR/STUR offsets without writeback", func() {
+			e.Memory().Write64(0x7FF8, 0x1122334455667788)
+			e.RegFile().WriteReg(2, 0xAB)
+			runAsm(e, "ldur x0, [x1, #-8]; sturb w2, [x1, #3]")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0x1122334455667788)))
+			Expect(e.Memory().Read8(0x8003)).To(Equal(byte(0xAB)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8000)))
+		})
+
+		It("should access memory for LDTR/STTR like an unscaled offset", func() {
+			e.Memory().Write32(0x8004, 0x80000000)
+			e.RegFile().WriteReg(2, 0x1234)
+			runAsm(e, "ldtrsw x0, [x1, #4]; sttrh w2, [x1, #-2]")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xFFFFFFFF80000000)))
+			Expect(e.Memory().Read16(0x7FFE)).To(Equal(uint16(0x1234)))
+		})
+
+		It("should scale byte and halfword unsigned offsets by their size", func() {
+			e.Memory().Write16(0x8006, 0xFFFE)
+			e.Memory().Write8(0x8005, 0x80)
+			runAsm(e, "ldrsh w0, [x1, #6]; ldrsb x2, [x1, #5]")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xFFFFFFFE)))
+			Expect(e.RegFile().ReadReg(2)).To(Equal(uint64(0xFFFFFFFFFFFFFF80)))
+		})
+	})
+
+	Describe("Prefetch", func() {
+		It("should execute PRFM and PRFUM without changing state", func() {
+			e.RegFile().WriteReg(0, 7)
+			runAsm(e, "prfm pldl1keep, [x1]; prfum pstl2strm, [x1, #-1]; prfm plil3keep, [x1, x0, lsl #3]")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(7)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8000)))
+			Expect(e.RegFile().PC).To(Equal(uint64(0x100C)))
+		})
+	})
+
+	Describe("Pairs", func() {
+		It("should sign-extend both words for LDPSW", func() {
+			e.Memory().Write32(0x8000, 0xFFFFFFFF)
+			e.Memory().Write32(0x8004, 0x7FFFFFFF)
+			runAsm(e, "ldpsw x2, x3, [x1], #8")
+
+			Expect(e.RegFile().ReadReg(2)).To(Equal(uint64(0xFFFFFFFFFFFFFFFF)))
+			Expect(e.RegFile().ReadReg(3)).To(Equal(uint64(0x7FFFFFFF)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8008)))
+		})
+
+		It("should store and load non-temporal pairs", func() {
+			e.RegFile().WriteReg(2, 0xAAAA)
+			e.RegFile().WriteReg(3, 0xBBBB)
+			runAsm(e, "stnp x2, x3, [x1, #16]; ldnp x4, x5, [x1, #16]")
+
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(0xAAAA)))
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(0xBBBB)))
+		})
+
+		It("should transfer SIMD&FP register pairs", func() {
+			e.SIMDRegFile().WriteD(0, 0x1111111111111111)
+			e.SIMDRegFile().WriteD(1, 0x2222222222222222)
+			e.RegFile().SP = 0x9000
+			runAsm(e, "stp d0, d1, [sp, #-16]!; ldp s2, s3, [sp, #4]")
+
+			Expect(e.RegFile().SP).To(Equal(uint64(0x8FF0)))
+			Expect(e.Memory().Read64(0x8FF0)).To(Equal(uint64(0x1111111111111111)))
+			Expect(e.Memory().Read64(0x8FF8)).To(Equal(uint64(0x2222222222222222)))
+			Expect(e.SIMDRegFile().ReadD(2)).To(Equal(uint64(0x11111111)))
+			Expect(e.SIMDRegFile().ReadD(3)).To(Equal(uint64(0x22222222)))
+		})
+
+		It("should transfer Q register pairs", func() {
+			e.SIMDRegFile().WriteQ(4, 1, 2)
+			e.SIMDRegFile().WriteQ(5, 3, 4)
+			runAsm(e, "stp q4, q5, [x1]; ldp q6, q7, [x1], #32")
+
+			Expect(e.SIMDRegFile().V[6]).To(Equal([2]uint64{1, 2}))
+			Expect(e.SIMDRegFile().V[7]).To(Equal([2]uint64{3, 4}))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8020)))
+		})
+	})
+
+	Describe("SIMD&FP registers", func() {
+		It("should load B, H, S and D registers and zero the upper bits", func() {
+			e.Memory().Write64(0x8000, 0x0807060504030201)
+			for i := uint8(0); i < 4; i++ {
+				e.SIMDRegFile().WriteQ(i, ^uint64(0), ^uint64(0))
+			}
+			runAsm(e, "ldr b0, [x1]; ldr h1, [x1]; ldr s2, [x1]; ldr d3, [x1]")
+
+			Expect(e.SIMDRegFile().V[0]).To(Equal([2]uint64{0x01, 0}))
+			Expect(e.SIMDRegFile().V[1]).To(Equal([2]uint64{0x0201, 0}))
+			Expect(e.SIMDRegFile().V[2]).To(Equal([2]uint64{0x04030201, 0}))
+			Expect(e.SIMDRegFile().V[3]).To(Equal([2]uint64{0x0807060504030201, 0}))
+		})
+
+		It("should store only the register width", func() {
+			e.Memory().Write64(0x8000, ^uint64(0))
+			e.SIMDRegFile().WriteQ(0, 0x1122334455667788, 0x99)
+			runAsm(e, "str h0, [x1, #2]!")
+
+			Expect(e.Memory().Read64(0x8000)).To(Equal(uint64(0xFFFFFFFF7788FFFF)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8002)))
+		})
+
+		It("should use post-index, register-offset and unscaled addressing", func() {
+			e.Memory().Write32(0x8000, 0x3F800000)
+			e.Memory().Write64(0x801C, 0xCAFEF00D)
+			e.Memory().Write64(0x7FF0, 5)
+			e.Memory().Write64(0x7FF8, 6)
+			e.RegFile().WriteReg(2, 3)
+			runAsm(e, "ldr s0, [x1], #4; ldr d1, [x1, x2, lsl #3]; ldur q2, [x1, #-20]")
+
+			Expect(e.SIMDRegFile().ReadS(0)).To(Equal(uint32(0x3F800000)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8004)))
+			Expect(e.SIMDRegFile().ReadD(1)).To(Equal(uint64(0xCAFEF00D))) // 0x8004 + 3*8
+			Expect(e.SIMDRegFile().V[2]).To(Equal([2]uint64{5, 6}))
+		})
+
+		It("should load S, D and Q literals", func() {
+			e.Memory().Write32(0x1010, 0x40490FDB)
+			e.Memory().Write64(0x1018, 0x400921FB54442D18)
+			e.Memory().Write64(0x1020, 7)
+			e.Memory().Write64(0x1028, 8)
+			runAsm(e, "ldr s0, . + 16; ldr d1, . + 20; ldr q2, . + 24; ldrsw x3, . + 12")
+
+			Expect(e.SIMDRegFile().ReadS(0)).To(Equal(uint32(0x40490FDB)))
+			Expect(e.SIMDRegFile().ReadD(1)).To(Equal(uint64(0x400921FB54442D18)))
+			Expect(e.SIMDRegFile().V[2]).To(Equal([2]uint64{7, 8}))
+			// 0x100C + 12 = 0x1018: low word 0x54442D18
+			Expect(e.RegFile().ReadReg(3)).To(Equal(uint64(0x54442D18)))
+		})
+	})
+
+	Describe("Structure loads and stores", func() {
+		It("should de-interleave LD2 and post-index by the transfer size", func() {
+			for i := uint64(0); i < 8; i++ {
+				e.Memory().Write32(0x8000+i*4, uint32(i))
+			}
+			runAsm(e, "ld2 {v0.4s, v1.4s}, [x1], #32")
+
+			Expect(e.SIMDRegFile().V[0]).To(Equal([2]uint64{0x0000000200000000, 0x0000000600000004}))
+			Expect(e.SIMDRegFile().V[1]).To(Equal([2]uint64{0x0000000300000001, 0x0000000700000005}))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8020)))
+		})
+
+		It("should load consecutive registers with LD1 and zero 64-bit upper halves", func() {
+			e.Memory().Write64(0x8000, 0x1111)
+			e.Memory().Write64(0x8008, 0x2222)
+			e.Memory().Write64(0x8010, 0x3333)
+			e.SIMDRegFile().WriteQ(31, ^uint64(0), ^uint64(0))
+			runAsm(e, "ld1 {v31.8b, v0.8b, v1.8b}, [x1]")
+
+			Expect(e.SIMDRegFile().V[31]).To(Equal([2]uint64{0x1111, 0}))
+			Expect(e.SIMDRegFile().V[0]).To(Equal([2]uint64{0x2222, 0}))
+			Expect(e.SIMDRegFile().V[1]).To(Equal([2]uint64{0x3333, 0}))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8000)))
+		})
+
+		It("should interleave ST3 and post-index by a register", func() {
+			e.SIMDRegFile().WriteQ(2, 0x0706050403020100, 0x0F0E0D0C0B0A0908)
+			e.SIMDRegFile().WriteQ(3, 0x1716151413121110, 0x1F1E1D1C1B1A1918)
+			e.SIMDRegFile().WriteQ(4, 0x2726252423222120, 0x2F2E2D2C2B2A2928)
+			e.RegFile().WriteReg(5, 3)
+			runAsm(e, "st3 {v2.8b, v3.8b, v4.8b}, [x1], x5")
+
+			Expect(e.Memory().Read64(0x8000)).To(Equal(uint64(0x1202211101201000)))
+			Expect(e.Memory().Read64(0x8010)).To(Equal(uint64(0x2717072616062515)))
+			Expect(e.Memory().Read64(0x8018)).To(Equal(uint64(0)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(0x8003)))
+		})
+
+		It("should transfer a single lane and leave the other lanes unchanged", func() {
+			e.Memory().Write16(0x8000, 0xAAAA)
+			e.Memory().Write16(0x8002, 0xBBBB)
+			e.SIMDRegFile().WriteQ(0, 1, 2)
+			e.SIMDRegFile().WriteQ(1, 3, 4)
+			e.SIMDRegFile().WriteQ(2, 0, 0x0000CCCC00000000)
+			runAsm(e, "ld2 {v0.h, v1.h}[5], [x1], #4; st1 {v2.s}[3], [x1]")
+
+			Expect(e.SIMDRegFile().V[0]).To(Equal([2]uint64{1, 0x00000000AAAA0002}))
+			Expect(e.SIMDRegFile().V[1]).To(Equal([2]uint64{3, 0x00000000BBBB0004}))
+			Expect(e.Memory().Read32(0x8004)).To(Equal(uint32(0xCCCC)))
+		})
+
+		It("should replicate LD1R-LD4R elements to every lane", func() {
+			e.Memory().Write32(0x8000, 0x11223344)
+			e.Memory().Write32(0x8004, 0x55667788)
+			e.SIMDRegFile().WriteQ(1, ^uint64(0), ^uint64(0))
+			runAsm(e, "ld2r {v0.2s, v1.2s}, [x1]; ld1r {v2.16b}, [x1]")
+
+			Expect(e.SIMDRegFile().V[0]).To(Equal([2]uint64{0x1122334411223344, 0}))
+			Expect(e.SIMDRegFile().V[1]).To(Equal([2]uint64{0x5566778855667788, 0}))
+			Expect(e.SIMDRegFile().V[2]).To(Equal([2]uint64{0x4444444444444444, 0x4444444444444444}))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 // Package emu provides functional ARM64 emulation.
 package emu
 
+import "math/bits"
+
 // ALU implements ARM64 arithmetic and logic operations.
 type ALU struct {
 	regFile *RegFile
@@ -344,3 +346,37 @@ func (a *ALU) EOR32Imm(rd, rn uint8, imm uint64) {
 
 	a.regFile.WriteReg(rd, uint64(result))
 }
+
+// addWithCarry64 returns op1 + op2 + carryIn and, if setFlags, sets NZCV
+// from the full-width sum. SBC passes ^op2, as in the architecture's
+// AddWithCarry pseudocode.
+func (a *ALU) addWithCarry64(op1, op2 uint64, carryIn, setFlags bool) uint64 {
+	var cin uint64
+	if carryIn {
+		cin = 1
+	}
+	result, carryOut := bits.Add64(op1, op2, cin)
+	if setFlags {
+		a.regFile.PSTATE.N = (result >> 63) == 1
+		a.regFile.PSTATE.Z = result == 0
+		a.regFile.PSTATE.C = carryOut == 1
+		a.regFile.PSTATE.V = ((op1^result)&(op2^result))>>63 == 1
+	}
+	return result
+}
+
+// addWithCarry32 is the 32-bit form of addWithCarry64.
+func (a *ALU) addWithCarry32(op1, op2 uint32, carryIn, setFlags bool) uint32 {
+	var cin uint32
+	if carryIn {
+		cin = 1
+	}
+	result, carryOut := bits.Add32(op1, op2, cin)
+	if setFlags {
+		a.regFile.PSTATE.N = (result >> 31) == 1
+		a.regFile.PSTATE.Z = result == 0
+		a.regFile.PSTATE.C = carryOut == 1
+		a.regFile.PSTATE.V = ((op1^result)&(op2^result))>>31 == 1
+	}
+	return result
+}
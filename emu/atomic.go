@@ -0,0 +1,192 @@
+// Package emu provides functional ARM64 emulation.
+package emu
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// ExclusiveMonitor models the local exclusive monitor of a single PE.
+// A load-exclusive marks an address range; a store-exclusive succeeds only if
+// the same range is still marked. Any store-exclusive, CLREX or exception
+// entry clears the monitor.
+type ExclusiveMonitor struct {
+	valid bool
+	addr  uint64
+	size  uint64
+}
+
+// Mark records [addr, addr+size) as the exclusive range.
+func (m *ExclusiveMonitor) Mark(addr, size uint64) {
+	m.valid = true
+	m.addr = addr
+	m.size = size
+}
+
+// IsExclusive reports whether [addr, addr+size) is the marked range.
+func (m *ExclusiveMonitor) IsExclusive(addr, size uint64) bool {
+	return m.valid && m.addr == addr && m.size == size
+}
+
+// Clear returns the monitor to the open access state.
+func (m *ExclusiveMonitor) Clear() {
+	m.valid = false
+}
+
+// readSized reads a size-byte little-endian value from memory.
+func (e *Emulator) readSized(addr uint64, size uint8) uint64 {
+	switch size {
+	case 1:
+		return uint64(e.memory.Read8(addr))
+	case 2:
+		return uint64(e.memory.Read16(addr))
+	case 4:
+		return uint64(e.memory.Read32(addr))
+	default:
+		return e.memory.Read64(addr)
+	}
+}
+
+// writeSized writes the low size bytes of value to memory.
+func (e *Emulator) writeSized(addr uint64, size uint8, value uint64) {
+	switch size {
+	case 1:
+		e.memory.Write8(addr, uint8(value))
+	case 2:
+		e.memory.Write16(addr, uint16(value))
+	case 4:
+		e.memory.Write32(addr, uint32(value))
+	default:
+		e.memory.Write64(addr, value)
+	}
+}
+
+// sizeMask returns a mask of the low size bytes.
+func sizeMask(size uint8) uint64 {
+	if size >= 8 {
+		return ^uint64(0)
+	}
+	return uint64(1)<<(8*size) - 1
+}
+
+// checkAlignment rejects exclusive and atomic accesses that are not aligned
+// to their total access size.
+func (e *Emulator) checkAlignment(addr, size uint64) error {
+	if addr%size != 0 {
+		return fmt.Errorf("unaligned atomic access to 0x%X at PC=0x%X", addr, e.regFile.PC)
+	}
+	return nil
+}
+
+// executeLoadStoreExclusive executes LDXR, STXR, LDXP, STXP, LDAR, STLR,
+// CAS and CASP. Acquire and release ordering has no functional effect on a
+// single PE.
+func (e *Emulator) executeLoadStoreExclusive(inst *insts.Instruction) error {
+	addr := e.regFile.ReadRegOrSP(inst.Rn)
+	size := inst.AccessSize
+	total := uint64(size)
+	if inst.Op == insts.OpLDXP || inst.Op == insts.OpSTXP || inst.Op == insts.OpCASP {
+		total *= 2
+	}
+	if err := e.checkAlignment(addr, total); err != nil {
+		return err
+	}
+
+	switch inst.Op {
+	case insts.OpLDAR:
+		e.regFile.WriteReg(inst.Rd, e.readSized(addr, size))
+	case insts.OpSTLR:
+		e.writeSized(addr, size, e.regFile.ReadReg(inst.Rd))
+	case insts.OpLDXR:
+		e.monitor.Mark(addr, total)
+		e.regFile.WriteReg(inst.Rd, e.readSized(addr, size))
+	case insts.OpLDXP:
+		e.monitor.Mark(addr, total)
+		first := e.readSized(addr, size)
+		second := e.readSized(addr+uint64(size), size)
+		e.regFile.WriteReg(inst.Rd, first)
+		e.regFile.WriteReg(inst.Rt2, second)
+	case insts.OpSTXR, insts.OpSTXP:
+		status := uint64(1)
+		if e.monitor.IsExclusive(addr, total) {
+			e.writeSized(addr, size, e.regFile.ReadReg(inst.Rd))
+			if inst.Op == insts.OpSTXP {
+				e.writeSized(addr+uint64(size), size, e.regFile.ReadReg(inst.Rt2))
+			}
+			status = 0
+		}
+		e.monitor.Clear()
+		e.regFile.WriteReg(inst.Rm, status)
+	case insts.OpCAS:
+		mask := sizeMask(size)
+		old := e.readSized(addr, size)
+		if old == e.regFile.ReadReg(inst.Rm)&mask {
+			e.writeSized(addr, size, e.regFile.ReadReg(inst.Rd))
+		}
+		e.regFile.WriteReg(inst.Rm, old)
+	case insts.OpCASP:
+		mask := sizeMask(size)
+		old1 := e.readSized(addr, size)
+		old2 := e.readSized(addr+uint64(size), size)
+		if old1 == e.regFile.ReadReg(inst.Rm)&mask && old2 == e.regFile.ReadReg(inst.Rm+1)&mask {
+			e.writeSized(addr, size, e.regFile.ReadReg(inst.Rd))
+			e.writeSized(addr+uint64(size), size, e.regFile.ReadReg(inst.Rt2))
+		}
+		e.regFile.WriteReg(inst.Rm, old1)
+		e.regFile.WriteReg(inst.Rm+1, old2)
+	}
+
+	return nil
+}
+
+// executeAtomic executes the LSE atomic memory operations. Rt receives the
+// value memory held before the operation. LDAPR is a plain load.
+func (e *Emulator) executeAtomic(inst *insts.Instruction) error {
+	addr := e.regFile.ReadRegOrSP(inst.Rn)
+	size := inst.AccessSize
+	if err := e.checkAlignment(addr, uint64(size)); err != nil {
+		return err
+	}
+
+	if inst.Op == insts.OpLDAPR {
+		e.regFile.WriteReg(inst.Rd, e.readSized(addr, size))
+		return nil
+	}
+
+	mask := sizeMask(size)
+	old := e.readSized(addr, size)
+	operand := e.regFile.ReadReg(inst.Rm) & mask
+
+	// Sign-extend values of the access size for the signed comparisons.
+	shift := 64 - 8*uint(size)
+	signedOld := int64(old<<shift) >> shift
+	signedOperand := int64(operand<<shift) >> shift
+
+	var value uint64
+	switch inst.Op {
+	case insts.OpLDADD:
+		value = old + operand
+	case insts.OpLDCLR:
+		value = old &^ operand
+	case insts.OpLDEOR:
+		value = old ^ operand
+	case insts.OpLDSET:
+		value = old | operand
+	case insts.OpLDSMAX:
+		value = uint64(max(signedOld, signedOperand))
+	case insts.OpLDSMIN:
+		value = uint64(min(signedOld, signedOperand))
+	case insts.OpLDUMAX:
+		value = max(old, operand)
+	case insts.OpLDUMIN:
+		value = min(old, operand)
+	case insts.OpSWP:
+		value = operand
+	}
+
+	e.writeSized(addr, size, value&mask)
+	e.regFile.WriteReg(inst.Rd, old)
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package emu_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Exclusive and atomic memory instructions", func() {
+	var (
+		e       *emu.Emulator
+		regFile *emu.RegFile
+		memory  *emu.Memory
+	)
+
+	const addr = uint64(0x10000)
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+		regFile = e.RegFile()
+		memory = e.Memory()
+		regFile.X[1] = addr
+	})
+
+	execute := func(word uint32) emu.StepResult {
+		return e.Execute(insts.NewDecoder().Decode(word))
+	}
+	run := func(words ...uint32) {
+		for _, word := range words {
+			Expect(execute(word).Err).NotTo(HaveOccurred())
+		}
+	}
+
+	Describe("Load/store exclusive", func() {
+		It("should succeed when the monitor is still exclusive", func() {
+			memory.Write64(addr, 10)
+			regFile.X[3] = 11
+			regFile.X[2] = 99
+
+			run(
+				0xc85f7c20, // ldxr x0, [x1]
+				0xc8027c23, // stxr w2, x3, [x1]
+			)
+
+			Expect(regFile.X[0]).To(Equal(uint64(10)))
+			Expect(regFile.X[2]).To(BeZero())
+			Expect(memory.Read64(addr)).To(Equal(uint64(11)))
+		})
+
+		It("should fail a second store-exclusive", func() {
+			regFile.X[3] = 11
+			regFile.X[5] = 12
+
+			run(
+				0xc85f7c20, // ldxr x0, [x1]
+				0xc8027c23, // stxr w2, x3, [x1]
+				0xc8047c25, // stxr w4, x5, [x1]
+			)
+
+			Expect(regFile.X[2]).To(BeZero())
+			Expect(regFile.X[4]).To(Equal(uint64(1)))
+			Expect(memory.Read64(addr)).To(Equal(uint64(11)))
+		})
+
+		It("should fail after CLREX", func() {
+			memory.Write32(addr, 5)
+			regFile.X[3] = 6
+
+			run(
+				0x885ffc20, // ldaxr w0, [x1]
+				0xd5033f5f, // clrex
+				0x8802fc23, // stlxr w2, w3, [x1]
+			)
+
+			Expect(regFile.X[2]).To(Equal(uint64(1)))
+			Expect(memory.Read32(addr)).To(Equal(uint32(5)))
+		})
+
+		It("should fail after a system call", func() {
+			regFile.X[8] = 172 // getpid
+			run(
+				0xc85f7c20, // ldxr x0, [x1]
+				0xd4000001, // svc #0
+			)
+			regFile.X[3] = 1
+			run(0xc8027c23) // stxr w2, x3, [x1]
+
+			Expect(regFile.X[2]).To(Equal(uint64(1)))
+		})
+
+		It("should fail for a different address", func() {
+			regFile.X[8] = addr + 8
+
+			run(
+				0xc85f7c20, // ldxr x0, [x1]
+				0xc8027d03, // stxr w2, x3, [x8]
+			)
+
+			Expect(regFile.X[2]).To(Equal(uint64(1)))
+		})
+
+		It("should load and store exclusive pairs", func() {
+			memory.Write64(addr, 1)
+			memory.Write64(addr+8, 2)
+
+			run(
+				0xc87f1c26, // ldxp x6, x7, [x1]
+				0xc8221827, // stxp w2, x7, x6, [x1]
+			)
+
+			Expect(regFile.X[6]).To(Equal(uint64(1)))
+			Expect(regFile.X[7]).To(Equal(uint64(2)))
+			Expect(regFile.X[2]).To(BeZero())
+			Expect(memory.Read64(addr)).To(Equal(uint64(2)))
+			Expect(memory.Read64(addr + 8)).To(Equal(uint64(1)))
+		})
+
+		It("should reject unaligned addresses", func() {
+			regFile.X[1] = addr + 4
+
+			result := execute(0xc85f7c20) // ldxr x0, [x1]
+
+			Expect(result.Err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Atomic memory operations", func() {
+		It("should add and return the old value", func() {
+			memory.Write64(addr, 40)
+			regFile.X[3] = 2
+
+			run(0xf8e30020) // ldaddal x3, x0, [x1]
+
+			Expect(regFile.X[0]).To(Equal(uint64(40)))
+			Expect(memory.Read64(addr)).To(Equal(uint64(42)))
+		})
+
+		It("should use SP as the base register", func() {
+			regFile.SP = addr
+			memory.Write64(addr, 1)
+			regFile.X[3] = 1
+
+			run(0xf82303e0) // ldadd x3, x0, [sp]
+
+			Expect(memory.Read64(addr)).To(Equal(uint64(2)))
+		})
+
+		It("should compare signed and unsigned values at the access size", func() {
+			memory.Write32(addr, 0xFFFFFFFF) // -1
+			regFile.X[3] = 5
+
+			run(0xb8234020) // ldsmax w3, w0, [x1]
+			Expect(regFile.X[0]).To(Equal(uint64(0xFFFFFFFF)))
+			Expect(memory.Read32(addr)).To(Equal(uint32(5)))
+
+			memory.Write32(addr, 0xFFFFFFFF)
+			run(0xb8237020) // ldumin w3, w0, [x1]
+			Expect(memory.Read32(addr)).To(Equal(uint32(5)))
+
+			memory.Write8(addr, 0x80) // -128
+			run(0x38235020)           // ldsminb w3, w0, [x1]
+			Expect(memory.Read8(addr)).To(Equal(uint8(0x80)))
+
+			memory.Write32(addr, 3)
+			run(0xb8636020) // ldumaxl w3, w0, [x1]
+			Expect(memory.Read32(addr)).To(Equal(uint32(5)))
+		})
+
+		It("should apply the bitwise operations", func() {
+			memory.Write16(addr, 0xFF0F)
+			regFile.X[3] = 0x0F0F
+
+			run(0x78231020) // ldclrh w3, w0, [x1]
+			Expect(regFile.X[0]).To(Equal(uint64(0xFF0F)))
+			Expect(memory.Read16(addr)).To(Equal(uint16(0xF000)))
+
+			memory.Write8(addr, 0xFF)
+			run(0x38232020) // ldeorb w3, w0, [x1]
+			Expect(memory.Read8(addr)).To(Equal(uint8(0xF0)))
+			Expect(memory.Read8(addr + 1)).To(Equal(uint8(0xF0)))
+
+			memory.Write64(addr, 0xF0)
+			run(0xf8233020) // ldset x3, x0, [x1]
+			Expect(memory.Read64(addr)).To(Equal(uint64(0xFFF)))
+		})
+
+		It("should swap", func() {
+			memory.Write64(addr, 7)
+			regFile.X[3] = 8
+
+			run(0xf8a38020) // swpa x3, x0, [x1]
+
+			Expect(regFile.X[0]).To(Equal(uint64(7)))
+			Expect(memory.Read64(addr)).To(Equal(uint64(8)))
+		})
+	})
+
+	Describe("Compare and swap", func() {
+		It("should store when the comparison matches", func() {
+			memory.Write64(addr, 5)
+			regFile.X[2] = 5
+			regFile.X[3] = 6
+
+			run(0xc8e2fc23) // casal x2, x3, [x1]
+
+			Expect(regFile.X[2]).To(Equal(uint64(5)))
+			Expect(memory.Read64(addr)).To(Equal(uint64(6)))
+		})
+
+		It("should not store when the comparison fails", func() {
+			memory.Write64(addr, 5)
+			regFile.X[2] = 4
+			regFile.X[3] = 6
+
+			run(0xc8e2fc23) // casal x2, x3, [x1]
+
+			Expect(regFile.X[2]).To(Equal(uint64(5)))
+			Expect(memory.Read64(addr)).To(Equal(uint64(5)))
+		})
+
+		It("should compare only the access size", func() {
+			memory.Write8(addr, 0x34)
+			regFile.X[2] = 0x1234
+			regFile.X[3] = 0x56
+
+			run(0x08a27c23) // casb w2, w3, [x1]
+
+			Expect(regFile.X[2]).To(Equal(uint64(0x34)))
+			Expect(memory.Read8(addr)).To(Equal(uint8(0x56)))
+		})
+
+		It("should compare and swap pairs", func() {
+			memory.Write64(addr, 1)
+			memory.Write64(addr+8, 2)
+			regFile.X[4] = 1
+			regFile.X[5] = 2
+			regFile.X[6] = 3
+			regFile.X[7] = 4
+
+			run(0x48247c26) // casp x4, x5, x6, x7, [x1]
+
+			Expect(memory.Read64(addr)).To(Equal(uint64(3)))
+			Expect(memory.Read64(addr + 8)).To(Equal(uint64(4)))
+			Expect(regFile.X[4]).To(Equal(uint64(1)))
+			Expect(regFile.X[5]).To(Equal(uint64(2)))
+		})
+	})
+
+	Describe("Load-acquire and store-release", func() {
+		It("should load and store at the access size", func() {
+			memory.Write64(addr, 0x1122334455667788)
+			regFile.X[5] = 0xAABB
+
+			run(
+				0xc8dffc20, // ldar x0, [x1]
+				0x48dffc23, // ldarh w3, [x1]
+			)
+			Expect(regFile.X[0]).To(Equal(uint64(0x1122334455667788)))
+			Expect(regFile.X[3]).To(Equal(uint64(0x7788)))
+
+			regFile.X[6] = addr
+			run(0x089ffcc5) // stlrb w5, [x6]
+			Expect(memory.Read64(addr)).To(Equal(uint64(0x11223344556677BB)))
+		})
+
+		It("should load with LDAPR", func() {
+			memory.Write32(addr, 0xDEADBEEF)
+
+			run(0xb8bfc020) // ldapr w0, [x1]
+
+			Expect(regFile.X[0]).To(Equal(uint64(0xDEADBEEF)))
+		})
+
+		It("should not disturb the exclusive monitor", func() {
+			regFile.X[3] = 1
+
+			run(
+				0xc85f7c20, // ldxr x0, [x1]
+				0xc8dffc24, // ldar x4, [x1]
+				0xc8027c23, // stxr w2, x3, [x1]
+			)
+
+			Expect(regFile.X[2]).To(BeZero())
+		})
+	})
+
+	Describe("Barriers and hints", func() {
+		It("should execute as no-ops", func() {
+			regFile.X[0] = 7
+
+			for _, word := range []uint32{
+				0xd5033bbf, // dmb ish
+				0xd5033f9f, // dsb sy
+				0xd5033fdf, // isb
+				0xd503203f, // yield
+				0xd503229f, // csdb
+			} {
+				pc := regFile.PC
+				Expect(execute(word).Err).NotTo(HaveOccurred())
+				Expect(regFile.PC).To(Equal(pc + 4))
+			}
+
+			Expect(regFile.X[0]).To(Equal(uint64(7)))
+		})
+	})
+})
@@ -0,0 +1,83 @@
+package emu
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// realtimeEpoch is the wall-clock time, in seconds since the Unix epoch, at
+// which every simulation boots: 2024-01-01 00:00:00 UTC.
+const realtimeEpoch = 1_704_067_200
+
+// Clock is the simulated time a program observes through the generic timer
+// and the time syscalls. It counts simulated core cycles at the core
+// frequency and never reads the host clock, so runs are deterministic.
+type Clock struct {
+	cycles    func() uint64
+	frequency uint64
+
+	// slept is the number of cycles skipped by sleep syscalls.
+	slept uint64
+}
+
+// NewClock creates a clock that reads the elapsed cycles from cycles and
+// runs at frequency Hz.
+func NewClock(cycles func() uint64, frequency uint64) *Clock {
+	return &Clock{cycles: cycles, frequency: frequency}
+}
+
+// Frequency returns the core frequency in Hz.
+func (c *Clock) Frequency() uint64 {
+	return c.frequency
+}
+
+// Cycles returns the simulated cycles since boot, including sleeps.
+func (c *Clock) Cycles() uint64 {
+	return c.cycles() + c.slept
+}
+
+// ExecutedCycles returns the simulated cycles the program has spent
+// executing, leaving out sleeps.
+func (c *Clock) ExecutedCycles() uint64 {
+	return c.cycles()
+}
+
+// Now returns the simulated time since boot.
+func (c *Clock) Now() time.Duration {
+	return c.duration(c.Cycles())
+}
+
+// CPUTime returns the simulated time the program has spent executing.
+func (c *Clock) CPUTime() time.Duration {
+	return c.duration(c.ExecutedCycles())
+}
+
+// duration converts a cycle count to time at the core frequency.
+func (c *Clock) duration(cycles uint64) time.Duration {
+	return time.Duration(min(scale(cycles, uint64(time.Second), c.frequency), math.MaxInt64))
+}
+
+// Counter returns the generic timer count, which ticks at
+// CounterFrequency.
+func (c *Clock) Counter() uint64 {
+	return scale(c.Cycles(), CounterFrequency, c.frequency)
+}
+
+// Sleep advances the clock by d without executing anything.
+func (c *Clock) Sleep(d time.Duration) {
+	if d > 0 {
+		c.slept += scale(uint64(d), c.frequency, uint64(time.Second))
+	}
+}
+
+// scale returns value * mul / div without intermediate overflow. The
+// result saturates if it does not fit in 64 bits.
+func scale(value, mul, div uint64) uint64 {
+	hi, lo := bits.Mul64(value, mul)
+	if hi >= div {
+		return ^uint64(0)
+	}
+	quo, _ := bits.Div64(hi, lo, div)
+	return quo
+}
@@ -0,0 +1,57 @@
+package emu_test
+
+import (
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Clock", func() {
+	var cycles uint64
+
+	BeforeEach(func() {
+		cycles = 0
+	})
+
+	newClock := func(frequency uint64) *emu.Clock {
+		return emu.NewClock(func() uint64 { return cycles }, frequency)
+	}
+
+	It("should convert cycles to time at the core frequency", func() {
+		clock := newClock(2_000_000_000)
+		cycles = 3_000_000_000
+
+		Expect(clock.Frequency()).To(Equal(uint64(2_000_000_000)))
+		Expect(clock.Now()).To(Equal(1500 * time.Millisecond))
+		Expect(clock.Counter()).To(Equal(uint64(36_000_000)))
+	})
+
+	It("should advance by sleeps without executing", func() {
+		clock := newClock(emu.CoreFrequency)
+		cycles = 35
+
+		clock.Sleep(2 * time.Second)
+		Expect(clock.Cycles()).To(Equal(uint64(7_000_000_035)))
+		Expect(clock.Now()).To(Equal(2*time.Second + 10*time.Nanosecond))
+		Expect(clock.ExecutedCycles()).To(Equal(uint64(35)))
+		Expect(clock.CPUTime()).To(Equal(10 * time.Nanosecond))
+
+		clock.Sleep(-time.Second)
+		Expect(clock.Cycles()).To(Equal(uint64(7_000_000_035)))
+	})
+
+	It("should not overflow for long runs", func() {
+		clock := newClock(1_000_000)
+		cycles = 1 << 40
+
+		Expect(clock.Counter()).To(Equal(uint64(24 << 40)))
+		Expect(clock.Now()).To(Equal(time.Duration(1<<40) * time.Microsecond))
+
+		cycles = 1 << 62
+		Expect(clock.Now()).To(Equal(time.Duration(math.MaxInt64)))
+	})
+})
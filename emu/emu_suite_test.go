@@ -5,9 +5,44 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/asm"
+	"github.com/sarchlab/m2sim/emu"
 )
 
 func TestEmu(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Emu Suite")
 }
+
+// runAsm assembles src at 0x1000 and executes every instruction in it on e.
+func runAsm(e *emu.Emulator, src string) {
+	code := asm.MustAssemble(src)
+	e.LoadProgram(0x1000, code)
+	for i := 0; i < len(code)/4; i++ {
+		ExpectWithOffset(1, e.Step().Err).To(BeNil())
+	}
+}
+
+// readQ returns the low and high halves of SIMD register reg.
+func readQ(v *emu.SIMDRegFile, reg uint8) [2]uint64 {
+	low, high := v.ReadQ(reg)
+	return [2]uint64{low, high}
+}
+
+// syscaller makes syscalls on a handler through its register file.
+type syscaller struct {
+	handler *emu.DefaultSyscallHandler
+	regFile *emu.RegFile
+}
+
+// call makes syscall num with args in X0.., which must not exit the
+// process, and returns X0.
+func (s syscaller) call(num uint64, args ...uint64) int64 {
+	s.regFile.WriteReg(8, num)
+	for i, arg := range args {
+		s.regFile.WriteReg(uint8(i), arg)
+	}
+	ExpectWithOffset(1, s.handler.Handle().Exited).To(BeFalse())
+	return int64(s.regFile.ReadReg(0))
+}
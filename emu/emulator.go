@@ -2,8 +2,11 @@
 package emu
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"math/bits"
 	"os"
 
 	"github.com/sarchlab/m2sim/insts"
@@ -33,10 +36,17 @@ type Emulator struct {
 	lsu        *LoadStoreUnit
 	branchUnit *BranchUnit
 	simdUnit   *SIMD
+	fpu        *FPU
+
+	// Local exclusive monitor for LDXR/STXR
+	monitor ExclusiveMonitor
 
 	// SIMD register file
 	simdRegFile *SIMDRegFile
 
+	// System registers
+	sysRegFile *SysRegFile
+
 	// I/O
 	stdout io.Writer
 	stderr io.Writer
@@ -44,6 +54,20 @@ type Emulator struct {
 	// Execution state
 	instructionCount uint64
 	maxInstructions  uint64 // 0 means no limit
+
+	// Pointer authentication and branch target identification
+	pointerAuth PointerAuthMode
+	bti         bool
+
+	// cycles returns the simulated cycle count the generic timer and the
+	// time syscalls derive from. nil: one cycle per instruction.
+	cycles        func() uint64
+	coreFrequency uint64
+	clock         *Clock
+
+	// Diagnostics for unimplemented instructions
+	symbolizer    Symbolizer
+	unimplemented *UnimplementedReport // nil: stop on unimplemented instructions
 }
 
 // EmulatorOption is a functional option for configuring the Emulator.
@@ -77,6 +101,23 @@ func WithStackPointer(sp uint64) EmulatorOption {
 	}
 }
 
+// WithRegFile makes the emulator operate on an existing register file.
+// Timing models use it to share architectural state with the emulator's
+// execution core. Apply it before options that modify registers, such as
+// WithStackPointer.
+func WithRegFile(regFile *RegFile) EmulatorOption {
+	return func(e *Emulator) {
+		e.regFile = regFile
+	}
+}
+
+// WithMemory makes the emulator operate on an existing memory.
+func WithMemory(memory *Memory) EmulatorOption {
+	return func(e *Emulator) {
+		e.memory = memory
+	}
+}
+
 // WithMaxInstructions sets the maximum number of instructions to execute.
 // A value of 0 means no limit.
 func WithMaxInstructions(max uint64) EmulatorOption {
@@ -85,6 +126,24 @@ func WithMaxInstructions(max uint64) EmulatorOption {
 	}
 }
 
+// WithCycleCounter makes the simulated clock, which the generic timer
+// (CNTVCT_EL0, CNTPCT_EL0) and the time syscalls read, count the cycles
+// reported by cycles instead of one cycle per instruction. Timing models
+// use it to expose simulated time to the program.
+func WithCycleCounter(cycles func() uint64) EmulatorOption {
+	return func(e *Emulator) {
+		e.cycles = cycles
+	}
+}
+
+// WithCoreFrequency sets the core frequency in Hz at which the simulated
+// clock converts cycles to time. The default is CoreFrequency.
+func WithCoreFrequency(hz uint64) EmulatorOption {
+	return func(e *Emulator) {
+		e.coreFrequency = hz
+	}
+}
+
 // NewEmulator creates a new ARM64 emulator.
 func NewEmulator(opts ...EmulatorOption) *Emulator {
 	regFile := &RegFile{}
@@ -98,6 +157,7 @@ func NewEmulator(opts ...EmulatorOption) *Emulator {
 		stderr:           os.Stderr,
 		instructionCount: 0,
 		maxInstructions:  0,
+		coreFrequency:    CoreFrequency,
 	}
 
 	// Apply options first (may set stdout/stderr)
@@ -106,20 +166,48 @@ func NewEmulator(opts ...EmulatorOption) *Emulator {
 	}
 
 	// Create execution units
-	e.alu = NewALU(regFile)
-	e.lsu = NewLoadStoreUnit(regFile, memory)
-	e.branchUnit = NewBranchUnit(regFile)
+	e.alu = NewALU(e.regFile)
+	e.lsu = NewLoadStoreUnit(e.regFile, e.memory)
+	e.branchUnit = NewBranchUnit(e.regFile)
 	e.simdRegFile = NewSIMDRegFile()
-	e.simdUnit = NewSIMD(e.simdRegFile, regFile, memory)
+	e.simdUnit = NewSIMD(e.simdRegFile, e.regFile, e.memory)
+	e.fpu = NewFPU(e.simdRegFile, e.regFile)
+	e.sysRegFile = NewSysRegFile()
 
 	// If no syscall handler was provided, create a default one
 	if e.syscallHandler == nil {
-		e.syscallHandler = NewDefaultSyscallHandler(regFile, memory, e.stdout, e.stderr)
+		e.syscallHandler = NewDefaultSyscallHandler(e.regFile, e.memory, e.stdout, e.stderr)
 	}
+	e.clock = NewClock(e.elapsedCycles, e.coreFrequency)
+	e.attachHandler()
 
 	return e
 }
 
+// Clock returns the simulated clock the program observes.
+func (e *Emulator) Clock() *Clock {
+	return e.clock
+}
+
+// elapsedCycles returns the simulated cycles executed so far.
+func (e *Emulator) elapsedCycles() uint64 {
+	if e.cycles != nil {
+		return e.cycles()
+	}
+	return e.instructionCount
+}
+
+// attachHandler gives the syscall handler the simulated clock, if it keeps
+// time, and the instruction count, if it traces.
+func (e *Emulator) attachHandler() {
+	if handler, ok := e.syscallHandler.(interface{ SetClock(*Clock) }); ok {
+		handler.SetClock(e.clock)
+	}
+	if handler, ok := e.syscallHandler.(interface{ SetInstructionCounter(func() uint64) }); ok {
+		handler.SetInstructionCounter(e.InstructionCount)
+	}
+}
+
 // RegFile returns the emulator's register file.
 func (e *Emulator) RegFile() *RegFile {
 	return e.regFile
@@ -130,11 +218,21 @@ func (e *Emulator) Memory() *Memory {
 	return e.memory
 }
 
+// SyscallHandler returns the emulator's syscall handler.
+func (e *Emulator) SyscallHandler() SyscallHandler {
+	return e.syscallHandler
+}
+
 // SIMDRegFile returns the emulator's SIMD register file.
 func (e *Emulator) SIMDRegFile() *SIMDRegFile {
 	return e.simdRegFile
 }
 
+// SysRegFile returns the emulator's system register file.
+func (e *Emulator) SysRegFile() *SysRegFile {
+	return e.sysRegFile
+}
+
 // InstructionCount returns the number of instructions executed.
 func (e *Emulator) InstructionCount() uint64 {
 	return e.instructionCount
@@ -154,6 +252,7 @@ func (e *Emulator) LoadProgram(entry uint64, program interface{}) {
 		e.simdUnit = NewSIMD(e.simdRegFile, e.regFile, e.memory)
 		// Update syscall handler with new memory
 		e.syscallHandler = NewDefaultSyscallHandler(e.regFile, e.memory, e.stdout, e.stderr)
+		e.attachHandler()
 	}
 	e.regFile.PC = entry
 }
@@ -163,6 +262,7 @@ func (e *Emulator) Reset() {
 	e.regFile = &RegFile{}
 	e.memory = NewMemory()
 	e.instructionCount = 0
+	e.monitor.Clear()
 
 	// Recreate execution units
 	e.alu = NewALU(e.regFile)
@@ -170,9 +270,13 @@ func (e *Emulator) Reset() {
 	e.branchUnit = NewBranchUnit(e.regFile)
 	e.simdRegFile = NewSIMDRegFile()
 	e.simdUnit = NewSIMD(e.simdRegFile, e.regFile, e.memory)
+	e.fpu = NewFPU(e.simdRegFile, e.regFile)
+	e.sysRegFile = NewSysRegFile()
 
-	// Recreate syscall handler
+	// Recreate syscall handler and restart the clock
 	e.syscallHandler = NewDefaultSyscallHandler(e.regFile, e.memory, e.stdout, e.stderr)
+	e.clock = NewClock(e.elapsedCycles, e.coreFrequency)
+	e.attachHandler()
 }
 
 // Step executes a single instruction.
@@ -192,9 +296,39 @@ func (e *Emulator) Step() StepResult {
 	inst := e.decoder.Decode(word)
 
 	// 3. Execute
-	result := e.execute(inst)
+	return e.Execute(inst)
+}
+
+// Execute executes a single decoded instruction at the current PC and
+// advances the PC. Timing models call it so that their architectural results
+// come from the same execution core as Step.
+//
+// If the memory enforces protection and the instruction faults, Execute
+// returns the *MemoryFault as the result's Err and leaves the PC at the
+// faulting instruction.
+func (e *Emulator) Execute(inst *insts.Instruction) StepResult {
+	pc := e.regFile.PC
+	if fault := e.memory.checkFetch(pc); fault != nil {
+		return StepResult{Err: fault}
+	}
 
-	// Increment instruction count
+	// Syscalls access memory on behalf of the kernel, which is not checked.
+	if inst.Op != insts.OpSVC {
+		e.memory.beginInstruction(pc)
+	}
+	var result StepResult
+	if err := e.checkBranchTarget(inst); err != nil {
+		result = StepResult{Err: err}
+	} else {
+		result = e.execute(inst)
+	}
+	if fault := e.memory.endInstruction(); fault != nil {
+		e.regFile.PC = pc
+		result = StepResult{Err: fault}
+	}
+	if e.bti && result.Err == nil {
+		e.regFile.PSTATE.BTYPE = branchType(inst)
+	}
 	e.instructionCount++
 
 	return result
@@ -220,9 +354,7 @@ func (e *Emulator) Run() int64 {
 func (e *Emulator) execute(inst *insts.Instruction) StepResult {
 	// Check for unknown instruction
 	if inst.Op == insts.OpUnknown {
-		return StepResult{
-			Err: fmt.Errorf("unknown instruction at PC=0x%X", e.regFile.PC),
-		}
+		return e.unimplementedInstruction(inst)
 	}
 
 	// Handle SVC (syscall) separately
@@ -239,6 +371,16 @@ func (e *Emulator) execute(inst *insts.Instruction) StepResult {
 		}
 	}
 
+	// Pointer authentication spans the hint and data-processing formats.
+	switch inst.Op {
+	case insts.OpPAC, insts.OpAUT, insts.OpXPAC, insts.OpPACGA:
+		if err := e.executePointerAuth(inst); err != nil {
+			return StepResult{Err: err}
+		}
+		e.regFile.PC += 4
+		return StepResult{}
+	}
+
 	// Handle NOP - no operation, just advance PC
 	if inst.Op == insts.OpNOP {
 		e.regFile.PC += 4
@@ -264,7 +406,9 @@ func (e *Emulator) execute(inst *insts.Instruction) StepResult {
 		e.executeBranchCond(inst)
 		return StepResult{} // PC already updated
 	case insts.FormatBranchReg:
-		e.executeBranchReg(inst)
+		if err := e.executeBranchReg(inst); err != nil {
+			return StepResult{Err: err}
+		}
 		return StepResult{} // PC already updated
 	case insts.FormatLoadStore:
 		e.executeLoadStore(inst)
@@ -284,6 +428,12 @@ func (e *Emulator) execute(inst *insts.Instruction) StepResult {
 		e.executeDataProc2Src(inst)
 	case insts.FormatDataProc3Src:
 		e.executeDataProc3Src(inst)
+	case insts.FormatDataProc1Src:
+		e.executeDataProc1Src(inst)
+	case insts.FormatAddSubCarry:
+		e.executeAddSubCarry(inst)
+	case insts.FormatAddSubExt:
+		e.executeAddSubExt(inst)
 	case insts.FormatTestBranch:
 		e.executeTestBranch(inst)
 		return StepResult{} // PC already updated by branch
@@ -294,14 +444,54 @@ func (e *Emulator) execute(inst *insts.Instruction) StepResult {
 		e.executeSIMDReg(inst)
 	case insts.FormatSIMDLoadStore:
 		e.executeSIMDLoadStore(inst)
+	case insts.FormatSIMDLoadStoreStruct:
+		e.executeSIMDLoadStoreStruct(inst)
 	case insts.FormatSIMDCopy:
 		e.executeSIMDCopy(inst)
+	case insts.FormatSIMDTwoReg:
+		e.executeSIMDTwoReg(inst)
+	case insts.FormatSIMDThreeDiff:
+		e.executeSIMDThreeDiff(inst)
+	case insts.FormatSIMDShiftImm:
+		e.executeSIMDShiftImm(inst)
+	case insts.FormatSIMDModImm:
+		e.executeSIMDModImm(inst)
+	case insts.FormatSIMDAcross:
+		e.executeSIMDAcross(inst)
+	case insts.FormatSIMDPermute:
+		e.executeSIMDPermute(inst)
+	case insts.FormatSIMDElem:
+		e.executeSIMDElem(inst)
+	case insts.FormatSIMDScalarPairwise:
+		e.executeSIMDScalarPairwise(inst)
+	case insts.FormatSIMDExtract:
+		e.executeSIMDExtract(inst)
+	case insts.FormatSIMDTableLookup:
+		e.executeSIMDTableLookup(inst)
+	case insts.FormatCryptoTwoReg, insts.FormatCryptoThreeReg, insts.FormatCryptoFourReg:
+		e.executeCrypto(inst)
 	case insts.FormatSystemReg:
 		e.executeSystemReg(inst)
-	default:
-		return StepResult{
-			Err: fmt.Errorf("unimplemented format %d at PC=0x%X", inst.Format, e.regFile.PC),
+	case insts.FormatFPDataProc:
+		e.executeFPDataProc(inst)
+	case insts.FormatFPConvert:
+		e.executeFPConvert(inst)
+	case insts.FormatLoadStoreExclusive:
+		if err := e.executeLoadStoreExclusive(inst); err != nil {
+			return StepResult{Err: err}
+		}
+	case insts.FormatAtomic:
+		if err := e.executeAtomic(inst); err != nil {
+			return StepResult{Err: err}
+		}
+	case insts.FormatBarrier:
+		// Memory and instruction barriers and hints have no functional
+		// effect on a single in-order PE.
+		if inst.Op == insts.OpCLREX {
+			e.monitor.Clear()
 		}
+	default:
+		return e.unimplementedInstruction(inst)
 	}
 
 	// Advance PC by 4 (for non-branch instructions)
@@ -315,6 +505,9 @@ func (e *Emulator) executeSVC() StepResult {
 	// Advance PC first (syscall return address is next instruction)
 	e.regFile.PC += 4
 
+	// Taking an exception clears the local exclusive monitor
+	e.monitor.Clear()
+
 	// Invoke syscall handler
 	syscallResult := e.syscallHandler.Handle()
 
@@ -385,6 +578,87 @@ func applyShift32(value uint32, shiftType insts.ShiftType, amount uint8) uint32
 	}
 }
 
+// extendReg applies an add/subtract or load/store extend option (UXTB ..
+// SXTX, as stored in ShiftType) to value and shifts it left by amount.
+func extendReg(value uint64, option insts.ShiftType, amount uint8) uint64 {
+	switch option {
+	case 0b000: // UXTB
+		value = uint64(uint8(value))
+	case 0b001: // UXTH
+		value = uint64(uint16(value))
+	case 0b010: // UXTW
+		value = uint64(uint32(value))
+	case 0b100: // SXTB
+		value = uint64(int64(int8(value)))
+	case 0b101: // SXTH
+		value = uint64(int64(int16(value)))
+	case 0b110: // SXTW
+		value = uint64(int64(int32(value)))
+	}
+	// 0b011 (UXTX/LSL) and 0b111 (SXTX) use the 64-bit value as-is.
+	return value << amount
+}
+
+// executeAddSubExt executes ADD/ADDS/SUB/SUBS (extended register). Rn is
+// SP when 31, and so is Rd unless the instruction sets flags.
+func (e *Emulator) executeAddSubExt(inst *insts.Instruction) {
+	op1 := e.regFile.ReadRegOrSP(inst.Rn)
+	op2 := extendReg(e.regFile.ReadReg(inst.Rm), inst.ShiftType, inst.ShiftAmount)
+
+	var result uint64
+	if inst.Is64Bit {
+		if inst.Op == insts.OpSUB {
+			result = op1 - op2
+			if inst.SetFlags {
+				e.alu.setSubFlags64(op1, op2, result)
+			}
+		} else {
+			result = op1 + op2
+			if inst.SetFlags {
+				e.alu.setAddFlags64(op1, op2, result)
+			}
+		}
+	} else {
+		a, b := uint32(op1), uint32(op2)
+		var r uint32
+		if inst.Op == insts.OpSUB {
+			r = a - b
+			if inst.SetFlags {
+				e.alu.setSubFlags32(a, b, r)
+			}
+		} else {
+			r = a + b
+			if inst.SetFlags {
+				e.alu.setAddFlags32(a, b, r)
+			}
+		}
+		result = uint64(r)
+	}
+
+	if inst.SetFlags {
+		e.regFile.WriteReg(inst.Rd, result)
+	} else {
+		e.regFile.WriteRegOrSP(inst.Rd, result)
+	}
+}
+
+// executeAddSubCarry executes ADC, ADCS, SBC and SBCS.
+func (e *Emulator) executeAddSubCarry(inst *insts.Instruction) {
+	op1 := e.regFile.ReadReg(inst.Rn)
+	op2 := e.regFile.ReadReg(inst.Rm)
+	if inst.Op == insts.OpSBC {
+		op2 = ^op2 // Rn - Rm - !C == Rn + ^Rm + C
+	}
+	carry := e.regFile.PSTATE.C
+
+	if inst.Is64Bit {
+		e.regFile.WriteReg(inst.Rd, e.alu.addWithCarry64(op1, op2, carry, inst.SetFlags))
+	} else {
+		result := e.alu.addWithCarry32(uint32(op1), uint32(op2), carry, inst.SetFlags)
+		e.regFile.WriteReg(inst.Rd, uint64(result))
+	}
+}
+
 // executeDPReg executes Data Processing Register instructions.
 func (e *Emulator) executeDPReg(inst *insts.Instruction) {
 	switch inst.Op {
@@ -721,8 +995,22 @@ func (e *Emulator) executeBranchCond(inst *insts.Instruction) {
 	}
 }
 
-// executeBranchReg executes branch to register instructions (BR, BLR, RET).
-func (e *Emulator) executeBranchReg(inst *insts.Instruction) {
+// executeBranchReg executes branch to register instructions (BR, BLR, RET)
+// and their authenticated forms, which fail without branching if the
+// target does not authenticate.
+func (e *Emulator) executeBranchReg(inst *insts.Instruction) error {
+	if inst.PACKey != insts.PACKeyNone {
+		target, err := e.authenticatedTarget(inst)
+		if err != nil {
+			return err
+		}
+		if inst.Op == insts.OpBLR {
+			e.regFile.WriteReg(30, e.regFile.PC+4)
+		}
+		e.regFile.PC = target
+		return nil
+	}
+
 	switch inst.Op {
 	case insts.OpBR:
 		e.branchUnit.BR(inst.Rn)
@@ -731,54 +1019,44 @@ func (e *Emulator) executeBranchReg(inst *insts.Instruction) {
 	case insts.OpRET:
 		e.branchUnit.RET(inst.Rn)
 	}
+	return nil
 }
 
-// executeLoadStore executes load and store instructions.
-func (e *Emulator) executeLoadStore(inst *insts.Instruction) {
-	// Check if base register is SP (register 31 in load/store context means SP)
-	useSP := inst.Rn == 31
+// loadStoreAddress returns the data address of a single-register or pair
+// load/store, and the base register value it was computed from.
+func (e *Emulator) loadStoreAddress(inst *insts.Instruction) (addr, base uint64) {
+	// Register 31 in load/store context means SP
+	base = e.regFile.ReadRegOrSP(inst.Rn)
 
-	// Calculate address based on indexing mode
-	var base uint64
-	if useSP {
-		base = e.regFile.SP
-	} else {
-		base = e.regFile.ReadReg(inst.Rn)
-	}
-
-	var addr uint64
 	switch inst.IndexMode {
-	case insts.IndexPre:
-		// Pre-index: address = base + offset, then writeback
+	case insts.IndexPre, insts.IndexSigned:
+		// Pre-index and signed/unscaled offset: address = base + offset
 		addr = uint64(int64(base) + inst.SignedImm)
 	case insts.IndexPost:
 		// Post-index: address = base, then writeback base + offset
 		addr = base
 	case insts.IndexRegBase:
 		// Register offset: base + (extended Rm << shift)
-		rm := e.regFile.ReadReg(inst.Rm)
-		var offset uint64
-		// Handle extend type (stored in ShiftType)
-		// 010=UXTW, 011=LSL/UXTX, 110=SXTW, 111=SXTX
-		switch inst.ShiftType {
-		case 0b010: // UXTW - zero extend 32-bit
-			offset = uint64(uint32(rm))
-		case 0b011: // LSL or UXTX - use 64-bit value as-is
-			offset = rm
-		case 0b110: // SXTW - sign extend 32-bit
-			offset = uint64(int64(int32(rm)))
-		case 0b111: // SXTX - use 64-bit value as-is (signed)
-			offset = rm
-		default:
-			offset = rm // Fallback
-		}
-		// Apply scale shift
-		offset <<= inst.ShiftAmount
+		// Extend type (stored in ShiftType): 010=UXTW, 011=LSL, 110=SXTW, 111=SXTX
+		offset := extendReg(e.regFile.ReadReg(inst.Rm), inst.ShiftType, inst.ShiftAmount)
 		addr = base + offset
 	default:
 		// Unsigned offset (no writeback)
 		addr = base + inst.Imm
 	}
+	return addr, base
+}
+
+// writeBackBase updates the base register of pre- and post-indexed accesses.
+func (e *Emulator) writeBackBase(inst *insts.Instruction, base uint64) {
+	if inst.IndexMode == insts.IndexPre || inst.IndexMode == insts.IndexPost {
+		e.regFile.WriteRegOrSP(inst.Rn, uint64(int64(base)+inst.SignedImm))
+	}
+}
+
+// executeLoadStore executes load, store and prefetch instructions.
+func (e *Emulator) executeLoadStore(inst *insts.Instruction) {
+	addr, base := e.loadStoreAddress(inst)
 
 	// Execute the load/store operation
 	switch inst.Op {
@@ -821,48 +1099,34 @@ func (e *Emulator) executeLoadStore(inst *insts.Instruction) {
 	case insts.OpLDRSW:
 		// LDRSW: Load 32-bit word and sign-extend to 64-bit
 		e.lsu.LDRSW(inst.Rd, addr)
+	case insts.OpPRFM:
+		// Prefetches are hints with no architectural effect
 	}
 
-	// Handle writeback for pre/post-indexed modes
-	if inst.IndexMode == insts.IndexPre || inst.IndexMode == insts.IndexPost {
-		newBase := uint64(int64(base) + inst.SignedImm)
-		if useSP {
-			e.regFile.SP = newBase
-		} else {
-			e.regFile.WriteReg(inst.Rn, newBase)
-		}
-	}
+	e.writeBackBase(inst, base)
 }
 
-// executeLoadStorePair executes LDP and STP instructions.
+// executeLoadStorePair executes LDP, STP, LDPSW, LDNP and STNP instructions.
 func (e *Emulator) executeLoadStorePair(inst *insts.Instruction) {
-	// Check if base register is SP
-	useSP := inst.Rn == 31
-
-	var base uint64
-	if useSP {
-		base = e.regFile.SP
-	} else {
-		base = e.regFile.ReadReg(inst.Rn)
-	}
+	addr, base := e.loadStoreAddress(inst)
 
-	// Calculate address based on indexing mode
-	var addr uint64
-	switch inst.IndexMode {
-	case insts.IndexPre:
-		// Pre-index: address = base + offset, then writeback
-		addr = uint64(int64(base) + inst.SignedImm)
-	case insts.IndexPost:
-		// Post-index: address = base, then writeback base + offset
-		addr = base
-	default:
-		// Signed offset (no writeback)
-		addr = uint64(int64(base) + inst.SignedImm)
+	if inst.IsSIMD {
+		size := uint64(inst.AccessSize)
+		switch inst.Op {
+		case insts.OpLDP:
+			e.simdUnit.LDRFP(inst.Rd, addr, inst.AccessSize)
+			e.simdUnit.LDRFP(inst.Rt2, addr+size, inst.AccessSize)
+		case insts.OpSTP:
+			e.simdUnit.STRFP(inst.Rd, addr, inst.AccessSize)
+			e.simdUnit.STRFP(inst.Rt2, addr+size, inst.AccessSize)
+		}
+		e.writeBackBase(inst, base)
+		return
 	}
 
 	// Determine element size
 	var elemSize uint64 = 4 // 32-bit
-	if inst.Is64Bit {
+	if inst.Is64Bit && inst.Op != insts.OpLDPSW {
 		elemSize = 8 // 64-bit
 	}
 
@@ -880,6 +1144,12 @@ func (e *Emulator) executeLoadStorePair(inst *insts.Instruction) {
 			e.regFile.WriteReg(inst.Rd, uint64(val1))
 			e.regFile.WriteReg(inst.Rt2, uint64(val2))
 		}
+	case insts.OpLDPSW:
+		// Load pair of words, sign-extending each to 64 bits
+		val1 := int32(e.memory.Read32(addr))
+		val2 := int32(e.memory.Read32(addr + elemSize))
+		e.regFile.WriteReg(inst.Rd, uint64(int64(val1)))
+		e.regFile.WriteReg(inst.Rt2, uint64(int64(val2)))
 	case insts.OpSTP:
 		// Store pair
 		if inst.Is64Bit {
@@ -895,15 +1165,7 @@ func (e *Emulator) executeLoadStorePair(inst *insts.Instruction) {
 		}
 	}
 
-	// Handle writeback for pre/post-indexed modes
-	if inst.IndexMode == insts.IndexPre || inst.IndexMode == insts.IndexPost {
-		newBase := uint64(int64(base) + inst.SignedImm)
-		if useSP {
-			e.regFile.SP = newBase
-		} else {
-			e.regFile.WriteReg(inst.Rn, newBase)
-		}
-	}
+	e.writeBackBase(inst, base)
 }
 
 // executePCRel executes PC-relative addressing instructions (ADR, ADRP).
@@ -931,7 +1193,9 @@ func (e *Emulator) executeLoadStoreLit(inst *insts.Instruction) {
 
 	switch inst.Op {
 	case insts.OpLDRLit:
-		if inst.Is64Bit {
+		if inst.IsSIMD {
+			e.simdUnit.LDRFP(inst.Rd, addr, inst.AccessSize)
+		} else if inst.Is64Bit {
 			// Load 64-bit value
 			val := e.memory.Read64(addr)
 			e.regFile.WriteReg(inst.Rd, val)
@@ -940,6 +1204,8 @@ func (e *Emulator) executeLoadStoreLit(inst *insts.Instruction) {
 			val := uint64(e.memory.Read32(addr))
 			e.regFile.WriteReg(inst.Rd, val)
 		}
+	case insts.OpLDRSW:
+		e.lsu.LDRSW(inst.Rd, addr)
 	}
 }
 
@@ -1151,6 +1417,64 @@ func (e *Emulator) executeDataProc2Src(inst *insts.Instruction) {
 			shift := uint32(rmVal) & 0x1F // Shift amount mod 32
 			result = uint64((rn32 >> shift) | (rn32 << (32 - shift)))
 		}
+	case insts.OpCRC32:
+		result = uint64(crc32Update(crc32.IEEETable, uint32(rnVal), rmVal, int(inst.Imm)))
+	case insts.OpCRC32C:
+		result = uint64(crc32Update(crc32cTable, uint32(rnVal), rmVal, int(inst.Imm)))
+	}
+
+	e.regFile.WriteReg(inst.Rd, result)
+}
+
+// crc32cTable is the table for the CRC-32C (Castagnoli) polynomial.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crc32Update accumulates the low size bytes of data into acc, as the
+// CRC32 instructions do: bit-reflected and without the initial and final
+// inversion that hash/crc32 applies.
+func crc32Update(tab *crc32.Table, acc uint32, data uint64, size int) uint32 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], data)
+	return ^crc32.Update(^acc, tab, buf[:size])
+}
+
+// executeDataProc1Src executes one-source data processing instructions
+// (RBIT, REV16, REV32, REV, CLZ, CLS).
+func (e *Emulator) executeDataProc1Src(inst *insts.Instruction) {
+	rnVal := e.regFile.ReadReg(inst.Rn)
+
+	var result uint64
+	if inst.Is64Bit {
+		switch inst.Op {
+		case insts.OpRBIT:
+			result = bits.Reverse64(rnVal)
+		case insts.OpREV16:
+			result = (rnVal&0x00FF00FF00FF00FF)<<8 | (rnVal>>8)&0x00FF00FF00FF00FF
+		case insts.OpREV32:
+			rev := bits.ReverseBytes64(rnVal)
+			result = rev<<32 | rev>>32
+		case insts.OpREV:
+			result = bits.ReverseBytes64(rnVal)
+		case insts.OpCLZ:
+			result = uint64(bits.LeadingZeros64(rnVal))
+		case insts.OpCLS:
+			// Leading bits equal to the sign bit, not counting it.
+			result = uint64(bits.LeadingZeros64(rnVal ^ rnVal<<1 | 1))
+		}
+	} else {
+		rn32 := uint32(rnVal)
+		switch inst.Op {
+		case insts.OpRBIT:
+			result = uint64(bits.Reverse32(rn32))
+		case insts.OpREV16:
+			result = uint64((rn32&0x00FF00FF)<<8 | (rn32>>8)&0x00FF00FF)
+		case insts.OpREV:
+			result = uint64(bits.ReverseBytes32(rn32))
+		case insts.OpCLZ:
+			result = uint64(bits.LeadingZeros32(rn32))
+		case insts.OpCLS:
+			result = uint64(bits.LeadingZeros32(rn32 ^ rn32<<1 | 1))
+		}
 	}
 
 	e.regFile.WriteReg(inst.Rd, result)
@@ -1179,6 +1503,27 @@ func (e *Emulator) executeDataProc3Src(inst *insts.Instruction) {
 		} else {
 			result = uint64(uint32(raVal) - uint32(rnVal)*uint32(rmVal))
 		}
+	case insts.OpSMADDL:
+		result = raVal + uint64(int64(int32(rnVal))*int64(int32(rmVal)))
+	case insts.OpSMSUBL:
+		result = raVal - uint64(int64(int32(rnVal))*int64(int32(rmVal)))
+	case insts.OpUMADDL:
+		result = raVal + uint64(uint32(rnVal))*uint64(uint32(rmVal))
+	case insts.OpUMSUBL:
+		result = raVal - uint64(uint32(rnVal))*uint64(uint32(rmVal))
+	case insts.OpUMULH:
+		result, _ = bits.Mul64(rnVal, rmVal)
+	case insts.OpSMULH:
+		// Signed high half from the unsigned product: subtract each
+		// operand wherever the other is negative.
+		hi, _ := bits.Mul64(rnVal, rmVal)
+		if int64(rnVal) < 0 {
+			hi -= rmVal
+		}
+		if int64(rmVal) < 0 {
+			hi -= rnVal
+		}
+		result = hi
 	}
 
 	e.regFile.WriteReg(inst.Rd, result)
@@ -1256,60 +1601,706 @@ func (e *Emulator) executeSIMDReg(inst *insts.Instruction) {
 		e.simdUnit.VFSUB(inst.Rd, inst.Rn, inst.Rm, arr)
 	case insts.OpVFMUL:
 		e.simdUnit.VFMUL(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFDIV:
+		e.simdUnit.VFDIV(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFMLA:
+		e.simdUnit.VFMLA(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFMLS:
+		e.simdUnit.VFMLS(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFMAX, insts.OpVFMAXNM:
+		e.simdUnit.VFMAX(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVFMAXNM)
+	case insts.OpVFMIN, insts.OpVFMINNM:
+		e.simdUnit.VFMIN(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVFMINNM)
+	case insts.OpVFABD:
+		e.simdUnit.VFABD(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFADDP:
+		e.simdUnit.VFADDP(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFMAXP, insts.OpVFMAXNMP:
+		e.simdUnit.VFMAXP(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVFMAXNMP)
+	case insts.OpVFMINP, insts.OpVFMINNMP:
+		e.simdUnit.VFMINP(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVFMINNMP)
+	case insts.OpVFCMEQ:
+		e.simdUnit.VFCMEQ(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFCMGE:
+		e.simdUnit.VFCMGE(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFCMGT:
+		e.simdUnit.VFCMGT(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFACGE:
+		e.simdUnit.VFACGE(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFACGT:
+		e.simdUnit.VFACGT(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFRECPS:
+		e.simdUnit.VFRECPS(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVFRSQRTS:
+		e.simdUnit.VFRSQRTS(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVSQADD:
+		e.simdUnit.VSQADD(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVUQADD:
+		e.simdUnit.VUQADD(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVSQSUB:
+		e.simdUnit.VSQSUB(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVUQSUB:
+		e.simdUnit.VUQSUB(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVSQDMULH, insts.OpVSQRDMULH:
+		e.simdUnit.VSQDMULH(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSQRDMULH)
+	case insts.OpVSSHL, insts.OpVUSHL:
+		e.simdUnit.VSHLReg(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSSHL)
+	case insts.OpVCMEQ:
+		e.simdUnit.VCMEQ(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVCMGT:
+		e.simdUnit.VCMGT(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVCMGE:
+		e.simdUnit.VCMGE(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVCMHI:
+		e.simdUnit.VCMHI(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVCMHS:
+		e.simdUnit.VCMHS(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVCMTST:
+		e.simdUnit.VCMTST(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVSMAX, insts.OpVUMAX:
+		e.simdUnit.VMAX(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSMAX)
+	case insts.OpVSMIN, insts.OpVUMIN:
+		e.simdUnit.VMIN(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSMIN)
+	case insts.OpVSABD, insts.OpVUABD:
+		e.simdUnit.VABD(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSABD)
+	case insts.OpVSABA, insts.OpVUABA:
+		e.simdUnit.VABA(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSABA)
+	case insts.OpVMLA:
+		e.simdUnit.VMLA(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVMLS:
+		e.simdUnit.VMLS(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVADDP:
+		e.simdUnit.VADDP(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVSMAXP, insts.OpVUMAXP:
+		e.simdUnit.VMAXP(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSMAXP)
+	case insts.OpVSMINP, insts.OpVUMINP:
+		e.simdUnit.VMINP(inst.Rd, inst.Rn, inst.Rm, arr, inst.Op == insts.OpVSMINP)
+	case insts.OpVAND:
+		e.simdUnit.VAND(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVBIC:
+		e.simdUnit.VBIC(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVORR:
+		e.simdUnit.VORR(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVORN:
+		e.simdUnit.VORN(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVEOR:
+		e.simdUnit.VEOR(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVBSL:
+		e.simdUnit.VBSL(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVBIT:
+		e.simdUnit.VBIT(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVBIF:
+		e.simdUnit.VBIF(inst.Rd, inst.Rn, inst.Rm, arr)
 	}
 }
 
-// executeSIMDLoadStore executes SIMD load/store instructions.
-func (e *Emulator) executeSIMDLoadStore(inst *insts.Instruction) {
-	// Calculate address: base + unsigned offset
-	useSP := inst.Rn == 31
-	var base uint64
-	if useSP {
-		base = e.regFile.SP
-	} else {
-		base = e.regFile.ReadReg(inst.Rn)
+// executeSIMDTwoReg executes SIMD two-register miscellaneous instructions.
+func (e *Emulator) executeSIMDTwoReg(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVCMEQZ:
+		e.simdUnit.VCMEQZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVCMGTZ:
+		e.simdUnit.VCMGTZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVCMGEZ:
+		e.simdUnit.VCMGEZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVCMLEZ:
+		e.simdUnit.VCMLEZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVCMLTZ:
+		e.simdUnit.VCMLTZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVABS:
+		e.simdUnit.VABS(inst.Rd, inst.Rn, arr)
+	case insts.OpVNEG:
+		e.simdUnit.VNEG(inst.Rd, inst.Rn, arr)
+	case insts.OpVNOT:
+		e.simdUnit.VNOT(inst.Rd, inst.Rn, arr)
+	case insts.OpVCNT:
+		e.simdUnit.VCNT(inst.Rd, inst.Rn, arr)
+	case insts.OpVREV16:
+		e.simdUnit.VREV(inst.Rd, inst.Rn, arr, 2)
+	case insts.OpVREV32:
+		e.simdUnit.VREV(inst.Rd, inst.Rn, arr, 4)
+	case insts.OpVREV64:
+		e.simdUnit.VREV(inst.Rd, inst.Rn, arr, 8)
+	case insts.OpVXTN:
+		e.simdUnit.VXTN(inst.Rd, inst.Rn, arr)
+	case insts.OpVSQXTN:
+		e.simdUnit.VSQXTN(inst.Rd, inst.Rn, arr)
+	case insts.OpVUQXTN:
+		e.simdUnit.VUQXTN(inst.Rd, inst.Rn, arr)
+	case insts.OpVSQXTUN:
+		e.simdUnit.VSQXTUN(inst.Rd, inst.Rn, arr)
+	case insts.OpVSADDLP, insts.OpVUADDLP:
+		e.simdUnit.VADDLP(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVSADDLP, false)
+	case insts.OpVSADALP, insts.OpVUADALP:
+		e.simdUnit.VADDLP(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVSADALP, true)
+	case insts.OpVFCMEQZ:
+		e.simdUnit.VFCMEQZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVFCMGEZ:
+		e.simdUnit.VFCMGEZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVFCMGTZ:
+		e.simdUnit.VFCMGTZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVFCMLEZ:
+		e.simdUnit.VFCMLEZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVFCMLTZ:
+		e.simdUnit.VFCMLTZ(inst.Rd, inst.Rn, arr)
+	case insts.OpVFABS:
+		e.simdUnit.VFABS(inst.Rd, inst.Rn, arr)
+	case insts.OpVFNEG:
+		e.simdUnit.VFNEG(inst.Rd, inst.Rn, arr)
+	case insts.OpVFSQRT:
+		e.simdUnit.VFSQRT(inst.Rd, inst.Rn, arr)
+	case insts.OpVFRINTN, insts.OpVFRINTA, insts.OpVFRINTP, insts.OpVFRINTM,
+		insts.OpVFRINTZ, insts.OpVFRINTX, insts.OpVFRINTI:
+		e.simdUnit.VFRINT(inst.Rd, inst.Rn, arr, e.frintRounding(inst.Op), inst.Op == insts.OpVFRINTX)
+	case insts.OpVSCVTF, insts.OpVUCVTF:
+		e.simdUnit.VIntToFP(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVSCVTF)
+	case insts.OpVFCVTL:
+		e.simdUnit.VFCVTL(inst.Rd, inst.Rn, arr, inst.Is64Bit)
+	case insts.OpVFCVTN:
+		e.simdUnit.VFCVTN(inst.Rd, inst.Rn, arr)
+	case insts.OpVFRECPE:
+		e.simdUnit.VFRECPE(inst.Rd, inst.Rn, arr)
+	case insts.OpVFRSQRTE:
+		e.simdUnit.VFRSQRTE(inst.Rd, inst.Rn, arr)
+	default:
+		if mode, signed, ok := vecFPToIntRounding(inst.Op); ok {
+			e.simdUnit.VFPToInt(inst.Rd, inst.Rn, arr, signed, mode)
+		}
+	}
+}
+
+// frintRounding returns the rounding mode of a scalar or vector FRINT*
+// opcode. FRINTI and FRINTX use the FPCR mode.
+func (e *Emulator) frintRounding(op insts.Op) FPRounding {
+	switch op {
+	case insts.OpVFRINTN, insts.OpFRINTN:
+		return FPRoundNearest
+	case insts.OpVFRINTA, insts.OpFRINTA:
+		return FPRoundNearestAway
+	case insts.OpVFRINTP, insts.OpFRINTP:
+		return FPRoundPlusInf
+	case insts.OpVFRINTM, insts.OpFRINTM:
+		return FPRoundMinusInf
+	case insts.OpVFRINTZ, insts.OpFRINTZ:
+		return FPRoundZero
+	default:
+		return e.fpu.rounding()
+	}
+}
+
+// vecFPToIntRounding returns the rounding mode and signedness of a vector
+// FCVT*S or FCVT*U opcode.
+func vecFPToIntRounding(op insts.Op) (mode FPRounding, signed, ok bool) {
+	switch op {
+	case insts.OpVFCVTNS, insts.OpVFCVTNU:
+		mode = FPRoundNearest
+	case insts.OpVFCVTPS, insts.OpVFCVTPU:
+		mode = FPRoundPlusInf
+	case insts.OpVFCVTMS, insts.OpVFCVTMU:
+		mode = FPRoundMinusInf
+	case insts.OpVFCVTZS, insts.OpVFCVTZU:
+		mode = FPRoundZero
+	case insts.OpVFCVTAS, insts.OpVFCVTAU:
+		mode = FPRoundNearestAway
+	default:
+		return 0, false, false
 	}
-	addr := base + inst.Imm
+
+	switch op {
+	case insts.OpVFCVTNS, insts.OpVFCVTPS, insts.OpVFCVTMS, insts.OpVFCVTZS, insts.OpVFCVTAS:
+		signed = true
+	}
+
+	return mode, signed, true
+}
+
+// executeSIMDThreeDiff executes the SIMD long and wide instructions. Q
+// (Is64Bit) selects the "2" variants, which read the upper source halves.
+func (e *Emulator) executeSIMDThreeDiff(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+	upper := inst.Is64Bit
+
+	switch inst.Op {
+	case insts.OpVSADDL, insts.OpVUADDL:
+		e.simdUnit.VADDL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSADDL)
+	case insts.OpVSADDW, insts.OpVUADDW:
+		e.simdUnit.VADDW(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSADDW)
+	case insts.OpVSSUBL, insts.OpVUSUBL:
+		e.simdUnit.VSUBL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSSUBL)
+	case insts.OpVSSUBW, insts.OpVUSUBW:
+		e.simdUnit.VSUBW(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSSUBW)
+	case insts.OpVSABAL, insts.OpVUABAL:
+		e.simdUnit.VABAL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSABAL)
+	case insts.OpVSABDL, insts.OpVUABDL:
+		e.simdUnit.VABDL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSABDL)
+	case insts.OpVSMLAL, insts.OpVUMLAL:
+		e.simdUnit.VMLAL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSMLAL)
+	case insts.OpVSMLSL, insts.OpVUMLSL:
+		e.simdUnit.VMLSL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSMLSL)
+	case insts.OpVSMULL, insts.OpVUMULL:
+		e.simdUnit.VMULL(inst.Rd, inst.Rn, inst.Rm, arr, upper, inst.Op == insts.OpVSMULL)
+	case insts.OpVPMULL:
+		e.simdUnit.VPMULL(inst.Rd, inst.Rn, inst.Rm, arr, upper)
+	}
+}
+
+// executeCrypto executes the AES, SHA1, SHA256, SHA512 and SHA3
+// instructions.
+func (e *Emulator) executeCrypto(inst *insts.Instruction) {
+	s := e.simdUnit
+	switch inst.Op {
+	case insts.OpAESE:
+		s.AESE(inst.Rd, inst.Rn)
+	case insts.OpAESD:
+		s.AESD(inst.Rd, inst.Rn)
+	case insts.OpAESMC:
+		s.AESMC(inst.Rd, inst.Rn)
+	case insts.OpAESIMC:
+		s.AESIMC(inst.Rd, inst.Rn)
+	case insts.OpSHA1C:
+		s.SHA1C(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA1P:
+		s.SHA1P(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA1M:
+		s.SHA1M(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA1H:
+		s.SHA1H(inst.Rd, inst.Rn)
+	case insts.OpSHA1SU0:
+		s.SHA1SU0(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA1SU1:
+		s.SHA1SU1(inst.Rd, inst.Rn)
+	case insts.OpSHA256H:
+		s.SHA256H(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA256H2:
+		s.SHA256H2(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA256SU0:
+		s.SHA256SU0(inst.Rd, inst.Rn)
+	case insts.OpSHA256SU1:
+		s.SHA256SU1(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA512H:
+		s.SHA512H(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA512H2:
+		s.SHA512H2(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpSHA512SU0:
+		s.SHA512SU0(inst.Rd, inst.Rn)
+	case insts.OpSHA512SU1:
+		s.SHA512SU1(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpEOR3:
+		s.EOR3(inst.Rd, inst.Rn, inst.Rm, inst.Rt2)
+	case insts.OpBCAX:
+		s.BCAX(inst.Rd, inst.Rn, inst.Rm, inst.Rt2)
+	case insts.OpRAX1:
+		s.RAX1(inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpXAR:
+		s.XAR(inst.Rd, inst.Rn, inst.Rm, inst.Imm)
+	}
+}
+
+// executeSIMDShiftImm executes SIMD shift by immediate instructions.
+func (e *Emulator) executeSIMDShiftImm(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVSHL:
+		e.simdUnit.VSHL(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVSSHR:
+		e.simdUnit.VSSHR(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVUSHR:
+		e.simdUnit.VUSHR(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVSSRA:
+		e.simdUnit.VSSRA(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVUSRA:
+		e.simdUnit.VUSRA(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVSHRN:
+		e.simdUnit.VSHRN(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVRSHRN:
+		e.simdUnit.VRSHRN(inst.Rd, inst.Rn, inst.Imm, arr)
+	case insts.OpVSSHLL, insts.OpVUSHLL:
+		e.simdUnit.VSHLL(inst.Rd, inst.Rn, inst.Imm, arr, inst.Is64Bit, inst.Op == insts.OpVSSHLL)
+	}
+}
+
+// executeSIMDModImm executes MOVI, MVNI, the vector ORR and BIC
+// (immediate) and FMOV, whose immediate is already replicated like MOVI's.
+func (e *Emulator) executeSIMDModImm(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVMOVI, insts.OpVFMOVImm:
+		e.simdUnit.VMOVI(inst.Rd, inst.Imm, arr)
+	case insts.OpVMVNI:
+		e.simdUnit.VMVNI(inst.Rd, inst.Imm, arr)
+	case insts.OpVORRImm:
+		e.simdUnit.VORRImm(inst.Rd, inst.Imm, arr)
+	case insts.OpVBICImm:
+		e.simdUnit.VBICImm(inst.Rd, inst.Imm, arr)
+	}
+}
+
+// executeSIMDAcross executes SIMD reductions across lanes.
+func (e *Emulator) executeSIMDAcross(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVADDV:
+		e.simdUnit.VADDV(inst.Rd, inst.Rn, arr)
+	case insts.OpVSADDLV, insts.OpVUADDLV:
+		e.simdUnit.VADDLV(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVSADDLV)
+	case insts.OpVSMAXV, insts.OpVUMAXV:
+		e.simdUnit.VMAXV(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVSMAXV)
+	case insts.OpVSMINV, insts.OpVUMINV:
+		e.simdUnit.VMINV(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVSMINV)
+	case insts.OpVFMAXV, insts.OpVFMAXNMV:
+		e.simdUnit.VFMAXV(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVFMAXNMV)
+	case insts.OpVFMINV, insts.OpVFMINNMV:
+		e.simdUnit.VFMINV(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVFMINNMV)
+	}
+}
+
+// executeSIMDPermute executes SIMD permute instructions.
+func (e *Emulator) executeSIMDPermute(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVUZP1:
+		e.simdUnit.VUZP1(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVUZP2:
+		e.simdUnit.VUZP2(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVZIP1:
+		e.simdUnit.VZIP1(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVZIP2:
+		e.simdUnit.VZIP2(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVTRN1:
+		e.simdUnit.VTRN1(inst.Rd, inst.Rn, inst.Rm, arr)
+	case insts.OpVTRN2:
+		e.simdUnit.VTRN2(inst.Rd, inst.Rn, inst.Rm, arr)
+	}
+}
+
+// executeSIMDExtract executes EXT.
+func (e *Emulator) executeSIMDExtract(inst *insts.Instruction) {
+	if inst.Op == insts.OpVEXT {
+		e.simdUnit.VEXT(inst.Rd, inst.Rn, inst.Rm, uint8(inst.Imm), SIMDArrangement(inst.Arrangement))
+	}
+}
+
+// executeSIMDTableLookup executes TBL and TBX.
+func (e *Emulator) executeSIMDTableLookup(inst *insts.Instruction) {
+	switch inst.Op {
+	case insts.OpVTBL, insts.OpVTBX:
+		e.simdUnit.VTBL(inst.Rd, inst.Rn, inst.RegCount, inst.Rm,
+			SIMDArrangement(inst.Arrangement), inst.Op == insts.OpVTBX)
+	}
+}
+
+// executeSIMDElem executes SIMD vector by element instructions.
+func (e *Emulator) executeSIMDElem(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVFMLA:
+		e.simdUnit.VFMLAElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr)
+	case insts.OpVFMLS:
+		e.simdUnit.VFMLSElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr)
+	case insts.OpVFMUL:
+		e.simdUnit.VFMULElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr)
+	case insts.OpVMUL:
+		e.simdUnit.VMULElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr)
+	case insts.OpVMLA:
+		e.simdUnit.VMLAElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr)
+	case insts.OpVMLS:
+		e.simdUnit.VMLSElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr)
+	case insts.OpVSQDMULH, insts.OpVSQRDMULH:
+		e.simdUnit.VSQDMULHElem(inst.Rd, inst.Rn, inst.Rm, inst.Lane, arr, inst.Op == insts.OpVSQRDMULH)
+	}
+}
+
+// executeSIMDScalarPairwise executes SIMD scalar pairwise instructions.
+func (e *Emulator) executeSIMDScalarPairwise(inst *insts.Instruction) {
+	arr := SIMDArrangement(inst.Arrangement)
+
+	switch inst.Op {
+	case insts.OpVADDP:
+		e.simdUnit.ADDP(inst.Rd, inst.Rn, arr)
+	case insts.OpVFADDP:
+		e.simdUnit.FADDP(inst.Rd, inst.Rn, arr)
+	case insts.OpVFMAXP, insts.OpVFMAXNMP:
+		e.simdUnit.FMAXP(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVFMAXNMP)
+	case insts.OpVFMINP, insts.OpVFMINNMP:
+		e.simdUnit.FMINP(inst.Rd, inst.Rn, arr, inst.Op == insts.OpVFMINNMP)
+	}
+}
+
+// executeSIMDLoadStore executes SIMD&FP register load/store instructions.
+func (e *Emulator) executeSIMDLoadStore(inst *insts.Instruction) {
+	addr, base := e.loadStoreAddress(inst)
 
 	switch inst.Op {
 	case insts.OpLDRQ:
-		e.simdUnit.LDR128(inst.Rd, addr)
+		e.simdUnit.LDRFP(inst.Rd, addr, inst.AccessSize)
 	case insts.OpSTRQ:
-		e.simdUnit.STR128(inst.Rd, addr)
+		e.simdUnit.STRFP(inst.Rd, addr, inst.AccessSize)
+	}
+
+	e.writeBackBase(inst, base)
+}
+
+// executeSIMDLoadStoreStruct executes the structure loads and stores
+// (LD1-LD4, ST1-ST4 and LD1R-LD4R).
+func (e *Emulator) executeSIMDLoadStoreStruct(inst *insts.Instruction) {
+	base := e.regFile.ReadRegOrSP(inst.Rn)
+	q := inst.Is64Bit // Set for 128-bit vectors
+
+	switch inst.Op {
+	case insts.OpVLDN:
+		e.simdUnit.LDN(inst.Rd, base, inst.RegCount, inst.StructElems, inst.AccessSize, q)
+	case insts.OpVSTN:
+		e.simdUnit.STN(inst.Rd, base, inst.RegCount, inst.StructElems, inst.AccessSize, q)
+	case insts.OpVLDNLane:
+		e.simdUnit.LDNLane(inst.Rd, base, inst.StructElems, inst.AccessSize, inst.Lane)
+	case insts.OpVSTNLane:
+		e.simdUnit.STNLane(inst.Rd, base, inst.StructElems, inst.AccessSize, inst.Lane)
+	case insts.OpVLDNR:
+		e.simdUnit.LDNR(inst.Rd, base, inst.StructElems, inst.AccessSize, q)
+	}
+
+	// Post-index by Rm, or by the transfer size (SignedImm) when Rm is 31
+	if inst.IndexMode == insts.IndexPost {
+		offset := uint64(inst.SignedImm)
+		if inst.Rm != 31 {
+			offset = e.regFile.ReadReg(inst.Rm)
+		}
+		e.regFile.WriteRegOrSP(inst.Rn, base+offset)
 	}
 }
 
-// executeSIMDCopy executes SIMD copy instructions like DUP.
+// executeSIMDCopy executes SIMD copy instructions (DUP, INS, UMOV, SMOV).
 func (e *Emulator) executeSIMDCopy(inst *insts.Instruction) {
 	arr := SIMDArrangement(inst.Arrangement)
+	size, _ := laneShape(arr)
 
 	switch inst.Op {
 	case insts.OpDUP:
 		e.simdUnit.DUP(inst.Rd, inst.Rn, arr)
+	case insts.OpDUPElem:
+		e.simdUnit.DUPElem(inst.Rd, inst.Rn, inst.Lane, arr)
+	case insts.OpINS:
+		e.simdUnit.INS(inst.Rd, inst.Lane, size, inst.Rn)
+	case insts.OpINSElem:
+		e.simdUnit.INSElem(inst.Rd, inst.Lane, inst.Rn, uint8(inst.Imm2), size)
+	case insts.OpUMOV:
+		e.simdUnit.UMOV(inst.Rd, inst.Rn, inst.Lane, size)
+	case insts.OpSMOV:
+		e.simdUnit.SMOV(inst.Rd, inst.Rn, inst.Lane, size, inst.Is64Bit)
 	}
 }
 
-// executeSystemReg executes system register instructions like MRS.
+// executeSystemReg executes system register instructions (MRS, MSR) and
+// cache maintenance (DC, IC).
 func (e *Emulator) executeSystemReg(inst *insts.Instruction) {
 	switch inst.Op {
 	case insts.OpMRS:
-		e.executeMRS(inst)
+		e.regFile.WriteReg(inst.Rd, e.readSysReg(inst.SysReg))
+	case insts.OpMSR:
+		e.writeSysReg(inst.SysReg, e.regFile.ReadReg(inst.Rn))
+	case insts.OpDC:
+		e.executeDC(inst)
+	case insts.OpIC:
+		// Instructions are fetched from memory, so there is no stale
+		// instruction cache to invalidate.
 	}
 }
 
-// executeMRS executes MRS (Move from System Register) instructions.
-func (e *Emulator) executeMRS(inst *insts.Instruction) {
-	// Handle specific system registers
-	switch inst.SysReg {
-	case 0x5807: // DCZID_EL0 - Data Cache Zero ID register (actual encoding from instruction)
-		// DCZID_EL0[3:0] = DZP (Data Zero Prohibited)
-		// DCZID_EL0[7:4] = BS (Block Size) - log2 of cache line size
-		// Set BS=6 for 64-byte cache lines (2^6 = 64), DZP=0
-		value := uint64(0x60) // BS=6 (64-byte lines), DZP=0
+// readSysReg returns the value MRS reads from a system register. Registers
+// that are not modeled read as zero, as the ID space does under Linux.
+func (e *Emulator) readSysReg(enc uint16) uint64 {
+	switch enc {
+	case sysRegNZCV:
+		return e.regFile.PSTATE.nzcv()
+	case sysRegFPCR:
+		return e.simdRegFile.FPCR
+	case sysRegFPSR:
+		return e.simdRegFile.FPSR
+	case sysRegTPIDR:
+		return e.sysRegFile.TPIDR
+	case sysRegTPIDRRO:
+		return e.sysRegFile.TPIDRRO
+	case sysRegCNTFRQ:
+		return CounterFrequency
+	case sysRegCNTVCT, sysRegCNTPCT:
+		return e.clock.Counter()
+	case sysRegCTR:
+		return ctrValue
+	case sysRegDCZID:
+		return dczidValue
+	case sysRegMIDR:
+		return midrValue
+	case sysRegMPIDR:
+		return mpidrValue
+	case sysRegPFR0:
+		return pfr0Value
+	case sysRegISAR0:
+		return isar0Value
+	case sysRegISAR1:
+		return isar1Value
+	default:
+		return 0
+	}
+}
+
+// writeSysReg writes the value of MSR to a system register. Writes to
+// read-only and unmodeled registers are ignored.
+func (e *Emulator) writeSysReg(enc uint16, value uint64) {
+	switch enc {
+	case sysRegNZCV:
+		e.regFile.PSTATE.setNZCV(value)
+	case sysRegFPCR:
+		e.simdRegFile.FPCR = value
+	case sysRegFPSR:
+		e.simdRegFile.FPSR = value
+	case sysRegTPIDR:
+		e.sysRegFile.TPIDR = value
+	}
+}
+
+// executeDC executes the DC operations. DC ZVA zeroes the aligned block
+// containing the address; cleaning and invalidating have no architectural
+// effect on a memory without caches.
+func (e *Emulator) executeDC(inst *insts.Instruction) {
+	if inst.SysReg != insts.DCZVA {
+		return
+	}
+	addr := e.regFile.ReadReg(inst.Rn) &^ (zvaBlockSize - 1)
+	e.memory.WriteBytes(addr, make([]byte, zvaBlockSize))
+}
+
+// executeFPDataProc executes scalar floating-point data processing.
+func (e *Emulator) executeFPDataProc(inst *insts.Instruction) {
+	t := FPType(inst.FPType)
+
+	switch inst.Op {
+	case insts.OpFADD:
+		e.fpu.FADD(t, inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpFSUB:
+		e.fpu.FSUB(t, inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpFMUL:
+		e.fpu.FMUL(t, inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpFNMUL:
+		e.fpu.FNMUL(t, inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpFDIV:
+		e.fpu.FDIV(t, inst.Rd, inst.Rn, inst.Rm)
+	case insts.OpFMAX, insts.OpFMAXNM:
+		e.fpu.FMAX(t, inst.Rd, inst.Rn, inst.Rm, inst.Op == insts.OpFMAXNM)
+	case insts.OpFMIN, insts.OpFMINNM:
+		e.fpu.FMIN(t, inst.Rd, inst.Rn, inst.Rm, inst.Op == insts.OpFMINNM)
+	case insts.OpFRINTN, insts.OpFRINTA, insts.OpFRINTP, insts.OpFRINTM,
+		insts.OpFRINTZ, insts.OpFRINTX, insts.OpFRINTI:
+		e.fpu.FRINT(t, inst.Rd, inst.Rn, e.frintRounding(inst.Op), inst.Op == insts.OpFRINTX)
+	case insts.OpFSQRT:
+		e.fpu.FSQRT(t, inst.Rd, inst.Rn)
+	case insts.OpFMADD:
+		// Ra is stored in Rt2 field
+		e.fpu.FMADD(t, inst.Rd, inst.Rn, inst.Rm, inst.Rt2, false, false)
+	case insts.OpFMSUB:
+		e.fpu.FMADD(t, inst.Rd, inst.Rn, inst.Rm, inst.Rt2, true, false)
+	case insts.OpFNMADD:
+		e.fpu.FMADD(t, inst.Rd, inst.Rn, inst.Rm, inst.Rt2, true, true)
+	case insts.OpFNMSUB:
+		e.fpu.FMADD(t, inst.Rd, inst.Rn, inst.Rm, inst.Rt2, false, true)
+	case insts.OpFABS:
+		e.fpu.FABS(t, inst.Rd, inst.Rn)
+	case insts.OpFNEG:
+		e.fpu.FNEG(t, inst.Rd, inst.Rn)
+	case insts.OpFMOV:
+		e.fpu.FMOV(t, inst.Rd, inst.Rn)
+	case insts.OpFMOVImm:
+		e.fpu.Write(t, inst.Rd, inst.Imm)
+	case insts.OpFCVT:
+		e.fpu.FCVT(t, FPType(inst.FPDstType), inst.Rd, inst.Rn)
+	case insts.OpFCMP, insts.OpFCMPE:
+		// Rm == 0xFF marks the compare-with-zero form
+		e.fpu.FCMP(t, inst.Rn, inst.Rm, inst.Rm == 0xFF, inst.Op == insts.OpFCMPE)
+	case insts.OpFCCMP, insts.OpFCCMPE:
+		if e.branchUnit.CheckCondition(Cond(inst.Cond)) {
+			e.fpu.FCMP(t, inst.Rn, inst.Rm, false, inst.Op == insts.OpFCCMPE)
+		} else {
+			// Condition false: set flags to nzcv value
+			nzcv := inst.Imm
+			e.regFile.PSTATE.N = (nzcv>>3)&1 == 1
+			e.regFile.PSTATE.Z = (nzcv>>2)&1 == 1
+			e.regFile.PSTATE.C = (nzcv>>1)&1 == 1
+			e.regFile.PSTATE.V = nzcv&1 == 1
+		}
+	case insts.OpFCSEL:
+		src := inst.Rm
+		if e.branchUnit.CheckCondition(Cond(inst.Cond)) {
+			src = inst.Rn
+		}
+		e.fpu.FMOV(t, inst.Rd, src)
+	}
+}
+
+// executeFPConvert executes conversions between floating-point registers and
+// general registers.
+func (e *Emulator) executeFPConvert(inst *insts.Instruction) {
+	t := FPType(inst.FPType)
+	fbits := uint(inst.Imm) // Fraction bits of fixed-point forms
+
+	switch inst.Op {
+	case insts.OpSCVTF, insts.OpUCVTF:
+		value := e.regFile.ReadReg(inst.Rn)
+		e.fpu.IntToFP(t, inst.Rd, value, inst.Is64Bit, inst.Op == insts.OpSCVTF, fbits)
+	case insts.OpFMOVToGP:
+		// Imm selects the upper 64 bits for FMOV Xd, Vn.D[1]
+		value := e.fpu.Read(t, inst.Rn)
+		if inst.Imm == 1 {
+			value = e.simdRegFile.ReadLane64(inst.Rn, 1)
+		}
 		e.regFile.WriteReg(inst.Rd, value)
+	case insts.OpFMOVFromGP:
+		value := e.regFile.ReadReg(inst.Rn)
+		if !inst.Is64Bit {
+			value &= 0xFFFFFFFF
+		}
+		if inst.Imm == 1 {
+			e.simdRegFile.WriteLane64(inst.Rd, 1, value)
+		} else {
+			e.fpu.Write(t, inst.Rd, value)
+		}
 	default:
-		// For unknown system registers, return 0
-		// This is a common approach in simulators
-		e.regFile.WriteReg(inst.Rd, 0)
+		mode, signed, ok := fpToIntRounding(inst.Op)
+		if !ok {
+			return
+		}
+		value := e.fpu.FPToInt(t, inst.Rn, inst.Is64Bit, signed, fbits, mode)
+		e.regFile.WriteReg(inst.Rd, value)
+	}
+}
+
+// fpToIntRounding returns the rounding mode and signedness of an FCVT*S or
+// FCVT*U opcode.
+func fpToIntRounding(op insts.Op) (mode FPRounding, signed, ok bool) {
+	switch op {
+	case insts.OpFCVTNS, insts.OpFCVTNU:
+		mode = FPRoundNearest
+	case insts.OpFCVTPS, insts.OpFCVTPU:
+		mode = FPRoundPlusInf
+	case insts.OpFCVTMS, insts.OpFCVTMU:
+		mode = FPRoundMinusInf
+	case insts.OpFCVTZS, insts.OpFCVTZU:
+		mode = FPRoundZero
+	case insts.OpFCVTAS, insts.OpFCVTAU:
+		mode = FPRoundNearestAway
+	default:
+		return 0, false, false
+	}
+
+	switch op {
+	case insts.OpFCVTNS, insts.OpFCVTPS, insts.OpFCVTMS, insts.OpFCVTZS, insts.OpFCVTAS:
+		signed = true
 	}
+
+	return mode, signed, true
 }
@@ -525,9 +525,9 @@ var _ = Describe("Emulator SIMD Dispatch", func() {
 		e.SIMDRegFile().WriteLane32(2, 0, math.Float32bits(3.0))
 		e.SIMDRegFile().WriteLane32(2, 1, math.Float32bits(4.0))
 
-		// FADD V0.4S, V1.4S, V2.4S -> 0x4EA2D420
+		// FADD V0.4S, V1.4S, V2.4S -> 0x4E22D420
 		program := make([]byte, 8)
-		binary.LittleEndian.PutUint32(program[0:4], 0x4EA2D420)
+		binary.LittleEndian.PutUint32(program[0:4], 0x4E22D420)
 		binary.LittleEndian.PutUint32(program[4:8], encodeSVC(0))
 
 		e.RegFile().WriteReg(8, 93)
@@ -565,9 +565,9 @@ var _ = Describe("MRS System Register Instructions", func() {
 		Expect(result.Exited).To(BeFalse())
 
 		// Check that x5 register was set correctly
-		// DCZID_EL0 should return 0x60 (BS=6 for 64-byte cache lines, DZP=0)
+		// DCZID_EL0 should return 0x4 (BS=4 for 4<<4 = 64-byte blocks, DZP=0)
 		x5Value := e.RegFile().ReadReg(5)
-		Expect(x5Value).To(Equal(uint64(0x60)))
+		Expect(x5Value).To(Equal(uint64(0x4)))
 
 		// Check that PC was advanced correctly
 		Expect(e.RegFile().PC).To(Equal(uint64(0x1004)))
@@ -2,6 +2,7 @@
 package emu
 
 import (
+	"io"
 	"os"
 	"sync"
 	"time"
@@ -9,24 +10,27 @@ import (
 
 // FileDescriptor represents an open file descriptor.
 type FileDescriptor struct {
-	HostFile *os.File // Host file handle (nil for closed or special FDs)
-	Path     string   // Original path (empty for stdin/stdout/stderr)
-	Flags    int      // Open flags
-	IsOpen   bool     // Whether the FD is currently open
+	File   File   // Open file (nil for closed or special FDs)
+	Path   string // Original path (empty for stdin/stdout/stderr)
+	Flags  int    // Open flags
+	IsOpen bool   // Whether the FD is currently open
 }
 
 // FDTable manages file descriptors for syscall emulation.
 type FDTable struct {
 	fds    map[uint64]*FileDescriptor
 	nextFD uint64
+	fs     FileSystem
 	mu     sync.Mutex
 }
 
-// NewFDTable creates a new file descriptor table with standard streams initialized.
+// NewFDTable creates a new file descriptor table with standard streams
+// initialized. Files open on the host, through HostFS.
 func NewFDTable() *FDTable {
 	t := &FDTable{
 		fds:    make(map[uint64]*FileDescriptor),
 		nextFD: 3, // Start allocating at FD 3
+		fs:     HostFS{},
 	}
 
 	// Initialize standard streams (FDs 0, 1, 2)
@@ -38,13 +42,28 @@ func NewFDTable() *FDTable {
 	return t
 }
 
+// SetFileSystem sets the filesystem that Open opens files in. Files that
+// are already open are unaffected.
+func (t *FDTable) SetFileSystem(fsys FileSystem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fs = fsys
+}
+
+// FileSystem returns the filesystem that Open opens files in.
+func (t *FDTable) FileSystem() FileSystem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fs
+}
+
 // Open opens a file and returns a new file descriptor.
 func (t *FDTable) Open(path string, flags int, mode os.FileMode) (uint64, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	// Open the file on the host
-	hostFile, err := os.OpenFile(path, flags, mode)
+	// Open the file in the filesystem
+	file, err := t.fs.OpenFile(path, flags, mode)
 	if err != nil {
 		return 0, err
 	}
@@ -54,10 +73,10 @@ func (t *FDTable) Open(path string, flags int, mode os.FileMode) (uint64, error)
 	t.nextFD++
 
 	t.fds[fd] = &FileDescriptor{
-		HostFile: hostFile,
-		Path:     path,
-		Flags:    flags,
-		IsOpen:   true,
+		File:   file,
+		Path:   path,
+		Flags:  flags,
+		IsOpen: true,
 	}
 
 	return fd, nil
@@ -80,15 +99,15 @@ func (t *FDTable) Close(fd uint64) error {
 		return nil
 	}
 
-	// Close the host file
-	if entry.HostFile != nil {
-		err := entry.HostFile.Close()
+	// Close the file
+	if entry.File != nil {
+		err := entry.File.Close()
 		if err != nil {
 			return err
 		}
 	}
 
-	entry.HostFile = nil
+	entry.File = nil
 	entry.IsOpen = false
 
 	return nil
@@ -107,6 +126,22 @@ func (t *FDTable) Get(fd uint64) (*FileDescriptor, bool) {
 	return entry, true
 }
 
+// Reopen opens the file behind a file descriptor again with the given
+// flags, for a reference that outlives the descriptor, as a file mapping
+// does. It fails with os.ErrInvalid for closed descriptors and the
+// standard streams.
+func (t *FDTable) Reopen(fd uint64, flags int) (File, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, exists := t.fds[fd]
+	if !exists || !entry.IsOpen || entry.File == nil {
+		return nil, os.ErrInvalid
+	}
+
+	return t.fs.OpenFile(entry.Path, flags, 0)
+}
+
 // IsOpen checks if a file descriptor is open.
 func (t *FDTable) IsOpen(fd uint64) bool {
 	t.mu.Lock()
@@ -125,7 +160,7 @@ func (t *FDTable) Read(fd uint64, buf []byte) (int, error) {
 		return 0, os.ErrInvalid
 	}
 
-	hostFile := entry.HostFile
+	file := entry.File
 	t.mu.Unlock()
 
 	// stdin is handled separately by the syscall handler
@@ -133,11 +168,11 @@ func (t *FDTable) Read(fd uint64, buf []byte) (int, error) {
 		return 0, os.ErrInvalid
 	}
 
-	if hostFile == nil {
+	if file == nil {
 		return 0, os.ErrInvalid
 	}
 
-	return hostFile.Read(buf)
+	return file.Read(buf)
 }
 
 // Write writes a buffer to a file descriptor.
@@ -149,7 +184,7 @@ func (t *FDTable) Write(fd uint64, buf []byte) (int, error) {
 		return 0, os.ErrInvalid
 	}
 
-	hostFile := entry.HostFile
+	file := entry.File
 	t.mu.Unlock()
 
 	// stdout/stderr are handled separately by the syscall handler
@@ -157,11 +192,55 @@ func (t *FDTable) Write(fd uint64, buf []byte) (int, error) {
 		return 0, os.ErrInvalid
 	}
 
-	if hostFile == nil {
+	if file == nil {
+		return 0, os.ErrInvalid
+	}
+
+	return file.Write(buf)
+}
+
+// ReadAt reads from a file descriptor at an offset, without moving its file
+// position. Reading past the end of the file is a short read, not an error.
+func (t *FDTable) ReadAt(fd uint64, buf []byte, offset int64) (int, error) {
+	t.mu.Lock()
+	entry, exists := t.fds[fd]
+	if !exists || !entry.IsOpen {
+		t.mu.Unlock()
+		return 0, os.ErrInvalid
+	}
+
+	file := entry.File
+	t.mu.Unlock()
+
+	if file == nil {
+		return 0, os.ErrInvalid
+	}
+
+	n, err := file.ReadAt(buf, offset)
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// WriteAt writes a buffer to a file descriptor at an offset, without moving
+// its file position.
+func (t *FDTable) WriteAt(fd uint64, buf []byte, offset int64) (int, error) {
+	t.mu.Lock()
+	entry, exists := t.fds[fd]
+	if !exists || !entry.IsOpen {
+		t.mu.Unlock()
+		return 0, os.ErrInvalid
+	}
+
+	file := entry.File
+	t.mu.Unlock()
+
+	if file == nil {
 		return 0, os.ErrInvalid
 	}
 
-	return hostFile.Write(buf)
+	return file.WriteAt(buf, offset)
 }
 
 // Stat returns file information for a file descriptor.
@@ -173,7 +252,7 @@ func (t *FDTable) Stat(fd uint64) (os.FileInfo, error) {
 		return nil, os.ErrInvalid
 	}
 
-	hostFile := entry.HostFile
+	file := entry.File
 	t.mu.Unlock()
 
 	// stdin/stdout/stderr return a stub FileInfo
@@ -181,11 +260,11 @@ func (t *FDTable) Stat(fd uint64) (os.FileInfo, error) {
 		return &stdioFileInfo{name: entry.Path, isCharDevice: true}, nil
 	}
 
-	if hostFile == nil {
+	if file == nil {
 		return nil, os.ErrInvalid
 	}
 
-	return hostFile.Stat()
+	return file.Stat()
 }
 
 // Seek sets the file position for the given file descriptor.
@@ -197,7 +276,7 @@ func (t *FDTable) Seek(fd uint64, offset int64, whence int) (int64, error) {
 		return 0, os.ErrInvalid
 	}
 
-	hostFile := entry.HostFile
+	file := entry.File
 	t.mu.Unlock()
 
 	// stdin/stdout/stderr can't be seeked
@@ -205,11 +284,11 @@ func (t *FDTable) Seek(fd uint64, offset int64, whence int) (int64, error) {
 		return 0, os.ErrInvalid
 	}
 
-	if hostFile == nil {
+	if file == nil {
 		return 0, os.ErrInvalid
 	}
 
-	return hostFile.Seek(offset, whence)
+	return file.Seek(offset, whence)
 }
 
 // stdioFileInfo is a stub FileInfo for stdin/stdout/stderr.
@@ -0,0 +1,1118 @@
+// Package emu provides functional ARM64 emulation.
+package emu
+
+import (
+	"math"
+	"math/big"
+)
+
+// FPType represents the precision of a scalar floating-point operand.
+type FPType = uint8
+
+// Scalar floating-point precisions matching insts package.
+const (
+	FPSingle FPType = 0b00 // 32-bit (S register)
+	FPDouble FPType = 0b01 // 64-bit (D register)
+	FPHalf   FPType = 0b11 // 16-bit (H register)
+)
+
+// FPSR cumulative exception flags.
+const (
+	FPSRIOC uint64 = 1 << 0  // Invalid operation
+	FPSRDZC uint64 = 1 << 1  // Divide by zero
+	FPSROFC uint64 = 1 << 2  // Overflow
+	FPSRUFC uint64 = 1 << 3  // Underflow
+	FPSRIXC uint64 = 1 << 4  // Inexact
+	FPSRIDC uint64 = 1 << 7  // Input denormal
+	FPSRQC  uint64 = 1 << 27 // Cumulative saturation (integer SIMD)
+)
+
+// FPCR control fields.
+const (
+	FPCRRModeShift        = 22      // Rounding mode, bits [23:22]
+	FPCRRModeMask  uint64 = 3 << 22 // Rounding mode mask
+	FPCRFZ         uint64 = 1 << 24 // Flush denormals to zero
+	FPCRDN         uint64 = 1 << 25 // Default NaN
+)
+
+// FPRounding is a floating-point rounding mode. The first four values match
+// the FPCR.RMode encoding.
+type FPRounding uint8
+
+// Rounding modes.
+const (
+	FPRoundNearest     FPRounding = 0 // Round to nearest, ties to even
+	FPRoundPlusInf     FPRounding = 1 // Round toward +infinity
+	FPRoundMinusInf    FPRounding = 2 // Round toward -infinity
+	FPRoundZero        FPRounding = 3 // Round toward zero
+	FPRoundNearestAway FPRounding = 4 // Round to nearest, ties away (FCVTA*)
+)
+
+// fpFormat describes an IEEE 754 binary interchange format.
+type fpFormat struct {
+	expBits  uint
+	fracBits uint
+}
+
+var (
+	fpHalfFormat   = fpFormat{expBits: 5, fracBits: 10}
+	fpSingleFormat = fpFormat{expBits: 8, fracBits: 23}
+	fpDoubleFormat = fpFormat{expBits: 11, fracBits: 52}
+)
+
+func formatOf(t FPType) fpFormat {
+	switch t {
+	case FPHalf:
+		return fpHalfFormat
+	case FPSingle:
+		return fpSingleFormat
+	default:
+		return fpDoubleFormat
+	}
+}
+
+func (f fpFormat) bias() int          { return 1<<(f.expBits-1) - 1 }
+func (f fpFormat) maxExp() uint64     { return 1<<f.expBits - 1 }
+func (f fpFormat) signBit() uint64    { return 1 << (f.expBits + f.fracBits) }
+func (f fpFormat) fracMask() uint64   { return 1<<f.fracBits - 1 }
+func (f fpFormat) quietBit() uint64   { return 1 << (f.fracBits - 1) }
+func (f fpFormat) defaultNaN() uint64 { return f.maxExp()<<f.fracBits | f.quietBit() }
+
+func (f fpFormat) zero(sign bool) uint64 {
+	if sign {
+		return f.signBit()
+	}
+	return 0
+}
+
+func (f fpFormat) inf(sign bool) uint64 {
+	return f.zero(sign) | f.maxExp()<<f.fracBits
+}
+
+func (f fpFormat) maxNormal(sign bool) uint64 {
+	return f.zero(sign) | (f.maxExp()-1)<<f.fracBits | f.fracMask()
+}
+
+// fpClass classifies an unpacked floating-point value.
+type fpClass uint8
+
+const (
+	fpZero fpClass = iota
+	fpFinite
+	fpInf
+	fpQNaN
+	fpSNaN
+)
+
+// fpValue is an unpacked floating-point operand.
+type fpValue struct {
+	class fpClass
+	sign  bool
+	bits  uint64     // Original encoding, used for NaN propagation
+	mag   *big.Float // Exact magnitude of a finite non-zero value
+}
+
+func (v fpValue) isNaN() bool { return v.class == fpQNaN || v.class == fpSNaN }
+
+// value returns the exact signed value of a zero or finite operand.
+func (v fpValue) value() *big.Float {
+	if v.class == fpZero {
+		return new(big.Float)
+	}
+	x := new(big.Float).Set(v.mag)
+	if v.sign {
+		x.Neg(x)
+	}
+	return x
+}
+
+// FPU implements ARM64 scalar floating-point operations on the SIMD register
+// file. Results are rounded according to FPCR and exceptions accumulate in
+// FPSR, both of which live in the SIMD register file.
+type FPU struct {
+	simdRegFile *SIMDRegFile
+	regFile     *RegFile // For NZCV flags and general register transfers
+}
+
+// NewFPU creates a new scalar floating-point unit.
+func NewFPU(simdRegFile *SIMDRegFile, regFile *RegFile) *FPU {
+	return &FPU{
+		simdRegFile: simdRegFile,
+		regFile:     regFile,
+	}
+}
+
+// Read reads a scalar FP register of the given precision as raw bits.
+func (u *FPU) Read(t FPType, reg uint8) uint64 {
+	switch t {
+	case FPHalf:
+		return uint64(u.simdRegFile.ReadH(reg))
+	case FPSingle:
+		return uint64(u.simdRegFile.ReadS(reg))
+	default:
+		return u.simdRegFile.ReadD(reg)
+	}
+}
+
+// Write writes raw bits to a scalar FP register, zeroing the upper bits of
+// the vector register.
+func (u *FPU) Write(t FPType, reg uint8, bits uint64) {
+	switch t {
+	case FPHalf:
+		u.simdRegFile.WriteH(reg, uint16(bits))
+	case FPSingle:
+		u.simdRegFile.WriteS(reg, uint32(bits))
+	default:
+		u.simdRegFile.WriteD(reg, bits)
+	}
+}
+
+func (u *FPU) rounding() FPRounding {
+	return FPRounding((u.simdRegFile.FPCR & FPCRRModeMask) >> FPCRRModeShift)
+}
+
+// flushToZero reports whether denormals of format f are flushed to zero.
+// Half precision is governed by FPCR.FZ16, which is not modeled.
+func (u *FPU) flushToZero(f fpFormat) bool {
+	return u.simdRegFile.FPCR&FPCRFZ != 0 && f != fpHalfFormat
+}
+
+func (u *FPU) raise(flags uint64) {
+	u.simdRegFile.FPSR |= flags
+}
+
+// fpOp identifies an arithmetic operation.
+type fpOp uint8
+
+const (
+	fpOpAdd fpOp = iota
+	fpOpSub
+	fpOpMul
+	fpOpDiv
+	fpOpSqrt
+)
+
+// FADD computes Vd = Vn + Vm.
+func (u *FPU) FADD(t FPType, vd, vn, vm uint8) {
+	u.arith(fpOpAdd, t, vd, vn, vm)
+}
+
+// FSUB computes Vd = Vn - Vm.
+func (u *FPU) FSUB(t FPType, vd, vn, vm uint8) {
+	u.arith(fpOpSub, t, vd, vn, vm)
+}
+
+// FMUL computes Vd = Vn * Vm.
+func (u *FPU) FMUL(t FPType, vd, vn, vm uint8) {
+	u.arith(fpOpMul, t, vd, vn, vm)
+}
+
+// FNMUL computes Vd = -(Vn * Vm), negating the rounded product.
+func (u *FPU) FNMUL(t FPType, vd, vn, vm uint8) {
+	u.Write(t, vd, fpNeg(t, u.compute(fpOpMul, t, u.Read(t, vn), u.Read(t, vm))))
+}
+
+// FDIV computes Vd = Vn / Vm.
+func (u *FPU) FDIV(t FPType, vd, vn, vm uint8) {
+	u.arith(fpOpDiv, t, vd, vn, vm)
+}
+
+// FSQRT computes Vd = sqrt(Vn).
+func (u *FPU) FSQRT(t FPType, vd, vn uint8) {
+	u.arith(fpOpSqrt, t, vd, vn, vn)
+}
+
+func (u *FPU) arith(op fpOp, t FPType, vd, vn, vm uint8) {
+	u.Write(t, vd, u.compute(op, t, u.Read(t, vn), u.Read(t, vm)))
+}
+
+// compute applies an arithmetic operation to the encodings a and b.
+func (u *FPU) compute(op fpOp, t FPType, a, b uint64) uint64 {
+	if r, ok := u.fastArith(op, t, a, b); ok {
+		return r
+	}
+	return u.slowArith(op, formatOf(t), a, b)
+}
+
+// fastArith computes single and double precision arithmetic natively when
+// that is guaranteed to match the architected result: round to nearest, no
+// flush-to-zero, inexact already signaled (the only flag a normal result can
+// raise) and a normal result that is not at the underflow boundary.
+func (u *FPU) fastArith(op fpOp, t FPType, a, b uint64) (uint64, bool) {
+	if u.simdRegFile.FPCR&(FPCRRModeMask|FPCRFZ) != 0 || u.simdRegFile.FPSR&FPSRIXC == 0 {
+		return 0, false
+	}
+
+	var x, y float64
+	switch t {
+	case FPDouble:
+		x, y = math.Float64frombits(a), math.Float64frombits(b)
+	case FPSingle:
+		// Double has more than 2p+2 bits, so rounding the double result
+		// to single gives the correctly rounded single result.
+		x = float64(math.Float32frombits(uint32(a)))
+		y = float64(math.Float32frombits(uint32(b)))
+	default:
+		return 0, false
+	}
+
+	var r float64
+	switch op {
+	case fpOpAdd:
+		r = x + y
+	case fpOpSub:
+		r = x - y
+	case fpOpMul:
+		r = x * y
+	case fpOpDiv:
+		r = x / y
+	case fpOpSqrt:
+		r = math.Sqrt(x)
+	}
+
+	var bits uint64
+	if t == FPSingle {
+		bits = uint64(math.Float32bits(float32(r)))
+	} else {
+		bits = math.Float64bits(r)
+	}
+	return bits, isSafeNormal(formatOf(t), bits)
+}
+
+// isSafeNormal reports whether bits encode a normal number above the
+// smallest normal exponent.
+func isSafeNormal(f fpFormat, bits uint64) bool {
+	exp := (bits >> f.fracBits) & f.maxExp()
+	return exp >= 2 && exp != f.maxExp()
+}
+
+// slowArith computes an arithmetic operation exactly and rounds it with the
+// full architected behavior.
+func (u *FPU) slowArith(op fpOp, f fpFormat, a, b uint64) uint64 {
+	x, y := u.unpack(f, a), u.unpack(f, b)
+
+	if op == fpOpSqrt {
+		if x.isNaN() {
+			return u.processNaN(f, x)
+		}
+		switch {
+		case x.class == fpZero:
+			return f.zero(x.sign)
+		case x.sign:
+			u.raise(FPSRIOC)
+			return f.defaultNaN()
+		case x.class == fpInf:
+			return f.inf(false)
+		}
+		return u.round(f, false, sqrtSticky(x.mag, f.fracBits+3))
+	}
+
+	if x.isNaN() || y.isNaN() {
+		return u.processNaNs(f, x, y)
+	}
+
+	switch op {
+	case fpOpAdd, fpOpSub:
+		if op == fpOpSub {
+			y.sign = !y.sign
+		}
+		switch {
+		case x.class == fpInf && y.class == fpInf && x.sign != y.sign:
+			u.raise(FPSRIOC)
+			return f.defaultNaN()
+		case x.class == fpInf:
+			return f.inf(x.sign)
+		case y.class == fpInf:
+			return f.inf(y.sign)
+		case x.class == fpZero && y.class == fpZero && x.sign == y.sign:
+			return f.zero(x.sign)
+		}
+		sum := new(big.Float).SetPrec(4096).Add(x.value(), y.value())
+		if sum.Sign() == 0 {
+			// An exact zero sum of opposite operands is +0, or -0 when
+			// rounding toward -infinity.
+			return f.zero(u.rounding() == FPRoundMinusInf)
+		}
+		return u.round(f, sum.Sign() < 0, sum.Abs(sum))
+
+	case fpOpMul:
+		sign := x.sign != y.sign
+		switch {
+		case (x.class == fpInf && y.class == fpZero) || (x.class == fpZero && y.class == fpInf):
+			u.raise(FPSRIOC)
+			return f.defaultNaN()
+		case x.class == fpInf || y.class == fpInf:
+			return f.inf(sign)
+		case x.class == fpZero || y.class == fpZero:
+			return f.zero(sign)
+		}
+		return u.round(f, sign, new(big.Float).SetPrec(256).Mul(x.mag, y.mag))
+
+	default: // fpOpDiv
+		sign := x.sign != y.sign
+		switch {
+		case (x.class == fpInf && y.class == fpInf) || (x.class == fpZero && y.class == fpZero):
+			u.raise(FPSRIOC)
+			return f.defaultNaN()
+		case x.class == fpInf || y.class == fpZero:
+			if y.class == fpZero {
+				u.raise(FPSRDZC)
+			}
+			return f.inf(sign)
+		case x.class == fpZero || y.class == fpInf:
+			return f.zero(sign)
+		}
+		q := new(big.Float).SetPrec(f.fracBits+3).SetMode(big.ToZero).Quo(x.mag, y.mag)
+		return u.round(f, sign, withSticky(q, q.Acc() != big.Exact))
+	}
+}
+
+// FMADD computes Vd = Va + Vn*Vm with a single rounding. negateProduct and
+// negateAddend select FMSUB (-Vn*Vm), FNMADD (both) and FNMSUB (-Va).
+func (u *FPU) FMADD(t FPType, vd, vn, vm, va uint8, negateProduct, negateAddend bool) {
+	f := formatOf(t)
+	n, m, a := u.Read(t, vn), u.Read(t, vm), u.Read(t, va)
+	if negateProduct {
+		n ^= f.signBit()
+	}
+	if negateAddend {
+		a ^= f.signBit()
+	}
+	u.Write(t, vd, u.mulAdd(t, a, n, m))
+}
+
+// mulAdd computes addend + op1*op2 on encodings, natively for doubles when
+// that matches the architected result (see fastArith).
+func (u *FPU) mulAdd(t FPType, addend, op1, op2 uint64) uint64 {
+	f := formatOf(t)
+	if t == FPDouble && u.simdRegFile.FPCR&(FPCRRModeMask|FPCRFZ) == 0 &&
+		u.simdRegFile.FPSR&FPSRIXC != 0 {
+		r := math.Float64bits(math.FMA(math.Float64frombits(op1),
+			math.Float64frombits(op2), math.Float64frombits(addend)))
+		if isSafeNormal(f, r) {
+			return r
+		}
+	}
+	return u.fma(f, addend, op1, op2)
+}
+
+// fma implements FPMulAdd: addend + op1*op2, rounded once.
+func (u *FPU) fma(f fpFormat, addend, op1, op2 uint64) uint64 {
+	a, x, y := u.unpack(f, addend), u.unpack(f, op1), u.unpack(f, op2)
+
+	infTimesZero := (x.class == fpInf && y.class == fpZero) ||
+		(x.class == fpZero && y.class == fpInf)
+	if a.class == fpQNaN && infTimesZero {
+		u.raise(FPSRIOC)
+		return f.defaultNaN()
+	}
+	if a.isNaN() || x.isNaN() || y.isNaN() {
+		return u.processNaNs(f, a, x, y)
+	}
+
+	productSign := x.sign != y.sign
+	productInf := x.class == fpInf || y.class == fpInf
+	productZero := x.class == fpZero || y.class == fpZero
+
+	switch {
+	case infTimesZero || (productInf && a.class == fpInf && a.sign != productSign):
+		u.raise(FPSRIOC)
+		return f.defaultNaN()
+	case productInf:
+		return f.inf(productSign)
+	case a.class == fpInf:
+		return f.inf(a.sign)
+	case productZero && a.class == fpZero:
+		if a.sign == productSign {
+			return f.zero(a.sign)
+		}
+		return f.zero(u.rounding() == FPRoundMinusInf)
+	}
+
+	sum := new(big.Float).SetPrec(4096).Set(a.value())
+	if !productZero {
+		product := new(big.Float).SetPrec(256).Mul(x.mag, y.mag)
+		if productSign {
+			product.Neg(product)
+		}
+		sum.Add(sum, product)
+	}
+	if sum.Sign() == 0 {
+		return f.zero(u.rounding() == FPRoundMinusInf)
+	}
+	return u.round(f, sum.Sign() < 0, sum.Abs(sum))
+}
+
+// FABS computes Vd = |Vn| without raising exceptions.
+func (u *FPU) FABS(t FPType, vd, vn uint8) {
+	u.Write(t, vd, u.Read(t, vn)&^formatOf(t).signBit())
+}
+
+// FNEG computes Vd = -Vn without raising exceptions.
+func (u *FPU) FNEG(t FPType, vd, vn uint8) {
+	u.Write(t, vd, u.Read(t, vn)^formatOf(t).signBit())
+}
+
+// FMAX computes Vd = max(Vn, Vm). number selects FMAXNM, where a quiet NaN
+// loses to a number.
+func (u *FPU) FMAX(t FPType, vd, vn, vm uint8, number bool) {
+	u.Write(t, vd, u.maxMin(t, u.Read(t, vn), u.Read(t, vm), true, number))
+}
+
+// FMIN computes Vd = min(Vn, Vm). number selects FMINNM.
+func (u *FPU) FMIN(t FPType, vd, vn, vm uint8, number bool) {
+	u.Write(t, vd, u.maxMin(t, u.Read(t, vn), u.Read(t, vm), false, number))
+}
+
+// FRINT rounds Vn to an integral value using mode. exact selects FRINTX,
+// which raises Inexact when the value changes.
+func (u *FPU) FRINT(t FPType, vd, vn uint8, mode FPRounding, exact bool) {
+	u.Write(t, vd, u.roundToIntegral(t, u.Read(t, vn), mode, exact))
+}
+
+// FMOV copies Vn to Vd.
+func (u *FPU) FMOV(t FPType, vd, vn uint8) {
+	u.Write(t, vd, u.Read(t, vn))
+}
+
+// FCMP compares Vn with Vm (or with +0.0 if withZero) and sets NZCV.
+// signaling selects FCMPE, which treats quiet NaNs as invalid too.
+func (u *FPU) FCMP(t FPType, vn, vm uint8, withZero, signaling bool) {
+	var b uint64 // +0.0
+	if !withZero {
+		b = u.Read(t, vm)
+	}
+	c, ordered := u.compare(t, u.Read(t, vn), b, signaling)
+
+	pstate := &u.regFile.PSTATE
+	switch {
+	case !ordered: // Unordered: 0011
+		pstate.N, pstate.Z, pstate.C, pstate.V = false, false, true, true
+	case c == 0: // Equal: 0110
+		pstate.N, pstate.Z, pstate.C, pstate.V = false, true, true, false
+	case c < 0: // Less than: 1000
+		pstate.N, pstate.Z, pstate.C, pstate.V = true, false, false, false
+	default: // Greater than: 0010
+		pstate.N, pstate.Z, pstate.C, pstate.V = false, false, true, false
+	}
+}
+
+// compare compares the encodings a and b. ordered is false if either is a
+// NaN, which raises Invalid Operation if it is signaling, or for any NaN if
+// signaling is set.
+func (u *FPU) compare(t FPType, a, b uint64, signaling bool) (c int, ordered bool) {
+	f := formatOf(t)
+	x, y := u.unpack(f, a), u.unpack(f, b)
+	if x.isNaN() || y.isNaN() {
+		if signaling || x.class == fpSNaN || y.class == fpSNaN {
+			u.raise(FPSRIOC)
+		}
+		return 0, false
+	}
+	return compareValues(x, y), true
+}
+
+// compareValues compares two non-NaN values.
+func compareValues(x, y fpValue) int {
+	if x.class == fpInf || y.class == fpInf {
+		return compareInf(x, y)
+	}
+	return x.value().Cmp(y.value())
+}
+
+// compareInf compares two non-NaN values where at least one is infinite.
+func compareInf(x, y fpValue) int {
+	rank := func(v fpValue) int {
+		switch {
+		case v.class != fpInf:
+			return 0
+		case v.sign:
+			return -1
+		default:
+			return 1
+		}
+	}
+	rx, ry := rank(x), rank(y)
+	switch {
+	case rx < ry:
+		return -1
+	case rx > ry:
+		return 1
+	}
+	return 0
+}
+
+// FCVT converts Vn from precision src to precision dst.
+func (u *FPU) FCVT(src, dst FPType, vd, vn uint8) {
+	u.Write(dst, vd, u.convert(formatOf(src), formatOf(dst), u.Read(src, vn)))
+}
+
+func (u *FPU) convert(from, to fpFormat, bits uint64) uint64 {
+	x := u.unpack(from, bits)
+
+	switch x.class {
+	case fpQNaN, fpSNaN:
+		if x.class == fpSNaN {
+			u.raise(FPSRIOC)
+		}
+		if u.simdRegFile.FPCR&FPCRDN != 0 {
+			return to.defaultNaN()
+		}
+		// Keep the sign and the most significant fraction bits.
+		frac := bits & from.fracMask()
+		if to.fracBits > from.fracBits {
+			frac <<= to.fracBits - from.fracBits
+		} else {
+			frac >>= from.fracBits - to.fracBits
+		}
+		return to.inf(x.sign) | frac | to.quietBit()
+	case fpInf:
+		return to.inf(x.sign)
+	case fpZero:
+		return to.zero(x.sign)
+	}
+	return u.round(to, x.sign, x.mag)
+}
+
+// maxMin implements FPMax and FPMin on encodings. With number set it
+// implements FPMaxNum and FPMinNum, where a quiet NaN loses to a number.
+func (u *FPU) maxMin(t FPType, a, b uint64, isMax, number bool) uint64 {
+	f := formatOf(t)
+	x, y := u.unpack(f, a), u.unpack(f, b)
+	if number {
+		loser := fpValue{class: fpInf, sign: isMax}
+		switch {
+		case x.class == fpQNaN && y.class != fpQNaN:
+			x = loser
+		case y.class == fpQNaN && x.class != fpQNaN:
+			y = loser
+		}
+	}
+	if x.isNaN() || y.isNaN() {
+		return u.processNaNs(f, x, y)
+	}
+
+	r := y
+	switch c := compareValues(x, y); {
+	case x.class == fpZero && y.class == fpZero:
+		// +0 is larger than -0
+		r.sign = x.sign && y.sign
+		if !isMax {
+			r.sign = x.sign || y.sign
+		}
+	case (c > 0) == isMax && c != 0:
+		r = x
+	}
+
+	switch r.class {
+	case fpInf:
+		return f.inf(r.sign)
+	case fpZero:
+		return f.zero(r.sign)
+	}
+	return u.round(f, r.sign, r.mag)
+}
+
+// roundToIntegral implements FPRoundInt: it rounds an encoding to an
+// integral value in the same precision. exact selects FRINTX, which raises
+// Inexact when the value changes.
+func (u *FPU) roundToIntegral(t FPType, bits uint64, mode FPRounding, exact bool) uint64 {
+	f := formatOf(t)
+	x := u.unpack(f, bits)
+	switch x.class {
+	case fpQNaN, fpSNaN:
+		return u.processNaN(f, x)
+	case fpInf:
+		return f.inf(x.sign)
+	case fpZero:
+		return f.zero(x.sign)
+	}
+
+	n, inexact := roundInteger(x.mag, x.sign, mode)
+	if inexact && exact {
+		u.raise(FPSRIXC)
+	}
+	if n.Sign() == 0 {
+		return f.zero(x.sign)
+	}
+	return u.round(f, x.sign, new(big.Float).SetInt(n))
+}
+
+// recipEstimate implements FPRecipEstimate (FRECPE): an estimate of 1/x
+// accurate to 8 bits, computed as the architecture specifies so results
+// match hardware bit for bit.
+func (u *FPU) recipEstimate(t FPType, bits uint64) uint64 {
+	f := formatOf(t)
+	x := u.unpack(f, bits)
+	switch x.class {
+	case fpQNaN, fpSNaN:
+		return u.processNaN(f, x)
+	case fpInf:
+		return f.zero(x.sign)
+	case fpZero:
+		u.raise(FPSRDZC)
+		return f.inf(x.sign)
+	}
+
+	// mag = m * 2^e with 0.5 <= m < 1
+	switch e := x.mag.MantExp(nil); {
+	case e <= -f.bias()-1: // The reciprocal overflows
+		u.raise(FPSROFC | FPSRIXC)
+		switch mode := u.rounding(); {
+		case mode == FPRoundZero,
+			mode == FPRoundPlusInf && x.sign,
+			mode == FPRoundMinusInf && !x.sign:
+			return f.maxNormal(x.sign)
+		}
+		return f.inf(x.sign)
+	case e >= f.bias() && u.flushToZero(f): // The reciprocal is denormal
+		u.raise(FPSRUFC)
+		return f.zero(x.sign)
+	}
+
+	// Scale the fraction, widened to 52 bits, to 0.5 <= scaled/512 < 1.
+	exp := int((bits >> f.fracBits) & f.maxExp())
+	frac := (bits & f.fracMask()) << (52 - f.fracBits)
+	if exp == 0 {
+		if frac>>51 == 0 {
+			exp = -1
+			frac <<= 1
+		}
+		frac = frac << 1 & (1<<52 - 1)
+	}
+	resultExp := 2*f.bias() - 1 - exp
+
+	frac = (recipEstimateFixed(1<<8|frac>>44) & 0xFF) << 44
+	switch resultExp {
+	case 0:
+		frac = 1<<51 | frac>>1
+	case -1:
+		frac = 1<<50 | frac>>2
+		resultExp = 0
+	}
+	return f.zero(x.sign) | uint64(resultExp)<<f.fracBits | frac>>(52-f.fracBits)
+}
+
+// recipEstimateFixed returns the reciprocal of a/512, for 256 <= a < 512,
+// in units of 1/256 rounded to nearest.
+func recipEstimateFixed(a uint64) uint64 {
+	b := (1 << 19) / (2*a + 1)
+	return (b + 1) / 2
+}
+
+// recipSqrtEstimate implements FPRSqrtEstimate (FRSQRTE): an estimate of
+// 1/sqrt(x) accurate to 8 bits, computed as the architecture specifies.
+func (u *FPU) recipSqrtEstimate(t FPType, bits uint64) uint64 {
+	f := formatOf(t)
+	x := u.unpack(f, bits)
+	switch {
+	case x.isNaN():
+		return u.processNaN(f, x)
+	case x.class == fpZero:
+		u.raise(FPSRDZC)
+		return f.inf(x.sign)
+	case x.sign:
+		u.raise(FPSRIOC)
+		return f.defaultNaN()
+	case x.class == fpInf:
+		return f.zero(false)
+	}
+
+	// Scale the fraction, widened to 52 bits, to 0.25 <= scaled/512 < 1,
+	// keeping the parity of the exponent.
+	exp := int((bits >> f.fracBits) & f.maxExp())
+	frac := (bits & f.fracMask()) << (52 - f.fracBits)
+	if exp == 0 {
+		for frac>>51 == 0 {
+			frac <<= 1
+			exp--
+		}
+		frac = frac << 1 & (1<<52 - 1)
+	}
+	scaled := 1<<8 | frac>>44
+	if exp&1 == 1 {
+		scaled = 1<<7 | frac>>45
+	}
+	resultExp := (3*f.bias() - 1 - exp) / 2
+
+	est := recipSqrtEstimateFixed(scaled) & 0xFF
+	return uint64(resultExp)<<f.fracBits | est<<(f.fracBits-8)
+}
+
+// recipSqrtEstimateFixed returns the reciprocal square root of a/512, for
+// 128 <= a < 512, in units of 1/256 rounded to nearest.
+func recipSqrtEstimateFixed(a uint64) uint64 {
+	if a < 256 {
+		a = 2*a + 1 // In units of 1/512
+	} else {
+		a = (a>>1<<1 + 1) * 2 // In units of 1/256, bottom bit discarded
+	}
+	b := uint64(512)
+	for a*(b+1)*(b+1) < 1<<28 {
+		b++
+	}
+	return (b + 1) / 2
+}
+
+// recipStep implements FPRecipStepFused (FRECPS), the Newton-Raphson step
+// 2 - a*b with a single rounding. halve selects FPRSqrtStepFused
+// (FRSQRTS), which computes (3 - a*b)/2.
+func (u *FPU) recipStep(t FPType, a, b uint64, halve bool) uint64 {
+	f := formatOf(t)
+	x, y := u.unpack(f, a^f.signBit()), u.unpack(f, b)
+	if x.isNaN() || y.isNaN() {
+		return u.processNaNs(f, x, y)
+	}
+
+	// Infinity times zero counts as zero, giving 2 or 1.5.
+	infTimesZero := (x.class == fpInf && y.class == fpZero) ||
+		(x.class == fpZero && y.class == fpInf)
+	if !infTimesZero && (x.class == fpInf || y.class == fpInf) {
+		return f.inf(x.sign != y.sign)
+	}
+
+	sum := new(big.Float).SetPrec(4096).SetInt64(2)
+	if halve {
+		sum.SetInt64(3)
+	}
+	if x.class == fpFinite && y.class == fpFinite {
+		product := new(big.Float).SetPrec(256).Mul(x.mag, y.mag)
+		if x.sign != y.sign {
+			product.Neg(product)
+		}
+		sum.Add(sum, product)
+	}
+	if halve {
+		sum.SetMantExp(sum, -1)
+	}
+	if sum.Sign() == 0 {
+		return f.zero(u.rounding() == FPRoundMinusInf)
+	}
+	return u.round(f, sum.Sign() < 0, sum.Abs(sum))
+}
+
+// IntToFP converts a general register value to floating point.
+// fbits is the number of fraction bits of a fixed-point source.
+func (u *FPU) IntToFP(t FPType, vd uint8, value uint64, is64Bit, signed bool, fbits uint) {
+	u.Write(t, vd, u.fromInt(t, value, intSize(is64Bit), signed, fbits))
+}
+
+// intSize returns the width in bits of a W or X register.
+func intSize(is64Bit bool) uint {
+	if is64Bit {
+		return 64
+	}
+	return 32
+}
+
+// fromInt converts the low size bits of value, an integer or a fixed-point
+// value with fbits fraction bits, to an encoding of precision t.
+func (u *FPU) fromInt(t FPType, value uint64, size uint, signed bool, fbits uint) uint64 {
+	f := formatOf(t)
+
+	mag := value & intMask(size)
+	neg := signed && mag>>(size-1) == 1
+	if neg {
+		mag = -mag & intMask(size)
+	}
+
+	// Values that fit the significand convert exactly.
+	if mag <= 1<<(f.fracBits+1) && t != FPHalf {
+		r := float64(mag)
+		if neg {
+			r = -r
+		}
+		r = math.Ldexp(r, -int(fbits))
+		if t == FPSingle {
+			return uint64(math.Float32bits(float32(r)))
+		}
+		return math.Float64bits(r)
+	}
+
+	if mag == 0 {
+		return f.zero(false)
+	}
+	m := new(big.Float).SetPrec(64).SetUint64(mag)
+	return u.round(f, neg, m.SetMantExp(m, -int(fbits)))
+}
+
+// intMask returns a mask of the low size bits.
+func intMask(size uint) uint64 {
+	if size == 64 {
+		return math.MaxUint64
+	}
+	return uint64(1)<<size - 1
+}
+
+// FPToInt converts Vn to an integer (or fixed-point value with fbits
+// fraction bits) using the given rounding. Out-of-range values and NaNs
+// saturate and raise Invalid Operation.
+func (u *FPU) FPToInt(t FPType, vn uint8, is64Bit, signed bool, fbits uint, mode FPRounding) uint64 {
+	return u.toInt(t, u.Read(t, vn), intSize(is64Bit), signed, fbits, mode)
+}
+
+// toInt converts the encoding bits to a size-bit integer, as FPToInt does.
+func (u *FPU) toInt(t FPType, bits uint64, size uint, signed bool, fbits uint, mode FPRounding) uint64 {
+	f := formatOf(t)
+
+	// Truncating conversions of in-range doubles and singles are native.
+	if mode == FPRoundZero && t != FPHalf && !u.flushToZero(f) {
+		x := math.Float64frombits(bits)
+		if t == FPSingle {
+			x = float64(math.Float32frombits(uint32(bits)))
+		}
+		if v, ok := fastToInt(x, size, signed, fbits); ok {
+			if math.Trunc(math.Ldexp(x, int(fbits))) != math.Ldexp(x, int(fbits)) {
+				u.raise(FPSRIXC)
+			}
+			return v
+		}
+	}
+
+	x := u.unpack(f, bits)
+	if x.isNaN() {
+		u.raise(FPSRIOC)
+		return 0
+	}
+
+	var n *big.Int
+	inexact := false
+	if x.class == fpInf {
+		n = new(big.Int).Lsh(big.NewInt(1), size+1) // Out of range
+	} else if x.class == fpFinite {
+		scaled := new(big.Float).SetMantExp(x.mag, int(fbits))
+		n, inexact = roundInteger(scaled, x.sign, mode)
+	} else {
+		n = new(big.Int)
+	}
+	if x.sign {
+		n.Neg(n)
+	}
+
+	var lo, hi *big.Int
+	if signed {
+		lo = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), size-1))
+		hi = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), size-1), big.NewInt(1))
+	} else {
+		lo = new(big.Int)
+		hi = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), size), big.NewInt(1))
+	}
+
+	switch {
+	case n.Cmp(lo) < 0:
+		u.raise(FPSRIOC)
+		n = lo
+	case n.Cmp(hi) > 0:
+		u.raise(FPSRIOC)
+		n = hi
+	case inexact:
+		u.raise(FPSRIXC)
+	}
+
+	if n.Sign() < 0 {
+		return uint64(n.Int64()) & intMask(size)
+	}
+	return n.Uint64() & intMask(size)
+}
+
+// fastToInt truncates x*2^fbits to an integer when the result is in range.
+func fastToInt(x float64, size uint, signed bool, fbits uint) (uint64, bool) {
+	x = math.Trunc(math.Ldexp(x, int(fbits)))
+	limit := math.Ldexp(1, int(size))
+	switch {
+	case math.IsNaN(x):
+		return 0, false
+	case signed && x >= -limit/2 && x < limit/2:
+		return uint64(int64(x)) & intMask(size), true
+	case !signed && x >= 0 && x < limit:
+		return uint64(x), true
+	}
+	return 0, false
+}
+
+// unpack classifies an encoding and computes its exact magnitude. With
+// flush-to-zero enabled, denormal inputs read as zero and raise Input
+// Denormal.
+func (u *FPU) unpack(f fpFormat, bits uint64) fpValue {
+	v := fpValue{sign: bits&f.signBit() != 0, bits: bits}
+	exp := (bits >> f.fracBits) & f.maxExp()
+	frac := bits & f.fracMask()
+
+	switch {
+	case exp == f.maxExp() && frac == 0:
+		v.class = fpInf
+	case exp == f.maxExp() && frac&f.quietBit() != 0:
+		v.class = fpQNaN
+	case exp == f.maxExp():
+		v.class = fpSNaN
+	case exp == 0 && frac == 0:
+		v.class = fpZero
+	case exp == 0 && u.flushToZero(f):
+		u.raise(FPSRIDC)
+		v.class = fpZero
+	default:
+		v.class = fpFinite
+		mant := frac
+		e := int(exp) - f.bias() - int(f.fracBits)
+		if exp == 0 {
+			e++ // Denormals share the minimum exponent
+		} else {
+			mant |= 1 << f.fracBits
+		}
+		m := new(big.Float).SetPrec(64).SetUint64(mant)
+		v.mag = m.SetMantExp(m, e)
+	}
+
+	return v
+}
+
+// processNaN returns the result of an operation with a single NaN operand.
+func (u *FPU) processNaN(f fpFormat, v fpValue) uint64 {
+	if v.class == fpSNaN {
+		u.raise(FPSRIOC)
+	}
+	if u.simdRegFile.FPCR&FPCRDN != 0 {
+		return f.defaultNaN()
+	}
+	return v.bits | f.quietBit()
+}
+
+// processNaNs picks the NaN result of an operation: the first signaling NaN,
+// otherwise the first quiet NaN, in operand order.
+func (u *FPU) processNaNs(f fpFormat, ops ...fpValue) uint64 {
+	for _, v := range ops {
+		if v.class == fpSNaN {
+			return u.processNaN(f, v)
+		}
+	}
+	for _, v := range ops {
+		if v.class == fpQNaN {
+			return u.processNaN(f, v)
+		}
+	}
+	return f.defaultNaN()
+}
+
+// round rounds sign*mag (finite, non-zero) to format f using the FPCR
+// rounding mode, raising Inexact, Underflow and Overflow as required.
+// Tininess is detected before rounding.
+func (u *FPU) round(f fpFormat, sign bool, mag *big.Float) uint64 {
+	mode := u.rounding()
+	p := int(f.fracBits)
+	emin := 1 - f.bias()
+
+	// mag = m * 2^exp with 0.5 <= m < 1, so the unbiased exponent is exp-1.
+	e := mag.MantExp(nil) - 1
+	tiny := e < emin
+	if tiny && u.flushToZero(f) {
+		u.raise(FPSRUFC)
+		return f.zero(sign)
+	}
+
+	// Scale so that the last significand bit has weight 1.
+	lsb := max(e, emin) - p
+	scaled := new(big.Float).SetMantExp(mag, -lsb)
+	n, inexact := roundInteger(scaled, sign, mode)
+
+	if inexact {
+		u.raise(FPSRIXC)
+		if tiny {
+			u.raise(FPSRUFC)
+		}
+	}
+
+	significand := n.Uint64()
+	if significand == 1<<(p+1) { // Rounding carried out of the significand
+		significand >>= 1
+		lsb++
+	}
+
+	if significand < 1<<p {
+		return f.zero(sign) | significand // Denormal
+	}
+
+	biased := uint64(lsb + p + f.bias())
+	if biased >= f.maxExp() {
+		u.raise(FPSROFC | FPSRIXC)
+		switch {
+		case mode == FPRoundZero,
+			mode == FPRoundPlusInf && sign,
+			mode == FPRoundMinusInf && !sign:
+			return f.maxNormal(sign)
+		}
+		return f.inf(sign)
+	}
+
+	return f.zero(sign) | biased<<f.fracBits | (significand & f.fracMask())
+}
+
+// roundInteger rounds a non-negative magnitude to an integer. sign is the
+// sign of the value the magnitude belongs to, needed for directed rounding.
+func roundInteger(mag *big.Float, sign bool, mode FPRounding) (*big.Int, bool) {
+	n, _ := mag.Int(nil) // Truncates toward zero
+	frac := new(big.Float).Sub(mag, new(big.Float).SetInt(n))
+	if frac.Sign() == 0 {
+		return n, false
+	}
+
+	half := frac.Cmp(big.NewFloat(0.5))
+	var up bool
+	switch mode {
+	case FPRoundNearest:
+		up = half > 0 || (half == 0 && n.Bit(0) == 1)
+	case FPRoundNearestAway:
+		up = half >= 0
+	case FPRoundPlusInf:
+		up = !sign
+	case FPRoundMinusInf:
+		up = sign
+	case FPRoundZero:
+		up = false
+	}
+	if up {
+		n.Add(n, big.NewInt(1))
+	}
+
+	return n, true
+}
+
+// withSticky returns a value that rounds like the exact result, given q, a
+// truncation of it to q.Prec() bits, and whether the truncation was inexact.
+// The result lies strictly between q and the next value at that precision.
+func withSticky(q *big.Float, inexact bool) *big.Float {
+	if !inexact {
+		return q
+	}
+	prec := q.Prec()
+	r := new(big.Float).SetPrec(prec + 1).Set(q)
+	halfUlp := new(big.Float).SetMantExp(big.NewFloat(1), q.MantExp(nil)-int(prec)-1)
+	return r.Add(r, halfUlp)
+}
+
+// sqrtSticky computes sqrt(x) truncated to prec bits with a sticky bit, as
+// withSticky does.
+func sqrtSticky(x *big.Float, prec uint) *big.Float {
+	s := new(big.Float).SetPrec(prec).SetMode(big.ToZero).Sqrt(x)
+
+	ulp := func(v *big.Float) *big.Float {
+		return new(big.Float).SetMantExp(big.NewFloat(1), v.MantExp(nil)-int(prec))
+	}
+	square := func(v *big.Float) *big.Float {
+		return new(big.Float).SetPrec(2*prec+2).Mul(v, v)
+	}
+
+	// big.Float.Sqrt does not promise a correctly truncated result; step
+	// to the largest s with s*s <= x.
+	for square(s).Cmp(x) > 0 {
+		s.Sub(s, ulp(s))
+	}
+	for {
+		next := new(big.Float).SetPrec(prec).Add(s, ulp(s))
+		if square(next).Cmp(x) > 0 {
+			break
+		}
+		s = next
+	}
+
+	return withSticky(s, square(s).Cmp(x) != 0)
+}
@@ -0,0 +1,620 @@
+package emu_test
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("FPU", func() {
+	var (
+		simdRegFile *emu.SIMDRegFile
+		regFile     *emu.RegFile
+		fpu         *emu.FPU
+	)
+
+	BeforeEach(func() {
+		simdRegFile = emu.NewSIMDRegFile()
+		regFile = &emu.RegFile{}
+		fpu = emu.NewFPU(simdRegFile, regFile)
+	})
+
+	setD := func(reg uint8, v float64) {
+		simdRegFile.WriteD(reg, math.Float64bits(v))
+	}
+	getD := func(reg uint8) float64 {
+		return math.Float64frombits(simdRegFile.ReadD(reg))
+	}
+	setS := func(reg uint8, v float32) {
+		simdRegFile.WriteS(reg, math.Float32bits(v))
+	}
+	getS := func(reg uint8) float32 {
+		return math.Float32frombits(simdRegFile.ReadS(reg))
+	}
+	setRMode := func(mode emu.FPRounding) {
+		simdRegFile.FPCR = uint64(mode) << emu.FPCRRModeShift
+	}
+
+	Describe("Arithmetic", func() {
+		It("should add, subtract, multiply and divide doubles", func() {
+			setD(1, 1.5)
+			setD(2, 0.25)
+
+			fpu.FADD(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(1.75))
+			fpu.FSUB(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(1.25))
+			fpu.FMUL(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(0.375))
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(6.0))
+			Expect(simdRegFile.FPSR).To(BeZero())
+		})
+
+		It("should zero the upper bits of the vector register", func() {
+			simdRegFile.WriteQ(0, math.MaxUint64, math.MaxUint64)
+			setS(1, 2)
+			setS(2, 3)
+
+			fpu.FMUL(emu.FPSingle, 0, 1, 2)
+
+			low, high := simdRegFile.ReadQ(0)
+			Expect(low).To(Equal(uint64(math.Float32bits(6))))
+			Expect(high).To(BeZero())
+		})
+
+		It("should compute square roots", func() {
+			setD(1, 2)
+			fpu.FSQRT(emu.FPDouble, 0, 1)
+			Expect(getD(0)).To(Equal(math.Sqrt(2)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC))
+
+			setS(1, 16)
+			fpu.FSQRT(emu.FPSingle, 0, 1)
+			Expect(getS(0)).To(Equal(float32(4)))
+		})
+
+		It("should negate the rounded product for FNMUL", func() {
+			setD(1, 2)
+			setD(2, -3)
+			fpu.FNMUL(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(6.0))
+
+			setD(2, 0)
+			fpu.FNMUL(emu.FPDouble, 0, 1, 2)
+			Expect(math.Signbit(getD(0))).To(BeTrue())
+		})
+
+		It("should take maxima and minima, with NaNs losing to numbers for FMAXNM and FMINNM", func() {
+			setD(1, -1)
+			setD(2, 2)
+			fpu.FMAX(emu.FPDouble, 0, 1, 2, false)
+			Expect(getD(0)).To(Equal(2.0))
+			fpu.FMIN(emu.FPDouble, 0, 1, 2, false)
+			Expect(getD(0)).To(Equal(-1.0))
+
+			setD(2, math.NaN())
+			fpu.FMAX(emu.FPDouble, 0, 1, 2, false)
+			Expect(math.IsNaN(getD(0))).To(BeTrue())
+			fpu.FMAX(emu.FPDouble, 0, 1, 2, true)
+			Expect(getD(0)).To(Equal(-1.0))
+			fpu.FMIN(emu.FPDouble, 0, 1, 2, true)
+			Expect(getD(0)).To(Equal(-1.0))
+
+			setD(1, 0)
+			setD(2, math.Copysign(0, -1))
+			fpu.FMIN(emu.FPDouble, 0, 1, 2, false)
+			Expect(math.Signbit(getD(0))).To(BeTrue())
+			fpu.FMAX(emu.FPDouble, 0, 1, 2, false)
+			Expect(math.Signbit(getD(0))).To(BeFalse())
+		})
+
+		It("should round to integral values in each mode", func() {
+			for mode, want := range map[emu.FPRounding][2]float64{
+				emu.FPRoundNearest:     {-2, 2},
+				emu.FPRoundNearestAway: {-3, 3},
+				emu.FPRoundPlusInf:     {-2, 3},
+				emu.FPRoundMinusInf:    {-3, 2},
+				emu.FPRoundZero:        {-2, 2},
+			} {
+				setD(1, -2.5)
+				fpu.FRINT(emu.FPDouble, 0, 1, mode, false)
+				Expect(getD(0)).To(Equal(want[0]), "mode %d", mode)
+				setD(1, 2.5)
+				fpu.FRINT(emu.FPDouble, 0, 1, mode, false)
+				Expect(getD(0)).To(Equal(want[1]), "mode %d", mode)
+			}
+			Expect(simdRegFile.FPSR).To(BeZero())
+
+			setS(1, 1.25)
+			fpu.FRINT(emu.FPSingle, 0, 1, emu.FPRoundNearest, true)
+			Expect(getS(0)).To(Equal(float32(1)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC))
+		})
+
+		It("should operate on half precision", func() {
+			simdRegFile.WriteH(1, 0x3C00) // 1.0
+			simdRegFile.WriteH(2, 0x4000) // 2.0
+
+			fpu.FADD(emu.FPHalf, 0, 1, 2)
+
+			Expect(simdRegFile.ReadH(0)).To(Equal(uint16(0x4200))) // 3.0
+		})
+
+		It("should fuse multiply-add with a single rounding", func() {
+			// a*b - c where a*b is not representable exactly
+			a := 1 + math.Ldexp(1, -30)
+			setD(1, a)
+			setD(2, a)
+			setD(3, -1-math.Ldexp(1, -29))
+
+			fpu.FMADD(emu.FPDouble, 0, 1, 2, 3, false, false)
+
+			Expect(getD(0)).To(Equal(math.Ldexp(1, -60)))
+		})
+
+		It("should negate product and addend for FMSUB, FNMADD and FNMSUB", func() {
+			setD(1, 2)
+			setD(2, 3)
+			setD(3, 10)
+
+			fpu.FMADD(emu.FPDouble, 0, 1, 2, 3, true, false) // FMSUB
+			Expect(getD(0)).To(Equal(4.0))
+			fpu.FMADD(emu.FPDouble, 0, 1, 2, 3, true, true) // FNMADD
+			Expect(getD(0)).To(Equal(-16.0))
+			fpu.FMADD(emu.FPDouble, 0, 1, 2, 3, false, true) // FNMSUB
+			Expect(getD(0)).To(Equal(-4.0))
+		})
+
+		It("should match native round-to-nearest results", func() {
+			rng := rand.New(rand.NewSource(1))
+			randomDouble := func() float64 {
+				return math.Float64frombits(rng.Uint64())
+			}
+
+			for i := 0; i < 2000; i++ {
+				x, y, z := randomDouble(), randomDouble(), randomDouble()
+				if math.IsNaN(x) || math.IsNaN(y) || math.IsNaN(z) {
+					continue
+				}
+				setD(1, x)
+				setD(2, y)
+				setD(3, z)
+
+				simdRegFile.FPSR = 0
+				fpu.FADD(emu.FPDouble, 0, 1, 2)
+				Expect(getD(0)).To(Equal(x + y))
+
+				simdRegFile.FPSR = 0
+				fpu.FMUL(emu.FPDouble, 0, 1, 2)
+				Expect(getD(0)).To(Equal(x * y))
+
+				simdRegFile.FPSR = 0
+				fpu.FDIV(emu.FPDouble, 0, 1, 2)
+				Expect(getD(0)).To(Equal(x / y))
+
+				simdRegFile.FPSR = 0
+				fpu.FSQRT(emu.FPDouble, 0, 1)
+				if x >= 0 {
+					Expect(getD(0)).To(Equal(math.Sqrt(x)))
+				}
+
+				simdRegFile.FPSR = 0
+				fpu.FMADD(emu.FPDouble, 0, 1, 2, 3, false, false)
+				Expect(getD(0)).To(Equal(math.FMA(x, y, z)))
+
+				xs, ys := float32(x/math.MaxFloat64), float32(y/math.MaxFloat64)
+				if ys == 0 {
+					continue
+				}
+				setS(1, xs)
+				setS(2, ys)
+				simdRegFile.FPSR = 0
+				fpu.FDIV(emu.FPSingle, 0, 1, 2)
+				Expect(getS(0)).To(Equal(xs / ys))
+			}
+		})
+	})
+
+	Describe("Rounding modes", func() {
+		It("should round 1/3 according to FPCR.RMode", func() {
+			setD(1, 1)
+			setD(2, 3)
+			nearest := 1.0 / 3.0
+
+			setRMode(emu.FPRoundZero)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(nearest))
+
+			setRMode(emu.FPRoundPlusInf)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(math.Nextafter(nearest, 1)))
+
+			setRMode(emu.FPRoundMinusInf)
+			setD(1, -1)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(math.Nextafter(-nearest, -1)))
+		})
+
+		It("should round addition toward zero", func() {
+			setRMode(emu.FPRoundZero)
+			setD(1, 1)
+			setD(2, math.Ldexp(1, -53)*1.5) // more than half an ulp of 1.0
+
+			fpu.FADD(emu.FPDouble, 0, 1, 2)
+
+			Expect(getD(0)).To(Equal(1.0))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC))
+		})
+
+		It("should produce -0 for an exact zero sum when rounding toward -infinity", func() {
+			setRMode(emu.FPRoundMinusInf)
+			setD(1, 1)
+			setD(2, -1)
+
+			fpu.FADD(emu.FPDouble, 0, 1, 2)
+
+			Expect(simdRegFile.ReadD(0)).To(Equal(uint64(1) << 63))
+		})
+
+		It("should saturate overflow to the largest finite value toward zero", func() {
+			setRMode(emu.FPRoundZero)
+			setD(1, math.MaxFloat64)
+			setD(2, 2)
+
+			fpu.FMUL(emu.FPDouble, 0, 1, 2)
+
+			Expect(getD(0)).To(Equal(math.MaxFloat64))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSROFC | emu.FPSRIXC))
+		})
+	})
+
+	Describe("Exception flags", func() {
+		It("should signal overflow to infinity", func() {
+			setD(1, math.MaxFloat64)
+			setD(2, 2)
+
+			fpu.FMUL(emu.FPDouble, 0, 1, 2)
+
+			Expect(math.IsInf(getD(0), 1)).To(BeTrue())
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSROFC | emu.FPSRIXC))
+		})
+
+		It("should signal underflow only for inexact tiny results", func() {
+			setD(1, math.SmallestNonzeroFloat64*4)
+			setD(2, 2)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(Equal(math.SmallestNonzeroFloat64 * 2))
+			Expect(simdRegFile.FPSR).To(BeZero())
+
+			setD(1, math.SmallestNonzeroFloat64)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			Expect(getD(0)).To(BeZero())
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRUFC | emu.FPSRIXC))
+		})
+
+		It("should signal divide by zero", func() {
+			setD(1, -1)
+			setD(2, 0)
+
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+
+			Expect(math.IsInf(getD(0), -1)).To(BeTrue())
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRDZC))
+		})
+
+		It("should return the default NaN for invalid operations", func() {
+			setD(1, math.Inf(1))
+			setD(2, math.Inf(1))
+
+			fpu.FSUB(emu.FPDouble, 0, 1, 2)
+
+			Expect(simdRegFile.ReadD(0)).To(Equal(uint64(0x7FF8000000000000)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIOC))
+		})
+
+		It("should quiet signaling NaNs and signal invalid", func() {
+			simdRegFile.WriteS(1, 0x7F800001) // sNaN
+			simdRegFile.WriteS(2, 0x7FC00002) // qNaN
+
+			fpu.FADD(emu.FPSingle, 0, 2, 1)
+
+			Expect(simdRegFile.ReadS(0)).To(Equal(uint32(0x7FC00001)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIOC))
+		})
+
+		It("should propagate the first quiet NaN without flags", func() {
+			simdRegFile.WriteD(1, 0xFFF8000000000123)
+			setD(2, 1)
+
+			fpu.FMUL(emu.FPDouble, 0, 2, 1)
+
+			Expect(simdRegFile.ReadD(0)).To(Equal(uint64(0xFFF8000000000123)))
+			Expect(simdRegFile.FPSR).To(BeZero())
+		})
+
+		It("should use the default NaN when FPCR.DN is set", func() {
+			simdRegFile.FPCR = emu.FPCRDN
+			simdRegFile.WriteD(1, 0xFFF8000000000123)
+			setD(2, 1)
+
+			fpu.FADD(emu.FPDouble, 0, 1, 2)
+
+			Expect(simdRegFile.ReadD(0)).To(Equal(uint64(0x7FF8000000000000)))
+		})
+
+		It("should flush denormals to zero when FPCR.FZ is set", func() {
+			simdRegFile.FPCR = emu.FPCRFZ
+			setD(1, math.SmallestNonzeroFloat64)
+			setD(2, 1)
+
+			fpu.FMUL(emu.FPDouble, 0, 1, 2)
+
+			Expect(getD(0)).To(BeZero())
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIDC))
+		})
+
+		It("should accumulate flags", func() {
+			setD(1, 1)
+			setD(2, 3)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+			setD(2, 0)
+			fpu.FDIV(emu.FPDouble, 0, 1, 2)
+
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC | emu.FPSRDZC))
+		})
+	})
+
+	Describe("FCMP", func() {
+		It("should set NZCV for less, equal, greater and unordered", func() {
+			setD(1, 1)
+			setD(2, 2)
+			fpu.FCMP(emu.FPDouble, 1, 2, false, false)
+			Expect(regFile.PSTATE).To(Equal(emu.PSTATE{N: true}))
+
+			fpu.FCMP(emu.FPDouble, 1, 1, false, false)
+			Expect(regFile.PSTATE).To(Equal(emu.PSTATE{Z: true, C: true}))
+
+			fpu.FCMP(emu.FPDouble, 2, 1, false, false)
+			Expect(regFile.PSTATE).To(Equal(emu.PSTATE{C: true}))
+
+			setD(3, math.NaN())
+			fpu.FCMP(emu.FPDouble, 3, 1, false, false)
+			Expect(regFile.PSTATE).To(Equal(emu.PSTATE{C: true, V: true}))
+			Expect(simdRegFile.FPSR).To(BeZero())
+		})
+
+		It("should compare with zero and treat -0 as equal", func() {
+			setD(1, math.Copysign(0, -1))
+			fpu.FCMP(emu.FPDouble, 1, 0, true, false)
+			Expect(regFile.PSTATE).To(Equal(emu.PSTATE{Z: true, C: true}))
+		})
+
+		It("should signal invalid for quiet NaNs only with FCMPE", func() {
+			setS(1, float32(math.NaN()))
+			setS(2, 1)
+
+			fpu.FCMP(emu.FPSingle, 1, 2, false, false)
+			Expect(simdRegFile.FPSR).To(BeZero())
+
+			fpu.FCMP(emu.FPSingle, 1, 2, false, true)
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIOC))
+		})
+
+		It("should order infinities", func() {
+			setD(1, math.Inf(-1))
+			setD(2, -math.MaxFloat64)
+			fpu.FCMP(emu.FPDouble, 1, 2, false, false)
+			Expect(regFile.PSTATE).To(Equal(emu.PSTATE{N: true}))
+		})
+	})
+
+	Describe("FCVT", func() {
+		It("should narrow double to single with rounding", func() {
+			setD(1, 1.0/3.0)
+
+			fpu.FCVT(emu.FPDouble, emu.FPSingle, 0, 1)
+
+			Expect(getS(0)).To(Equal(float32(1.0 / 3.0)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC))
+		})
+
+		It("should widen single to double exactly", func() {
+			setS(1, 0.1)
+
+			fpu.FCVT(emu.FPSingle, emu.FPDouble, 0, 1)
+
+			Expect(getD(0)).To(Equal(float64(float32(0.1))))
+			Expect(simdRegFile.FPSR).To(BeZero())
+		})
+
+		It("should convert to and from half precision", func() {
+			setD(1, 65520) // rounds up past the largest half (65504)
+			fpu.FCVT(emu.FPDouble, emu.FPHalf, 0, 1)
+			Expect(simdRegFile.ReadH(0)).To(Equal(uint16(0x7C00)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSROFC | emu.FPSRIXC))
+
+			simdRegFile.WriteH(1, 0x0001) // smallest half denormal
+			fpu.FCVT(emu.FPHalf, emu.FPDouble, 0, 1)
+			Expect(getD(0)).To(Equal(math.Ldexp(1, -24)))
+		})
+
+		It("should keep NaN payloads and quiet them", func() {
+			simdRegFile.WriteD(1, 0x7FF4000000000000) // sNaN
+
+			fpu.FCVT(emu.FPDouble, emu.FPSingle, 0, 1)
+
+			Expect(simdRegFile.ReadS(0)).To(Equal(uint32(0x7FE00000)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIOC))
+		})
+	})
+
+	Describe("Integer conversions", func() {
+		It("should convert signed and unsigned integers", func() {
+			fpu.IntToFP(emu.FPDouble, 0, uint64(0xFFFFFFFFFFFFFFFD), true, true, 0)
+			Expect(getD(0)).To(Equal(-3.0))
+
+			fpu.IntToFP(emu.FPDouble, 0, 0xFFFFFFFD, false, false, 0)
+			Expect(getD(0)).To(Equal(4294967293.0))
+
+			fpu.IntToFP(emu.FPSingle, 0, 0xFFFFFFFD, false, true, 0)
+			Expect(getS(0)).To(Equal(float32(-3)))
+		})
+
+		It("should round large integers using FPCR", func() {
+			value := uint64(1)<<53 + 1
+
+			fpu.IntToFP(emu.FPDouble, 0, value, true, true, 0)
+			Expect(getD(0)).To(Equal(math.Ldexp(1, 53)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC))
+
+			setRMode(emu.FPRoundPlusInf)
+			fpu.IntToFP(emu.FPDouble, 0, value, true, true, 0)
+			Expect(getD(0)).To(Equal(math.Ldexp(1, 53) + 2))
+		})
+
+		It("should convert fixed-point values", func() {
+			fpu.IntToFP(emu.FPDouble, 0, 0x180, true, true, 8)
+			Expect(getD(0)).To(Equal(1.5))
+
+			setD(1, 1.75)
+			Expect(fpu.FPToInt(emu.FPDouble, 1, true, true, 4, emu.FPRoundZero)).To(Equal(uint64(28)))
+		})
+
+		It("should round according to the conversion's rounding mode", func() {
+			setD(1, -2.5)
+
+			Expect(int64(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundNearest))).To(Equal(int64(-2)))
+			Expect(int64(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundNearestAway))).To(Equal(int64(-3)))
+			Expect(int64(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundPlusInf))).To(Equal(int64(-2)))
+			Expect(int64(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundMinusInf))).To(Equal(int64(-3)))
+			Expect(int64(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundZero))).To(Equal(int64(-2)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIXC))
+		})
+
+		It("should zero-extend 32-bit signed results", func() {
+			setS(1, -7.9)
+
+			Expect(fpu.FPToInt(emu.FPSingle, 1, false, true, 0, emu.FPRoundZero)).To(Equal(uint64(0xFFFFFFF9)))
+		})
+
+		It("should saturate out-of-range values and NaNs", func() {
+			setD(1, 1e300)
+			Expect(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundZero)).To(Equal(uint64(math.MaxInt64)))
+			Expect(simdRegFile.FPSR).To(Equal(emu.FPSRIOC))
+
+			setD(1, -1)
+			Expect(fpu.FPToInt(emu.FPDouble, 1, false, false, 0, emu.FPRoundZero)).To(BeZero())
+
+			setD(1, math.Inf(-1))
+			Expect(fpu.FPToInt(emu.FPDouble, 1, false, true, 0, emu.FPRoundNearest)).To(Equal(uint64(0x80000000)))
+
+			setD(1, math.NaN())
+			Expect(fpu.FPToInt(emu.FPDouble, 1, true, true, 0, emu.FPRoundZero)).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("Emulator scalar floating-point", func() {
+	var e *emu.Emulator
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+	})
+
+	run := func(words ...uint32) {
+		for _, word := range words {
+			inst := insts.NewDecoder().Decode(word)
+			result := e.Execute(inst)
+			Expect(result.Err).NotTo(HaveOccurred())
+		}
+	}
+
+	It("should compute with doubles and convert the result", func() {
+		e.RegFile().X[1] = 10
+		run(
+			0x9e620020, // scvtf  d0, x1
+			0x1e6e1001, // fmov   d1, #1.0
+			0x1e612802, // fadd   d2, d0, d1
+			0x1e621843, // fdiv   d3, d2, d2
+			0x1f420c44, // fmadd  d4, d2, d2, d3
+			0x9e780080, // fcvtzs x0, d4
+		)
+
+		Expect(e.RegFile().X[0]).To(Equal(uint64(122)))
+	})
+
+	It("should compute floor, trunc, fmax and negated products", func() {
+		e.RegFile().X[1] = 0xC004000000000000 // -2.5
+		run(
+			0x9e670020, // fmov   d0, x1
+			0x1e654001, // frintm d1, d0
+			0x1e65c002, // frintz d2, d0
+			0x1e624823, // fmax   d3, d1, d2
+			0x1e628864, // fnmul  d4, d3, d2
+		)
+
+		Expect(math.Float64frombits(e.SIMDRegFile().ReadD(1))).To(Equal(-3.0))
+		Expect(math.Float64frombits(e.SIMDRegFile().ReadD(2))).To(Equal(-2.0))
+		Expect(math.Float64frombits(e.SIMDRegFile().ReadD(3))).To(Equal(-2.0))
+		Expect(math.Float64frombits(e.SIMDRegFile().ReadD(4))).To(Equal(-4.0))
+	})
+
+	It("should compare and select", func() {
+		run(
+			0x1e6e1001, // fmov  d1, #1.0
+			0x1e601002, // fmov  d2, #2.0
+			0x1e612040, // fcmp  d2, d1
+			0x1e61cc40, // fcsel d0, d2, d1, gt
+		)
+
+		Expect(e.RegFile().PSTATE).To(Equal(emu.PSTATE{C: true}))
+		Expect(math.Float64frombits(e.SIMDRegFile().ReadD(0))).To(Equal(2.0))
+	})
+
+	It("should take NZCV from the immediate when FCCMP's condition fails", func() {
+		run(
+			0x1e6e1001, // fmov  d1, #1.0
+			0x1e612020, // fcmp  d1, d1
+			0x1e611424, // fccmp d1, d1, #4, ne
+		)
+
+		Expect(e.RegFile().PSTATE).To(Equal(emu.PSTATE{Z: true}))
+	})
+
+	It("should move between general and FP registers", func() {
+		e.RegFile().X[1] = 0x4000000000000000
+		e.RegFile().X[2] = 0x1122334455667788
+		run(
+			0x9e670020, // fmov d0, x1
+			0x9eaf0040, // fmov v0.d[1], x2
+			0x9eae0003, // fmov x3, v0.d[1]
+			0x9e660004, // fmov x4, d0
+		)
+
+		Expect(e.RegFile().X[3]).To(Equal(uint64(0x1122334455667788)))
+		Expect(e.RegFile().X[4]).To(Equal(uint64(0x4000000000000000)))
+	})
+
+	It("should read and write FPCR and FPSR", func() {
+		e.RegFile().X[0] = 3 << emu.FPCRRModeShift
+		run(
+			0xd51b4400, // msr fpcr, x0
+			0x1e6e1001, // fmov d1, #1.0
+			0x1e611002, // fmov d2, #3.0
+			0x1e621823, // fdiv d3, d1, d2
+			0xd53b4421, // mrs x1, fpsr
+			0xd53b4402, // mrs x2, fpcr
+		)
+
+		Expect(e.SIMDRegFile().FPCR).To(Equal(uint64(3 << emu.FPCRRModeShift)))
+		Expect(e.RegFile().X[1]).To(Equal(emu.FPSRIXC))
+		Expect(e.RegFile().X[2]).To(Equal(uint64(3 << emu.FPCRRModeShift)))
+		Expect(math.Float64frombits(e.SIMDRegFile().ReadD(3))).To(Equal(1.0 / 3.0))
+	})
+})
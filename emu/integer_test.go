@@ -0,0 +1,214 @@
+package emu_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Integer Instructions", func() {
+	var e *emu.Emulator
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+	})
+
+	Describe("Bit manipulation", func() {
+		It("should reverse bits", func() {
+			e.RegFile().WriteReg(1, 0x1)
+			runAsm(e, "rbit x0, x1; rbit w2, w1")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0x8000000000000000)))
+			Expect(e.RegFile().ReadReg(2)).To(Equal(uint64(0x80000000)))
+		})
+
+		It("should reverse bytes", func() {
+			e.RegFile().WriteReg(1, 0x0102030405060708)
+			runAsm(e, "rev x0, x1; rev w2, w1; rev16 x3, x1; rev32 x4, x1; rev16 w5, w1")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0x0807060504030201)))
+			Expect(e.RegFile().ReadReg(2)).To(Equal(uint64(0x08070605)))
+			Expect(e.RegFile().ReadReg(3)).To(Equal(uint64(0x0201040306050807)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(0x0403020108070605)))
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(0x06050807)))
+		})
+
+		It("should count leading zeros", func() {
+			e.RegFile().WriteReg(1, 0x1)
+			e.RegFile().WriteReg(2, 0x00010000)
+			runAsm(e, "clz x0, x1; clz w3, w2; clz x4, xzr")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(63)))
+			Expect(e.RegFile().ReadReg(3)).To(Equal(uint64(15)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(64)))
+		})
+
+		It("should count leading sign bits", func() {
+			e.RegFile().WriteReg(1, 0x1)
+			e.RegFile().WriteReg(2, 0xFFFFFFFFFFFFFFFF)
+			e.RegFile().WriteReg(3, 0xFFFF0000)
+			runAsm(e, "cls x0, x1; cls x4, x2; cls w5, w3; cls x6, xzr")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(62)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(63)))
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(15)))
+			Expect(e.RegFile().ReadReg(6)).To(Equal(uint64(63)))
+		})
+	})
+
+	Describe("Add/subtract with carry", func() {
+		It("should propagate the carry of a 128-bit addition", func() {
+			// x1:x0 = 0x0:0xffffffffffffffff + 0x0:0x1
+			e.RegFile().WriteReg(2, 0xFFFFFFFFFFFFFFFF)
+			e.RegFile().WriteReg(4, 1)
+			runAsm(e, "adds x0, x2, x4; adc x1, xzr, xzr")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(1)))
+		})
+
+		It("should propagate the borrow of a 128-bit subtraction", func() {
+			e.RegFile().WriteReg(2, 0)
+			e.RegFile().WriteReg(3, 5)
+			e.RegFile().WriteReg(4, 1)
+			e.RegFile().WriteReg(5, 2)
+			runAsm(e, "subs x0, x2, x4; sbc x1, x3, x5")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xFFFFFFFFFFFFFFFF)))
+			Expect(e.RegFile().ReadReg(1)).To(Equal(uint64(2)))
+		})
+
+		It("should set flags for ADCS", func() {
+			e.RegFile().WriteReg(1, 0x7FFFFFFF)
+			e.RegFile().PSTATE.C = true
+			runAsm(e, "adcs w0, w1, wzr")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0x80000000)))
+			Expect(e.RegFile().PSTATE.N).To(BeTrue())
+			Expect(e.RegFile().PSTATE.Z).To(BeFalse())
+			Expect(e.RegFile().PSTATE.C).To(BeFalse())
+			Expect(e.RegFile().PSTATE.V).To(BeTrue())
+		})
+
+		It("should set the carry for SBCS without a borrow", func() {
+			e.RegFile().WriteReg(1, 3)
+			e.RegFile().WriteReg(2, 3)
+			e.RegFile().PSTATE.C = true
+			runAsm(e, "sbcs x0, x1, x2")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0)))
+			Expect(e.RegFile().PSTATE.Z).To(BeTrue())
+			Expect(e.RegFile().PSTATE.C).To(BeTrue())
+		})
+
+		It("should negate with carry for NGC", func() {
+			e.RegFile().WriteReg(1, 5)
+			e.RegFile().PSTATE.C = false
+			runAsm(e, "ngc x0, x1")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xFFFFFFFFFFFFFFFA))) // -5 - 1
+		})
+	})
+
+	Describe("Long and high multiply", func() {
+		It("should multiply-add signed words into a doubleword", func() {
+			e.RegFile().WriteReg(1, 0x12345678FFFFFFFE) // w1 = -2
+			e.RegFile().WriteReg(2, 3)
+			e.RegFile().WriteReg(3, 10)
+			runAsm(e, "smaddl x0, w1, w2, x3; smsubl x4, w1, w2, x3; smull x5, w1, w2")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(4)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(16)))
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(0xFFFFFFFFFFFFFFFA)))
+		})
+
+		It("should multiply-add unsigned words into a doubleword", func() {
+			e.RegFile().WriteReg(1, 0xFFFFFFFF)
+			e.RegFile().WriteReg(2, 2)
+			e.RegFile().WriteReg(3, 1)
+			runAsm(e, "umaddl x0, w1, w2, x3; umsubl x4, w1, w2, x3; umull x5, w1, w2")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0x1FFFFFFFF)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(0xFFFFFFFE00000003)))
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(0x1FFFFFFFE)))
+		})
+
+		It("should return the high half of 128-bit products", func() {
+			e.RegFile().WriteReg(1, 0xFFFFFFFFFFFFFFFF)
+			e.RegFile().WriteReg(2, 0xC000000000000000) // -(1 << 62)
+			e.RegFile().WriteReg(3, 4)
+			runAsm(e, "umulh x0, x1, x1; smulh x4, x1, x3; smulh x5, x2, x3; umulh x6, x2, x3")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xFFFFFFFFFFFFFFFE)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(0xFFFFFFFFFFFFFFFF))) // -4 >> 64
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(0xFFFFFFFFFFFFFFFF))) // -(1 << 64) >> 64
+			Expect(e.RegFile().ReadReg(6)).To(Equal(uint64(3)))
+		})
+	})
+
+	Describe("Add/subtract (extended register)", func() {
+		It("should add a zero-extended, shifted word to SP", func() {
+			e.RegFile().SP = 0x8000
+			e.RegFile().WriteReg(1, 0xFFFFFFFF00000004)
+			runAsm(e, "add x0, sp, w1, uxtw #2")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0x8010)))
+		})
+
+		It("should write SP when Rd is 31 and flags are not set", func() {
+			e.RegFile().SP = 0x8000
+			e.RegFile().WriteReg(2, 0x100)
+			runAsm(e, "sub sp, sp, x2")
+
+			Expect(e.RegFile().SP).To(Equal(uint64(0x7F00)))
+		})
+
+		It("should sign-extend bytes and halfwords", func() {
+			e.RegFile().WriteReg(1, 1000)
+			e.RegFile().WriteReg(2, 0x180) // low byte 0x80 = -128
+			e.RegFile().WriteReg(3, 0xFFFF)
+			runAsm(e, "add x0, x1, w2, sxtb; sub w4, w1, w3, sxth; add w5, w1, w3, uxth")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(872)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(1001)))
+			Expect(e.RegFile().ReadReg(5)).To(Equal(uint64(1000 + 0xFFFF)))
+		})
+
+		It("should set flags and discard the result for CMP", func() {
+			e.RegFile().SP = 0x8000
+			e.RegFile().WriteReg(1, 0xFFFF)
+			e.RegFile().WriteReg(0, 0x7FFF)
+			runAsm(e, "cmp w0, w1, sxth")
+
+			// 0x7fff - 0xffffffff borrows, but is 0x8000 as signed values.
+			Expect(e.RegFile().PSTATE.N).To(BeFalse())
+			Expect(e.RegFile().PSTATE.Z).To(BeFalse())
+			Expect(e.RegFile().PSTATE.C).To(BeFalse())
+			Expect(e.RegFile().PSTATE.V).To(BeFalse())
+			Expect(e.RegFile().SP).To(Equal(uint64(0x8000)))
+		})
+	})
+
+	Describe("CRC32", func() {
+		// Both checks compute the standard check value of "123456789".
+		It("should compute CRC-32", func() {
+			e.RegFile().WriteReg(1, 0x3837363534333231) // "12345678"
+			e.RegFile().WriteReg(2, '9')
+			runAsm(e, "mov w0, #-1; crc32x w0, w0, x1; crc32b w0, w0, w2; mvn w0, w0")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xCBF43926)))
+		})
+
+		It("should compute CRC-32C", func() {
+			e.RegFile().WriteReg(1, 0x34333231) // "1234"
+			e.RegFile().WriteReg(2, 0x3635)     // "56"
+			e.RegFile().WriteReg(3, 0x3837)     // "78"
+			e.RegFile().WriteReg(4, '9')
+			runAsm(e, "mov w0, #-1; crc32cw w0, w0, w1; crc32ch w0, w0, w2; "+
+				"crc32ch w0, w0, w3; crc32cb w0, w0, w4; mvn w0, w0")
+
+			Expect(e.RegFile().ReadReg(0)).To(Equal(uint64(0xE3069283)))
+		})
+	})
+})
@@ -0,0 +1,372 @@
+package emu
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Manifest lists the files of an in-memory filesystem: it maps guest paths
+// to the host files whose contents they start with.
+type Manifest map[string]string
+
+// LoadManifest reads a manifest file. Each line holds a guest path and a
+// host path, separated by white space; relative host paths are relative to
+// the manifest's directory. Blank lines and lines starting with # are
+// ignored.
+func LoadManifest(name string) (Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	manifest := make(Manifest)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want a guest path and a host path", name, line)
+		}
+		host := fields[1]
+		if !filepath.IsAbs(host) {
+			host = filepath.Join(filepath.Dir(name), host)
+		}
+		manifest[fields[0]] = host
+	}
+	return manifest, scanner.Err()
+}
+
+// memTime is the modification time of in-memory files: the simulated boot
+// time, so that runs are deterministic.
+var memTime = time.Unix(realtimeEpoch, 0)
+
+// memNode is the contents of an in-memory file.
+type memNode struct {
+	data []byte
+	mode fs.FileMode
+}
+
+// MemFS is an in-memory filesystem. Files the program creates or writes
+// live only in memory.
+//
+// A MemFS may overlay a lower filesystem. Reads of files it does not hold
+// go to the lower filesystem, and a file is copied into memory the first
+// time it is opened for writing, so that writes never reach the lower
+// filesystem.
+type MemFS struct {
+	files map[string]*memNode // by absolute guest path
+	lower FileSystem
+}
+
+// NewMemFS creates an in-memory filesystem holding the files of manifest,
+// read from the host now. Its working directory is /.
+func NewMemFS(manifest Manifest) (*MemFS, error) {
+	m := &MemFS{files: make(map[string]*memNode)}
+	for guest, host := range manifest {
+		data, err := os.ReadFile(host)
+		if err != nil {
+			return nil, err
+		}
+		m.files[path.Join("/", guest)] = &memNode{data: data, mode: 0644}
+	}
+	return m, nil
+}
+
+// NewOverlayFS creates an empty in-memory filesystem over lower. It keeps
+// the working directory of lower.
+func NewOverlayFS(lower FileSystem) *MemFS {
+	return &MemFS{files: make(map[string]*memNode), lower: lower}
+}
+
+// ReadFile returns the contents of a file held in memory, which lets the
+// caller check what the program wrote.
+func (m *MemFS) ReadFile(name string) ([]byte, bool) {
+	node, ok := m.files[m.abs(name)]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), node.data...), true
+}
+
+// abs returns the absolute, cleaned form of a guest path.
+func (m *MemFS) abs(name string) string {
+	if path.IsAbs(name) {
+		return path.Clean(name)
+	}
+	wd, _ := m.Getwd()
+	return path.Join(wd, name)
+}
+
+// isDir reports whether the in-memory files include a directory p. The
+// directories are those that contain a file.
+func (m *MemFS) isDir(p string) bool {
+	if p == "/" {
+		return true
+	}
+	for name := range m.files {
+		if strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenFile opens a file with os.OpenFile flags.
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	p := m.abs(name)
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	node, ok := m.files[p]
+	if !ok && m.lower != nil {
+		if !writable && flag&(os.O_CREATE|os.O_TRUNC) == 0 {
+			return m.lower.OpenFile(p, flag, perm)
+		}
+		var err error
+		if node, err = m.copyUp(p, flag); err != nil {
+			return nil, err
+		}
+		ok = node != nil
+	}
+
+	switch {
+	case !ok && m.isDir(p):
+		if writable {
+			return nil, &fs.PathError{Op: "open", Path: p, Err: syscall.EISDIR}
+		}
+		node = &memNode{mode: fs.ModeDir | 0755}
+	case !ok:
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+		}
+		if !m.dirExists(path.Dir(p)) {
+			return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+		}
+		node = &memNode{mode: perm.Perm()}
+		m.files[p] = node
+	case flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrExist}
+	case flag&os.O_TRUNC != 0 && writable:
+		node.data = nil
+	}
+
+	return &memFile{node: node, name: p, flag: flag}, nil
+}
+
+// copyUp copies file p of the lower filesystem into memory, for opening it
+// with flag. It returns nil if the lower filesystem has no such file.
+func (m *MemFS) copyUp(p string, flag int) (*memNode, error) {
+	info, err := m.lower.Stat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: syscall.EISDIR}
+	}
+	if flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrExist}
+	}
+
+	node := &memNode{mode: info.Mode().Perm()}
+	if flag&os.O_TRUNC == 0 {
+		f, err := m.lower.OpenFile(p, os.O_RDONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		node.data, err = io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.files[p] = node
+	return node, nil
+}
+
+// dirExists reports whether directory p exists in memory or below.
+func (m *MemFS) dirExists(p string) bool {
+	if m.isDir(p) {
+		return true
+	}
+	if m.lower != nil {
+		info, err := m.lower.Stat(p)
+		return err == nil && info.IsDir()
+	}
+	return false
+}
+
+// Stat returns information about a file. In-memory files have no symbolic
+// links, so it is the same as Lstat for them.
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	return m.stat(name, FileSystem.Stat)
+}
+
+// Lstat returns information about a file without following a final
+// symbolic link.
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	return m.stat(name, FileSystem.Lstat)
+}
+
+// stat stats an in-memory file, or a lower file with lowerStat.
+func (m *MemFS) stat(name string, lowerStat func(FileSystem, string) (fs.FileInfo, error)) (fs.FileInfo, error) {
+	p := m.abs(name)
+	if node, ok := m.files[p]; ok {
+		return &memFileInfo{name: path.Base(p), node: node}, nil
+	}
+	if m.lower != nil {
+		return lowerStat(m.lower, p)
+	}
+	if m.isDir(p) {
+		return &memFileInfo{name: path.Base(p), node: &memNode{mode: fs.ModeDir | 0755}}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+}
+
+// Readlink returns the target of a symbolic link. In-memory files are
+// never links.
+func (m *MemFS) Readlink(name string) (string, error) {
+	p := m.abs(name)
+	if _, ok := m.files[p]; ok || m.lower == nil && m.isDir(p) {
+		return "", &fs.PathError{Op: "readlink", Path: p, Err: syscall.EINVAL}
+	}
+	if m.lower != nil {
+		return m.lower.Readlink(p)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: p, Err: fs.ErrNotExist}
+}
+
+// Getwd returns the working directory of the lower filesystem, or / if
+// there is none.
+func (m *MemFS) Getwd() (string, error) {
+	if m.lower != nil {
+		return m.lower.Getwd()
+	}
+	return "/", nil
+}
+
+// memFile is an open in-memory file.
+type memFile struct {
+	node   *memNode
+	name   string
+	flag   int
+	offset int64
+	closed bool
+}
+
+// check returns the error for operation op on f, if any.
+func (f *memFile) check(op string, write bool) error {
+	var err error
+	access := f.flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	switch {
+	case f.closed:
+		err = fs.ErrClosed
+	case f.node.mode.IsDir():
+		err = syscall.EISDIR
+	case write && access == os.O_RDONLY, !write && access == os.O_WRONLY:
+		err = syscall.EBADF
+	default:
+		return nil
+	}
+	return &fs.PathError{Op: op, Path: f.name, Err: err}
+}
+
+func (f *memFile) Read(buf []byte) (int, error) {
+	n, err := f.ReadAt(buf, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *memFile) ReadAt(buf []byte, offset int64) (int, error) {
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: syscall.EINVAL}
+	}
+	if offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(buf, f.node.data[offset:])
+	if n < len(buf) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(buf []byte) (int, error) {
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	n, err := f.WriteAt(buf, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *memFile) WriteAt(buf []byte, offset int64) (int, error) {
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: syscall.EINVAL}
+	}
+	if end := offset + int64(len(buf)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	return copy(f.node.data[offset:], buf), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return &memFileInfo{name: path.Base(f.name), node: f.node}, nil
+}
+
+// memFileInfo describes an in-memory file.
+type memFileInfo struct {
+	name string
+	node *memNode
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i *memFileInfo) ModTime() time.Time { return memTime }
+func (i *memFileInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i *memFileInfo) Sys() interface{}   { return nil }
@@ -1,36 +1,162 @@
 // Package emu provides functional ARM64 emulation.
 package emu
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"fmt"
+)
 
-// Memory provides a simple byte-addressable memory model for emulation.
+// DefaultPageSize is the default size of a memory page in bytes.
+const DefaultPageSize = 4096
+
+// Memory provides a sparse byte-addressable memory model for emulation.
+//
+// Memory is stored in fixed-size pages that are allocated on the first write.
+// Reading memory that was never written returns zero. Memory also records the
+// protection of mapped pages, which it enforces when created WithProtection.
 type Memory struct {
-	data map[uint64]byte
+	pages     map[uint64][]byte
+	pageShift uint
+	pageMask  uint64
+
+	// Most recently used page, to skip the map lookup for sequential and
+	// repeated accesses.
+	lastPageNum uint64
+	lastPage    []byte
+
+	// writeObserver, if set, is notified of every write.
+	writeObserver WriteObserver
+
+	// Page protection, by page number, of the mapped pages.
+	perms map[uint64]int
+	// Most recently checked mapped page.
+	permNum   uint64
+	permProt  int
+	permValid bool
+
+	// protected enables protection checks; checking is set while an
+	// instruction executes. fault is the first fault of that instruction.
+	protected bool
+	checking  bool
+	faultPC   uint64
+	fault     *MemoryFault
+}
+
+// MemoryOption is a functional option for configuring Memory.
+type MemoryOption func(*Memory)
+
+// WithPageSize sets the page size. It must be a power of two of at least 8
+// bytes; Apple M2 Linux uses 16 KiB pages.
+func WithPageSize(size uint64) MemoryOption {
+	return func(m *Memory) {
+		if size < 8 || size&(size-1) != 0 {
+			panic(fmt.Sprintf("emu: invalid page size %d", size))
+		}
+		m.pageShift = 0
+		for uint64(1)<<m.pageShift < size {
+			m.pageShift++
+		}
+		m.pageMask = size - 1
+	}
+}
+
+// WriteObserver is notified of a write of data at addr. The data slice is
+// only valid for the duration of the call.
+type WriteObserver func(addr uint64, data []byte)
+
+// SetWriteObserver registers a function that is notified of every write to
+// memory. Pass nil to remove it.
+func (m *Memory) SetWriteObserver(observer WriteObserver) {
+	m.writeObserver = observer
 }
 
 // NewMemory creates a new memory instance.
-func NewMemory() *Memory {
-	return &Memory{
-		data: make(map[uint64]byte),
+func NewMemory(opts ...MemoryOption) *Memory {
+	m := &Memory{
+		pages: make(map[uint64][]byte),
+		perms: make(map[uint64]int),
 	}
+	WithPageSize(DefaultPageSize)(m)
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// PageSize returns the page size in bytes.
+func (m *Memory) PageSize() uint64 {
+	return m.pageMask + 1
+}
+
+// page returns the page holding addr, or nil if it has not been allocated.
+func (m *Memory) page(addr uint64) []byte {
+	num := addr >> m.pageShift
+	if m.lastPage != nil && m.lastPageNum == num {
+		return m.lastPage
+	}
+	page := m.pages[num]
+	if page != nil {
+		m.lastPageNum = num
+		m.lastPage = page
+	}
+	return page
+}
+
+// writablePage returns the page holding addr, allocating it if needed.
+func (m *Memory) writablePage(addr uint64) []byte {
+	if page := m.page(addr); page != nil {
+		return page
+	}
+	num := addr >> m.pageShift
+	page := make([]byte, m.pageMask+1)
+	m.pages[num] = page
+	m.lastPageNum = num
+	m.lastPage = page
+	return page
+}
+
+// fits reports whether size bytes at addr lie within one page.
+func (m *Memory) fits(addr, size uint64) bool {
+	return addr&m.pageMask <= m.pageMask+1-size
 }
 
 // Read8 reads a single byte from memory.
 func (m *Memory) Read8(addr uint64) byte {
-	return m.data[addr]
+	if m.checking && !m.allowed(addr, 1, AccessRead) {
+		return 0
+	}
+	if page := m.page(addr); page != nil {
+		return page[addr&m.pageMask]
+	}
+	return 0
 }
 
 // Write8 writes a single byte to memory.
 func (m *Memory) Write8(addr uint64, value byte) {
-	m.data[addr] = value
+	if m.checking && !m.allowed(addr, 1, AccessWrite) {
+		return
+	}
+	m.writablePage(addr)[addr&m.pageMask] = value
+	if m.writeObserver != nil {
+		m.writeObserver(addr, []byte{value})
+	}
 }
 
 // Read16 reads a 16-bit little-endian value from memory.
 func (m *Memory) Read16(addr uint64) uint16 {
-	var buf [2]byte
-	for i := uint64(0); i < 2; i++ {
-		buf[i] = m.data[addr+i]
+	if m.checking && !m.allowed(addr, 2, AccessRead) {
+		return 0
 	}
+	if m.fits(addr, 2) {
+		if page := m.page(addr); page != nil {
+			return binary.LittleEndian.Uint16(page[addr&m.pageMask:])
+		}
+		return 0
+	}
+	var buf [2]byte
+	m.ReadBytes(addr, buf[:])
 	return binary.LittleEndian.Uint16(buf[:])
 }
 
@@ -38,17 +164,22 @@ func (m *Memory) Read16(addr uint64) uint16 {
 func (m *Memory) Write16(addr uint64, value uint16) {
 	var buf [2]byte
 	binary.LittleEndian.PutUint16(buf[:], value)
-	for i := uint64(0); i < 2; i++ {
-		m.data[addr+i] = buf[i]
-	}
+	m.WriteBytes(addr, buf[:])
 }
 
 // Read32 reads a 32-bit little-endian value from memory.
 func (m *Memory) Read32(addr uint64) uint32 {
-	var buf [4]byte
-	for i := uint64(0); i < 4; i++ {
-		buf[i] = m.data[addr+i]
+	if m.checking && !m.allowed(addr, 4, AccessRead) {
+		return 0
 	}
+	if m.fits(addr, 4) {
+		if page := m.page(addr); page != nil {
+			return binary.LittleEndian.Uint32(page[addr&m.pageMask:])
+		}
+		return 0
+	}
+	var buf [4]byte
+	m.ReadBytes(addr, buf[:])
 	return binary.LittleEndian.Uint32(buf[:])
 }
 
@@ -56,17 +187,22 @@ func (m *Memory) Read32(addr uint64) uint32 {
 func (m *Memory) Write32(addr uint64, value uint32) {
 	var buf [4]byte
 	binary.LittleEndian.PutUint32(buf[:], value)
-	for i := uint64(0); i < 4; i++ {
-		m.data[addr+i] = buf[i]
-	}
+	m.WriteBytes(addr, buf[:])
 }
 
 // Read64 reads a 64-bit little-endian value from memory.
 func (m *Memory) Read64(addr uint64) uint64 {
-	var buf [8]byte
-	for i := uint64(0); i < 8; i++ {
-		buf[i] = m.data[addr+i]
+	if m.checking && !m.allowed(addr, 8, AccessRead) {
+		return 0
 	}
+	if m.fits(addr, 8) {
+		if page := m.page(addr); page != nil {
+			return binary.LittleEndian.Uint64(page[addr&m.pageMask:])
+		}
+		return 0
+	}
+	var buf [8]byte
+	m.ReadBytes(addr, buf[:])
 	return binary.LittleEndian.Uint64(buf[:])
 }
 
@@ -74,14 +210,88 @@ func (m *Memory) Read64(addr uint64) uint64 {
 func (m *Memory) Write64(addr uint64, value uint64) {
 	var buf [8]byte
 	binary.LittleEndian.PutUint64(buf[:], value)
-	for i := uint64(0); i < 8; i++ {
-		m.data[addr+i] = buf[i]
+	m.WriteBytes(addr, buf[:])
+}
+
+// ReadBytes fills buf with the memory contents starting at addr.
+func (m *Memory) ReadBytes(addr uint64, buf []byte) {
+	if m.checking && !m.allowed(addr, uint64(len(buf)), AccessRead) {
+		clear(buf)
+		return
+	}
+	for len(buf) > 0 {
+		offset := addr & m.pageMask
+		n := min(uint64(len(buf)), m.pageMask+1-offset)
+		if page := m.page(addr); page != nil {
+			copy(buf[:n], page[offset:])
+		} else {
+			clear(buf[:n])
+		}
+		buf = buf[n:]
+		addr += n
+	}
+}
+
+// WriteBytes writes data to memory starting at addr.
+func (m *Memory) WriteBytes(addr uint64, data []byte) {
+	if m.checking && !m.allowed(addr, uint64(len(data)), AccessWrite) {
+		return
+	}
+	start, rest := addr, data
+	for len(rest) > 0 {
+		offset := addr & m.pageMask
+		n := min(uint64(len(rest)), m.pageMask+1-offset)
+		copy(m.writablePage(addr)[offset:], rest[:n])
+		rest = rest[n:]
+		addr += n
+	}
+	if m.writeObserver != nil {
+		m.writeObserver(start, data)
+	}
+}
+
+// Zero clears size bytes at addr, releasing the pages it covers entirely.
+// The write observer sees only the pages that held data.
+func (m *Memory) Zero(addr, size uint64) {
+	if size == 0 {
+		return
+	}
+	first, last := m.pageRange(addr, size)
+	if last-first >= uint64(len(m.pages)) {
+		// Sparse range: visit only the allocated pages.
+		for num := range m.pages {
+			if num >= first && num <= last {
+				m.zeroPage(num, addr, addr+size-1)
+			}
+		}
+	} else {
+		for num := first; ; num++ {
+			if _, ok := m.pages[num]; ok {
+				m.zeroPage(num, addr, addr+size-1)
+			}
+			if num == last {
+				break
+			}
+		}
+	}
+	m.lastPage = nil
+}
+
+// zeroPage clears the bytes of allocated page num that lie in [lo, hi].
+func (m *Memory) zeroPage(num, lo, hi uint64) {
+	start := max(num<<m.pageShift, lo)
+	end := min(num<<m.pageShift|m.pageMask, hi)
+	if start == num<<m.pageShift && end == num<<m.pageShift|m.pageMask {
+		delete(m.pages, num)
+	} else {
+		clear(m.pages[num][start&m.pageMask : end&m.pageMask+1])
+	}
+	if m.writeObserver != nil {
+		m.writeObserver(start, make([]byte, end-start+1))
 	}
 }
 
 // LoadProgram loads a binary program into memory at the specified address.
 func (m *Memory) LoadProgram(addr uint64, program []byte) {
-	for i, b := range program {
-		m.data[addr+uint64(i)] = b
-	}
+	m.WriteBytes(addr, program)
 }
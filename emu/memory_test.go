@@ -238,4 +238,126 @@ var _ = Describe("Memory", func() {
 			Expect(mem.Read16(0x1002)).To(Equal(uint16(0xBBAA)))
 		})
 	})
+	Describe("page boundaries", func() {
+		It("should use 4 KiB pages by default", func() {
+			Expect(mem.PageSize()).To(Equal(uint64(emu.DefaultPageSize)))
+		})
+
+		It("should read and write values that straddle two pages", func() {
+			mem.Write16(0x1FFF, 0xBEEF)
+			mem.Write32(0x2FFE, 0xDEADBEEF)
+			mem.Write64(0x3FFB, 0x0123456789ABCDEF)
+
+			Expect(mem.Read16(0x1FFF)).To(Equal(uint16(0xBEEF)))
+			Expect(mem.Read8(0x1FFF)).To(Equal(byte(0xEF)))
+			Expect(mem.Read8(0x2000)).To(Equal(byte(0xBE)))
+			Expect(mem.Read32(0x2FFE)).To(Equal(uint32(0xDEADBEEF)))
+			Expect(mem.Read64(0x3FFB)).To(Equal(uint64(0x0123456789ABCDEF)))
+			Expect(mem.Read32(0x4000)).To(Equal(uint32(0x00012345)))
+		})
+
+		It("should read zero from the unwritten half of a straddling read", func() {
+			mem.Write8(0x0FFF, 0xAA)
+			Expect(mem.Read64(0x0FFF)).To(Equal(uint64(0xAA)))
+			Expect(mem.Read64(0x0FF9)).To(Equal(uint64(0xAA) << 48))
+		})
+
+		It("should handle the top of the address space", func() {
+			mem.Write64(0xFFFFFFFFFFFFFFF8, 0x1122334455667788)
+			Expect(mem.Read64(0xFFFFFFFFFFFFFFF8)).To(Equal(uint64(0x1122334455667788)))
+			Expect(mem.Read8(0xFFFFFFFFFFFFFFFF)).To(Equal(byte(0x11)))
+		})
+
+		It("should support 16 KiB pages", func() {
+			mem = emu.NewMemory(emu.WithPageSize(16384))
+			Expect(mem.PageSize()).To(Equal(uint64(16384)))
+
+			mem.Write64(0x3FFC, 0xCAFEF00DDEADBEEF)
+			Expect(mem.Read64(0x3FFC)).To(Equal(uint64(0xCAFEF00DDEADBEEF)))
+			Expect(mem.Read32(0x4000)).To(Equal(uint32(0xCAFEF00D)))
+		})
+
+		It("should reject page sizes that are not a power of two", func() {
+			Expect(func() { emu.NewMemory(emu.WithPageSize(3000)) }).To(Panic())
+			Expect(func() { emu.NewMemory(emu.WithPageSize(4)) }).To(Panic())
+		})
+	})
+
+	Describe("bulk access", func() {
+		It("should write and read back a buffer spanning several pages", func() {
+			data := make([]byte, 3*emu.DefaultPageSize+100)
+			for i := range data {
+				data[i] = byte(i * 7)
+			}
+
+			mem.WriteBytes(0x10F00, data)
+
+			buf := make([]byte, len(data))
+			mem.ReadBytes(0x10F00, buf)
+			Expect(buf).To(Equal(data))
+			Expect(mem.Read8(0x10F00 + 1000)).To(Equal(data[1000]))
+		})
+
+		It("should zero-fill unwritten memory", func() {
+			mem.Write8(0x2000, 0x55)
+
+			buf := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+			mem.ReadBytes(0x1FFE, buf)
+			Expect(buf).To(Equal([]byte{0, 0, 0x55, 0, 0, 0}))
+		})
+
+		It("should handle empty buffers", func() {
+			mem.WriteBytes(0x1000, nil)
+			mem.ReadBytes(0x1000, nil)
+			Expect(mem.Read8(0x1000)).To(BeZero())
+		})
+	})
+
+	Describe("write observer", func() {
+		type write struct {
+			addr uint64
+			data []byte
+		}
+
+		var writes []write
+
+		BeforeEach(func() {
+			writes = nil
+			mem.SetWriteObserver(func(addr uint64, data []byte) {
+				writes = append(writes, write{addr, append([]byte(nil), data...)})
+			})
+		})
+
+		It("should report writes of every width", func() {
+			mem.Write8(0x1000, 0x11)
+			mem.Write16(0x1002, 0x2233)
+			mem.Write32(0x1004, 0x44556677)
+			mem.Write64(0x1008, 0x8899AABBCCDDEEFF)
+
+			Expect(writes).To(Equal([]write{
+				{0x1000, []byte{0x11}},
+				{0x1002, []byte{0x33, 0x22}},
+				{0x1004, []byte{0x77, 0x66, 0x55, 0x44}},
+				{0x1008, []byte{0xFF, 0xEE, 0xDD, 0xCC, 0xBB, 0xAA, 0x99, 0x88}},
+			}))
+		})
+
+		It("should report loaded programs", func() {
+			mem.LoadProgram(0x2000, []byte{0x01, 0x02})
+			Expect(writes).To(Equal([]write{{0x2000, []byte{0x01, 0x02}}}))
+		})
+
+		It("should report a bulk write across pages once", func() {
+			data := make([]byte, emu.DefaultPageSize+16)
+			mem.WriteBytes(0x1FF8, data)
+			Expect(writes).To(Equal([]write{{0x1FF8, data}}))
+		})
+
+		It("should not report reads or writes after removal", func() {
+			_ = mem.Read64(0x1000)
+			mem.SetWriteObserver(nil)
+			mem.Write8(0x1000, 0x11)
+			Expect(writes).To(BeEmpty())
+		})
+	})
 })
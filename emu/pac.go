@@ -0,0 +1,382 @@
+package emu
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// PointerAuthMode selects how the pointer authentication instructions
+// execute.
+type PointerAuthMode uint8
+
+const (
+	// PointerAuthNOP executes pointer authentication as architectural NOPs,
+	// as a core without FEAT_PAuth executes the hint forms: PAC and AUT leave
+	// pointers unchanged, authenticated branches do not check their target
+	// and PACGA returns zero. XPAC still strips the PAC field.
+	PointerAuthNOP PointerAuthMode = iota
+
+	// PointerAuthEnabled signs pointers with fixed keys and a QARMA-like
+	// hash, and authenticates them as a core with FEAT_FPAC does: a failed
+	// AUT or authenticated branch stops execution with a PointerAuthFault.
+	PointerAuthEnabled
+)
+
+// WithPointerAuth selects how pointer authentication instructions execute.
+// The default is PointerAuthNOP.
+func WithPointerAuth(mode PointerAuthMode) EmulatorOption {
+	return func(e *Emulator) {
+		e.pointerAuth = mode
+	}
+}
+
+// WithBTI enforces branch target identification. Every page is treated as
+// guarded, as Linux maps the segments of binaries built with BTI: the
+// target of an indirect branch must be a compatible BTI, PACIASP or PACIBSP
+// landing pad, or execution stops with a BranchTargetFault.
+func WithBTI() EmulatorOption {
+	return func(e *Emulator) {
+		e.bti = true
+	}
+}
+
+// PointerAuth returns how the emulator executes pointer authentication.
+func (e *Emulator) PointerAuth() PointerAuthMode {
+	return e.pointerAuth
+}
+
+// PointerAuthFault describes a pointer that failed authentication. It is
+// the emulator's equivalent of the SIGILL Linux delivers for an FPAC
+// exception.
+type PointerAuthFault struct {
+	// PC is the address of the authenticating instruction.
+	PC uint64
+	// Pointer is the signed pointer that failed.
+	Pointer uint64
+	// Key is the key the pointer was authenticated with.
+	Key insts.PACKey
+}
+
+// Error implements the error interface.
+func (f *PointerAuthFault) Error() string {
+	return fmt.Sprintf("pointer authentication failure: 0x%X with key %s at PC=0x%X",
+		f.Pointer, pacKeyNames[f.Key], f.PC)
+}
+
+// BranchTargetFault describes an indirect branch to an instruction that is
+// not a compatible landing pad. It is the emulator's equivalent of the
+// SIGILL Linux delivers for a branch target exception.
+type BranchTargetFault struct {
+	// PC is the address of the branch target.
+	PC uint64
+	// BTYPE is the branch type of the indirect branch.
+	BTYPE uint8
+}
+
+// Error implements the error interface.
+func (f *BranchTargetFault) Error() string {
+	return fmt.Sprintf("branch target exception: BTYPE=%d at PC=0x%X", f.BTYPE, f.PC)
+}
+
+// pacKeyNames are the names of the keys in faults.
+var pacKeyNames = [...]string{
+	insts.PACKeyNone: "none", insts.PACKeyIA: "IA", insts.PACKeyIB: "IB",
+	insts.PACKeyDA: "DA", insts.PACKeyDB: "DB", insts.PACKeyGA: "GA",
+}
+
+// pacKey is a 128-bit pointer authentication key.
+type pacKey struct {
+	hi, lo uint64
+}
+
+// pacKeys are the keys of the simulated process. Linux picks random keys
+// at exec; fixed keys keep simulations reproducible. They are the first
+// SHA-512 round constants.
+var pacKeys = [...]pacKey{
+	insts.PACKeyIA: {0x428A2F98D728AE22, 0x7137449123EF65CD},
+	insts.PACKeyIB: {0xB5C0FBCFEC4D3B2F, 0xE9B5DBA58189DBBC},
+	insts.PACKeyDA: {0x3956C25BF348B538, 0x59F111F1B605D019},
+	insts.PACKeyDB: {0x923F82A4AF194F9B, 0xAB1C5ED5DA6D8118},
+	insts.PACKeyGA: {0xD807AA98A3030242, 0x12835B0145706FBE},
+}
+
+// pacRoundConstants and pacAlpha are the QARMA-64 constants, taken from
+// the digits of pi.
+var pacRoundConstants = [5]uint64{
+	0x0000000000000000, 0x13198A2E03707344, 0xA4093822299F31D0,
+	0x082EFA98EC4E6C89, 0x452821E638D01377,
+}
+
+const pacAlpha = 0xC0AC29B7C97C50DD
+
+// pacSBox is the QARMA sigma0 S-box. It is an involution, so it is also
+// its own inverse.
+var pacSBox = [16]uint64{0, 14, 2, 10, 9, 15, 8, 11, 6, 4, 3, 7, 13, 12, 1, 5}
+
+// Cell permutations of the state (tau) and of the tweak (h), and their
+// inverses. Cell i of the result is cell perm[i] of the input.
+var (
+	pacShuffle         = [16]uint8{0, 11, 6, 13, 10, 1, 12, 7, 5, 14, 3, 8, 15, 4, 9, 2}
+	pacInvShuffle      = [16]uint8{0, 5, 15, 10, 13, 8, 2, 7, 11, 14, 4, 1, 6, 3, 9, 12}
+	pacTweakShuffle    = [16]uint8{6, 5, 14, 15, 0, 1, 2, 3, 7, 12, 13, 4, 8, 9, 10, 11}
+	pacTweakInvShuffle = [16]uint8{4, 5, 6, 7, 11, 1, 0, 8, 12, 13, 14, 15, 9, 10, 2, 3}
+)
+
+// pacTweakLFSRCells are the tweak cells the QARMA LFSR updates.
+const pacTweakLFSRCells = 1<<0 | 1<<1 | 1<<3 | 1<<4 | 1<<8 | 1<<11 | 1<<13
+
+// permuteCells permutes the 4-bit cells of x.
+func permuteCells(x uint64, perm *[16]uint8) uint64 {
+	var y uint64
+	for i, src := range perm {
+		y |= (x >> (4 * src) & 0xF) << (4 * i)
+	}
+	return y
+}
+
+// subCells applies the S-box to every cell of x.
+func subCells(x uint64) uint64 {
+	var y uint64
+	for i := 0; i < 64; i += 4 {
+		y |= pacSBox[x>>i&0xF] << i
+	}
+	return y
+}
+
+// mixColumns multiplies x, as a 4x4 matrix of cells, by the involutory
+// QARMA matrix circ(0, rho, rho^2, rho), where rho rotates a cell left by
+// one bit.
+func mixColumns(x uint64) uint64 {
+	rotations := [4]uint{0, 1, 2, 1}
+	var y uint64
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			var v uint64
+			for k := 0; k < 4; k++ {
+				r := rotations[(k-row+4)%4]
+				if r == 0 {
+					continue
+				}
+				c := x >> (4 * (4*k + col)) & 0xF
+				v ^= (c<<r | c>>(4-r)) & 0xF
+			}
+			y |= v << (4 * (4*row + col))
+		}
+	}
+	return y
+}
+
+// tweakShuffle advances the tweak by one round: it permutes the cells and
+// steps the LFSR cells.
+func tweakShuffle(t uint64) uint64 {
+	t = permuteCells(t, &pacTweakShuffle)
+	for i := 0; i < 16; i++ {
+		if pacTweakLFSRCells&(1<<i) != 0 {
+			c := t >> (4 * i) & 0xF
+			c = c>>1 | ((c^c>>1)&1)<<3
+			t = t&^(0xF<<(4*i)) | c<<(4*i)
+		}
+	}
+	return t
+}
+
+// tweakInvShuffle undoes tweakShuffle.
+func tweakInvShuffle(t uint64) uint64 {
+	for i := 0; i < 16; i++ {
+		if pacTweakLFSRCells&(1<<i) != 0 {
+			c := t >> (4 * i) & 0xF
+			c = c<<1&0xE | (c>>3^c)&1
+			t = t&^(0xF<<(4*i)) | c<<(4*i)
+		}
+	}
+	return permuteCells(t, &pacTweakInvShuffle)
+}
+
+// computePAC computes the 64-bit authentication code of data with the
+// given modifier. It follows the structure of the architected QARMA-64
+// ComputePAC (five forward rounds, a reflector keyed by the whitening key
+// and five backward rounds, tweaked by the modifier), but its cell
+// operations are not bit-exact with hardware.
+func computePAC(data, modifier uint64, key pacKey) uint64 {
+	k0, k1 := key.hi, key.lo
+	modk0 := k0<<63 | k0>>2<<1 | (k0>>63^k0>>1)&1
+
+	state := data ^ k0
+	tweak := modifier
+	for i, c := range pacRoundConstants {
+		state ^= k1 ^ tweak ^ c
+		if i > 0 {
+			state = mixColumns(permuteCells(state, &pacShuffle))
+		}
+		state = subCells(state)
+		tweak = tweakShuffle(tweak)
+	}
+
+	state ^= modk0 ^ tweak
+	state = mixColumns(permuteCells(state, &pacShuffle))
+	state = subCells(state)
+	state = mixColumns(permuteCells(state, &pacShuffle))
+	state ^= k1
+	state = subCells(permuteCells(state, &pacInvShuffle))
+	state = permuteCells(mixColumns(state), &pacInvShuffle)
+	state ^= k0 ^ tweak
+
+	for i := range pacRoundConstants {
+		state = subCells(state)
+		if i < 4 {
+			state = permuteCells(mixColumns(state), &pacInvShuffle)
+		}
+		tweak = tweakInvShuffle(tweak)
+		state ^= k1 ^ tweak ^ pacRoundConstants[4-i] ^ pacAlpha
+	}
+	return state ^ modk0
+}
+
+// pacField returns the bits of a pointer that hold its PAC with 48-bit
+// virtual addresses. Instruction pointers use bits 63:56 and 54:48; data
+// pointers keep their top byte for tagging and use bits 54:48.
+func pacField(data bool) uint64 {
+	if data {
+		return 0x007F000000000000
+	}
+	return 0xFF7F000000000000
+}
+
+// stripPAC fills the PAC field of ptr with copies of bit 55, which selects
+// the upper or lower address range.
+func stripPAC(ptr uint64, data bool) uint64 {
+	if ptr&(1<<55) != 0 {
+		return ptr | pacField(data)
+	}
+	return ptr &^ pacField(data)
+}
+
+// addPAC inserts the PAC of ptr into its PAC field. If the field did not
+// hold the extension of bit 55, the pointer was not valid and a bit of the
+// PAC is flipped so that it fails authentication.
+func addPAC(ptr, modifier uint64, key insts.PACKey) uint64 {
+	data := key.IsData()
+	original := stripPAC(ptr, data)
+	pac := computePAC(original, modifier, pacKeys[key])
+	if ptr != original {
+		if data {
+			pac ^= 1 << 54
+		} else {
+			pac ^= 1 << 62
+		}
+	}
+	return original&^pacField(data) | pac&pacField(data)
+}
+
+// authPAC checks the PAC of ptr and returns the pointer with its PAC
+// stripped. ok is false if the PAC does not match.
+func authPAC(ptr, modifier uint64, key insts.PACKey) (uint64, bool) {
+	original := stripPAC(ptr, key.IsData())
+	return original, addPAC(original, modifier, key) == ptr
+}
+
+// pacModifier returns the modifier of a pointer authentication instruction
+// held in register reg, where 31 is SP.
+func (e *Emulator) pacModifier(inst *insts.Instruction, reg uint8) uint64 {
+	if inst.ZeroModifier {
+		return 0
+	}
+	return e.regFile.ReadRegOrSP(reg)
+}
+
+// executePointerAuth executes PAC, AUT, XPAC and PACGA.
+func (e *Emulator) executePointerAuth(inst *insts.Instruction) error {
+	ptr := e.regFile.ReadReg(inst.Rd)
+	enabled := e.pointerAuth == PointerAuthEnabled
+
+	switch inst.Op {
+	case insts.OpXPAC:
+		e.regFile.WriteReg(inst.Rd, stripPAC(ptr, inst.PACKey.IsData()))
+	case insts.OpPACGA:
+		var pac uint64
+		if enabled {
+			data := e.regFile.ReadReg(inst.Rn)
+			pac = computePAC(data, e.pacModifier(inst, inst.Rm), pacKeys[inst.PACKey])
+		}
+		e.regFile.WriteReg(inst.Rd, pac&0xFFFFFFFF00000000)
+	case insts.OpPAC:
+		if enabled {
+			e.regFile.WriteReg(inst.Rd, addPAC(ptr, e.pacModifier(inst, inst.Rn), inst.PACKey))
+		}
+	case insts.OpAUT:
+		if enabled {
+			original, ok := authPAC(ptr, e.pacModifier(inst, inst.Rn), inst.PACKey)
+			if !ok {
+				return &PointerAuthFault{PC: e.regFile.PC, Pointer: ptr, Key: inst.PACKey}
+			}
+			e.regFile.WriteReg(inst.Rd, original)
+		}
+	}
+	return nil
+}
+
+// authenticatedTarget returns the target of an authenticated branch with
+// its PAC stripped.
+func (e *Emulator) authenticatedTarget(inst *insts.Instruction) (uint64, error) {
+	target := e.regFile.ReadReg(inst.Rn)
+	if e.pointerAuth != PointerAuthEnabled {
+		return target, nil
+	}
+	original, ok := authPAC(target, e.pacModifier(inst, inst.Rm), inst.PACKey)
+	if !ok {
+		return 0, &PointerAuthFault{PC: e.regFile.PC, Pointer: target, Key: inst.PACKey}
+	}
+	return original, nil
+}
+
+// branchType returns the PSTATE.BTYPE an instruction sets: 01 for BR from
+// X16 or X17, 11 for other BRs, 10 for BLR and 00 otherwise.
+func branchType(inst *insts.Instruction) uint8 {
+	switch inst.Op {
+	case insts.OpBR:
+		if inst.Rn == 16 || inst.Rn == 17 {
+			return 0b01
+		}
+		return 0b11
+	case insts.OpBLR:
+		return 0b10
+	default:
+		return 0
+	}
+}
+
+// isLandingPad reports whether inst may be the target of an indirect
+// branch of type btype. PACIASP and PACIBSP act as BTI c, and BRK is
+// compatible with every branch type.
+func isLandingPad(inst *insts.Instruction, btype uint8) bool {
+	var targets uint64
+	switch {
+	case inst.Op == insts.OpBTI:
+		targets = inst.Imm
+	case inst.Op == insts.OpPAC && inst.Format == insts.FormatBarrier &&
+		inst.Rd == 30 && !inst.ZeroModifier:
+		targets = insts.BTITargetCall
+	case inst.Op == insts.OpBRK:
+		return true
+	}
+
+	switch btype {
+	case 0b01:
+		return targets != 0
+	case 0b10:
+		return targets&insts.BTITargetCall != 0
+	default:
+		return targets&insts.BTITargetJump != 0
+	}
+}
+
+// checkBranchTarget checks that an instruction reached by an indirect
+// branch is a compatible landing pad.
+func (e *Emulator) checkBranchTarget(inst *insts.Instruction) error {
+	btype := e.regFile.PSTATE.BTYPE
+	if btype == 0 || isLandingPad(inst, btype) {
+		return nil
+	}
+	return &BranchTargetFault{PC: e.regFile.PC, BTYPE: btype}
+}
@@ -0,0 +1,183 @@
+package emu_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/asm"
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Pointer Authentication", func() {
+	var e *emu.Emulator
+
+	// load assembles src at 0x1000.
+	load := func(src string, opts ...emu.EmulatorOption) {
+		e = emu.NewEmulator(append([]emu.EmulatorOption{emu.WithStackPointer(0x8000)}, opts...)...)
+		e.LoadProgram(0x1000, asm.MustAssemble(src))
+	}
+
+	// step executes n instructions and returns the error of the last one.
+	step := func(n int) error {
+		for i := 0; i < n-1; i++ {
+			Expect(e.Step().Err).To(BeNil())
+		}
+		return e.Step().Err
+	}
+
+	x := func(reg uint8) uint64 {
+		return e.RegFile().ReadReg(reg)
+	}
+
+	const signAndReturn = `adr x30, 1f
+		paciasp
+		mov x0, x30
+		retaa
+		brk #1
+	1:	nop`
+
+	It("should execute as NOPs by default", func() {
+		load(signAndReturn)
+		Expect(e.PointerAuth()).To(Equal(emu.PointerAuthNOP))
+
+		Expect(step(4)).To(Succeed())
+		Expect(x(0)).To(Equal(uint64(0x1014)))
+		Expect(e.RegFile().PC).To(Equal(uint64(0x1014)))
+	})
+
+	It("should sign the link register and authenticate the return", func() {
+		load(signAndReturn, emu.WithPointerAuth(emu.PointerAuthEnabled))
+
+		Expect(step(4)).To(Succeed())
+		Expect(x(0)).NotTo(Equal(uint64(0x1014)))
+		Expect(x(0) & 0x0000FFFFFFFFFFFF).To(Equal(uint64(0x1014)))
+		Expect(e.RegFile().PC).To(Equal(uint64(0x1014)))
+	})
+
+	It("should fault when the modifier changes", func() {
+		load(`adr x30, 1f
+			paciasp
+			sub sp, sp, #16
+			retaa
+		1:	nop`, emu.WithPointerAuth(emu.PointerAuthEnabled))
+
+		err := step(4)
+		var fault *emu.PointerAuthFault
+		Expect(err).To(BeAssignableToTypeOf(fault))
+		fault = err.(*emu.PointerAuthFault)
+		Expect(fault.PC).To(Equal(uint64(0x100C)))
+		Expect(fault.Key).To(Equal(insts.PACKeyIA))
+		Expect(e.RegFile().PC).To(Equal(uint64(0x100C)))
+	})
+
+	It("should produce different codes for different keys and modifiers", func() {
+		load(`movz x1, #0x4000
+			movz x2, #7
+			pacia x1, x2
+			movz x3, #0x4000
+			pacib x3, x2
+			movz x4, #0x4000
+			paciza x4
+			mov x5, x1
+			autia x5, x2
+			mov x6, x1
+			xpaci x6
+			movz x7, #0x4000
+			pacda x7, x2`, emu.WithPointerAuth(emu.PointerAuthEnabled))
+
+		Expect(step(13)).To(Succeed())
+		Expect(x(1)).NotTo(Equal(uint64(0x4000)))
+		Expect(x(1)).NotTo(Equal(x(3)))
+		Expect(x(1)).NotTo(Equal(x(4)))
+		Expect(x(5)).To(Equal(uint64(0x4000)))
+		Expect(x(6)).To(Equal(uint64(0x4000)))
+		Expect(x(7) >> 56).To(BeZero()) // data pointers keep their tag byte
+	})
+
+	It("should fault when authenticating with the wrong key", func() {
+		load(`movz x1, #0x4000
+			pacia x1, x2
+			autib x1, x2`, emu.WithPointerAuth(emu.PointerAuthEnabled))
+
+		Expect(step(3)).To(BeAssignableToTypeOf(&emu.PointerAuthFault{}))
+	})
+
+	It("should compute a generic authentication code", func() {
+		src := `movz x1, #0x1234
+			movz x2, #0x5678
+			pacga x0, x1, x2`
+
+		load(src)
+		Expect(step(3)).To(Succeed())
+		Expect(x(0)).To(BeZero())
+
+		load(src, emu.WithPointerAuth(emu.PointerAuthEnabled))
+		Expect(step(3)).To(Succeed())
+		Expect(x(0)).NotTo(BeZero())
+		Expect(x(0) & 0xFFFFFFFF).To(BeZero())
+	})
+
+	It("should strip the link register with XPACLRI", func() {
+		load(`adr x30, 1f
+			paciasp
+			xpaclri
+		1:	nop`, emu.WithPointerAuth(emu.PointerAuthEnabled))
+
+		Expect(step(3)).To(Succeed())
+		Expect(x(30)).To(Equal(uint64(0x100C)))
+	})
+})
+
+var _ = Describe("Branch Target Identification", func() {
+	var e *emu.Emulator
+
+	// run assembles src at 0x1000 and executes n instructions, returning
+	// the error of the last one.
+	run := func(src string, n int, opts ...emu.EmulatorOption) error {
+		e = emu.NewEmulator(opts...)
+		e.LoadProgram(0x1000, asm.MustAssemble(src))
+		for i := 0; i < n-1; i++ {
+			Expect(e.Step().Err).To(BeNil())
+		}
+		return e.Step().Err
+	}
+
+	It("should accept compatible landing pads", func() {
+		Expect(run(`adr x16, 1f
+			br x16
+		1:	bti c
+			adr x1, 2f
+			blr x1
+		2:	paciasp
+			adr x2, 3f
+			br x2
+		3:	bti jc
+			nop`, 10, emu.WithBTI())).To(Succeed())
+		Expect(e.RegFile().PSTATE.BTYPE).To(BeZero())
+	})
+
+	It("should fault on a branch to an incompatible landing pad", func() {
+		err := run(`adr x1, 1f
+			br x1
+		1:	bti c`, 3, emu.WithBTI())
+
+		var fault *emu.BranchTargetFault
+		Expect(err).To(BeAssignableToTypeOf(fault))
+		fault = err.(*emu.BranchTargetFault)
+		Expect(fault.PC).To(Equal(uint64(0x1008)))
+		Expect(fault.BTYPE).To(Equal(uint8(0b11)))
+	})
+
+	It("should fault on a call to an instruction that is not a landing pad", func() {
+		Expect(run(`adr x1, 1f
+			blr x1
+		1:	nop`, 3, emu.WithBTI())).To(BeAssignableToTypeOf(&emu.BranchTargetFault{}))
+	})
+
+	It("should not check landing pads unless enforced", func() {
+		Expect(run(`adr x1, 1f
+			br x1
+		1:	nop`, 3)).To(Succeed())
+	})
+})
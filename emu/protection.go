@@ -0,0 +1,251 @@
+package emu
+
+import (
+	"fmt"
+	"maps"
+)
+
+// AccessType is the kind of memory access that caused a fault.
+type AccessType uint8
+
+// Memory access types.
+const (
+	AccessRead AccessType = iota
+	AccessWrite
+	AccessExecute
+)
+
+// String returns a human-readable name for the access type.
+func (a AccessType) String() string {
+	switch a {
+	case AccessRead:
+		return "read"
+	case AccessWrite:
+		return "write"
+	case AccessExecute:
+		return "execute"
+	default:
+		return fmt.Sprintf("AccessType(%d)", uint8(a))
+	}
+}
+
+// prot returns the mmap protection bit the access requires.
+func (a AccessType) prot() int {
+	switch a {
+	case AccessWrite:
+		return PROT_WRITE
+	case AccessExecute:
+		return PROT_EXEC
+	default:
+		return PROT_READ
+	}
+}
+
+// MemoryFault describes an access to an unmapped page or one whose
+// protection does not permit it. It is the emulator's equivalent of a
+// SIGSEGV.
+type MemoryFault struct {
+	// Addr is the faulting address.
+	Addr uint64
+	// PC is the address of the faulting instruction.
+	PC uint64
+	// Access is the kind of access that faulted.
+	Access AccessType
+	// Mapped is true if the page is mapped but its protection does not
+	// permit the access (SEGV_ACCERR), and false if the page is not mapped
+	// (SEGV_MAPERR).
+	Mapped bool
+}
+
+// Error implements the error interface.
+func (f *MemoryFault) Error() string {
+	reason := "address not mapped"
+	if f.Mapped {
+		reason = "invalid permissions"
+	}
+	return fmt.Sprintf("segmentation fault: %s of 0x%X at PC=0x%X (%s)",
+		f.Access, f.Addr, f.PC, reason)
+}
+
+// WithProtection enables enforcement of the page protection recorded with
+// Map and Protect. While the emulator executes an instruction, accesses to
+// unmapped pages or pages whose protection does not permit them fail with a
+// MemoryFault; reads return zero and writes are dropped. Accesses made
+// outside instruction execution, such as program loading, syscalls and timing
+// model fetches, are never checked.
+func WithProtection() MemoryOption {
+	return func(m *Memory) {
+		m.protected = true
+	}
+}
+
+// ProtectionEnabled reports whether page protection is enforced.
+func (m *Memory) ProtectionEnabled() bool {
+	return m.protected
+}
+
+// pageRange returns the first and last page numbers covering size bytes at
+// addr. size must not be zero.
+func (m *Memory) pageRange(addr, size uint64) (uint64, uint64) {
+	return addr >> m.pageShift, (addr + size - 1) >> m.pageShift
+}
+
+// Map marks the pages covering size bytes at addr as mapped with the given
+// mmap protection bits (PROT_READ, PROT_WRITE, PROT_EXEC), replacing any
+// previous protection.
+func (m *Memory) Map(addr, size uint64, prot int) {
+	if size == 0 {
+		return
+	}
+	first, last := m.pageRange(addr, size)
+	for num := first; ; num++ {
+		m.perms[num] = prot
+		if num == last {
+			break
+		}
+	}
+	m.permValid = false
+}
+
+// Unmap marks the pages covering size bytes at addr as unmapped. Their
+// contents are kept.
+func (m *Memory) Unmap(addr, size uint64) {
+	if size == 0 {
+		return
+	}
+	first, last := m.pageRange(addr, size)
+	for num := first; ; num++ {
+		delete(m.perms, num)
+		if num == last {
+			break
+		}
+	}
+	m.permValid = false
+}
+
+// Protect changes the protection of the mapped pages covering size bytes at
+// addr. It reports false, changing nothing, if any of the pages is not
+// mapped.
+func (m *Memory) Protect(addr, size uint64, prot int) bool {
+	if size == 0 {
+		return true
+	}
+	first, last := m.pageRange(addr, size)
+	for num := first; ; num++ {
+		if _, ok := m.perms[num]; !ok {
+			return false
+		}
+		if num == last {
+			break
+		}
+	}
+	m.Map(addr, size, prot)
+	return true
+}
+
+// copyProtection replaces the page protection of m with that of src, which
+// must have the same page size.
+func (m *Memory) copyProtection(src *Memory) {
+	m.perms = maps.Clone(src.perms)
+	m.permValid = false
+}
+
+// Protection returns the protection bits of the page holding addr and
+// whether it is mapped.
+func (m *Memory) Protection(addr uint64) (int, bool) {
+	prot, ok := m.perms[addr>>m.pageShift]
+	return prot, ok
+}
+
+// beginInstruction starts checking accesses on behalf of the instruction at
+// pc. It is a no-op unless protection is enabled.
+func (m *Memory) beginInstruction(pc uint64) {
+	m.checking = m.protected
+	m.faultPC = pc
+	m.fault = nil
+}
+
+// endInstruction stops checking accesses and returns the first fault the
+// instruction caused, if any.
+func (m *Memory) endInstruction() *MemoryFault {
+	m.checking = false
+	fault := m.fault
+	m.fault = nil
+	return fault
+}
+
+// checkFetch returns a fault if protection is enabled and the instruction at
+// pc may not be executed.
+func (m *Memory) checkFetch(pc uint64) *MemoryFault {
+	if !m.protected {
+		return nil
+	}
+	m.beginInstruction(pc)
+	m.allowed(pc, 4, AccessExecute)
+	return m.endInstruction()
+}
+
+// allowed reports whether size bytes at addr may be accessed. If not, it
+// records the first fault of the current instruction.
+func (m *Memory) allowed(addr, size uint64, access AccessType) bool {
+	if size == 0 {
+		return true
+	}
+	need := access.prot()
+	first, last := m.pageRange(addr, size)
+	for num := first; ; num++ {
+		prot, mapped := m.pagePerm(num)
+		if !mapped || prot&need == 0 {
+			if m.fault == nil {
+				faultAddr := addr
+				if num != first {
+					faultAddr = num << m.pageShift
+				}
+				m.fault = &MemoryFault{
+					Addr:   faultAddr,
+					PC:     m.faultPC,
+					Access: access,
+					Mapped: mapped,
+				}
+			}
+			return false
+		}
+		if num == last {
+			return true
+		}
+	}
+}
+
+// readableLen returns how many of the size bytes at addr, counting from
+// addr, lie in pages that may be read. Without protection enabled, all of
+// them do.
+func (m *Memory) readableLen(addr, size uint64) uint64 {
+	if !m.protected || size == 0 {
+		return size
+	}
+	first, last := m.pageRange(addr, size)
+	for num := first; ; num++ {
+		prot, mapped := m.pagePerm(num)
+		if !mapped || prot&PROT_READ == 0 {
+			if num == first {
+				return 0
+			}
+			return num<<m.pageShift - addr
+		}
+		if num == last {
+			return size
+		}
+	}
+}
+
+// pagePerm returns the protection of page num and whether it is mapped.
+func (m *Memory) pagePerm(num uint64) (int, bool) {
+	if m.permValid && m.permNum == num {
+		return m.permProt, true
+	}
+	prot, ok := m.perms[num]
+	if ok {
+		m.permNum, m.permProt, m.permValid = num, prot, true
+	}
+	return prot, ok
+}
@@ -0,0 +1,174 @@
+package emu_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Memory protection", func() {
+	Describe("page table", func() {
+		var mem *emu.Memory
+
+		BeforeEach(func() {
+			mem = emu.NewMemory()
+		})
+
+		protection := func(addr uint64) int {
+			prot, mapped := mem.Protection(addr)
+			Expect(mapped).To(BeTrue())
+			return prot
+		}
+
+		It("should map whole pages covering the range", func() {
+			mem.Map(0x1800, 0x1000, emu.PROT_READ)
+
+			Expect(protection(0x1000)).To(Equal(emu.PROT_READ))
+			Expect(protection(0x2FFF)).To(Equal(emu.PROT_READ))
+			_, mapped := mem.Protection(0x3000)
+			Expect(mapped).To(BeFalse())
+		})
+
+		It("should change the protection of mapped pages only", func() {
+			mem.Map(0x1000, 0x2000, emu.PROT_READ|emu.PROT_WRITE)
+
+			Expect(mem.Protect(0x2000, 0x1000, emu.PROT_READ)).To(BeTrue())
+			Expect(protection(0x1000)).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+			Expect(protection(0x2000)).To(Equal(emu.PROT_READ))
+
+			Expect(mem.Protect(0x2000, 0x2000, emu.PROT_NONE)).To(BeFalse())
+			Expect(protection(0x2000)).To(Equal(emu.PROT_READ))
+		})
+
+		It("should unmap pages but keep their contents", func() {
+			mem.Map(0x1000, 0x1000, emu.PROT_READ|emu.PROT_WRITE)
+			mem.Write64(0x1000, 42)
+
+			mem.Unmap(0x1000, 0x1000)
+
+			_, mapped := mem.Protection(0x1000)
+			Expect(mapped).To(BeFalse())
+			Expect(mem.Read64(0x1000)).To(Equal(uint64(42)))
+		})
+
+		It("should not enforce protection by default", func() {
+			Expect(mem.ProtectionEnabled()).To(BeFalse())
+			Expect(emu.NewMemory(emu.WithProtection()).ProtectionEnabled()).To(BeTrue())
+		})
+	})
+
+	Describe("enforcement", func() {
+		const (
+			codeAddr = uint64(0x400000)
+			dataAddr = uint64(0x500000)
+		)
+
+		var (
+			e       *emu.Emulator
+			regFile *emu.RegFile
+			memory  *emu.Memory
+		)
+
+		execute := func(word uint32) emu.StepResult {
+			return e.Execute(insts.NewDecoder().Decode(word))
+		}
+
+		fault := func(result emu.StepResult) *emu.MemoryFault {
+			var f *emu.MemoryFault
+			Expect(result.Err).To(BeAssignableToTypeOf(f))
+			return result.Err.(*emu.MemoryFault)
+		}
+
+		BeforeEach(func() {
+			memory = emu.NewMemory(emu.WithProtection())
+			memory.Map(codeAddr, 0x1000, emu.PROT_READ|emu.PROT_EXEC)
+			memory.Map(dataAddr, 0x1000, emu.PROT_READ)
+
+			e = emu.NewEmulator(emu.WithMemory(memory))
+			regFile = e.RegFile()
+			regFile.PC = codeAddr
+		})
+
+		It("should allow permitted accesses", func() {
+			memory.Write64(dataAddr, 7)
+			regFile.X[1] = dataAddr
+
+			result := execute(0xf9400020) // ldr x0, [x1]
+
+			Expect(result.Err).NotTo(HaveOccurred())
+			Expect(regFile.X[0]).To(Equal(uint64(7)))
+			Expect(regFile.PC).To(Equal(codeAddr + 4))
+		})
+
+		It("should fault on a read of an unmapped page", func() {
+			regFile.X[1] = 0x10
+
+			f := fault(execute(0xf9400020)) // ldr x0, [x1]
+
+			Expect(f.Addr).To(Equal(uint64(0x10)))
+			Expect(f.PC).To(Equal(codeAddr))
+			Expect(f.Access).To(Equal(emu.AccessRead))
+			Expect(f.Mapped).To(BeFalse())
+			Expect(f.Error()).To(Equal(
+				"segmentation fault: read of 0x10 at PC=0x400000 (address not mapped)"))
+			Expect(regFile.PC).To(Equal(codeAddr))
+		})
+
+		It("should fault on a write to a read-only page and drop it", func() {
+			regFile.X[0] = 99
+			regFile.X[1] = dataAddr
+
+			f := fault(execute(0xf9000020)) // str x0, [x1]
+
+			Expect(f.Addr).To(Equal(dataAddr))
+			Expect(f.Access).To(Equal(emu.AccessWrite))
+			Expect(f.Mapped).To(BeTrue())
+			Expect(memory.Read64(dataAddr)).To(BeZero())
+		})
+
+		It("should report the first unmapped byte of a straddling access", func() {
+			regFile.X[1] = dataAddr + 0xFF8
+
+			f := fault(execute(0xa9400c22)) // ldp x2, x3, [x1]
+
+			Expect(f.Addr).To(Equal(dataAddr + 0x1000))
+			Expect(f.Mapped).To(BeFalse())
+		})
+
+		It("should fault on execution from a non-executable page", func() {
+			regFile.PC = dataAddr
+
+			f := fault(execute(0x91000400)) // add x0, x0, #1
+
+			Expect(f.Addr).To(Equal(dataAddr))
+			Expect(f.PC).To(Equal(dataAddr))
+			Expect(f.Access).To(Equal(emu.AccessExecute))
+			Expect(f.Mapped).To(BeTrue())
+			Expect(regFile.X[0]).To(BeZero())
+		})
+
+		It("should stop Run with a segmentation fault report", func() {
+			stderr := &bytes.Buffer{}
+			e = emu.NewEmulator(emu.WithMemory(memory), emu.WithStderr(stderr))
+			memory.Write32(codeAddr, 0xf9400020) // ldr x0, [x1]
+			e.RegFile().PC = codeAddr
+
+			Expect(e.Run()).To(Equal(int64(-1)))
+			Expect(stderr.String()).To(ContainSubstring(
+				"segmentation fault: read of 0x0 at PC=0x400000"))
+		})
+
+		It("should not check accesses without protection", func() {
+			e = emu.NewEmulator()
+			e.RegFile().X[1] = 0x10
+
+			result := e.Execute(insts.NewDecoder().Decode(0xf9000020)) // str x0, [x1]
+
+			Expect(result.Err).NotTo(HaveOccurred())
+		})
+	})
+})
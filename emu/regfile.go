@@ -29,6 +29,9 @@ type PSTATE struct {
 	C bool
 	// V is the overflow flag.
 	V bool
+	// BTYPE is the branch type of the last indirect branch, checked
+	// against the landing pad at its target when BTI is enforced.
+	BTYPE uint8
 }
 
 // ReadReg reads a register value. Register 31 returns 0 (XZR).
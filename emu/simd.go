@@ -1,22 +1,23 @@
 // Package emu provides functional ARM64 emulation.
 package emu
 
-import (
-	"math"
-)
-
 // SIMDArrangement represents the SIMD vector arrangement specifier.
 type SIMDArrangement = uint8
 
 // SIMD arrangement specifiers matching insts package.
 const (
-	Arr8B  SIMDArrangement = 0 // 8 bytes (64-bit, D register)
-	Arr16B SIMDArrangement = 1 // 16 bytes (128-bit, Q register)
-	Arr4H  SIMDArrangement = 2 // 4 halfwords (64-bit)
-	Arr8H  SIMDArrangement = 3 // 8 halfwords (128-bit)
-	Arr2S  SIMDArrangement = 4 // 2 singles (64-bit)
-	Arr4S  SIMDArrangement = 5 // 4 singles (128-bit)
-	Arr2D  SIMDArrangement = 6 // 2 doubles (128-bit)
+	Arr8B  SIMDArrangement = 0  // 8 bytes (64-bit, D register)
+	Arr16B SIMDArrangement = 1  // 16 bytes (128-bit, Q register)
+	Arr4H  SIMDArrangement = 2  // 4 halfwords (64-bit)
+	Arr8H  SIMDArrangement = 3  // 8 halfwords (128-bit)
+	Arr2S  SIMDArrangement = 4  // 2 singles (64-bit)
+	Arr4S  SIMDArrangement = 5  // 4 singles (128-bit)
+	Arr2D  SIMDArrangement = 6  // 2 doubles (128-bit)
+	Arr1D  SIMDArrangement = 7  // 1 double (64-bit)
+	Arr1Q  SIMDArrangement = 8  // 1 quadword (128-bit)
+	Arr1B  SIMDArrangement = 9  // 1 byte (Advanced SIMD scalar)
+	Arr1H  SIMDArrangement = 10 // 1 halfword (Advanced SIMD scalar)
+	Arr1S  SIMDArrangement = 11 // 1 single (Advanced SIMD scalar)
 )
 
 // SIMD implements ARM64 SIMD (NEON) operations.
@@ -24,6 +25,7 @@ type SIMD struct {
 	simdRegFile *SIMDRegFile
 	regFile     *RegFile // For accessing base registers in load/store
 	memory      *Memory
+	fpu         *FPU // Floating-point arithmetic on elements
 }
 
 // NewSIMD creates a new SIMD execution unit.
@@ -32,11 +34,13 @@ func NewSIMD(simdRegFile *SIMDRegFile, regFile *RegFile, memory *Memory) *SIMD {
 		simdRegFile: simdRegFile,
 		regFile:     regFile,
 		memory:      memory,
+		fpu:         NewFPU(simdRegFile, regFile),
 	}
 }
 
 // VADD performs vector integer addition.
-// Arrangement specifies the element size: 8B, 16B, 4H, 8H, 2S, 4S, 2D.
+// Arrangement specifies the element size: 8B, 16B, 4H, 8H, 2S, 4S, 2D, or
+// 1D for the scalar form.
 func (s *SIMD) VADD(vd, vn, vm uint8, arrangement SIMDArrangement) {
 	switch arrangement {
 	case Arr8B:
@@ -53,6 +57,8 @@ func (s *SIMD) VADD(vd, vn, vm uint8, arrangement SIMDArrangement) {
 		s.vaddWords(vd, vn, vm, 4)
 	case Arr2D:
 		s.vaddDoubles(vd, vn, vm, 2)
+	case Arr1D:
+		s.vaddDoubles(vd, vn, vm, 1)
 	}
 }
 
@@ -96,6 +102,9 @@ func (s *SIMD) vaddDoubles(vd, vn, vm uint8, count int) {
 		b := s.simdRegFile.ReadLane64(vm, uint8(i))
 		s.simdRegFile.WriteLane64(vd, uint8(i), a+b)
 	}
+	if count <= 1 {
+		s.simdRegFile.WriteLane64(vd, 1, 0)
+	}
 }
 
 // VSUB performs vector integer subtraction.
@@ -115,6 +124,8 @@ func (s *SIMD) VSUB(vd, vn, vm uint8, arrangement SIMDArrangement) {
 		s.vsubWords(vd, vn, vm, 4)
 	case Arr2D:
 		s.vsubDoubles(vd, vn, vm, 2)
+	case Arr1D:
+		s.vsubDoubles(vd, vn, vm, 1)
 	}
 }
 
@@ -157,6 +168,9 @@ func (s *SIMD) vsubDoubles(vd, vn, vm uint8, count int) {
 		b := s.simdRegFile.ReadLane64(vm, uint8(i))
 		s.simdRegFile.WriteLane64(vd, uint8(i), a-b)
 	}
+	if count <= 1 {
+		s.simdRegFile.WriteLane64(vd, 1, 0)
+	}
 }
 
 // VMUL performs vector integer multiplication (element-wise).
@@ -212,129 +226,155 @@ func (s *SIMD) vmulWords(vd, vn, vm uint8, count int) {
 	}
 }
 
-// VFADD performs vector floating-point addition.
-func (s *SIMD) VFADD(vd, vn, vm uint8, arrangement SIMDArrangement) {
-	switch arrangement {
-	case Arr2S:
-		s.vfaddFloat32(vd, vn, vm, 2)
-	case Arr4S:
-		s.vfaddFloat32(vd, vn, vm, 4)
-	case Arr2D:
-		s.vfaddFloat64(vd, vn, vm, 2)
-	}
+// LDR128 loads a 128-bit Q register from memory.
+func (s *SIMD) LDR128(vd uint8, addr uint64) {
+	low := s.memory.Read64(addr)
+	high := s.memory.Read64(addr + 8)
+	s.simdRegFile.WriteQ(vd, low, high)
 }
 
-func (s *SIMD) vfaddFloat32(vd, vn, vm uint8, count int) {
-	for i := 0; i < count; i++ {
-		aBits := s.simdRegFile.ReadLane32(vn, uint8(i))
-		bBits := s.simdRegFile.ReadLane32(vm, uint8(i))
-		a := math.Float32frombits(aBits)
-		b := math.Float32frombits(bBits)
-		result := math.Float32bits(a + b)
-		s.simdRegFile.WriteLane32(vd, uint8(i), result)
-	}
-	if count <= 2 {
-		s.simdRegFile.WriteLane64(vd, 1, 0)
-	}
+// STR128 stores a 128-bit Q register to memory.
+func (s *SIMD) STR128(vd uint8, addr uint64) {
+	low, high := s.simdRegFile.ReadQ(vd)
+	s.memory.Write64(addr, low)
+	s.memory.Write64(addr+8, high)
 }
 
-func (s *SIMD) vfaddFloat64(vd, vn, vm uint8, count int) {
-	for i := 0; i < count; i++ {
-		aBits := s.simdRegFile.ReadLane64(vn, uint8(i))
-		bBits := s.simdRegFile.ReadLane64(vm, uint8(i))
-		a := math.Float64frombits(aBits)
-		b := math.Float64frombits(bBits)
-		result := math.Float64bits(a + b)
-		s.simdRegFile.WriteLane64(vd, uint8(i), result)
+// LDRFP loads a B, H, S, D or Q register of size bytes from memory, zeroing
+// the rest of the vector register. A size of 0 loads a Q register.
+func (s *SIMD) LDRFP(vd uint8, addr uint64, size uint8) {
+	switch size {
+	case 1:
+		s.simdRegFile.WriteB(vd, s.memory.Read8(addr))
+	case 2:
+		s.simdRegFile.WriteH(vd, s.memory.Read16(addr))
+	case 4:
+		s.simdRegFile.WriteS(vd, s.memory.Read32(addr))
+	case 8:
+		s.simdRegFile.WriteD(vd, s.memory.Read64(addr))
+	default:
+		s.LDR128(vd, addr)
 	}
 }
 
-// VFSUB performs vector floating-point subtraction.
-func (s *SIMD) VFSUB(vd, vn, vm uint8, arrangement SIMDArrangement) {
-	switch arrangement {
-	case Arr2S:
-		s.vfsubFloat32(vd, vn, vm, 2)
-	case Arr4S:
-		s.vfsubFloat32(vd, vn, vm, 4)
-	case Arr2D:
-		s.vfsubFloat64(vd, vn, vm, 2)
+// STRFP stores the low size bytes of vector register vd to memory. A size of
+// 0 stores the whole Q register.
+func (s *SIMD) STRFP(vd uint8, addr uint64, size uint8) {
+	switch size {
+	case 1:
+		s.memory.Write8(addr, s.simdRegFile.ReadB(vd))
+	case 2:
+		s.memory.Write16(addr, s.simdRegFile.ReadH(vd))
+	case 4:
+		s.memory.Write32(addr, s.simdRegFile.ReadS(vd))
+	case 8:
+		s.memory.Write64(addr, s.simdRegFile.ReadD(vd))
+	default:
+		s.STR128(vd, addr)
 	}
 }
 
-func (s *SIMD) vfsubFloat32(vd, vn, vm uint8, count int) {
-	for i := 0; i < count; i++ {
-		aBits := s.simdRegFile.ReadLane32(vn, uint8(i))
-		bBits := s.simdRegFile.ReadLane32(vm, uint8(i))
-		a := math.Float32frombits(aBits)
-		b := math.Float32frombits(bBits)
-		result := math.Float32bits(a - b)
-		s.simdRegFile.WriteLane32(vd, uint8(i), result)
-	}
-	if count <= 2 {
-		s.simdRegFile.WriteLane64(vd, 1, 0)
+// readElem reads an element of size bytes from memory.
+func (s *SIMD) readElem(addr uint64, size uint8) uint64 {
+	switch size {
+	case 1:
+		return uint64(s.memory.Read8(addr))
+	case 2:
+		return uint64(s.memory.Read16(addr))
+	case 4:
+		return uint64(s.memory.Read32(addr))
+	default:
+		return s.memory.Read64(addr)
 	}
 }
 
-func (s *SIMD) vfsubFloat64(vd, vn, vm uint8, count int) {
-	for i := 0; i < count; i++ {
-		aBits := s.simdRegFile.ReadLane64(vn, uint8(i))
-		bBits := s.simdRegFile.ReadLane64(vm, uint8(i))
-		a := math.Float64frombits(aBits)
-		b := math.Float64frombits(bBits)
-		result := math.Float64bits(a - b)
-		s.simdRegFile.WriteLane64(vd, uint8(i), result)
+// writeElem writes the low size bytes of value to memory.
+func (s *SIMD) writeElem(addr uint64, size uint8, value uint64) {
+	switch size {
+	case 1:
+		s.memory.Write8(addr, uint8(value))
+	case 2:
+		s.memory.Write16(addr, uint16(value))
+	case 4:
+		s.memory.Write32(addr, uint32(value))
+	default:
+		s.memory.Write64(addr, value)
 	}
 }
 
-// VFMUL performs vector floating-point multiplication.
-func (s *SIMD) VFMUL(vd, vn, vm uint8, arrangement SIMDArrangement) {
-	switch arrangement {
-	case Arr2S:
-		s.vfmulFloat32(vd, vn, vm, 2)
-	case Arr4S:
-		s.vfmulFloat32(vd, vn, vm, 4)
-	case Arr2D:
-		s.vfmulFloat64(vd, vn, vm, 2)
+// structLanes returns the number of size-byte elements in a 64-bit or, if
+// q is set, 128-bit vector.
+func structLanes(size uint8, q bool) uint8 {
+	if q {
+		return 16 / size
 	}
+	return 8 / size
 }
 
-func (s *SIMD) vfmulFloat32(vd, vn, vm uint8, count int) {
-	for i := 0; i < count; i++ {
-		aBits := s.simdRegFile.ReadLane32(vn, uint8(i))
-		bBits := s.simdRegFile.ReadLane32(vm, uint8(i))
-		a := math.Float32frombits(aBits)
-		b := math.Float32frombits(bBits)
-		result := math.Float32bits(a * b)
-		s.simdRegFile.WriteLane32(vd, uint8(i), result)
+// LDN loads regs vector registers starting at vt (wrapping from V31 to V0)
+// from consecutive memory, de-interleaving structures of selem elements of
+// size bytes into selem registers (LD1-LD4, multiple structures). 64-bit
+// vectors zero the upper half of each register.
+func (s *SIMD) LDN(vt uint8, addr uint64, regs, selem, size uint8, q bool) {
+	lanes := structLanes(size, q)
+	for r := uint8(0); r < regs; r += selem {
+		for e := uint8(0); e < lanes; e++ {
+			for i := uint8(0); i < selem; i++ {
+				s.simdRegFile.WriteElem((vt+r+i)%32, e, size, s.readElem(addr, size))
+				addr += uint64(size)
+			}
+		}
 	}
-	if count <= 2 {
-		s.simdRegFile.WriteLane64(vd, 1, 0)
+	if !q {
+		for r := uint8(0); r < regs; r++ {
+			s.simdRegFile.WriteLane64((vt+r)%32, 1, 0)
+		}
 	}
 }
 
-func (s *SIMD) vfmulFloat64(vd, vn, vm uint8, count int) {
-	for i := 0; i < count; i++ {
-		aBits := s.simdRegFile.ReadLane64(vn, uint8(i))
-		bBits := s.simdRegFile.ReadLane64(vm, uint8(i))
-		a := math.Float64frombits(aBits)
-		b := math.Float64frombits(bBits)
-		result := math.Float64bits(a * b)
-		s.simdRegFile.WriteLane64(vd, uint8(i), result)
+// STN stores regs vector registers starting at vt to consecutive memory,
+// interleaving the elements of each group of selem registers (ST1-ST4,
+// multiple structures).
+func (s *SIMD) STN(vt uint8, addr uint64, regs, selem, size uint8, q bool) {
+	lanes := structLanes(size, q)
+	for r := uint8(0); r < regs; r += selem {
+		for e := uint8(0); e < lanes; e++ {
+			for i := uint8(0); i < selem; i++ {
+				s.writeElem(addr, size, s.simdRegFile.ReadElem((vt+r+i)%32, e, size))
+				addr += uint64(size)
+			}
+		}
 	}
 }
 
-// LDR128 loads a 128-bit Q register from memory.
-func (s *SIMD) LDR128(vd uint8, addr uint64) {
-	low := s.memory.Read64(addr)
-	high := s.memory.Read64(addr + 8)
-	s.simdRegFile.WriteQ(vd, low, high)
+// LDNLane loads one structure of selem elements of size bytes into lane
+// of the selem registers starting at vt, leaving the other lanes unchanged.
+func (s *SIMD) LDNLane(vt uint8, addr uint64, selem, size, lane uint8) {
+	for i := uint8(0); i < selem; i++ {
+		s.simdRegFile.WriteElem((vt+i)%32, lane, size, s.readElem(addr+uint64(i*size), size))
+	}
 }
 
-// STR128 stores a 128-bit Q register to memory.
-func (s *SIMD) STR128(vd uint8, addr uint64) {
-	low, high := s.simdRegFile.ReadQ(vd)
-	s.memory.Write64(addr, low)
-	s.memory.Write64(addr+8, high)
+// STNLane stores lane of the selem registers starting at vt as one
+// structure.
+func (s *SIMD) STNLane(vt uint8, addr uint64, selem, size, lane uint8) {
+	for i := uint8(0); i < selem; i++ {
+		s.writeElem(addr+uint64(i*size), size, s.simdRegFile.ReadElem((vt+i)%32, lane, size))
+	}
+}
+
+// LDNR loads one structure of selem elements of size bytes and replicates
+// each element to every lane of its register (LD1R-LD4R).
+func (s *SIMD) LDNR(vt uint8, addr uint64, selem, size uint8, q bool) {
+	lanes := structLanes(size, q)
+	for i := uint8(0); i < selem; i++ {
+		reg := (vt + i) % 32
+		value := s.readElem(addr+uint64(i*size), size)
+		s.simdRegFile.WriteQ(reg, 0, 0)
+		for e := uint8(0); e < lanes; e++ {
+			s.simdRegFile.WriteElem(reg, e, size, value)
+		}
+	}
 }
 
 // DUP duplicates a scalar register value into all elements of a vector register.
@@ -0,0 +1,340 @@
+package emu
+
+import "math/bits"
+
+// aesSBox and aesInvSBox are the AES byte substitution table and its
+// inverse.
+var aesSBox, aesInvSBox = aesSBoxes()
+
+// gfMul multiplies a and b in GF(2^8) modulo the AES polynomial
+// x^8 + x^4 + x^3 + x + 1.
+func gfMul(a, b byte) byte {
+	var p byte
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1B
+		}
+	}
+	return p
+}
+
+// aesSBoxes builds the substitution tables from the multiplicative inverse
+// in GF(2^8) (x^254, which maps 0 to 0) followed by the affine transform.
+func aesSBoxes() (sbox, inv [256]byte) {
+	for x := 0; x < 256; x++ {
+		b, sq := byte(1), byte(x)
+		for e := 254; e != 0; e >>= 1 {
+			if e&1 != 0 {
+				b = gfMul(b, sq)
+			}
+			sq = gfMul(sq, sq)
+		}
+		s := b ^ bits.RotateLeft8(b, 1) ^ bits.RotateLeft8(b, 2) ^
+			bits.RotateLeft8(b, 3) ^ bits.RotateLeft8(b, 4) ^ 0x63
+		sbox[x], inv[s] = s, byte(x)
+	}
+	return sbox, inv
+}
+
+// aesState returns the bytes of a vector as an AES state, whose byte 4c+r
+// is row r of column c.
+func aesState(v vreg) (state [16]byte) {
+	for i := range state {
+		state[i] = byte(v.elem(uint8(i), 1))
+	}
+	return state
+}
+
+// aesVector returns an AES state as a vector.
+func aesVector(state [16]byte) (v vreg) {
+	for i, b := range state {
+		v.setElem(uint8(i), 1, uint64(b))
+	}
+	return v
+}
+
+// aesRound XORs the round key in vn into vd, then shifts row r of the
+// state left by r columns (right for the inverse) and substitutes each byte
+// through sbox.
+func (s *SIMD) aesRound(vd, vn uint8, sbox *[256]byte, inverse bool) {
+	d, n := s.vec(vd), s.vec(vn)
+	in := aesState(vreg{d[0] ^ n[0], d[1] ^ n[1]})
+	var out [16]byte
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			from := (c + r) % 4
+			if inverse {
+				from = (c - r + 4) % 4
+			}
+			out[4*c+r] = sbox[in[4*from+r]]
+		}
+	}
+	s.setVec(vd, aesVector(out), true)
+}
+
+// aesMix multiplies each column of the state in vn by the circulant matrix
+// whose first row is m.
+func (s *SIMD) aesMix(vd, vn uint8, m [4]byte) {
+	in := aesState(s.vec(vn))
+	var out [16]byte
+	for c := 0; c < 16; c += 4 {
+		for r := 0; r < 4; r++ {
+			for k := 0; k < 4; k++ {
+				out[c+r] ^= gfMul(m[(k-r+4)%4], in[c+k])
+			}
+		}
+	}
+	s.setVec(vd, aesVector(out), true)
+}
+
+// AESE performs an AES encryption round without MixColumns: AddRoundKey
+// with vn, ShiftRows and SubBytes.
+func (s *SIMD) AESE(vd, vn uint8) {
+	s.aesRound(vd, vn, &aesSBox, false)
+}
+
+// AESD performs an AES decryption round without InvMixColumns:
+// AddRoundKey with vn, InvShiftRows and InvSubBytes.
+func (s *SIMD) AESD(vd, vn uint8) {
+	s.aesRound(vd, vn, &aesInvSBox, true)
+}
+
+// AESMC performs AES MixColumns on vn.
+func (s *SIMD) AESMC(vd, vn uint8) {
+	s.aesMix(vd, vn, [4]byte{2, 3, 1, 1})
+}
+
+// AESIMC performs AES InvMixColumns on vn.
+func (s *SIMD) AESIMC(vd, vn uint8) {
+	s.aesMix(vd, vn, [4]byte{14, 11, 13, 9})
+}
+
+// words32 returns the four 32-bit elements of a vector.
+func words32(v vreg) [4]uint32 {
+	return [4]uint32{uint32(v[0]), uint32(v[0] >> 32), uint32(v[1]), uint32(v[1] >> 32)}
+}
+
+// vector32 returns four 32-bit elements as a vector.
+func vector32(w [4]uint32) vreg {
+	return vreg{uint64(w[1])<<32 | uint64(w[0]), uint64(w[3])<<32 | uint64(w[2])}
+}
+
+// SHA hash functions.
+func shaChoose(x, y, z uint32) uint32   { return (y^z)&x ^ z }
+func shaParity(x, y, z uint32) uint32   { return x ^ y ^ z }
+func shaMajority(x, y, z uint32) uint32 { return x&y | (x|y)&z }
+
+// sha1Hash performs four SHA1 rounds on the state abcd in vd and e in the
+// low word of vn, adding the schedule words in vm. f is the round function
+// (SHA1C, SHA1P or SHA1M).
+func (s *SIMD) sha1Hash(vd, vn, vm uint8, f func(x, y, z uint32) uint32) {
+	x, y, w := words32(s.vec(vd)), uint32(s.vec(vn)[0]), words32(s.vec(vm))
+	for e := 0; e < 4; e++ {
+		y += bits.RotateLeft32(x[0], 5) + f(x[1], x[2], x[3]) + w[e]
+		x[1] = bits.RotateLeft32(x[1], 30)
+		y, x = x[3], [4]uint32{y, x[0], x[1], x[2]}
+	}
+	s.setVec(vd, vector32(x), true)
+}
+
+// SHA1C performs four SHA1 rounds with the choose function.
+func (s *SIMD) SHA1C(vd, vn, vm uint8) { s.sha1Hash(vd, vn, vm, shaChoose) }
+
+// SHA1P performs four SHA1 rounds with the parity function.
+func (s *SIMD) SHA1P(vd, vn, vm uint8) { s.sha1Hash(vd, vn, vm, shaParity) }
+
+// SHA1M performs four SHA1 rounds with the majority function.
+func (s *SIMD) SHA1M(vd, vn, vm uint8) { s.sha1Hash(vd, vn, vm, shaMajority) }
+
+// SHA1H rotates the low word of vn left by 30 into the S register vd.
+func (s *SIMD) SHA1H(vd, vn uint8) {
+	s.setVec(vd, vreg{uint64(bits.RotateLeft32(uint32(s.vec(vn)[0]), 30))}, true)
+}
+
+// SHA1SU0 performs the first part of the SHA1 schedule update.
+func (s *SIMD) SHA1SU0(vd, vn, vm uint8) {
+	d, n, m := s.vec(vd), s.vec(vn), s.vec(vm)
+	s.setVec(vd, vreg{d[1] ^ d[0] ^ m[0], n[0] ^ d[1] ^ m[1]}, true)
+}
+
+// SHA1SU1 performs the second part of the SHA1 schedule update.
+func (s *SIMD) SHA1SU1(vd, vn uint8) {
+	d, n := s.vec(vd), s.vec(vn)
+	t := words32(vreg{d[0] ^ (n[0]>>32 | n[1]<<32), d[1] ^ n[1]>>32})
+	var w [4]uint32
+	for e := range w {
+		w[e] = bits.RotateLeft32(t[e], 1)
+	}
+	w[3] ^= bits.RotateLeft32(t[0], 2)
+	s.setVec(vd, vector32(w), true)
+}
+
+// sha256Hash performs four SHA256 rounds on the state halves x (abcd) and
+// y (efgh) with the schedule words w, returning x for part 1 (SHA256H) and
+// y for part 2 (SHA256H2).
+func sha256Hash(x, y, w [4]uint32, part1 bool) [4]uint32 {
+	for e := 0; e < 4; e++ {
+		sigma0 := bits.RotateLeft32(x[0], -2) ^ bits.RotateLeft32(x[0], -13) ^ bits.RotateLeft32(x[0], -22)
+		sigma1 := bits.RotateLeft32(y[0], -6) ^ bits.RotateLeft32(y[0], -11) ^ bits.RotateLeft32(y[0], -25)
+		t := y[3] + sigma1 + shaChoose(y[0], y[1], y[2]) + w[e]
+		x[3] += t
+		y[3] = t + sigma0 + shaMajority(x[0], x[1], x[2])
+		x, y = [4]uint32{y[3], x[0], x[1], x[2]}, [4]uint32{x[3], y[0], y[1], y[2]}
+	}
+	if part1 {
+		return x
+	}
+	return y
+}
+
+// SHA256H performs four SHA256 rounds and returns the updated abcd half
+// of the state, with abcd in vd and efgh in vn.
+func (s *SIMD) SHA256H(vd, vn, vm uint8) {
+	r := sha256Hash(words32(s.vec(vd)), words32(s.vec(vn)), words32(s.vec(vm)), true)
+	s.setVec(vd, vector32(r), true)
+}
+
+// SHA256H2 performs four SHA256 rounds and returns the updated efgh half
+// of the state, with efgh in vd and the original abcd in vn.
+func (s *SIMD) SHA256H2(vd, vn, vm uint8) {
+	r := sha256Hash(words32(s.vec(vn)), words32(s.vec(vd)), words32(s.vec(vm)), false)
+	s.setVec(vd, vector32(r), true)
+}
+
+// SHA256SU0 performs the first part of the SHA256 schedule update.
+func (s *SIMD) SHA256SU0(vd, vn uint8) {
+	d, n := s.vec(vd), s.vec(vn)
+	x, t := words32(d), words32(vreg{d[0]>>32 | d[1]<<32, d[1]>>32 | n[0]<<32})
+	var r [4]uint32
+	for e, elt := range t {
+		r[e] = x[e] + (bits.RotateLeft32(elt, -7) ^ bits.RotateLeft32(elt, -18) ^ elt>>3)
+	}
+	s.setVec(vd, vector32(r), true)
+}
+
+// SHA256SU1 performs the second part of the SHA256 schedule update.
+func (s *SIMD) SHA256SU1(vd, vn, vm uint8) {
+	d, n, m := s.vec(vd), s.vec(vn), s.vec(vm)
+	x, mw := words32(d), words32(m)
+	t0 := words32(vreg{n[0]>>32 | n[1]<<32, n[1]>>32 | m[0]<<32})
+	sigma1 := func(elt uint32) uint32 {
+		return bits.RotateLeft32(elt, -17) ^ bits.RotateLeft32(elt, -19) ^ elt>>10
+	}
+	var r [4]uint32
+	for e := 0; e < 2; e++ {
+		r[e] = sigma1(mw[e+2]) + x[e] + t0[e]
+	}
+	for e := 2; e < 4; e++ {
+		r[e] = sigma1(r[e-2]) + x[e] + t0[e]
+	}
+	s.setVec(vd, vector32(r), true)
+}
+
+// SHA512 sigma functions.
+func sha512Sigma1(x uint64) uint64 {
+	return bits.RotateLeft64(x, -14) ^ bits.RotateLeft64(x, -18) ^ bits.RotateLeft64(x, -41)
+}
+
+func sha512Sigma0(x uint64) uint64 {
+	return bits.RotateLeft64(x, -28) ^ bits.RotateLeft64(x, -34) ^ bits.RotateLeft64(x, -39)
+}
+
+// SHA512H performs the first part of two SHA512 rounds, adding the
+// schedule words in vd to the state words in vn and vm.
+func (s *SIMD) SHA512H(vd, vn, vm uint8) {
+	x, y, w := s.vec(vn), s.vec(vm), s.vec(vd)
+	var r vreg
+	r[1] = (y[1]&x[0] ^ ^y[1]&x[1]) + sha512Sigma1(y[1]) + w[1]
+	t := r[1] + y[0]
+	r[0] = (t&y[1] ^ ^t&x[0]) + sha512Sigma1(t) + w[0]
+	s.setVec(vd, r, true)
+}
+
+// SHA512H2 performs the second part of two SHA512 rounds.
+func (s *SIMD) SHA512H2(vd, vn, vm uint8) {
+	x, y, w := s.vec(vn), s.vec(vm), s.vec(vd)
+	var r vreg
+	r[1] = (x[0]&y[1] ^ x[0]&y[0] ^ y[1]&y[0]) + sha512Sigma0(y[0]) + w[1]
+	r[0] = (r[1]&y[0] ^ r[1]&y[1] ^ y[1]&y[0]) + sha512Sigma0(r[1]) + w[0]
+	s.setVec(vd, r, true)
+}
+
+// SHA512SU0 performs the first part of the SHA512 schedule update.
+func (s *SIMD) SHA512SU0(vd, vn uint8) {
+	w, x := s.vec(vd), s.vec(vn)
+	sig0 := func(v uint64) uint64 { return bits.RotateLeft64(v, -1) ^ bits.RotateLeft64(v, -8) ^ v>>7 }
+	s.setVec(vd, vreg{w[0] + sig0(w[1]), w[1] + sig0(x[0])}, true)
+}
+
+// SHA512SU1 performs the second part of the SHA512 schedule update.
+func (s *SIMD) SHA512SU1(vd, vn, vm uint8) {
+	w, x, y := s.vec(vd), s.vec(vn), s.vec(vm)
+	sig1 := func(v uint64) uint64 { return bits.RotateLeft64(v, -19) ^ bits.RotateLeft64(v, -61) ^ v>>6 }
+	s.setVec(vd, vreg{w[0] + sig1(x[0]) + y[0], w[1] + sig1(x[1]) + y[1]}, true)
+}
+
+// EOR3 writes vn XOR vm XOR va to vd.
+func (s *SIMD) EOR3(vd, vn, vm, va uint8) {
+	n, m, a := s.vec(vn), s.vec(vm), s.vec(va)
+	s.setVec(vd, vreg{n[0] ^ m[0] ^ a[0], n[1] ^ m[1] ^ a[1]}, true)
+}
+
+// BCAX writes vn XOR (vm AND NOT va) to vd.
+func (s *SIMD) BCAX(vd, vn, vm, va uint8) {
+	n, m, a := s.vec(vn), s.vec(vm), s.vec(va)
+	s.setVec(vd, vreg{n[0] ^ m[0]&^a[0], n[1] ^ m[1]&^a[1]}, true)
+}
+
+// RAX1 writes each doubleword of vn XOR the doubleword of vm rotated left
+// by one to vd.
+func (s *SIMD) RAX1(vd, vn, vm uint8) {
+	n, m := s.vec(vn), s.vec(vm)
+	s.setVec(vd, vreg{n[0] ^ bits.RotateLeft64(m[0], 1), n[1] ^ bits.RotateLeft64(m[1], 1)}, true)
+}
+
+// XAR writes each doubleword of vn XOR vm, rotated right by rotate, to vd.
+func (s *SIMD) XAR(vd, vn, vm uint8, rotate uint64) {
+	n, m := s.vec(vn), s.vec(vm)
+	r := -int(rotate)
+	s.setVec(vd, vreg{bits.RotateLeft64(n[0]^m[0], r), bits.RotateLeft64(n[1]^m[1], r)}, true)
+}
+
+// clmul returns the 128-bit carry-less product of a and b.
+func clmul(a, b uint64) (hi, lo uint64) {
+	for i := uint(0); i < 64; i++ {
+		if b>>i&1 != 0 {
+			lo ^= a << i
+			if i > 0 {
+				hi ^= a >> (64 - i)
+			}
+		}
+	}
+	return hi, lo
+}
+
+// VPMULL multiplies the bytes (arrangement 8H) or doublewords (1Q) of the
+// lower or upper halves of vn and vm as polynomials over GF(2) into
+// elements of twice the size (PMULL, PMULL2).
+func (s *SIMD) VPMULL(vd, vn, vm uint8, arrangement SIMDArrangement, upper bool) {
+	n, m := s.vec(vn), s.vec(vm)
+	half := 0
+	if upper {
+		half = 1
+	}
+	if arrangement == Arr1Q {
+		hi, lo := clmul(n[half], m[half])
+		s.setVec(vd, vreg{lo, hi}, true)
+		return
+	}
+	var r vreg
+	for i := uint8(0); i < 8; i++ {
+		_, p := clmul(n.elem(i+8*uint8(half), 1), m.elem(i+8*uint8(half), 1))
+		r.setElem(i, 2, p)
+	}
+	s.setVec(vd, r, true)
+}
@@ -0,0 +1,195 @@
+package emu_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+// sha256K are the SHA-256 round constants.
+var sha256K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+var _ = Describe("Cryptographic Instructions", func() {
+	var (
+		e *emu.Emulator
+		v *emu.SIMDRegFile
+	)
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+		v = e.SIMDRegFile()
+	})
+
+	// writeWords writes four 32-bit words to a vector register.
+	writeWords := func(reg uint8, w []uint32) {
+		v.WriteQ(reg, uint64(w[1])<<32|uint64(w[0]), uint64(w[3])<<32|uint64(w[2]))
+	}
+
+	// readWords returns the four 32-bit words of a vector register.
+	readWords := func(reg uint8) []uint32 {
+		low, high := v.ReadQ(reg)
+		return []uint32{uint32(low), uint32(low >> 32), uint32(high), uint32(high >> 32)}
+	}
+
+	// loadBlock pads msg into a single 64-byte block and writes it as
+	// big-endian words to V16-V19.
+	loadBlock := func(msg string) {
+		block := make([]byte, 64)
+		copy(block, msg)
+		block[len(msg)] = 0x80
+		binary.BigEndian.PutUint64(block[56:], uint64(len(msg))*8)
+		for i := 0; i < 16; i += 4 {
+			w := make([]uint32, 4)
+			for j := range w {
+				w[j] = binary.BigEndian.Uint32(block[4*(i+j):])
+			}
+			writeWords(uint8(16+i/4), w)
+		}
+	}
+
+	Describe("AES", func() {
+		// The first round of the FIPS-197 Appendix B example.
+		It("should perform an encryption round and mix columns", func() {
+			v.WriteQ(0, 0x8D305A88A8F64332, 0x340737E0A2983131)
+			v.WriteQ(1, 0xA6D2AE2816157E2B, 0x3C4FCF098815F7AB)
+			runAsm(e, "aese v0.16b, v1.16b; aesmc v2.16b, v0.16b")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xAE52B4E0305DBFD4, 0xE598271EF11141B8}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0x9A19CBE0E5816604, 0x4C2606287AD3F848}))
+		})
+
+		It("should invert a round with AESIMC and AESD", func() {
+			v.WriteQ(2, 0x9A19CBE0E5816604, 0x4C2606287AD3F848)
+			runAsm(e, "aesimc v0.16b, v2.16b; aesd v0.16b, v1.16b")
+
+			// With a zero key AESD recovers the state XORed with the round key.
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x2BE2F4A0BEE33D19, 0x0848F8E92A8DC69A}))
+		})
+	})
+
+	Describe("SHA", func() {
+		It("should compute a SHA-256 block", func() {
+			msg := "The quick brown fox"
+			h := []uint32{0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19}
+			writeWords(0, h[0:4])
+			writeWords(1, h[4:8])
+			loadBlock(msg)
+
+			for g := 0; g < 16; g++ {
+				writeWords(4, sha256K[4*g:])
+				w := 16 + g%4
+				src := fmt.Sprintf(`add v5.4s, v%d.4s, v4.4s
+					mov v2.16b, v0.16b
+					sha256h q0, q1, v5.4s
+					sha256h2 q1, q2, v5.4s`, w)
+				if g < 12 {
+					src += fmt.Sprintf("\nsha256su0 v%d.4s, v%d.4s\nsha256su1 v%d.4s, v%d.4s, v%d.4s",
+						w, 16+(g+1)%4, w, 16+(g+2)%4, 16+(g+3)%4)
+				}
+				runAsm(e, src)
+			}
+
+			digest := make([]byte, 32)
+			for i, w := range append(readWords(0), readWords(1)...) {
+				binary.BigEndian.PutUint32(digest[4*i:], w+h[i])
+			}
+			want := sha256.Sum256([]byte(msg))
+			Expect(digest).To(Equal(want[:]))
+		})
+
+		It("should compute a SHA-1 block", func() {
+			msg := "The quick brown fox"
+			h := []uint32{0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476, 0xC3D2E1F0}
+			writeWords(0, h[0:4])
+			v.WriteQ(1, uint64(h[4]), 0)
+			loadBlock(msg)
+
+			k := []uint32{0x5A827999, 0x6ED9EBA1, 0x8F1BBCDC, 0xCA62C1D6}
+			hash := []string{"sha1c", "sha1p", "sha1m", "sha1p"}
+			e0, e1 := 1, 2
+			for g := 0; g < 20; g++ {
+				writeWords(4, []uint32{k[g/5], k[g/5], k[g/5], k[g/5]})
+				w := 16 + g%4
+				src := fmt.Sprintf(`add v5.4s, v%d.4s, v4.4s
+					sha1h s%d, s0
+					%s q0, s%d, v5.4s`, w, e1, hash[g/5], e0)
+				if g < 16 {
+					src += fmt.Sprintf("\nsha1su0 v%d.4s, v%d.4s, v%d.4s\nsha1su1 v%d.4s, v%d.4s",
+						w, 16+(g+1)%4, 16+(g+2)%4, w, 16+(g+3)%4)
+				}
+				runAsm(e, src)
+				e0, e1 = e1, e0
+			}
+
+			digest := make([]byte, 20)
+			for i, w := range append(readWords(0), readWords(uint8(e0))[0]) {
+				binary.BigEndian.PutUint32(digest[4*i:], w+h[i])
+			}
+			want := sha1.Sum([]byte(msg))
+			Expect(digest).To(Equal(want[:]))
+		})
+
+		It("should update the SHA-512 state and schedule", func() {
+			v.WriteQ(1, 0x0123456789ABCDEF, 0xFEDCBA9876543210)
+			v.WriteQ(2, 0x1111111122222222, 0x3333333344444444)
+			for _, reg := range []uint8{0, 3, 4, 5} {
+				v.WriteQ(reg, 0x5555555566666666, 0x7777777788888888)
+			}
+			runAsm(e, `sha512h q0, q1, v2.2d
+				sha512h2 q3, q1, v2.2d
+				sha512su0 v4.2d, v1.2d
+				sha512su1 v5.2d, v1.2d, v2.2d`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xA48DDC5D1389B4D5, 0xDF0AE344DCB15875}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xEA7F1C2410DAC154, 0xD19977985A224462}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x08777777C3444443, 0xE70A3EF3F4D7A329}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0xD709AC74445CBA02, 0x1E07649D10F89B51}))
+		})
+	})
+
+	Describe("SHA3", func() {
+		It("should combine and rotate doublewords", func() {
+			v.WriteQ(1, 0xFF00FF00FF00FF00, 0x8000000000000001)
+			v.WriteQ(2, 0x0F0F0F0F0F0F0F0F, 0x8000000000000000)
+			v.WriteQ(3, 0x3333333333333333, 0x0000000000000001)
+			runAsm(e, `eor3 v0.16b, v1.16b, v2.16b, v3.16b
+				bcax v4.16b, v1.16b, v2.16b, v3.16b
+				rax1 v5.2d, v1.2d, v2.2d
+				xar v6.2d, v1.2d, v2.2d, #4`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xC33CC33CC33CC33C, 0x0000000000000000}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0xF30CF30CF30CF30C, 0x0000000000000001}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0xE11EE11EE11EE11E, 0x8000000000000000}))
+			Expect(readQ(v, 6)).To(Equal([2]uint64{0xFF00FF00FF00FF00, 0x1000000000000000}))
+		})
+	})
+
+	Describe("Polynomial multiply", func() {
+		It("should multiply bytes and doublewords carry-lessly", func() {
+			v.WriteQ(1, 0x00000000000003FF, 0xFFFFFFFFFFFFFFFF)
+			v.WriteQ(2, 0x00000000000003FF, 0xFFFFFFFFFFFFFFFF)
+			runAsm(e, `pmull v0.8h, v1.8b, v2.8b
+				pmull2 v3.1q, v1.2d, v2.2d
+				pmull v4.1q, v1.1d, v2.1d`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0000000000055555, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x5555555555555555, 0x5555555555555555}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x0000000000055555, 0}))
+		})
+	})
+})
@@ -0,0 +1,405 @@
+package emu
+
+// Vector floating-point operations. Elements are processed lane by lane
+// with the scalar FPU arithmetic, so results are rounded according to FPCR
+// and exceptions accumulate in FPSR. The element size of the arrangement
+// selects half (4H, 8H), single (2S, 4S) or double (2D) precision. The
+// Advanced SIMD scalar forms use the one-element arrangements (1H, 1S,
+// 1D), so only element 0 is computed and the rest of vd is zeroed.
+
+// fpTypeOf returns the precision of size-byte elements.
+func fpTypeOf(size uint8) FPType {
+	switch size {
+	case 2:
+		return FPHalf
+	case 4:
+		return FPSingle
+	default:
+		return FPDouble
+	}
+}
+
+// fpLanewise applies f to each lane of vd, vn and vm and writes the results
+// to vd.
+func (s *SIMD) fpLanewise(vd, vn, vm uint8, arrangement SIMDArrangement,
+	f func(t FPType, d, n, m uint64) uint64) {
+	s.lanewise(vd, vn, vm, arrangement, func(d, n, m uint64, size uint8) uint64 {
+		return f(fpTypeOf(size), d, n, m)
+	})
+}
+
+// fpUnary applies f to each lane of vn and writes the results to vd.
+func (s *SIMD) fpUnary(vd, vn uint8, arrangement SIMDArrangement, f func(t FPType, n uint64) uint64) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 {
+		return f(fpTypeOf(size), n)
+	})
+}
+
+// fpPairwise applies f to adjacent pairs of elements of the concatenation
+// vm:vn.
+func (s *SIMD) fpPairwise(vd, vn, vm uint8, arrangement SIMDArrangement, f func(t FPType, a, b uint64) uint64) {
+	s.pairwise(vd, vn, vm, arrangement, func(a, b uint64, size uint8) uint64 {
+		return f(fpTypeOf(size), a, b)
+	})
+}
+
+// fpByElement applies f to each lane of vd and vn and element lane of vm,
+// and writes the results to vd.
+func (s *SIMD) fpByElement(vd, vn, vm, lane uint8, arrangement SIMDArrangement,
+	f func(t FPType, d, n, m uint64) uint64) {
+	size, _ := laneShape(arrangement)
+	m := s.simdRegFile.ReadElem(vm, lane, size)
+	s.fpLanewise(vd, vn, vd, arrangement, func(t FPType, d, n, _ uint64) uint64 {
+		return f(t, d, n, m)
+	})
+}
+
+// fpReduce folds the lanes of vn with f, pairing adjacent elements and
+// then adjacent results, and writes the result to the low element of vd,
+// zeroing the rest of the register.
+func (s *SIMD) fpReduce(vd, vn uint8, arrangement SIMDArrangement, f func(t FPType, a, b uint64) uint64) {
+	size, lanes := laneShape(arrangement)
+	t := fpTypeOf(size)
+	v := s.vec(vn)
+	for ; lanes > 1; lanes /= 2 {
+		var r vreg
+		for i := uint8(0); i < lanes/2; i++ {
+			r.setElem(i, size, f(t, v.elem(2*i, size), v.elem(2*i+1, size)))
+		}
+		v = r
+	}
+	s.setVec(vd, v, true)
+}
+
+// fpCompare returns an all-ones mask if a and b compare as accepted by
+// want. signaling compares raise Invalid Operation for quiet NaNs too.
+func (s *SIMD) fpCompare(t FPType, a, b uint64, signaling bool, want func(c int) bool) uint64 {
+	c, ordered := s.fpu.compare(t, a, b, signaling)
+	return mask(ordered && want(c))
+}
+
+func fpEQ(c int) bool { return c == 0 }
+func fpGE(c int) bool { return c >= 0 }
+func fpGT(c int) bool { return c > 0 }
+
+// fpAbs clears the sign bit of an encoding of precision t.
+func fpAbs(t FPType, bits uint64) uint64 {
+	return bits &^ formatOf(t).signBit()
+}
+
+// fpNeg flips the sign bit of an encoding of precision t.
+func fpNeg(t FPType, bits uint64) uint64 {
+	return bits ^ formatOf(t).signBit()
+}
+
+// VFADD performs vector floating-point addition.
+func (s *SIMD) VFADD(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.compute(fpOpAdd, t, n, m)
+	})
+}
+
+// VFSUB performs vector floating-point subtraction.
+func (s *SIMD) VFSUB(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.compute(fpOpSub, t, n, m)
+	})
+}
+
+// VFMUL performs vector floating-point multiplication.
+func (s *SIMD) VFMUL(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.compute(fpOpMul, t, n, m)
+	})
+}
+
+// VFDIV performs vector floating-point division.
+func (s *SIMD) VFDIV(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.compute(fpOpDiv, t, n, m)
+	})
+}
+
+// VFMLA computes vd += vn * vm with a single rounding per lane.
+func (s *SIMD) VFMLA(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, d, n, m uint64) uint64 {
+		return s.fpu.mulAdd(t, d, n, m)
+	})
+}
+
+// VFMLS computes vd -= vn * vm with a single rounding per lane.
+func (s *SIMD) VFMLS(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, d, n, m uint64) uint64 {
+		return s.fpu.mulAdd(t, d, fpNeg(t, n), m)
+	})
+}
+
+// VFMLAElem computes vd += vn * vm[lane] (FMLA by element).
+func (s *SIMD) VFMLAElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement) {
+	s.fpByElement(vd, vn, vm, lane, arrangement, func(t FPType, d, n, m uint64) uint64 {
+		return s.fpu.mulAdd(t, d, n, m)
+	})
+}
+
+// VFMLSElem computes vd -= vn * vm[lane] (FMLS by element).
+func (s *SIMD) VFMLSElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement) {
+	s.fpByElement(vd, vn, vm, lane, arrangement, func(t FPType, d, n, m uint64) uint64 {
+		return s.fpu.mulAdd(t, d, fpNeg(t, n), m)
+	})
+}
+
+// VFMULElem computes vd = vn * vm[lane] (FMUL by element).
+func (s *SIMD) VFMULElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement) {
+	s.fpByElement(vd, vn, vm, lane, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.compute(fpOpMul, t, n, m)
+	})
+}
+
+// VFMAX performs vector floating-point maximum. number selects FMAXNM,
+// where a quiet NaN loses to a number.
+func (s *SIMD) VFMAX(vd, vn, vm uint8, arrangement SIMDArrangement, number bool) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.maxMin(t, n, m, true, number)
+	})
+}
+
+// VFMIN performs vector floating-point minimum. number selects FMINNM.
+func (s *SIMD) VFMIN(vd, vn, vm uint8, arrangement SIMDArrangement, number bool) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.maxMin(t, n, m, false, number)
+	})
+}
+
+// VFABD computes the absolute difference |vn - vm| of each lane.
+func (s *SIMD) VFABD(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return fpAbs(t, s.fpu.compute(fpOpSub, t, n, m))
+	})
+}
+
+// VFADDP adds adjacent pairs of elements of vn and vm.
+func (s *SIMD) VFADDP(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpPairwise(vd, vn, vm, arrangement, func(t FPType, a, b uint64) uint64 {
+		return s.fpu.compute(fpOpAdd, t, a, b)
+	})
+}
+
+// VFMAXP takes the maximum of adjacent pairs of elements of vn and vm.
+// number selects FMAXNMP.
+func (s *SIMD) VFMAXP(vd, vn, vm uint8, arrangement SIMDArrangement, number bool) {
+	s.fpPairwise(vd, vn, vm, arrangement, func(t FPType, a, b uint64) uint64 {
+		return s.fpu.maxMin(t, a, b, true, number)
+	})
+}
+
+// VFMINP takes the minimum of adjacent pairs of elements of vn and vm.
+// number selects FMINNMP.
+func (s *SIMD) VFMINP(vd, vn, vm uint8, arrangement SIMDArrangement, number bool) {
+	s.fpPairwise(vd, vn, vm, arrangement, func(t FPType, a, b uint64) uint64 {
+		return s.fpu.maxMin(t, a, b, false, number)
+	})
+}
+
+// VFCMEQ sets each lane to all ones if vn equals vm.
+func (s *SIMD) VFCMEQ(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpCompare(t, n, m, false, fpEQ)
+	})
+}
+
+// VFCMGE sets each lane to all ones if vn is greater than or equal to vm.
+func (s *SIMD) VFCMGE(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpCompare(t, n, m, true, fpGE)
+	})
+}
+
+// VFCMGT sets each lane to all ones if vn is greater than vm.
+func (s *SIMD) VFCMGT(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpCompare(t, n, m, true, fpGT)
+	})
+}
+
+// VFACGE sets each lane to all ones if |vn| is greater than or equal to
+// |vm|.
+func (s *SIMD) VFACGE(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpCompare(t, fpAbs(t, n), fpAbs(t, m), true, fpGE)
+	})
+}
+
+// VFACGT sets each lane to all ones if |vn| is greater than |vm|.
+func (s *SIMD) VFACGT(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpCompare(t, fpAbs(t, n), fpAbs(t, m), true, fpGT)
+	})
+}
+
+// VFCMEQZ sets each lane to all ones if vn equals zero.
+func (s *SIMD) VFCMEQZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpCompare(t, n, 0, false, fpEQ)
+	})
+}
+
+// VFCMGEZ sets each lane to all ones if vn is greater than or equal to
+// zero.
+func (s *SIMD) VFCMGEZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpCompare(t, n, 0, true, fpGE)
+	})
+}
+
+// VFCMGTZ sets each lane to all ones if vn is greater than zero.
+func (s *SIMD) VFCMGTZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpCompare(t, n, 0, true, fpGT)
+	})
+}
+
+// VFCMLEZ sets each lane to all ones if vn is less than or equal to zero.
+func (s *SIMD) VFCMLEZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpCompare(t, 0, n, true, fpGE)
+	})
+}
+
+// VFCMLTZ sets each lane to all ones if vn is less than zero.
+func (s *SIMD) VFCMLTZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpCompare(t, 0, n, true, fpGT)
+	})
+}
+
+// VFABS computes the absolute value of each lane without raising
+// exceptions.
+func (s *SIMD) VFABS(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, fpAbs)
+}
+
+// VFNEG negates each lane without raising exceptions.
+func (s *SIMD) VFNEG(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, fpNeg)
+}
+
+// VFSQRT computes the square root of each lane.
+func (s *SIMD) VFSQRT(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpu.compute(fpOpSqrt, t, n, n)
+	})
+}
+
+// VFRINT rounds each lane to an integral value using mode (FRINTN, FRINTA,
+// FRINTP, FRINTM, FRINTZ, and FRINTI and FRINTX with the FPCR mode). exact
+// selects FRINTX, which raises Inexact when a value changes.
+func (s *SIMD) VFRINT(vd, vn uint8, arrangement SIMDArrangement, mode FPRounding, exact bool) {
+	s.fpUnary(vd, vn, arrangement, func(t FPType, n uint64) uint64 {
+		return s.fpu.roundToIntegral(t, n, mode, exact)
+	})
+}
+
+// VFRECPE estimates the reciprocal of each lane to 8 bits.
+func (s *SIMD) VFRECPE(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, s.fpu.recipEstimate)
+}
+
+// VFRSQRTE estimates the reciprocal square root of each lane to 8 bits.
+func (s *SIMD) VFRSQRTE(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpUnary(vd, vn, arrangement, s.fpu.recipSqrtEstimate)
+}
+
+// VFRECPS computes the reciprocal step 2 - vn*vm with a single rounding
+// per lane.
+func (s *SIMD) VFRECPS(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.recipStep(t, n, m, false)
+	})
+}
+
+// VFRSQRTS computes the reciprocal square root step (3 - vn*vm)/2 with a
+// single rounding per lane.
+func (s *SIMD) VFRSQRTS(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.fpLanewise(vd, vn, vm, arrangement, func(t FPType, _, n, m uint64) uint64 {
+		return s.fpu.recipStep(t, n, m, true)
+	})
+}
+
+// VIntToFP converts each lane from a signed or unsigned integer of the
+// element size to floating point (SCVTF, UCVTF).
+func (s *SIMD) VIntToFP(vd, vn uint8, arrangement SIMDArrangement, signed bool) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 {
+		return s.fpu.fromInt(fpTypeOf(size), n, 8*uint(size), signed, 0)
+	})
+}
+
+// VFPToInt converts each lane to a signed or unsigned integer of the
+// element size using mode, saturating out-of-range values (FCVTNS, FCVTZU,
+// ...).
+func (s *SIMD) VFPToInt(vd, vn uint8, arrangement SIMDArrangement, signed bool, mode FPRounding) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 {
+		return s.fpu.toInt(fpTypeOf(size), n, 8*uint(size), signed, 0, mode)
+	})
+}
+
+// VFCVTL converts the elements in the lower half of vn, or the upper half
+// if upper is set (FCVTL2), to the twice as wide elements of arrangement.
+func (s *SIMD) VFCVTL(vd, vn uint8, arrangement SIMDArrangement, upper bool) {
+	size, lanes := laneShape(arrangement)
+	half := size / 2
+	from, to := formatOf(fpTypeOf(half)), formatOf(fpTypeOf(size))
+	off := uint8(0)
+	if upper {
+		off = lanes
+	}
+	n := s.vec(vn)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		r.setElem(i, size, s.fpu.convert(from, to, n.elem(i+off, half)))
+	}
+	s.setVec(vd, r, true)
+}
+
+// VFCVTN converts the elements of vn to the half as wide elements of
+// arrangement, writing the lower half of vd, or the upper half if the
+// arrangement is 128-bit (FCVTN2).
+func (s *SIMD) VFCVTN(vd, vn uint8, arrangement SIMDArrangement) {
+	s.narrow(vd, vn, arrangement, func(n uint64, size uint8) uint64 {
+		return s.fpu.convert(formatOf(fpTypeOf(size)), formatOf(fpTypeOf(size/2)), n)
+	})
+}
+
+// VFMAXV writes the maximum lane of vn to the scalar vd. number selects
+// FMAXNMV.
+func (s *SIMD) VFMAXV(vd, vn uint8, arrangement SIMDArrangement, number bool) {
+	s.fpReduce(vd, vn, arrangement, func(t FPType, a, b uint64) uint64 {
+		return s.fpu.maxMin(t, a, b, true, number)
+	})
+}
+
+// VFMINV writes the minimum lane of vn to the scalar vd. number selects
+// FMINNMV.
+func (s *SIMD) VFMINV(vd, vn uint8, arrangement SIMDArrangement, number bool) {
+	s.fpReduce(vd, vn, arrangement, func(t FPType, a, b uint64) uint64 {
+		return s.fpu.maxMin(t, a, b, false, number)
+	})
+}
+
+// FADDP adds the two elements of vn into the scalar vd.
+func (s *SIMD) FADDP(vd, vn uint8, arrangement SIMDArrangement) {
+	s.fpReduce(vd, vn, arrangement, func(t FPType, a, b uint64) uint64 {
+		return s.fpu.compute(fpOpAdd, t, a, b)
+	})
+}
+
+// FMAXP writes the maximum of the two elements of vn to the scalar vd.
+// number selects FMAXNMP.
+func (s *SIMD) FMAXP(vd, vn uint8, arrangement SIMDArrangement, number bool) {
+	s.VFMAXV(vd, vn, arrangement, number)
+}
+
+// FMINP writes the minimum of the two elements of vn to the scalar vd.
+// number selects FMINNMP.
+func (s *SIMD) FMINP(vd, vn uint8, arrangement SIMDArrangement, number bool) {
+	s.VFMINV(vd, vn, arrangement, number)
+}
@@ -0,0 +1,245 @@
+package emu_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("NEON Floating-Point Instructions", func() {
+	var (
+		e *emu.Emulator
+		v *emu.SIMDRegFile
+	)
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+		v = e.SIMDRegFile()
+	})
+
+	// s packs two single-precision values into a 64-bit half of a vector.
+	s := func(lo, hi float32) uint64 {
+		return uint64(math.Float32bits(hi))<<32 | uint64(math.Float32bits(lo))
+	}
+
+	d := math.Float64bits
+	negZero := float32(math.Copysign(0, -1))
+	qNaN := s(float32(math.NaN()), 1)
+
+	Describe("Arithmetic", func() {
+		It("should operate on single and double precision lanes", func() {
+			v.WriteQ(1, s(1.5, -2), s(10, 0.25))
+			v.WriteQ(2, s(2, 4), s(4, 0.5))
+			runAsm(e, `fadd v0.4s, v1.4s, v2.4s
+				fmul v3.4s, v1.4s, v2.4s
+				fdiv v4.4s, v1.4s, v2.4s
+				fabd v5.2s, v1.2s, v2.2s`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(3.5, 2), s(14, 0.75)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{s(3, -8), s(40, 0.125)}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{s(0.75, -0.5), s(2.5, 0.5)}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{s(0.5, 6), 0}))
+
+			v.WriteQ(1, d(1.0/3), d(-1e300))
+			v.WriteQ(2, d(3), d(1e300))
+			runAsm(e, "fmul v0.2d, v1.2d, v2.2d")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{d(1), d(math.Inf(-1))}))
+			Expect(v.FPSR & emu.FPSROFC).NotTo(BeZero())
+		})
+
+		It("should operate on half-precision lanes", func() {
+			// 1.0, 2.0, -0.5, 65504 (the largest half) in each half
+			v.WriteQ(1, 0x7BFFB8004000_3C00, 0)
+			v.WriteQ(2, 0x7BFF3C003C00_4000, 0)
+			runAsm(e, "fadd v0.4h, v1.4h, v2.4h")
+
+			// 3.0, 3.0, 0.5, +Inf
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x7C0038004200_4200, 0}))
+		})
+
+		It("should fuse multiply-accumulate by vector and by element", func() {
+			v.WriteQ(0, s(1, 1), s(1, 1))
+			v.WriteQ(1, s(2, 3), s(4, 5))
+			v.WriteQ(2, s(0, 0), s(0, 10))
+			runAsm(e, "fmla v0.4s, v1.4s, v2.s[3]; fmls v3.4s, v1.4s, v1.4s")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(21, 31), s(41, 51)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{s(-4, -9), s(-16, -25)}))
+		})
+
+		It("should pick numbers over quiet NaNs only for the NM forms", func() {
+			v.WriteQ(1, qNaN, s(negZero, 3))
+			v.WriteQ(2, s(5, 2), s(0, 7))
+			runAsm(e, "fmax v0.4s, v1.4s, v2.4s; fminnm v3.4s, v1.4s, v2.4s")
+
+			Expect(readQ(v, 0)[0] & 0xFFFFFFFF).To(Equal(uint64(0x7FC00000)))
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(float32(math.NaN()), 2), s(0, 7)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{s(5, 1), s(negZero, 3)}))
+		})
+	})
+
+	Describe("Pairwise and reductions", func() {
+		It("should add adjacent pairs and reduce across lanes", func() {
+			v.WriteQ(1, s(1, 2), s(3, 4))
+			v.WriteQ(2, s(10, 20), s(30, 40))
+			runAsm(e, `faddp v0.4s, v1.4s, v2.4s
+				faddp s3, v1.2s
+				fmaxv s4, v2.4s
+				fminnmp d5, v6.2d`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(3, 7), s(30, 70)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{uint64(math.Float32bits(3)), 0}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{uint64(math.Float32bits(40)), 0}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0, 0}))
+		})
+	})
+
+	Describe("Compares", func() {
+		It("should produce all-ones masks and false for unordered lanes", func() {
+			v.WriteQ(1, qNaN, s(-1, 2))
+			v.WriteQ(2, s(0, 1), s(-1, 3))
+			runAsm(e, `fcmge v0.4s, v1.4s, v2.4s
+				fcmeq v3.4s, v1.4s, v2.4s
+				facgt v4.4s, v1.4s, v2.4s
+				fcmlt v5.4s, v1.4s, #0.0`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xFFFFFFFF00000000, 0x00000000FFFFFFFF}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xFFFFFFFF00000000, 0x00000000FFFFFFFF}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0, 0}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0, 0x00000000FFFFFFFF}))
+			Expect(v.FPSR & emu.FPSRIOC).NotTo(BeZero())
+		})
+	})
+
+	Describe("Rounding and conversions", func() {
+		It("should round to integral values with each mode", func() {
+			v.WriteQ(1, s(2.5, -2.5), s(1.25, -0.75))
+			runAsm(e, `frintn v0.4s, v1.4s
+				frinta v2.4s, v1.4s
+				frintm v3.4s, v1.4s
+				frintz v4.4s, v1.4s`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(2, -2), s(1, -1)}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{s(3, -3), s(1, -1)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{s(2, -3), s(1, -1)}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{s(2, -2), s(1, negZero)}))
+		})
+
+		It("should use the FPCR rounding mode for FRINTI and FRINTX", func() {
+			v.FPCR = uint64(emu.FPRoundPlusInf) << emu.FPCRRModeShift
+			v.WriteQ(1, d(1.5), d(-1.5))
+			runAsm(e, "frinti v0.2d, v1.2d")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{d(2), d(-1)}))
+			Expect(v.FPSR & emu.FPSRIXC).To(BeZero())
+
+			runAsm(e, "frintx v0.2d, v1.2d")
+			Expect(v.FPSR & emu.FPSRIXC).NotTo(BeZero())
+		})
+
+		It("should convert between integers and floating point", func() {
+			v.WriteQ(1, s(-1.5, 3e9), s(float32(math.NaN()), 7.9))
+			v.WriteQ(2, 0x00000005FFFFFFFE, 0)
+			runAsm(e, `fcvtzs v0.4s, v1.4s
+				fcvtnu v3.4s, v1.4s
+				scvtf v4.2s, v2.2s
+				ucvtf v5.2s, v2.2s`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x7FFFFFFFFFFFFFFF, 0x0000000700000000}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xB2D05E0000000000, 0x0000000800000000}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{s(-2, 5), 0}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{s(4294967294, 5), 0}))
+			Expect(v.FPSR & emu.FPSRIOC).NotTo(BeZero())
+		})
+
+		It("should widen and narrow between precisions", func() {
+			v.WriteQ(1, s(1, 2), s(-0.5, 3))
+			runAsm(e, "fcvtl2 v0.2d, v1.4s; fcvtl v2.2d, v1.2s")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{d(-0.5), d(3)}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{d(1), d(2)}))
+
+			v.WriteQ(3, 0xAAAA, 0xBBBB)
+			runAsm(e, "fcvtn2 v3.4s, v0.2d")
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xAAAA, s(-0.5, 3)}))
+
+			runAsm(e, "fcvtn v4.4h, v1.4s")
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x4200B80040003C00, 0}))
+		})
+
+		It("should move floating-point immediates into every lane", func() {
+			v.WriteQ(0, 1, 1)
+			runAsm(e, "fmov v0.2s, #-1.25; fmov v1.2d, #0.5")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(-1.25, -1.25), 0}))
+			Expect(readQ(v, 1)).To(Equal([2]uint64{d(0.5), d(0.5)}))
+		})
+	})
+
+	Describe("Scalar forms", func() {
+		It("should operate on element 0 and zero the rest of the register", func() {
+			v.WriteQ(0, s(1, 77), d(77))
+			v.WriteQ(1, s(3, 99), d(99))
+			v.WriteQ(2, s(1, 2), s(5, 7))
+			v.WriteQ(4, d(1.5), d(99))
+			v.WriteQ(5, d(99), d(-4))
+			runAsm(e, "fmla s0, s1, v2.s[2]; fmul d3, d4, v5.d[1]")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(16, 0), 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{d(-6), 0}))
+		})
+
+		It("should convert only element 0", func() {
+			// The upper lane would raise Inexact if it were converted.
+			v.WriteQ(1, uint64(math.MaxUint64-2), 1<<60+1)
+			v.WriteQ(3, d(-2.5), d(math.NaN()))
+			v.WriteQ(5, s(-1, float32(math.NaN())), 0)
+			runAsm(e, "scvtf d0, d1; fcvtzs d2, d3; fcmgt s4, s5, #0.0")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{d(-3), 0}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{uint64(math.MaxUint64 - 1), 0}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0, 0}))
+			Expect(v.FPSR & (emu.FPSRIXC | emu.FPSRIOC)).To(Equal(emu.FPSRIXC))
+		})
+	})
+
+	Describe("Reciprocal estimates and steps", func() {
+		It("should estimate reciprocals to 8 bits as the architecture does", func() {
+			v.WriteQ(1, s(1, 3), s(0, float32(math.Inf(-1))))
+			v.WriteQ(2, d(1), d(4))
+			runAsm(e, "frecpe v0.4s, v1.4s; frsqrte v3.2d, v2.2d")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{s(0.998046875, 0.3330078125), s(float32(math.Inf(1)), negZero)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{d(0.998046875), d(0.4990234375)}))
+			Expect(v.FPSR & emu.FPSRDZC).NotTo(BeZero())
+
+			v.WriteQ(2, d(-1), 0)
+			runAsm(e, "frsqrte d4, d2")
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x7FF8000000000000, 0}))
+			Expect(v.FPSR & emu.FPSRIOC).NotTo(BeZero())
+		})
+
+		It("should compute Newton-Raphson steps with infinity times zero giving 2 and 1.5", func() {
+			v.WriteQ(1, d(1.5), d(math.Inf(1)))
+			v.WriteQ(2, d(1), 0)
+			runAsm(e, "frecps v0.2d, v1.2d, v2.2d; frsqrts v3.2d, v1.2d, v2.2d")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{d(0.5), d(2)}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{d(0.75), d(1.5)}))
+		})
+
+		It("should refine a reciprocal estimate to full precision", func() {
+			v.WriteQ(1, d(3), 0)
+			runAsm(e, `frecpe d0, d1
+				frecps d2, d1, d0
+				fmul d0, d0, d2
+				frecps d2, d1, d0
+				fmul d0, d0, d2
+				frecps d2, d1, d0
+				fmul d0, d0, d2`)
+
+			Expect(math.Float64frombits(readQ(v, 0)[0])).To(BeNumerically("~", 1.0/3, 1e-15))
+		})
+	})
+})
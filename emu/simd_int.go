@@ -0,0 +1,971 @@
+package emu
+
+import "math/bits"
+
+// vreg is a working copy of a vector register. Operations assemble their
+// result in a vreg before writing it back, so the destination may also be
+// a source.
+type vreg [2]uint64
+
+// elemMask returns a mask of the low size bytes.
+func elemMask(size uint8) uint64 {
+	return uint64(1)<<(8*uint(size)) - 1
+}
+
+// signExtend sign-extends the low size bytes of v.
+func signExtend(v uint64, size uint8) int64 {
+	shift := 64 - 8*uint(size)
+	return int64(v<<shift) >> shift
+}
+
+// elem returns element i of a vector viewed as elements of size bytes.
+func (v *vreg) elem(i, size uint8) uint64 {
+	off := uint(i) * uint(size) * 8
+	return v[off/64] >> (off % 64) & elemMask(size)
+}
+
+// setElem writes the low size bytes of value to element i.
+func (v *vreg) setElem(i, size uint8, value uint64) {
+	off := uint(i) * uint(size) * 8
+	mask := elemMask(size) << (off % 64)
+	v[off/64] = v[off/64]&^mask | value<<(off%64)&mask
+}
+
+// ext returns element i extended to 64 bits, sign-extended if signed.
+func (v *vreg) ext(i, size uint8, signed bool) int64 {
+	return extend(v.elem(i, size), size, signed)
+}
+
+// laneShape returns the element size in bytes and the number of lanes of
+// an arrangement.
+func laneShape(arrangement SIMDArrangement) (size, lanes uint8) {
+	switch arrangement {
+	case Arr8B:
+		return 1, 8
+	case Arr16B:
+		return 1, 16
+	case Arr4H:
+		return 2, 4
+	case Arr8H:
+		return 2, 8
+	case Arr2S:
+		return 4, 2
+	case Arr4S:
+		return 4, 4
+	case Arr2D:
+		return 8, 2
+	case Arr1B:
+		return 1, 1
+	case Arr1H:
+		return 2, 1
+	case Arr1S:
+		return 4, 1
+	default:
+		return 8, 1
+	}
+}
+
+// isQ reports whether an arrangement fills the 128-bit register.
+func isQ(arrangement SIMDArrangement) bool {
+	size, lanes := laneShape(arrangement)
+	return size*lanes == 16
+}
+
+func (s *SIMD) vec(reg uint8) vreg {
+	low, high := s.simdRegFile.ReadQ(reg)
+	return vreg{low, high}
+}
+
+// setVec writes a vector register, zeroing the upper half unless q is set.
+func (s *SIMD) setVec(reg uint8, v vreg, q bool) {
+	if !q {
+		v[1] = 0
+	}
+	s.simdRegFile.WriteQ(reg, v[0], v[1])
+}
+
+// saturate records a saturated result in FPSR.QC.
+func (s *SIMD) saturate(sat bool) {
+	if sat {
+		s.simdRegFile.FPSR |= FPSRQC
+	}
+}
+
+// lanewise applies f to each lane of vd, vn and vm and writes the results
+// to vd.
+func (s *SIMD) lanewise(vd, vn, vm uint8, arrangement SIMDArrangement,
+	f func(d, n, m uint64, size uint8) uint64) {
+	size, lanes := laneShape(arrangement)
+	d, n, m := s.vec(vd), s.vec(vn), s.vec(vm)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		r.setElem(i, size, f(d.elem(i, size), n.elem(i, size), m.elem(i, size), size))
+	}
+	s.setVec(vd, r, size*lanes == 16)
+}
+
+// unary applies f to each lane of vn and writes the results to vd.
+func (s *SIMD) unary(vd, vn uint8, arrangement SIMDArrangement, f func(n uint64, size uint8) uint64) {
+	s.lanewise(vd, vn, vn, arrangement, func(_, n, _ uint64, size uint8) uint64 {
+		return f(n, size)
+	})
+}
+
+// pairwise applies f to adjacent pairs of elements of the concatenation
+// vm:vn, vn supplying the low-numbered results.
+func (s *SIMD) pairwise(vd, vn, vm uint8, arrangement SIMDArrangement, f func(a, b uint64, size uint8) uint64) {
+	size, lanes := laneShape(arrangement)
+	n, m := s.vec(vn), s.vec(vm)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		src, j := &n, 2*i
+		if j >= lanes {
+			src, j = &m, j-lanes
+		}
+		r.setElem(i, size, f(src.elem(j, size), src.elem(j+1, size), size))
+	}
+	s.setVec(vd, r, size*lanes == 16)
+}
+
+// narrow applies f to each element of the 128-bit vn, whose elements are
+// twice the size of the arrangement's, and writes the narrowed results to
+// the lower half of vd, or to the upper half if the arrangement is 128-bit
+// (the "2" variants).
+func (s *SIMD) narrow(vd, vn uint8, arrangement SIMDArrangement, f func(n uint64, size uint8) uint64) {
+	size, lanes := laneShape(arrangement)
+	n, d := s.vec(vn), s.vec(vd)
+	var r vreg
+	for i := uint8(0); i < 8/size; i++ {
+		r.setElem(i, size, f(n.elem(i, 2*size), 2*size))
+	}
+	if size*lanes == 16 {
+		s.setVec(vd, vreg{d[0], r[0]}, true)
+		return
+	}
+	s.setVec(vd, r, false)
+}
+
+// widen applies f to each lane of the 128-bit arrangement, reading
+// half-size elements of vn and vm from their lower halves or, if upper is
+// set, their upper halves. If wideN is set, vn holds full-size elements.
+// Elements are extended to 64 bits, sign-extended if signed.
+func (s *SIMD) widen(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed, wideN bool,
+	f func(d, n, m int64) int64) {
+	size, lanes := laneShape(arrangement)
+	half := size / 2
+	off := uint8(0)
+	if upper {
+		off = lanes
+	}
+	d, n, m := s.vec(vd), s.vec(vn), s.vec(vm)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		nv := n.ext(i+off, half, signed)
+		if wideN {
+			nv = n.ext(i, size, signed)
+		}
+		r.setElem(i, size, uint64(f(d.ext(i, size, signed), nv, m.ext(i+off, half, signed))))
+	}
+	s.setVec(vd, r, true)
+}
+
+// reduce folds the lanes of vn with f and writes the result, resultSize
+// bytes wide, to the low element of vd, zeroing the rest of the register.
+func (s *SIMD) reduce(vd, vn uint8, arrangement SIMDArrangement, signed bool, resultSize uint8,
+	f func(acc, x int64) int64) {
+	size, lanes := laneShape(arrangement)
+	n := s.vec(vn)
+	acc := n.ext(0, size, signed)
+	for i := uint8(1); i < lanes; i++ {
+		acc = f(acc, n.ext(i, size, signed))
+	}
+	var r vreg
+	r.setElem(0, resultSize, uint64(acc))
+	s.setVec(vd, r, true)
+}
+
+// saturateSigned clamps v to the signed range of size bytes.
+func saturateSigned(v int64, size uint8) (uint64, bool) {
+	maxValue := int64(1)<<(8*uint(size)-1) - 1
+	switch {
+	case v > maxValue:
+		return uint64(maxValue), true
+	case v < -maxValue-1:
+		return uint64(-maxValue - 1), true
+	}
+	return uint64(v), false
+}
+
+// saturateUnsigned clamps v to the unsigned range of size bytes.
+func saturateUnsigned(v int64, size uint8) (uint64, bool) {
+	switch {
+	case v < 0:
+		return 0, true
+	case size < 8 && uint64(v) > elemMask(size):
+		return elemMask(size), true
+	}
+	return uint64(v), false
+}
+
+// signedLimit returns the saturated result of a signed 64-bit operation
+// that overflowed, negatively if negative is set.
+func signedLimit(negative bool) uint64 {
+	if negative {
+		return 1 << 63
+	}
+	return 1<<63 - 1
+}
+
+func sqadd(n, m uint64, size uint8) (uint64, bool) {
+	a, b := signExtend(n, size), signExtend(m, size)
+	if size == 8 {
+		r := a + b
+		if (a^r)&(b^r) < 0 {
+			return signedLimit(a < 0), true
+		}
+		return uint64(r), false
+	}
+	return saturateSigned(a+b, size)
+}
+
+func sqsub(n, m uint64, size uint8) (uint64, bool) {
+	a, b := signExtend(n, size), signExtend(m, size)
+	if size == 8 {
+		r := a - b
+		if (a^b)&(a^r) < 0 {
+			return signedLimit(a < 0), true
+		}
+		return uint64(r), false
+	}
+	return saturateSigned(a-b, size)
+}
+
+func uqadd(n, m uint64, size uint8) (uint64, bool) {
+	r, carry := bits.Add64(n, m, 0)
+	if carry != 0 || r > elemMask(size) {
+		return elemMask(size), true
+	}
+	return r, false
+}
+
+func uqsub(n, m uint64, _ uint8) (uint64, bool) {
+	if n < m {
+		return 0, true
+	}
+	return n - m, false
+}
+
+// saturating applies a saturating operation lanewise, setting FPSR.QC if
+// any lane saturates.
+func (s *SIMD) saturating(vd, vn, vm uint8, arrangement SIMDArrangement,
+	f func(n, m uint64, size uint8) (uint64, bool)) {
+	sat := false
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		r, lane := f(n, m, size)
+		sat = sat || lane
+		return r
+	})
+	s.saturate(sat)
+}
+
+// mask returns an all-ones element if b is set, or zero.
+func mask(b bool) uint64 {
+	if b {
+		return ^uint64(0)
+	}
+	return 0
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// VSQADD performs signed saturating vector addition.
+func (s *SIMD) VSQADD(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.saturating(vd, vn, vm, arrangement, sqadd)
+}
+
+// VUQADD performs unsigned saturating vector addition.
+func (s *SIMD) VUQADD(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.saturating(vd, vn, vm, arrangement, uqadd)
+}
+
+// VSQSUB performs signed saturating vector subtraction.
+func (s *SIMD) VSQSUB(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.saturating(vd, vn, vm, arrangement, sqsub)
+}
+
+// VUQSUB performs unsigned saturating vector subtraction.
+func (s *SIMD) VUQSUB(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.saturating(vd, vn, vm, arrangement, uqsub)
+}
+
+// sqdmulh returns the high half of twice the signed product of n and m,
+// rounded if rounding is set. Only the product of two most negative values
+// saturates.
+func sqdmulh(n, m uint64, size uint8, rounding bool) (uint64, bool) {
+	p := signExtend(n, size) * signExtend(m, size)
+	shift := 8*uint(size) - 1
+	if rounding {
+		p += 1 << (shift - 1)
+	}
+	return saturateSigned(p>>shift, size)
+}
+
+// VSQDMULH performs signed saturating doubling multiply returning the high
+// half, rounded if rounding is set (SQDMULH, SQRDMULH).
+func (s *SIMD) VSQDMULH(vd, vn, vm uint8, arrangement SIMDArrangement, rounding bool) {
+	s.saturating(vd, vn, vm, arrangement, func(n, m uint64, size uint8) (uint64, bool) {
+		return sqdmulh(n, m, size, rounding)
+	})
+}
+
+// VCMEQ sets each lane to all ones if vn equals vm, or zero.
+func (s *SIMD) VCMEQ(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, _ uint8) uint64 {
+		return mask(n == m)
+	})
+}
+
+// VCMGT sets each lane to all ones if vn is greater than vm (signed).
+func (s *SIMD) VCMGT(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		return mask(signExtend(n, size) > signExtend(m, size))
+	})
+}
+
+// VCMGE sets each lane to all ones if vn is greater than or equal to vm
+// (signed).
+func (s *SIMD) VCMGE(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		return mask(signExtend(n, size) >= signExtend(m, size))
+	})
+}
+
+// VCMHI sets each lane to all ones if vn is higher than vm (unsigned).
+func (s *SIMD) VCMHI(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, _ uint8) uint64 {
+		return mask(n > m)
+	})
+}
+
+// VCMHS sets each lane to all ones if vn is higher than or the same as vm
+// (unsigned).
+func (s *SIMD) VCMHS(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, _ uint8) uint64 {
+		return mask(n >= m)
+	})
+}
+
+// VCMTST sets each lane to all ones if vn AND vm is nonzero.
+func (s *SIMD) VCMTST(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, _ uint8) uint64 {
+		return mask(n&m != 0)
+	})
+}
+
+// VMAX performs vector maximum, signed or unsigned.
+func (s *SIMD) VMAX(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		return uint64(max64(extend(n, size, signed), extend(m, size, signed)))
+	})
+}
+
+// VMIN performs vector minimum, signed or unsigned.
+func (s *SIMD) VMIN(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		return uint64(min64(extend(n, size, signed), extend(m, size, signed)))
+	})
+}
+
+// VABD performs vector absolute difference, signed or unsigned.
+func (s *SIMD) VABD(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		return uint64(abs64(extend(n, size, signed) - extend(m, size, signed)))
+	})
+}
+
+// VABA adds the absolute difference of vn and vm to vd, signed or
+// unsigned.
+func (s *SIMD) VABA(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.lanewise(vd, vn, vm, arrangement, func(d, n, m uint64, size uint8) uint64 {
+		return d + uint64(abs64(extend(n, size, signed)-extend(m, size, signed)))
+	})
+}
+
+// VMLA adds the product of vn and vm to vd.
+func (s *SIMD) VMLA(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(d, n, m uint64, _ uint8) uint64 {
+		return d + n*m
+	})
+}
+
+// VMLS subtracts the product of vn and vm from vd.
+func (s *SIMD) VMLS(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vm, arrangement, func(d, n, m uint64, _ uint8) uint64 {
+		return d - n*m
+	})
+}
+
+// VADDP adds adjacent pairs of elements of vn and vm.
+func (s *SIMD) VADDP(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.pairwise(vd, vn, vm, arrangement, func(a, b uint64, _ uint8) uint64 {
+		return a + b
+	})
+}
+
+// VMAXP takes the maximum of adjacent pairs of elements of vn and vm,
+// signed or unsigned.
+func (s *SIMD) VMAXP(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.pairwise(vd, vn, vm, arrangement, func(a, b uint64, size uint8) uint64 {
+		return uint64(max64(extend(a, size, signed), extend(b, size, signed)))
+	})
+}
+
+// VMINP takes the minimum of adjacent pairs of elements of vn and vm,
+// signed or unsigned.
+func (s *SIMD) VMINP(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.pairwise(vd, vn, vm, arrangement, func(a, b uint64, size uint8) uint64 {
+		return uint64(min64(extend(a, size, signed), extend(b, size, signed)))
+	})
+}
+
+// VSHLReg shifts each lane of vn left by the signed low byte of the
+// corresponding lane of vm, or right if it is negative, arithmetically if
+// signed (SSHL, USHL).
+func (s *SIMD) VSHLReg(vd, vn, vm uint8, arrangement SIMDArrangement, signed bool) {
+	s.lanewise(vd, vn, vm, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		shift := int8(m)
+		switch {
+		case shift >= 0:
+			return n << uint(shift)
+		case signed:
+			return uint64(signExtend(n, size) >> uint(-int(shift)))
+		default:
+			return n >> uint(-int(shift))
+		}
+	})
+}
+
+// byElement applies f to each lane of vd and vn and element lane of vm.
+func (s *SIMD) byElement(vd, vn, vm, lane uint8, arrangement SIMDArrangement,
+	f func(d, n, m uint64, size uint8) uint64) {
+	size, _ := laneShape(arrangement)
+	m := s.simdRegFile.ReadElem(vm, lane, size)
+	s.lanewise(vd, vn, vd, arrangement, func(d, n, _ uint64, size uint8) uint64 {
+		return f(d, n, m, size)
+	})
+}
+
+// VMULElem multiplies each lane of vn by element lane of vm.
+func (s *SIMD) VMULElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement) {
+	s.byElement(vd, vn, vm, lane, arrangement, func(_, n, m uint64, _ uint8) uint64 {
+		return n * m
+	})
+}
+
+// VMLAElem adds the product of each lane of vn and element lane of vm to
+// vd.
+func (s *SIMD) VMLAElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement) {
+	s.byElement(vd, vn, vm, lane, arrangement, func(d, n, m uint64, _ uint8) uint64 {
+		return d + n*m
+	})
+}
+
+// VMLSElem subtracts the product of each lane of vn and element lane of vm
+// from vd.
+func (s *SIMD) VMLSElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement) {
+	s.byElement(vd, vn, vm, lane, arrangement, func(d, n, m uint64, _ uint8) uint64 {
+		return d - n*m
+	})
+}
+
+// VSQDMULHElem performs SQDMULH or, if rounding is set, SQRDMULH of each
+// lane of vn and element lane of vm.
+func (s *SIMD) VSQDMULHElem(vd, vn, vm, lane uint8, arrangement SIMDArrangement, rounding bool) {
+	sat := false
+	s.byElement(vd, vn, vm, lane, arrangement, func(_, n, m uint64, size uint8) uint64 {
+		r, overflow := sqdmulh(n, m, size, rounding)
+		sat = sat || overflow
+		return r
+	})
+	s.saturate(sat)
+}
+
+// extend extends the low size bytes of v to 64 bits, sign-extending if
+// signed.
+func extend(v uint64, size uint8, signed bool) int64 {
+	if signed {
+		return signExtend(v, size)
+	}
+	return int64(v)
+}
+
+// bitwise applies f to the whole of vd, vn and vm, 64 bits at a time.
+func (s *SIMD) bitwise(vd, vn, vm uint8, arrangement SIMDArrangement, f func(d, n, m uint64) uint64) {
+	d, n, m := s.vec(vd), s.vec(vn), s.vec(vm)
+	s.setVec(vd, vreg{f(d[0], n[0], m[0]), f(d[1], n[1], m[1])}, isQ(arrangement))
+}
+
+// VAND performs bitwise AND.
+func (s *SIMD) VAND(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(_, n, m uint64) uint64 { return n & m })
+}
+
+// VBIC performs bitwise AND of vn with the complement of vm.
+func (s *SIMD) VBIC(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(_, n, m uint64) uint64 { return n &^ m })
+}
+
+// VORR performs bitwise OR.
+func (s *SIMD) VORR(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(_, n, m uint64) uint64 { return n | m })
+}
+
+// VORN performs bitwise OR of vn with the complement of vm.
+func (s *SIMD) VORN(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(_, n, m uint64) uint64 { return n | ^m })
+}
+
+// VEOR performs bitwise exclusive OR.
+func (s *SIMD) VEOR(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(_, n, m uint64) uint64 { return n ^ m })
+}
+
+// VBSL takes each bit from vn where vd is set and from vm where it is
+// clear.
+func (s *SIMD) VBSL(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(d, n, m uint64) uint64 { return n&d | m&^d })
+}
+
+// VBIT inserts each bit of vn into vd where vm is set.
+func (s *SIMD) VBIT(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(d, n, m uint64) uint64 { return n&m | d&^m })
+}
+
+// VBIF inserts each bit of vn into vd where vm is clear.
+func (s *SIMD) VBIF(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.bitwise(vd, vn, vm, arrangement, func(d, n, m uint64) uint64 { return d&m | n&^m })
+}
+
+// VCMEQZ sets each lane to all ones if vn is zero.
+func (s *SIMD) VCMEQZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return mask(n == 0) })
+}
+
+// VCMGTZ sets each lane to all ones if vn is greater than zero (signed).
+func (s *SIMD) VCMGTZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 { return mask(signExtend(n, size) > 0) })
+}
+
+// VCMGEZ sets each lane to all ones if vn is greater than or equal to zero
+// (signed).
+func (s *SIMD) VCMGEZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 { return mask(signExtend(n, size) >= 0) })
+}
+
+// VCMLEZ sets each lane to all ones if vn is less than or equal to zero
+// (signed).
+func (s *SIMD) VCMLEZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 { return mask(signExtend(n, size) <= 0) })
+}
+
+// VCMLTZ sets each lane to all ones if vn is less than zero (signed).
+func (s *SIMD) VCMLTZ(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 { return mask(signExtend(n, size) < 0) })
+}
+
+// VABS computes the absolute value of each lane. The most negative value
+// is unchanged.
+func (s *SIMD) VABS(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 { return uint64(abs64(signExtend(n, size))) })
+}
+
+// VNEG negates each lane.
+func (s *SIMD) VNEG(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return -n })
+}
+
+// VNOT inverts every bit of vn.
+func (s *SIMD) VNOT(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return ^n })
+}
+
+// VCNT counts the set bits of each byte.
+func (s *SIMD) VCNT(vd, vn uint8, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return uint64(bits.OnesCount64(n)) })
+}
+
+// VXTN narrows each element of vn by truncation. The arrangement is that
+// of the result; a 128-bit arrangement writes the upper half (XTN2).
+func (s *SIMD) VXTN(vd, vn uint8, arrangement SIMDArrangement) {
+	s.narrow(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return n })
+}
+
+// saturatingNarrow narrows with f, setting FPSR.QC if any lane saturates.
+func (s *SIMD) saturatingNarrow(vd, vn uint8, arrangement SIMDArrangement,
+	f func(n uint64, size uint8) (uint64, bool)) {
+	sat := false
+	s.narrow(vd, vn, arrangement, func(n uint64, size uint8) uint64 {
+		r, lane := f(n, size)
+		sat = sat || lane
+		return r
+	})
+	s.saturate(sat)
+}
+
+// VSQXTN narrows each signed element of vn with signed saturation.
+func (s *SIMD) VSQXTN(vd, vn uint8, arrangement SIMDArrangement) {
+	s.saturatingNarrow(vd, vn, arrangement, func(n uint64, size uint8) (uint64, bool) {
+		return saturateSigned(signExtend(n, size), size/2)
+	})
+}
+
+// VUQXTN narrows each unsigned element of vn with unsigned saturation.
+func (s *SIMD) VUQXTN(vd, vn uint8, arrangement SIMDArrangement) {
+	s.saturatingNarrow(vd, vn, arrangement, func(n uint64, size uint8) (uint64, bool) {
+		if n > elemMask(size/2) {
+			return elemMask(size / 2), true
+		}
+		return n, false
+	})
+}
+
+// VSQXTUN narrows each signed element of vn with unsigned saturation.
+func (s *SIMD) VSQXTUN(vd, vn uint8, arrangement SIMDArrangement) {
+	s.saturatingNarrow(vd, vn, arrangement, func(n uint64, size uint8) (uint64, bool) {
+		return saturateUnsigned(signExtend(n, size), size/2)
+	})
+}
+
+// VADDLP adds adjacent pairs of elements of vn into elements of twice
+// their size, signed or unsigned (SADDLP, UADDLP), adding the sums to vd
+// if accumulate is set (SADALP, UADALP). The arrangement is that of the
+// result.
+func (s *SIMD) VADDLP(vd, vn uint8, arrangement SIMDArrangement, signed, accumulate bool) {
+	size, lanes := laneShape(arrangement)
+	n, d := s.vec(vn), s.vec(vd)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		sum := n.ext(2*i, size/2, signed) + n.ext(2*i+1, size/2, signed)
+		if accumulate {
+			sum += int64(d.elem(i, size))
+		}
+		r.setElem(i, size, uint64(sum))
+	}
+	s.setVec(vd, r, size*lanes == 16)
+}
+
+// VADDL adds the half-size elements of vn and vm into full-size elements
+// of vd (SADDL, UADDL). The arrangement is that of the result; upper
+// selects the upper halves of the sources (the "2" variants).
+func (s *SIMD) VADDL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(_, n, m int64) int64 { return n + m })
+}
+
+// VADDW adds the half-size elements of vm to the full-size elements of vn
+// (SADDW, UADDW).
+func (s *SIMD) VADDW(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, true, func(_, n, m int64) int64 { return n + m })
+}
+
+// VSUBL subtracts the half-size elements of vm from those of vn into
+// full-size elements of vd (SSUBL, USUBL).
+func (s *SIMD) VSUBL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(_, n, m int64) int64 { return n - m })
+}
+
+// VSUBW subtracts the half-size elements of vm from the full-size elements
+// of vn (SSUBW, USUBW).
+func (s *SIMD) VSUBW(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, true, func(_, n, m int64) int64 { return n - m })
+}
+
+// VABAL adds the absolute difference of the half-size elements of vn and
+// vm to the full-size elements of vd (SABAL, UABAL).
+func (s *SIMD) VABAL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(d, n, m int64) int64 { return d + abs64(n-m) })
+}
+
+// VABDL computes the absolute difference of the half-size elements of vn
+// and vm into full-size elements of vd (SABDL, UABDL).
+func (s *SIMD) VABDL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(_, n, m int64) int64 { return abs64(n - m) })
+}
+
+// VMLAL adds the full-size product of the half-size elements of vn and vm
+// to vd (SMLAL, UMLAL).
+func (s *SIMD) VMLAL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(d, n, m int64) int64 { return d + n*m })
+}
+
+// VMLSL subtracts the full-size product of the half-size elements of vn
+// and vm from vd (SMLSL, UMLSL).
+func (s *SIMD) VMLSL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(d, n, m int64) int64 { return d - n*m })
+}
+
+// VMULL multiplies the half-size elements of vn and vm into full-size
+// elements of vd (SMULL, UMULL).
+func (s *SIMD) VMULL(vd, vn, vm uint8, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vm, arrangement, upper, signed, false, func(_, n, m int64) int64 { return n * m })
+}
+
+// VSHL shifts each lane left by shift.
+func (s *SIMD) VSHL(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return n << shift })
+}
+
+// VSSHR shifts each lane right arithmetically by shift (1 to the element
+// size).
+func (s *SIMD) VSSHR(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, size uint8) uint64 { return uint64(signExtend(n, size) >> shift) })
+}
+
+// VUSHR shifts each lane right logically by shift (1 to the element size).
+func (s *SIMD) VUSHR(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.unary(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return n >> shift })
+}
+
+// VSSRA adds each lane of vn, shifted right arithmetically, to vd.
+func (s *SIMD) VSSRA(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vn, arrangement, func(d, n, _ uint64, size uint8) uint64 {
+		return d + uint64(signExtend(n, size)>>shift)
+	})
+}
+
+// VUSRA adds each lane of vn, shifted right logically, to vd.
+func (s *SIMD) VUSRA(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.lanewise(vd, vn, vn, arrangement, func(d, n, _ uint64, _ uint8) uint64 { return d + n>>shift })
+}
+
+// VSHRN shifts each element of vn right and narrows it. The arrangement is
+// that of the result; a 128-bit arrangement writes the upper half (SHRN2).
+func (s *SIMD) VSHRN(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.narrow(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return n >> shift })
+}
+
+// VRSHRN shifts each element of vn right, rounding to nearest, and narrows
+// it (RSHRN, RSHRN2).
+func (s *SIMD) VRSHRN(vd, vn uint8, shift uint64, arrangement SIMDArrangement) {
+	s.narrow(vd, vn, arrangement, func(n uint64, _ uint8) uint64 { return n>>shift + n>>(shift-1)&1 })
+}
+
+// VSHLL extends the half-size elements of vn and shifts them left (SSHLL,
+// USHLL, and SXTL and UXTL when shift is 0). The arrangement is that of
+// the result; upper selects the upper half of vn.
+func (s *SIMD) VSHLL(vd, vn uint8, shift uint64, arrangement SIMDArrangement, upper, signed bool) {
+	s.widen(vd, vn, vn, arrangement, upper, signed, false, func(_, n, _ int64) int64 { return n << shift })
+}
+
+// VMOVI writes the 64-bit pattern imm to each half of vd (only the lower
+// half for 64-bit arrangements).
+func (s *SIMD) VMOVI(vd uint8, imm uint64, arrangement SIMDArrangement) {
+	s.setVec(vd, vreg{imm, imm}, isQ(arrangement))
+}
+
+// VMVNI writes the complement of the 64-bit pattern imm to vd.
+func (s *SIMD) VMVNI(vd uint8, imm uint64, arrangement SIMDArrangement) {
+	s.VMOVI(vd, ^imm, arrangement)
+}
+
+// VORRImm ORs the 64-bit pattern imm into vd.
+func (s *SIMD) VORRImm(vd uint8, imm uint64, arrangement SIMDArrangement) {
+	d := s.vec(vd)
+	s.setVec(vd, vreg{d[0] | imm, d[1] | imm}, isQ(arrangement))
+}
+
+// VBICImm clears the bits of the 64-bit pattern imm in vd.
+func (s *SIMD) VBICImm(vd uint8, imm uint64, arrangement SIMDArrangement) {
+	d := s.vec(vd)
+	s.setVec(vd, vreg{d[0] &^ imm, d[1] &^ imm}, isQ(arrangement))
+}
+
+// DUPElem duplicates element lane of vn into every lane of vd.
+func (s *SIMD) DUPElem(vd, vn, lane uint8, arrangement SIMDArrangement) {
+	size, _ := laneShape(arrangement)
+	value := s.simdRegFile.ReadElem(vn, lane, size)
+	s.unary(vd, vn, arrangement, func(uint64, uint8) uint64 { return value })
+}
+
+// INS inserts the low size bytes of general register rn into element lane
+// of vd, leaving the other elements unchanged.
+func (s *SIMD) INS(vd, lane, size, rn uint8) {
+	s.simdRegFile.WriteElem(vd, lane, size, s.regFile.ReadReg(rn))
+}
+
+// UMOV moves element lane of vn, zero-extended, to general register rd.
+func (s *SIMD) UMOV(rd, vn, lane, size uint8) {
+	s.regFile.WriteReg(rd, s.simdRegFile.ReadElem(vn, lane, size))
+}
+
+// SMOV moves element lane of vn, sign-extended to 32 or, if is64, 64 bits,
+// to general register rd.
+func (s *SIMD) SMOV(rd, vn, lane, size uint8, is64 bool) {
+	value := uint64(signExtend(s.simdRegFile.ReadElem(vn, lane, size), size))
+	if !is64 {
+		value = uint64(uint32(value))
+	}
+	s.regFile.WriteReg(rd, value)
+}
+
+// VADDV adds the lanes of vn into the scalar vd.
+func (s *SIMD) VADDV(vd, vn uint8, arrangement SIMDArrangement) {
+	size, _ := laneShape(arrangement)
+	s.reduce(vd, vn, arrangement, false, size, func(acc, x int64) int64 { return acc + x })
+}
+
+// ADDP adds the two elements of vn into the scalar vd.
+func (s *SIMD) ADDP(vd, vn uint8, arrangement SIMDArrangement) {
+	s.VADDV(vd, vn, arrangement)
+}
+
+// VADDLV adds the lanes of vn into a scalar vd of twice the element size
+// (SADDLV, UADDLV).
+func (s *SIMD) VADDLV(vd, vn uint8, arrangement SIMDArrangement, signed bool) {
+	size, _ := laneShape(arrangement)
+	s.reduce(vd, vn, arrangement, signed, 2*size, func(acc, x int64) int64 { return acc + x })
+}
+
+// VMAXV writes the maximum lane of vn to the scalar vd (SMAXV, UMAXV).
+func (s *SIMD) VMAXV(vd, vn uint8, arrangement SIMDArrangement, signed bool) {
+	size, _ := laneShape(arrangement)
+	s.reduce(vd, vn, arrangement, signed, size, max64)
+}
+
+// VMINV writes the minimum lane of vn to the scalar vd (SMINV, UMINV).
+func (s *SIMD) VMINV(vd, vn uint8, arrangement SIMDArrangement, signed bool) {
+	size, _ := laneShape(arrangement)
+	s.reduce(vd, vn, arrangement, signed, size, min64)
+}
+
+// VUZP1 writes the even-numbered elements of the concatenation vm:vn to vd.
+func (s *SIMD) VUZP1(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.pairwise(vd, vn, vm, arrangement, func(a, _ uint64, _ uint8) uint64 { return a })
+}
+
+// VUZP2 writes the odd-numbered elements of the concatenation vm:vn to vd.
+func (s *SIMD) VUZP2(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.pairwise(vd, vn, vm, arrangement, func(_, b uint64, _ uint8) uint64 { return b })
+}
+
+// VZIP1 interleaves the elements of the lower halves of vn and vm.
+func (s *SIMD) VZIP1(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.zip(vd, vn, vm, arrangement, 0)
+}
+
+// VZIP2 interleaves the elements of the upper halves of vn and vm.
+func (s *SIMD) VZIP2(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	_, lanes := laneShape(arrangement)
+	s.zip(vd, vn, vm, arrangement, lanes/2)
+}
+
+// zip writes elements base, base+1, ... of vn and vm alternately to vd.
+func (s *SIMD) zip(vd, vn, vm uint8, arrangement SIMDArrangement, base uint8) {
+	size, lanes := laneShape(arrangement)
+	n, m := s.vec(vn), s.vec(vm)
+	var r vreg
+	for i := uint8(0); i < lanes/2; i++ {
+		r.setElem(2*i, size, n.elem(base+i, size))
+		r.setElem(2*i+1, size, m.elem(base+i, size))
+	}
+	s.setVec(vd, r, isQ(arrangement))
+}
+
+// VTRN1 writes the even-numbered elements of vn and vm to the even and odd
+// elements of vd.
+func (s *SIMD) VTRN1(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.trn(vd, vn, vm, arrangement, 0)
+}
+
+// VTRN2 writes the odd-numbered elements of vn and vm to the even and odd
+// elements of vd.
+func (s *SIMD) VTRN2(vd, vn, vm uint8, arrangement SIMDArrangement) {
+	s.trn(vd, vn, vm, arrangement, 1)
+}
+
+// trn writes elements 2i+part of vn and vm to elements 2i and 2i+1 of vd.
+func (s *SIMD) trn(vd, vn, vm uint8, arrangement SIMDArrangement, part uint8) {
+	size, lanes := laneShape(arrangement)
+	n, m := s.vec(vn), s.vec(vm)
+	var r vreg
+	for i := uint8(0); i < lanes; i += 2 {
+		r.setElem(i, size, n.elem(i+part, size))
+		r.setElem(i+1, size, m.elem(i+part, size))
+	}
+	s.setVec(vd, r, isQ(arrangement))
+}
+
+// VEXT writes the bytes of the concatenation vm:vn starting at byte index
+// of vn to vd (EXT).
+func (s *SIMD) VEXT(vd, vn, vm uint8, index uint8, arrangement SIMDArrangement) {
+	_, lanes := laneShape(arrangement)
+	n, m := s.vec(vn), s.vec(vm)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		src, j := &n, index+i
+		if j >= lanes {
+			src, j = &m, j-lanes
+		}
+		r.setElem(i, 1, src.elem(j, 1))
+	}
+	s.setVec(vd, r, isQ(arrangement))
+}
+
+// VREV reverses the order of the elements within each container of the
+// given size in bytes (REV16, REV32, REV64).
+func (s *SIMD) VREV(vd, vn uint8, arrangement SIMDArrangement, container uint8) {
+	size, lanes := laneShape(arrangement)
+	group := container / size
+	n := s.vec(vn)
+	var r vreg
+	for i := uint8(0); i < lanes; i++ {
+		r.setElem(i, size, n.elem(i^(group-1), size))
+	}
+	s.setVec(vd, r, isQ(arrangement))
+}
+
+// VTBL looks up each byte of vm in the table formed by count consecutive
+// registers starting at vn, wrapping from V31 to V0. Out-of-range indices
+// give zero, or with keep set (TBX) leave the byte of vd unchanged.
+func (s *SIMD) VTBL(vd, vn, count, vm uint8, arrangement SIMDArrangement, keep bool) {
+	_, lanes := laneShape(arrangement)
+	table := make([]vreg, count)
+	for i := range table {
+		table[i] = s.vec((vn + uint8(i)) % 32)
+	}
+	indices, r := s.vec(vm), s.vec(vd)
+	for i := uint8(0); i < lanes; i++ {
+		index := indices.elem(i, 1)
+		switch {
+		case index < 16*uint64(count):
+			r.setElem(i, 1, table[index/16].elem(uint8(index%16), 1))
+		case !keep:
+			r.setElem(i, 1, 0)
+		}
+	}
+	s.setVec(vd, r, isQ(arrangement))
+}
+
+// INSElem copies element from of vn to element lane of vd, leaving the
+// other elements unchanged.
+func (s *SIMD) INSElem(vd, lane, vn, from, size uint8) {
+	s.simdRegFile.WriteElem(vd, lane, size, s.simdRegFile.ReadElem(vn, from, size))
+}
@@ -0,0 +1,388 @@
+package emu_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("NEON Integer Instructions", func() {
+	var (
+		e *emu.Emulator
+		v *emu.SIMDRegFile
+	)
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+		v = e.SIMDRegFile()
+	})
+
+	Describe("Saturating arithmetic", func() {
+		It("should clamp signed and unsigned results and set FPSR.QC", func() {
+			v.WriteQ(1, 0x7F7F80017F000180, 0)
+			v.WriteQ(2, 0x0102FF017F000180, 0)
+			runAsm(e, "sqadd v0.8b, v1.8b, v2.8b; uqadd v3.8b, v1.8b, v2.8b")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x7F7F80027F000280, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x8081FF02FE0002FF, 0}))
+			Expect(v.FPSR & emu.FPSRQC).NotTo(BeZero())
+		})
+
+		It("should saturate 64-bit lanes and leave QC clear without overflow", func() {
+			v.WriteQ(1, 0x7FFFFFFFFFFFFFFF, 5)
+			v.WriteQ(2, 1, 7)
+			runAsm(e, "uqsub v0.2d, v1.2d, v2.2d")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x7FFFFFFFFFFFFFFE, 0}))
+			Expect(v.FPSR & emu.FPSRQC).NotTo(BeZero())
+
+			v.FPSR = 0
+			runAsm(e, "sqadd v0.2d, v1.2d, v2.2d")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x7FFFFFFFFFFFFFFF, 12}))
+			Expect(v.FPSR & emu.FPSRQC).NotTo(BeZero())
+
+			v.FPSR = 0
+			runAsm(e, "sqsub v0.2d, v2.2d, v1.2d")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x8000000000000002, 2}))
+			Expect(v.FPSR).To(BeZero())
+		})
+	})
+
+	Describe("Compares, min/max and absolute difference", func() {
+		It("should produce all-ones masks for true lanes", func() {
+			v.WriteQ(1, 0xFFFFFFFF00000005, 0x0000000700000001)
+			v.WriteQ(2, 0x0000000100000005, 0x0000000300000002)
+			runAsm(e, `cmeq v0.4s, v1.4s, v2.4s
+				cmgt v3.4s, v1.4s, v2.4s
+				cmhi v4.4s, v1.4s, v2.4s
+				cmlt v5.4s, v1.4s, #0`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x00000000FFFFFFFF, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0, 0xFFFFFFFF00000000}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0xFFFFFFFF00000000, 0xFFFFFFFF00000000}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0xFFFFFFFF00000000, 0}))
+		})
+
+		It("should distinguish signed and unsigned lanes", func() {
+			v.WriteQ(1, 0x00F0_0010, 0)
+			v.WriteQ(2, 0x0010_00F0, 0)
+			runAsm(e, `smax v0.4h, v1.4h, v2.4h
+				umin v3.4h, v1.4h, v2.4h
+				sabd v4.8b, v1.8b, v2.8b
+				uabd v5.8b, v1.8b, v2.8b`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x00F000F0, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x00100010, 0}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x00200020, 0}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0x00E000E0, 0}))
+		})
+
+		It("should accumulate with MLA and SABA", func() {
+			v.WriteQ(0, 0x0000000A00000001, 0)
+			v.WriteQ(1, 0x0000000300000002, 0)
+			v.WriteQ(2, 0x0000000400000005, 0)
+			v.WriteQ(3, 100, 0)
+			runAsm(e, "mla v0.2s, v1.2s, v2.2s; saba v3.2s, v1.2s, v2.2s")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x000000160000000B, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x0000000100000067, 0}))
+		})
+
+		It("should operate on adjacent pairs of the concatenated sources", func() {
+			v.WriteQ(1, 0x0000000200000001, 0x0000000400000003)
+			v.WriteQ(2, 0x0000000600000005, 0x0000000800000007)
+			runAsm(e, "addp v0.4s, v1.4s, v2.4s; uminp v3.4s, v1.4s, v2.4s")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0000000700000003, 0x0000000F0000000B}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x0000000300000001, 0x0000000700000005}))
+		})
+	})
+
+	Describe("Bitwise operations", func() {
+		It("should select bits with BSL, BIT and BIF", func() {
+			v.WriteQ(1, 0xAAAAAAAAAAAAAAAA, 0xAAAAAAAAAAAAAAAA)
+			v.WriteQ(2, 0x5555555555555555, 0x5555555555555555)
+			v.WriteQ(0, 0xFF00FF00FF00FF00, 0)
+			v.WriteQ(3, 0x1111111111111111, 0)
+			v.WriteQ(4, 0x1111111111111111, 0)
+			v.WriteQ(5, 0xFF00FF00FF00FF00, 0)
+			runAsm(e, `bsl v0.16b, v1.16b, v2.16b
+				bit v3.8b, v1.8b, v5.8b
+				bif v4.8b, v1.8b, v5.8b`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xAA55AA55AA55AA55, 0x5555555555555555}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xAA11AA11AA11AA11, 0}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x11AA11AA11AA11AA, 0}))
+		})
+
+		It("should zero the upper half for 64-bit arrangements", func() {
+			v.WriteQ(1, 0xF0F0, 0xFFFF)
+			v.WriteQ(2, 0x0FF0, 0xFFFF)
+			runAsm(e, "and v0.8b, v1.8b, v2.8b; mov v3.16b, v1.16b; mvn v4.8b, v1.8b")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x00F0, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xF0F0, 0xFFFF}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0xFFFFFFFFFFFF0F0F, 0}))
+		})
+
+		It("should count set bits per byte", func() {
+			v.WriteQ(1, 0x0103070F1F3F7FFF, 0)
+			runAsm(e, "cnt v0.8b, v1.8b")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0102030405060708, 0}))
+		})
+	})
+
+	Describe("Widening and narrowing", func() {
+		It("should widen from the lower or upper half", func() {
+			v.WriteQ(1, 0x00000000FFFFFFFE, 0x0000000300000002)
+			v.WriteQ(2, 0x0000000000000003, 0x0000000400000004)
+			runAsm(e, `smull v0.2d, v1.2s, v2.2s
+				umull2 v3.2d, v1.4s, v2.4s
+				saddw v4.2d, v2.2d, v1.2s`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xFFFFFFFFFFFFFFFA, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{8, 12}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{1, 0x0000000400000004}))
+		})
+
+		It("should extend with SXTL and USHLL", func() {
+			v.WriteQ(1, 0x000000000000FF01, 0)
+			runAsm(e, "sxtl v0.8h, v1.8b; ushll v2.8h, v1.8b, #4")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xFFFF0001, 0}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0x0FF00010, 0}))
+		})
+
+		It("should narrow into the lower half and the upper half with the 2 variant", func() {
+			v.WriteQ(1, 0x0000012300000045, 0xFFFFFF0000000080)
+			v.WriteQ(0, 0, 0xDEAD)
+			runAsm(e, "xtn v0.4h, v1.4s; sqxtn2 v0.8h, v1.4s")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0xFF00008001230045, 0xFF00008001230045}))
+			Expect(v.FPSR & emu.FPSRQC).To(BeZero())
+		})
+
+		It("should saturate narrowing to unsigned", func() {
+			v.WriteQ(1, 0x0100_FFFF_0080_0001, 0)
+			runAsm(e, "sqxtun v0.8b, v1.8h")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x00000000FF008001, 0}))
+			Expect(v.FPSR & emu.FPSRQC).NotTo(BeZero())
+		})
+
+		It("should unzip even and odd elements", func() {
+			v.WriteQ(1, 0x0000000100000000, 0x0000000300000002)
+			v.WriteQ(2, 0x0000000500000004, 0x0000000700000006)
+			runAsm(e, "uzp1 v0.4s, v1.4s, v2.4s; uzp2 v3.4s, v1.4s, v2.4s")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0000000200000000, 0x0000000600000004}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x0000000300000001, 0x0000000700000005}))
+		})
+
+		It("should interleave and transpose elements", func() {
+			v.WriteQ(1, 0x0000000100000000, 0x0000000300000002)
+			v.WriteQ(2, 0x0000000500000004, 0x0000000700000006)
+			runAsm(e, `zip1 v0.4s, v1.4s, v2.4s
+				zip2 v3.4s, v1.4s, v2.4s
+				trn1 v4.4s, v1.4s, v2.4s
+				trn2 v5.4s, v1.4s, v2.4s
+				zip2 v6.8b, v1.8b, v2.8b`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0000000400000000, 0x0000000500000001}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x0000000600000002, 0x0000000700000003}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x0000000400000000, 0x0000000600000002}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0x0000000500000001, 0x0000000700000003}))
+			Expect(readQ(v, 6)).To(Equal([2]uint64{0x0000000000000501, 0}))
+		})
+
+		It("should extract bytes from a pair of vectors", func() {
+			v.WriteQ(1, 0x0706050403020100, 0x0F0E0D0C0B0A0908)
+			v.WriteQ(2, 0x1716151413121110, 0x1F1E1D1C1B1A1918)
+			runAsm(e, "ext v0.16b, v1.16b, v2.16b, #3; ext v3.8b, v1.8b, v2.8b, #7")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0A09080706050403, 0x1211100F0E0D0C0B}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x1615141312111007, 0}))
+		})
+
+		It("should reverse elements within containers", func() {
+			v.WriteQ(1, 0x0706050403020100, 0x0F0E0D0C0B0A0908)
+			runAsm(e, `rev64 v0.4s, v1.4s
+				rev32 v2.8h, v1.8h
+				rev16 v3.8b, v1.8b`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0302010007060504, 0x0B0A09080F0E0D0C}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0x0504070601000302, 0x0D0C0F0E09080B0A}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x0607040502030001, 0}))
+		})
+
+		It("should look up bytes in tables of up to four registers", func() {
+			v.WriteQ(31, 0x0706050403020100, 0x0F0E0D0C0B0A0908)
+			v.WriteQ(0, 0x1716151413121110, 0x1F1E1D1C1B1A1918)
+			v.WriteQ(4, 0x20FF1F10110F0100, 0x0203040506070809)
+			v.WriteQ(5, 0xAAAAAAAAAAAAAAAA, 0xBBBBBBBBBBBBBBBB)
+			runAsm(e, `tbl v3.16b, {v31.16b, v0.16b}, v4.16b
+				tbx v5.8b, {v31.16b}, v4.8b`)
+
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x00001F10110F0100, 0x0203040506070809}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0xAAAAAAAAAA0F0100, 0}))
+		})
+
+		It("should insert an element from another vector", func() {
+			v.WriteQ(0, 0x1111111122222222, 0x3333333344444444)
+			v.WriteQ(1, 0x5555555566666666, 0x7777777788888888)
+			runAsm(e, "mov v0.s[1], v1.s[3]; ins v0.b[15], v1.b[0]")
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x7777777722222222, 0x6633333344444444}))
+		})
+	})
+
+	Describe("Shifts by immediate", func() {
+		It("should shift left, right and accumulate", func() {
+			v.WriteQ(1, 0x8000000000000010, 0x10)
+			v.WriteQ(3, 1, 1)
+			runAsm(e, `shl v0.2d, v1.2d, #4
+				sshr v2.2d, v1.2d, #64
+				usra v3.2d, v1.2d, #4
+				shrn v4.2s, v1.2d, #4`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x100, 0x100}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0xFFFFFFFFFFFFFFFF, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x0800000000000002, 2}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x0000000100000001, 0}))
+		})
+	})
+
+	Describe("Modified immediates", func() {
+		It("should move, invert, OR and clear replicated patterns", func() {
+			v.WriteQ(2, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF)
+			runAsm(e, `movi v0.4s, #0x21, msl #8
+				mvni v1.4h, #0x1, lsl #8
+				bic v2.4s, #0xff, lsl #24
+				orr v2.8h, #0x12, lsl #8
+				movi d3, #0xff00ff`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x000021FF000021FF, 0x000021FF000021FF}))
+			Expect(readQ(v, 1)).To(Equal([2]uint64{0xFEFFFEFFFEFFFEFF, 0}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0x12FFFFFF12FFFFFF, 0x12FFFFFF12FFFFFF}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xFF00FF, 0}))
+		})
+	})
+
+	Describe("Element moves", func() {
+		It("should duplicate, insert and extract elements", func() {
+			v.WriteQ(1, 0x00000002FFFF8001, 0x0000000400000003)
+			e.RegFile().WriteReg(5, 0xAABBCCDD)
+			runAsm(e, `dup v0.4s, v1.s[2]
+				mov v1.s[3], w5
+				mov w2, v1.s[3]
+				umov w3, v1.h[0]
+				smov x4, v1.h[0]
+				smov w6, v1.b[1]`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0000000300000003, 0x0000000300000003}))
+			Expect(readQ(v, 1)).To(Equal([2]uint64{0x00000002FFFF8001, 0xAABBCCDD00000003}))
+			Expect(e.RegFile().ReadReg(2)).To(Equal(uint64(0xAABBCCDD)))
+			Expect(e.RegFile().ReadReg(3)).To(Equal(uint64(0x8001)))
+			Expect(e.RegFile().ReadReg(4)).To(Equal(uint64(0xFFFFFFFFFFFF8001)))
+			Expect(e.RegFile().ReadReg(6)).To(Equal(uint64(0xFFFFFF80)))
+		})
+	})
+
+	Describe("Reductions across lanes", func() {
+		It("should reduce into a scalar and zero the rest of the register", func() {
+			v.WriteQ(1, 0x80FF0201, 0)
+			v.WriteQ(0, 0xFFFF, 0xFFFF)
+			runAsm(e, `addv b0, v1.8b
+				saddlv h2, v1.8b
+				uaddlv h3, v1.8b
+				smaxv b4, v1.8b
+				uminv b5, v1.8b
+				sminv b6, v1.8b`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x82, 0}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0xFF82, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x182, 0}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x02, 0}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0x00, 0}))
+			Expect(readQ(v, 6)).To(Equal([2]uint64{0x80, 0}))
+		})
+	})
+
+	Describe("Scalar, by-element and pairwise long forms", func() {
+		It("should operate on doubleword scalars and zero the upper half", func() {
+			v.WriteQ(0, 0xFFFF, 0xFFFF)
+			v.WriteQ(1, 5, 0x10)
+			v.WriteQ(2, 5, 3)
+			v.WriteQ(9, 0xFF, 4)
+			v.WriteQ(12, 0x8000000000000000, 1)
+			runAsm(e, `addp d0, v1.2d
+				cmeq d3, d1, d2
+				ushr d4, d1, #2
+				shl d5, d2, #4
+				cmeq d6, d1, #0
+				mov d7, v1.d[1]
+				ushl v8.2d, v1.2d, v9.2d
+				sshl v10.2d, v12.2d, v9.2d`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x15, 0}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0xFFFFFFFFFFFFFFFF, 0}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{1, 0}))
+			Expect(readQ(v, 5)).To(Equal([2]uint64{0x50, 0}))
+			Expect(readQ(v, 6)).To(Equal([2]uint64{0, 0}))
+			Expect(readQ(v, 7)).To(Equal([2]uint64{0x10, 0}))
+			Expect(readQ(v, 8)).To(Equal([2]uint64{2, 0x100}))
+			Expect(readQ(v, 10)).To(Equal([2]uint64{0xC000000000000000, 0x10}))
+		})
+
+		It("should double, round and saturate high-half multiplies", func() {
+			v.WriteQ(1, 0x0000000040008000, 0)
+			v.WriteQ(4, 1, 0xFFFF)
+			v.WriteQ(5, 0x4000, 0xFFFF)
+			v.WriteQ(8, 0x40000000, 0)
+			v.WriteQ(9, 0x4000000000000000, 0)
+			runAsm(e, `sqrdmulh h3, h4, h5
+				sqdmulh h6, h4, h5
+				sqdmulh s7, s8, v9.s[1]`)
+
+			Expect(readQ(v, 3)).To(Equal([2]uint64{1, 0}))
+			Expect(readQ(v, 6)).To(Equal([2]uint64{0, 0}))
+			Expect(readQ(v, 7)).To(Equal([2]uint64{0x20000000, 0}))
+			Expect(v.FPSR).To(BeZero())
+
+			runAsm(e, "sqdmulh v0.4h, v1.4h, v1.4h")
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0000000020007FFF, 0}))
+			Expect(v.FPSR & emu.FPSRQC).NotTo(BeZero())
+		})
+
+		It("should multiply and accumulate by an element", func() {
+			v.WriteQ(1, 0x0000000200000001, 0x0000000400000003)
+			v.WriteQ(2, 0x0000000A00000000, 0)
+			v.WriteQ(3, 0x0000000100000001, 0x0000000100000001)
+			v.WriteQ(4, 0x0000006400000064, 0x0000006400000064)
+			runAsm(e, `mul v0.4s, v1.4s, v2.s[1]
+				mla v3.4s, v1.4s, v2.s[1]
+				mls v4.4s, v1.4s, v2.s[1]`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x000000140000000A, 0x000000280000001E}))
+			Expect(readQ(v, 3)).To(Equal([2]uint64{0x000000150000000B, 0x000000290000001F}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{0x000000500000005A, 0x0000003C00000046}))
+		})
+
+		It("should round narrowing shifts and add adjacent pairs into wider lanes", func() {
+			v.WriteQ(1, 0x0000000000170018, 0)
+			v.WriteQ(3, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF)
+			v.WriteQ(4, 10, 0)
+			v.WriteQ(5, 0x00000002FFFFFFFF, 0x000000017FFFFFFF)
+			runAsm(e, `rshrn v0.8b, v1.8h, #4
+				uaddlp v2.8h, v3.16b
+				sadalp v4.2d, v5.4s
+				saddlp v6.1d, v5.2s`)
+
+			Expect(readQ(v, 0)).To(Equal([2]uint64{0x0102, 0}))
+			Expect(readQ(v, 2)).To(Equal([2]uint64{0x01FE01FE01FE01FE, 0x01FE01FE01FE01FE}))
+			Expect(readQ(v, 4)).To(Equal([2]uint64{11, 0x80000000}))
+			Expect(readQ(v, 6)).To(Equal([2]uint64{1, 0}))
+		})
+	})
+})
@@ -13,6 +13,12 @@ type SIMDRegFile struct {
 	// V holds the 32 vector registers.
 	// Each register is represented as a pair of uint64 (low, high).
 	V [32][2]uint64
+
+	// FPCR is the floating-point control register (rounding mode, FZ, DN).
+	FPCR uint64
+
+	// FPSR is the floating-point status register (cumulative exceptions).
+	FPSR uint64
 }
 
 // NewSIMDRegFile creates a new SIMD register file.
@@ -53,6 +59,28 @@ func (s *SIMDRegFile) WriteS(reg uint8, value uint32) {
 	s.V[reg][1] = 0
 }
 
+// ReadH reads the lower 16 bits (H register).
+func (s *SIMDRegFile) ReadH(reg uint8) uint16 {
+	return uint16(s.V[reg][0])
+}
+
+// WriteH writes the lower 16 bits (H register), zeroing upper bits.
+func (s *SIMDRegFile) WriteH(reg uint8, value uint16) {
+	s.V[reg][0] = uint64(value)
+	s.V[reg][1] = 0
+}
+
+// ReadB reads the lowest 8 bits (B register).
+func (s *SIMDRegFile) ReadB(reg uint8) uint8 {
+	return uint8(s.V[reg][0])
+}
+
+// WriteB writes the lowest 8 bits (B register), zeroing upper bits.
+func (s *SIMDRegFile) WriteB(reg uint8, value uint8) {
+	s.V[reg][0] = uint64(value)
+	s.V[reg][1] = 0
+}
+
 // ReadLane8 reads an 8-bit lane from a vector register.
 // Lane index: 0-15 (0 is lowest byte).
 func (s *SIMDRegFile) ReadLane8(reg uint8, lane uint8) uint8 {
@@ -127,6 +155,36 @@ func (s *SIMDRegFile) WriteLane64(reg uint8, lane uint8, value uint64) {
 	s.V[reg][lane] = value
 }
 
+// ReadElem reads lane of a vector register viewed as elements of size
+// bytes (1, 2, 4 or 8).
+func (s *SIMDRegFile) ReadElem(reg uint8, lane uint8, size uint8) uint64 {
+	switch size {
+	case 1:
+		return uint64(s.ReadLane8(reg, lane))
+	case 2:
+		return uint64(s.ReadLane16(reg, lane))
+	case 4:
+		return uint64(s.ReadLane32(reg, lane))
+	default:
+		return s.ReadLane64(reg, lane)
+	}
+}
+
+// WriteElem writes the low size bytes of value to lane of a vector register
+// viewed as elements of size bytes (1, 2, 4 or 8).
+func (s *SIMDRegFile) WriteElem(reg uint8, lane uint8, size uint8, value uint64) {
+	switch size {
+	case 1:
+		s.WriteLane8(reg, lane, uint8(value))
+	case 2:
+		s.WriteLane16(reg, lane, uint16(value))
+	case 4:
+		s.WriteLane32(reg, lane, uint32(value))
+	default:
+		s.WriteLane64(reg, lane, value)
+	}
+}
+
 // Clear zeros all SIMD registers.
 func (s *SIMDRegFile) Clear() {
 	for i := range s.V {
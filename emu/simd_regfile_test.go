@@ -83,6 +83,18 @@ var _ = Describe("SIMDRegFile", func() {
 		})
 	})
 
+	Describe("B register (8-bit) operations", func() {
+		It("should zero upper bits when writing B register", func() {
+			simdRegFile.WriteQ(0, 0x1111111111111111, 0x2222222222222222)
+			simdRegFile.WriteB(0, 0xAB)
+
+			Expect(simdRegFile.ReadB(0)).To(Equal(uint8(0xAB)))
+			low, high := simdRegFile.ReadQ(0)
+			Expect(low).To(Equal(uint64(0xAB)))
+			Expect(high).To(Equal(uint64(0)))
+		})
+	})
+
 	Describe("Lane operations", func() {
 		Context("8-bit lanes", func() {
 			It("should read and write 8-bit lanes in low half", func() {
@@ -165,6 +177,21 @@ var _ = Describe("SIMDRegFile", func() {
 		})
 	})
 
+	Describe("Element operations", func() {
+		It("should read and write lanes by element size", func() {
+			simdRegFile.WriteElem(0, 15, 1, 0xAB)
+			simdRegFile.WriteElem(0, 1, 2, 0x1234)
+			simdRegFile.WriteElem(1, 3, 4, 0xDEADBEEF)
+			simdRegFile.WriteElem(1, 0, 8, 0x0102030405060708)
+
+			Expect(simdRegFile.ReadElem(0, 15, 1)).To(Equal(uint64(0xAB)))
+			Expect(simdRegFile.ReadElem(0, 1, 2)).To(Equal(uint64(0x1234)))
+			Expect(simdRegFile.V[0]).To(Equal([2]uint64{0x12340000, 0xAB00000000000000}))
+			Expect(simdRegFile.ReadElem(1, 3, 4)).To(Equal(uint64(0xDEADBEEF)))
+			Expect(simdRegFile.V[1]).To(Equal([2]uint64{0x0102030405060708, 0xDEADBEEF00000000}))
+		})
+	})
+
 	Describe("Clear", func() {
 		It("should zero all registers", func() {
 			for i := uint8(0); i < 32; i++ {
@@ -2,34 +2,80 @@
 package emu
 
 import (
+	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"syscall"
 )
 
 // ARM64 Linux syscall numbers.
 const (
-	SyscallOpenat   uint64 = 56  // openat(dirfd, pathname, flags, mode)
-	SyscallClose    uint64 = 57  // close(fd)
-	SyscallLseek    uint64 = 62  // lseek(fd, offset, whence)
-	SyscallRead     uint64 = 63  // read(fd, buf, count)
-	SyscallWrite    uint64 = 64  // write(fd, buf, count)
-	SyscallFstat    uint64 = 80  // fstat(fd, statbuf)
-	SyscallExit     uint64 = 93  // exit(status)
-	SyscallBrk      uint64 = 214 // brk(addr)
-	SyscallMmap     uint64 = 222 // mmap(addr, length, prot, flags, fd, offset)
-	SyscallMprotect uint64 = 226 // mprotect(addr, len, prot)
+	SyscallGetcwd           uint64 = 17  // getcwd(buf, size)
+	SyscallIoctl            uint64 = 29  // ioctl(fd, request, arg)
+	SyscallOpenat           uint64 = 56  // openat(dirfd, pathname, flags, mode)
+	SyscallClose            uint64 = 57  // close(fd)
+	SyscallLseek            uint64 = 62  // lseek(fd, offset, whence)
+	SyscallRead             uint64 = 63  // read(fd, buf, count)
+	SyscallWrite            uint64 = 64  // write(fd, buf, count)
+	SyscallReadv            uint64 = 65  // readv(fd, iov, iovcnt)
+	SyscallWritev           uint64 = 66  // writev(fd, iov, iovcnt)
+	SyscallPread64          uint64 = 67  // pread64(fd, buf, count, offset)
+	SyscallPwrite64         uint64 = 68  // pwrite64(fd, buf, count, offset)
+	SyscallReadlinkat       uint64 = 78  // readlinkat(dirfd, pathname, buf, bufsiz)
+	SyscallNewfstatat       uint64 = 79  // newfstatat(dirfd, pathname, statbuf, flags)
+	SyscallFstat            uint64 = 80  // fstat(fd, statbuf)
+	SyscallExit             uint64 = 93  // exit(status)
+	SyscallExitGroup        uint64 = 94  // exit_group(status)
+	SyscallSetTidAddress    uint64 = 96  // set_tid_address(tidptr)
+	SyscallSetRobustList    uint64 = 99  // set_robust_list(head, len)
+	SyscallNanosleep        uint64 = 101 // nanosleep(req, rem)
+	SyscallClockGettime     uint64 = 113 // clock_gettime(clockid, tp)
+	SyscallClockNanosleep   uint64 = 115 // clock_nanosleep(clockid, flags, req, rem)
+	SyscallSchedGetaffinity uint64 = 123 // sched_getaffinity(pid, cpusetsize, mask)
+	SyscallRtSigaction      uint64 = 134 // rt_sigaction(sig, act, oact, sigsetsize)
+	SyscallRtSigprocmask    uint64 = 135 // rt_sigprocmask(how, set, oset, sigsetsize)
+	SyscallUname            uint64 = 160 // uname(buf)
+	SyscallGettimeofday     uint64 = 169 // gettimeofday(tv, tz)
+	SyscallGetpid           uint64 = 172 // getpid()
+	SyscallGetppid          uint64 = 173 // getppid()
+	SyscallGetuid           uint64 = 174 // getuid()
+	SyscallGeteuid          uint64 = 175 // geteuid()
+	SyscallGetgid           uint64 = 176 // getgid()
+	SyscallGetegid          uint64 = 177 // getegid()
+	SyscallGettid           uint64 = 178 // gettid()
+	SyscallBrk              uint64 = 214 // brk(addr)
+	SyscallMunmap           uint64 = 215 // munmap(addr, length)
+	SyscallMremap           uint64 = 216 // mremap(old_addr, old_size, new_size, flags, new_addr)
+	SyscallMmap             uint64 = 222 // mmap(addr, length, prot, flags, fd, offset)
+	SyscallMprotect         uint64 = 226 // mprotect(addr, len, prot)
+	SyscallMsync            uint64 = 227 // msync(addr, length, flags)
+	SyscallMadvise          uint64 = 233 // madvise(addr, length, advice)
+	SyscallPrlimit64        uint64 = 261 // prlimit64(pid, resource, new_limit, old_limit)
+	SyscallGetrandom        uint64 = 278 // getrandom(buf, buflen, flags)
+	SyscallStatx            uint64 = 291 // statx(dirfd, pathname, flags, mask, statxbuf)
 )
 
 // Linux error codes.
 const (
-	ENOENT = 2  // No such file or directory
-	EIO    = 5  // I/O error
-	EBADF  = 9  // Bad file descriptor
-	ENOMEM = 12 // Out of memory
-	EACCES = 13 // Permission denied
-	EINVAL = 22 // Invalid argument
-	ESPIPE = 29 // Illegal seek (on pipes/sockets)
-	ENOSYS = 38 // Function not implemented
+	ENOENT       = 2  // No such file or directory
+	ESRCH        = 3  // No such process
+	EIO          = 5  // I/O error
+	EBADF        = 9  // Bad file descriptor
+	ENOMEM       = 12 // Out of memory
+	EACCES       = 13 // Permission denied
+	EFAULT       = 14 // Bad address
+	EEXIST       = 17 // File exists
+	ENODEV       = 19 // No such device
+	ENOTDIR      = 20 // Not a directory
+	EISDIR       = 21 // Is a directory
+	EINVAL       = 22 // Invalid argument
+	ENOTTY       = 25 // Inappropriate ioctl for device
+	ESPIPE       = 29 // Illegal seek (on pipes/sockets)
+	ERANGE       = 34 // Result too large
+	ENAMETOOLONG = 36 // File name too long
+	ENOSYS       = 38 // Function not implemented
+	ELOOP        = 40 // Too many levels of symbolic links
 )
 
 // Linux mmap protection flags.
@@ -46,6 +92,8 @@ const (
 	MAP_PRIVATE   = 0x2
 	MAP_FIXED     = 0x10
 	MAP_ANONYMOUS = 0x20
+
+	MAP_FIXED_NOREPLACE = 0x100000
 )
 
 // Linux open flags.
@@ -107,9 +155,23 @@ type DefaultSyscallHandler struct {
 	stdin        io.Reader
 	stdout       io.Writer
 	stderr       io.Writer
-	programBreak uint64       // Current program break (heap end)
-	nextMmapAddr uint64       // Next address for anonymous mmap
-	mmapRegions  []MmapRegion // Tracked mmap regions
+	programBreak uint64        // Current program break (heap end)
+	heapStart    uint64        // Initial program break (heap start)
+	addressSpace *AddressSpace // Mappings of the process
+
+	// Process state for the syscalls static libc startup makes
+	clearChildTID  uint64            // set_tid_address pointer
+	sigActions     [64]sigAction     // rt_sigaction table, by signal - 1
+	sigMask        uint64            // rt_sigprocmask blocked set
+	terminal       bool              // whether fds 0-2 are terminals
+	executablePath string            // target of /proc/self/exe
+	clock          *Clock            // simulated time
+	randomState    uint64            // getrandom generator state
+	unknown        map[uint64]uint64 // unimplemented syscalls, by number
+
+	// Syscall tracing
+	trace        io.Writer     // strace-format log, or nil
+	instructions func() uint64 // instructions executed so far
 }
 
 // DefaultProgramBreak is the initial program break address.
@@ -130,8 +192,11 @@ func NewDefaultSyscallHandler(regFile *RegFile, memory *Memory, stdout, stderr i
 		stdout:       stdout,
 		stderr:       stderr,
 		programBreak: DefaultProgramBreak,
-		nextMmapAddr: DefaultMmapBase,
-		mmapRegions:  make([]MmapRegion, 0),
+		heapStart:    DefaultProgramBreak,
+		addressSpace: NewAddressSpace(memory),
+		randomState:  randomSeed,
+		unknown:      make(map[uint64]uint64),
+		clock:        NewClock(func() uint64 { return 0 }, CoreFrequency),
 	}
 }
 
@@ -140,6 +205,13 @@ func (h *DefaultSyscallHandler) SetFDTable(fdTable *FDTable) {
 	h.fdTable = fdTable
 }
 
+// SetFileSystem sets the filesystem the program's paths resolve in, for
+// example a RootFS or MemFS that keeps it away from the host's files. It
+// must be set before the program opens files.
+func (h *DefaultSyscallHandler) SetFileSystem(fsys FileSystem) {
+	h.fdTable.SetFileSystem(fsys)
+}
+
 // GetFDTable returns the file descriptor table used by the syscall handler.
 func (h *DefaultSyscallHandler) GetFDTable() *FDTable {
 	return h.fdTable
@@ -150,20 +222,71 @@ func (h *DefaultSyscallHandler) SetStdin(stdin io.Reader) {
 	h.stdin = stdin
 }
 
+// SetTerminal sets whether the standard streams are terminals, which
+// decides whether terminal ioctls on them succeed. Programs line-buffer
+// their output on terminals.
+func (h *DefaultSyscallHandler) SetTerminal(terminal bool) {
+	h.terminal = terminal
+}
+
+// SetExecutablePath sets the path /proc/self/exe links to.
+func (h *DefaultSyscallHandler) SetExecutablePath(path string) {
+	h.executablePath = path
+}
+
+// SetClock sets the simulated clock the time syscalls read. The emulator
+// attaches its clock when it runs the handler; until then, time stands
+// still at boot.
+func (h *DefaultSyscallHandler) SetClock(clock *Clock) {
+	h.clock = clock
+}
+
 // GetProgramBreak returns the current program break.
 func (h *DefaultSyscallHandler) GetProgramBreak() uint64 {
 	return h.programBreak
 }
 
-// SetProgramBreak sets the program break to a specific address.
+// SetProgramBreak sets the program break to a specific address, where the
+// heap starts. brk never moves the break below it.
 func (h *DefaultSyscallHandler) SetProgramBreak(addr uint64) {
 	h.programBreak = addr
+	h.heapStart = addr
+}
+
+// AddressSpace returns the mappings of the simulated process. The loader
+// maps the program into it, so that mmap and brk avoid the program.
+func (h *DefaultSyscallHandler) AddressSpace() *AddressSpace {
+	return h.addressSpace
+}
+
+// CopyProcessState makes h's process state that of src: the program break,
+// the mappings and page protection, the signal state, the set_tid_address
+// pointer and the getrandom generator. A lockstep reference that does not
+// run syscalls itself calls it to follow the syscalls its pipeline ran. The
+// file descriptors, filesystem and standard streams remain h's own, as does
+// the contents of memory. Both handlers' memories must have the same page
+// size.
+func (h *DefaultSyscallHandler) CopyProcessState(src *DefaultSyscallHandler) {
+	h.programBreak = src.programBreak
+	h.heapStart = src.heapStart
+	h.addressSpace.copyFrom(src.addressSpace)
+	h.memory.copyProtection(src.memory)
+	h.clearChildTID = src.clearChildTID
+	h.sigActions = src.sigActions
+	h.sigMask = src.sigMask
+	h.randomState = src.randomState
 }
 
 // Handle executes the syscall indicated by the register file state.
 func (h *DefaultSyscallHandler) Handle() SyscallResult {
-	syscallNum := h.regFile.ReadReg(8)
+	if h.trace != nil {
+		return h.traceCall()
+	}
+	return h.dispatch(h.regFile.ReadReg(8))
+}
 
+// dispatch handles syscall number syscallNum.
+func (h *DefaultSyscallHandler) dispatch(syscallNum uint64) SyscallResult {
 	switch syscallNum {
 	case SyscallOpenat:
 		return h.handleOpenat()
@@ -177,22 +300,84 @@ func (h *DefaultSyscallHandler) Handle() SyscallResult {
 		return h.handleWrite()
 	case SyscallFstat:
 		return h.handleFstat()
-	case SyscallExit:
+	case SyscallReadv:
+		return h.handleReadv()
+	case SyscallWritev:
+		return h.handleWritev()
+	case SyscallPread64:
+		return h.handlePread64()
+	case SyscallPwrite64:
+		return h.handlePwrite64()
+	case SyscallIoctl:
+		return h.handleIoctl()
+	case SyscallNewfstatat:
+		return h.handleNewfstatat()
+	case SyscallStatx:
+		return h.handleStatx()
+	case SyscallReadlinkat:
+		return h.handleReadlinkat()
+	case SyscallGetcwd:
+		return h.handleGetcwd()
+	case SyscallExit, SyscallExitGroup:
 		return h.handleExit()
+	case SyscallSetTidAddress:
+		return h.handleSetTidAddress()
+	case SyscallSetRobustList:
+		return h.handleSetRobustList()
+	case SyscallRtSigaction:
+		return h.handleRtSigaction()
+	case SyscallRtSigprocmask:
+		return h.handleRtSigprocmask()
+	case SyscallUname:
+		return h.handleUname()
+	case SyscallGetpid, SyscallGettid:
+		return h.succeed(simulatedPID)
+	case SyscallGetppid:
+		return h.succeed(simulatedPPID)
+	case SyscallGetuid, SyscallGeteuid:
+		return h.succeed(simulatedUID)
+	case SyscallGetgid, SyscallGetegid:
+		return h.succeed(simulatedGID)
+	case SyscallClockGettime:
+		return h.handleClockGettime()
+	case SyscallNanosleep:
+		return h.handleNanosleep()
+	case SyscallClockNanosleep:
+		return h.handleClockNanosleep()
+	case SyscallGettimeofday:
+		return h.handleGettimeofday()
+	case SyscallGetrandom:
+		return h.handleGetrandom()
+	case SyscallPrlimit64:
+		return h.handlePrlimit64()
+	case SyscallSchedGetaffinity:
+		return h.handleSchedGetaffinity()
 	case SyscallBrk:
 		return h.handleBrk()
 	case SyscallMmap:
 		return h.handleMmap()
+	case SyscallMunmap:
+		return h.handleMunmap()
+	case SyscallMremap:
+		return h.handleMremap()
 	case SyscallMprotect:
 		return h.handleMprotect()
+	case SyscallMsync:
+		return h.handleMsync()
+	case SyscallMadvise:
+		return h.handleMadvise()
 	default:
 		return h.handleUnknown()
 	}
 }
 
-// handleExit handles the exit syscall (93).
+// handleExit handles the exit (93) and exit_group (94) syscalls. The
+// simulated process has a single thread, so they are the same. Shared file
+// mappings are written back, as the kernel does when the process's
+// mappings go away.
 func (h *DefaultSyscallHandler) handleExit() SyscallResult {
 	exitCode := int64(h.regFile.ReadReg(0))
+	_ = h.addressSpace.SyncAll()
 	return SyscallResult{
 		Exited:   true,
 		ExitCode: exitCode,
@@ -205,15 +390,61 @@ func (h *DefaultSyscallHandler) handleRead() SyscallResult {
 	bufPtr := h.regFile.ReadReg(1)
 	count := h.regFile.ReadReg(2)
 
+	n, errno := h.readChunks(bufPtr, count, func(buf []byte, _ uint64) (int, int) {
+		return h.readFD(fd, buf)
+	})
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	// Return bytes read
+	h.regFile.WriteReg(0, n)
+	return SyscallResult{}
+}
+
+// transferChunk is the most bytes readChunks and writeChunks buffer at once.
+const transferChunk = 1 << 20
+
+// readChunks reads up to count bytes, shortened to maxRWCount, into memory
+// at addr. It calls read with buffers of at most transferChunk bytes and the
+// number of bytes read before them, so that a huge count does not allocate
+// a buffer as large, and stops after a short read. read is called at least
+// once, so that a zero count still checks the file. It returns the number of
+// bytes read, or the Linux errno of a read that failed before any data.
+func (h *DefaultSyscallHandler) readChunks(addr, count uint64,
+	read func(buf []byte, done uint64) (int, int)) (uint64, int) {
+	count = min(count, maxRWCount)
+	buf := make([]byte, min(count, transferChunk))
+	var done uint64
+	for {
+		chunk := buf[:min(count-done, transferChunk)]
+		n, errno := read(chunk, done)
+		if errno != 0 {
+			if done > 0 {
+				break
+			}
+			return 0, errno
+		}
+		h.memory.WriteBytes(addr+done, chunk[:n])
+		done += uint64(n)
+		if n < len(chunk) || done == count {
+			break
+		}
+	}
+	return done, 0
+}
+
+// readFD reads from fd into buf. It returns the number of bytes read, 0 at
+// end of file, or a Linux errno.
+func (h *DefaultSyscallHandler) readFD(fd uint64, buf []byte) (int, int) {
 	var n int
 	var err error
-	buf := make([]byte, count)
 
 	if fd == 0 {
 		// stdin: use configured stdin reader
 		if h.stdin == nil {
-			h.regFile.WriteReg(0, 0) // EOF
-			return SyscallResult{}
+			return 0, 0 // EOF
 		}
 		n, err = h.stdin.Read(buf)
 	} else {
@@ -223,22 +454,16 @@ func (h *DefaultSyscallHandler) handleRead() SyscallResult {
 
 	if err != nil && n == 0 {
 		if err == os.ErrInvalid {
-			h.setError(EBADF)
-		} else {
-			// EOF or other error with no bytes read
-			h.regFile.WriteReg(0, 0)
+			return 0, EBADF
 		}
-		return SyscallResult{}
-	}
-
-	// Write to memory
-	for i := 0; i < n; i++ {
-		h.memory.Write8(bufPtr+uint64(i), buf[i])
+		if errors.Is(err, syscall.EBADF) || errors.Is(err, syscall.EISDIR) {
+			// Not open for reading, or a directory
+			return 0, hostErrno(err)
+		}
+		// EOF or other error with no bytes read
+		return 0, 0
 	}
-
-	// Return bytes read
-	h.regFile.WriteReg(0, uint64(n))
-	return SyscallResult{}
+	return n, 0
 }
 
 // handleWrite handles the write syscall (64).
@@ -247,12 +472,61 @@ func (h *DefaultSyscallHandler) handleWrite() SyscallResult {
 	bufPtr := h.regFile.ReadReg(1)
 	count := h.regFile.ReadReg(2)
 
-	// Read buffer from memory
-	buf := make([]byte, count)
-	for i := uint64(0); i < count; i++ {
-		buf[i] = h.memory.Read8(bufPtr + i)
+	n, errno := h.writeChunks(bufPtr, count, func(buf []byte, _ uint64) (int, int) {
+		return h.writeFD(fd, buf)
+	})
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	// Return bytes written
+	h.regFile.WriteReg(0, n)
+	return SyscallResult{}
+}
+
+// writeChunks writes up to count bytes, shortened to maxRWCount, from
+// memory at addr. It calls write with buffers of at most transferChunk bytes
+// and the number of bytes written before them, so that a huge count does not
+// allocate a buffer as large, and stops after a short write. When memory
+// enforces protection, the data ends at the first page that may not be
+// read. write is called at least once, so that a zero count still checks
+// the file. It returns the number of bytes written, or a Linux errno if
+// nothing was: EFAULT if addr may not be read, or that of the failed write.
+func (h *DefaultSyscallHandler) writeChunks(addr, count uint64,
+	write func(buf []byte, done uint64) (int, int)) (uint64, int) {
+	count = min(count, maxRWCount)
+	buf := make([]byte, min(count, transferChunk))
+	var done uint64
+	for {
+		size := min(count-done, transferChunk)
+		readable := h.memory.readableLen(addr+done, size)
+		if size > 0 && readable == 0 {
+			if done > 0 {
+				break
+			}
+			return 0, EFAULT
+		}
+		chunk := buf[:readable]
+		h.memory.ReadBytes(addr+done, chunk)
+		n, errno := write(chunk, done)
+		if errno != 0 {
+			if done > 0 {
+				break
+			}
+			return 0, errno
+		}
+		done += uint64(n)
+		if n < len(chunk) || readable < size || done == count {
+			break
+		}
 	}
+	return done, 0
+}
 
+// writeFD writes buf to fd. It returns the number of bytes written or a
+// Linux errno.
+func (h *DefaultSyscallHandler) writeFD(fd uint64, buf []byte) (int, int) {
 	var n int
 	var err error
 
@@ -270,20 +544,17 @@ func (h *DefaultSyscallHandler) handleWrite() SyscallResult {
 
 	if err != nil {
 		if err == os.ErrInvalid {
-			h.setError(EBADF)
-		} else {
-			h.setError(EIO)
+			return 0, EBADF
 		}
-		return SyscallResult{}
+		return 0, hostErrno(err)
 	}
-
-	// Return bytes written
-	h.regFile.WriteReg(0, uint64(n))
-	return SyscallResult{}
+	return n, 0
 }
 
-// handleUnknown handles unrecognized syscalls.
+// handleUnknown handles unrecognized syscalls. It records them for
+// UnknownSyscalls and fails them with ENOSYS, as Linux does.
 func (h *DefaultSyscallHandler) handleUnknown() SyscallResult {
+	h.unknown[h.regFile.ReadReg(8)]++
 	h.setError(ENOSYS)
 	return SyscallResult{}
 }
@@ -293,6 +564,38 @@ func (h *DefaultSyscallHandler) setError(errno int) {
 	h.regFile.WriteReg(0, uint64(-int64(errno)))
 }
 
+// succeed sets X0 to the return value of a successful syscall.
+func (h *DefaultSyscallHandler) succeed(value uint64) SyscallResult {
+	h.regFile.WriteReg(0, value)
+	return SyscallResult{}
+}
+
+// hostErrno converts an error from a host file operation to a Linux errno.
+func hostErrno(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ENOENT
+	case errors.Is(err, fs.ErrPermission):
+		return EACCES
+	case errors.Is(err, fs.ErrExist):
+		return EEXIST
+	case errors.Is(err, syscall.ENOTDIR):
+		return ENOTDIR
+	case errors.Is(err, syscall.EISDIR):
+		return EISDIR
+	case errors.Is(err, syscall.ELOOP):
+		return ELOOP
+	case errors.Is(err, syscall.ENAMETOOLONG):
+		return ENAMETOOLONG
+	case errors.Is(err, syscall.EINVAL):
+		return EINVAL
+	case errors.Is(err, syscall.EBADF):
+		return EBADF
+	default:
+		return EIO
+	}
+}
+
 // handleClose handles the close syscall (57).
 func (h *DefaultSyscallHandler) handleClose() SyscallResult {
 	fd := h.regFile.ReadReg(0)
@@ -330,13 +633,7 @@ func (h *DefaultSyscallHandler) handleOpenat() SyscallResult {
 	// Open the file
 	fd, err := h.fdTable.Open(pathname, goFlags, mode)
 	if err != nil {
-		if os.IsNotExist(err) {
-			h.setError(ENOENT)
-		} else if os.IsPermission(err) {
-			h.setError(EACCES)
-		} else {
-			h.setError(EIO)
-		}
+		h.setError(hostErrno(err))
 		return SyscallResult{}
 	}
 
@@ -387,106 +684,6 @@ func (h *DefaultSyscallHandler) linuxToGoFlags(linuxFlags int) int {
 	return goFlags
 }
 
-// handleBrk handles the brk syscall (214).
-// brk manages the program break (end of heap).
-// - addr == 0: query current program break
-// - addr < current: no change, return current break
-// - addr > current: extend heap, return new break
-func (h *DefaultSyscallHandler) handleBrk() SyscallResult {
-	addr := h.regFile.ReadReg(0)
-
-	// If addr is 0 or less than current break, just return current break
-	if addr == 0 || addr < h.programBreak {
-		h.regFile.WriteReg(0, h.programBreak)
-		return SyscallResult{}
-	}
-
-	// Extend the program break
-	h.programBreak = addr
-	h.regFile.WriteReg(0, h.programBreak)
-	return SyscallResult{}
-}
-
-// handleMmap handles the mmap syscall (222).
-// mmap maps memory regions. Currently only supports anonymous mappings.
-// Arguments:
-//   - X0: addr (hint address, or 0 for kernel to choose)
-//   - X1: length (size of mapping)
-//   - X2: prot (protection flags)
-//   - X3: flags (mapping flags)
-//   - X4: fd (file descriptor, -1 for anonymous)
-//   - X5: offset (offset in file)
-func (h *DefaultSyscallHandler) handleMmap() SyscallResult {
-	addr := h.regFile.ReadReg(0)
-	length := h.regFile.ReadReg(1)
-	prot := int(h.regFile.ReadReg(2))
-	flags := int(h.regFile.ReadReg(3))
-	fd := int64(h.regFile.ReadReg(4))
-	// offset := h.regFile.ReadReg(5) // Not used for anonymous mappings
-
-	// Validate length
-	if length == 0 {
-		h.setError(EINVAL)
-		return SyscallResult{}
-	}
-
-	// Check if anonymous mapping
-	isAnonymous := (flags & MAP_ANONYMOUS) != 0
-
-	// For now, only support anonymous mappings
-	// fd should be -1 for anonymous mappings
-	if !isAnonymous || (fd != -1 && !isAnonymous) {
-		h.setError(ENOSYS) // File mappings not implemented
-		return SyscallResult{}
-	}
-
-	// Page-align the length (4KB pages)
-	const pageSize uint64 = 4096
-	alignedLength := (length + pageSize - 1) & ^(pageSize - 1)
-
-	var mappedAddr uint64
-
-	// Handle MAP_FIXED
-	if flags&MAP_FIXED != 0 {
-		if addr == 0 {
-			h.setError(EINVAL)
-			return SyscallResult{}
-		}
-		// Use the requested address (page-aligned)
-		mappedAddr = addr & ^(pageSize - 1)
-	} else {
-		// Allocate from next available mmap address
-		mappedAddr = h.nextMmapAddr
-		h.nextMmapAddr += alignedLength
-	}
-
-	// Track the mapping
-	region := MmapRegion{
-		Addr:   mappedAddr,
-		Length: alignedLength,
-		Prot:   prot,
-		Flags:  flags,
-	}
-	h.mmapRegions = append(h.mmapRegions, region)
-
-	// Return the mapped address
-	h.regFile.WriteReg(0, mappedAddr)
-	return SyscallResult{}
-}
-
-// handleMprotect handles the mprotect syscall (226).
-// For emulation, we accept the call but don't enforce protection.
-// This matches gem5's approach in SE mode.
-func (h *DefaultSyscallHandler) handleMprotect() SyscallResult {
-	h.regFile.WriteReg(0, 0) // Return success
-	return SyscallResult{}
-}
-
-// GetMmapRegions returns the list of mmap'd regions.
-func (h *DefaultSyscallHandler) GetMmapRegions() []MmapRegion {
-	return h.mmapRegions
-}
-
 // handleLseek handles the lseek syscall (62).
 // lseek repositions the file offset of an open file descriptor.
 func (h *DefaultSyscallHandler) handleLseek() SyscallResult {
@@ -0,0 +1,421 @@
+package emu
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Flags of the *at syscalls.
+const (
+	AT_SYMLINK_NOFOLLOW = 0x100
+	AT_EMPTY_PATH       = 0x1000
+)
+
+// iovMax is the largest iovec count readv and writev accept (UIO_MAXIOV).
+const iovMax = 1024
+
+// maxRWCount is the most bytes one read or write transfers (MAX_RW_COUNT).
+// Larger requests are shortened to it, as Linux does.
+const maxRWCount = 0x7ffff000
+
+// iovec is a struct iovec read from simulated memory.
+type iovec struct {
+	base, len uint64
+}
+
+// readIovecs reads an iovec array from memory, shortening the buffers to
+// maxRWCount bytes in total. It fails with EINVAL if a length is negative
+// as an ssize_t.
+func (h *DefaultSyscallHandler) readIovecs(addr, count uint64) ([]iovec, int) {
+	iovs := make([]iovec, count)
+	var total uint64
+	for i := range iovs {
+		iovs[i].base = h.memory.Read64(addr + uint64(i)*16)
+		iovs[i].len = h.memory.Read64(addr + uint64(i)*16 + 8)
+		if int64(iovs[i].len) < 0 {
+			return nil, EINVAL
+		}
+		iovs[i].len = min(iovs[i].len, maxRWCount-total)
+		total += iovs[i].len
+	}
+	return iovs, 0
+}
+
+// handleReadv handles the readv syscall (65). Buffers are filled in order
+// until one is only partly filled.
+func (h *DefaultSyscallHandler) handleReadv() SyscallResult {
+	fd := h.regFile.ReadReg(0)
+	iovPtr := h.regFile.ReadReg(1)
+	iovcnt := h.regFile.ReadReg(2)
+
+	if iovcnt > iovMax {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	iovs, errno := h.readIovecs(iovPtr, iovcnt)
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	var total uint64
+	for _, iov := range iovs {
+		if iov.len == 0 {
+			continue
+		}
+
+		n, errno := h.readChunks(iov.base, iov.len, func(buf []byte, _ uint64) (int, int) {
+			return h.readFD(fd, buf)
+		})
+		if errno != 0 {
+			if total > 0 {
+				break
+			}
+			h.setError(errno)
+			return SyscallResult{}
+		}
+
+		total += n
+		if n < iov.len {
+			break
+		}
+	}
+
+	return h.succeed(total)
+}
+
+// handleWritev handles the writev syscall (66). Buffers are written in
+// order until one is only partly written.
+func (h *DefaultSyscallHandler) handleWritev() SyscallResult {
+	fd := h.regFile.ReadReg(0)
+	iovPtr := h.regFile.ReadReg(1)
+	iovcnt := h.regFile.ReadReg(2)
+
+	if iovcnt > iovMax {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	iovs, errno := h.readIovecs(iovPtr, iovcnt)
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	if len(iovs) == 0 {
+		iovs = []iovec{{}} // an empty write still checks the file
+	}
+
+	var total uint64
+	for _, iov := range iovs {
+		n, errno := h.writeChunks(iov.base, iov.len, func(buf []byte, _ uint64) (int, int) {
+			return h.writeFD(fd, buf)
+		})
+		if errno != 0 {
+			if total > 0 {
+				break
+			}
+			h.setError(errno)
+			return SyscallResult{}
+		}
+
+		total += n
+		if n < iov.len {
+			break
+		}
+	}
+
+	return h.succeed(total)
+}
+
+// handlePread64 handles the pread64 syscall (67).
+func (h *DefaultSyscallHandler) handlePread64() SyscallResult {
+	fd := h.regFile.ReadReg(0)
+	bufPtr := h.regFile.ReadReg(1)
+	count := h.regFile.ReadReg(2)
+	offset := int64(h.regFile.ReadReg(3))
+
+	if errno := h.checkPositionalIO(fd, offset); errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	n, errno := h.readChunks(bufPtr, count, func(buf []byte, done uint64) (int, int) {
+		n, err := h.fdTable.ReadAt(fd, buf, offset+int64(done))
+		if err != nil {
+			return 0, hostErrno(err)
+		}
+		return n, 0
+	})
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+	return h.succeed(n)
+}
+
+// handlePwrite64 handles the pwrite64 syscall (68).
+func (h *DefaultSyscallHandler) handlePwrite64() SyscallResult {
+	fd := h.regFile.ReadReg(0)
+	bufPtr := h.regFile.ReadReg(1)
+	count := h.regFile.ReadReg(2)
+	offset := int64(h.regFile.ReadReg(3))
+
+	if errno := h.checkPositionalIO(fd, offset); errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	n, errno := h.writeChunks(bufPtr, count, func(buf []byte, done uint64) (int, int) {
+		n, err := h.fdTable.WriteAt(fd, buf, offset+int64(done))
+		if err != nil {
+			return 0, hostErrno(err)
+		}
+		return n, 0
+	})
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+	return h.succeed(n)
+}
+
+// checkPositionalIO validates the fd and offset of pread64 and pwrite64.
+func (h *DefaultSyscallHandler) checkPositionalIO(fd uint64, offset int64) int {
+	switch {
+	case !h.fdTable.IsOpen(fd):
+		return EBADF
+	case fd <= 2:
+		return ESPIPE
+	case offset < 0:
+		return EINVAL
+	}
+	return 0
+}
+
+// Terminal ioctl requests.
+const (
+	TCGETS     = 0x5401
+	TIOCGWINSZ = 0x5413
+)
+
+// handleIoctl handles the ioctl syscall (29). Only the terminal queries
+// libc makes to choose stdio buffering are supported, and only the standard
+// streams are terminals, when the handler is told they are.
+func (h *DefaultSyscallHandler) handleIoctl() SyscallResult {
+	fd := h.regFile.ReadReg(0)
+	request := h.regFile.ReadReg(1)
+	arg := h.regFile.ReadReg(2)
+
+	if !h.fdTable.IsOpen(fd) {
+		h.setError(EBADF)
+		return SyscallResult{}
+	}
+	if fd > 2 || !h.terminal {
+		h.setError(ENOTTY)
+		return SyscallResult{}
+	}
+
+	switch request {
+	case TCGETS:
+		// struct termios: c_iflag, c_oflag, c_cflag, c_lflag, c_line and
+		// c_cc[19], with the flags of a freshly opened terminal.
+		termios := make([]byte, 36)
+		h.memory.WriteBytes(arg, termios)
+		h.memory.Write32(arg, 0x500)     // ICRNL | IXON
+		h.memory.Write32(arg+4, 0x5)     // OPOST | ONLCR
+		h.memory.Write32(arg+8, 0xbf)    // B38400 | CS8 | CREAD
+		h.memory.Write32(arg+12, 0x8a3b) // ISIG | ICANON | ECHO | ECHOE | ECHOK | ECHOCTL | ECHOKE | IEXTEN
+	case TIOCGWINSZ:
+		// struct winsize: ws_row, ws_col, ws_xpixel, ws_ypixel.
+		h.memory.Write16(arg, 24)
+		h.memory.Write16(arg+2, 80)
+		h.memory.Write32(arg+4, 0)
+	default:
+		h.setError(ENOTTY)
+		return SyscallResult{}
+	}
+	return h.succeed(0)
+}
+
+// resolvePath resolves a path relative to a directory file descriptor.
+func (h *DefaultSyscallHandler) resolvePath(dirfd uint64, path string) (string, int) {
+	if filepath.IsAbs(path) || dirfd == AT_FDCWD_U64 {
+		return path, 0
+	}
+
+	entry, ok := h.fdTable.Get(dirfd)
+	if !ok {
+		return "", EBADF
+	}
+	return filepath.Join(entry.Path, path), 0
+}
+
+// statAt returns file information for the *at stat syscalls. An empty path
+// with AT_EMPTY_PATH refers to dirfd itself.
+func (h *DefaultSyscallHandler) statAt(dirfd, pathPtr, flags uint64) (os.FileInfo, int) {
+	var path string
+	if pathPtr != 0 {
+		path = h.readString(pathPtr)
+	}
+
+	if path == "" {
+		if flags&AT_EMPTY_PATH == 0 {
+			return nil, ENOENT
+		}
+		if dirfd == AT_FDCWD_U64 {
+			path = "."
+		} else {
+			info, err := h.fdTable.Stat(dirfd)
+			if err != nil {
+				return nil, EBADF
+			}
+			return info, 0
+		}
+	}
+
+	path, errno := h.resolvePath(dirfd, path)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	var info os.FileInfo
+	var err error
+	if flags&AT_SYMLINK_NOFOLLOW != 0 {
+		info, err = h.fdTable.FileSystem().Lstat(path)
+	} else {
+		info, err = h.fdTable.FileSystem().Stat(path)
+	}
+	if err != nil {
+		return nil, hostErrno(err)
+	}
+	return info, 0
+}
+
+// handleNewfstatat handles the newfstatat syscall (79).
+func (h *DefaultSyscallHandler) handleNewfstatat() SyscallResult {
+	dirfd := h.regFile.ReadReg(0)
+	pathPtr := h.regFile.ReadReg(1)
+	statbufPtr := h.regFile.ReadReg(2)
+	flags := h.regFile.ReadReg(3)
+
+	info, errno := h.statAt(dirfd, pathPtr, flags)
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	h.writeStatToMemory(statbufPtr, info)
+	return h.succeed(0)
+}
+
+// ARM64 Linux statx structure offsets (256 bytes total). Timestamps are a
+// 64-bit seconds field followed by a 32-bit nanoseconds field.
+const (
+	statxOffsetMask    = 0   // uint32
+	statxOffsetBlksize = 4   // uint32
+	statxOffsetNlink   = 16  // uint32
+	statxOffsetUID     = 20  // uint32
+	statxOffsetGID     = 24  // uint32
+	statxOffsetMode    = 28  // uint16
+	statxOffsetIno     = 32  // uint64
+	statxOffsetSize    = 40  // uint64
+	statxOffsetBlocks  = 48  // uint64
+	statxOffsetAtime   = 64  // statx_timestamp
+	statxOffsetCtime   = 96  // statx_timestamp
+	statxOffsetMtime   = 112 // statx_timestamp
+	statxSize          = 256
+
+	// STATX_BASIC_STATS is the statx mask of the fields stat also returns.
+	STATX_BASIC_STATS = 0x7ff
+)
+
+// handleStatx handles the statx syscall (291). The basic stat fields are
+// always returned, whatever the requested mask.
+func (h *DefaultSyscallHandler) handleStatx() SyscallResult {
+	dirfd := h.regFile.ReadReg(0)
+	pathPtr := h.regFile.ReadReg(1)
+	flags := h.regFile.ReadReg(2)
+	statxPtr := h.regFile.ReadReg(4)
+
+	info, errno := h.statAt(dirfd, pathPtr, flags)
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+
+	h.memory.WriteBytes(statxPtr, make([]byte, statxSize))
+	h.memory.Write32(statxPtr+statxOffsetMask, STATX_BASIC_STATS)
+	h.memory.Write32(statxPtr+statxOffsetBlksize, 4096)
+	h.memory.Write32(statxPtr+statxOffsetNlink, 1)
+	h.memory.Write16(statxPtr+statxOffsetMode, uint16(h.fileInfoToLinuxMode(info)))
+	h.memory.Write64(statxPtr+statxOffsetSize, uint64(info.Size()))
+	h.memory.Write64(statxPtr+statxOffsetBlocks, uint64((info.Size()+511)/512))
+
+	modTime := info.ModTime()
+	for _, offset := range []uint64{statxOffsetAtime, statxOffsetCtime, statxOffsetMtime} {
+		h.memory.Write64(statxPtr+offset, uint64(modTime.Unix()))
+		h.memory.Write32(statxPtr+offset+8, uint32(modTime.Nanosecond()))
+	}
+	return h.succeed(0)
+}
+
+// handleReadlinkat handles the readlinkat syscall (78). /proc/self/exe
+// names the simulated program rather than the simulator. Like Linux, the
+// result is truncated to the buffer and not NUL-terminated.
+func (h *DefaultSyscallHandler) handleReadlinkat() SyscallResult {
+	dirfd := h.regFile.ReadReg(0)
+	path := h.readString(h.regFile.ReadReg(1))
+	bufPtr := h.regFile.ReadReg(2)
+	bufsiz := int64(h.regFile.ReadReg(3))
+
+	if bufsiz <= 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	var target string
+	if path == "/proc/self/exe" && h.executablePath != "" {
+		target = h.executablePath
+	} else {
+		resolved, errno := h.resolvePath(dirfd, path)
+		if errno != 0 {
+			h.setError(errno)
+			return SyscallResult{}
+		}
+
+		var err error
+		target, err = h.fdTable.FileSystem().Readlink(resolved)
+		if err != nil {
+			h.setError(hostErrno(err))
+			return SyscallResult{}
+		}
+	}
+
+	if int64(len(target)) > bufsiz {
+		target = target[:bufsiz]
+	}
+	h.memory.WriteBytes(bufPtr, []byte(target))
+	return h.succeed(uint64(len(target)))
+}
+
+// handleGetcwd handles the getcwd syscall (17). Like the raw Linux syscall,
+// it returns the length of the path including its NUL terminator.
+func (h *DefaultSyscallHandler) handleGetcwd() SyscallResult {
+	bufPtr := h.regFile.ReadReg(0)
+	size := h.regFile.ReadReg(1)
+
+	cwd, err := h.fdTable.FileSystem().Getwd()
+	if err != nil {
+		h.setError(hostErrno(err))
+		return SyscallResult{}
+	}
+
+	path := append([]byte(cwd), 0)
+	if uint64(len(path)) > size {
+		h.setError(ERANGE)
+		return SyscallResult{}
+	}
+
+	h.memory.WriteBytes(bufPtr, path)
+	return h.succeed(uint64(len(path)))
+}
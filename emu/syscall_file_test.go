@@ -0,0 +1,252 @@
+package emu_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("File Syscalls", func() {
+	var (
+		regFile *emu.RegFile
+		memory  *emu.Memory
+		stdout  *bytes.Buffer
+		handler *emu.DefaultSyscallHandler
+		sys     syscaller
+		tempDir string
+	)
+
+	BeforeEach(func() {
+		regFile = &emu.RegFile{}
+		memory = emu.NewMemory()
+		stdout = new(bytes.Buffer)
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, stdout, new(bytes.Buffer))
+		sys = syscaller{handler, regFile}
+
+		var err error
+		tempDir, err = os.MkdirTemp("", "syscall_file_test")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(tempDir)
+	})
+
+	writeString := func(addr uint64, s string) uint64 {
+		memory.WriteBytes(addr, append([]byte(s), 0))
+		return addr
+	}
+
+	readString := func(addr uint64, n int64) string {
+		buf := make([]byte, n)
+		memory.ReadBytes(addr, buf)
+		return string(buf)
+	}
+
+	// open creates a file with content and opens it for reading and writing.
+	open := func(content string) uint64 {
+		path := filepath.Join(tempDir, "data.txt")
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		fd := sys.call(emu.SyscallOpenat, emu.AT_FDCWD_U64, writeString(0x100, path), emu.O_RDWR, 0)
+		Expect(fd).To(BeNumerically(">=", 3))
+		return uint64(fd)
+	}
+
+	It("should gather buffers with writev", func() {
+		memory.WriteBytes(0x1000, []byte("hello, "))
+		memory.WriteBytes(0x2000, []byte("world\n"))
+		memory.Write64(0x3000, 0x1000)
+		memory.Write64(0x3008, 7)
+		memory.Write64(0x3010, 0x2000)
+		memory.Write64(0x3018, 6)
+
+		Expect(sys.call(emu.SyscallWritev, 1, 0x3000, 2)).To(Equal(int64(13)))
+		Expect(stdout.String()).To(Equal("hello, world\n"))
+
+		Expect(sys.call(emu.SyscallWritev, 1, 0x3000, 1025)).To(Equal(int64(-emu.EINVAL)))
+		Expect(sys.call(emu.SyscallWritev, 42, 0x3000, 2)).To(Equal(int64(-emu.EBADF)))
+	})
+
+	It("should scatter a read with readv", func() {
+		fd := open("abcdefgh")
+		memory.Write64(0x3000, 0x1000)
+		memory.Write64(0x3008, 3)
+		memory.Write64(0x3010, 0x2000)
+		memory.Write64(0x3018, 10)
+
+		Expect(sys.call(emu.SyscallReadv, fd, 0x3000, 2)).To(Equal(int64(8)))
+		Expect(readString(0x1000, 3)).To(Equal("abc"))
+		Expect(readString(0x2000, 5)).To(Equal("defgh"))
+	})
+
+	It("should read and write at offsets without moving the file position", func() {
+		fd := open("0123456789")
+
+		Expect(sys.call(emu.SyscallPread64, fd, 0x1000, 4, 6)).To(Equal(int64(4)))
+		Expect(readString(0x1000, 4)).To(Equal("6789"))
+		Expect(sys.call(emu.SyscallPread64, fd, 0x1000, 4, 20)).To(BeZero())
+
+		memory.WriteBytes(0x2000, []byte("XY"))
+		Expect(sys.call(emu.SyscallPwrite64, fd, 0x2000, 2, 2)).To(Equal(int64(2)))
+
+		Expect(sys.call(emu.SyscallRead, fd, 0x1000, 4)).To(Equal(int64(4)))
+		Expect(readString(0x1000, 4)).To(Equal("01XY"))
+
+		Expect(sys.call(emu.SyscallPread64, 0, 0x1000, 4, 0)).To(Equal(int64(-emu.ESPIPE)))
+		Expect(sys.call(emu.SyscallPread64, fd, 0x1000, 4, ^uint64(0))).To(Equal(int64(-emu.EINVAL)))
+		Expect(sys.call(emu.SyscallPwrite64, 42, 0x2000, 2, 0)).To(Equal(int64(-emu.EBADF)))
+	})
+
+	It("should shorten huge transfers and reject negative lengths", func() {
+		fd := open("abcdefgh")
+
+		Expect(sys.call(emu.SyscallPread64, fd, 0x1000, 1<<62, 0)).To(Equal(int64(8)))
+		Expect(readString(0x1000, 8)).To(Equal("abcdefgh"))
+
+		memory.Write64(0x3000, 0x1000)
+		memory.Write64(0x3008, 1<<62)
+		memory.Write64(0x3010, 0x2000)
+		memory.Write64(0x3018, 1<<62)
+		Expect(sys.call(emu.SyscallReadv, fd, 0x3000, 2)).To(Equal(int64(8)))
+
+		memory.Write64(0x3008, ^uint64(0))
+		Expect(sys.call(emu.SyscallReadv, fd, 0x3000, 2)).To(Equal(int64(-emu.EINVAL)))
+		Expect(sys.call(emu.SyscallWritev, 1, 0x3000, 2)).To(Equal(int64(-emu.EINVAL)))
+	})
+
+	It("should write huge counts only up to the end of a mapped buffer", func() {
+		memory = emu.NewMemory(emu.WithProtection())
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, stdout, new(bytes.Buffer))
+		sys = syscaller{handler, regFile}
+		const size = 1<<20 + 4096
+		memory.Map(0x100000, size, emu.PROT_READ|emu.PROT_WRITE)
+		memory.WriteBytes(0x100000+size-4, []byte("tail"))
+
+		Expect(sys.call(emu.SyscallWrite, 1, 0x100000, 1<<40)).To(Equal(int64(size)))
+		Expect(stdout.Len()).To(Equal(size))
+		Expect(stdout.String()).To(HaveSuffix("tail"))
+
+		memory.Write64(0x3000, 0x100000)
+		memory.Write64(0x3008, 1<<40)
+		memory.Write64(0x3010, 0x100000)
+		memory.Write64(0x3018, 4)
+		memory.Map(0x3000, 4096, emu.PROT_READ)
+		stdout.Reset()
+		Expect(sys.call(emu.SyscallWritev, 1, 0x3000, 2)).To(Equal(int64(size)))
+		Expect(stdout.Len()).To(Equal(size))
+
+		Expect(sys.call(emu.SyscallWrite, 1, 0x900000, 1<<40)).To(Equal(int64(-emu.EFAULT)))
+	})
+
+	It("should read more than a megabyte at once", func() {
+		fd := open(strings.Repeat("x", 1<<20) + "tail")
+
+		Expect(sys.call(emu.SyscallRead, fd, 0x100000, 1<<21)).To(Equal(int64(1<<20 + 4)))
+		Expect(readString(0x100000+1<<20, 4)).To(Equal("tail"))
+		Expect(sys.call(emu.SyscallRead, fd, 0x100000, 1<<21)).To(BeZero())
+		Expect(sys.call(emu.SyscallRead, 42, 0x100000, 0)).To(Equal(int64(-emu.EBADF)))
+	})
+
+	Describe("ioctl", func() {
+		It("should not treat the standard streams as terminals by default", func() {
+			Expect(sys.call(emu.SyscallIoctl, 1, emu.TIOCGWINSZ, 0x1000)).To(Equal(int64(-emu.ENOTTY)))
+			Expect(sys.call(emu.SyscallIoctl, 42, emu.TCGETS, 0x1000)).To(Equal(int64(-emu.EBADF)))
+		})
+
+		It("should describe a terminal when told the streams are one", func() {
+			handler.SetTerminal(true)
+
+			Expect(sys.call(emu.SyscallIoctl, 1, emu.TIOCGWINSZ, 0x1000)).To(BeZero())
+			Expect(memory.Read16(0x1000)).To(Equal(uint16(24)))
+			Expect(memory.Read16(0x1002)).To(Equal(uint16(80)))
+
+			Expect(sys.call(emu.SyscallIoctl, 0, emu.TCGETS, 0x2000)).To(BeZero())
+			Expect(memory.Read32(0x200C) & 0x2).NotTo(BeZero()) // ICANON
+
+			Expect(sys.call(emu.SyscallIoctl, 1, 0x5402, 0x1000)).To(Equal(int64(-emu.ENOTTY)))
+			Expect(sys.call(emu.SyscallIoctl, open(""), emu.TCGETS, 0x1000)).To(Equal(int64(-emu.ENOTTY)))
+		})
+	})
+
+	Describe("newfstatat and statx", func() {
+		It("should stat a path", func() {
+			path := filepath.Join(tempDir, "file")
+			Expect(os.WriteFile(path, []byte("12345"), 0644)).To(Succeed())
+
+			Expect(sys.call(emu.SyscallNewfstatat, emu.AT_FDCWD_U64, writeString(0x100, path), 0x1000, 0)).To(BeZero())
+			Expect(memory.Read64(0x1000 + 48)).To(Equal(uint64(5)))
+			Expect(memory.Read32(0x1000+16) & emu.S_IFMT).To(Equal(uint32(emu.S_IFREG)))
+
+			Expect(sys.call(emu.SyscallStatx, emu.AT_FDCWD_U64, 0x100, 0, emu.STATX_BASIC_STATS, 0x2000)).To(BeZero())
+			Expect(memory.Read32(0x2000)).To(Equal(uint32(emu.STATX_BASIC_STATS)))
+			Expect(memory.Read64(0x2000 + 40)).To(Equal(uint64(5)))
+			Expect(uint32(memory.Read16(0x2000+28)) & emu.S_IFMT).To(Equal(uint32(emu.S_IFREG)))
+		})
+
+		It("should stat a file descriptor with AT_EMPTY_PATH", func() {
+			fd := open("abc")
+
+			Expect(sys.call(emu.SyscallNewfstatat, fd, writeString(0x100, ""), 0x1000, emu.AT_EMPTY_PATH)).To(BeZero())
+			Expect(memory.Read64(0x1000 + 48)).To(Equal(uint64(3)))
+
+			Expect(sys.call(emu.SyscallNewfstatat, fd, 0x100, 0x1000, 0)).To(Equal(int64(-emu.ENOENT)))
+		})
+
+		It("should not follow symbolic links with AT_SYMLINK_NOFOLLOW", func() {
+			link := filepath.Join(tempDir, "link")
+			Expect(os.Symlink(tempDir, link)).To(Succeed())
+			writeString(0x100, link)
+
+			Expect(sys.call(emu.SyscallStatx, emu.AT_FDCWD_U64, 0x100, emu.AT_SYMLINK_NOFOLLOW, 0, 0x2000)).To(BeZero())
+			Expect(uint32(memory.Read16(0x2000+28)) & emu.S_IFMT).To(Equal(uint32(emu.S_IFLNK)))
+
+			Expect(sys.call(emu.SyscallStatx, emu.AT_FDCWD_U64, 0x100, 0, 0, 0x2000)).To(BeZero())
+			Expect(uint32(memory.Read16(0x2000+28)) & emu.S_IFMT).To(Equal(uint32(emu.S_IFDIR)))
+		})
+
+		It("should fail for missing files", func() {
+			path := writeString(0x100, filepath.Join(tempDir, "missing"))
+			Expect(sys.call(emu.SyscallNewfstatat, emu.AT_FDCWD_U64, path, 0x1000, 0)).To(Equal(int64(-emu.ENOENT)))
+		})
+	})
+
+	Describe("readlinkat", func() {
+		It("should name the simulated program as /proc/self/exe", func() {
+			handler.SetExecutablePath("/bench/mcf")
+			writeString(0x100, "/proc/self/exe")
+
+			Expect(sys.call(emu.SyscallReadlinkat, emu.AT_FDCWD_U64, 0x100, 0x1000, 64)).To(Equal(int64(10)))
+			Expect(readString(0x1000, 10)).To(Equal("/bench/mcf"))
+
+			Expect(sys.call(emu.SyscallReadlinkat, emu.AT_FDCWD_U64, 0x100, 0x1000, 4)).To(Equal(int64(4)))
+			Expect(sys.call(emu.SyscallReadlinkat, emu.AT_FDCWD_U64, 0x100, 0x1000, 0)).To(Equal(int64(-emu.EINVAL)))
+		})
+
+		It("should read host symbolic links", func() {
+			link := filepath.Join(tempDir, "link")
+			Expect(os.Symlink("target", link)).To(Succeed())
+
+			Expect(sys.call(emu.SyscallReadlinkat, emu.AT_FDCWD_U64, writeString(0x100, link), 0x1000, 64)).To(Equal(int64(6)))
+			Expect(readString(0x1000, 6)).To(Equal("target"))
+
+			Expect(sys.call(emu.SyscallReadlinkat, emu.AT_FDCWD_U64, writeString(0x100, tempDir), 0x1000, 64)).
+				To(Equal(int64(-emu.EINVAL)))
+		})
+	})
+
+	It("should return the working directory with its terminator", func() {
+		cwd, err := os.Getwd()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(sys.call(emu.SyscallGetcwd, 0x1000, 4096)).To(Equal(int64(len(cwd) + 1)))
+		Expect(readString(0x1000, int64(len(cwd)+1))).To(Equal(cwd + "\x00"))
+
+		Expect(sys.call(emu.SyscallGetcwd, 0x1000, 1)).To(Equal(int64(-emu.ERANGE)))
+	})
+})
@@ -0,0 +1,313 @@
+package emu
+
+import (
+	"os"
+)
+
+// Linux mremap flags.
+const (
+	MREMAP_MAYMOVE = 0x1
+	MREMAP_FIXED   = 0x2
+)
+
+// MADV_DONTNEED discards the pages of a range. Private anonymous pages
+// read as zero afterwards.
+const MADV_DONTNEED = 4
+
+// Linux msync flags.
+const (
+	MS_ASYNC      = 1
+	MS_INVALIDATE = 2
+	MS_SYNC       = 4
+)
+
+// mapShareMask selects the mapping type from mmap flags: MAP_SHARED,
+// MAP_PRIVATE or MAP_SHARED_VALIDATE.
+const mapShareMask = 0x3
+
+// handleBrk handles the brk syscall (214).
+// brk manages the program break (end of heap).
+// - addr == 0 or below the heap start: query current program break
+// - addr < current: shrink the heap, return new break
+// - addr > current: extend heap, return new break
+// The heap cannot grow into another mapping; brk then returns the current
+// break, as Linux does.
+func (h *DefaultSyscallHandler) handleBrk() SyscallResult {
+	addr := h.regFile.ReadReg(0)
+
+	oldEnd, newEnd := pageUp(h.programBreak), pageUp(addr)
+	if addr < h.heapStart || newEnd < addr {
+		return h.succeed(h.programBreak)
+	}
+
+	switch {
+	case newEnd < oldEnd:
+		h.addressSpace.Unmap(newEnd, oldEnd-newEnd)
+	case newEnd > oldEnd:
+		if !h.addressSpace.free(oldEnd, newEnd) {
+			return h.succeed(h.programBreak)
+		}
+		h.memory.Zero(oldEnd, newEnd-oldEnd)
+	}
+	if start := pageUp(h.heapStart); newEnd > start {
+		h.addressSpace.MapNamed(start, newEnd-start, PROT_READ|PROT_WRITE, "[heap]")
+	}
+
+	h.programBreak = addr
+	return h.succeed(h.programBreak)
+}
+
+// handleMmap handles the mmap syscall (222).
+// mmap maps anonymous memory or a file, privately or shared. Shared file
+// mappings are written back to the file on msync, munmap and exit.
+// Arguments:
+//   - X0: addr (hint address, or 0 for kernel to choose)
+//   - X1: length (size of mapping)
+//   - X2: prot (protection flags)
+//   - X3: flags (mapping flags)
+//   - X4: fd (file descriptor, -1 for anonymous)
+//   - X5: offset (offset in file)
+func (h *DefaultSyscallHandler) handleMmap() SyscallResult {
+	addr := h.regFile.ReadReg(0)
+	length := h.regFile.ReadReg(1)
+	prot := int(h.regFile.ReadReg(2))
+	flags := int(h.regFile.ReadReg(3))
+	fd := h.regFile.ReadReg(4)
+	offset := h.regFile.ReadReg(5)
+
+	if length == 0 || offset%vmaPageSize != 0 || flags&mapShareMask == 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	size := pageUp(length)
+	if size == 0 {
+		h.setError(ENOMEM)
+		return SyscallResult{}
+	}
+
+	v := VMA{Prot: prot, Flags: flags & (mapShareMask | MAP_ANONYMOUS), mmapped: true}
+	if v.Flags&mapShareMask != MAP_PRIVATE {
+		v.Flags = v.Flags&^mapShareMask | MAP_SHARED
+	}
+	if flags&MAP_ANONYMOUS == 0 {
+		if errno := h.openMapping(fd, &v); errno != 0 {
+			h.setError(errno)
+			return SyscallResult{}
+		}
+		v.Offset = offset
+	}
+
+	switch {
+	case flags&(MAP_FIXED|MAP_FIXED_NOREPLACE) != 0:
+		if addr == 0 || addr%vmaPageSize != 0 || addr+size < addr {
+			h.release(v)
+			h.setError(EINVAL)
+			return SyscallResult{}
+		}
+		if flags&MAP_FIXED == 0 && !h.addressSpace.free(addr, addr+size) {
+			h.release(v)
+			h.setError(EEXIST)
+			return SyscallResult{}
+		}
+		v.Start = addr
+	case addr != 0 && pageDown(addr)+size > pageDown(addr) &&
+		h.addressSpace.free(pageDown(addr), pageDown(addr)+size):
+		v.Start = pageDown(addr)
+	default:
+		start, ok := h.addressSpace.findFree(size)
+		if !ok {
+			h.release(v)
+			h.setError(ENOMEM)
+			return SyscallResult{}
+		}
+		v.Start = start
+	}
+	v.End = v.Start + size
+
+	if err := h.addressSpace.mapArea(v); err != nil {
+		h.addressSpace.Unmap(v.Start, size)
+		h.setError(hostErrno(err))
+		return SyscallResult{}
+	}
+	return h.succeed(v.Start)
+}
+
+// openMapping opens the file behind fd for mapping v. The mapping keeps its
+// own reference to the file, which lets the program close fd. As in Linux,
+// the file must be open for reading, and for writing too if v is a shared
+// writable mapping.
+func (h *DefaultSyscallHandler) openMapping(fd uint64, v *VMA) int {
+	entry, ok := h.fdTable.Get(fd)
+	if !ok {
+		return EBADF
+	}
+	if entry.File == nil {
+		return ENODEV
+	}
+
+	access := entry.Flags & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	shared := v.Flags&MAP_SHARED != 0 && v.Prot&PROT_WRITE != 0
+	if access == os.O_WRONLY || shared && access != os.O_RDWR {
+		return EACCES
+	}
+
+	file, err := h.fdTable.Reopen(fd, fileFlags(*v))
+	if err != nil {
+		return hostErrno(err)
+	}
+	v.file = file
+	v.Name = entry.Path
+	return 0
+}
+
+// fileFlags returns the flags to open the file of mapping v with.
+func fileFlags(v VMA) int {
+	if v.Flags&MAP_SHARED != 0 && v.Prot&PROT_WRITE != 0 {
+		return os.O_RDWR
+	}
+	return os.O_RDONLY
+}
+
+// release closes the file of a mapping that was never made.
+func (h *DefaultSyscallHandler) release(v VMA) {
+	if v.file != nil {
+		_ = v.file.Close()
+	}
+}
+
+// handleMprotect handles the mprotect syscall (226).
+// It updates the protection of the pages in the range, which must all be
+// mapped, splitting the mappings it covers in part. Pages mapped in memory
+// outside the address space are updated too. Unless the memory enforces
+// protection, the call always succeeds, matching gem5's approach in SE mode.
+func (h *DefaultSyscallHandler) handleMprotect() SyscallResult {
+	addr := h.regFile.ReadReg(0)
+	length := h.regFile.ReadReg(1)
+	prot := int(h.regFile.ReadReg(2))
+
+	if !h.addressSpace.Protect(addr, length, prot) &&
+		!h.memory.Protect(addr, length, prot) && h.memory.ProtectionEnabled() {
+		h.setError(ENOMEM)
+		return SyscallResult{}
+	}
+
+	h.regFile.WriteReg(0, 0) // Return success
+	return SyscallResult{}
+}
+
+// handleMunmap handles the munmap syscall (215). Mappings that straddle
+// the range are split, and only their parts inside it are removed.
+func (h *DefaultSyscallHandler) handleMunmap() SyscallResult {
+	addr := h.regFile.ReadReg(0)
+	length := h.regFile.ReadReg(1)
+
+	if addr%vmaPageSize != 0 || length == 0 || addr+pageUp(length) < addr {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	h.addressSpace.Unmap(addr, length)
+	return h.succeed(0)
+}
+
+// handleMremap handles the mremap syscall (216). The range must lie within
+// one mapping. It shrinks in place, and grows in place if the pages after
+// it are free; otherwise it moves to a new address, which MREMAP_MAYMOVE
+// must allow. An old size of zero asks for a second copy of a shared
+// mapping, which is not implemented, and is invalid for a private one.
+func (h *DefaultSyscallHandler) handleMremap() SyscallResult {
+	oldAddr := h.regFile.ReadReg(0)
+	oldSize := h.regFile.ReadReg(1)
+	newSize := h.regFile.ReadReg(2)
+	flags := int(h.regFile.ReadReg(3))
+
+	if oldAddr%vmaPageSize != 0 || newSize == 0 || flags&^(MREMAP_MAYMOVE|MREMAP_FIXED) != 0 ||
+		flags&MREMAP_FIXED != 0 && flags&MREMAP_MAYMOVE == 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	if flags&MREMAP_FIXED != 0 {
+		h.setError(ENOSYS) // Moving to a requested address is not implemented
+		return SyscallResult{}
+	}
+	if oldSize == 0 {
+		if v, ok := h.addressSpace.Find(oldAddr); ok {
+			if v.Flags&MAP_SHARED != 0 {
+				h.setError(ENOSYS)
+			} else {
+				h.setError(EINVAL)
+			}
+			return SyscallResult{}
+		}
+	}
+
+	newAddr, errno := h.addressSpace.Remap(oldAddr, oldSize, newSize, flags&MREMAP_MAYMOVE != 0)
+	if errno != 0 {
+		h.setError(errno)
+		return SyscallResult{}
+	}
+	return h.succeed(newAddr)
+}
+
+// handleMsync handles the msync syscall (227), writing shared file mappings
+// in the range back to their files. The range must be mapped.
+func (h *DefaultSyscallHandler) handleMsync() SyscallResult {
+	addr := h.regFile.ReadReg(0)
+	length := h.regFile.ReadReg(1)
+	flags := h.regFile.ReadReg(2)
+
+	if addr%vmaPageSize != 0 || flags&^(MS_ASYNC|MS_INVALIDATE|MS_SYNC) != 0 ||
+		flags&MS_ASYNC != 0 && flags&MS_SYNC != 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	if length == 0 {
+		return h.succeed(0)
+	}
+	if !h.addressSpace.covered(addr, pageUp(addr+length)) {
+		h.setError(ENOMEM)
+		return SyscallResult{}
+	}
+
+	if err := h.addressSpace.Sync(addr, length); err != nil {
+		h.setError(hostErrno(err))
+		return SyscallResult{}
+	}
+	return h.succeed(0)
+}
+
+// handleMadvise handles the madvise syscall (233). Advice is only a hint
+// except for MADV_DONTNEED, which discards the contents of the range:
+// anonymous pages read as zero and private file pages are read again.
+func (h *DefaultSyscallHandler) handleMadvise() SyscallResult {
+	addr := h.regFile.ReadReg(0)
+	length := h.regFile.ReadReg(1)
+	advice := h.regFile.ReadReg(2)
+
+	if addr%vmaPageSize != 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	if advice == MADV_DONTNEED {
+		h.addressSpace.Discard(addr, length)
+	}
+
+	return h.succeed(0)
+}
+
+// GetMmapRegions returns the regions mapped by mmap, in address order.
+// munmap and mremap split and resize them.
+func (h *DefaultSyscallHandler) GetMmapRegions() []MmapRegion {
+	regions := make([]MmapRegion, 0)
+	for _, v := range h.addressSpace.vmas {
+		if v.mmapped {
+			regions = append(regions, MmapRegion{
+				Addr:   v.Start,
+				Length: v.Len(),
+				Prot:   v.Prot,
+				Flags:  v.Flags,
+			})
+		}
+	}
+	return regions
+}
@@ -0,0 +1,235 @@
+package emu
+
+// Identity of the simulated process. It is the only process and has a
+// single thread, whose ID is the process ID.
+const (
+	simulatedPID  uint64 = 1000
+	simulatedPPID uint64 = 999
+	simulatedUID  uint64 = 1000
+	simulatedGID  uint64 = 1000
+)
+
+// Signals whose action and blocking cannot be changed.
+const (
+	SIGKILL = 9
+	SIGSTOP = 19
+)
+
+// rt_sigprocmask operations.
+const (
+	SIG_BLOCK   = 0
+	SIG_UNBLOCK = 1
+	SIG_SETMASK = 2
+)
+
+// sigAction is the ARM64 kernel struct sigaction.
+type sigAction struct {
+	handler, flags, restorer, mask uint64
+}
+
+// sigsetSize is the size of the kernel sigset_t that rt_sig* syscalls
+// require.
+const sigsetSize = 8
+
+// handleSetTidAddress handles the set_tid_address syscall (96). The pointer
+// is recorded but never written, since the thread never exits before the
+// process does.
+func (h *DefaultSyscallHandler) handleSetTidAddress() SyscallResult {
+	h.clearChildTID = h.regFile.ReadReg(0)
+	return h.succeed(simulatedPID)
+}
+
+// handleSetRobustList handles the set_robust_list syscall (99). The list is
+// only walked when a thread dies, so it is not recorded.
+func (h *DefaultSyscallHandler) handleSetRobustList() SyscallResult {
+	const robustListHeadSize = 24
+	if h.regFile.ReadReg(1) != robustListHeadSize {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	return h.succeed(0)
+}
+
+// handleRtSigaction handles the rt_sigaction syscall (134). Actions are
+// recorded and reported back; no signal is ever delivered.
+func (h *DefaultSyscallHandler) handleRtSigaction() SyscallResult {
+	sig := h.regFile.ReadReg(0)
+	actPtr := h.regFile.ReadReg(1)
+	oldActPtr := h.regFile.ReadReg(2)
+
+	if sig < 1 || sig > 64 || h.regFile.ReadReg(3) != sigsetSize ||
+		actPtr != 0 && (sig == SIGKILL || sig == SIGSTOP) {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	action := &h.sigActions[sig-1]
+	if oldActPtr != 0 {
+		h.memory.Write64(oldActPtr, action.handler)
+		h.memory.Write64(oldActPtr+8, action.flags)
+		h.memory.Write64(oldActPtr+16, action.restorer)
+		h.memory.Write64(oldActPtr+24, action.mask)
+	}
+	if actPtr != 0 {
+		*action = sigAction{
+			handler:  h.memory.Read64(actPtr),
+			flags:    h.memory.Read64(actPtr + 8),
+			restorer: h.memory.Read64(actPtr + 16),
+			mask:     h.memory.Read64(actPtr + 24),
+		}
+	}
+	return h.succeed(0)
+}
+
+// handleRtSigprocmask handles the rt_sigprocmask syscall (135).
+func (h *DefaultSyscallHandler) handleRtSigprocmask() SyscallResult {
+	how := h.regFile.ReadReg(0)
+	setPtr := h.regFile.ReadReg(1)
+	oldSetPtr := h.regFile.ReadReg(2)
+
+	if h.regFile.ReadReg(3) != sigsetSize {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	var set uint64
+	if setPtr != 0 {
+		set = h.memory.Read64(setPtr)
+		if how > SIG_SETMASK {
+			h.setError(EINVAL)
+			return SyscallResult{}
+		}
+	}
+
+	if oldSetPtr != 0 {
+		h.memory.Write64(oldSetPtr, h.sigMask)
+	}
+	if setPtr != 0 {
+		switch how {
+		case SIG_BLOCK:
+			h.sigMask |= set
+		case SIG_UNBLOCK:
+			h.sigMask &^= set
+		case SIG_SETMASK:
+			h.sigMask = set
+		}
+		h.sigMask &^= 1<<(SIGKILL-1) | 1<<(SIGSTOP-1)
+	}
+	return h.succeed(0)
+}
+
+// utsname describes the simulated system, in the order of the fields of
+// struct utsname.
+var utsname = [...]string{"Linux", "m2sim", "6.1.0", "#1 SMP", "aarch64", "(none)"}
+
+// handleUname handles the uname syscall (160).
+func (h *DefaultSyscallHandler) handleUname() SyscallResult {
+	bufPtr := h.regFile.ReadReg(0)
+	if bufPtr == 0 {
+		h.setError(EFAULT)
+		return SyscallResult{}
+	}
+
+	const fieldSize = 65
+	for i, value := range utsname {
+		field := make([]byte, fieldSize)
+		copy(field, value)
+		h.memory.WriteBytes(bufPtr+uint64(i*fieldSize), field)
+	}
+	return h.succeed(0)
+}
+
+// randomSeed seeds the getrandom generator, so that programs that seed
+// themselves from it behave the same in every run.
+const randomSeed = 0x6D32_7369_6D00_0001
+
+// handleGetrandom handles the getrandom syscall (278). The bytes come from
+// a fixed-seed SplitMix64 generator rather than the host's entropy.
+func (h *DefaultSyscallHandler) handleGetrandom() SyscallResult {
+	bufPtr := h.regFile.ReadReg(0)
+	length := h.regFile.ReadReg(1)
+	flags := h.regFile.ReadReg(2)
+
+	const grndNonblock, grndRandom, grndInsecure = 0x1, 0x2, 0x4
+	if flags&^(grndNonblock|grndRandom|grndInsecure) != 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	// Linux fills at most 32 MiB - 1 bytes per call.
+	buf := make([]byte, min(length, 1<<25-1))
+	for i := range buf {
+		if i%8 == 0 {
+			h.randomState += 0x9E3779B97F4A7C15
+		}
+		z := h.randomState
+		z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+		z = (z ^ z>>27) * 0x94D049BB133111EB
+		z ^= z >> 31
+		buf[i] = byte(z >> (8 * (i % 8)))
+	}
+	h.memory.WriteBytes(bufPtr, buf)
+	return h.succeed(uint64(len(buf)))
+}
+
+// Resource limits reported by prlimit64.
+const (
+	RLIMIT_STACK  = 3
+	RLIMIT_NOFILE = 7
+
+	rlimInfinity = ^uint64(0)
+)
+
+// handlePrlimit64 handles the prlimit64 syscall (261). It reports an 8 MiB
+// stack and 1024 file descriptors (hard limit 4096), and no limit on other
+// resources. New limits are accepted but not enforced.
+func (h *DefaultSyscallHandler) handlePrlimit64() SyscallResult {
+	pid := h.regFile.ReadReg(0)
+	resource := h.regFile.ReadReg(1)
+	oldLimit := h.regFile.ReadReg(3)
+
+	const rlimNlimits = 16
+	if pid != 0 && pid != simulatedPID {
+		h.setError(ESRCH)
+		return SyscallResult{}
+	}
+	if resource >= rlimNlimits {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	if oldLimit != 0 {
+		cur, max := rlimInfinity, rlimInfinity
+		switch resource {
+		case RLIMIT_STACK:
+			cur = 8 << 20
+		case RLIMIT_NOFILE:
+			cur, max = 1024, 4096
+		}
+		h.memory.Write64(oldLimit, cur)
+		h.memory.Write64(oldLimit+8, max)
+	}
+	return h.succeed(0)
+}
+
+// handleSchedGetaffinity handles the sched_getaffinity syscall (123). The
+// process runs on the single simulated CPU, CPU 0.
+func (h *DefaultSyscallHandler) handleSchedGetaffinity() SyscallResult {
+	pid := h.regFile.ReadReg(0)
+	size := h.regFile.ReadReg(1)
+	maskPtr := h.regFile.ReadReg(2)
+
+	// The kernel copies whole longs of its CPU mask.
+	const maskSize = 8
+	if pid != 0 && pid != simulatedPID {
+		h.setError(ESRCH)
+		return SyscallResult{}
+	}
+	if size < maskSize || size%maskSize != 0 {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	h.memory.Write64(maskPtr, 1)
+	return h.succeed(maskSize)
+}
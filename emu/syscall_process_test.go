@@ -0,0 +1,168 @@
+package emu_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Process Syscalls", func() {
+	var (
+		regFile *emu.RegFile
+		memory  *emu.Memory
+		handler *emu.DefaultSyscallHandler
+		sys     syscaller
+	)
+
+	BeforeEach(func() {
+		regFile = &emu.RegFile{}
+		memory = emu.NewMemory()
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, new(bytes.Buffer), new(bytes.Buffer))
+		sys = syscaller{handler, regFile}
+	})
+
+	It("should exit the whole process on exit_group", func() {
+		regFile.WriteReg(8, emu.SyscallExitGroup)
+		regFile.WriteReg(0, 3)
+
+		result := handler.Handle()
+		Expect(result.Exited).To(BeTrue())
+		Expect(result.ExitCode).To(Equal(int64(3)))
+	})
+
+	It("should report a single-threaded process identity", func() {
+		pid := sys.call(emu.SyscallGetpid)
+		Expect(pid).To(BeNumerically(">", 1))
+		Expect(sys.call(emu.SyscallGettid)).To(Equal(pid))
+		Expect(sys.call(emu.SyscallSetTidAddress, 0x1000)).To(Equal(pid))
+		Expect(sys.call(emu.SyscallGetppid)).To(BeNumerically(">", 0))
+		Expect(sys.call(emu.SyscallGetuid)).To(Equal(sys.call(emu.SyscallGeteuid)))
+		Expect(sys.call(emu.SyscallGetgid)).To(Equal(sys.call(emu.SyscallGetegid)))
+	})
+
+	It("should check the robust list head size", func() {
+		Expect(sys.call(emu.SyscallSetRobustList, 0x1000, 24)).To(BeZero())
+		Expect(sys.call(emu.SyscallSetRobustList, 0x1000, 16)).To(Equal(int64(-emu.EINVAL)))
+	})
+
+	Describe("rt_sigaction", func() {
+		It("should record actions and report the previous one", func() {
+			memory.Write64(0x1000, 0x4000) // handler
+			memory.Write64(0x1008, 0x4)    // SA_SIGINFO
+			Expect(sys.call(emu.SyscallRtSigaction, 11, 0x1000, 0, 8)).To(BeZero())
+
+			Expect(sys.call(emu.SyscallRtSigaction, 11, 0, 0x2000, 8)).To(BeZero())
+			Expect(memory.Read64(0x2000)).To(Equal(uint64(0x4000)))
+			Expect(memory.Read64(0x2008)).To(Equal(uint64(0x4)))
+		})
+
+		It("should reject invalid signals and sigset sizes", func() {
+			Expect(sys.call(emu.SyscallRtSigaction, 0, 0, 0x2000, 8)).To(Equal(int64(-emu.EINVAL)))
+			Expect(sys.call(emu.SyscallRtSigaction, 65, 0, 0x2000, 8)).To(Equal(int64(-emu.EINVAL)))
+			Expect(sys.call(emu.SyscallRtSigaction, emu.SIGKILL, 0x1000, 0, 8)).To(Equal(int64(-emu.EINVAL)))
+			Expect(sys.call(emu.SyscallRtSigaction, 11, 0, 0x2000, 16)).To(Equal(int64(-emu.EINVAL)))
+		})
+	})
+
+	It("should block and unblock signals except SIGKILL and SIGSTOP", func() {
+		memory.Write64(0x1000, ^uint64(0))
+		Expect(sys.call(emu.SyscallRtSigprocmask, emu.SIG_BLOCK, 0x1000, 0, 8)).To(BeZero())
+
+		memory.Write64(0x1000, 1<<1)
+		Expect(sys.call(emu.SyscallRtSigprocmask, emu.SIG_UNBLOCK, 0x1000, 0x2000, 8)).To(BeZero())
+		Expect(memory.Read64(0x2000)).To(Equal(^uint64(1<<(emu.SIGKILL-1) | 1<<(emu.SIGSTOP-1))))
+
+		Expect(sys.call(emu.SyscallRtSigprocmask, emu.SIG_SETMASK, 0, 0x2000, 8)).To(BeZero())
+		Expect(memory.Read64(0x2000) & (1 << 1)).To(BeZero())
+
+		Expect(sys.call(emu.SyscallRtSigprocmask, 3, 0x1000, 0, 8)).To(Equal(int64(-emu.EINVAL)))
+	})
+
+	It("should describe an aarch64 Linux system in uname", func() {
+		Expect(sys.call(emu.SyscallUname, 0x1000)).To(BeZero())
+
+		field := func(i int) string {
+			buf := make([]byte, 65)
+			memory.ReadBytes(0x1000+uint64(i*65), buf)
+			return string(bytes.TrimRight(buf, "\x00"))
+		}
+		Expect(field(0)).To(Equal("Linux"))
+		Expect(field(4)).To(Equal("aarch64"))
+
+		Expect(sys.call(emu.SyscallUname, 0)).To(Equal(int64(-emu.EFAULT)))
+	})
+
+	It("should return the same random bytes in every run", func() {
+		Expect(sys.call(emu.SyscallGetrandom, 0x1000, 16, 0)).To(Equal(int64(16)))
+		first := make([]byte, 16)
+		memory.ReadBytes(0x1000, first)
+		Expect(first).NotTo(Equal(make([]byte, 16)))
+
+		other := emu.NewDefaultSyscallHandler(regFile, memory, new(bytes.Buffer), new(bytes.Buffer))
+		regFile.WriteReg(8, emu.SyscallGetrandom)
+		regFile.WriteReg(0, 0x2000)
+		other.Handle()
+		second := make([]byte, 16)
+		memory.ReadBytes(0x2000, second)
+		Expect(second).To(Equal(first))
+
+		Expect(sys.call(emu.SyscallGetrandom, 0x1000, 16, 0x8)).To(Equal(int64(-emu.EINVAL)))
+	})
+
+	It("should fill at most 32 MiB - 1 random bytes per call", func() {
+		Expect(sys.call(emu.SyscallGetrandom, 0x1000, ^uint64(0), 0)).To(Equal(int64(1<<25 - 1)))
+	})
+
+	It("should report resource limits", func() {
+		Expect(sys.call(emu.SyscallPrlimit64, 0, emu.RLIMIT_STACK, 0, 0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(8 << 20)))
+		Expect(memory.Read64(0x1008)).To(Equal(^uint64(0)))
+
+		Expect(sys.call(emu.SyscallPrlimit64, 0, emu.RLIMIT_NOFILE, 0, 0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(1024)))
+
+		Expect(sys.call(emu.SyscallPrlimit64, 12345, emu.RLIMIT_STACK, 0, 0x1000)).To(Equal(int64(-emu.ESRCH)))
+	})
+
+	It("should run on a single CPU", func() {
+		Expect(sys.call(emu.SyscallSchedGetaffinity, 0, 128, 0x1000)).To(Equal(int64(8)))
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(1)))
+		Expect(sys.call(emu.SyscallSchedGetaffinity, 0, 4, 0x1000)).To(Equal(int64(-emu.EINVAL)))
+	})
+
+	Describe("Unknown syscalls", func() {
+		It("should name syscalls", func() {
+			Expect(emu.SyscallName(emu.SyscallWritev)).To(Equal("writev"))
+			Expect(emu.SyscallName(435)).To(Equal("clone3"))
+			Expect(emu.SyscallName(999)).To(Equal("syscall_999"))
+		})
+
+		It("should count unknown syscalls by name, most frequent first", func() {
+			Expect(sys.call(98)).To(Equal(int64(-emu.ENOSYS))) // futex
+			sys.call(220)                                      // clone
+			sys.call(220)
+
+			Expect(handler.UnknownSyscalls()).To(Equal([]emu.UnknownSyscall{
+				{Number: 220, Name: "clone", Count: 2},
+				{Number: 98, Name: "futex", Count: 1},
+			}))
+
+			var out strings.Builder
+			handler.PrintUnknownSyscalls(&out)
+			Expect(out.String()).To(ContainSubstring("clone"))
+			Expect(out.String()).To(ContainSubstring("futex"))
+		})
+
+		It("should print nothing when every syscall was implemented", func() {
+			sys.call(emu.SyscallGetpid)
+
+			var out strings.Builder
+			handler.PrintUnknownSyscalls(&out)
+			Expect(out.String()).To(BeEmpty())
+		})
+	})
+})
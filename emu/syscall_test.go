@@ -19,6 +19,7 @@ var _ = Describe("Syscall Handler", func() {
 		stdout  *bytes.Buffer
 		stderr  *bytes.Buffer
 		handler *emu.DefaultSyscallHandler
+		sys     syscaller
 	)
 
 	BeforeEach(func() {
@@ -27,6 +28,7 @@ var _ = Describe("Syscall Handler", func() {
 		stdout = new(bytes.Buffer)
 		stderr = new(bytes.Buffer)
 		handler = emu.NewDefaultSyscallHandler(regFile, memory, stdout, stderr)
+		sys = syscaller{handler, regFile}
 	})
 
 	Describe("Unknown syscall", func() {
@@ -464,6 +466,18 @@ var _ = Describe("Syscall Handler", func() {
 
 			Expect(regFile.ReadReg(0)).To(Equal(customBreak))
 		})
+
+		It("should map the new heap pages read-write", func() {
+			regFile.WriteReg(8, 214)
+			regFile.WriteReg(0, emu.DefaultProgramBreak+0x1800)
+			handler.Handle()
+
+			prot, mapped := memory.Protection(emu.DefaultProgramBreak + 0x1000)
+			Expect(mapped).To(BeTrue())
+			Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+			_, mapped = memory.Protection(emu.DefaultProgramBreak + 0x2000)
+			Expect(mapped).To(BeFalse())
+		})
 	})
 
 	Describe("Mmap syscall", func() {
@@ -489,6 +503,11 @@ var _ = Describe("Syscall Handler", func() {
 			Expect(regions).To(HaveLen(1))
 			Expect(regions[0].Addr).To(Equal(addr))
 			Expect(regions[0].Length).To(Equal(uint64(4096)))
+
+			// The pages are mapped with the requested protection
+			prot, mapped := memory.Protection(addr)
+			Expect(mapped).To(BeTrue())
+			Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
 		})
 
 		It("should page-align allocation length", func() {
@@ -592,23 +611,20 @@ var _ = Describe("Syscall Handler", func() {
 			Expect(x0).To(Equal(expectedError))
 		})
 
-		It("should return ENOSYS for file mappings", func() {
+		It("should return EBADF for file mappings of a closed fd", func() {
 			regFile.WriteReg(8, 222)
 			regFile.WriteReg(0, 0)
 			regFile.WriteReg(1, 4096)
 			regFile.WriteReg(2, emu.PROT_READ)
 			regFile.WriteReg(3, emu.MAP_PRIVATE) // No MAP_ANONYMOUS
-			regFile.WriteReg(4, 5)               // Valid fd (file mapping)
+			regFile.WriteReg(4, 5)               // Unopened fd
 			regFile.WriteReg(5, 0)
 
 			result := handler.Handle()
 
 			Expect(result.Exited).To(BeFalse())
-			// X0 should contain -ENOSYS (38)
-			x0 := regFile.ReadReg(0)
-			var enosys int64 = 38
-			expectedError := uint64(-enosys)
-			Expect(x0).To(Equal(expectedError))
+			var ebadf int64 = emu.EBADF
+			Expect(regFile.ReadReg(0)).To(Equal(uint64(-ebadf)))
 		})
 	})
 
@@ -636,6 +652,38 @@ var _ = Describe("Syscall Handler", func() {
 			Expect(result.Exited).To(BeFalse())
 			Expect(regFile.ReadReg(0)).To(Equal(uint64(0)))
 		})
+
+		It("should change the protection of mapped pages", func() {
+			memory.Map(0x3000, 0x2000, emu.PROT_READ|emu.PROT_WRITE)
+
+			regFile.WriteReg(8, 226)    // SyscallMprotect
+			regFile.WriteReg(0, 0x4000) // addr
+			regFile.WriteReg(1, 4096)   // length
+			regFile.WriteReg(2, emu.PROT_READ)
+
+			handler.Handle()
+
+			Expect(regFile.ReadReg(0)).To(Equal(uint64(0)))
+			prot, _ := memory.Protection(0x3000)
+			Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+			prot, _ = memory.Protection(0x4000)
+			Expect(prot).To(Equal(emu.PROT_READ))
+		})
+
+		It("should return ENOMEM for unmapped pages when protection is enforced", func() {
+			memory = emu.NewMemory(emu.WithProtection())
+			handler = emu.NewDefaultSyscallHandler(regFile, memory, stdout, stderr)
+
+			regFile.WriteReg(8, 226)    // SyscallMprotect
+			regFile.WriteReg(0, 0x1000) // addr
+			regFile.WriteReg(1, 4096)   // length
+			regFile.WriteReg(2, emu.PROT_READ)
+
+			handler.Handle()
+
+			var enomem int64 = emu.ENOMEM
+			Expect(regFile.ReadReg(0)).To(Equal(uint64(-enomem)))
+		})
 	})
 
 	Describe("Fstat syscall", func() {
@@ -1165,4 +1213,72 @@ var _ = Describe("Syscall Handler", func() {
 			handler.Handle()
 		})
 	})
+
+	Describe("Munmap, mremap and madvise syscalls", func() {
+		// mmap maps length bytes of anonymous read-write memory.
+		mmap := func(length uint64) uint64 {
+			regFile.WriteReg(8, emu.SyscallMmap)
+			regFile.WriteReg(0, 0)
+			regFile.WriteReg(1, length)
+			regFile.WriteReg(2, emu.PROT_READ|emu.PROT_WRITE)
+			regFile.WriteReg(3, emu.MAP_PRIVATE|emu.MAP_ANONYMOUS)
+			regFile.WriteReg(4, ^uint64(0))
+			regFile.WriteReg(5, 0)
+			handler.Handle()
+			return regFile.ReadReg(0)
+		}
+
+		It("should unmap a region", func() {
+			addr := mmap(8192)
+
+			Expect(sys.call(emu.SyscallMunmap, addr, 8192)).To(BeZero())
+			Expect(handler.GetMmapRegions()).To(BeEmpty())
+			_, mapped := memory.Protection(addr)
+			Expect(mapped).To(BeFalse())
+
+			Expect(sys.call(emu.SyscallMunmap, addr+1, 4096)).To(Equal(int64(-emu.EINVAL)))
+			Expect(sys.call(emu.SyscallMunmap, addr, 0)).To(Equal(int64(-emu.EINVAL)))
+		})
+
+		It("should shrink a region in place", func() {
+			addr := mmap(8192)
+
+			Expect(sys.call(emu.SyscallMremap, addr, 8192, 4096, 0)).To(Equal(int64(addr)))
+			Expect(handler.GetMmapRegions()[0].Length).To(Equal(uint64(4096)))
+			_, mapped := memory.Protection(addr + 4096)
+			Expect(mapped).To(BeFalse())
+		})
+
+		It("should move a growing region only with MREMAP_MAYMOVE", func() {
+			addr := mmap(4096)
+			neighbor := mmap(4096) // blocks growth in place
+			memory.Write64(addr, 0xCAFE)
+
+			Expect(sys.call(emu.SyscallMremap, addr, 4096, 8192, 0)).To(Equal(int64(-emu.ENOMEM)))
+
+			newAddr := uint64(sys.call(emu.SyscallMremap, addr, 4096, 8192, emu.MREMAP_MAYMOVE))
+			Expect(newAddr).NotTo(Equal(addr))
+			Expect(memory.Read64(newAddr)).To(Equal(uint64(0xCAFE)))
+			Expect(handler.GetMmapRegions()).To(ConsistOf(
+				emu.MmapRegion{Addr: neighbor, Length: 4096, Prot: emu.PROT_READ | emu.PROT_WRITE,
+					Flags: emu.MAP_PRIVATE | emu.MAP_ANONYMOUS},
+				emu.MmapRegion{Addr: newAddr, Length: 8192, Prot: emu.PROT_READ | emu.PROT_WRITE,
+					Flags: emu.MAP_PRIVATE | emu.MAP_ANONYMOUS},
+			))
+
+			Expect(sys.call(emu.SyscallMremap, addr, 4096, 8192, emu.MREMAP_MAYMOVE)).To(Equal(int64(-emu.EFAULT)))
+		})
+
+		It("should zero pages on MADV_DONTNEED", func() {
+			addr := mmap(8192)
+			memory.Write64(addr, 1)
+			memory.Write64(addr+4096, 2)
+
+			Expect(sys.call(emu.SyscallMadvise, addr, 4096, emu.MADV_DONTNEED)).To(BeZero())
+			Expect(memory.Read64(addr)).To(BeZero())
+			Expect(memory.Read64(addr + 4096)).To(Equal(uint64(2)))
+
+			Expect(sys.call(emu.SyscallMadvise, addr+8, 4096, emu.MADV_DONTNEED)).To(Equal(int64(-emu.EINVAL)))
+		})
+	})
 })
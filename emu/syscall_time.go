@@ -0,0 +1,141 @@
+package emu
+
+import (
+	"time"
+)
+
+// Linux clock IDs.
+const (
+	CLOCK_REALTIME           = 0
+	CLOCK_MONOTONIC          = 1
+	CLOCK_PROCESS_CPUTIME_ID = 2
+	CLOCK_THREAD_CPUTIME_ID  = 3
+	CLOCK_MONOTONIC_RAW      = 4
+	CLOCK_REALTIME_COARSE    = 5
+	CLOCK_MONOTONIC_COARSE   = 6
+	CLOCK_BOOTTIME           = 7
+)
+
+// TIMER_ABSTIME makes clock_nanosleep sleep until an absolute time.
+const TIMER_ABSTIME = 1
+
+// clockTime returns the simulated time of a clock. The realtime clocks
+// start at realtimeEpoch and the monotonic clocks at boot, and both advance
+// across sleeps. The CPU-time clocks of the only process and thread count
+// only the time spent executing.
+func (h *DefaultSyscallHandler) clockTime(clock uint64) (time.Duration, bool) {
+	switch clock {
+	case CLOCK_REALTIME, CLOCK_REALTIME_COARSE:
+		return realtimeEpoch*time.Second + h.clock.Now(), true
+	case CLOCK_MONOTONIC, CLOCK_MONOTONIC_RAW, CLOCK_MONOTONIC_COARSE, CLOCK_BOOTTIME:
+		return h.clock.Now(), true
+	case CLOCK_PROCESS_CPUTIME_ID, CLOCK_THREAD_CPUTIME_ID:
+		return h.clock.CPUTime(), true
+	default:
+		return 0, false
+	}
+}
+
+// writeTimespec writes a struct timespec.
+func (h *DefaultSyscallHandler) writeTimespec(addr uint64, t time.Duration) {
+	h.memory.Write64(addr, uint64(t/time.Second))
+	h.memory.Write64(addr+8, uint64(t%time.Second))
+}
+
+// readTimespec reads a struct timespec, reporting whether it is valid.
+func (h *DefaultSyscallHandler) readTimespec(addr uint64) (time.Duration, bool) {
+	sec := int64(h.memory.Read64(addr))
+	nsec := int64(h.memory.Read64(addr + 8))
+	if sec < 0 || nsec < 0 || nsec >= int64(time.Second) {
+		return 0, false
+	}
+	if sec > int64(time.Duration(1<<63-1)/time.Second) {
+		return 1<<63 - 1, true
+	}
+	return time.Duration(sec)*time.Second + time.Duration(nsec), true
+}
+
+// handleClockGettime handles the clock_gettime syscall (113).
+func (h *DefaultSyscallHandler) handleClockGettime() SyscallResult {
+	clock := h.regFile.ReadReg(0)
+	tp := h.regFile.ReadReg(1)
+
+	t, ok := h.clockTime(clock)
+	if !ok {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	if tp == 0 {
+		h.setError(EFAULT)
+		return SyscallResult{}
+	}
+
+	h.writeTimespec(tp, t)
+	return h.succeed(0)
+}
+
+// handleGettimeofday handles the gettimeofday syscall (169). The time zone,
+// if requested, is UTC.
+func (h *DefaultSyscallHandler) handleGettimeofday() SyscallResult {
+	tv := h.regFile.ReadReg(0)
+	tz := h.regFile.ReadReg(1)
+
+	if tv != 0 {
+		t, _ := h.clockTime(CLOCK_REALTIME)
+		h.memory.Write64(tv, uint64(t/time.Second))
+		h.memory.Write64(tv+8, uint64(t%time.Second/time.Microsecond))
+	}
+	if tz != 0 {
+		h.memory.Write64(tz, 0) // tz_minuteswest, tz_dsttime
+	}
+	return h.succeed(0)
+}
+
+// handleNanosleep handles the nanosleep syscall (101). Sleeping advances
+// the simulated clock instead of blocking the host, and is never
+// interrupted, so the remaining time is not written.
+func (h *DefaultSyscallHandler) handleNanosleep() SyscallResult {
+	req := h.regFile.ReadReg(0)
+
+	if req == 0 {
+		h.setError(EFAULT)
+		return SyscallResult{}
+	}
+	d, ok := h.readTimespec(req)
+	if !ok {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	h.clock.Sleep(d)
+	return h.succeed(0)
+}
+
+// handleClockNanosleep handles the clock_nanosleep syscall (115), which
+// glibc's nanosleep uses. Like nanosleep, it advances the simulated clock.
+func (h *DefaultSyscallHandler) handleClockNanosleep() SyscallResult {
+	clock := h.regFile.ReadReg(0)
+	flags := h.regFile.ReadReg(1)
+	req := h.regFile.ReadReg(2)
+
+	now, ok := h.clockTime(clock)
+	if !ok || clock == CLOCK_THREAD_CPUTIME_ID {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+	if req == 0 {
+		h.setError(EFAULT)
+		return SyscallResult{}
+	}
+	d, ok := h.readTimespec(req)
+	if !ok {
+		h.setError(EINVAL)
+		return SyscallResult{}
+	}
+
+	if flags&TIMER_ABSTIME != 0 {
+		d -= now
+	}
+	h.clock.Sleep(d)
+	return h.succeed(0)
+}
@@ -0,0 +1,152 @@
+package emu_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/asm"
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Time Syscalls", func() {
+	var (
+		regFile *emu.RegFile
+		memory  *emu.Memory
+		handler *emu.DefaultSyscallHandler
+		sys     syscaller
+		cycles  uint64
+		clock   *emu.Clock
+	)
+
+	// The 2024-01-01 boot time of every simulation.
+	const epoch = 1_704_067_200
+
+	BeforeEach(func() {
+		regFile = &emu.RegFile{}
+		memory = emu.NewMemory()
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, new(bytes.Buffer), new(bytes.Buffer))
+		sys = syscaller{handler, regFile}
+
+		// At 1 GHz, a cycle is a nanosecond.
+		cycles = 0
+		clock = emu.NewClock(func() uint64 { return cycles }, 1_000_000_000)
+		handler.SetClock(clock)
+	})
+
+	writeTimespec := func(addr uint64, sec, nsec int64) uint64 {
+		memory.Write64(addr, uint64(sec))
+		memory.Write64(addr+8, uint64(nsec))
+		return addr
+	}
+
+	It("should return simulated time from clock_gettime", func() {
+		cycles = 2_500_000_007
+
+		Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_MONOTONIC, 0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(2)))
+		Expect(memory.Read64(0x1008)).To(Equal(uint64(500_000_007)))
+
+		Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_PROCESS_CPUTIME_ID, 0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(2)))
+
+		Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_REALTIME, 0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(epoch + 2)))
+		Expect(memory.Read64(0x1008)).To(Equal(uint64(500_000_007)))
+	})
+
+	It("should reject unknown clocks and null pointers", func() {
+		Expect(sys.call(emu.SyscallClockGettime, 99, 0x1000)).To(Equal(int64(-emu.EINVAL)))
+		Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_REALTIME, 0)).To(Equal(int64(-emu.EFAULT)))
+	})
+
+	It("should return the time of day in microseconds", func() {
+		cycles = 1_000_002_500
+		memory.Write64(0x2000, ^uint64(0))
+
+		Expect(sys.call(emu.SyscallGettimeofday, 0x1000, 0x2000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(Equal(uint64(epoch + 1)))
+		Expect(memory.Read64(0x1008)).To(Equal(uint64(2)))
+		Expect(memory.Read64(0x2000)).To(BeZero())
+	})
+
+	It("should stand still at boot until a clock is attached", func() {
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, nil, nil)
+
+		Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_MONOTONIC, 0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1000)).To(BeZero())
+		Expect(memory.Read64(0x1008)).To(BeZero())
+	})
+
+	Describe("Sleeping", func() {
+		It("should advance the clock on nanosleep", func() {
+			Expect(sys.call(emu.SyscallNanosleep, writeTimespec(0x1000, 1, 500), 0)).To(BeZero())
+			Expect(clock.Now()).To(Equal(time.Second + 500*time.Nanosecond))
+
+			Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_MONOTONIC, 0x2000)).To(BeZero())
+			Expect(memory.Read64(0x2000)).To(Equal(uint64(1)))
+			Expect(memory.Read64(0x2008)).To(Equal(uint64(500)))
+		})
+
+		It("should not count sleeps as CPU time", func() {
+			cycles = 3000
+			Expect(sys.call(emu.SyscallNanosleep, writeTimespec(0x1000, 2, 0), 0)).To(BeZero())
+
+			for _, id := range []uint64{emu.CLOCK_PROCESS_CPUTIME_ID, emu.CLOCK_THREAD_CPUTIME_ID} {
+				Expect(sys.call(emu.SyscallClockGettime, id, 0x2000)).To(BeZero())
+				Expect(memory.Read64(0x2000)).To(BeZero())
+				Expect(memory.Read64(0x2008)).To(Equal(uint64(3000)))
+			}
+			Expect(sys.call(emu.SyscallClockGettime, emu.CLOCK_MONOTONIC, 0x2000)).To(BeZero())
+			Expect(memory.Read64(0x2000)).To(Equal(uint64(2)))
+		})
+
+		It("should reject invalid durations", func() {
+			Expect(sys.call(emu.SyscallNanosleep, writeTimespec(0x1000, 0, 1_000_000_000), 0)).
+				To(Equal(int64(-emu.EINVAL)))
+			Expect(sys.call(emu.SyscallNanosleep, writeTimespec(0x1000, -1, 0), 0)).To(Equal(int64(-emu.EINVAL)))
+			Expect(sys.call(emu.SyscallNanosleep, 0, 0)).To(Equal(int64(-emu.EFAULT)))
+			Expect(clock.Now()).To(BeZero())
+		})
+
+		It("should sleep relative to or until a time with clock_nanosleep", func() {
+			cycles = 1000
+			req := writeTimespec(0x1000, 0, 4000)
+
+			Expect(sys.call(emu.SyscallClockNanosleep, emu.CLOCK_MONOTONIC, 0, req, 0)).To(BeZero())
+			Expect(clock.Now()).To(Equal(5000 * time.Nanosecond))
+
+			Expect(sys.call(emu.SyscallClockNanosleep, emu.CLOCK_MONOTONIC, emu.TIMER_ABSTIME, req, 0)).To(BeZero())
+			Expect(clock.Now()).To(Equal(5000 * time.Nanosecond))
+
+			writeTimespec(0x1000, 0, 8000)
+			Expect(sys.call(emu.SyscallClockNanosleep, emu.CLOCK_MONOTONIC, emu.TIMER_ABSTIME, req, 0)).To(BeZero())
+			Expect(clock.Now()).To(Equal(8000 * time.Nanosecond))
+
+			Expect(sys.call(emu.SyscallClockNanosleep, emu.CLOCK_THREAD_CPUTIME_ID, 0, req, 0)).
+				To(Equal(int64(-emu.EINVAL)))
+		})
+	})
+
+	It("should derive time from the instruction count in functional mode", func() {
+		src := `movz x0, #1
+			movz x1, #0x2000
+			movz x8, #113
+			svc #0`
+
+		times := make([]uint64, 2)
+		for i := range times {
+			e := emu.NewEmulator(emu.WithCoreFrequency(1_000_000_000))
+			e.LoadProgram(0x1000, asm.MustAssemble(src))
+			for j := 0; j < 4; j++ {
+				Expect(e.Step().Err).To(BeNil())
+			}
+			times[i] = e.Memory().Read64(0x2008)
+		}
+
+		Expect(times[0]).To(Equal(uint64(3)))
+		Expect(times[1]).To(Equal(times[0]))
+	})
+})
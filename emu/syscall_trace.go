@@ -0,0 +1,437 @@
+package emu
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// traceStringLimit is how many bytes of a string or buffer a trace line
+// shows, as strace's default -s 32.
+const traceStringLimit = 32
+
+// traceArg says how a trace line formats a syscall argument.
+type traceArg int
+
+const (
+	argInt         traceArg = iota // C int, signed decimal
+	argLong                        // C long or off_t, signed decimal
+	argUint                        // size, unsigned decimal
+	argHex                         // hexadecimal
+	argPtr                         // address, or NULL
+	argFD                          // file descriptor
+	argDirFD                       // directory file descriptor, or AT_FDCWD
+	argPath                        // NUL-terminated string the call reads
+	argOutPath                     // NUL-terminated string the call writes
+	argInBuf                       // buffer the call reads; its length is the next argument
+	argOutBuf                      // buffer the call writes; its length is the result
+	argOpenFlags                   // O_* flags
+	argMode                        // permission bits, in octal
+	argProt                        // PROT_* flags
+	argMapFlags                    // MAP_* flags
+	argAtFlags                     // AT_* flags
+	argWhence                      // SEEK_*
+	argClock                       // CLOCK_*
+	argTimerFlags                  // TIMER_* flags
+	argSignal                      // SIG*
+	argSigHow                      // SIG_BLOCK, SIG_UNBLOCK or SIG_SETMASK
+	argResource                    // RLIMIT_*
+	argIoctl                       // ioctl request
+	argMremapFlags                 // MREMAP_* flags
+	argMsyncFlags                  // MS_* flags
+	argAdvice                      // MADV_*
+	argRandomFlags                 // GRND_* flags
+)
+
+// traceSignatures gives the arguments of the syscalls the trace decodes.
+// Other syscalls show all six argument registers in hexadecimal.
+var traceSignatures = map[uint64][]traceArg{
+	SyscallGetcwd:           {argOutPath, argUint},
+	SyscallIoctl:            {argFD, argIoctl, argPtr},
+	SyscallOpenat:           {argDirFD, argPath, argOpenFlags, argMode},
+	SyscallClose:            {argFD},
+	SyscallLseek:            {argFD, argLong, argWhence},
+	SyscallRead:             {argFD, argOutBuf, argUint},
+	SyscallWrite:            {argFD, argInBuf, argUint},
+	SyscallReadv:            {argFD, argPtr, argInt},
+	SyscallWritev:           {argFD, argPtr, argInt},
+	SyscallPread64:          {argFD, argOutBuf, argUint, argLong},
+	SyscallPwrite64:         {argFD, argInBuf, argUint, argLong},
+	SyscallReadlinkat:       {argDirFD, argPath, argOutBuf, argUint},
+	SyscallNewfstatat:       {argDirFD, argPath, argPtr, argAtFlags},
+	SyscallFstat:            {argFD, argPtr},
+	SyscallExit:             {argInt},
+	SyscallExitGroup:        {argInt},
+	SyscallSetTidAddress:    {argPtr},
+	SyscallSetRobustList:    {argPtr, argUint},
+	SyscallNanosleep:        {argPtr, argPtr},
+	SyscallClockGettime:     {argClock, argPtr},
+	SyscallClockNanosleep:   {argClock, argTimerFlags, argPtr, argPtr},
+	SyscallSchedGetaffinity: {argInt, argUint, argPtr},
+	SyscallRtSigaction:      {argSignal, argPtr, argPtr, argUint},
+	SyscallRtSigprocmask:    {argSigHow, argPtr, argPtr, argUint},
+	SyscallUname:            {argPtr},
+	SyscallGettimeofday:     {argPtr, argPtr},
+	SyscallGetpid:           {},
+	SyscallGetppid:          {},
+	SyscallGetuid:           {},
+	SyscallGeteuid:          {},
+	SyscallGetgid:           {},
+	SyscallGetegid:          {},
+	SyscallGettid:           {},
+	SyscallBrk:              {argPtr},
+	SyscallMunmap:           {argPtr, argUint},
+	SyscallMremap:           {argPtr, argUint, argUint, argMremapFlags, argPtr},
+	SyscallMmap:             {argPtr, argUint, argProt, argMapFlags, argFD, argHex},
+	SyscallMprotect:         {argPtr, argUint, argProt},
+	SyscallMsync:            {argPtr, argUint, argMsyncFlags},
+	SyscallMadvise:          {argPtr, argUint, argAdvice},
+	SyscallPrlimit64:        {argInt, argResource, argPtr, argPtr},
+	SyscallGetrandom:        {argOutBuf, argUint, argRandomFlags},
+	SyscallStatx:            {argDirFD, argPath, argAtFlags, argHex, argPtr},
+}
+
+// traceAddressResults lists the syscalls that return addresses, which the
+// trace shows in hexadecimal.
+var traceAddressResults = map[uint64]bool{
+	SyscallBrk:    true,
+	SyscallMmap:   true,
+	SyscallMremap: true,
+}
+
+// flagName names one flag bit, or a set of bits, of a flags argument.
+type flagName struct {
+	value uint64
+	name  string
+}
+
+var (
+	openAccessModes = map[uint64]string{O_RDONLY: "O_RDONLY", O_WRONLY: "O_WRONLY", O_RDWR: "O_RDWR", 3: "O_ACCMODE"}
+	openFlagNames   = []flagName{
+		{O_CREAT, "O_CREAT"}, {0x80, "O_EXCL"}, {0x100, "O_NOCTTY"}, {O_TRUNC, "O_TRUNC"},
+		{O_APPEND, "O_APPEND"}, {0x800, "O_NONBLOCK"}, {0x1000, "O_DSYNC"}, {0x2000, "O_ASYNC"},
+		{0x4000, "O_DIRECTORY"}, {0x8000, "O_NOFOLLOW"}, {0x10000, "O_DIRECT"}, {0x20000, "O_LARGEFILE"},
+		{0x40000, "O_NOATIME"}, {0x80000, "O_CLOEXEC"}, {0x200000, "O_PATH"},
+	}
+	protFlagNames = []flagName{{PROT_READ, "PROT_READ"}, {PROT_WRITE, "PROT_WRITE"}, {PROT_EXEC, "PROT_EXEC"}}
+	mapTypes      = map[uint64]string{MAP_SHARED: "MAP_SHARED", MAP_PRIVATE: "MAP_PRIVATE", 3: "MAP_SHARED_VALIDATE"}
+	mapFlagNames  = []flagName{
+		{MAP_FIXED, "MAP_FIXED"}, {MAP_ANONYMOUS, "MAP_ANONYMOUS"}, {0x100, "MAP_GROWSDOWN"},
+		{0x800, "MAP_DENYWRITE"}, {0x1000, "MAP_EXECUTABLE"}, {0x2000, "MAP_LOCKED"},
+		{0x4000, "MAP_NORESERVE"}, {0x8000, "MAP_POPULATE"}, {0x10000, "MAP_NONBLOCK"},
+		{0x20000, "MAP_STACK"}, {0x40000, "MAP_HUGETLB"}, {MAP_FIXED_NOREPLACE, "MAP_FIXED_NOREPLACE"},
+	}
+	atFlagNames = []flagName{
+		{AT_SYMLINK_NOFOLLOW, "AT_SYMLINK_NOFOLLOW"}, {0x200, "AT_REMOVEDIR"}, {0x400, "AT_SYMLINK_FOLLOW"},
+		{0x800, "AT_NO_AUTOMOUNT"}, {AT_EMPTY_PATH, "AT_EMPTY_PATH"}, {0x2000, "AT_STATX_FORCE_SYNC"},
+		{0x4000, "AT_STATX_DONT_SYNC"},
+	}
+	whenceNames = map[uint64]string{SEEK_SET: "SEEK_SET", SEEK_CUR: "SEEK_CUR", SEEK_END: "SEEK_END", 3: "SEEK_DATA", 4: "SEEK_HOLE"}
+	clockNames  = map[uint64]string{
+		CLOCK_REALTIME: "CLOCK_REALTIME", CLOCK_MONOTONIC: "CLOCK_MONOTONIC",
+		CLOCK_PROCESS_CPUTIME_ID: "CLOCK_PROCESS_CPUTIME_ID", CLOCK_THREAD_CPUTIME_ID: "CLOCK_THREAD_CPUTIME_ID",
+		CLOCK_MONOTONIC_RAW: "CLOCK_MONOTONIC_RAW", CLOCK_REALTIME_COARSE: "CLOCK_REALTIME_COARSE",
+		CLOCK_MONOTONIC_COARSE: "CLOCK_MONOTONIC_COARSE", CLOCK_BOOTTIME: "CLOCK_BOOTTIME",
+		8: "CLOCK_REALTIME_ALARM", 9: "CLOCK_BOOTTIME_ALARM", 11: "CLOCK_TAI",
+	}
+	timerFlagNames = []flagName{{TIMER_ABSTIME, "TIMER_ABSTIME"}}
+	signalNames    = map[uint64]string{
+		1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP", 6: "SIGABRT", 7: "SIGBUS",
+		8: "SIGFPE", 9: "SIGKILL", 10: "SIGUSR1", 11: "SIGSEGV", 12: "SIGUSR2", 13: "SIGPIPE",
+		14: "SIGALRM", 15: "SIGTERM", 16: "SIGSTKFLT", 17: "SIGCHLD", 18: "SIGCONT", 19: "SIGSTOP",
+		20: "SIGTSTP", 21: "SIGTTIN", 22: "SIGTTOU", 23: "SIGURG", 24: "SIGXCPU", 25: "SIGXFSZ",
+		26: "SIGVTALRM", 27: "SIGPROF", 28: "SIGWINCH", 29: "SIGIO", 30: "SIGPWR", 31: "SIGSYS",
+	}
+	sigHowNames   = map[uint64]string{SIG_BLOCK: "SIG_BLOCK", SIG_UNBLOCK: "SIG_UNBLOCK", SIG_SETMASK: "SIG_SETMASK"}
+	resourceNames = map[uint64]string{
+		0: "RLIMIT_CPU", 1: "RLIMIT_FSIZE", 2: "RLIMIT_DATA", RLIMIT_STACK: "RLIMIT_STACK", 4: "RLIMIT_CORE",
+		5: "RLIMIT_RSS", 6: "RLIMIT_NPROC", RLIMIT_NOFILE: "RLIMIT_NOFILE", 8: "RLIMIT_MEMLOCK",
+		9: "RLIMIT_AS", 10: "RLIMIT_LOCKS", 11: "RLIMIT_SIGPENDING", 12: "RLIMIT_MSGQUEUE",
+		13: "RLIMIT_NICE", 14: "RLIMIT_RTPRIO", 15: "RLIMIT_RTTIME",
+	}
+	ioctlNames      = map[uint64]string{TCGETS: "TCGETS", 0x5402: "TCSETS", 0x540F: "TIOCGPGRP", TIOCGWINSZ: "TIOCGWINSZ"}
+	mremapFlagNames = []flagName{{MREMAP_MAYMOVE, "MREMAP_MAYMOVE"}, {MREMAP_FIXED, "MREMAP_FIXED"}, {4, "MREMAP_DONTUNMAP"}}
+	msyncFlagNames  = []flagName{{MS_ASYNC, "MS_ASYNC"}, {MS_INVALIDATE, "MS_INVALIDATE"}, {MS_SYNC, "MS_SYNC"}}
+	adviceNames     = map[uint64]string{
+		0: "MADV_NORMAL", 1: "MADV_RANDOM", 2: "MADV_SEQUENTIAL", 3: "MADV_WILLNEED", MADV_DONTNEED: "MADV_DONTNEED",
+		8: "MADV_FREE", 9: "MADV_REMOVE", 10: "MADV_DONTFORK", 11: "MADV_DOFORK", 12: "MADV_MERGEABLE",
+		13: "MADV_UNMERGEABLE", 14: "MADV_HUGEPAGE", 15: "MADV_NOHUGEPAGE", 16: "MADV_DONTDUMP", 17: "MADV_DODUMP",
+	}
+	randomFlagNames = []flagName{{1, "GRND_NONBLOCK"}, {2, "GRND_RANDOM"}, {4, "GRND_INSECURE"}}
+)
+
+// errnoNames gives the names and messages of the errnos the trace shows.
+var errnoNames = map[int][2]string{
+	ENOENT:       {"ENOENT", "No such file or directory"},
+	ESRCH:        {"ESRCH", "No such process"},
+	EIO:          {"EIO", "Input/output error"},
+	EBADF:        {"EBADF", "Bad file descriptor"},
+	ENOMEM:       {"ENOMEM", "Cannot allocate memory"},
+	EACCES:       {"EACCES", "Permission denied"},
+	EFAULT:       {"EFAULT", "Bad address"},
+	EEXIST:       {"EEXIST", "File exists"},
+	ENODEV:       {"ENODEV", "No such device"},
+	ENOTDIR:      {"ENOTDIR", "Not a directory"},
+	EISDIR:       {"EISDIR", "Is a directory"},
+	EINVAL:       {"EINVAL", "Invalid argument"},
+	ENOTTY:       {"ENOTTY", "Inappropriate ioctl for device"},
+	ESPIPE:       {"ESPIPE", "Illegal seek"},
+	ERANGE:       {"ERANGE", "Numerical result out of range"},
+	ENAMETOOLONG: {"ENAMETOOLONG", "File name too long"},
+	ENOSYS:       {"ENOSYS", "Function not implemented"},
+	ELOOP:        {"ELOOP", "Too many levels of symbolic links"},
+}
+
+// SetTrace makes the handler log every syscall to w in the format of
+// strace: the call with its decoded arguments, then its result or errno.
+// Each line starts with the instruction count and cycle the call happened
+// at. A nil w turns tracing off.
+func (h *DefaultSyscallHandler) SetTrace(w io.Writer) {
+	h.trace = w
+}
+
+// SetInstructionCounter sets the function that counts the instructions
+// executed so far, for trace lines. The emulator attaches its own counter
+// when it runs the handler.
+func (h *DefaultSyscallHandler) SetInstructionCounter(count func() uint64) {
+	h.instructions = count
+}
+
+// traceCall handles the syscall in X8 and logs it to the trace.
+func (h *DefaultSyscallHandler) traceCall() SyscallResult {
+	num := h.regFile.ReadReg(8)
+	var args [6]uint64
+	for i := range args {
+		args[i] = h.regFile.ReadReg(uint8(i))
+	}
+	var instructions uint64
+	if h.instructions != nil {
+		instructions = h.instructions()
+	}
+	cycles := h.clock.Cycles()
+
+	result := h.dispatch(num)
+
+	// Arguments are formatted after the call, from the saved registers, so
+	// that buffers the call fills show what the program received.
+	ret := int64(h.regFile.ReadReg(0))
+	_, _ = fmt.Fprintf(h.trace, "[insts %d cycles %d] %s(%s) = %s\n",
+		instructions, cycles, SyscallName(num), h.traceArgs(num, args, ret), h.traceResult(num, ret, result))
+	return result
+}
+
+// traceArgs formats the arguments of syscall num. ret is its result.
+func (h *DefaultSyscallHandler) traceArgs(num uint64, args [6]uint64, ret int64) string {
+	signature, ok := traceSignatures[num]
+	if !ok {
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = fmt.Sprintf("%#x", arg)
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	// Like strace, show the mode of openat only when it creates a file.
+	if num == SyscallOpenat && args[2]&O_CREAT == 0 {
+		signature = signature[:3]
+	}
+
+	parts := make([]string, len(signature))
+	for i, kind := range signature {
+		parts[i] = h.traceArg(kind, args, i, ret)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// traceArg formats argument i of args as kind.
+func (h *DefaultSyscallHandler) traceArg(kind traceArg, args [6]uint64, i int, ret int64) string {
+	v := args[i]
+	switch kind {
+	case argInt, argFD:
+		return fmt.Sprint(int32(v))
+	case argLong:
+		return fmt.Sprint(int64(v))
+	case argUint:
+		return fmt.Sprint(v)
+	case argHex:
+		return fmt.Sprintf("%#x", v)
+	case argPtr:
+		return tracePointer(v)
+	case argDirFD:
+		if int32(v) == int32(AT_FDCWD) {
+			return "AT_FDCWD"
+		}
+		return fmt.Sprint(int32(v))
+	case argPath:
+		return h.traceString(v)
+	case argOutPath:
+		if ret < 0 {
+			return tracePointer(v)
+		}
+		return h.traceString(v)
+	case argInBuf:
+		return h.traceBuffer(v, args[i+1])
+	case argOutBuf:
+		if ret < 0 {
+			return tracePointer(v)
+		}
+		return h.traceBuffer(v, uint64(ret))
+	case argOpenFlags:
+		access := traceEnum(v&3, openAccessModes)
+		if rest := v &^ 3; rest != 0 {
+			return access + "|" + traceFlags(rest, openFlagNames)
+		}
+		return access
+	case argMode:
+		return fmt.Sprintf("%#o", v)
+	case argProt:
+		if v == PROT_NONE {
+			return "PROT_NONE"
+		}
+		return traceFlags(v, protFlagNames)
+	case argMapFlags:
+		mapType := traceEnum(v&0xf, mapTypes)
+		if rest := v &^ 0xf; rest != 0 {
+			return mapType + "|" + traceFlags(rest, mapFlagNames)
+		}
+		return mapType
+	case argAtFlags:
+		return traceFlags(v, atFlagNames)
+	case argWhence:
+		return traceEnum(v, whenceNames)
+	case argClock:
+		return traceEnum(v, clockNames)
+	case argTimerFlags:
+		return traceFlags(v, timerFlagNames)
+	case argSignal:
+		return traceEnum(v, signalNames)
+	case argSigHow:
+		return traceEnum(v, sigHowNames)
+	case argResource:
+		return traceEnum(v, resourceNames)
+	case argIoctl:
+		if name, ok := ioctlNames[v]; ok {
+			return name
+		}
+		return fmt.Sprintf("%#x", v)
+	case argMremapFlags:
+		return traceFlags(v, mremapFlagNames)
+	case argMsyncFlags:
+		return traceFlags(v, msyncFlagNames)
+	case argAdvice:
+		return traceEnum(v, adviceNames)
+	case argRandomFlags:
+		return traceFlags(v, randomFlagNames)
+	}
+	return fmt.Sprintf("%#x", v)
+}
+
+// traceResult formats the result of syscall num: an errno for -4095 to -1,
+// ? for calls that do not return.
+func (h *DefaultSyscallHandler) traceResult(num uint64, ret int64, result SyscallResult) string {
+	switch {
+	case result.Exited:
+		return "?"
+	case ret < 0 && ret >= -4095:
+		errno := int(-ret)
+		if name, ok := errnoNames[errno]; ok {
+			return fmt.Sprintf("-1 %s (%s)", name[0], name[1])
+		}
+		return fmt.Sprintf("-1 E%d (Unknown error %d)", errno, errno)
+	case traceAddressResults[num]:
+		return fmt.Sprintf("%#x", uint64(ret))
+	}
+	return fmt.Sprint(ret)
+}
+
+// traceString formats the NUL-terminated string at addr, cut off after
+// traceStringLimit bytes.
+func (h *DefaultSyscallHandler) traceString(addr uint64) string {
+	if addr == 0 {
+		return "NULL"
+	}
+	var buf []byte
+	for len(buf) < traceStringLimit {
+		b := h.memory.Read8(addr + uint64(len(buf)))
+		if b == 0 {
+			return traceQuote(buf, false)
+		}
+		buf = append(buf, b)
+	}
+	return traceQuote(buf, h.memory.Read8(addr+uint64(len(buf))) != 0)
+}
+
+// traceBuffer formats the size bytes at addr, cut off after
+// traceStringLimit bytes.
+func (h *DefaultSyscallHandler) traceBuffer(addr, size uint64) string {
+	if addr == 0 {
+		return "NULL"
+	}
+	buf := make([]byte, min(size, traceStringLimit))
+	h.memory.ReadBytes(addr, buf)
+	return traceQuote(buf, size > uint64(len(buf)))
+}
+
+// traceQuote quotes data as a C string literal, followed by ... if it was
+// cut off.
+func traceQuote(data []byte, more bool) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range data {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c < ' ' || c >= 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	if more {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// tracePointer formats an address, or NULL.
+func tracePointer(addr uint64) string {
+	if addr == 0 {
+		return "NULL"
+	}
+	return fmt.Sprintf("%#x", addr)
+}
+
+// traceEnum formats v by its name, or in decimal if it has none.
+func traceEnum(v uint64, names map[uint64]string) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return fmt.Sprint(v)
+}
+
+// traceFlags formats v as the names of its flags joined by |, with any
+// unnamed bits last in hexadecimal.
+func traceFlags(v uint64, names []flagName) string {
+	if v == 0 {
+		return "0"
+	}
+	var parts []string
+	for _, flag := range names {
+		if v&flag.value == flag.value {
+			parts = append(parts, flag.name)
+			v &^= flag.value
+		}
+	}
+	if v != 0 {
+		parts = append(parts, fmt.Sprintf("%#x", v))
+	}
+	return strings.Join(parts, "|")
+}
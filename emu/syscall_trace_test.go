@@ -0,0 +1,90 @@
+package emu_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Syscall tracing", func() {
+	var (
+		regFile *emu.RegFile
+		memory  *emu.Memory
+		stdout  *bytes.Buffer
+		trace   *bytes.Buffer
+		handler *emu.DefaultSyscallHandler
+	)
+
+	BeforeEach(func() {
+		regFile = &emu.RegFile{}
+		memory = emu.NewMemory()
+		stdout = new(bytes.Buffer)
+		trace = new(bytes.Buffer)
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, stdout, new(bytes.Buffer))
+		handler.SetTrace(trace)
+	})
+
+	call := func(num uint64, args ...uint64) string {
+		regFile.WriteReg(8, num)
+		for i, arg := range args {
+			regFile.WriteReg(uint8(i), arg)
+		}
+		trace.Reset()
+		handler.Handle()
+		return strings.TrimSuffix(trace.String(), "\n")
+	}
+
+	writeString := func(addr uint64, s string) uint64 {
+		memory.WriteBytes(addr, append([]byte(s), 0))
+		return addr
+	}
+
+	It("should prefix each call with the instruction count and cycle", func() {
+		handler.SetInstructionCounter(func() uint64 { return 42 })
+		handler.SetClock(emu.NewClock(func() uint64 { return 100 }, emu.CoreFrequency))
+		Expect(call(emu.SyscallGetpid)).To(MatchRegexp(`^\[insts 42 cycles 100\] getpid\(\) = \d+$`))
+	})
+
+	It("should attach the emulator's instruction count", func() {
+		emu.NewEmulator(emu.WithSyscallHandler(handler))
+		Expect(call(emu.SyscallGettid)).To(HavePrefix("[insts 0 cycles 0] gettid() = "))
+	})
+
+	It("should show strings and symbolized flags", func() {
+		path := writeString(0x1000, "/no/such/file")
+		Expect(call(emu.SyscallOpenat, emu.AT_FDCWD_U64, path, emu.O_RDONLY|0x80000, 0)).
+			To(HaveSuffix(`openat(AT_FDCWD, "/no/such/file", O_RDONLY|O_CLOEXEC) = -1 ENOENT (No such file or directory)`))
+		Expect(call(emu.SyscallOpenat, emu.AT_FDCWD_U64, path, emu.O_WRONLY|emu.O_CREAT|emu.O_TRUNC, 0644)).
+			To(HaveSuffix(`openat(AT_FDCWD, "/no/such/file", O_WRONLY|O_CREAT|O_TRUNC, 0644) = -1 ENOENT (No such file or directory)`))
+
+		Expect(call(emu.SyscallMmap, 0, 8192, emu.PROT_READ|emu.PROT_WRITE, emu.MAP_PRIVATE|emu.MAP_ANONYMOUS,
+			^uint64(0), 0)).
+			To(MatchRegexp(`mmap\(NULL, 8192, PROT_READ\|PROT_WRITE, MAP_PRIVATE\|MAP_ANONYMOUS, -1, 0x0\) = 0x[0-9a-f]+$`))
+		Expect(call(emu.SyscallClockGettime, emu.CLOCK_MONOTONIC, 0x2000)).
+			To(HaveSuffix("clock_gettime(CLOCK_MONOTONIC, 0x2000) = 0"))
+	})
+
+	It("should show buffer contents, cut off after 32 bytes", func() {
+		writeString(0x1000, "hi\n")
+		Expect(call(emu.SyscallWrite, 1, 0x1000, 3)).To(HaveSuffix(`write(1, "hi\n", 3) = 3`))
+		Expect(stdout.String()).To(Equal("hi\n"))
+
+		writeString(0x1000, strings.Repeat("a", 40))
+		Expect(call(emu.SyscallWrite, 1, 0x1000, 40)).
+			To(HaveSuffix(`write(1, "` + strings.Repeat("a", 32) + `"..., 40) = 40`))
+	})
+
+	It("should show calls that do not return and unknown syscalls", func() {
+		Expect(call(emu.SyscallExitGroup, 3)).To(HaveSuffix("exit_group(3) = ?"))
+		Expect(call(98, 1, 2)).To(HaveSuffix("futex(0x1, 0x2, 0x0, 0x0, 0x0, 0x0) = -1 ENOSYS (Function not implemented)"))
+	})
+
+	It("should not trace once tracing is off", func() {
+		handler.SetTrace(nil)
+		Expect(call(emu.SyscallGetpid)).To(BeEmpty())
+	})
+})
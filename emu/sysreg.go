@@ -0,0 +1,109 @@
+// Package emu provides functional ARM64 emulation.
+package emu
+
+// SysRegFile holds the EL0-writable system registers that are not part of
+// the general-purpose or SIMD&FP register files. NZCV lives in the
+// RegFile's PSTATE, and FPCR and FPSR in the SIMDRegFile.
+type SysRegFile struct {
+	// TPIDR is TPIDR_EL0, the thread pointer libc uses for thread-local
+	// storage.
+	TPIDR uint64
+
+	// TPIDRRO is TPIDRRO_EL0, which EL0 can read but not write. Linux keeps
+	// it zero.
+	TPIDRRO uint64
+}
+
+// NewSysRegFile creates a new system register file.
+func NewSysRegFile() *SysRegFile {
+	return &SysRegFile{}
+}
+
+// System register encodings (o0:op1:CRn:CRm:op2) of the registers modeled
+// by MRS and MSR.
+const (
+	sysRegMIDR    = 0x4000 // MIDR_EL1
+	sysRegMPIDR   = 0x4005 // MPIDR_EL1
+	sysRegREVIDR  = 0x4006 // REVIDR_EL1
+	sysRegPFR0    = 0x4020 // ID_AA64PFR0_EL1
+	sysRegISAR0   = 0x4030 // ID_AA64ISAR0_EL1
+	sysRegISAR1   = 0x4031 // ID_AA64ISAR1_EL1
+	sysRegCTR     = 0x5801 // CTR_EL0
+	sysRegDCZID   = 0x5807 // DCZID_EL0
+	sysRegNZCV    = 0x5A10 // NZCV
+	sysRegFPCR    = 0x5A20 // FPCR
+	sysRegFPSR    = 0x5A21 // FPSR
+	sysRegTPIDR   = 0x5E82 // TPIDR_EL0
+	sysRegTPIDRRO = 0x5E83 // TPIDRRO_EL0
+	sysRegCNTFRQ  = 0x5F00 // CNTFRQ_EL0
+	sysRegCNTPCT  = 0x5F01 // CNTPCT_EL0
+	sysRegCNTVCT  = 0x5F02 // CNTVCT_EL0
+)
+
+// The values of the ID registers as Linux presents them to EL0 on an M2
+// performance core. Linux traps EL0 reads of the ID space and returns
+// sanitized values: only the features the simulator implements are
+// advertised, and registers it does not list read as zero.
+const (
+	// midrValue is MIDR_EL1: implementer Apple (0x61), architecture 0xF,
+	// part 0x033 (M2 Avalanche).
+	midrValue uint64 = 0x610F0330
+
+	// mpidrValue is MPIDR_EL1 with only the RES1 bit 31 set, as Linux
+	// reports it.
+	mpidrValue uint64 = 0x80000000
+
+	// pfr0Value is ID_AA64PFR0_EL1: AArch64 at EL0 and EL1, and FP and
+	// Advanced SIMD with half-precision support.
+	pfr0Value uint64 = 0x1<<20 | 0x1<<16 | 0x1<<4 | 0x1
+
+	// isar0Value is ID_AA64ISAR0_EL1: AES with PMULL, SHA1, SHA256 and
+	// SHA512, CRC32, LSE atomics and SHA3.
+	isar0Value uint64 = 0x1<<32 | 0x2<<20 | 0x1<<16 | 0x2<<12 | 0x1<<8 | 0x2<<4
+
+	// isar1Value is ID_AA64ISAR1_EL1: DC CVAP and LDAPR.
+	isar1Value uint64 = 0x1<<20 | 0x1
+
+	// ctrValue is CTR_EL0: 64-byte minimum data and instruction cache
+	// lines, exclusives reservation and writeback granules, and a PIPT
+	// instruction cache. IDC and DIC are clear, so code that writes
+	// instructions must clean and invalidate the caches.
+	ctrValue uint64 = 1<<31 | 4<<24 | 4<<20 | 4<<16 | 3<<14 | 4
+
+	// dczidValue is DCZID_EL0: DC ZVA is permitted and zeroes blocks of
+	// 4<<4 = 64 bytes.
+	dczidValue uint64 = 4
+)
+
+// Generic timer configuration. The counter ticks at the 24 MHz of Apple
+// silicon, derived from the core clock, 3.5 GHz unless configured with
+// WithCoreFrequency.
+const (
+	// CounterFrequency is the generic timer frequency in Hz, CNTFRQ_EL0.
+	CounterFrequency = 24_000_000
+
+	// CoreFrequency is the default simulated core clock in Hz.
+	CoreFrequency = 3_500_000_000
+)
+
+// zvaBlockSize is the size of the block DC ZVA zeroes.
+const zvaBlockSize = 4 << dczidValue
+
+// nzcv packs the condition flags into the NZCV register layout.
+func (p PSTATE) nzcv() uint64 {
+	var value uint64
+	for i, flag := range []bool{p.V, p.C, p.Z, p.N} {
+		if flag {
+			value |= 1 << (28 + i)
+		}
+	}
+	return value
+}
+
+// setNZCV sets the condition flags from the NZCV register layout.
+func (p *PSTATE) setNZCV(value uint64) {
+	p.N = value&(1<<31) != 0
+	p.Z = value&(1<<30) != 0
+	p.C = value&(1<<29) != 0
+	p.V = value&(1<<28) != 0
+}
@@ -0,0 +1,120 @@
+package emu_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("System Registers", func() {
+	var e *emu.Emulator
+
+	BeforeEach(func() {
+		e = emu.NewEmulator()
+	})
+
+	x := func(reg uint8) uint64 {
+		return e.RegFile().ReadReg(reg)
+	}
+
+	It("should read and write the thread pointer", func() {
+		runAsm(e, `movz x0, #0xbeef
+			msr tpidr_el0, x0
+			mrs x1, tpidr_el0
+			msr tpidrro_el0, x0
+			mrs x2, tpidrro_el0`)
+
+		Expect(e.SysRegFile().TPIDR).To(Equal(uint64(0xBEEF)))
+		Expect(x(1)).To(Equal(uint64(0xBEEF)))
+		Expect(x(2)).To(BeZero())
+	})
+
+	It("should move the condition flags through NZCV", func() {
+		runAsm(e, `movz x4, #0
+			cmp x4, #1
+			mrs x0, nzcv
+			movz x1, #0x6000, lsl #16
+			msr nzcv, x1
+			cset x2, eq
+			cset x3, cs`)
+
+		Expect(x(0)).To(Equal(uint64(0x80000000)))
+		Expect(e.RegFile().PSTATE).To(Equal(emu.PSTATE{Z: true, C: true}))
+		Expect(x(2)).To(Equal(uint64(1)))
+		Expect(x(3)).To(Equal(uint64(1)))
+	})
+
+	It("should read the floating-point control and status registers", func() {
+		runAsm(e, `movz x0, #0xc0, lsl #16
+			msr fpcr, x0
+			mrs x1, fpcr
+			mrs x2, fpsr`)
+
+		Expect(e.SIMDRegFile().FPCR).To(Equal(uint64(0xC00000)))
+		Expect(x(1)).To(Equal(uint64(0xC00000)))
+		Expect(x(2)).To(BeZero())
+	})
+
+	It("should read the ID and cache type registers Linux exposes", func() {
+		runAsm(e, `mrs x0, midr_el1
+			mrs x1, id_aa64isar0_el1
+			mrs x2, id_aa64pfr0_el1
+			mrs x3, ctr_el0
+			mrs x4, id_aa64mmfr2_el1
+			msr midr_el1, x3
+			mrs x5, midr_el1`)
+
+		Expect(x(0)).To(Equal(uint64(0x610F0330)))
+		Expect(x(1)).To(Equal(uint64(0x0000000100212120)))
+		Expect(x(2)).To(Equal(uint64(0x110011)))
+		Expect(x(3)).To(Equal(uint64(0x8444C004)))
+		Expect(x(4)).To(BeZero())
+		Expect(x(5)).To(Equal(uint64(0x610F0330)))
+	})
+
+	It("should derive the generic timer from the instruction count", func() {
+		runAsm(e, strings.Repeat("nop\n", 1000)+"mrs x0, cntvct_el0\nmrs x1, cntfrq_el0")
+
+		// 1000 instructions at 3.5 GHz are 6.86 ticks of the 24 MHz counter
+		Expect(x(0)).To(Equal(uint64(6)))
+		Expect(x(1)).To(Equal(uint64(emu.CounterFrequency)))
+	})
+
+	It("should derive the generic timer from an attached cycle counter", func() {
+		cycles := uint64(7_000_000_000)
+		e = emu.NewEmulator(emu.WithCycleCounter(func() uint64 { return cycles }))
+		runAsm(e, "mrs x0, cntvct_el0; mrs x1, cntpct_el0")
+
+		Expect(x(0)).To(Equal(uint64(48_000_000)))
+		Expect(x(1)).To(Equal(uint64(48_000_000)))
+	})
+
+	It("should scale the generic timer with the core frequency", func() {
+		e = emu.NewEmulator(
+			emu.WithCycleCounter(func() uint64 { return 1_750_000_000 }),
+			emu.WithCoreFrequency(1_750_000_000))
+		runAsm(e, "mrs x0, cntvct_el0")
+
+		Expect(x(0)).To(Equal(uint64(emu.CounterFrequency)))
+	})
+
+	It("should zero a block with DC ZVA and ignore cache cleaning", func() {
+		for addr := uint64(0x2000); addr < 0x2100; addr += 8 {
+			e.Memory().Write64(addr, 0xFFFFFFFFFFFFFFFF)
+		}
+		runAsm(e, `movz x0, #0x2047
+			dc zva, x0
+			dc cvau, x0
+			ic ivau, x0
+			dc civac, x0`)
+
+		Expect(e.Memory().Read64(0x2038)).To(Equal(uint64(0xFFFFFFFFFFFFFFFF)))
+		for addr := uint64(0x2040); addr < 0x2080; addr += 8 {
+			Expect(e.Memory().Read64(addr)).To(BeZero(), "0x%X", addr)
+		}
+		Expect(e.Memory().Read64(0x2080)).To(Equal(uint64(0xFFFFFFFFFFFFFFFF)))
+	})
+})
@@ -0,0 +1,149 @@
+package emu
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// UnimplementedInstructionError reports an instruction that the decoder does
+// not recognize or the emulator cannot execute.
+type UnimplementedInstructionError struct {
+	// PC is the address of the instruction.
+	PC uint64
+	// Word is the raw instruction word.
+	Word uint32
+	// Class is the best-guess A64 encoding class of Word.
+	Class string
+	// Symbol names PC as symbol+offset, or is empty if unknown.
+	Symbol string
+}
+
+// Error implements the error interface.
+func (e *UnimplementedInstructionError) Error() string {
+	return fmt.Sprintf("unknown instruction %s (%s)", e.location(), e.Class)
+}
+
+// location describes the instruction word and where it is.
+func (e *UnimplementedInstructionError) location() string {
+	s := fmt.Sprintf("0x%08X at PC=0x%X", e.Word, e.PC)
+	if e.Symbol != "" {
+		s += " <" + e.Symbol + ">"
+	}
+	return s
+}
+
+// Symbolizer names code addresses in diagnostics. *loader.Program implements
+// it.
+type Symbolizer interface {
+	// Symbolize names addr as symbol+offset, or returns "" if it cannot.
+	Symbolize(addr uint64) string
+}
+
+// WithSymbolizer sets the symbolizer used to name the location of
+// unimplemented instructions.
+func WithSymbolizer(symbolizer Symbolizer) EmulatorOption {
+	return func(e *Emulator) {
+		e.symbolizer = symbolizer
+	}
+}
+
+// WithUnimplementedReport makes the emulator record unimplemented
+// instructions in report and execute them as NOPs instead of stopping.
+func WithUnimplementedReport(report *UnimplementedReport) EmulatorOption {
+	return func(e *Emulator) {
+		e.unimplemented = report
+	}
+}
+
+// unimplementedInstruction handles an instruction the emulator cannot
+// execute. It records and skips the instruction if a report is configured
+// and fails otherwise.
+func (e *Emulator) unimplementedInstruction(inst *insts.Instruction) StepResult {
+	err := &UnimplementedInstructionError{
+		PC:    e.regFile.PC,
+		Word:  inst.Word,
+		Class: insts.Classify(inst.Word),
+	}
+	if e.symbolizer != nil {
+		err.Symbol = e.symbolizer.Symbolize(err.PC)
+	}
+
+	if e.unimplemented != nil {
+		e.unimplemented.Record(err)
+		e.regFile.PC += 4
+		return StepResult{}
+	}
+
+	return StepResult{Err: err}
+}
+
+// UnimplementedEntry counts the unimplemented instructions of one encoding
+// class.
+type UnimplementedEntry struct {
+	// Class is the encoding class.
+	Class string
+	// Count is the number of times instructions of the class executed.
+	Count uint64
+	// First is the first occurrence.
+	First *UnimplementedInstructionError
+}
+
+// UnimplementedReport is a histogram of the unimplemented instructions a
+// program executed, by encoding class.
+type UnimplementedReport struct {
+	entries map[string]*UnimplementedEntry
+	total   uint64
+}
+
+// NewUnimplementedReport creates an empty report.
+func NewUnimplementedReport() *UnimplementedReport {
+	return &UnimplementedReport{entries: make(map[string]*UnimplementedEntry)}
+}
+
+// Record counts one executed unimplemented instruction.
+func (r *UnimplementedReport) Record(err *UnimplementedInstructionError) {
+	entry, ok := r.entries[err.Class]
+	if !ok {
+		entry = &UnimplementedEntry{Class: err.Class, First: err}
+		r.entries[err.Class] = entry
+	}
+	entry.Count++
+	r.total++
+}
+
+// Total returns the number of unimplemented instructions executed.
+func (r *UnimplementedReport) Total() uint64 {
+	return r.total
+}
+
+// Entries returns the histogram, most frequent class first.
+func (r *UnimplementedReport) Entries() []UnimplementedEntry {
+	entries := make([]UnimplementedEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Class < entries[j].Class
+	})
+	return entries
+}
+
+// Print writes the histogram to w.
+func (r *UnimplementedReport) Print(w io.Writer) {
+	if r.total == 0 {
+		_, _ = fmt.Fprintf(w, "Unimplemented instructions: none\n")
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "Unimplemented instructions: %d in %d encoding classes\n",
+		r.total, len(r.entries))
+	for _, entry := range r.Entries() {
+		_, _ = fmt.Fprintf(w, "  %10d  %-50s  first: %s\n", entry.Count, entry.Class, entry.First.location())
+	}
+}
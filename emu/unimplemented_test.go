@@ -0,0 +1,124 @@
+package emu_test
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// symbolTable names addresses relative to a single symbol.
+type symbolTable struct {
+	name string
+	addr uint64
+}
+
+func (s symbolTable) Symbolize(addr uint64) string {
+	if addr < s.addr {
+		return ""
+	}
+	if addr == s.addr {
+		return s.name
+	}
+	return fmt.Sprintf("%s+0x%x", s.name, addr-s.addr)
+}
+
+var _ = Describe("Unimplemented instructions", func() {
+	const codeAddr = uint64(0x400000)
+
+	execute := func(e *emu.Emulator, word uint32) emu.StepResult {
+		return e.Execute(insts.NewDecoder().Decode(word))
+	}
+
+	It("should report a typed error with the encoding class and symbol", func() {
+		e := emu.NewEmulator(emu.WithSymbolizer(symbolTable{name: "main", addr: codeAddr}))
+		e.RegFile().PC = codeAddr + 8
+
+		result := execute(e, 0x00000001)
+
+		var err *emu.UnimplementedInstructionError
+		Expect(result.Err).To(BeAssignableToTypeOf(err))
+		err = result.Err.(*emu.UnimplementedInstructionError)
+		Expect(err.PC).To(Equal(codeAddr + 8))
+		Expect(err.Word).To(Equal(uint32(0x00000001)))
+		Expect(err.Class).To(Equal("Reserved"))
+		Expect(err.Symbol).To(Equal("main+0x8"))
+		Expect(err.Error()).To(Equal(
+			"unknown instruction 0x00000001 at PC=0x400008 <main+0x8> (Reserved)"))
+		Expect(e.RegFile().PC).To(Equal(codeAddr + 8))
+	})
+
+	It("should omit the symbol without a symbolizer", func() {
+		e := emu.NewEmulator()
+		e.RegFile().PC = codeAddr
+
+		result := execute(e, 0x00000001)
+
+		Expect(result.Err).To(MatchError(
+			"unknown instruction 0x00000001 at PC=0x400000 (Reserved)"))
+	})
+
+	Describe("report mode", func() {
+		var (
+			e      *emu.Emulator
+			report *emu.UnimplementedReport
+		)
+
+		BeforeEach(func() {
+			report = emu.NewUnimplementedReport()
+			e = emu.NewEmulator(emu.WithUnimplementedReport(report))
+			e.RegFile().PC = codeAddr
+		})
+
+		It("should execute unimplemented instructions as NOPs", func() {
+			e.RegFile().X[0] = 5
+
+			result := execute(e, 0x00000001)
+
+			Expect(result.Err).NotTo(HaveOccurred())
+			Expect(result.Exited).To(BeFalse())
+			Expect(e.RegFile().PC).To(Equal(codeAddr + 4))
+			Expect(e.RegFile().X[0]).To(Equal(uint64(5)))
+			Expect(report.Total()).To(Equal(uint64(1)))
+		})
+
+		It("should build a histogram by encoding class", func() {
+			execute(e, 0x00000001)
+			execute(e, 0x04000000) // SVE
+			execute(e, 0x00000002)
+
+			Expect(report.Total()).To(Equal(uint64(3)))
+			entries := report.Entries()
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Class).To(Equal("Reserved"))
+			Expect(entries[0].Count).To(Equal(uint64(2)))
+			Expect(entries[0].First.PC).To(Equal(codeAddr))
+			Expect(entries[1].Class).To(Equal("SVE"))
+			Expect(entries[1].Count).To(Equal(uint64(1)))
+			Expect(entries[1].First.PC).To(Equal(codeAddr + 4))
+		})
+
+		It("should print the histogram", func() {
+			execute(e, 0x00000001)
+
+			out := &bytes.Buffer{}
+			report.Print(out)
+
+			Expect(out.String()).To(HavePrefix(
+				"Unimplemented instructions: 1 in 1 encoding classes\n"))
+			Expect(out.String()).To(MatchRegexp(
+				`\s+1  Reserved\s+first: 0x00000001 at PC=0x400000\n`))
+		})
+
+		It("should print an empty report", func() {
+			out := &bytes.Buffer{}
+			emu.NewUnimplementedReport().Print(out)
+
+			Expect(out.String()).To(Equal("Unimplemented instructions: none\n"))
+		})
+	})
+})
@@ -0,0 +1,112 @@
+package emu
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// File is an open file of a FileSystem. *os.File implements it.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Stat() (fs.FileInfo, error)
+}
+
+// FileSystem is the filesystem the program's paths resolve in. Paths are
+// guest paths: absolute, or relative to the directory Getwd returns.
+// Errors wrap the fs and syscall errors the host would return, which the
+// syscall handler converts to Linux errnos.
+type FileSystem interface {
+	// OpenFile opens a file with os.OpenFile flags.
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	// Stat returns information about a file, following symbolic links.
+	Stat(name string) (fs.FileInfo, error)
+	// Lstat returns information about a file without following a final
+	// symbolic link.
+	Lstat(name string) (fs.FileInfo, error)
+	// Readlink returns the target of a symbolic link.
+	Readlink(name string) (string, error)
+	// Getwd returns the program's working directory.
+	Getwd() (string, error)
+}
+
+// HostFS passes guest paths through to the host unchanged. The program can
+// read and write anything the simulator can. It is the default.
+type HostFS struct{}
+
+// OpenFile opens a host file.
+func (HostFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stat stats a host file.
+func (HostFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// Lstat lstats a host file.
+func (HostFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+// Readlink reads a host symbolic link.
+func (HostFS) Readlink(name string) (string, error) { return os.Readlink(name) }
+
+// Getwd returns the simulator's working directory.
+func (HostFS) Getwd() (string, error) { return os.Getwd() }
+
+// RootFS confines the program to a host directory, which it sees as /, as
+// if chrooted. Neither .. nor symbolic links lead out of it.
+type RootFS struct {
+	root *os.Root
+}
+
+// NewRootFS creates a filesystem rooted at the host directory dir.
+func NewRootFS(dir string) (*RootFS, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &RootFS{root: root}, nil
+}
+
+// rel converts a guest path to a path relative to the root. The working
+// directory is /, and .. at / stays at /.
+func (r *RootFS) rel(name string) string {
+	rel := strings.TrimPrefix(path.Join("/", name), "/")
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// OpenFile opens a file under the root.
+func (r *RootFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := r.root.OpenFile(r.rel(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stat stats a file under the root.
+func (r *RootFS) Stat(name string) (fs.FileInfo, error) { return r.root.Stat(r.rel(name)) }
+
+// Lstat lstats a file under the root.
+func (r *RootFS) Lstat(name string) (fs.FileInfo, error) { return r.root.Lstat(r.rel(name)) }
+
+// Readlink reads a symbolic link under the root.
+func (r *RootFS) Readlink(name string) (string, error) { return r.root.Readlink(r.rel(name)) }
+
+// Getwd returns /.
+func (r *RootFS) Getwd() (string, error) { return "/", nil }
+
+// Close releases the root directory.
+func (r *RootFS) Close() error { return r.root.Close() }
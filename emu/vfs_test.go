@@ -0,0 +1,204 @@
+package emu_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Filesystems", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "data"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "data", "in.txt"), []byte("hello"), 0644)).To(Succeed())
+	})
+
+	readAll := func(fsys emu.FileSystem, name string) string {
+		f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = f.Close() }()
+		data, err := io.ReadAll(f)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	Describe("RootFS", func() {
+		var fsys *emu.RootFS
+
+		BeforeEach(func() {
+			var err error
+			fsys, err = emu.NewRootFS(dir)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(fsys.Close)
+		})
+
+		It("should resolve guest paths under the root", func() {
+			Expect(readAll(fsys, "/data/in.txt")).To(Equal("hello"))
+			Expect(readAll(fsys, "data/in.txt")).To(Equal("hello"))
+			Expect(fsys.Getwd()).To(Equal("/"))
+		})
+
+		It("should keep the program inside the root", func() {
+			Expect(readAll(fsys, "/../../data/in.txt")).To(Equal("hello"))
+
+			Expect(os.Symlink("/etc", filepath.Join(dir, "escape"))).To(Succeed())
+			_, err := fsys.OpenFile("/escape/passwd", os.O_RDONLY, 0)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("MemFS", func() {
+		It("should load files from a manifest", func() {
+			manifestPath := filepath.Join(dir, "manifest")
+			Expect(os.WriteFile(manifestPath,
+				[]byte("# inputs\n/input/a.txt data/in.txt\n\nb.txt "+filepath.Join(dir, "data", "in.txt")+"\n"),
+				0644)).To(Succeed())
+
+			manifest, err := emu.LoadManifest(manifestPath)
+			Expect(err).NotTo(HaveOccurred())
+			fsys, err := emu.NewMemFS(manifest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(readAll(fsys, "/input/a.txt")).To(Equal("hello"))
+			Expect(readAll(fsys, "b.txt")).To(Equal("hello"))
+			info, err := fsys.Stat("/input")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.IsDir()).To(BeTrue())
+			_, err = fsys.Stat("/missing")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should reject malformed manifests", func() {
+			manifestPath := filepath.Join(dir, "manifest")
+			Expect(os.WriteFile(manifestPath, []byte("only-one-field\n"), 0644)).To(Succeed())
+			_, err := emu.LoadManifest(manifestPath)
+			Expect(err).To(MatchError(ContainSubstring("manifest:1")))
+		})
+
+		It("should create, write and truncate files in memory", func() {
+			fsys, err := emu.NewMemFS(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			f, err := fsys.OpenFile("/out.txt", os.O_WRONLY|os.O_CREATE, 0644)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write([]byte("abc"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Read(make([]byte, 1))
+			Expect(err).To(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+
+			f, err = fsys.OpenFile("/out.txt", os.O_WRONLY|os.O_APPEND, 0)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write([]byte("def"))
+			Expect(err).NotTo(HaveOccurred())
+			data, ok := fsys.ReadFile("/out.txt")
+			Expect(ok).To(BeTrue())
+			Expect(string(data)).To(Equal("abcdef"))
+
+			_, err = fsys.OpenFile("/out.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+			Expect(os.IsExist(err)).To(BeTrue())
+			_, err = fsys.OpenFile("/out.txt", os.O_RDWR|os.O_TRUNC, 0)
+			Expect(err).NotTo(HaveOccurred())
+			data, _ = fsys.ReadFile("/out.txt")
+			Expect(data).To(BeEmpty())
+
+			_, err = fsys.OpenFile("/no/such/dir.txt", os.O_WRONLY|os.O_CREATE, 0644)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Overlay", func() {
+		var fsys *emu.MemFS
+
+		BeforeEach(func() {
+			root, err := emu.NewRootFS(dir)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(root.Close)
+			fsys = emu.NewOverlayFS(root)
+		})
+
+		It("should read through to the lower filesystem", func() {
+			Expect(readAll(fsys, "/data/in.txt")).To(Equal("hello"))
+		})
+
+		It("should never write to the lower filesystem", func() {
+			f, err := fsys.OpenFile("/data/in.txt", os.O_RDWR, 0)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.WriteAt([]byte("J"), 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			f, err = fsys.OpenFile("/data/new.txt", os.O_WRONLY|os.O_CREATE, 0644)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write([]byte("new"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(readAll(fsys, "/data/in.txt")).To(Equal("Jello"))
+			Expect(readAll(fsys, "/data/new.txt")).To(Equal("new"))
+
+			data, err := os.ReadFile(filepath.Join(dir, "data", "in.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("hello"))
+			_, err = os.Stat(filepath.Join(dir, "data", "new.txt"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Syscalls", func() {
+		var (
+			regFile *emu.RegFile
+			memory  *emu.Memory
+			handler *emu.DefaultSyscallHandler
+			sys     syscaller
+		)
+
+		BeforeEach(func() {
+			regFile = &emu.RegFile{}
+			memory = emu.NewMemory()
+			handler = emu.NewDefaultSyscallHandler(regFile, memory, new(bytes.Buffer), new(bytes.Buffer))
+			sys = syscaller{handler, regFile}
+		})
+
+		writeString := func(addr uint64, s string) uint64 {
+			memory.WriteBytes(addr, append([]byte(s), 0))
+			return addr
+		}
+
+		It("should open, read and stat files in the handler's filesystem", func() {
+			root, err := emu.NewRootFS(dir)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(root.Close)
+			handler.SetFileSystem(root)
+
+			path := writeString(0x1000, "/data/in.txt")
+			fd := sys.call(emu.SyscallOpenat, emu.AT_FDCWD_U64, path, emu.O_RDONLY, 0)
+			Expect(fd).To(BeNumerically(">=", 3))
+			Expect(sys.call(emu.SyscallRead, uint64(fd), 0x2000, 16)).To(Equal(int64(5)))
+
+			Expect(sys.call(emu.SyscallNewfstatat, emu.AT_FDCWD_U64, path, 0x3000, 0)).To(BeZero())
+			Expect(sys.call(emu.SyscallNewfstatat, emu.AT_FDCWD_U64, writeString(0x1000, "/in.txt"), 0x3000, 0)).
+				To(Equal(int64(-emu.ENOENT)))
+
+			Expect(sys.call(emu.SyscallGetcwd, 0x4000, 64)).To(Equal(int64(2)))
+			Expect(memory.Read8(0x4000)).To(Equal(byte('/')))
+		})
+
+		It("should fail writes to files opened read-only", func() {
+			fsys, err := emu.NewMemFS(emu.Manifest{"in.txt": filepath.Join(dir, "data", "in.txt")})
+			Expect(err).NotTo(HaveOccurred())
+			handler.SetFileSystem(fsys)
+
+			fd := sys.call(emu.SyscallOpenat, emu.AT_FDCWD_U64, writeString(0x1000, "in.txt"), emu.O_RDONLY, 0)
+			Expect(sys.call(emu.SyscallWrite, uint64(fd), 0x2000, 4)).To(Equal(int64(-emu.EBADF)))
+			Expect(sys.call(emu.SyscallOpenat, emu.AT_FDCWD_U64, writeString(0x1000, "/"), emu.O_RDWR, 0)).
+				To(Equal(int64(-emu.EISDIR)))
+		})
+	})
+})
@@ -0,0 +1,430 @@
+package emu
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// vmaPageSize is the page size of the simulated process's address space,
+// which mmap and brk align to. It is the page size the loader reports in
+// AT_PAGESZ.
+const vmaPageSize uint64 = 4096
+
+// VMA is a virtual memory area: a page-aligned range of the address space
+// with uniform protection and backing, as the kernel tracks mappings.
+type VMA struct {
+	// Start and End delimit the area, [Start, End).
+	Start, End uint64
+	// Prot holds the mmap protection bits.
+	Prot int
+	// Flags holds MAP_SHARED or MAP_PRIVATE, and MAP_ANONYMOUS for mappings
+	// that no file backs.
+	Flags int
+	// Offset is the file offset mapped at Start.
+	Offset uint64
+	// Name is the backing file's path, a pseudo-path such as [heap] or
+	// [stack], or empty.
+	Name string
+
+	// file backs file mappings; shared mappings are written back to it.
+	file File
+	// mmapped is set for areas created by mmap.
+	mmapped bool
+}
+
+// Len returns the size of the area in bytes.
+func (v VMA) Len() uint64 {
+	return v.End - v.Start
+}
+
+// shared reports whether v is a shared file mapping, whose writes must
+// reach the file.
+func (v VMA) shared() bool {
+	return v.file != nil && v.Flags&MAP_SHARED != 0
+}
+
+// AddressSpace manages the virtual memory areas of the simulated process.
+// It keeps the page protection of its Memory in step with the areas, and
+// moves file contents in and out of memory for file mappings.
+type AddressSpace struct {
+	memory   *Memory
+	vmas     []VMA // sorted by Start, non-overlapping
+	mmapBase uint64
+}
+
+// NewAddressSpace creates an empty address space over memory. mmap places
+// mappings from DefaultMmapBase up.
+func NewAddressSpace(memory *Memory) *AddressSpace {
+	return &AddressSpace{memory: memory, mmapBase: DefaultMmapBase}
+}
+
+// copyFrom replaces the areas and the mmap placement hint of as with those
+// of src. The areas share src's backing files.
+func (as *AddressSpace) copyFrom(src *AddressSpace) {
+	as.vmas = slices.Clone(src.vmas)
+	as.mmapBase = src.mmapBase
+}
+
+// pageDown and pageUp align addresses to vmaPageSize.
+func pageDown(addr uint64) uint64 { return addr &^ (vmaPageSize - 1) }
+func pageUp(addr uint64) uint64   { return (addr + vmaPageSize - 1) &^ (vmaPageSize - 1) }
+
+// Map records a private anonymous mapping of size bytes at addr, replacing
+// any it overlaps, without changing memory contents. It lets the loader map
+// program segments and the stack into the address space.
+func (as *AddressSpace) Map(addr, size uint64, prot int) {
+	as.MapNamed(addr, size, prot, "")
+}
+
+// MapNamed is Map for a mapping shown with a name, such as the program's
+// path for its segments or [stack].
+func (as *AddressSpace) MapNamed(addr, size uint64, prot int, name string) {
+	if size == 0 {
+		return
+	}
+	as.insert(VMA{
+		Start: pageDown(addr),
+		End:   pageUp(addr + size),
+		Prot:  prot,
+		Flags: MAP_PRIVATE | MAP_ANONYMOUS,
+		Name:  name,
+	})
+}
+
+// VMAs returns the areas in address order.
+func (as *AddressSpace) VMAs() []VMA {
+	return append([]VMA(nil), as.vmas...)
+}
+
+// Find returns the area containing addr.
+func (as *AddressSpace) Find(addr uint64) (VMA, bool) {
+	i := as.index(addr)
+	if i < len(as.vmas) && as.vmas[i].Start <= addr {
+		return as.vmas[i], true
+	}
+	return VMA{}, false
+}
+
+// index returns the index of the first area that ends after addr.
+func (as *AddressSpace) index(addr uint64) int {
+	return sort.Search(len(as.vmas), func(i int) bool {
+		return as.vmas[i].End > addr
+	})
+}
+
+// free reports whether no area overlaps [start, end).
+func (as *AddressSpace) free(start, end uint64) bool {
+	i := as.index(start)
+	return i == len(as.vmas) || as.vmas[i].Start >= end
+}
+
+// covered reports whether areas cover every page of [start, end).
+func (as *AddressSpace) covered(start, end uint64) bool {
+	for i := as.index(start); start < end; i++ {
+		if i == len(as.vmas) || as.vmas[i].Start > start {
+			return false
+		}
+		start = as.vmas[i].End
+	}
+	return true
+}
+
+// findFree returns the lowest address at or above the mmap base where size
+// bytes are free.
+func (as *AddressSpace) findFree(size uint64) (uint64, bool) {
+	addr := as.mmapBase
+	for i := as.index(addr); i < len(as.vmas); i++ {
+		if as.vmas[i].Start >= addr+size {
+			break
+		}
+		addr = as.vmas[i].End
+	}
+	if addr+size < addr {
+		return 0, false
+	}
+	return addr, true
+}
+
+// insert adds v, replacing the parts of the areas it overlaps, and maps its
+// pages in memory with its protection.
+func (as *AddressSpace) insert(v VMA) {
+	as.remove(v.Start, v.End)
+	i := as.index(v.Start)
+	as.vmas = append(as.vmas, VMA{})
+	copy(as.vmas[i+1:], as.vmas[i:])
+	as.vmas[i] = v
+	as.memory.Map(v.Start, v.Len(), v.Prot)
+}
+
+// split splits the area containing addr, if any, so that an area starts at
+// addr.
+func (as *AddressSpace) split(addr uint64) {
+	i := as.index(addr)
+	if i == len(as.vmas) || as.vmas[i].Start >= addr {
+		return
+	}
+	head := as.vmas[i]
+	tail := head
+	head.End = addr
+	tail.Start = addr
+	if tail.file != nil {
+		tail.Offset += addr - head.Start
+	}
+	as.vmas = append(as.vmas, VMA{})
+	copy(as.vmas[i+2:], as.vmas[i+1:])
+	as.vmas[i] = head
+	as.vmas[i+1] = tail
+}
+
+// remove deletes the parts of areas in [start, end), writing shared file
+// mappings back first. Memory protection is left to the caller.
+func (as *AddressSpace) remove(start, end uint64) {
+	as.split(start)
+	as.split(end)
+	first := as.index(start)
+	last := first
+	for last < len(as.vmas) && as.vmas[last].End <= end {
+		last++
+	}
+	removed := append([]VMA(nil), as.vmas[first:last]...)
+	as.vmas = append(as.vmas[:first], as.vmas[last:]...)
+
+	for _, v := range removed {
+		if v.shared() {
+			_ = as.writeBack(v)
+		}
+		as.release(v.file)
+	}
+}
+
+// release closes a mapping's file once no area uses it.
+func (as *AddressSpace) release(file File) {
+	if file == nil {
+		return
+	}
+	for _, v := range as.vmas {
+		if v.file == file {
+			return
+		}
+	}
+	_ = file.Close()
+}
+
+// Unmap removes the mappings in size bytes at addr, writing shared file
+// mappings back to their files.
+func (as *AddressSpace) Unmap(addr, size uint64) {
+	if size == 0 {
+		return
+	}
+	start, end := pageDown(addr), pageUp(addr+size)
+	as.remove(start, end)
+	as.memory.Unmap(start, end-start)
+}
+
+// Protect changes the protection of the mappings in size bytes at addr. It
+// reports false, changing nothing, unless mappings cover the whole range.
+func (as *AddressSpace) Protect(addr, size uint64, prot int) bool {
+	start, end := pageDown(addr), pageUp(addr+size)
+	if !as.covered(start, end) {
+		return false
+	}
+	as.split(start)
+	as.split(end)
+	for i := as.index(start); i < len(as.vmas) && as.vmas[i].Start < end; i++ {
+		as.vmas[i].Prot = prot
+	}
+	as.memory.Map(start, end-start, prot)
+	return true
+}
+
+// fill loads the file contents of the part [start, end) of file mapping v
+// into memory. Bytes past the end of the file read as zero.
+func (as *AddressSpace) fill(v VMA, start, end uint64) error {
+	as.memory.Zero(start, end-start)
+	info, err := v.file.Stat()
+	if err != nil {
+		return err
+	}
+	offset := v.Offset + start - v.Start
+	if uint64(info.Size()) <= offset {
+		return nil
+	}
+	buf := make([]byte, min(end-start, uint64(info.Size())-offset))
+	n, err := v.file.ReadAt(buf, int64(offset))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	as.memory.WriteBytes(start, buf[:n])
+	return nil
+}
+
+// mapArea inserts v and initializes its contents: zero for anonymous
+// mappings, and the file's data for file mappings.
+func (as *AddressSpace) mapArea(v VMA) error {
+	as.insert(v)
+	if v.file == nil {
+		as.memory.Zero(v.Start, v.Len())
+		return nil
+	}
+	return as.fill(v, v.Start, v.End)
+}
+
+// writeBack writes shared file mapping v back to its file, up to the end of
+// the file: as in Linux, a mapping never extends its file.
+func (as *AddressSpace) writeBack(v VMA) error {
+	info, err := v.file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size() - int64(v.Offset)
+	if size <= 0 {
+		return nil
+	}
+	buf := make([]byte, min(v.Len(), uint64(size)))
+	as.memory.ReadBytes(v.Start, buf)
+	_, err = v.file.WriteAt(buf, int64(v.Offset))
+	return err
+}
+
+// Sync writes the shared file mappings in size bytes at addr back to their
+// files.
+func (as *AddressSpace) Sync(addr, size uint64) error {
+	return as.sync(pageDown(addr), pageUp(addr+size))
+}
+
+// SyncAll writes every shared file mapping back to its file.
+func (as *AddressSpace) SyncAll() error {
+	return as.sync(0, ^uint64(0))
+}
+
+// sync writes the shared file mappings in [start, end) back.
+func (as *AddressSpace) sync(start, end uint64) error {
+	for i := as.index(start); i < len(as.vmas) && as.vmas[i].Start < end; i++ {
+		if !as.vmas[i].shared() {
+			continue
+		}
+		v := as.vmas[i]
+		v.Start, v.End = max(v.Start, start), min(v.End, end)
+		v.Offset += v.Start - as.vmas[i].Start
+		if err := as.writeBack(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Discard drops the contents of the mappings in size bytes at addr, as
+// MADV_DONTNEED does: private file mappings reload from their files,
+// anonymous mappings read as zero, and shared file mappings keep their data.
+func (as *AddressSpace) Discard(addr, size uint64) {
+	start, end := pageDown(addr), pageUp(addr+size)
+	for i := as.index(start); i < len(as.vmas) && as.vmas[i].Start < end; i++ {
+		v := as.vmas[i]
+		lo, hi := max(v.Start, start), min(v.End, end)
+		switch {
+		case v.shared():
+		case v.file != nil:
+			_ = as.fill(v, lo, hi)
+		default:
+			as.memory.Zero(lo, hi-lo)
+		}
+	}
+}
+
+// Remap resizes the mapping of oldSize bytes at oldAddr to newSize bytes, as
+// mremap does, and returns its address or a Linux errno. The mapping grows
+// in place if the pages after it are free, or else moves if mayMove is set.
+func (as *AddressSpace) Remap(oldAddr, oldSize, newSize uint64, mayMove bool) (uint64, int) {
+	oldSize, newSize = pageUp(oldSize), pageUp(newSize)
+	v, ok := as.Find(oldAddr)
+	if !ok || oldAddr+oldSize > v.End {
+		return 0, EFAULT
+	}
+	if newSize <= oldSize {
+		as.Unmap(oldAddr+newSize, oldSize-newSize)
+		return oldAddr, 0
+	}
+
+	// The part of the area being remapped.
+	part := v
+	part.Start, part.End = oldAddr, oldAddr+oldSize
+	if part.file != nil {
+		part.Offset += oldAddr - v.Start
+	}
+
+	if oldAddr+newSize > oldAddr && as.free(part.End, oldAddr+newSize) {
+		grown := part
+		grown.End = oldAddr + newSize
+		as.split(part.Start)
+		as.split(part.End)
+		as.vmas[as.index(part.Start)] = grown
+		as.memory.Map(grown.Start, grown.Len(), grown.Prot)
+		as.load(grown, part.End)
+		return oldAddr, 0
+	}
+	if !mayMove {
+		return 0, ENOMEM
+	}
+
+	newAddr, ok := as.findFree(newSize)
+	if !ok {
+		return 0, ENOMEM
+	}
+	data := make([]byte, oldSize)
+	as.memory.ReadBytes(oldAddr, data)
+
+	moved := part
+	moved.Start, moved.End = newAddr, newAddr+newSize
+	// The data and the file move with the mapping, so the old part is
+	// neither written back nor released.
+	as.split(part.Start)
+	as.split(part.End)
+	as.removeAt(as.index(part.Start))
+	as.memory.Unmap(part.Start, part.Len())
+
+	as.insert(moved)
+	as.memory.WriteBytes(newAddr, data)
+	as.load(moved, moved.Start+oldSize)
+	return newAddr, 0
+}
+
+// removeAt deletes and returns area i.
+func (as *AddressSpace) removeAt(i int) VMA {
+	v := as.vmas[i]
+	as.vmas = append(as.vmas[:i], as.vmas[i+1:]...)
+	return v
+}
+
+// load initializes the pages of v from from on, which a remap just added:
+// from the file for file mappings, and zero otherwise.
+func (as *AddressSpace) load(v VMA, from uint64) {
+	if v.file != nil {
+		_ = as.fill(v, from, v.End)
+	} else {
+		as.memory.Zero(from, v.End-from)
+	}
+}
+
+// WriteMaps writes the memory map in the format of /proc/self/maps.
+func (as *AddressSpace) WriteMaps(w io.Writer) {
+	for _, v := range as.vmas {
+		perms := []byte("---p")
+		for i, bit := range []int{PROT_READ, PROT_WRITE, PROT_EXEC} {
+			if v.Prot&bit != 0 {
+				perms[i] = "rwx"[i]
+			}
+		}
+		if v.Flags&MAP_SHARED != 0 {
+			perms[3] = 's'
+		}
+
+		line := fmt.Sprintf("%08x-%08x %s %08x 00:00 0", v.Start, v.End, perms, v.Offset)
+		if v.Name != "" {
+			line += strings.Repeat(" ", max(1, 73-len(line))) + v.Name
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+}
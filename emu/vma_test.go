@@ -0,0 +1,246 @@
+package emu_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+)
+
+var _ = Describe("Address space", func() {
+	var (
+		regFile *emu.RegFile
+		memory  *emu.Memory
+		handler *emu.DefaultSyscallHandler
+		sys     syscaller
+		space   *emu.AddressSpace
+	)
+
+	BeforeEach(func() {
+		regFile = &emu.RegFile{}
+		memory = emu.NewMemory()
+		handler = emu.NewDefaultSyscallHandler(regFile, memory, new(bytes.Buffer), new(bytes.Buffer))
+		sys = syscaller{handler, regFile}
+		space = handler.AddressSpace()
+	})
+
+	mmap := func(addr, length uint64, prot, flags int, fd int64, offset uint64) int64 {
+		return sys.call(emu.SyscallMmap, addr, length, uint64(prot), uint64(flags), uint64(fd), offset)
+	}
+
+	const rw = emu.PROT_READ | emu.PROT_WRITE
+	const anon = emu.MAP_PRIVATE | emu.MAP_ANONYMOUS
+
+	bounds := func() [][2]uint64 {
+		var areas [][2]uint64
+		for _, v := range space.VMAs() {
+			areas = append(areas, [2]uint64{v.Start, v.End})
+		}
+		return areas
+	}
+
+	It("should split a mapping when unmapping its middle", func() {
+		addr := uint64(mmap(0, 3*4096, rw, anon, -1, 0))
+
+		Expect(sys.call(emu.SyscallMunmap, addr+4096, 4096)).To(BeZero())
+		Expect(bounds()).To(Equal([][2]uint64{
+			{addr, addr + 4096},
+			{addr + 2*4096, addr + 3*4096},
+		}))
+		_, mapped := memory.Protection(addr + 4096)
+		Expect(mapped).To(BeFalse())
+
+		// The hole is reused by the next mapping that fits.
+		Expect(uint64(mmap(0, 4096, rw, anon, -1, 0))).To(Equal(addr + 4096))
+	})
+
+	It("should split a mapping when changing the protection of part of it", func() {
+		addr := uint64(mmap(0, 2*4096, rw, anon, -1, 0))
+
+		Expect(sys.call(emu.SyscallMprotect, addr+4096, 4096, emu.PROT_READ)).To(BeZero())
+		vmas := space.VMAs()
+		Expect(vmas).To(HaveLen(2))
+		Expect(vmas[0].Prot).To(Equal(rw))
+		Expect(vmas[1].Prot).To(Equal(emu.PROT_READ))
+	})
+
+	Describe("MAP_FIXED", func() {
+		It("should replace the mappings it overlaps with zeroed pages", func() {
+			addr := uint64(mmap(0, 3*4096, rw, anon, -1, 0))
+			memory.Write64(addr+4096, 0xCAFE)
+
+			Expect(uint64(mmap(addr+4096, 4096, emu.PROT_READ, anon|emu.MAP_FIXED, -1, 0))).
+				To(Equal(addr + 4096))
+			Expect(memory.Read64(addr + 4096)).To(BeZero())
+			Expect(bounds()).To(Equal([][2]uint64{
+				{addr, addr + 4096},
+				{addr + 4096, addr + 2*4096},
+				{addr + 2*4096, addr + 3*4096},
+			}))
+			prot, _ := memory.Protection(addr + 4096)
+			Expect(prot).To(Equal(emu.PROT_READ))
+		})
+
+		It("should fail with EEXIST over a mapping with MAP_FIXED_NOREPLACE", func() {
+			addr := uint64(mmap(0, 4096, rw, anon, -1, 0))
+
+			Expect(mmap(addr, 4096, rw, anon|emu.MAP_FIXED_NOREPLACE, -1, 0)).To(Equal(int64(-emu.EEXIST)))
+			Expect(mmap(addr+4096, 4096, rw, anon|emu.MAP_FIXED_NOREPLACE, -1, 0)).To(Equal(int64(addr + 4096)))
+		})
+
+		It("should reject unaligned addresses", func() {
+			Expect(mmap(0x50000010, 4096, rw, anon|emu.MAP_FIXED, -1, 0)).To(Equal(int64(-emu.EINVAL)))
+		})
+	})
+
+	It("should use a free hint address and ignore a taken one", func() {
+		Expect(mmap(0x50000000, 4096, rw, anon, -1, 0)).To(Equal(int64(0x50000000)))
+		Expect(mmap(0x50000000, 4096, rw, anon, -1, 0)).To(Equal(int64(emu.DefaultMmapBase)))
+	})
+
+	It("should grow a mapping in place when the pages after it are free", func() {
+		addr := uint64(mmap(0, 4096, rw, anon, -1, 0))
+		memory.Write64(addr, 0xCAFE)
+
+		Expect(sys.call(emu.SyscallMremap, addr, 4096, 3*4096, 0)).To(Equal(int64(addr)))
+		Expect(memory.Read64(addr)).To(Equal(uint64(0xCAFE)))
+		Expect(bounds()).To(Equal([][2]uint64{{addr, addr + 3*4096}}))
+	})
+
+	It("should refuse to duplicate a private mapping with a zero old size", func() {
+		addr := uint64(mmap(0, 2*4096, rw, anon, -1, 0))
+
+		Expect(sys.call(emu.SyscallMremap, addr, 0, 4096, emu.MREMAP_MAYMOVE)).To(Equal(int64(-emu.EINVAL)))
+		Expect(bounds()).To(Equal([][2]uint64{{addr, addr + 2*4096}}))
+	})
+
+	Describe("File mappings", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "data")
+			content := make([]byte, 6000)
+			for i := range content {
+				content[i] = byte(i)
+			}
+			Expect(os.WriteFile(path, content, 0644)).To(Succeed())
+		})
+
+		open := func(flags int) int64 {
+			fd, err := handler.GetFDTable().Open(path, flags, 0)
+			Expect(err).NotTo(HaveOccurred())
+			return int64(fd)
+		}
+
+		It("should map a file privately, zero-filling past its end", func() {
+			fd := open(os.O_RDONLY)
+			addr := uint64(mmap(0, 8192, rw, emu.MAP_PRIVATE, fd, 4096))
+			Expect(sys.call(emu.SyscallClose, uint64(fd))).To(BeZero())
+
+			Expect(memory.Read8(addr)).To(Equal(uint8(4096 % 256)))
+			Expect(memory.Read8(addr + 6000 - 4096 - 1)).To(Equal(uint8(5999 % 256)))
+			Expect(memory.Read8(addr + 6000 - 4096)).To(BeZero())
+
+			// Private writes never reach the file.
+			memory.Write8(addr, 0xFF)
+			Expect(sys.call(emu.SyscallMunmap, addr, 8192)).To(BeZero())
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data[4096]).To(Equal(uint8(4096 % 256)))
+		})
+
+		It("should write shared mappings back on msync and munmap without growing the file", func() {
+			fd := open(os.O_RDWR)
+			addr := uint64(mmap(0, 8192, rw, emu.MAP_SHARED, fd, 0))
+
+			memory.Write8(addr+10, 0xAA)
+			Expect(sys.call(emu.SyscallMsync, addr, 4096, emu.MS_SYNC)).To(BeZero())
+			data, _ := os.ReadFile(path)
+			Expect(data[10]).To(Equal(uint8(0xAA)))
+
+			memory.Write8(addr+5000, 0xBB)
+			memory.Write8(addr+7000, 0xCC)
+			Expect(sys.call(emu.SyscallMunmap, addr, 8192)).To(BeZero())
+			data, _ = os.ReadFile(path)
+			Expect(data[5000]).To(Equal(uint8(0xBB)))
+			Expect(data).To(HaveLen(6000))
+		})
+
+		It("should write shared mappings back on exit", func() {
+			fd := open(os.O_RDWR)
+			addr := uint64(mmap(0, 4096, rw, emu.MAP_SHARED, fd, 0))
+			memory.Write8(addr, 0xAA)
+
+			regFile.WriteReg(8, emu.SyscallExitGroup)
+			regFile.WriteReg(0, 0)
+			Expect(handler.Handle().Exited).To(BeTrue())
+
+			data, _ := os.ReadFile(path)
+			Expect(data[0]).To(Equal(uint8(0xAA)))
+		})
+
+		It("should reload private file pages on MADV_DONTNEED", func() {
+			fd := open(os.O_RDONLY)
+			addr := uint64(mmap(0, 4096, rw, emu.MAP_PRIVATE, fd, 0))
+			memory.Write8(addr+1, 0xFF)
+
+			Expect(sys.call(emu.SyscallMadvise, addr, 4096, emu.MADV_DONTNEED)).To(BeZero())
+			Expect(memory.Read8(addr + 1)).To(Equal(uint8(1)))
+		})
+
+		It("should check the file's access mode", func() {
+			Expect(mmap(0, 4096, rw, emu.MAP_SHARED, open(os.O_RDONLY), 0)).To(Equal(int64(-emu.EACCES)))
+			Expect(mmap(0, 4096, emu.PROT_READ, emu.MAP_PRIVATE, open(os.O_WRONLY), 0)).
+				To(Equal(int64(-emu.EACCES)))
+			Expect(mmap(0, 4096, emu.PROT_READ, emu.MAP_PRIVATE, 1, 0)).To(Equal(int64(-emu.ENODEV)))
+			Expect(mmap(0, 4096, emu.PROT_READ, emu.MAP_PRIVATE, open(os.O_RDONLY), 100)).
+				To(Equal(int64(-emu.EINVAL)))
+		})
+	})
+
+	Describe("brk", func() {
+		It("should not grow the heap into another mapping", func() {
+			Expect(mmap(emu.DefaultProgramBreak+0x2000, 4096, rw, anon|emu.MAP_FIXED, -1, 0)).
+				To(Equal(int64(emu.DefaultProgramBreak + 0x2000)))
+
+			Expect(sys.call(emu.SyscallBrk, emu.DefaultProgramBreak+0x2000)).
+				To(Equal(int64(emu.DefaultProgramBreak + 0x2000)))
+			Expect(sys.call(emu.SyscallBrk, emu.DefaultProgramBreak+0x2001)).
+				To(Equal(int64(emu.DefaultProgramBreak + 0x2000)))
+		})
+
+		It("should shrink the heap down to its start", func() {
+			Expect(sys.call(emu.SyscallBrk, emu.DefaultProgramBreak+0x3000)).
+				To(Equal(int64(emu.DefaultProgramBreak + 0x3000)))
+			memory.Write64(emu.DefaultProgramBreak+0x1000, 0xCAFE)
+
+			Expect(sys.call(emu.SyscallBrk, emu.DefaultProgramBreak+0x1000)).
+				To(Equal(int64(emu.DefaultProgramBreak + 0x1000)))
+			_, mapped := memory.Protection(emu.DefaultProgramBreak + 0x1000)
+			Expect(mapped).To(BeFalse())
+
+			// Pages the heap grows back into read as zero.
+			sys.call(emu.SyscallBrk, emu.DefaultProgramBreak+0x2000)
+			Expect(memory.Read64(emu.DefaultProgramBreak + 0x1000)).To(BeZero())
+		})
+	})
+
+	It("should dump the memory map like /proc/self/maps", func() {
+		space.MapNamed(0x400000, 0x1000, emu.PROT_READ|emu.PROT_EXEC, "/bin/prog")
+		sys.call(emu.SyscallBrk, emu.DefaultProgramBreak+0x1000)
+		mmap(0, 4096, rw, anon, -1, 0)
+
+		var out strings.Builder
+		space.WriteMaps(&out)
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[0]).To(MatchRegexp(`^00400000-00401000 r-xp 00000000 00:00 0 +/bin/prog$`))
+		Expect(lines[1]).To(MatchRegexp(`^10000000-10001000 rw-p 00000000 00:00 0 +\[heap\]$`))
+		Expect(lines[2]).To(Equal("40000000-40001000 rw-p 00000000 00:00 0"))
+	})
+})
@@ -0,0 +1,293 @@
+package insts
+
+// Classify returns the name of the A64 encoding class that word belongs to,
+// following the encoding index of the Arm Architecture Reference Manual. It
+// does not depend on whether the decoder implements the instruction, so it
+// can name the class of instructions the decoder reports as OpUnknown. Where
+// the class cannot be narrowed down, it returns the top-level group.
+func Classify(word uint32) string {
+	op0 := (word >> 25) & 0xF // bits [28:25]
+
+	switch {
+	case op0 == 0b0000:
+		if word>>31 == 1 {
+			return "SME"
+		}
+		return "Reserved"
+	case op0 == 0b0010:
+		return "SVE"
+	case op0&0b1110 == 0b1000:
+		return classifyDPImm(word)
+	case op0&0b1110 == 0b1010:
+		return classifyBranchSys(word)
+	case op0&0b0101 == 0b0100:
+		return classifyLoadStore(word)
+	case op0&0b0111 == 0b0101:
+		return classifyDPReg(word)
+	case op0&0b0111 == 0b0111:
+		return classifySIMDFP(word)
+	default:
+		return "Unallocated"
+	}
+}
+
+// bitField extracts bits [hi:lo] of word.
+func bitField(word uint32, hi, lo uint) uint32 {
+	return (word >> lo) & (1<<(hi-lo+1) - 1)
+}
+
+// classifyDPImm classifies Data Processing -- Immediate encodings.
+func classifyDPImm(word uint32) string {
+	switch bitField(word, 25, 23) {
+	case 0b000, 0b001:
+		return "PC-rel. addressing"
+	case 0b010:
+		return "Add/subtract (immediate)"
+	case 0b011:
+		return "Add/subtract (immediate, with tags)"
+	case 0b100:
+		return "Logical (immediate)"
+	case 0b101:
+		return "Move wide (immediate)"
+	case 0b110:
+		return "Bitfield"
+	default:
+		return "Extract"
+	}
+}
+
+// classifyBranchSys classifies Branches, Exception Generating and System
+// instruction encodings.
+func classifyBranchSys(word uint32) string {
+	switch {
+	case bitField(word, 30, 26) == 0b00101:
+		return "Unconditional branch (immediate)"
+	case bitField(word, 30, 25) == 0b011010:
+		return "Compare and branch (immediate)"
+	case bitField(word, 30, 25) == 0b011011:
+		return "Test and branch (immediate)"
+	case bitField(word, 31, 25) == 0b0101010:
+		return "Conditional branch (immediate)"
+	case bitField(word, 31, 24) == 0b11010100:
+		return "Exception generation"
+	case bitField(word, 31, 25) == 0b1101011:
+		return "Unconditional branch (register)"
+	case bitField(word, 31, 22) == 0b1101010100:
+		return classifySystem(word)
+	default:
+		return "Branches, Exception Generating and System instructions"
+	}
+}
+
+// classifySystem classifies the system instruction space (0xD5000000).
+func classifySystem(word uint32) string {
+	switch {
+	case word&0xFFFFF01F == 0xD503201F:
+		return "Hints"
+	case word&0xFFFFF01F == 0xD503301F:
+		return "Barriers"
+	case bitField(word, 21, 19) == 0b000 && bitField(word, 15, 12) == 0b0100:
+		return "PSTATE"
+	case bitField(word, 20, 19) == 0b01:
+		return "System instructions"
+	case bitField(word, 20, 20) == 1:
+		return "System register move"
+	default:
+		return "System"
+	}
+}
+
+// classifyLoadStore classifies Loads and Stores encodings.
+func classifyLoadStore(word uint32) string {
+	simd := bitField(word, 26, 26) == 1
+	suffix := ""
+	if simd {
+		suffix = " (SIMD&FP)"
+	}
+
+	switch bitField(word, 29, 27) {
+	case 0b001:
+		if simd {
+			if bitField(word, 24, 24) == 0 {
+				return "Advanced SIMD load/store multiple structures"
+			}
+			return "Advanced SIMD load/store single structure"
+		}
+		if bitField(word, 24, 24) == 0 {
+			return "Load/store exclusive and ordered"
+		}
+		return "Compare and swap pair"
+	case 0b011:
+		if bitField(word, 24, 24) == 0 {
+			return "Load register (literal)" + suffix
+		}
+		return "LDAPR/STLR (unscaled immediate) or memory copy/set"
+	case 0b101:
+		return "Load/store register pair" + suffix
+	case 0b111:
+		if bitField(word, 24, 24) == 1 {
+			return "Load/store register (unsigned immediate)" + suffix
+		}
+		if bitField(word, 21, 21) == 0 {
+			switch bitField(word, 11, 10) {
+			case 0b00:
+				return "Load/store register (unscaled immediate)" + suffix
+			case 0b10:
+				return "Load/store register (unprivileged)" + suffix
+			default:
+				return "Load/store register (immediate pre/post-indexed)" + suffix
+			}
+		}
+		switch bitField(word, 11, 10) {
+		case 0b00:
+			return "Atomic memory operations" + suffix
+		case 0b10:
+			return "Load/store register (register offset)" + suffix
+		default:
+			return "Load/store register (pac)"
+		}
+	default:
+		return "Loads and Stores"
+	}
+}
+
+// classifyDPReg classifies Data Processing -- Register encodings.
+func classifyDPReg(word uint32) string {
+	op2 := bitField(word, 24, 21)
+
+	if bitField(word, 28, 28) == 0 {
+		switch {
+		case op2&0b1000 == 0:
+			return "Logical (shifted register)"
+		case op2&0b1001 == 0b1000:
+			return "Add/subtract (shifted register)"
+		default:
+			return "Add/subtract (extended register)"
+		}
+	}
+
+	switch {
+	case op2 == 0b0110:
+		if bitField(word, 30, 30) == 0 {
+			return "Data-processing (2 source)"
+		}
+		return "Data-processing (1 source)"
+	case op2 == 0b0000:
+		return "Add/subtract (with carry)"
+	case op2 == 0b0010:
+		if bitField(word, 11, 11) == 0 {
+			return "Conditional compare (register)"
+		}
+		return "Conditional compare (immediate)"
+	case op2 == 0b0100:
+		return "Conditional select"
+	case op2&0b1000 != 0:
+		return "Data-processing (3 source)"
+	default:
+		return "Data Processing -- Register"
+	}
+}
+
+// classifySIMDFP classifies Data Processing -- Scalar Floating-Point and
+// Advanced SIMD encodings.
+func classifySIMDFP(word uint32) string {
+	switch {
+	case word&0xFF3E0C00 == 0x4E280800:
+		return "Cryptographic AES"
+	case word&0xFF208C00 == 0x5E000000:
+		return "Cryptographic three-register SHA"
+	case word&0xFF3E0C00 == 0x5E280800:
+		return "Cryptographic two-register SHA"
+	case word&0xFFE0B000 == 0xCE608000:
+		return "Cryptographic three-register SHA512"
+	case word&0xFFFFF000 == 0xCEC08000:
+		return "Cryptographic two-register SHA512"
+	case word&0xFF808000 == 0xCE000000:
+		return "Cryptographic four-register"
+	case word&0xFFE00000 == 0xCE800000:
+		return "XAR"
+	case bitField(word, 31, 31) == 0 && bitField(word, 28, 28) == 1 && bitField(word, 30, 30) == 0:
+		return classifyFP(word)
+	case bitField(word, 31, 31) == 0:
+		return classifyAdvSIMD(word)
+	default:
+		return "Data Processing -- Scalar Floating-Point and Advanced SIMD"
+	}
+}
+
+// classifyFP classifies scalar floating-point encodings.
+func classifyFP(word uint32) string {
+	switch {
+	case bitField(word, 24, 24) == 1:
+		return "Floating-point data-processing (3 source)"
+	case bitField(word, 21, 21) == 0:
+		return "Conversion between floating-point and fixed-point"
+	case bitField(word, 15, 10) == 0:
+		return "Conversion between floating-point and integer"
+	case bitField(word, 14, 10) == 0b10000:
+		return "Floating-point data-processing (1 source)"
+	case bitField(word, 13, 10) == 0b1000:
+		return "Floating-point compare"
+	case bitField(word, 12, 10) == 0b100:
+		return "Floating-point immediate"
+	case bitField(word, 11, 10) == 0b01:
+		return "Floating-point conditional compare"
+	case bitField(word, 11, 10) == 0b10:
+		return "Floating-point data-processing (2 source)"
+	default:
+		return "Floating-point conditional select"
+	}
+}
+
+// classifyAdvSIMD classifies Advanced SIMD vector and scalar encodings.
+func classifyAdvSIMD(word uint32) string {
+	prefix := "Advanced SIMD "
+	scalar := bitField(word, 28, 28) == 1
+	if scalar {
+		prefix = "Advanced SIMD scalar "
+	}
+
+	if bitField(word, 24, 24) == 1 {
+		switch {
+		case bitField(word, 10, 10) == 0:
+			return prefix + "x indexed element"
+		case !scalar && bitField(word, 22, 19) == 0:
+			return prefix + "modified immediate"
+		default:
+			return prefix + "shift by immediate"
+		}
+	}
+
+	if bitField(word, 21, 21) == 1 {
+		switch {
+		case bitField(word, 10, 10) == 1:
+			return prefix + "three same"
+		case bitField(word, 11, 10) == 0b00:
+			return prefix + "three different"
+		case bitField(word, 20, 17) == 0b0000:
+			return prefix + "two-register miscellaneous"
+		case bitField(word, 20, 17) == 0b1000:
+			if scalar {
+				return "Advanced SIMD scalar pairwise"
+			}
+			return "Advanced SIMD across lanes"
+		default:
+			return "Advanced SIMD"
+		}
+	}
+
+	switch {
+	case bitField(word, 23, 22) == 0 && bitField(word, 15, 15) == 0 && bitField(word, 10, 10) == 1:
+		return prefix + "copy"
+	case scalar:
+		return "Advanced SIMD scalar"
+	case bitField(word, 29, 29) == 1:
+		return "Advanced SIMD extract"
+	case bitField(word, 11, 10) == 0b10:
+		return "Advanced SIMD permute"
+	case bitField(word, 11, 10) == 0b00:
+		return "Advanced SIMD table lookup"
+	default:
+		return "Advanced SIMD"
+	}
+}
@@ -0,0 +1,108 @@
+package insts_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Encoding classes", func() {
+	expectClasses := func(classes map[uint32]string) {
+		for word, class := range classes {
+			Expect(insts.Classify(word)).To(Equal(class), "word 0x%08x", word)
+		}
+	}
+
+	It("should classify top-level groups", func() {
+		expectClasses(map[uint32]string{
+			0x00000000: "Reserved", // udf #0
+			0x04000000: "SVE",
+			0x80000000: "SME",
+		})
+	})
+
+	It("should classify data processing immediates", func() {
+		expectClasses(map[uint32]string{
+			0x10000000: "PC-rel. addressing",       // adr x0, .
+			0x91002820: "Add/subtract (immediate)", // add x0, x1, #10
+			0x92400c00: "Logical (immediate)",      // and x0, x0, #0xf
+			0xd2800000: "Move wide (immediate)",    // mov x0, #0
+			0xd3442c05: "Bitfield",                 // ubfx x5, x0, #4, #8
+			0x93c14013: "Extract",                  // extr x19, x0, x1, #16
+		})
+	})
+
+	It("should classify branches and system instructions", func() {
+		expectClasses(map[uint32]string{
+			0x14000000: "Unconditional branch (immediate)", // b .
+			0xb4000082: "Compare and branch (immediate)",   // cbz x2, +16
+			0x3617ffcf: "Test and branch (immediate)",      // tbz w15, #2, -8
+			0x54000000: "Conditional branch (immediate)",   // b.eq .
+			0xd4000001: "Exception generation",             // svc #0
+			0xd65f03c0: "Unconditional branch (register)",  // ret
+			0xd503201f: "Hints",                            // nop
+			0xd5033bbf: "Barriers",                         // dmb ish
+			0xd500409f: "PSTATE",                           // msr pan, #0
+			0xd53b4210: "System register move",             // mrs x16, nzcv
+		})
+	})
+
+	It("should classify loads and stores", func() {
+		expectClasses(map[uint32]string{
+			0xc85f7c20: "Load/store exclusive and ordered",                           // ldxr x0, [x1]
+			0x18000000: "Load register (literal)",                                    // ldr w0, .
+			0xa9400c22: "Load/store register pair",                                   // ldp x2, x3, [x1]
+			0xf9400020: "Load/store register (unsigned immediate)",                   // ldr x0, [x1]
+			0xf8408420: "Load/store register (immediate pre/post-indexed)",           // ldr x0, [x1], #8
+			0xf85f8020: "Load/store register (unscaled immediate)",                   // ldur x0, [x1, #-8]
+			0xf8606820: "Load/store register (register offset)",                      // ldr x0, [x1, x0]
+			0xf8208020: "Atomic memory operations",                                   // swp x0, x0, [x1]
+			0x3dc00020: "Load/store register (unsigned immediate) (SIMD&FP)",         // ldr q0, [x1]
+			0x4c407020: "Advanced SIMD load/store multiple structures",               // ld1 {v0.16b}, [x1]
+			0x0d40c020: "Advanced SIMD load/store single structure",                  // ld1r {v0.8b}, [x1]
+			0xfc408420: "Load/store register (immediate pre/post-indexed) (SIMD&FP)", // ldr d0, [x1], #8
+		})
+	})
+
+	It("should classify data processing registers", func() {
+		expectClasses(map[uint32]string{
+			0x0a020020: "Logical (shifted register)",       // and w0, w1, w2
+			0x8b020020: "Add/subtract (shifted register)",  // add x0, x1, x2
+			0x8b220020: "Add/subtract (extended register)", // add x0, x1, w2, uxtb
+			0x9ac10811: "Data-processing (2 source)",       // udiv x17, x0, x1
+			0xdac00020: "Data-processing (1 source)",       // rbit x0, x1
+			0x9a020020: "Add/subtract (with carry)",        // adc x0, x1, x2
+			0xfa471820: "Conditional compare (immediate)",  // ccmp x1, #7, #0, ne
+			0x9a82c029: "Conditional select",               // csel x9, x1, x2, gt
+			0x9b020023: "Data-processing (3 source)",       // madd x3, x1, x2, x0
+		})
+	})
+
+	It("should classify floating-point and SIMD instructions", func() {
+		expectClasses(map[uint32]string{
+			0x1e220020: "Conversion between floating-point and integer", // scvtf s0, w1
+			0x1e602820: "Floating-point data-processing (2 source)",     // fadd d0, d1, d0
+			0x1f420020: "Floating-point data-processing (3 source)",     // fmadd d0, d1, d2, d0
+			0x1e202020: "Floating-point compare",                        // fcmp s1, s0
+			0x1e201000: "Floating-point immediate",                      // fmov s0, #2.0
+			0x4e221c20: "Advanced SIMD three same",                      // and v0.16b, v1.16b, v2.16b
+			0x4e20b820: "Advanced SIMD two-register miscellaneous",      // abs v0.16b, v1.16b
+			0x4eb1b820: "Advanced SIMD across lanes",                    // addv s0, v1.4s
+			0x0e040c20: "Advanced SIMD copy",                            // dup v0.2s, w1
+			0x4f000400: "Advanced SIMD modified immediate",              // movi v0.4s, #0
+			0x4f085420: "Advanced SIMD shift by immediate",              // shl v0.16b, v1.16b, #0
+			0x4f809020: "Advanced SIMD x indexed element",               // fmul v0.4s, v1.4s, v0.s[0]
+			0x6e030020: "Advanced SIMD extract",                         // ext v0.16b, v1.16b, v3.16b, #0
+			0x4e421820: "Advanced SIMD permute",                         // uzp1 v0.8h, v1.8h, v2.8h
+			0x4e020000: "Advanced SIMD table lookup",                    // tbl v0.16b, {v0.16b}, v2.16b
+			0x5ee18420: "Advanced SIMD scalar three same",               // add d0, d1, d1
+			0x4e284820: "Cryptographic AES",                             // aese v0.16b, v1.16b
+			0x5e000020: "Cryptographic three-register SHA",              // sha1c q0, s1, v0.4s
+			0xce608020: "Cryptographic three-register SHA512",           // sha512h q0, q1, v0.2d
+			0xcec08020: "Cryptographic two-register SHA512",             // sha512su0 v0.2d, v1.2d
+			0xce020c20: "Cryptographic four-register",                   // eor3 v0.16b, v1.16b, v2.16b, v3.16b
+			0xce822820: "XAR",                                           // xar v0.2d, v1.2d, v2.2d, #10
+		})
+	})
+})
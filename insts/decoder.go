@@ -1,6 +1,8 @@
 // Package insts provides ARM64 instruction definitions and decoding.
 package insts
 
+import "math/bits"
+
 // Op represents an ARM64 opcode.
 type Op uint16
 
@@ -90,6 +92,349 @@ const (
 	OpBIC // Bitwise bit clear (AND NOT): Rd = Rn & ~Rm
 	OpORN // Bitwise OR NOT: Rd = Rn | ~Rm
 	OpEON // Bitwise exclusive OR NOT: Rd = Rn ^ ~Rm
+	// System register write
+	OpMSR // Move to system register
+	// Scalar floating-point arithmetic
+	OpFADD   // Floating-point add
+	OpFSUB   // Floating-point subtract
+	OpFMUL   // Floating-point multiply
+	OpFDIV   // Floating-point divide
+	OpFSQRT  // Floating-point square root
+	OpFMADD  // Fused multiply-add: Rd = Ra + Rn*Rm
+	OpFMSUB  // Fused multiply-subtract: Rd = Ra - Rn*Rm
+	OpFNMADD // Negated fused multiply-add: Rd = -Ra - Rn*Rm
+	OpFNMSUB // Negated fused multiply-subtract: Rd = -Ra + Rn*Rm
+	OpFNMUL  // Floating-point negated multiply
+	OpFABS   // Floating-point absolute value
+	OpFNEG   // Floating-point negate
+	// Scalar floating-point maximum, minimum and rounding
+	OpFMAX   // Maximum
+	OpFMIN   // Minimum
+	OpFMAXNM // Maximum number (a quiet NaN loses to a number)
+	OpFMINNM // Minimum number
+	OpFRINTN // Round to integral, to nearest with ties to even
+	OpFRINTA // Round to integral, to nearest with ties away
+	OpFRINTP // Round to integral, toward +infinity
+	OpFRINTM // Round to integral, toward -infinity
+	OpFRINTZ // Round to integral, toward zero
+	OpFRINTX // Round to integral exact, using FPCR rounding
+	OpFRINTI // Round to integral, using FPCR rounding
+	// Scalar floating-point compare and select
+	OpFCMP   // Floating-point compare (quiet)
+	OpFCMPE  // Floating-point compare (signaling)
+	OpFCCMP  // Floating-point conditional compare (quiet)
+	OpFCCMPE // Floating-point conditional compare (signaling)
+	OpFCSEL  // Floating-point conditional select
+	// Scalar floating-point moves
+	OpFMOV       // FMOV (register): copy between FP registers
+	OpFMOVImm    // FMOV (scalar, immediate)
+	OpFMOVToGP   // FMOV (general): FP register to general register
+	OpFMOVFromGP // FMOV (general): general register to FP register
+	// Scalar floating-point conversions
+	OpFCVT   // Convert between floating-point precisions
+	OpSCVTF  // Signed integer/fixed-point to floating-point
+	OpUCVTF  // Unsigned integer/fixed-point to floating-point
+	OpFCVTNS // To signed integer, round to nearest even
+	OpFCVTNU // To unsigned integer, round to nearest even
+	OpFCVTPS // To signed integer, round toward +infinity
+	OpFCVTPU // To unsigned integer, round toward +infinity
+	OpFCVTMS // To signed integer, round toward -infinity
+	OpFCVTMU // To unsigned integer, round toward -infinity
+	OpFCVTZS // To signed integer/fixed-point, round toward zero
+	OpFCVTZU // To unsigned integer/fixed-point, round toward zero
+	OpFCVTAS // To signed integer, round to nearest with ties away
+	OpFCVTAU // To unsigned integer, round to nearest with ties away
+	// Exclusive load/store opcodes (Acquire/Release select LDAXR, STLXR, ...)
+	OpLDXR  // Load exclusive register
+	OpSTXR  // Store exclusive register
+	OpLDXP  // Load exclusive pair
+	OpSTXP  // Store exclusive pair
+	OpCLREX // Clear exclusive monitor
+	// Compare and swap opcodes
+	OpCAS  // Compare and swap
+	OpCASP // Compare and swap pair
+	// LSE atomic memory opcodes (Rt receives the old memory value)
+	OpLDADD  // Atomic add
+	OpLDCLR  // Atomic bit clear
+	OpLDEOR  // Atomic exclusive OR
+	OpLDSET  // Atomic bit set
+	OpLDSMAX // Atomic signed maximum
+	OpLDSMIN // Atomic signed minimum
+	OpLDUMAX // Atomic unsigned maximum
+	OpLDUMIN // Atomic unsigned minimum
+	OpSWP    // Atomic swap
+	// Load-acquire/store-release opcodes
+	OpLDAR  // Load-acquire register
+	OpSTLR  // Store-release register
+	OpLDAPR // Load-acquire RCpc register
+	// Barrier and hint opcodes
+	OpDMB  // Data memory barrier
+	OpDSB  // Data synchronization barrier
+	OpISB  // Instruction synchronization barrier
+	OpHINT // Hint other than NOP (YIELD, WFE, WFI, SEV, SEVL, CSDB, ...)
+	// Data processing (1 source) opcodes
+	OpRBIT  // Reverse bit order
+	OpREV16 // Reverse bytes in each halfword
+	OpREV32 // Reverse bytes in each word (64-bit only)
+	OpREV   // Reverse bytes in the register
+	OpCLZ   // Count leading zeros
+	OpCLS   // Count leading sign bits
+	// Add/subtract with carry opcodes (SetFlags selects ADCS, SBCS)
+	OpADC // Add with carry
+	OpSBC // Subtract with carry
+	// Long and high multiply opcodes
+	OpSMADDL // Signed multiply-add long: Xd = Xa + Wn*Wm
+	OpSMSUBL // Signed multiply-subtract long: Xd = Xa - Wn*Wm
+	OpUMADDL // Unsigned multiply-add long
+	OpUMSUBL // Unsigned multiply-subtract long
+	OpSMULH  // Signed multiply high: upper 64 bits of Xn*Xm
+	OpUMULH  // Unsigned multiply high
+	// CRC32 checksum opcodes (Imm holds the data size in bytes)
+	OpCRC32  // CRC-32 (polynomial 0x04C11DB7)
+	OpCRC32C // CRC-32C (polynomial 0x1EDC6F41)
+	// Additional load/store opcodes
+	OpLDPSW // Load pair of signed words (sign-extended to 64-bit)
+	OpPRFM  // Prefetch memory (Rd holds the prfop operand)
+
+	// SIMD structure loads/stores
+	OpVLDN     // LD1-LD4 (multiple structures)
+	OpVSTN     // ST1-ST4 (multiple structures)
+	OpVLDNLane // LD1-LD4 (single structure to one lane)
+	OpVSTNLane // ST1-ST4 (single structure from one lane)
+	OpVLDNR    // LD1R-LD4R (single structure, replicated to all lanes)
+
+	// SIMD integer three-same
+	OpVSQADD // Signed saturating add
+	OpVUQADD // Unsigned saturating add
+	OpVSQSUB // Signed saturating subtract
+	OpVUQSUB // Unsigned saturating subtract
+	OpVCMEQ  // Compare equal
+	OpVCMGT  // Compare signed greater than
+	OpVCMGE  // Compare signed greater than or equal
+	OpVCMHI  // Compare unsigned higher
+	OpVCMHS  // Compare unsigned higher or same
+	OpVCMTST // Compare bitwise test (nonzero AND)
+	OpVSMAX  // Signed maximum
+	OpVUMAX  // Unsigned maximum
+	OpVSMIN  // Signed minimum
+	OpVUMIN  // Unsigned minimum
+	OpVSABD  // Signed absolute difference
+	OpVUABD  // Unsigned absolute difference
+	OpVSABA  // Signed absolute difference and accumulate
+	OpVUABA  // Unsigned absolute difference and accumulate
+	OpVMLA   // Multiply-add to accumulator
+	OpVMLS   // Multiply-subtract from accumulator
+	OpVADDP  // Add pairwise
+	OpVSMAXP // Signed maximum pairwise
+	OpVUMAXP // Unsigned maximum pairwise
+	OpVSMINP // Signed minimum pairwise
+	OpVUMINP // Unsigned minimum pairwise
+	OpVAND   // Bitwise AND
+	OpVBIC   // Bitwise bit clear (AND NOT)
+	OpVORR   // Bitwise OR (MOV when Rn == Rm)
+	OpVORN   // Bitwise OR NOT
+	OpVEOR   // Bitwise exclusive OR
+	OpVBSL   // Bitwise select (Rd selects between Rn and Rm)
+	OpVBIT   // Bitwise insert if true
+	OpVBIF   // Bitwise insert if false
+	OpVSSHL  // Signed shift left (right for negative shifts) by register
+	OpVUSHL  // Unsigned shift left (right for negative shifts) by register
+
+	// SIMD saturating doubling multiply returning high half (three-same, by
+	// element and scalar)
+	OpVSQDMULH  // Signed saturating doubling multiply returning high half
+	OpVSQRDMULH // Signed saturating rounding doubling multiply returning high half
+
+	// SIMD two-register miscellaneous
+	OpVCMEQZ  // Compare equal to zero
+	OpVCMGTZ  // Compare signed greater than zero
+	OpVCMGEZ  // Compare signed greater than or equal to zero
+	OpVCMLEZ  // Compare signed less than or equal to zero
+	OpVCMLTZ  // Compare signed less than zero
+	OpVABS    // Absolute value
+	OpVNEG    // Negate
+	OpVNOT    // Bitwise NOT (MVN)
+	OpVCNT    // Population count per byte
+	OpVREV64  // Reverse elements in doublewords
+	OpVREV32  // Reverse elements in words
+	OpVREV16  // Reverse bytes in halfwords
+	OpVXTN    // Extract narrow
+	OpVSQXTN  // Signed saturating extract narrow
+	OpVUQXTN  // Unsigned saturating extract narrow
+	OpVSQXTUN // Signed saturating extract unsigned narrow
+	OpVSADDLP // Signed add long pairwise
+	OpVUADDLP // Unsigned add long pairwise
+	OpVSADALP // Signed add and accumulate long pairwise
+	OpVUADALP // Unsigned add and accumulate long pairwise
+
+	// SIMD three-different (long and wide)
+	OpVSADDL // Signed add long
+	OpVUADDL // Unsigned add long
+	OpVSADDW // Signed add wide
+	OpVUADDW // Unsigned add wide
+	OpVSSUBL // Signed subtract long
+	OpVUSUBL // Unsigned subtract long
+	OpVSSUBW // Signed subtract wide
+	OpVUSUBW // Unsigned subtract wide
+	OpVSABAL // Signed absolute difference and accumulate long
+	OpVUABAL // Unsigned absolute difference and accumulate long
+	OpVSABDL // Signed absolute difference long
+	OpVUABDL // Unsigned absolute difference long
+	OpVSMLAL // Signed multiply-add long
+	OpVUMLAL // Unsigned multiply-add long
+	OpVSMLSL // Signed multiply-subtract long
+	OpVUMLSL // Unsigned multiply-subtract long
+	OpVSMULL // Signed multiply long
+	OpVUMULL // Unsigned multiply long
+	OpVPMULL // Polynomial (carry-less) multiply long
+
+	// SIMD shift by immediate (shift amount in Imm)
+	OpVSHL   // Shift left
+	OpVSSHR  // Signed shift right
+	OpVUSHR  // Unsigned shift right
+	OpVSSRA  // Signed shift right and accumulate
+	OpVUSRA  // Unsigned shift right and accumulate
+	OpVSHRN  // Shift right narrow
+	OpVRSHRN // Rounding shift right narrow
+	OpVSSHLL // Signed shift left long (SXTL when the shift is 0)
+	OpVUSHLL // Unsigned shift left long (UXTL when the shift is 0)
+
+	// SIMD modified immediate (expanded 64-bit pattern in Imm)
+	OpVMOVI   // Move immediate
+	OpVMVNI   // Move inverted immediate
+	OpVORRImm // Bitwise OR immediate
+	OpVBICImm // Bitwise bit clear immediate
+
+	// SIMD copy (element index in Lane)
+	OpDUPElem // Duplicate vector element to vector
+	OpINS     // Insert general register into element (MOV)
+	OpINSElem // Insert vector element into element (MOV)
+	OpUMOV    // Move element to general register, zero-extended
+	OpSMOV    // Move element to general register, sign-extended
+
+	// SIMD across lanes
+	OpVADDV   // Add across vector
+	OpVSADDLV // Signed add long across vector
+	OpVUADDLV // Unsigned add long across vector
+	OpVSMAXV  // Signed maximum across vector
+	OpVUMAXV  // Unsigned maximum across vector
+	OpVSMINV  // Signed minimum across vector
+	OpVUMINV  // Unsigned minimum across vector
+
+	// SIMD permute
+	OpVUZP1 // Unzip even elements
+	OpVUZP2 // Unzip odd elements
+	OpVZIP1 // Interleave lower halves
+	OpVZIP2 // Interleave upper halves
+	OpVTRN1 // Transpose even elements
+	OpVTRN2 // Transpose odd elements
+	OpVEXT  // Extract from a pair of vectors
+	OpVTBL  // Table lookup, zero for out-of-range indices
+	OpVTBX  // Table lookup, keep the destination for out-of-range indices
+
+	// SIMD floating-point three-same. FMLA, FMLS and FMUL also have a
+	// by-element form (FormatSIMDElem), and the pairwise operations a
+	// scalar form (FormatSIMDScalarPairwise). FABD, the compares and the
+	// reciprocal steps also have Advanced SIMD scalar forms (IsScalar).
+	OpVFDIV    // Divide
+	OpVFMLA    // Fused multiply-add to accumulator
+	OpVFMLS    // Fused multiply-subtract from accumulator
+	OpVFMAX    // Maximum
+	OpVFMIN    // Minimum
+	OpVFMAXNM  // Maximum number (a quiet NaN loses to a number)
+	OpVFMINNM  // Minimum number
+	OpVFABD    // Absolute difference
+	OpVFADDP   // Add pairwise
+	OpVFMAXP   // Maximum pairwise
+	OpVFMINP   // Minimum pairwise
+	OpVFMAXNMP // Maximum number pairwise
+	OpVFMINNMP // Minimum number pairwise
+	OpVFCMEQ   // Compare equal
+	OpVFCMGE   // Compare greater than or equal
+	OpVFCMGT   // Compare greater than
+	OpVFACGE   // Absolute compare greater than or equal
+	OpVFACGT   // Absolute compare greater than
+	OpVFRECPS  // Reciprocal step: 2 - n*m
+	OpVFRSQRTS // Reciprocal square root step: (3 - n*m) / 2
+
+	// SIMD floating-point two-register miscellaneous. The compares against
+	// zero and the conversions also have Advanced SIMD scalar forms.
+	OpVFABS   // Absolute value
+	OpVFNEG   // Negate
+	OpVFSQRT  // Square root
+	OpVFRINTN // Round to integral, to nearest with ties to even
+	OpVFRINTA // Round to integral, to nearest with ties away
+	OpVFRINTP // Round to integral, toward +infinity
+	OpVFRINTM // Round to integral, toward -infinity
+	OpVFRINTZ // Round to integral, toward zero
+	OpVFRINTX // Round to integral exact, using FPCR rounding
+	OpVFRINTI // Round to integral, using FPCR rounding
+	OpVFCMEQZ // Compare equal to zero
+	OpVFCMGEZ // Compare greater than or equal to zero
+	OpVFCMGTZ // Compare greater than zero
+	OpVFCMLEZ // Compare less than or equal to zero
+	OpVFCMLTZ // Compare less than zero
+	OpVSCVTF  // Signed integer to floating-point
+	OpVUCVTF  // Unsigned integer to floating-point
+	OpVFCVTNS // To signed integer, round to nearest even
+	OpVFCVTNU // To unsigned integer, round to nearest even
+	OpVFCVTPS // To signed integer, round toward +infinity
+	OpVFCVTPU // To unsigned integer, round toward +infinity
+	OpVFCVTMS // To signed integer, round toward -infinity
+	OpVFCVTMU // To unsigned integer, round toward -infinity
+	OpVFCVTZS // To signed integer, round toward zero
+	OpVFCVTZU // To unsigned integer, round toward zero
+	OpVFCVTAS // To signed integer, round to nearest with ties away
+	OpVFCVTAU // To unsigned integer, round to nearest with ties away
+	OpVFCVTL  // Convert to twice the precision (long)
+	OpVFCVTN  // Convert to half the precision (narrow)
+
+	// SIMD floating-point estimates (two-register miscellaneous, also
+	// scalar), accurate to 8 bits and refined with FRECPS and FRSQRTS
+	OpVFRECPE  // Reciprocal estimate
+	OpVFRSQRTE // Reciprocal square root estimate
+
+	// SIMD floating-point across lanes
+	OpVFMAXV   // Maximum across vector
+	OpVFMINV   // Minimum across vector
+	OpVFMAXNMV // Maximum number across vector
+	OpVFMINNMV // Minimum number across vector
+
+	// SIMD floating-point modified immediate (expanded 64-bit pattern in Imm)
+	OpVFMOVImm // FMOV (vector, immediate)
+
+	// Cryptographic extension
+	OpAESE      // AES single round encryption
+	OpAESD      // AES single round decryption
+	OpAESMC     // AES mix columns
+	OpAESIMC    // AES inverse mix columns
+	OpSHA1C     // SHA1 hash update (choose)
+	OpSHA1P     // SHA1 hash update (parity)
+	OpSHA1M     // SHA1 hash update (majority)
+	OpSHA1H     // SHA1 fixed rotate
+	OpSHA1SU0   // SHA1 schedule update 0
+	OpSHA1SU1   // SHA1 schedule update 1
+	OpSHA256H   // SHA256 hash update (part 1)
+	OpSHA256H2  // SHA256 hash update (part 2)
+	OpSHA256SU0 // SHA256 schedule update 0
+	OpSHA256SU1 // SHA256 schedule update 1
+	OpSHA512H   // SHA512 hash update (part 1)
+	OpSHA512H2  // SHA512 hash update (part 2)
+	OpSHA512SU0 // SHA512 schedule update 0
+	OpSHA512SU1 // SHA512 schedule update 1
+	OpEOR3      // Three-way exclusive OR
+	OpBCAX      // Bit clear and exclusive OR
+	OpRAX1      // Rotate and exclusive OR
+	OpXAR       // Exclusive OR and rotate
+	// Cache maintenance
+	OpDC // Data cache operation by virtual address (ZVA, CVAU, CIVAC, ...)
+	OpIC // Instruction cache invalidate by virtual address (IVAU)
+	// Pointer authentication and branch target identification
+	OpPAC   // Add pointer authentication code (PACIA, PACDZB, PACIASP, ...)
+	OpAUT   // Authenticate pointer (AUTIA, AUTDZB, AUTIASP, ...)
+	OpXPAC  // Strip pointer authentication code (XPACI, XPACD, XPACLRI)
+	OpPACGA // Generic authentication code
+	OpBTI   // Branch target identification landing pad
 )
 
 // Format represents an instruction encoding format.
@@ -97,31 +442,79 @@ type Format uint8
 
 // Instruction formats.
 const (
-	FormatUnknown       Format = iota
-	FormatDPImm                // Data Processing (Immediate)
-	FormatDPReg                // Data Processing (Register)
-	FormatBranch               // Unconditional Branch (Immediate)
-	FormatBranchCond           // Conditional Branch
-	FormatBranchReg            // Branch to Register
-	FormatLoadStore            // Load/Store (Immediate)
-	FormatLoadStoreLit         // Load/Store (PC-relative Literal)
-	FormatLoadStorePair        // Load/Store Pair (LDP/STP)
-	FormatPCRel                // PC-relative addressing (ADR, ADRP)
-	FormatMoveWide             // Move wide (MOVZ, MOVN, MOVK)
-	FormatException            // Exception Generation (SVC, HVC, SMC, BRK)
-	FormatSIMDReg              // SIMD Data Processing (Register)
-	FormatSIMDLoadStore        // SIMD Load/Store
-	FormatSIMDCopy             // SIMD Copy (DUP, MOV, etc.)
-	FormatCondSelect           // Conditional Select (CSEL, CSINC, etc.)
-	FormatDataProc2Src         // Data Processing (2 source) - UDIV, SDIV
-	FormatDataProc3Src         // Data Processing (3 source) - MADD, MSUB
-	FormatTestBranch           // Test and Branch (TBZ, TBNZ)
-	FormatCompareBranch        // Compare and Branch (CBZ, CBNZ)
-	FormatLogicalImm           // Logical Immediate (AND, ORR, EOR, ANDS)
-	FormatBitfield             // Bitfield (SBFM, BFM, UBFM / ASR, LSL, LSR imm)
-	FormatCondCmp              // Conditional compare (CCMP, CCMN)
-	FormatExtract              // Extract register (EXTR)
-	FormatSystemReg            // System register operations (MRS, MSR)
+	FormatUnknown             Format = iota
+	FormatDPImm                      // Data Processing (Immediate)
+	FormatDPReg                      // Data Processing (Register)
+	FormatBranch                     // Unconditional Branch (Immediate)
+	FormatBranchCond                 // Conditional Branch
+	FormatBranchReg                  // Branch to Register
+	FormatLoadStore                  // Load/Store (Immediate)
+	FormatLoadStoreLit               // Load/Store (PC-relative Literal)
+	FormatLoadStorePair              // Load/Store Pair (LDP/STP)
+	FormatPCRel                      // PC-relative addressing (ADR, ADRP)
+	FormatMoveWide                   // Move wide (MOVZ, MOVN, MOVK)
+	FormatException                  // Exception Generation (SVC, HVC, SMC, BRK)
+	FormatSIMDReg                    // SIMD Data Processing (Register)
+	FormatSIMDLoadStore              // SIMD&FP register Load/Store (B/H/S/D/Q by AccessSize)
+	FormatSIMDCopy                   // SIMD Copy (DUP, MOV, etc.)
+	FormatCondSelect                 // Conditional Select (CSEL, CSINC, etc.)
+	FormatDataProc2Src               // Data Processing (2 source) - UDIV, SDIV
+	FormatDataProc3Src               // Data Processing (3 source) - MADD, MSUB
+	FormatTestBranch                 // Test and Branch (TBZ, TBNZ)
+	FormatCompareBranch              // Compare and Branch (CBZ, CBNZ)
+	FormatLogicalImm                 // Logical Immediate (AND, ORR, EOR, ANDS)
+	FormatBitfield                   // Bitfield (SBFM, BFM, UBFM / ASR, LSL, LSR imm)
+	FormatCondCmp                    // Conditional compare (CCMP, CCMN)
+	FormatExtract                    // Extract register (EXTR)
+	FormatSystemReg                  // System register operations (MRS, MSR) and cache maintenance (DC, IC)
+	FormatFPDataProc                 // Scalar floating-point data processing
+	FormatFPConvert                  // Floating-point <-> integer/fixed-point conversion
+	FormatLoadStoreExclusive         // Load/Store exclusive and compare-and-swap
+	FormatAtomic                     // LSE atomic memory operations (LDADD, SWP, etc.)
+	FormatBarrier                    // Barriers, hints and CLREX
+	FormatDataProc1Src               // Data Processing (1 source) - RBIT, REV, CLZ, CLS
+	FormatAddSubCarry                // Add/subtract with carry (ADC, SBC)
+	FormatAddSubExt                  // Add/subtract (extended register)
+	FormatSIMDLoadStoreStruct        // SIMD structure Load/Store (LD1-LD4, ST1-ST4)
+	FormatSIMDTwoReg                 // SIMD two-register miscellaneous (ABS, CNT, XTN, etc.)
+	FormatSIMDThreeDiff              // SIMD three-different (SADDL, UMULL, etc.)
+	FormatSIMDShiftImm               // SIMD shift by immediate (SHL, USHR, SHRN, etc.)
+	FormatSIMDModImm                 // SIMD modified immediate (MOVI, MVNI, ORR, BIC)
+	FormatSIMDAcross                 // SIMD across lanes (ADDV, UMAXV, etc.)
+	FormatSIMDPermute                // SIMD permute (ZIP1, UZP2, TRN1, etc.)
+	FormatSIMDElem                   // SIMD vector by element (FMLA, FMUL, etc.)
+	FormatSIMDScalarPairwise         // SIMD scalar pairwise (FADDP, FMAXP, etc.)
+	FormatSIMDExtract                // SIMD extract (EXT)
+	FormatSIMDTableLookup            // SIMD table lookup (TBL, TBX)
+	FormatCryptoTwoReg               // Cryptographic two-register (AESE, SHA1H, SHA512SU0, etc.)
+	FormatCryptoThreeReg             // Cryptographic three-register (SHA1C, SHA256H, RAX1, etc.)
+	FormatCryptoFourReg              // Cryptographic four-register (EOR3, BCAX) and XAR
+)
+
+// PACKey identifies a pointer authentication key. XPAC records PACKeyIA
+// for instruction pointers and PACKeyDA for data pointers.
+type PACKey uint8
+
+// Pointer authentication keys.
+const (
+	PACKeyNone PACKey = iota
+	PACKeyIA          // Instruction key A
+	PACKeyIB          // Instruction key B
+	PACKeyDA          // Data key A
+	PACKeyDB          // Data key B
+	PACKeyGA          // Generic key (PACGA)
+)
+
+// IsData reports whether k signs data pointers, which keep their top byte
+// for tagging.
+func (k PACKey) IsData() bool {
+	return k == PACKeyDA || k == PACKeyDB
+}
+
+// BTI landing pad targets, stored in Imm as bits.
+const (
+	BTITargetCall uint64 = 1 << iota // BTI c: calls (BLR) and BR X16/X17
+	BTITargetJump                    // BTI j: jumps (BR)
 )
 
 // Cond represents an ARM64 condition code.
@@ -170,6 +563,22 @@ const (
 	Arr2S                         // 2 singles (64-bit)
 	Arr4S                         // 4 singles (128-bit)
 	Arr2D                         // 2 doubles (128-bit)
+	Arr1D                         // 1 double (64-bit)
+	Arr1Q                         // 1 quadword (128-bit, PMULL destination)
+	Arr1B                         // 1 byte (Advanced SIMD scalar)
+	Arr1H                         // 1 halfword (Advanced SIMD scalar)
+	Arr1S                         // 1 single (Advanced SIMD scalar)
+)
+
+// FPType represents the precision of a scalar floating-point operand.
+// Values match the ftype field of the instruction encoding.
+type FPType uint8
+
+// Scalar floating-point precisions.
+const (
+	FPSingle FPType = 0b00 // 32-bit (S register)
+	FPDouble FPType = 0b01 // 64-bit (D register)
+	FPHalf   FPType = 0b11 // 16-bit (H register)
 )
 
 // IndexMode represents the addressing mode for indexed load/store.
@@ -180,13 +589,14 @@ const (
 	IndexRegBase                  // Register offset: [Xn, Xm{, extend}]
 	IndexPost                     // Post-index: [Rn], #imm
 	IndexPre                      // Pre-index: [Rn, #imm]!
-	IndexSigned                   // Signed offset (for load/store pair)
+	IndexSigned                   // Signed offset: [Rn, #simm] (pairs, LDUR/STUR, LDTR/STTR)
 )
 
 // Instruction represents a decoded ARM64 instruction.
 type Instruction struct {
 	Op     Op     // Operation code
 	Format Format // Encoding format
+	Word   uint32 // Raw instruction word
 
 	// Common fields
 	Is64Bit  bool  // true for 64-bit (X registers), false for 32-bit (W registers)
@@ -209,17 +619,41 @@ type Instruction struct {
 	ShiftAmount uint8     // Shift amount for Rm
 
 	// Load/Store indexed fields
-	IndexMode IndexMode // Addressing mode (none, pre, post)
-	SignedImm int64     // Signed immediate for indexed addressing
-	Rt2       uint8     // Second register for load/store pair
+	IndexMode    IndexMode // Addressing mode (none, pre, post)
+	SignedImm    int64     // Signed immediate for indexed addressing
+	Rt2          uint8     // Second register for load/store pair
+	Unprivileged bool      // LDTR/STTR: access memory as if at EL0
+	NonTemporal  bool      // LDNP/STNP: hint that the data is not reused
 
 	// SIMD fields
 	IsSIMD      bool            // true if this is a SIMD instruction
 	Arrangement SIMDArrangement // Vector arrangement (8B, 16B, 4H, etc.)
 	IsFloat     bool            // true for floating-point SIMD ops
+	IsScalar    bool            // Advanced SIMD scalar form on element 0 (1B, 1H, 1S or 1D)
+
+	// SIMD structure load/store fields. Post-index writeback adds Rm, or
+	// SignedImm when Rm is 31.
+	StructElems uint8 // Elements per structure (the n of LDn/STn)
+	RegCount    uint8 // Registers in the list, starting at Rd
+	Lane        uint8 // Lane index of single-structure and element forms
+
+	// Scalar floating-point fields
+	FPType    FPType // Operand precision
+	FPDstType FPType // Result precision for FCVT
 
 	// System register fields
-	SysReg uint16 // System register encoding for MRS/MSR
+	SysReg uint16 // System register encoding for MRS/MSR, operation for DC/IC
+
+	// Memory access fields (ordering applies to exclusive and atomic ops)
+	AccessSize uint8 // Bytes accessed per register (1, 2, 4, 8 or 16)
+	Acquire    bool  // Load-acquire ordering (A variants)
+	Release    bool  // Store-release ordering (L variants)
+
+	// Pointer authentication fields. PAC and AUT sign or authenticate Rd
+	// with the modifier in Rn; PACGA and the authenticated branches take
+	// the modifier from Rm. A modifier register of 31 is SP.
+	PACKey       PACKey // Key used, or PACKeyNone for plain BR, BLR and RET
+	ZeroModifier bool   // The modifier is zero (PACIZA, AUTIAZ, BRAAZ, ...)
 }
 
 // Decoder decodes ARM64 machine code into instructions.
@@ -232,7 +666,7 @@ func NewDecoder() *Decoder {
 
 // Decode decodes a 32-bit ARM64 instruction word.
 func (d *Decoder) Decode(word uint32) *Instruction {
-	inst := &Instruction{Op: OpUnknown, Format: FormatUnknown}
+	inst := &Instruction{Op: OpUnknown, Format: FormatUnknown, Word: word}
 	d.decodeInto(word, inst)
 	return inst
 }
@@ -241,7 +675,7 @@ func (d *Decoder) Decode(word uint32) *Instruction {
 // Instruction, avoiding heap allocation. The caller is responsible for
 // ensuring inst is zeroed or freshly initialized before calling.
 func (d *Decoder) DecodeInto(word uint32, inst *Instruction) {
-	*inst = Instruction{Op: OpUnknown, Format: FormatUnknown}
+	*inst = Instruction{Op: OpUnknown, Format: FormatUnknown, Word: word}
 	d.decodeInto(word, inst)
 }
 
@@ -252,12 +686,64 @@ func (d *Decoder) decodeInto(word uint32, inst *Instruction) {
 	op0 := (word >> 25) & 0xF // bits [28:25]
 
 	switch {
-	case d.isSIMDLoadStore(word):
-		d.decodeSIMDLoadStore(word, inst)
+	case d.isCryptoAES(word):
+		d.decodeCryptoAES(word, inst)
+	case d.isCryptoThreeRegSHA(word):
+		d.decodeCryptoThreeRegSHA(word, inst)
+	case d.isCryptoTwoRegSHA(word):
+		d.decodeCryptoTwoRegSHA(word, inst)
+	case d.isCryptoThreeRegSHA512(word):
+		d.decodeCryptoThreeRegSHA512(word, inst)
+	case d.isCryptoTwoRegSHA512(word):
+		d.decodeCryptoTwoRegSHA512(word, inst)
+	case d.isCryptoFourReg(word):
+		d.decodeCryptoFourReg(word, inst)
+	case d.isCryptoXAR(word):
+		d.decodeCryptoXAR(word, inst)
 	case d.isSIMDThreeSame(word):
 		d.decodeSIMDThreeSame(word, inst)
+	case d.isSIMDThreeSameFP16(word):
+		d.decodeSIMDThreeSameFP16(word, inst)
 	case d.isSIMDCopy(word):
 		d.decodeSIMDCopy(word, inst)
+	case d.isSIMDTwoReg(word):
+		d.decodeSIMDTwoReg(word, inst)
+	case d.isSIMDTwoRegFP16(word):
+		d.decodeSIMDTwoRegFP16(word, inst)
+	case d.isSIMDAcross(word):
+		d.decodeSIMDAcross(word, inst)
+	case d.isSIMDThreeDiff(word):
+		d.decodeSIMDThreeDiff(word, inst)
+	case d.isSIMDModImm(word):
+		d.decodeSIMDModImm(word, inst)
+	case d.isSIMDShiftImm(word):
+		d.decodeSIMDShiftImm(word, inst)
+	case d.isSIMDPermute(word):
+		d.decodeSIMDPermute(word, inst)
+	case d.isSIMDExtract(word):
+		d.decodeSIMDExtract(word, inst)
+	case d.isSIMDTableLookup(word):
+		d.decodeSIMDTableLookup(word, inst)
+	case d.isSIMDElem(word):
+		d.decodeSIMDElem(word, inst)
+	case d.isSIMDScalarPairwise(word):
+		d.decodeSIMDScalarPairwise(word, inst)
+	case d.isSIMDScalarThreeSame(word):
+		d.decodeSIMDScalarThreeSame(word, inst)
+	case d.isSIMDScalarTwoReg(word):
+		d.decodeSIMDScalarTwoReg(word, inst)
+	case d.isFPConvert(word):
+		d.decodeFPConvert(word, inst)
+	case d.isFPDataProc(word):
+		d.decodeFPDataProc(word, inst)
+	case d.isFPDataProc3Src(word):
+		d.decodeFPDataProc3Src(word, inst)
+	case d.isLoadStoreExclusive(word):
+		d.decodeLoadStoreExclusive(word, inst)
+	case d.isAtomicMemOp(word):
+		d.decodeAtomicMemOp(word, inst)
+	case d.isSIMDLoadStoreStruct(word):
+		d.decodeSIMDLoadStoreStruct(word, inst)
 	case d.isLoadStorePair(word):
 		d.decodeLoadStorePair(word, inst)
 	case d.isLoadStoreLiteral(word):
@@ -276,8 +762,12 @@ func (d *Decoder) decodeInto(word uint32, inst *Instruction) {
 		d.decodeCondCmp(word, inst)
 	case d.isConditionalSelect(word):
 		d.decodeConditionalSelect(word, inst)
+	case d.isDataProc1Src(word):
+		d.decodeDataProc1Src(word, inst)
 	case d.isDataProc2Src(word):
 		d.decodeDataProc2Src(word, inst)
+	case d.isAddSubCarry(word):
+		d.decodeAddSubCarry(word, inst)
 	case d.isDataProc3Src(word):
 		d.decodeDataProc3Src(word, inst)
 	case d.isLogicalImm(word):
@@ -288,6 +778,8 @@ func (d *Decoder) decodeInto(word uint32, inst *Instruction) {
 		d.decodeBitfield(word, inst)
 	case d.isDataProcessingImm(word):
 		d.decodeDataProcessingImm(word, inst)
+	case d.isAddSubExt(word):
+		d.decodeAddSubExt(word, inst)
 	case d.isDataProcessingReg(word):
 		d.decodeDataProcessingReg(word, inst)
 	case d.isTestBranch(word):
@@ -300,10 +792,22 @@ func (d *Decoder) decodeInto(word uint32, inst *Instruction) {
 		d.decodeBranchCond(word, inst)
 	case d.isBranchReg(word):
 		d.decodeBranchReg(word, inst)
+	case d.isBranchRegAuth(word):
+		d.decodeBranchRegAuth(word, inst)
+	case d.isPointerAuthDP1(word):
+		d.decodePointerAuthDP1(word, inst)
 	case d.isNOP(word):
 		d.decodeNOP(word, inst)
 	case d.isException(word):
 		d.decodeException(word, inst)
+	case d.isPointerAuthHint(word):
+		d.decodePointerAuthHint(word, inst)
+	case d.isHint(word):
+		d.decodeHint(word, inst)
+	case d.isBarrier(word):
+		d.decodeBarrier(word, inst)
+	case d.isCacheMaint(word):
+		d.decodeCacheMaint(word, inst)
 	case d.isSystemReg(word):
 		d.decodeSystemReg(word, inst)
 	default:
@@ -357,6 +861,48 @@ func (d *Decoder) isDataProcessingReg(word uint32) bool {
 	return op == 0b01011 || op == 0b01010
 }
 
+// isAddSubExt checks for add/subtract (extended register) instructions.
+// Format: sf | op | S | 01011 | 00 | 1 | Rm | option | imm3 | Rn | Rd
+func (d *Decoder) isAddSubExt(word uint32) bool {
+	op := (word >> 21) & 0xFF // bits [28:21]
+	return op == 0b01011001
+}
+
+// decodeAddSubExt decodes ADD/ADDS/SUB/SUBS (extended register).
+// The extend type is stored in ShiftType and the left shift (0-4) in
+// ShiftAmount, as for register-offset loads.
+// option[15:13]: 000=UXTB, 001=UXTH, 010=UXTW, 011=UXTX/LSL,
+// 100=SXTB, 101=SXTH, 110=SXTW, 111=SXTX
+func (d *Decoder) decodeAddSubExt(word uint32, inst *Instruction) {
+	inst.Format = FormatAddSubExt
+
+	sf := (word >> 31) & 0x1     // bit 31
+	op := (word >> 30) & 0x1     // bit 30: 0=ADD, 1=SUB
+	s := (word >> 29) & 0x1      // bit 29: set flags
+	rm := (word >> 16) & 0x1F    // bits [20:16]
+	option := (word >> 13) & 0x7 // bits [15:13]
+	imm3 := (word >> 10) & 0x7   // bits [12:10]
+	rn := (word >> 5) & 0x1F     // bits [9:5]
+	rd := word & 0x1F            // bits [4:0]
+
+	inst.Is64Bit = sf == 1
+	inst.SetFlags = s == 1
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+	inst.Rm = uint8(rm)
+	inst.ShiftType = ShiftType(option)
+	inst.ShiftAmount = uint8(imm3)
+
+	switch {
+	case imm3 > 4:
+		inst.Op = OpUnknown
+	case op == 0:
+		inst.Op = OpADD
+	default:
+		inst.Op = OpSUB
+	}
+}
+
 // decodeDataProcessingReg decodes Add/Sub/Logical register instructions.
 // Add/Sub format: sf | op | S | 01011 | shift | 0 | Rm | imm6 | Rn | Rd
 // Logical format: sf | opc | 01010 | shift | N | Rm | imm6 | Rn | Rd
@@ -531,30 +1077,23 @@ func (d *Decoder) decodeBranchReg(word uint32, inst *Instruction) {
 }
 
 // isLoadStoreImm checks for Load/Store with unsigned immediate offset.
-// LDR/STR (unsigned immediate): bits [31:30] = size, bits [29:27] = 111, bit 26 = 0,
+// LDR/STR (unsigned immediate): bits [31:30] = size, bits [29:27] = 111, bit 26 = V,
 // bits [25:24] = 01, bit 23:22 = opc
-// 64-bit: size=11 (0xF9), 32-bit: size=10 (0xB9)
+// 64-bit: size=11 (0xF9), 32-bit: size=10 (0xB9), Q register: 0x3D with opc[1]=1
 func (d *Decoder) isLoadStoreImm(word uint32) bool {
-	// Check pattern: xx 111 0 01 xx
-	// bits [29:27] == 111, bit 26 == 0, bits [25:24] == 01
+	// Check pattern: xx 111 V 01 xx
+	// bits [29:27] == 111, bits [25:24] == 01
 	op1 := (word >> 27) & 0x7 // bits [29:27]
-	op2 := (word >> 26) & 0x1 // bit 26
 	op3 := (word >> 24) & 0x3 // bits [25:24]
 
-	return op1 == 0b111 && op2 == 0 && op3 == 0b01
+	return op1 == 0b111 && op3 == 0b01
 }
 
-// decodeLoadStoreImm decodes LDR, STR, and LDRSW with unsigned immediate offset.
+// decodeLoadStoreImm decodes LDR, STR, LDRSW, PRFM and the SIMD&FP LDR/STR
+// with unsigned immediate offset.
 // Format: size | 111 | V | 01 | opc | imm12 | Rn | Rt
-// size: 11=64-bit, 10=32-bit, 01=16-bit, 00=8-bit
-// V: 0 for integer registers
-// For size=10: opc: 00=STR, 01=LDR, 10=LDRSW
-// For size=11: opc: 00=STR, 01=LDR
+// imm12 is scaled by the access size.
 func (d *Decoder) decodeLoadStoreImm(word uint32, inst *Instruction) {
-	inst.Format = FormatLoadStore
-
-	size := (word >> 30) & 0x3    // bits [31:30]
-	opc := (word >> 22) & 0x3     // bits [23:22]
 	imm12 := (word >> 10) & 0xFFF // bits [21:10]
 	rn := (word >> 5) & 0x1F      // bits [9:5]
 	rt := word & 0x1F             // bits [4:0]
@@ -562,47 +1101,93 @@ func (d *Decoder) decodeLoadStoreImm(word uint32, inst *Instruction) {
 	inst.Rn = uint8(rn)
 	inst.Rd = uint8(rt) // Rt uses Rd field
 
-	// Scale immediate by size
-	// 64-bit (size=11): scale by 8
-	// 32-bit (size=10): scale by 4
-	var scale uint64
-	if size == 0b11 {
-		scale = 8
-	} else {
-		scale = 4
+	if !d.decodeLoadStoreOp(word, inst) {
+		inst.Op = OpUnknown
+		return
+	}
+	inst.Imm = uint64(imm12) * uint64(inst.AccessSize)
+}
+
+// decodeLoadStoreOp sets the operation, access size and register width of a
+// single-register load or store from the size, V and opc fields shared by
+// every addressing form. It returns false for unallocated combinations.
+// V=0, size=11: opc 00=STR, 01=LDR, 10=PRFM (Rt holds the prfop)
+// V=0, size=10: opc 00=STR, 01=LDR, 10=LDRSW
+// V=0, size=01/00: opc 00=STRH/B, 01=LDRH/B, 10=LDRSH/B (64-bit), 11=LDRSH/B (32-bit)
+// V=1: opc[0] selects LDR/STR of B/H/S/D (size=00..11) or Q (opc[1]=1, size=00)
+func (d *Decoder) decodeLoadStoreOp(word uint32, inst *Instruction) bool {
+	size := (word >> 30) & 0x3 // bits [31:30]
+	v := (word >> 26) & 0x1    // bit 26: 0=GPR, 1=SIMD&FP
+	opc := (word >> 22) & 0x3  // bits [23:22]
+
+	inst.AccessSize = 1 << size
+
+	if v == 1 {
+		inst.Format = FormatSIMDLoadStore
+		inst.IsSIMD = true
+		if opc&0x2 != 0 {
+			if size != 0b00 {
+				return false
+			}
+			inst.AccessSize = 16
+		}
+		if opc&0x1 == 1 {
+			inst.Op = OpLDRQ
+		} else {
+			inst.Op = OpSTRQ
+		}
+		return true
 	}
-	inst.Imm = uint64(imm12) * scale
 
-	// Determine operation based on size and opc
+	inst.Format = FormatLoadStore
+
 	switch size {
 	case 0b11: // 64-bit
 		inst.Is64Bit = true
-		if opc&0x1 == 1 {
-			inst.Op = OpLDR
-		} else {
+		switch opc {
+		case 0b00:
 			inst.Op = OpSTR
+		case 0b01:
+			inst.Op = OpLDR
+		case 0b10:
+			inst.Op = OpPRFM
+		default:
+			return false
 		}
 	case 0b10: // 32-bit
 		switch opc {
 		case 0b00:
 			inst.Op = OpSTR
-			inst.Is64Bit = false
 		case 0b01:
 			inst.Op = OpLDR
-			inst.Is64Bit = false
 		case 0b10:
 			inst.Op = OpLDRSW
 			inst.Is64Bit = true // LDRSW sign-extends to 64-bit
+		default:
+			return false
 		}
-	default:
-		// For size=01 and size=00, use default LDR/STR behavior
-		inst.Is64Bit = size == 0b11
-		if opc&0x1 == 1 {
-			inst.Op = OpLDR
-		} else {
-			inst.Op = OpSTR
+	case 0b01: // 16-bit (halfword)
+		switch opc {
+		case 0b00:
+			inst.Op = OpSTRH
+		case 0b01:
+			inst.Op = OpLDRH
+		default:
+			inst.Op = OpLDRSH
+			inst.Is64Bit = opc == 0b10 // 10=extend to 64-bit
+		}
+	case 0b00: // 8-bit (byte)
+		switch opc {
+		case 0b00:
+			inst.Op = OpSTRB
+		case 0b01:
+			inst.Op = OpLDRB
+		default:
+			inst.Op = OpLDRSB
+			inst.Is64Bit = opc == 0b10 // 10=extend to 64-bit
 		}
 	}
+	return true
 }
 
 // isNOP checks for NOP instruction (HINT #0).
@@ -653,75 +1238,15 @@ func (d *Decoder) decodeException(word uint32, inst *Instruction) {
 	}
 }
 
-// isSIMDLoadStore checks for SIMD/FP Load/Store with unsigned immediate offset.
-// LDR/STR (SIMD&FP, unsigned immediate): bits [31:30] = size, bits [29:26] = 0b1111
-// Q register (128-bit): size=00, opc=11 for LDR, opc=10 for STR
-func (d *Decoder) isSIMDLoadStore(word uint32) bool {
-	// Check pattern: xx 1111 xx (bits 29:26 = 0b1111)
-	// This indicates SIMD&FP load/store with unsigned immediate
-	op := (word >> 26) & 0xF // bits [29:26]
-	return op == 0b1111
-}
-
-// decodeSIMDLoadStore decodes SIMD LDR and STR with unsigned immediate offset.
-// Format: size | 111 | 1 | 01 | opc | imm12 | Rn | Rt
-// For Q register (128-bit): size=00, opc[1]=1
-func (d *Decoder) decodeSIMDLoadStore(word uint32, inst *Instruction) {
-	inst.Format = FormatSIMDLoadStore
-	inst.IsSIMD = true
-
-	size := (word >> 30) & 0x3    // bits [31:30]
-	opc := (word >> 22) & 0x3     // bits [23:22]
-	imm12 := (word >> 10) & 0xFFF // bits [21:10]
-	rn := (word >> 5) & 0x1F      // bits [9:5]
-	rt := word & 0x1F             // bits [4:0]
-
-	inst.Rn = uint8(rn)
-	inst.Rd = uint8(rt) // Rt uses Rd field (this is the SIMD register index)
-
-	// Determine size and scale
-	// For Q register (128-bit): size=00, opc[1]=1 -> scale=16
-	// For D register (64-bit): size=01, opc[1]=1 -> scale=8
-	// For S register (32-bit): size=10, opc[1]=1 -> scale=4
-	var scale uint64
-	if size == 0b00 && (opc&0x2) != 0 {
-		// Q register (128-bit)
-		scale = 16
-		inst.Is64Bit = true // Use this to indicate 128-bit
-		inst.Arrangement = Arr16B
-	} else if size == 0b01 {
-		// D register (64-bit)
-		scale = 8
-		inst.Arrangement = Arr8B
-	} else if size == 0b10 {
-		// S register (32-bit)
-		scale = 4
-		inst.Arrangement = Arr2S
-	} else {
-		// Default to Q register
-		scale = 16
-		inst.Arrangement = Arr16B
-	}
-
-	inst.Imm = uint64(imm12) * scale
-
-	// Determine LDR vs STR
-	// opc[0]: 0=STR, 1=LDR
-	if opc&0x1 == 1 {
-		inst.Op = OpLDRQ
-	} else {
-		inst.Op = OpSTRQ
-	}
-}
-
 // isSIMDThreeSame checks for SIMD Three Same instructions (ADD, SUB, MUL, etc.).
 // Format: 0 | Q | U | 01110 | size | 1 | Rm | opcode | 1 | Rn | Rd
-// bits [31] = 0, bits [28:24] = 0b01110, bit [21] = 1
+// bits [31] = 0, bits [28:24] = 0b01110, bit [21] = 1, bit [10] = 1
 func (d *Decoder) isSIMDThreeSame(word uint32) bool {
 	bit31 := (word >> 31) & 0x1
 	op := (word >> 24) & 0x1F   // bits [28:24]
 	bit21 := (word >> 21) & 0x1 // bit 21 must be 1 for three-same
-	return bit31 == 0 && op == 0b01110 && bit21 == 1
+	bit10 := (word >> 10) & 0x1 // bit 10 separates three-same from the other classes
+	return bit31 == 0 && op == 0b01110 && bit21 == 1 && bit10 == 1
 }
 
 // decodeSIMDThreeSame decodes SIMD Three Same instructions.
@@ -746,35 +1271,64 @@ func (d *Decoder) decodeSIMDThreeSame(word uint32, inst *Instruction) {
 	// Set arrangement based on Q and size
 	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
 
-	// Decode opcode
-	// Integer three-same: U=0, opcode determines operation
-	// ADD: U=0, opcode=10000 (0x10)
-	// SUB: U=1, opcode=10000 (0x10)
-	// MUL: U=0, opcode=10011 (0x13)
-	// Floating-point three-same: different encoding
-	switch {
-	case opcode == 0b10000: // ADD or SUB
-		if u == 0 {
-			inst.Op = OpVADD
-		} else {
-			inst.Op = OpVSUB
+	// Integer opcodes pair a signed (U=0) and an unsigned (U=1) operation.
+	// The logical operations (opcode 00011) use size to select the
+	// operation and always have byte arrangements.
+	if op := threeSameOps[opcode][u]; op != OpUnknown {
+		// There is no 1D arrangement, and only some opcodes have 2D
+		if size != 3 || (q == 1 && threeSame2D[opcode]) {
+			inst.Op = op
 		}
-	case opcode == 0b10011 && u == 0: // MUL (integer only)
+		return
+	}
+
+	switch {
+	case opcode == 0b00011: // AND, BIC, ORR, ORN / EOR, BSL, BIT, BIF
+		inst.Op = threeSameLogicalOps[u][size]
+		inst.Arrangement = d.getSIMDArrangement(q == 1, 0)
+	case opcode == 0b10011 && u == 0 && size != 3: // MUL (integer only)
 		inst.Op = OpVMUL
-	case opcode == 0b11010 && u == 0 && size >= 2: // FADD (floating-point)
-		inst.Op = OpVFADD
-		inst.IsFloat = true
-	case opcode == 0b11010 && u == 1 && size >= 2: // FSUB (floating-point)
-		inst.Op = OpVFSUB
-		inst.IsFloat = true
-	case opcode == 0b11011 && u == 1 && size >= 2: // FMUL (floating-point)
-		inst.Op = OpVFMUL
-		inst.IsFloat = true
+	case opcode == 0b10110 && (size == 1 || size == 2): // SQDMULH, SQRDMULH
+		inst.Op = [2]Op{OpVSQDMULH, OpVSQRDMULH}[u]
+	case opcode&0b11000 == 0b11000: // Floating-point; size[1] is part of the opcode
+		d.decodeSIMDFloatThreeSame(inst, q, u, size>>1, size&0x1, opcode&0x7)
 	default:
 		inst.Op = OpUnknown
 	}
 }
 
+// threeSameOps maps the integer three-same opcodes to their signed (U=0)
+// and unsigned (U=1) operations.
+var threeSameOps = [32][2]Op{
+	0b00001: {OpVSQADD, OpVUQADD},
+	0b00101: {OpVSQSUB, OpVUQSUB},
+	0b00110: {OpVCMGT, OpVCMHI},
+	0b00111: {OpVCMGE, OpVCMHS},
+	0b01000: {OpVSSHL, OpVUSHL},
+	0b01100: {OpVSMAX, OpVUMAX},
+	0b01101: {OpVSMIN, OpVUMIN},
+	0b01110: {OpVSABD, OpVUABD},
+	0b01111: {OpVSABA, OpVUABA},
+	0b10000: {OpVADD, OpVSUB},
+	0b10001: {OpVCMTST, OpVCMEQ},
+	0b10010: {OpVMLA, OpVMLS},
+	0b10100: {OpVSMAXP, OpVUMAXP},
+	0b10101: {OpVSMINP, OpVUMINP},
+	0b10111: {OpVADDP, OpUnknown},
+}
+
+// threeSame2D holds the integer three-same opcodes that have a 2D form.
+var threeSame2D = [32]bool{
+	0b00001: true, 0b00101: true, 0b00110: true, 0b00111: true,
+	0b01000: true, 0b10000: true, 0b10001: true, 0b10111: true,
+}
+
+// threeSameLogicalOps maps U and size to the logical three-same operations.
+var threeSameLogicalOps = [2][4]Op{
+	{OpVAND, OpVBIC, OpVORR, OpVORN},
+	{OpVEOR, OpVBSL, OpVBIT, OpVBIF},
+}
+
 // isPCRelAddressing checks for PC-relative addressing instructions (ADR, ADRP).
 // Format: op | immlo | 10000 | immhi | Rd
 // ADR:  op=0 (bit 31)
@@ -829,10 +1383,11 @@ func (d *Decoder) isLoadStoreLiteral(word uint32) bool {
 	return op1 == 0b011 && op2 == 0b00
 }
 
-// decodeLoadStoreLiteral decodes LDR (literal) instructions.
+// decodeLoadStoreLiteral decodes LDR (literal), LDRSW (literal) and
+// PRFM (literal) instructions.
 // Format: opc | 011 | V | 00 | imm19 | Rt
-// opc: 00=32-bit, 01=64-bit, 10=LDRSW, 11=PRFM
-// V: 0=GPR, 1=SIMD/FP
+// V=0: opc 00=32-bit, 01=64-bit, 10=LDRSW, 11=PRFM
+// V=1: opc 00=S, 01=D, 10=Q register
 func (d *Decoder) decodeLoadStoreLiteral(word uint32, inst *Instruction) {
 	inst.Format = FormatLoadStoreLit
 	inst.Op = OpLDRLit
@@ -846,8 +1401,22 @@ func (d *Decoder) decodeLoadStoreLiteral(word uint32, inst *Instruction) {
 	inst.IsSIMD = v == 1
 
 	// Determine size from opc
-	// For GPR: 00=32-bit, 01=64-bit
-	inst.Is64Bit = opc == 0b01
+	inst.AccessSize = 4 << opc
+	switch {
+	case v == 1 && opc == 0b11:
+		inst.Op = OpUnknown
+	case v == 1:
+		// S, D or Q register
+	case opc == 0b10:
+		inst.Op = OpLDRSW
+		inst.Is64Bit = true
+		inst.AccessSize = 4
+	case opc == 0b11:
+		inst.Op = OpPRFM
+		inst.AccessSize = 0
+	default:
+		inst.Is64Bit = opc == 0b01
+	}
 
 	// Sign-extend imm19 and multiply by 4 (word-aligned)
 	offset := int64(imm19)
@@ -923,27 +1492,159 @@ func (d *Decoder) getSIMDArrangement(isQ bool, size uint32) SIMDArrangement {
 			return Arr4H
 		case 2:
 			return Arr2S
+		case 3:
+			return Arr1D
 		}
 	}
 	return Arr16B // Default
 }
 
-// isLoadStorePair checks for load/store pair instructions (LDP/STP).
+// isSIMDLoadStoreStruct checks for Advanced SIMD load/store multiple and
+// single structure instructions (LD1-LD4, ST1-ST4, LD1R-LD4R).
+// Format: 0 | Q | 0011 | 0 | single | post | L | R | Rm | opcode | S | size | Rn | Rt
+// bit 31 == 0, bits [29:25] == 00110
+func (d *Decoder) isSIMDLoadStoreStruct(word uint32) bool {
+	return (word>>31) == 0 && (word>>25)&0x1F == 0b00110
+}
+
+// multipleStructs maps the opcode of the load/store multiple structures
+// instructions to their register count and elements per structure.
+var multipleStructs = map[uint32][2]uint8{
+	0b0000: {4, 4}, // LD4/ST4
+	0b0010: {4, 1}, // LD1/ST1, four registers
+	0b0100: {3, 3}, // LD3/ST3
+	0b0110: {3, 1}, // LD1/ST1, three registers
+	0b0111: {1, 1}, // LD1/ST1, one register
+	0b1000: {2, 2}, // LD2/ST2
+	0b1010: {2, 1}, // LD1/ST1, two registers
+}
+
+// decodeSIMDLoadStoreStruct decodes the Advanced SIMD structure loads and
+// stores.
+// Format: 0 | Q | 0011 | 0 | single | post | L | R | Rm | opcode | S | size | Rn | Rt
+// single[24]: 0=multiple structures, 1=single structure
+// post[23]: post-index by Rm, or by the transfer size when Rm is 31
+// multiple: opcode[15:12] selects the register count and interleaving, R
+// and Rm (without post-index) must be zero
+// single: opcode[15:13] and R select the element size and structure size;
+// opcode[15:14] == 11 is the replicate (LDnR) form
+func (d *Decoder) decodeSIMDLoadStoreStruct(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDLoadStoreStruct
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1      // bit 30: 0=64-bit, 1=128-bit vectors
+	single := (word >> 24) & 0x1 // bit 24
+	post := (word >> 23) & 0x1   // bit 23
+	l := (word >> 22) & 0x1      // bit 22: 0=store, 1=load
+	r := (word >> 21) & 0x1      // bit 21
+	rm := (word >> 16) & 0x1F    // bits [20:16]
+	opcode := (word >> 12) & 0xF // bits [15:12]
+	size := (word >> 10) & 0x3   // bits [11:10]
+	rn := (word >> 5) & 0x1F     // bits [9:5]
+	rt := word & 0x1F            // bits [4:0]
+
+	inst.Rd = uint8(rt) // Rt uses Rd field
+	inst.Rn = uint8(rn)
+	inst.Is64Bit = q == 1 // As for three-same, Q rather than X registers
+	if post == 0 && rm != 0 {
+		return
+	}
+
+	var total uint64 // Bytes transferred
+	if single == 0 {
+		regs, ok := multipleStructs[opcode]
+		if r == 1 || !ok || (size == 3 && q == 0 && regs[1] != 1) {
+			return
+		}
+		inst.RegCount, inst.StructElems = regs[0], regs[1]
+		inst.AccessSize = 1 << size
+		inst.Arrangement = d.structArrangement(q, size)
+		total = uint64(regs[0]) * (8 << q)
+		if l == 1 {
+			inst.Op = OpVLDN
+		} else {
+			inst.Op = OpVSTN
+		}
+	} else {
+		opc := opcode >> 1 // bits [15:13]
+		s := opcode & 0x1  // bit 12
+		scale := opc >> 1
+		inst.StructElems = uint8((opc&0x1)<<1|r) + 1
+		inst.RegCount = inst.StructElems
+		switch scale {
+		case 0b11:
+			if l == 0 || s == 1 {
+				return
+			}
+			inst.AccessSize = 1 << size
+			inst.Arrangement = d.structArrangement(q, size)
+			inst.Op = OpVLDNR
+		case 0b00:
+			inst.AccessSize = 1
+			inst.Lane = uint8(q<<3 | s<<2 | size)
+		case 0b01:
+			if size&0x1 == 1 {
+				return
+			}
+			inst.AccessSize = 2
+			inst.Lane = uint8(q<<2 | s<<1 | size>>1)
+		default:
+			switch {
+			case size == 0b00:
+				inst.AccessSize = 4
+				inst.Lane = uint8(q<<1 | s)
+			case size == 0b01 && s == 0:
+				inst.AccessSize = 8
+				inst.Lane = uint8(q)
+			default:
+				return
+			}
+		}
+		if scale != 0b11 {
+			if l == 1 {
+				inst.Op = OpVLDNLane
+			} else {
+				inst.Op = OpVSTNLane
+			}
+		}
+		total = uint64(inst.StructElems) * uint64(inst.AccessSize)
+	}
+
+	if post == 1 {
+		inst.IndexMode = IndexPost
+		inst.Rm = uint8(rm)
+		if rm == 31 {
+			inst.SignedImm = int64(total)
+		}
+	}
+}
+
+// structArrangement returns the arrangement of a structure load/store,
+// which unlike the data-processing forms allows 1D.
+func (d *Decoder) structArrangement(q, size uint32) SIMDArrangement {
+	if q == 0 && size == 3 {
+		return Arr1D
+	}
+	return d.getSIMDArrangement(q == 1, size)
+}
+
+// isLoadStorePair checks for load/store pair instructions (LDP/STP/LDNP/STNP).
 // Format: opc | 101 | V | mode | L | imm7 | Rt2 | Rn | Rt
-// bits [29:27] == 101, and mode[25:23] must be 001, 010, or 011
+// bits [29:27] == 101, and mode[25:23] must be 000, 001, 010, or 011
 func (d *Decoder) isLoadStorePair(word uint32) bool {
 	op := (word >> 27) & 0x7   // bits [29:27]
 	mode := (word >> 23) & 0x7 // bits [25:23]
-	// mode must be 001 (post-index), 010 (signed offset), or 011 (pre-index)
+	// bit 25 must be clear
 	// This distinguishes from data processing register instructions
-	return op == 0b101 && (mode == 0b001 || mode == 0b010 || mode == 0b011)
+	return op == 0b101 && mode&0b100 == 0
 }
 
-// decodeLoadStorePair decodes LDP and STP instructions.
+// decodeLoadStorePair decodes LDP, STP, LDPSW, LDNP and STNP instructions.
 // Format: opc | 101 | V | mode | L | imm7 | Rt2 | Rn | Rt
-// opc[31:30]: 00=32-bit, 10=64-bit
+// opc[31:30]: V=0: 00=32-bit, 01=LDPSW, 10=64-bit
+// opc[31:30]: V=1: 00=S, 01=D, 10=Q registers
 // V[26]: 0=GPR, 1=SIMD
-// mode[25:23]: 001=post-index, 010=signed offset, 011=pre-index
+// mode[25:23]: 000=non-temporal, 001=post-index, 010=signed offset, 011=pre-index
 // L[22]: 0=STP, 1=LDP
 // imm7[21:15]: signed offset, scaled by register size
 func (d *Decoder) decodeLoadStorePair(word uint32, inst *Instruction) {
@@ -964,34 +1665,18 @@ func (d *Decoder) decodeLoadStorePair(word uint32, inst *Instruction) {
 
 	inst.IsSIMD = v == 1
 
-	// Determine 64-bit vs 32-bit from opc
-	// For GPR: opc=00 means 32-bit, opc=10 means 64-bit
-	inst.Is64Bit = opc == 0b10
-
 	// Determine addressing mode
 	switch mode {
+	case 0b000:
+		inst.IndexMode = IndexSigned
+		inst.NonTemporal = true
 	case 0b001:
 		inst.IndexMode = IndexPost
 	case 0b010:
 		inst.IndexMode = IndexSigned
 	case 0b011:
 		inst.IndexMode = IndexPre
-	default:
-		inst.IndexMode = IndexNone
-	}
-
-	// Sign-extend imm7 and scale by register size
-	offset := int64(imm7)
-	if (imm7 >> 6) == 1 {
-		offset |= ^int64(0x7F) // Sign extend
 	}
-	// Scale: 4 for 32-bit, 8 for 64-bit
-	if inst.Is64Bit {
-		offset *= 8
-	} else {
-		offset *= 4
-	}
-	inst.SignedImm = offset
 
 	// Determine LDP vs STP
 	if l == 1 {
@@ -999,6 +1684,33 @@ func (d *Decoder) decodeLoadStorePair(word uint32, inst *Instruction) {
 	} else {
 		inst.Op = OpSTP
 	}
+
+	// Determine the register size from opc
+	switch {
+	case opc == 0b11:
+		inst.Op = OpUnknown
+	case v == 1:
+		inst.AccessSize = 4 << opc
+	case opc == 0b01:
+		// LDPSW; the store encoding (STGP) and LDNP form are not supported
+		if l == 0 || inst.NonTemporal {
+			inst.Op = OpUnknown
+		} else {
+			inst.Op = OpLDPSW
+		}
+		inst.Is64Bit = true
+		inst.AccessSize = 4
+	default:
+		inst.Is64Bit = opc == 0b10
+		inst.AccessSize = 4 << (opc >> 1)
+	}
+
+	// Sign-extend imm7 and scale by register size
+	offset := int64(imm7)
+	if (imm7 >> 6) == 1 {
+		offset |= ^int64(0x7F) // Sign extend
+	}
+	inst.SignedImm = offset * int64(inst.AccessSize)
 }
 
 // isLoadStoreRegOffset checks for load/store with register offset addressing.
@@ -1012,21 +1724,15 @@ func (d *Decoder) isLoadStoreRegOffset(word uint32) bool {
 	return op1 == 0b111 && op2 == 0b00 && bit21 == 1 && bits1110 == 0b10
 }
 
-// decodeLoadStoreRegOffset decodes LDR/STR with register offset addressing.
+// decodeLoadStoreRegOffset decodes LDR/STR/PRFM with register offset addressing.
 // Format: size | 111 | V | 00 | opc | 1 | Rm | option | S | 10 | Rn | Rt
-// size[31:30]: 00=byte, 01=halfword, 10=32-bit, 11=64-bit
-// V[26]: 0=GPR
-// opc[23:22]: 00=STR, 01=LDR
+// size[31:30], V[26] and opc[23:22]: see decodeLoadStoreOp
 // Rm[20:16]: offset register
 // option[15:13]: extend type (010=UXTW, 011=LSL, 110=SXTW, 111=SXTX)
-// S[12]: scale - if 1, shift by log2(size)
+// S[12]: scale - if 1, shift by log2(access size)
 func (d *Decoder) decodeLoadStoreRegOffset(word uint32, inst *Instruction) {
-	inst.Format = FormatLoadStore
 	inst.IndexMode = IndexRegBase
 
-	size := (word >> 30) & 0x3   // bits [31:30]
-	v := (word >> 26) & 0x1      // bit 26: 0=GPR
-	opc := (word >> 22) & 0x3    // bits [23:22]
 	rm := (word >> 16) & 0x1F    // bits [20:16]
 	option := (word >> 13) & 0x7 // bits [15:13]
 	s := (word >> 12) & 0x1      // bit 12: scale
@@ -1036,67 +1742,28 @@ func (d *Decoder) decodeLoadStoreRegOffset(word uint32, inst *Instruction) {
 	inst.Rn = uint8(rn)
 	inst.Rd = uint8(rt)
 	inst.Rm = uint8(rm)
-	inst.IsSIMD = v == 1
 
 	// Store extend type in ShiftType (repurposing for this use)
 	// Option: 010=UXTW, 011=LSL, 110=SXTW, 111=SXTX
 	inst.ShiftType = ShiftType(option)
 
-	// Calculate shift amount
-	if s == 1 {
-		// Scale by size: 0=byte(0), 1=halfword(1), 2=word(2), 3=dword(3)
-		inst.ShiftAmount = uint8(size)
-	} else {
-		inst.ShiftAmount = 0
+	// option[1] == 0 (byte and halfword extends) is unallocated
+	if !d.decodeLoadStoreOp(word, inst) || option&0x2 == 0 {
+		inst.Op = OpUnknown
+		return
 	}
 
-	// Determine operation based on size and opc
-	switch size {
-	case 0b11: // 64-bit
-		inst.Is64Bit = true
-		if opc&0x1 == 1 {
-			inst.Op = OpLDR
-		} else {
-			inst.Op = OpSTR
-		}
-	case 0b10: // 32-bit
-		switch opc {
-		case 0b00:
-			inst.Op = OpSTR
-			inst.Is64Bit = false
-		case 0b01:
-			inst.Op = OpLDR
-			inst.Is64Bit = false
-		case 0b10:
-			inst.Op = OpLDRSW
-			inst.Is64Bit = true // LDRSW sign-extends to 64-bit
-		}
-	case 0b01: // 16-bit (halfword)
-		inst.Is64Bit = false
-		switch opc {
-		case 0b00:
-			inst.Op = OpSTRH
-		case 0b01:
-			inst.Op = OpLDRH
-		case 0b10, 0b11:
-			inst.Op = OpLDRSH
-			inst.Is64Bit = opc == 0b10
-		}
-	case 0b00: // 8-bit (byte)
-		inst.Is64Bit = false
-		switch opc {
-		case 0b00:
-			inst.Op = OpSTRB
-		case 0b01:
-			inst.Op = OpLDRB
-		case 0b10, 0b11:
-			inst.Op = OpLDRSB
-			inst.Is64Bit = opc == 0b10
+	// Calculate shift amount: log2 of the access size
+	if s == 1 {
+		inst.ShiftAmount = uint8(word >> 30) // size field
+		if inst.AccessSize == 16 {
+			inst.ShiftAmount = 4
 		}
 	}
 }
 
-// isLoadStoreRegIndexed checks for load/store register with pre/post-indexed addressing.
+// isLoadStoreRegIndexed checks for load/store register with immediate
+// pre/post-indexed, unscaled or unprivileged addressing.
 // Format: size | 111 | V | 00 | opc | 0 | imm9 | mode | Rn | Rt
 // bits [29:27] == 111, bit 26 == V, bits [25:24] == 00, bit 21 == 0
 // mode[11:10]: 00=unscaled, 01=post-index, 10=unprivileged, 11=pre-index
@@ -1107,19 +1774,13 @@ func (d *Decoder) isLoadStoreRegIndexed(word uint32) bool {
 	return op1 == 0b111 && op2 == 0b00 && bit21 == 0
 }
 
-// decodeLoadStoreRegIndexed decodes LDR/STR with pre/post-indexed addressing.
+// decodeLoadStoreRegIndexed decodes LDR/STR with pre/post-indexed addressing,
+// LDUR/STUR/PRFUM (unscaled) and LDTR/STTR (unprivileged).
 // Format: size | 111 | V | 00 | opc | 0 | imm9 | mode | Rn | Rt
-// size[31:30]: 00=byte, 01=halfword, 10=32-bit, 11=64-bit
-// V[26]: 0=GPR
-// opc[23:22]: varies by size
-// imm9[20:12]: signed 9-bit immediate
-// mode[11:10]: 01=post-index, 11=pre-index
+// size[31:30], V[26] and opc[23:22]: see decodeLoadStoreOp
+// imm9[20:12]: signed 9-bit immediate, not scaled
+// mode[11:10]: 00=unscaled, 01=post-index, 10=unprivileged, 11=pre-index
 func (d *Decoder) decodeLoadStoreRegIndexed(word uint32, inst *Instruction) {
-	inst.Format = FormatLoadStore
-
-	size := (word >> 30) & 0x3   // bits [31:30]
-	v := (word >> 26) & 0x1      // bit 26: 0=GPR
-	opc := (word >> 22) & 0x3    // bits [23:22]
 	imm9 := (word >> 12) & 0x1FF // bits [20:12]
 	mode := (word >> 10) & 0x3   // bits [11:10]
 	rn := (word >> 5) & 0x1F     // bits [9:5]
@@ -1127,7 +1788,6 @@ func (d *Decoder) decodeLoadStoreRegIndexed(word uint32, inst *Instruction) {
 
 	inst.Rn = uint8(rn)
 	inst.Rd = uint8(rt)
-	inst.IsSIMD = v == 1
 
 	// Determine addressing mode
 	switch mode {
@@ -1135,8 +1795,11 @@ func (d *Decoder) decodeLoadStoreRegIndexed(word uint32, inst *Instruction) {
 		inst.IndexMode = IndexPost
 	case 0b11:
 		inst.IndexMode = IndexPre
+	case 0b10:
+		inst.IndexMode = IndexSigned
+		inst.Unprivileged = true
 	default:
-		inst.IndexMode = IndexNone // Unscaled or unprivileged
+		inst.IndexMode = IndexSigned // Unscaled
 	}
 
 	// Sign-extend imm9
@@ -1146,54 +1809,15 @@ func (d *Decoder) decodeLoadStoreRegIndexed(word uint32, inst *Instruction) {
 	}
 	inst.SignedImm = offset
 
-	// Determine operation based on size and opc
-	// size=11 (64-bit), opc=00=STR, opc=01=LDR
-	// size=10 (32-bit), opc=00=STR, opc=01=LDR, opc=10=LDRSW
-	// size=01 (16-bit), opc=00=STRH, opc=01=LDRH, opc=10=LDRSW(16), opc=11=LDRSH
-	// size=00 (8-bit), opc=00=STRB, opc=01=LDRB, opc=10=LDRSB(64), opc=11=LDRSB(32)
+	if !d.decodeLoadStoreOp(word, inst) {
+		inst.Op = OpUnknown
+		return
+	}
 
-	switch size {
-	case 0b11: // 64-bit
-		inst.Is64Bit = true
-		if opc&0x1 == 1 {
-			inst.Op = OpLDR
-		} else {
-			inst.Op = OpSTR
-		}
-	case 0b10: // 32-bit
-		switch opc {
-		case 0b00:
-			inst.Op = OpSTR
-			inst.Is64Bit = false
-		case 0b01:
-			inst.Op = OpLDR
-			inst.Is64Bit = false
-		case 0b10:
-			inst.Op = OpLDRSW
-			inst.Is64Bit = true // LDRSW sign-extends to 64-bit
-		}
-	case 0b01: // 16-bit (halfword)
-		inst.Is64Bit = false
-		switch opc {
-		case 0b00:
-			inst.Op = OpSTRH
-		case 0b01:
-			inst.Op = OpLDRH
-		case 0b10, 0b11:
-			inst.Op = OpLDRSH
-			inst.Is64Bit = opc == 0b10 // 10=extend to 64-bit
-		}
-	case 0b00: // 8-bit (byte)
-		inst.Is64Bit = false
-		switch opc {
-		case 0b00:
-			inst.Op = OpSTRB
-		case 0b01:
-			inst.Op = OpLDRB
-		case 0b10, 0b11:
-			inst.Op = OpLDRSB
-			inst.Is64Bit = opc == 0b10 // 10=extend to 64-bit
-		}
+	// Only the unscaled form has a prefetch (PRFUM), and there are no
+	// unprivileged SIMD&FP accesses.
+	if (inst.Op == OpPRFM && mode != 0b00) || (inst.IsSIMD && inst.Unprivileged) {
+		inst.Op = OpUnknown
 	}
 }
 
@@ -1292,17 +1916,64 @@ func (d *Decoder) decodeConditionalSelect(word uint32, inst *Instruction) {
 	}
 }
 
+// isDataProc1Src checks for data processing (1 source) instructions
+// (RBIT, REV16, REV32, REV, CLZ, CLS).
+// Format: sf | 1 | S | 11010110 | opcode2 | opcode | Rn | Rd
+// bits [30:21] == 0b1011010110 (S=0), opcode2[20:16] == 0b00000
+func (d *Decoder) isDataProc1Src(word uint32) bool {
+	op := (word >> 21) & 0x3FF     // bits [30:21]
+	opcode2 := (word >> 16) & 0x1F // bits [20:16]
+	return op == 0b1011010110 && opcode2 == 0
+}
+
+// decodeDataProc1Src decodes RBIT, REV16, REV32, REV, CLZ and CLS.
+// opcode[15:10]: 000000=RBIT, 000001=REV16, 000010=REV32 (REV when sf=0),
+// 000011=REV (64-bit only), 000100=CLZ, 000101=CLS
+func (d *Decoder) decodeDataProc1Src(word uint32, inst *Instruction) {
+	inst.Format = FormatDataProc1Src
+
+	sf := (word >> 31) & 0x1      // bit 31: 0=32-bit, 1=64-bit
+	opcode := (word >> 10) & 0x3F // bits [15:10]
+	rn := (word >> 5) & 0x1F      // bits [9:5]
+	rd := word & 0x1F             // bits [4:0]
+
+	inst.Is64Bit = sf == 1
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+
+	switch {
+	case opcode == 0b000000:
+		inst.Op = OpRBIT
+	case opcode == 0b000001:
+		inst.Op = OpREV16
+	case opcode == 0b000010 && sf == 1:
+		inst.Op = OpREV32
+	case opcode == 0b000010:
+		inst.Op = OpREV
+	case opcode == 0b000011 && sf == 1:
+		inst.Op = OpREV
+	case opcode == 0b000100:
+		inst.Op = OpCLZ
+	case opcode == 0b000101:
+		inst.Op = OpCLS
+	default:
+		inst.Op = OpUnknown
+	}
+}
+
 // isDataProc2Src checks for data processing (2 source) instructions (UDIV, SDIV).
 // Format: sf | 0 | S | 11010110 | Rm | opcode | Rn | Rd
-// bits [29:21] == 0b011010110 (S=0)
+// bits [30:21] == 0b0011010110 (S=0)
 func (d *Decoder) isDataProc2Src(word uint32) bool {
-	op := (word >> 21) & 0x1FF // bits [29:21]
-	return op == 0b011010110
+	op := (word >> 21) & 0x3FF // bits [30:21]
+	return op == 0b0011010110
 }
 
-// decodeDataProc2Src decodes UDIV and SDIV instructions.
+// decodeDataProc2Src decodes the divides, variable shifts, PACGA and CRC32
+// instructions.
 // Format: sf | 0 | S | 11010110 | Rm | opcode | Rn | Rd
-// opcode[15:10]: 000010=UDIV, 000011=SDIV
+// opcode[15:10]: 000010=UDIV, 000011=SDIV, 0010xx=shifts, 001100=PACGA,
+// 010Csz=CRC32
 func (d *Decoder) decodeDataProc2Src(word uint32, inst *Instruction) {
 	inst.Format = FormatDataProc2Src
 
@@ -1317,6 +1988,22 @@ func (d *Decoder) decodeDataProc2Src(word uint32, inst *Instruction) {
 	inst.Rn = uint8(rn)
 	inst.Rm = uint8(rm)
 
+	// CRC32{B,H,W,X} and CRC32C{B,H,W,X}: opcode = 010 | C | sz.
+	// The 64-bit data size requires sf=1; the others require sf=0.
+	if opcode>>3 == 0b010 {
+		sz := opcode & 0x3
+		if (sz == 0b11) != (sf == 1) {
+			inst.Op = OpUnknown
+			return
+		}
+		inst.Op = OpCRC32
+		if (opcode>>2)&0x1 == 1 {
+			inst.Op = OpCRC32C
+		}
+		inst.Imm = 1 << sz
+		return
+	}
+
 	// Decode operation based on opcode
 	// 000010 = UDIV
 	// 000011 = SDIV
@@ -1324,6 +2011,7 @@ func (d *Decoder) decodeDataProc2Src(word uint32, inst *Instruction) {
 	// 001001 = LSRV (logical shift right variable)
 	// 001010 = ASRV (arithmetic shift right variable)
 	// 001011 = RORV (rotate right variable)
+	// 001100 = PACGA (64-bit only)
 	switch opcode {
 	case 0b000010:
 		inst.Op = OpUDIV
@@ -1337,11 +2025,49 @@ func (d *Decoder) decodeDataProc2Src(word uint32, inst *Instruction) {
 		inst.Op = OpASRV
 	case 0b001011:
 		inst.Op = OpRORV
+	case 0b001100:
+		if sf == 1 {
+			inst.Op = OpPACGA
+			inst.PACKey = PACKeyGA
+		}
 	default:
 		inst.Op = OpUnknown
 	}
 }
 
+// isAddSubCarry checks for add/subtract with carry instructions (ADC, SBC).
+// Format: sf | op | S | 11010000 | Rm | 000000 | Rn | Rd
+func (d *Decoder) isAddSubCarry(word uint32) bool {
+	op := (word >> 21) & 0xFF      // bits [28:21]
+	opcode2 := (word >> 10) & 0x3F // bits [15:10]
+	return op == 0b11010000 && opcode2 == 0
+}
+
+// decodeAddSubCarry decodes ADC, ADCS, SBC and SBCS.
+// op[30]: 0=ADC, 1=SBC; S[29]: set flags
+func (d *Decoder) decodeAddSubCarry(word uint32, inst *Instruction) {
+	inst.Format = FormatAddSubCarry
+
+	sf := (word >> 31) & 0x1  // bit 31: 0=32-bit, 1=64-bit
+	op := (word >> 30) & 0x1  // bit 30
+	s := (word >> 29) & 0x1   // bit 29
+	rm := (word >> 16) & 0x1F // bits [20:16]
+	rn := (word >> 5) & 0x1F  // bits [9:5]
+	rd := word & 0x1F         // bits [4:0]
+
+	inst.Is64Bit = sf == 1
+	inst.SetFlags = s == 1
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+	inst.Rm = uint8(rm)
+
+	if op == 0 {
+		inst.Op = OpADC
+	} else {
+		inst.Op = OpSBC
+	}
+}
+
 // isDataProc3Src checks for data processing (3 source) instructions (MADD, MSUB).
 // Format: sf | op54 | 11011 | op31 | Rm | o0 | Ra | Rn | Rd
 // bits [28:24] == 0b11011
@@ -1350,19 +2076,22 @@ func (d *Decoder) isDataProc3Src(word uint32) bool {
 	return op == 0b11011
 }
 
-// decodeDataProc3Src decodes MADD and MSUB instructions.
+// decodeDataProc3Src decodes the multiply-add family.
 // Format: sf | op54 | 11011 | op31 | Rm | o0 | Ra | Rn | Rd
-// op54=00, op31=000 for MADD/MSUB
-// o0[15]: 0=MADD, 1=MSUB
+// op31[23:21]: 000=MADD/MSUB, 001=SMADDL/SMSUBL, 010=SMULH,
+// 101=UMADDL/UMSUBL, 110=UMULH
+// o0[15]: 0=add, 1=subtract
 func (d *Decoder) decodeDataProc3Src(word uint32, inst *Instruction) {
 	inst.Format = FormatDataProc3Src
 
-	sf := (word >> 31) & 0x1  // bit 31: 0=32-bit, 1=64-bit
-	rm := (word >> 16) & 0x1F // bits [20:16]
-	o0 := (word >> 15) & 0x1  // bit 15
-	ra := (word >> 10) & 0x1F // bits [14:10]
-	rn := (word >> 5) & 0x1F  // bits [9:5]
-	rd := word & 0x1F         // bits [4:0]
+	sf := (word >> 31) & 0x1   // bit 31: 0=32-bit, 1=64-bit
+	op54 := (word >> 29) & 0x3 // bits [30:29]
+	op31 := (word >> 21) & 0x7 // bits [23:21]
+	rm := (word >> 16) & 0x1F  // bits [20:16]
+	o0 := (word >> 15) & 0x1   // bit 15
+	ra := (word >> 10) & 0x1F  // bits [14:10]
+	rn := (word >> 5) & 0x1F   // bits [9:5]
+	rd := word & 0x1F          // bits [4:0]
 
 	inst.Is64Bit = sf == 1
 	inst.Rd = uint8(rd)
@@ -1370,13 +2099,31 @@ func (d *Decoder) decodeDataProc3Src(word uint32, inst *Instruction) {
 	inst.Rm = uint8(rm)
 	inst.Rt2 = uint8(ra) // Reuse Rt2 field for Ra
 
-	// Decode operation based on o0
-	// o0=0: MADD (Rd = Ra + Rn * Rm)
-	// o0=1: MSUB (Rd = Ra - Rn * Rm)
-	if o0 == 0 {
-		inst.Op = OpMADD
-	} else {
-		inst.Op = OpMSUB
+	// Everything but MADD/MSUB is 64-bit only.
+	if op54 != 0 || (op31 != 0b000 && sf == 0) {
+		inst.Op = OpUnknown
+		return
+	}
+
+	switch {
+	case op31 == 0b000 && o0 == 0:
+		inst.Op = OpMADD // Rd = Ra + Rn * Rm
+	case op31 == 0b000:
+		inst.Op = OpMSUB // Rd = Ra - Rn * Rm
+	case op31 == 0b001 && o0 == 0:
+		inst.Op = OpSMADDL
+	case op31 == 0b001:
+		inst.Op = OpSMSUBL
+	case op31 == 0b101 && o0 == 0:
+		inst.Op = OpUMADDL
+	case op31 == 0b101:
+		inst.Op = OpUMSUBL
+	case op31 == 0b010 && o0 == 0:
+		inst.Op = OpSMULH
+	case op31 == 0b110 && o0 == 0:
+		inst.Op = OpUMULH
+	default:
+		inst.Op = OpUnknown
 	}
 }
 
@@ -1646,99 +2393,165 @@ func (d *Decoder) decodeCompareBranch(word uint32, inst *Instruction) {
 	}
 }
 
-// isSIMDCopy checks for SIMD copy instructions (DUP).
-// DUP (general register): 0 | Q | 001110 | 0 | 0 | 0 | imm5 | 000011 | Rn | Rd
-// bits [31:24] == 0b01001110, bit 21 == 0, bits [15:10] == 0b000011
+// isSIMDCopy checks for SIMD copy instructions (DUP, INS, UMOV, SMOV) and
+// the scalar DUP (element).
+// Format: 0 | Q | op | 01110000 | imm5 | 0 | imm4 | 1 | Rn | Rd
+// bits [28:21] == 0b01110000, bit 15 == 0, bit 10 == 1
+// Scalar: 01 | 0 | 11110000 | imm5 | 0 | 0000 | 1 | Rn | Rd
 func (d *Decoder) isSIMDCopy(word uint32) bool {
-	op := (word >> 24) & 0xFF   // bits [31:24]
-	bit21 := (word >> 21) & 0x1 // bit 21
-	op2 := (word >> 10) & 0x3F  // bits [15:10]
-	return op == 0b01001110 && bit21 == 0 && op2 == 0b000011
+	return (word>>31) == 0 && (word>>21)&0xFF == 0b01110000 &&
+		(word>>15)&0x1 == 0 && (word>>10)&0x1 == 1 ||
+		word&0xFFE0FC00 == 0x5E000400
 }
 
-// decodeSIMDCopy decodes SIMD copy instructions like DUP.
-// Format: 0 | Q | 001110000 | imm5 | 000011 | Rn | Rd
-// Q[30]: 0=64-bit (D), 1=128-bit (Q)
-// imm5[20:16]: encodes element size and target arrangement
-// Rn[9:5]: source general register
-// Rd[4:0]: destination SIMD register
+// decodeSIMDCopy decodes SIMD copy instructions.
+// Format: 0 | Q | op | 01110000 | imm5 | 0 | imm4 | 1 | Rn | Rd
+// Q[30]: 0=64-bit (D), 1=128-bit (Q); for UMOV and SMOV, 1=X register
+// imm5[20:16]: the lowest set bit gives the element size, the bits above
+// it the element index
+// imm4[14:11]: 0000=DUP (element), 0001=DUP (general), 0011=INS (general),
+// 0101=SMOV, 0111=UMOV; with op=1, the source element index of INS
+// (element), which is stored in Imm2
+// The element forms set Arrangement to the 128-bit arrangement of the
+// element size, and DUP to its destination arrangement. The scalar DUP
+// (also written MOV) copies the element to a scalar of its size.
 func (d *Decoder) decodeSIMDCopy(word uint32, inst *Instruction) {
 	inst.Format = FormatSIMDCopy
 	inst.IsSIMD = true
-	inst.Op = OpDUP
 
 	q := (word >> 30) & 0x1     // bit 30: 0=64-bit, 1=128-bit
+	op := (word >> 29) & 0x1    // bit 29
 	imm5 := (word >> 16) & 0x1F // bits [20:16]
+	imm4 := (word >> 11) & 0xF  // bits [14:11]
 	rn := (word >> 5) & 0x1F    // bits [9:5]
 	rd := word & 0x1F           // bits [4:0]
 
-	inst.Rd = uint8(rd)   // SIMD destination register
-	inst.Rn = uint8(rn)   // General purpose source register
-	inst.Is64Bit = q == 1 // 128-bit (Q) vs 64-bit (D)
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+	inst.Is64Bit = q == 1
+	inst.Imm = uint64(imm5) // Store element size info in Imm for execution
 
-	// Decode element size from imm5
-	// imm5[0]: if 1, then 8-bit elements (byte)
-	// imm5[1]: if 1 and imm5[0]==0, then 16-bit elements (halfword)
-	// imm5[2]: if 1 and imm5[1:0]==00, then 32-bit elements (word)
-	// imm5[3]: if 1 and imm5[2:0]==000, then 64-bit elements (doubleword)
+	if imm5&0xF == 0 {
+		return // 128-bit elements
+	}
+	size := uint32(bits.TrailingZeros32(imm5))
+	inst.Lane = uint8(imm5 >> (size + 1))
+	inst.Arrangement = d.getSIMDArrangement(true, size)
 
-	if imm5&0x1 != 0 {
-		// 8-bit elements (byte)
+	if (word>>28)&0x1 == 1 { // DUP (element) to a scalar
+		inst.IsScalar = true
+		inst.Is64Bit = false
+		inst.Arrangement = scalarArrangement(size)
+		inst.Op = OpDUPElem
+		return
+	}
+	if op == 1 {
 		if q == 1 {
-			inst.Arrangement = Arr16B // 16 bytes for Q register
-		} else {
-			inst.Arrangement = Arr8B // 8 bytes for D register
+			inst.Op = OpINSElem
+			inst.Imm2 = uint64(imm4 >> size)
 		}
-	} else if imm5&0x2 != 0 {
-		// 16-bit elements (halfword)
-		if q == 1 {
-			inst.Arrangement = Arr8H // 8 halfwords for Q register
-		} else {
-			inst.Arrangement = Arr4H // 4 halfwords for D register
+		return
+	}
+
+	switch imm4 {
+	case 0b0000, 0b0001: // DUP (element), DUP (general)
+		if size == 3 && q == 0 {
+			return // Invalid: 64-bit elements in D register
 		}
-	} else if imm5&0x4 != 0 {
-		// 32-bit elements (word)
-		if q == 1 {
-			inst.Arrangement = Arr4S // 4 singles for Q register
-		} else {
-			inst.Arrangement = Arr2S // 2 singles for D register
+		inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+		inst.Op = OpDUPElem
+		if imm4 == 0b0001 {
+			inst.Op = OpDUP
 		}
-	} else if imm5&0x8 != 0 {
-		// 64-bit elements (doubleword) - only valid for Q register
+	case 0b0011:
 		if q == 1 {
-			inst.Arrangement = Arr2D // 2 doubles for Q register
-		} else {
-			// Invalid: 64-bit elements in D register
-			inst.Op = OpUnknown
+			inst.Op = OpINS
+		}
+	case 0b0101:
+		if size < 2 || (size == 2 && q == 1) {
+			inst.Op = OpSMOV
+		}
+	case 0b0111:
+		if (size == 3) == (q == 1) {
+			inst.Op = OpUMOV
 		}
-	} else {
-		// Invalid imm5 encoding
-		inst.Op = OpUnknown
 	}
-
-	// Store element size info in Imm for execution
-	inst.Imm = uint64(imm5)
 }
 
-// isSystemReg checks for system register instructions (MRS).
-// MRS pattern: 1101010100 | L | 1 | o0:o1:o2:op1:CRn:CRm:op2 | Rt
-// bits [31:21] == 0b11010101001 and L=1 for MRS (0xD53)
+// isSystemReg checks for system register instructions (MRS, MSR).
+// Pattern: 1101010100 | L | 1 | o0:o1:o2:op1:CRn:CRm:op2 | Rt
+// bits [31:20] == 0xD53 for MRS (L=1) and 0xD51 for MSR (L=0)
 func (d *Decoder) isSystemReg(word uint32) bool {
 	op := (word >> 20) & 0xFFF // bits [31:20]
-	return op == 0xD53         // MRS has L=1, so 0xD53 instead of 0xD51
+	return op == 0xD53 || op == 0xD51
 }
 
-// decodeSystemReg decodes system register instructions (MRS).
-// MRS format: 1101010100 | 1 | S:S:imm4:CRn:CRm:imm3 | Rt
+// decodeSystemReg decodes system register instructions (MRS, MSR).
+// Format: 1101010100 | L | 1 | S:S:imm4:CRn:CRm:imm3 | Rt
+// L[21]: 1=MRS (Rt is the destination), 0=MSR (Rt is the source)
 func (d *Decoder) decodeSystemReg(word uint32, inst *Instruction) {
 	inst.Format = FormatSystemReg
-	inst.Op = OpMRS
-	inst.Is64Bit = true // MRS always operates on 64-bit X registers
+	inst.Is64Bit = true // MRS/MSR always operate on 64-bit X registers
 
 	// Extract fields
-	rt := word & 0x1F              // bits [4:0] - destination register
+	l := (word >> 21) & 0x1        // bit 21
+	rt := word & 0x1F              // bits [4:0]
 	sysreg := (word >> 5) & 0x7FFF // bits [19:5] - system register encoding
 
-	inst.Rd = uint8(rt)
 	inst.SysReg = uint16(sysreg)
+
+	if l == 1 {
+		inst.Op = OpMRS
+		inst.Rd = uint8(rt)
+	} else {
+		inst.Op = OpMSR
+		inst.Rn = uint8(rt)
+		inst.Rd = 31 // No destination register
+		// MSR NZCV writes the condition flags like a flag-setting instruction
+		inst.SetFlags = inst.SysReg == SysRegNZCV
+	}
+}
+
+// System register encodings (o0:op1:CRn:CRm:op2) that decoding and the
+// timing models depend on.
+const (
+	SysRegNZCV   uint16 = 0x5A10 // NZCV
+	SysRegCNTPCT uint16 = 0x5F01 // CNTPCT_EL0
+	SysRegCNTVCT uint16 = 0x5F02 // CNTVCT_EL0
+)
+
+// Cache maintenance operations of DC and IC by their op1:CRn:CRm:op2
+// encoding, the ones available at EL0.
+const (
+	DCZVA   uint16 = 0x1BA1 // Zero a block of memory
+	DCCVAC  uint16 = 0x1BD1 // Clean to the point of coherency
+	DCCVAU  uint16 = 0x1BD9 // Clean to the point of unification
+	DCCVAP  uint16 = 0x1BE1 // Clean to the point of persistence
+	DCCVADP uint16 = 0x1BE9 // Clean to the point of deep persistence
+	DCCIVAC uint16 = 0x1BF1 // Clean and invalidate to the point of coherency
+	ICIVAU  uint16 = 0x1BA9 // Invalidate instruction cache to the point of unification
+)
+
+// isCacheMaint checks for the DC and IC cache maintenance instructions.
+// Pattern: 1101010100 | 0 | 01 | op1 | CRn | CRm | op2 | Rt
+func (d *Decoder) isCacheMaint(word uint32) bool {
+	return word&0xFFF80000 == 0xD5080000
+}
+
+// decodeCacheMaint decodes DC and IC by virtual address. The operation
+// op1:CRn:CRm:op2 is stored in SysReg and the address register Rt in Rn.
+// Other SYS operations, which are not available at EL0, stay unknown.
+func (d *Decoder) decodeCacheMaint(word uint32, inst *Instruction) {
+	inst.Format = FormatSystemReg
+	inst.Is64Bit = true
+	inst.SysReg = uint16((word >> 5) & 0x3FFF) // bits [18:5]
+	inst.Rn = uint8(word & 0x1F)
+	inst.Rd = 31
+
+	switch inst.SysReg {
+	case DCZVA, DCCVAC, DCCVAU, DCCVAP, DCCVADP, DCCIVAC:
+		inst.Op = OpDC
+	case ICIVAU:
+		inst.Op = OpIC
+	}
 }
@@ -0,0 +1,181 @@
+package insts
+
+// isLoadStoreExclusive checks for the load/store exclusive class, which also
+// holds the ARMv8.1 compare-and-swap instructions.
+// Format: size | 001000 | o2 | L | o1 | Rs | o0 | Rt2 | Rn | Rt
+func (d *Decoder) isLoadStoreExclusive(word uint32) bool {
+	return (word>>24)&0x3F == 0b001000
+}
+
+// decodeLoadStoreExclusive decodes LDXR/STXR/LDXP/STXP (with their acquire
+// and release variants), LDAR/STLR, CAS and CASP.
+// size[31:30]: access size (00=byte ... 11=doubleword)
+// o2[23], o1[21]: 00=exclusive register, 01=exclusive pair or CASP,
+// 10=load-acquire/store-release, 11=CAS
+// L[22]: 1=load; acquire for CAS/CASP
+// o0[15]: acquire for exclusive loads, release for stores and CAS/CASP
+// Rt is decoded into Rd and Rs into Rm.
+func (d *Decoder) decodeLoadStoreExclusive(word uint32, inst *Instruction) {
+	size := (word >> 30) & 0x3 // bits [31:30]
+	o2 := (word >> 23) & 0x1   // bit 23
+	l := (word >> 22) & 0x1    // bit 22
+	o1 := (word >> 21) & 0x1   // bit 21
+	rs := (word >> 16) & 0x1F  // bits [20:16]
+	o0 := (word >> 15) & 0x1   // bit 15
+	rt2 := (word >> 10) & 0x1F // bits [14:10]
+	rn := (word >> 5) & 0x1F   // bits [9:5]
+	rt := word & 0x1F          // bits [4:0]
+
+	inst.Rd = uint8(rt)
+	inst.Rn = uint8(rn)
+	inst.Rm = uint8(rs)
+	inst.Rt2 = uint8(rt2)
+	inst.AccessSize = 1 << size
+	inst.Is64Bit = size == 0b11
+
+	switch {
+	case o2 == 0 && o1 == 0:
+		if l == 1 {
+			inst.Op = OpLDXR
+			inst.Acquire = o0 == 1
+			inst.Rm = 31 // Rs is unused
+		} else {
+			inst.Op = OpSTXR
+			inst.Release = o0 == 1
+		}
+	case o2 == 0 && o1 == 1 && size >= 0b10:
+		inst.AccessSize = 4 << (size & 0x1)
+		if l == 1 {
+			inst.Op = OpLDXP
+			inst.Acquire = o0 == 1
+			inst.Rm = 31
+		} else {
+			inst.Op = OpSTXP
+			inst.Release = o0 == 1
+		}
+	case o2 == 0 && o1 == 1:
+		// CASP: size=0 sz; Rs and Rt name even-numbered register pairs
+		if rs&1 == 1 || rt&1 == 1 || rt2 != 0b11111 {
+			return
+		}
+		inst.Op = OpCASP
+		inst.Is64Bit = size&0x1 == 1
+		inst.AccessSize = 4 << (size & 0x1)
+		inst.Rt2 = uint8(rt + 1)
+		inst.Acquire = l == 1
+		inst.Release = o0 == 1
+	case o2 == 1 && o1 == 0:
+		// LDLAR/STLLR (o0=0) order only within a LORegion; without
+		// LORegions they behave as LDAR/STLR.
+		inst.Rm = 31
+		if l == 1 {
+			inst.Op = OpLDAR
+			inst.Acquire = true
+		} else {
+			inst.Op = OpSTLR
+			inst.Release = true
+		}
+	case o2 == 1 && o1 == 1:
+		if rt2 != 0b11111 {
+			return
+		}
+		inst.Op = OpCAS
+		inst.Acquire = l == 1
+		inst.Release = o0 == 1
+	default:
+		return
+	}
+
+	inst.Format = FormatLoadStoreExclusive
+}
+
+// isAtomicMemOp checks for the ARMv8.1 LSE atomic memory operations.
+// Format: size | 111 | V=0 | 00 | A | R | 1 | Rs | o3 | opc | 00 | Rn | Rt
+func (d *Decoder) isAtomicMemOp(word uint32) bool {
+	return word&0x3F200C00 == 0x38200000
+}
+
+// decodeAtomicMemOp decodes LDADD, LDCLR, LDEOR, LDSET, LD{S,U}{MAX,MIN} and
+// SWP in all sizes and ordering variants, and LDAPR, which shares the
+// encoding class. The ST* aliases are the same instructions with Rt=XZR.
+// A[23]: acquire, R[22]: release
+// o3[15]:opc[14:12]: 0xxx=LD<op>, 1000=SWP, 1100=LDAPR
+// Rt is decoded into Rd and Rs into Rm.
+func (d *Decoder) decodeAtomicMemOp(word uint32, inst *Instruction) {
+	size := (word >> 30) & 0x3 // bits [31:30]
+	a := (word >> 23) & 0x1    // bit 23
+	r := (word >> 22) & 0x1    // bit 22
+	rs := (word >> 16) & 0x1F  // bits [20:16]
+	o3 := (word >> 15) & 0x1   // bit 15
+	opc := (word >> 12) & 0x7  // bits [14:12]
+	rn := (word >> 5) & 0x1F   // bits [9:5]
+	rt := word & 0x1F          // bits [4:0]
+
+	switch {
+	case o3 == 0:
+		inst.Op = [8]Op{
+			OpLDADD, OpLDCLR, OpLDEOR, OpLDSET,
+			OpLDSMAX, OpLDSMIN, OpLDUMAX, OpLDUMIN,
+		}[opc]
+	case opc == 0b000:
+		inst.Op = OpSWP
+	case opc == 0b100 && a == 1 && r == 0 && rs == 0b11111:
+		inst.Op = OpLDAPR
+	default:
+		return
+	}
+
+	inst.Format = FormatAtomic
+	inst.Rd = uint8(rt)
+	inst.Rn = uint8(rn)
+	inst.Rm = uint8(rs)
+	inst.AccessSize = 1 << size
+	inst.Is64Bit = size == 0b11
+	inst.Acquire = a == 1
+	inst.Release = r == 1
+}
+
+// isHint checks for the hint instructions other than NOP.
+// Format: 11010101000000110010 | CRm | op2 | 11111
+func (d *Decoder) isHint(word uint32) bool {
+	return word&0xFFFFF01F == 0xD503201F
+}
+
+// decodeHint decodes YIELD, WFE, WFI, SEV, SEVL, CSDB and the other hints.
+// A single PE has nothing to wait for or signal, so they all execute as NOPs.
+// The hint number CRm:op2 is stored in Imm.
+func (d *Decoder) decodeHint(word uint32, inst *Instruction) {
+	inst.Op = OpHINT
+	inst.Format = FormatBarrier
+	inst.Imm = uint64((word >> 5) & 0x7F)
+	inst.Rd = 31
+}
+
+// isBarrier checks for CLREX, DSB, DMB and ISB.
+// Format: 11010101000000110011 | CRm | op2 | 11111
+// op2: 010=CLREX, 100=DSB, 101=DMB, 110=ISB
+func (d *Decoder) isBarrier(word uint32) bool {
+	if word&0xFFFFF01F != 0xD503301F {
+		return false
+	}
+	op2 := (word >> 5) & 0x7
+	return op2 == 0b010 || op2 == 0b100 || op2 == 0b101 || op2 == 0b110
+}
+
+// decodeBarrier decodes CLREX, DSB, DMB and ISB. The CRm option (the
+// shareability domain and access types of DSB/DMB) is stored in Imm.
+func (d *Decoder) decodeBarrier(word uint32, inst *Instruction) {
+	switch (word >> 5) & 0x7 {
+	case 0b010:
+		inst.Op = OpCLREX
+	case 0b100:
+		inst.Op = OpDSB
+	case 0b101:
+		inst.Op = OpDMB
+	case 0b110:
+		inst.Op = OpISB
+	}
+	inst.Format = FormatBarrier
+	inst.Imm = uint64((word >> 8) & 0xF)
+	inst.Rd = 31
+}
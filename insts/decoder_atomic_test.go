@@ -0,0 +1,306 @@
+package insts_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Exclusive and Atomic Decoder", func() {
+	var decoder *insts.Decoder
+
+	BeforeEach(func() {
+		decoder = insts.NewDecoder()
+	})
+
+	Describe("Load/store exclusive", func() {
+		It("should decode LDXR X0, [X1]", func() {
+			inst := decoder.Decode(0xc85f7c20)
+
+			Expect(inst.Op).To(Equal(insts.OpLDXR))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStoreExclusive))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Acquire).To(BeFalse())
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(31)))
+		})
+
+		It("should decode LDAXR W2, [SP]", func() {
+			inst := decoder.Decode(0x885fffe2)
+
+			Expect(inst.Op).To(Equal(insts.OpLDXR))
+			Expect(inst.Is64Bit).To(BeFalse())
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Rn).To(Equal(uint8(31)))
+		})
+
+		It("should decode byte and halfword exclusives", func() {
+			Expect(decoder.Decode(0x085f7c83).AccessSize).To(Equal(uint8(1))) // LDXRB
+			Expect(decoder.Decode(0x485ffc83).AccessSize).To(Equal(uint8(2))) // LDAXRH
+
+			inst := decoder.Decode(0x0801fc62) // STLXRB W1, W2, [X3]
+			Expect(inst.Op).To(Equal(insts.OpSTXR))
+			Expect(inst.AccessSize).To(Equal(uint8(1)))
+			Expect(inst.Release).To(BeTrue())
+		})
+
+		It("should decode STXR W5, X6, [X7]", func() {
+			inst := decoder.Decode(0xc8057ce6)
+
+			Expect(inst.Op).To(Equal(insts.OpSTXR))
+			Expect(inst.Release).To(BeFalse())
+			Expect(inst.Rd).To(Equal(uint8(6)))
+			Expect(inst.Rn).To(Equal(uint8(7)))
+			Expect(inst.Rm).To(Equal(uint8(5))) // status register
+		})
+
+		It("should decode exclusive pairs", func() {
+			inst := decoder.Decode(0xc87f0440) // LDXP X0, X1, [X2]
+			Expect(inst.Op).To(Equal(insts.OpLDXP))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rt2).To(Equal(uint8(1)))
+			Expect(inst.Rn).To(Equal(uint8(2)))
+
+			inst = decoder.Decode(0x88238440) // STLXP W3, W0, W1, [X2]
+			Expect(inst.Op).To(Equal(insts.OpSTXP))
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+			Expect(inst.Release).To(BeTrue())
+			Expect(inst.Rm).To(Equal(uint8(3)))
+		})
+
+		It("should decode CLREX with and without an immediate", func() {
+			Expect(decoder.Decode(0xd5033f5f).Op).To(Equal(insts.OpCLREX))
+
+			inst := decoder.Decode(0xd503335f)
+			Expect(inst.Op).To(Equal(insts.OpCLREX))
+			Expect(inst.Format).To(Equal(insts.FormatBarrier))
+		})
+	})
+
+	Describe("Compare and swap", func() {
+		It("should decode CAS X0, X1, [X2]", func() {
+			inst := decoder.Decode(0xc8a07c41)
+
+			Expect(inst.Op).To(Equal(insts.OpCAS))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStoreExclusive))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Acquire).To(BeFalse())
+			Expect(inst.Release).To(BeFalse())
+			Expect(inst.Rm).To(Equal(uint8(0))) // Rs: compare value
+			Expect(inst.Rd).To(Equal(uint8(1))) // Rt: new value
+			Expect(inst.Rn).To(Equal(uint8(2)))
+		})
+
+		It("should decode the ordering and size variants", func() {
+			inst := decoder.Decode(0x88e0fc41) // CASAL
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Release).To(BeTrue())
+
+			inst = decoder.Decode(0x08e07c41) // CASAB
+			Expect(inst.AccessSize).To(Equal(uint8(1)))
+			Expect(inst.Acquire).To(BeTrue())
+
+			inst = decoder.Decode(0x48a0fc41) // CASLH
+			Expect(inst.AccessSize).To(Equal(uint8(2)))
+			Expect(inst.Release).To(BeTrue())
+		})
+
+		It("should decode CASP with register pairs", func() {
+			inst := decoder.Decode(0x48207c82) // CASP X0, X1, X2, X3, [X4]
+			Expect(inst.Op).To(Equal(insts.OpCASP))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Rm).To(Equal(uint8(0)))
+			Expect(inst.Rd).To(Equal(uint8(2)))
+			Expect(inst.Rt2).To(Equal(uint8(3)))
+
+			inst = decoder.Decode(0x0864ffe6) // CASPAL W4, W5, W6, W7, [SP]
+			Expect(inst.Op).To(Equal(insts.OpCASP))
+			Expect(inst.Is64Bit).To(BeFalse())
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Release).To(BeTrue())
+		})
+
+		It("should reject CASP with an odd register", func() {
+			Expect(decoder.Decode(0x48217c82).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
+	Describe("Atomic memory operations", func() {
+		It("should decode LDADD X0, X1, [X2]", func() {
+			inst := decoder.Decode(0xf8200041)
+
+			Expect(inst.Op).To(Equal(insts.OpLDADD))
+			Expect(inst.Format).To(Equal(insts.FormatAtomic))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Rm).To(Equal(uint8(0))) // Rs: operand
+			Expect(inst.Rd).To(Equal(uint8(1))) // Rt: old value
+			Expect(inst.Rn).To(Equal(uint8(2)))
+		})
+
+		It("should decode each operation", func() {
+			Expect(decoder.Decode(0x38201041).Op).To(Equal(insts.OpLDCLR))
+			Expect(decoder.Decode(0x78202041).Op).To(Equal(insts.OpLDEOR))
+			Expect(decoder.Decode(0xf8a03041).Op).To(Equal(insts.OpLDSET))
+			Expect(decoder.Decode(0xf8604041).Op).To(Equal(insts.OpLDSMAX))
+			Expect(decoder.Decode(0xb8205041).Op).To(Equal(insts.OpLDSMIN))
+			Expect(decoder.Decode(0xb8206041).Op).To(Equal(insts.OpLDUMAX))
+			Expect(decoder.Decode(0xf8e07041).Op).To(Equal(insts.OpLDUMIN))
+			Expect(decoder.Decode(0xf8208041).Op).To(Equal(insts.OpSWP))
+		})
+
+		It("should decode acquire and release bits", func() {
+			inst := decoder.Decode(0xb8e00041) // LDADDAL W0, W1, [X2]
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Release).To(BeTrue())
+			Expect(inst.Is64Bit).To(BeFalse())
+
+			inst = decoder.Decode(0xf8a03041) // LDSETA
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Release).To(BeFalse())
+
+			inst = decoder.Decode(0xf8604041) // LDSMAXL
+			Expect(inst.Acquire).To(BeFalse())
+			Expect(inst.Release).To(BeTrue())
+		})
+
+		It("should decode byte and halfword sizes", func() {
+			Expect(decoder.Decode(0x38208041).AccessSize).To(Equal(uint8(1))) // SWPB
+			Expect(decoder.Decode(0x78202041).AccessSize).To(Equal(uint8(2))) // LDEORH
+		})
+
+		It("should decode the STADD alias", func() {
+			inst := decoder.Decode(0xf820005f)
+
+			Expect(inst.Op).To(Equal(insts.OpLDADD))
+			Expect(inst.Rd).To(Equal(uint8(31)))
+		})
+	})
+
+	Describe("Load-acquire and store-release", func() {
+		It("should decode LDAR X0, [X1]", func() {
+			inst := decoder.Decode(0xc8dffc20)
+
+			Expect(inst.Op).To(Equal(insts.OpLDAR))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStoreExclusive))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Release).To(BeFalse())
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(31)))
+		})
+
+		It("should decode STLR X5, [X6]", func() {
+			inst := decoder.Decode(0xc89ffcc5)
+
+			Expect(inst.Op).To(Equal(insts.OpSTLR))
+			Expect(inst.Release).To(BeTrue())
+			Expect(inst.Acquire).To(BeFalse())
+			Expect(inst.Rd).To(Equal(uint8(5)))
+			Expect(inst.Rn).To(Equal(uint8(6)))
+		})
+
+		It("should decode byte, halfword and word sizes", func() {
+			inst := decoder.Decode(0x08dfffe2) // LDARB W2, [SP]
+			Expect(inst.Op).To(Equal(insts.OpLDAR))
+			Expect(inst.AccessSize).To(Equal(uint8(1)))
+			Expect(inst.Rn).To(Equal(uint8(31)))
+
+			Expect(decoder.Decode(0x48dffc83).AccessSize).To(Equal(uint8(2))) // LDARH
+			Expect(decoder.Decode(0x089ffcc5).AccessSize).To(Equal(uint8(1))) // STLRB
+			Expect(decoder.Decode(0x489ffcc5).AccessSize).To(Equal(uint8(2))) // STLRH
+
+			inst = decoder.Decode(0x889ffcc5) // STLR W5, [X6]
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+			Expect(inst.Is64Bit).To(BeFalse())
+		})
+
+		It("should decode LDLAR as LDAR", func() {
+			Expect(decoder.Decode(0xc8df7c20).Op).To(Equal(insts.OpLDAR))
+		})
+
+		It("should decode LDAPR in all sizes", func() {
+			inst := decoder.Decode(0xf8bfc020) // LDAPR X0, [X1]
+			Expect(inst.Op).To(Equal(insts.OpLDAPR))
+			Expect(inst.Format).To(Equal(insts.FormatAtomic))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Acquire).To(BeTrue())
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(31)))
+
+			Expect(decoder.Decode(0x38bfc020).AccessSize).To(Equal(uint8(1))) // LDAPRB
+			Expect(decoder.Decode(0x78bfc020).AccessSize).To(Equal(uint8(2))) // LDAPRH
+			Expect(decoder.Decode(0xb8bfc020).AccessSize).To(Equal(uint8(4))) // LDAPR W0
+		})
+	})
+
+	Describe("Barriers and hints", func() {
+		It("should decode DMB, DSB and ISB with their options", func() {
+			inst := decoder.Decode(0xd5033bbf) // DMB ISH
+			Expect(inst.Op).To(Equal(insts.OpDMB))
+			Expect(inst.Format).To(Equal(insts.FormatBarrier))
+			Expect(inst.Imm).To(Equal(uint64(0b1011)))
+
+			Expect(decoder.Decode(0xd5033abf).Imm).To(Equal(uint64(0b1010))) // DMB ISHST
+			Expect(decoder.Decode(0xd50339bf).Imm).To(Equal(uint64(0b1001))) // DMB ISHLD
+
+			inst = decoder.Decode(0xd5033f9f) // DSB SY
+			Expect(inst.Op).To(Equal(insts.OpDSB))
+			Expect(inst.Imm).To(Equal(uint64(0b1111)))
+
+			inst = decoder.Decode(0xd5033fdf) // ISB
+			Expect(inst.Op).To(Equal(insts.OpISB))
+			Expect(inst.Format).To(Equal(insts.FormatBarrier))
+		})
+
+		It("should decode hints with their number", func() {
+			inst := decoder.Decode(0xd503203f) // YIELD
+			Expect(inst.Op).To(Equal(insts.OpHINT))
+			Expect(inst.Format).To(Equal(insts.FormatBarrier))
+			Expect(inst.Imm).To(Equal(uint64(1)))
+
+			Expect(decoder.Decode(0xd503205f).Imm).To(Equal(uint64(2)))  // WFE
+			Expect(decoder.Decode(0xd50320bf).Imm).To(Equal(uint64(5)))  // SEVL
+			Expect(decoder.Decode(0xd503229f).Imm).To(Equal(uint64(20))) // CSDB
+		})
+
+		It("should still decode NOP as NOP", func() {
+			Expect(decoder.Decode(0xd503201f).Op).To(Equal(insts.OpNOP))
+		})
+
+		It("should decode cache maintenance by address", func() {
+			inst := decoder.Decode(0xd50b7420) // DC ZVA, X0
+			Expect(inst.Op).To(Equal(insts.OpDC))
+			Expect(inst.Format).To(Equal(insts.FormatSystemReg))
+			Expect(inst.SysReg).To(Equal(insts.DCZVA))
+			Expect(inst.Rn).To(Equal(uint8(0)))
+			Expect(inst.Rd).To(Equal(uint8(31)))
+
+			inst = decoder.Decode(0xd50b7b21) // DC CVAU, X1
+			Expect(inst.Op).To(Equal(insts.OpDC))
+			Expect(inst.SysReg).To(Equal(insts.DCCVAU))
+
+			inst = decoder.Decode(0xd50b7522) // IC IVAU, X2
+			Expect(inst.Op).To(Equal(insts.OpIC))
+			Expect(inst.SysReg).To(Equal(insts.ICIVAU))
+			Expect(inst.Rn).To(Equal(uint8(2)))
+
+			Expect(decoder.Decode(0xd508871f).Op).To(Equal(insts.OpUnknown)) // TLBI VMALLE1
+			Expect(decoder.Decode(0xd5087800).Op).To(Equal(insts.OpUnknown)) // AT S1E1R, X0
+			Expect(decoder.Decode(0xd5087620).Op).To(Equal(insts.OpUnknown)) // DC IVAC, X0
+		})
+
+		It("should mark MSR NZCV as setting the flags", func() {
+			Expect(decoder.Decode(0xd51b4205).SetFlags).To(BeTrue())  // MSR NZCV, X5
+			Expect(decoder.Decode(0xd51bd046).SetFlags).To(BeFalse()) // MSR TPIDR_EL0, X6
+		})
+	})
+})
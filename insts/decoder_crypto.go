@@ -0,0 +1,184 @@
+package insts
+
+// isCryptoAES checks for the cryptographic AES instructions.
+// Format: 01001110 | size | 10100 | opcode | 10 | Rn | Rd
+func (d *Decoder) isCryptoAES(word uint32) bool {
+	return word&0xFF3E0C00 == 0x4E280800
+}
+
+// decodeCryptoAES decodes AESE, AESD, AESMC and AESIMC.
+// size[23:22] must be 00
+// opcode[16:12]: 00100=AESE, 00101=AESD, 00110=AESMC, 00111=AESIMC
+func (d *Decoder) decodeCryptoAES(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoTwoReg
+	inst.IsSIMD = true
+
+	size := (word >> 22) & 0x3    // bits [23:22]
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Arrangement = Arr16B
+	if size != 0 || opcode < 0b00100 || opcode > 0b00111 {
+		return
+	}
+	inst.Op = [4]Op{OpAESE, OpAESD, OpAESMC, OpAESIMC}[opcode-0b00100]
+}
+
+// isCryptoThreeRegSHA checks for the cryptographic three-register SHA
+// instructions.
+// Format: 01011110 | size | 0 | Rm | 0 | opcode | 00 | Rn | Rd
+func (d *Decoder) isCryptoThreeRegSHA(word uint32) bool {
+	return word&0xFF208C00 == 0x5E000000
+}
+
+// cryptoThreeRegSHAOps maps the three-register SHA opcodes to their
+// operations.
+var cryptoThreeRegSHAOps = [8]Op{
+	OpSHA1C, OpSHA1P, OpSHA1M, OpSHA1SU0, OpSHA256H, OpSHA256H2, OpSHA256SU1, OpUnknown,
+}
+
+// decodeCryptoThreeRegSHA decodes SHA1C, SHA1P, SHA1M, SHA1SU0, SHA256H,
+// SHA256H2 and SHA256SU1.
+// size[23:22] must be 00
+// opcode[14:12]: 000=SHA1C, 001=SHA1P, 010=SHA1M, 011=SHA1SU0,
+// 100=SHA256H, 101=SHA256H2, 110=SHA256SU1
+func (d *Decoder) decodeCryptoThreeRegSHA(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoThreeReg
+	inst.IsSIMD = true
+
+	size := (word >> 22) & 0x3   // bits [23:22]
+	opcode := (word >> 12) & 0x7 // bits [14:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Arrangement = Arr4S
+	if size == 0 {
+		inst.Op = cryptoThreeRegSHAOps[opcode]
+	}
+}
+
+// isCryptoTwoRegSHA checks for the cryptographic two-register SHA
+// instructions.
+// Format: 01011110 | size | 10100 | opcode | 10 | Rn | Rd
+func (d *Decoder) isCryptoTwoRegSHA(word uint32) bool {
+	return word&0xFF3E0C00 == 0x5E280800
+}
+
+// decodeCryptoTwoRegSHA decodes SHA1H, SHA1SU1 and SHA256SU0.
+// size[23:22] must be 00
+// opcode[16:12]: 00000=SHA1H, 00001=SHA1SU1, 00010=SHA256SU0
+func (d *Decoder) decodeCryptoTwoRegSHA(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoTwoReg
+	inst.IsSIMD = true
+
+	size := (word >> 22) & 0x3    // bits [23:22]
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Arrangement = Arr4S
+	if size != 0 || opcode > 0b00010 {
+		return
+	}
+	inst.Op = [3]Op{OpSHA1H, OpSHA1SU1, OpSHA256SU0}[opcode]
+}
+
+// isCryptoThreeRegSHA512 checks for the cryptographic three-register
+// SHA512 instructions.
+// Format: 11001110011 | Rm | 1 | O | 00 | opcode | Rn | Rd
+func (d *Decoder) isCryptoThreeRegSHA512(word uint32) bool {
+	return word&0xFFE0B000 == 0xCE608000
+}
+
+// decodeCryptoThreeRegSHA512 decodes SHA512H, SHA512H2, SHA512SU1 and
+// RAX1.
+// O[14] must be 0 (1 selects the SM3 instructions)
+// opcode[11:10]: 00=SHA512H, 01=SHA512H2, 10=SHA512SU1, 11=RAX1
+func (d *Decoder) decodeCryptoThreeRegSHA512(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoThreeReg
+	inst.IsSIMD = true
+
+	o := (word >> 14) & 0x1      // bit 14
+	opcode := (word >> 10) & 0x3 // bits [11:10]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Arrangement = Arr2D
+	if o == 0 {
+		inst.Op = [4]Op{OpSHA512H, OpSHA512H2, OpSHA512SU1, OpRAX1}[opcode]
+	}
+}
+
+// isCryptoTwoRegSHA512 checks for the cryptographic two-register SHA512
+// instructions.
+// Format: 11001110110000001000 | opcode | Rn | Rd
+func (d *Decoder) isCryptoTwoRegSHA512(word uint32) bool {
+	return word&0xFFFFF000 == 0xCEC08000
+}
+
+// decodeCryptoTwoRegSHA512 decodes SHA512SU0.
+// opcode[11:10]: 00=SHA512SU0 (01 is SM4E)
+func (d *Decoder) decodeCryptoTwoRegSHA512(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoTwoReg
+	inst.IsSIMD = true
+
+	opcode := (word >> 10) & 0x3 // bits [11:10]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Arrangement = Arr2D
+	if opcode == 0 {
+		inst.Op = OpSHA512SU0
+	}
+}
+
+// isCryptoFourReg checks for the cryptographic four-register instructions.
+// Format: 110011100 | Op0 | Rm | 0 | Ra | Rn | Rd
+func (d *Decoder) isCryptoFourReg(word uint32) bool {
+	return word&0xFF808000 == 0xCE000000
+}
+
+// decodeCryptoFourReg decodes EOR3 and BCAX.
+// Op0[22:21]: 00=EOR3, 01=BCAX (10 is SM3SS1)
+// Ra is stored in Rt2.
+func (d *Decoder) decodeCryptoFourReg(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoFourReg
+	inst.IsSIMD = true
+
+	op0 := (word >> 21) & 0x3 // bits [22:21]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rt2 = uint8((word >> 10) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Arrangement = Arr16B
+	switch op0 {
+	case 0b00:
+		inst.Op = OpEOR3
+	case 0b01:
+		inst.Op = OpBCAX
+	}
+}
+
+// isCryptoXAR checks for XAR.
+// Format: 11001110100 | Rm | imm6 | Rn | Rd
+func (d *Decoder) isCryptoXAR(word uint32) bool {
+	return word&0xFFE00000 == 0xCE800000
+}
+
+// decodeCryptoXAR decodes XAR, whose rotate amount imm6[15:10] is stored
+// in Imm.
+func (d *Decoder) decodeCryptoXAR(word uint32, inst *Instruction) {
+	inst.Format = FormatCryptoFourReg
+	inst.IsSIMD = true
+	inst.Op = OpXAR
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Imm = uint64((word >> 10) & 0x3F)
+	inst.Arrangement = Arr2D
+}
@@ -0,0 +1,306 @@
+package insts
+
+// isFPConvert checks for conversions between floating-point and integer or
+// fixed-point values (SCVTF, UCVTF, FCVT*S, FCVT*U, FMOV general).
+// Format: sf | 0 | S | 11110 | ftype | 1 | rmode | opcode | 000000 | Rn | Rd
+// Fixed-point form: sf | 0 | S | 11110 | ftype | 0 | rmode | opcode | scale | Rn | Rd
+func (d *Decoder) isFPConvert(word uint32) bool {
+	op := (word >> 24) & 0x7F // bits [30:24]
+	bit21 := (word >> 21) & 0x1
+	bits1510 := (word >> 10) & 0x3F // bits [15:10]
+	return op == 0b0011110 && (bit21 == 0 || bits1510 == 0)
+}
+
+// decodeFPConvert decodes floating-point/integer conversions.
+// sf[31]: 0=W register, 1=X register
+// ftype[23:22]: 00=single, 01=double, 11=half (10 with FMOV top half)
+// rmode[20:19] and opcode[18:16] select the operation
+// scale[15:10]: fixed-point forms have 64-scale fraction bits
+func (d *Decoder) decodeFPConvert(word uint32, inst *Instruction) {
+	inst.Format = FormatFPConvert
+
+	sf := (word >> 31) & 0x1     // bit 31
+	ftype := (word >> 22) & 0x3  // bits [23:22]
+	fixed := (word>>21)&0x1 == 0 // bit 21: 0=fixed-point
+	rmode := (word >> 19) & 0x3  // bits [20:19]
+	opcode := (word >> 16) & 0x7 // bits [18:16]
+	scale := (word >> 10) & 0x3F // bits [15:10]
+	rn := (word >> 5) & 0x1F     // bits [9:5]
+	rd := word & 0x1F            // bits [4:0]
+	inst.Is64Bit = sf == 1
+	inst.FPType = FPType(ftype)
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+
+	// FMOV Xd, Vn.D[1] / FMOV Vd.D[1], Xn use ftype=10, rmode=01
+	if ftype == 0b10 {
+		if !fixed && sf == 1 && rmode == 0b01 && opcode&0x6 == 0b110 {
+			inst.FPType = FPDouble
+			inst.Imm = 1 // Lane of the vector register
+			d.decodeFMOVGeneral(opcode, inst)
+		}
+		return
+	}
+
+	if fixed {
+		// Fixed-point conversions; 32-bit forms need scale >= 32
+		if sf == 0 && scale < 32 {
+			return
+		}
+		inst.Imm = uint64(64 - scale) // Number of fraction bits
+		switch {
+		case rmode == 0b00 && opcode == 0b010:
+			inst.Op = OpSCVTF
+		case rmode == 0b00 && opcode == 0b011:
+			inst.Op = OpUCVTF
+		case rmode == 0b11 && opcode == 0b000:
+			inst.Op = OpFCVTZS
+		case rmode == 0b11 && opcode == 0b001:
+			inst.Op = OpFCVTZU
+		}
+		return
+	}
+
+	switch opcode {
+	case 0b000, 0b001:
+		// FCVT{N,P,M,Z}{S,U}: rmode selects the rounding
+		signed := [4]Op{OpFCVTNS, OpFCVTPS, OpFCVTMS, OpFCVTZS}
+		unsigned := [4]Op{OpFCVTNU, OpFCVTPU, OpFCVTMU, OpFCVTZU}
+		if opcode == 0b000 {
+			inst.Op = signed[rmode]
+		} else {
+			inst.Op = unsigned[rmode]
+		}
+	case 0b010, 0b011, 0b100, 0b101:
+		if rmode != 0b00 {
+			return
+		}
+		inst.Op = [4]Op{OpSCVTF, OpUCVTF, OpFCVTAS, OpFCVTAU}[opcode-0b010]
+	case 0b110, 0b111:
+		// FMOV (general): W<->S, X<->D, W/X<->H
+		valid := (sf == 0 && ftype == 0b00) || (sf == 1 && ftype == 0b01) || ftype == 0b11
+		if rmode == 0b00 && valid {
+			d.decodeFMOVGeneral(opcode, inst)
+		}
+	}
+}
+
+// decodeFMOVGeneral sets the direction of an FMOV between a general register
+// and an FP register. opcode[0]: 0=to general register, 1=from general register.
+func (d *Decoder) decodeFMOVGeneral(opcode uint32, inst *Instruction) {
+	if opcode&0x1 == 0 {
+		inst.Op = OpFMOVToGP
+	} else {
+		inst.Op = OpFMOVFromGP
+	}
+}
+
+// isFPDataProc checks for scalar floating-point data processing with one or
+// two sources, compares, conditional compares, selects and immediates.
+// Format: 0 | 0 | 0 | 11110 | ftype | 1 | ... | Rn | Rd
+func (d *Decoder) isFPDataProc(word uint32) bool {
+	op := (word >> 24) & 0xFF // bits [31:24]
+	bit21 := (word >> 21) & 0x1
+	return op == 0b00011110 && bit21 == 1
+}
+
+// decodeFPDataProc decodes scalar floating-point data processing.
+// The group is selected by the low bits above Rn:
+// bits [11:10] = 01: FCCMP/FCCMPE    bits [11:10] = 10: two-source
+// bits [11:10] = 11: FCSEL           bits [12:10] = 100: FMOV (immediate)
+// bits [13:10] = 1000: FCMP/FCMPE    bits [14:10] = 10000: one-source
+func (d *Decoder) decodeFPDataProc(word uint32, inst *Instruction) {
+	inst.Format = FormatFPDataProc
+
+	ftype := (word >> 22) & 0x3 // bits [23:22]
+	rm := (word >> 16) & 0x1F   // bits [20:16]
+	rn := (word >> 5) & 0x1F    // bits [9:5]
+	rd := word & 0x1F           // bits [4:0]
+
+	if ftype == 0b10 {
+		return // Unallocated precision
+	}
+
+	inst.FPType = FPType(ftype)
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+	inst.Rm = uint8(rm)
+
+	switch {
+	case (word>>10)&0x3 == 0b01:
+		d.decodeFPCondCmp(word, inst)
+	case (word>>10)&0x3 == 0b10:
+		d.decodeFPDataProc2Src(word, inst)
+	case (word>>10)&0x3 == 0b11:
+		// FCSEL: cond[15:12]
+		inst.Op = OpFCSEL
+		inst.Cond = Cond((word >> 12) & 0xF)
+	case (word>>10)&0x7 == 0b100:
+		d.decodeFPImm(word, inst)
+	case (word>>10)&0xF == 0b1000:
+		d.decodeFPCompare(word, inst)
+	case (word>>10)&0x1F == 0b10000:
+		d.decodeFPDataProc1Src(word, inst)
+	}
+}
+
+// decodeFPDataProc1Src decodes FMOV (register), FABS, FNEG, FSQRT, FCVT and
+// FRINT*.
+// opcode[20:15]: 000000=FMOV, 000001=FABS, 000010=FNEG, 000011=FSQRT,
+// 0001xx=FCVT to the precision given by xx, 001xxx=FRINT with the rounding
+// given by xxx
+func (d *Decoder) decodeFPDataProc1Src(word uint32, inst *Instruction) {
+	opcode := (word >> 15) & 0x3F // bits [20:15]
+
+	if opcode&0x38 == 0b001000 {
+		inst.Op = [8]Op{OpFRINTN, OpFRINTP, OpFRINTM, OpFRINTZ,
+			OpFRINTA, OpUnknown, OpFRINTX, OpFRINTI}[opcode&0x7]
+		return
+	}
+
+	switch opcode {
+	case 0b000000:
+		inst.Op = OpFMOV
+	case 0b000001:
+		inst.Op = OpFABS
+	case 0b000010:
+		inst.Op = OpFNEG
+	case 0b000011:
+		inst.Op = OpFSQRT
+	case 0b000100, 0b000101, 0b000111:
+		dst := FPType(opcode & 0x3)
+		if dst != inst.FPType {
+			inst.Op = OpFCVT
+			inst.FPDstType = dst
+		}
+	}
+}
+
+// decodeFPDataProc2Src decodes two-source arithmetic.
+// opcode[15:12]: 0000=FMUL, 0001=FDIV, 0010=FADD, 0011=FSUB, 0100=FMAX,
+// 0101=FMIN, 0110=FMAXNM, 0111=FMINNM, 1000=FNMUL
+func (d *Decoder) decodeFPDataProc2Src(word uint32, inst *Instruction) {
+	opcode := (word >> 12) & 0xF // bits [15:12]
+
+	if opcode <= 0b1000 {
+		inst.Op = [9]Op{OpFMUL, OpFDIV, OpFADD, OpFSUB,
+			OpFMAX, OpFMIN, OpFMAXNM, OpFMINNM, OpFNMUL}[opcode]
+	}
+}
+
+// decodeFPCompare decodes FCMP and FCMPE.
+// op[15:14] must be 00; opcode2[4:0]: bit 3 = compare with zero,
+// bit 4 = signaling (FCMPE), bits [2:0] must be 000
+func (d *Decoder) decodeFPCompare(word uint32, inst *Instruction) {
+	op := (word >> 14) & 0x3 // bits [15:14]
+	opcode2 := word & 0x1F   // bits [4:0]
+
+	if op != 0 || opcode2&0x7 != 0 {
+		return
+	}
+
+	inst.Rd = 31 // No destination register
+	inst.SetFlags = true
+	if opcode2&0x8 != 0 {
+		inst.Rm = 0xFF // Compare with #0.0
+	}
+	if opcode2&0x10 == 0 {
+		inst.Op = OpFCMP
+	} else {
+		inst.Op = OpFCMPE
+	}
+}
+
+// decodeFPCondCmp decodes FCCMP and FCCMPE.
+// Format: ... | Rm | cond | 01 | Rn | op | nzcv
+// op[4]: 0=FCCMP, 1=FCCMPE; nzcv[3:0] is stored in Imm
+func (d *Decoder) decodeFPCondCmp(word uint32, inst *Instruction) {
+	inst.Rd = 31 // No destination register
+	inst.SetFlags = true
+	inst.Cond = Cond((word >> 12) & 0xF)
+	inst.Imm = uint64(word & 0xF)
+
+	if (word>>4)&0x1 == 0 {
+		inst.Op = OpFCCMP
+	} else {
+		inst.Op = OpFCCMPE
+	}
+}
+
+// decodeFPImm decodes FMOV (scalar, immediate).
+// Format: ... | imm8 | 100 | imm5 | Rd, imm5[9:5] must be 00000.
+// Imm holds the expanded bit pattern of the immediate in the operand precision.
+func (d *Decoder) decodeFPImm(word uint32, inst *Instruction) {
+	imm8 := (word >> 13) & 0xFF // bits [20:13]
+	imm5 := (word >> 5) & 0x1F  // bits [9:5]
+
+	if imm5 != 0 {
+		return
+	}
+
+	inst.Op = OpFMOVImm
+	inst.Rn = 0
+	inst.Rm = 0
+	inst.Imm = ExpandFPImm(uint8(imm8), inst.FPType)
+}
+
+// ExpandFPImm expands the 8-bit FMOV immediate (sign, 3-bit exponent,
+// 4-bit fraction) to the bit pattern of a value in the given precision.
+func ExpandFPImm(imm8 uint8, t FPType) uint64 {
+	var expBits, fracBits uint
+	switch t {
+	case FPHalf:
+		expBits, fracBits = 5, 10
+	case FPSingle:
+		expBits, fracBits = 8, 23
+	default:
+		expBits, fracBits = 11, 52
+	}
+
+	sign := uint64(imm8>>7) & 0x1
+	b6 := uint64(imm8>>6) & 0x1
+
+	// exp = NOT(b6) : Replicate(b6, expBits-3) : imm8<5:4>
+	exp := (b6 ^ 1) << (expBits - 1)
+	if b6 == 1 {
+		exp |= ((uint64(1) << (expBits - 3)) - 1) << 2
+	}
+	exp |= uint64(imm8>>4) & 0x3
+
+	frac := (uint64(imm8) & 0xF) << (fracBits - 4)
+
+	return sign<<(expBits+fracBits) | exp<<fracBits | frac
+}
+
+// isFPDataProc3Src checks for fused multiply-add instructions.
+// Format: 0 | 0 | 0 | 11111 | ftype | o1 | Rm | o0 | Ra | Rn | Rd
+func (d *Decoder) isFPDataProc3Src(word uint32) bool {
+	op := (word >> 24) & 0xFF // bits [31:24]
+	return op == 0b00011111
+}
+
+// decodeFPDataProc3Src decodes FMADD, FMSUB, FNMADD and FNMSUB.
+// o1[21]:o0[15]: 00=FMADD, 01=FMSUB, 10=FNMADD, 11=FNMSUB
+func (d *Decoder) decodeFPDataProc3Src(word uint32, inst *Instruction) {
+	inst.Format = FormatFPDataProc
+
+	ftype := (word >> 22) & 0x3 // bits [23:22]
+	o1 := (word >> 21) & 0x1    // bit 21
+	rm := (word >> 16) & 0x1F   // bits [20:16]
+	o0 := (word >> 15) & 0x1    // bit 15
+	ra := (word >> 10) & 0x1F   // bits [14:10]
+	rn := (word >> 5) & 0x1F    // bits [9:5]
+	rd := word & 0x1F           // bits [4:0]
+
+	if ftype == 0b10 {
+		return // Unallocated precision
+	}
+
+	inst.FPType = FPType(ftype)
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+	inst.Rm = uint8(rm)
+	inst.Rt2 = uint8(ra) // Reuse Rt2 field for Ra
+
+	inst.Op = [4]Op{OpFMADD, OpFMSUB, OpFNMADD, OpFNMSUB}[o1<<1|o0]
+}
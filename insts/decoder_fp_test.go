@@ -0,0 +1,284 @@
+package insts_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Scalar Floating-Point Decoder", func() {
+	var decoder *insts.Decoder
+
+	BeforeEach(func() {
+		decoder = insts.NewDecoder()
+	})
+
+	Describe("Arithmetic", func() {
+		It("should decode FADD D0, D1, D2", func() {
+			inst := decoder.Decode(0x1e622820)
+
+			Expect(inst.Op).To(Equal(insts.OpFADD))
+			Expect(inst.Format).To(Equal(insts.FormatFPDataProc))
+			Expect(inst.FPType).To(Equal(insts.FPDouble))
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+		})
+
+		It("should decode FSUB S3, S4, S5", func() {
+			inst := decoder.Decode(0x1e253883)
+
+			Expect(inst.Op).To(Equal(insts.OpFSUB))
+			Expect(inst.FPType).To(Equal(insts.FPSingle))
+			Expect(inst.Rd).To(Equal(uint8(3)))
+			Expect(inst.Rn).To(Equal(uint8(4)))
+			Expect(inst.Rm).To(Equal(uint8(5)))
+		})
+
+		It("should decode FMUL H1, H2, H3", func() {
+			inst := decoder.Decode(0x1ee30841)
+
+			Expect(inst.Op).To(Equal(insts.OpFMUL))
+			Expect(inst.FPType).To(Equal(insts.FPHalf))
+		})
+
+		It("should decode FDIV D7, D8, D9", func() {
+			inst := decoder.Decode(0x1e691907)
+
+			Expect(inst.Op).To(Equal(insts.OpFDIV))
+			Expect(inst.Rd).To(Equal(uint8(7)))
+			Expect(inst.Rn).To(Equal(uint8(8)))
+			Expect(inst.Rm).To(Equal(uint8(9)))
+		})
+
+		It("should decode FSQRT, FABS and FNEG", func() {
+			Expect(decoder.Decode(0x1e61c041).Op).To(Equal(insts.OpFSQRT))
+			Expect(decoder.Decode(0x1e20c041).Op).To(Equal(insts.OpFABS))
+
+			inst := decoder.Decode(0x1e614083) // FNEG D3, D4
+			Expect(inst.Op).To(Equal(insts.OpFNEG))
+			Expect(inst.Rd).To(Equal(uint8(3)))
+			Expect(inst.Rn).To(Equal(uint8(4)))
+		})
+
+		It("should decode FNMUL, FMAX, FMIN, FMAXNM and FMINNM", func() {
+			Expect(decoder.Decode(0x1e628820).Op).To(Equal(insts.OpFNMUL))
+			Expect(decoder.Decode(0x1e624820).Op).To(Equal(insts.OpFMAX))
+			Expect(decoder.Decode(0x1e625820).Op).To(Equal(insts.OpFMIN))
+			Expect(decoder.Decode(0x1e626820).Op).To(Equal(insts.OpFMAXNM))
+
+			inst := decoder.Decode(0x1e237841) // FMINNM S1, S2, S3
+			Expect(inst.Op).To(Equal(insts.OpFMINNM))
+			Expect(inst.FPType).To(Equal(insts.FPSingle))
+			Expect(inst.Rm).To(Equal(uint8(3)))
+		})
+
+		It("should decode the FRINT rounding modes", func() {
+			for word, op := range map[uint32]insts.Op{
+				0x1e644020: insts.OpFRINTN,
+				0x1e64c020: insts.OpFRINTP,
+				0x1e654020: insts.OpFRINTM,
+				0x1e65c020: insts.OpFRINTZ,
+				0x1e664020: insts.OpFRINTA,
+				0x1e674020: insts.OpFRINTX,
+				0x1e67c020: insts.OpFRINTI,
+			} {
+				Expect(decoder.Decode(word).Op).To(Equal(op), "%#08x", word)
+			}
+			Expect(decoder.Decode(0x1e66c020).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
+	Describe("Fused multiply-add", func() {
+		It("should decode FMADD D0, D1, D2, D3", func() {
+			inst := decoder.Decode(0x1f420c20)
+
+			Expect(inst.Op).To(Equal(insts.OpFMADD))
+			Expect(inst.Format).To(Equal(insts.FormatFPDataProc))
+			Expect(inst.FPType).To(Equal(insts.FPDouble))
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Rt2).To(Equal(uint8(3))) // Ra
+		})
+
+		It("should decode the negated and subtracting forms", func() {
+			Expect(decoder.Decode(0x1f028c20).Op).To(Equal(insts.OpFMSUB))
+			Expect(decoder.Decode(0x1f661ca4).Op).To(Equal(insts.OpFNMADD))
+
+			inst := decoder.Decode(0x1fe39041) // FNMSUB H1, H2, H3, H4
+			Expect(inst.Op).To(Equal(insts.OpFNMSUB))
+			Expect(inst.FPType).To(Equal(insts.FPHalf))
+		})
+	})
+
+	Describe("Compare and select", func() {
+		It("should decode FCMP D1, D2", func() {
+			inst := decoder.Decode(0x1e622020)
+
+			Expect(inst.Op).To(Equal(insts.OpFCMP))
+			Expect(inst.SetFlags).To(BeTrue())
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Rd).To(Equal(uint8(31)))
+		})
+
+		It("should decode FCMPE S1, #0.0", func() {
+			inst := decoder.Decode(0x1e202038)
+
+			Expect(inst.Op).To(Equal(insts.OpFCMPE))
+			Expect(inst.FPType).To(Equal(insts.FPSingle))
+			Expect(inst.Rm).To(Equal(uint8(0xFF)))
+		})
+
+		It("should decode FCCMP D1, D2, #4, NE", func() {
+			inst := decoder.Decode(0x1e621424)
+
+			Expect(inst.Op).To(Equal(insts.OpFCCMP))
+			Expect(inst.SetFlags).To(BeTrue())
+			Expect(inst.Cond).To(Equal(insts.CondNE))
+			Expect(inst.Imm).To(Equal(uint64(4)))
+		})
+
+		It("should decode FCCMPE S3, S4, #2, LT", func() {
+			inst := decoder.Decode(0x1e24b472)
+
+			Expect(inst.Op).To(Equal(insts.OpFCCMPE))
+			Expect(inst.Cond).To(Equal(insts.CondLT))
+			Expect(inst.Imm).To(Equal(uint64(2)))
+		})
+
+		It("should decode FCSEL D0, D1, D2, GT", func() {
+			inst := decoder.Decode(0x1e62cc20)
+
+			Expect(inst.Op).To(Equal(insts.OpFCSEL))
+			Expect(inst.Cond).To(Equal(insts.CondGT))
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+		})
+	})
+
+	Describe("Moves", func() {
+		It("should decode FMOV D1, D2", func() {
+			inst := decoder.Decode(0x1e604041)
+
+			Expect(inst.Op).To(Equal(insts.OpFMOV))
+			Expect(inst.Rd).To(Equal(uint8(1)))
+			Expect(inst.Rn).To(Equal(uint8(2)))
+		})
+
+		It("should expand FMOV immediates", func() {
+			inst := decoder.Decode(0x1e6e1001) // FMOV D1, #1.0
+			Expect(inst.Op).To(Equal(insts.OpFMOVImm))
+			Expect(inst.Imm).To(Equal(uint64(0x3FF0000000000000)))
+
+			inst = decoder.Decode(0x1e3c1002) // FMOV S2, #-0.5
+			Expect(inst.Imm).To(Equal(uint64(0xBF000000)))
+
+			inst = decoder.Decode(0x1ee01003) // FMOV H3, #2.0
+			Expect(inst.Imm).To(Equal(uint64(0x4000)))
+		})
+
+		It("should decode FMOV between general and FP registers", func() {
+			inst := decoder.Decode(0x9e660020) // FMOV X0, D1
+			Expect(inst.Op).To(Equal(insts.OpFMOVToGP))
+			Expect(inst.Format).To(Equal(insts.FormatFPConvert))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+
+			inst = decoder.Decode(0x1e260020) // FMOV W0, S1
+			Expect(inst.Op).To(Equal(insts.OpFMOVToGP))
+			Expect(inst.Is64Bit).To(BeFalse())
+
+			inst = decoder.Decode(0x9e670001) // FMOV D1, X0
+			Expect(inst.Op).To(Equal(insts.OpFMOVFromGP))
+			Expect(inst.FPType).To(Equal(insts.FPDouble))
+
+			inst = decoder.Decode(0x1ee60083) // FMOV W3, H4
+			Expect(inst.Op).To(Equal(insts.OpFMOVToGP))
+			Expect(inst.FPType).To(Equal(insts.FPHalf))
+		})
+
+		It("should decode FMOV to and from the upper half of a vector", func() {
+			inst := decoder.Decode(0x9eae0041) // FMOV X1, V2.D[1]
+			Expect(inst.Op).To(Equal(insts.OpFMOVToGP))
+			Expect(inst.Imm).To(Equal(uint64(1)))
+
+			inst = decoder.Decode(0x9eaf0022) // FMOV V2.D[1], X1
+			Expect(inst.Op).To(Equal(insts.OpFMOVFromGP))
+			Expect(inst.Imm).To(Equal(uint64(1)))
+			Expect(inst.Rd).To(Equal(uint8(2)))
+		})
+	})
+
+	Describe("Conversions", func() {
+		It("should decode FCVT between precisions", func() {
+			inst := decoder.Decode(0x1e624041) // FCVT S1, D2
+			Expect(inst.Op).To(Equal(insts.OpFCVT))
+			Expect(inst.FPType).To(Equal(insts.FPDouble))
+			Expect(inst.FPDstType).To(Equal(insts.FPSingle))
+
+			inst = decoder.Decode(0x1ee2c041) // FCVT D1, H2
+			Expect(inst.FPType).To(Equal(insts.FPHalf))
+			Expect(inst.FPDstType).To(Equal(insts.FPDouble))
+		})
+
+		It("should decode SCVTF and UCVTF", func() {
+			inst := decoder.Decode(0x9e620020) // SCVTF D0, X1
+			Expect(inst.Op).To(Equal(insts.OpSCVTF))
+			Expect(inst.Format).To(Equal(insts.FormatFPConvert))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.FPType).To(Equal(insts.FPDouble))
+			Expect(inst.Imm).To(Equal(uint64(0)))
+
+			inst = decoder.Decode(0x1e230020) // UCVTF S0, W1
+			Expect(inst.Op).To(Equal(insts.OpUCVTF))
+			Expect(inst.Is64Bit).To(BeFalse())
+
+			inst = decoder.Decode(0x1e42e020) // SCVTF D0, W1, #8
+			Expect(inst.Op).To(Equal(insts.OpSCVTF))
+			Expect(inst.Imm).To(Equal(uint64(8)))
+		})
+
+		It("should decode each FCVT*S/FCVT*U rounding variant", func() {
+			Expect(decoder.Decode(0x9e780020).Op).To(Equal(insts.OpFCVTZS))
+			Expect(decoder.Decode(0x1e390020).Op).To(Equal(insts.OpFCVTZU))
+			Expect(decoder.Decode(0x9e600020).Op).To(Equal(insts.OpFCVTNS))
+			Expect(decoder.Decode(0x9e690020).Op).To(Equal(insts.OpFCVTPU))
+			Expect(decoder.Decode(0x1e300020).Op).To(Equal(insts.OpFCVTMS))
+			Expect(decoder.Decode(0x9e640020).Op).To(Equal(insts.OpFCVTAS))
+			Expect(decoder.Decode(0x1ee50020).Op).To(Equal(insts.OpFCVTAU))
+		})
+
+		It("should decode fixed-point FCVTZS W0, D1, #16", func() {
+			inst := decoder.Decode(0x1e58c020)
+
+			Expect(inst.Op).To(Equal(insts.OpFCVTZS))
+			Expect(inst.Is64Bit).To(BeFalse())
+			Expect(inst.Imm).To(Equal(uint64(16)))
+		})
+	})
+
+	Describe("FP system registers", func() {
+		It("should decode MSR FPCR, X0", func() {
+			inst := decoder.Decode(0xd51b4400)
+
+			Expect(inst.Op).To(Equal(insts.OpMSR))
+			Expect(inst.Format).To(Equal(insts.FormatSystemReg))
+			Expect(inst.SysReg).To(Equal(uint16(0x5A20)))
+			Expect(inst.Rn).To(Equal(uint8(0)))
+			Expect(inst.Rd).To(Equal(uint8(31)))
+		})
+
+		It("should decode MRS X1, FPSR", func() {
+			inst := decoder.Decode(0xd53b4421)
+
+			Expect(inst.Op).To(Equal(insts.OpMRS))
+			Expect(inst.SysReg).To(Equal(uint16(0x5A21)))
+			Expect(inst.Rd).To(Equal(uint8(1)))
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package insts
+
+// pacKeys maps the key bits of the PAC and AUT encodings (D:B) to keys.
+var pacKeys = [4]PACKey{PACKeyIA, PACKeyIB, PACKeyDA, PACKeyDB}
+
+// isPointerAuthHint checks for the pointer authentication and BTI
+// instructions in the hint space.
+// Format: 11010101000000110010 | CRm | op2 | 11111
+// CRm:op2: 7=XPACLRI, 8-14 (even)=PAC/AUT 1716, 24-31=PAC/AUT Z and SP,
+// 32-38 (even)=BTI
+func (d *Decoder) isPointerAuthHint(word uint32) bool {
+	if word&0xFFFFF01F != 0xD503201F {
+		return false
+	}
+	n := (word >> 5) & 0x7F
+	switch {
+	case n == 7, n >= 24 && n <= 31:
+		return true
+	case n >= 8 && n <= 14, n >= 32 && n <= 38:
+		return n%2 == 0
+	default:
+		return false
+	}
+}
+
+// decodePointerAuthHint decodes XPACLRI, the PAC and AUT hints that sign
+// X17 with X16 (PACIA1716, ...) or X30 with SP or zero (PACIASP, PACIAZ,
+// ...), and BTI. They keep the hint format and number in Imm, so that
+// they still execute as NOPs where pointer authentication is disabled.
+// BTI records its targets (BTITargetCall, BTITargetJump) in Imm instead.
+func (d *Decoder) decodePointerAuthHint(word uint32, inst *Instruction) {
+	inst.Format = FormatBarrier
+	inst.Is64Bit = true
+	n := uint64((word >> 5) & 0x7F)
+	inst.Imm = n
+
+	switch {
+	case n == 7:
+		inst.Op = OpXPAC
+		inst.PACKey = PACKeyIA
+		inst.Rd = 30
+	case n < 16:
+		inst.Rd = 17
+		inst.Rn = 16
+	case n < 32:
+		inst.Rd = 30
+		inst.Rn = 31
+		inst.ZeroModifier = n%2 == 0
+	default:
+		inst.Op = OpBTI
+		inst.Imm = (n >> 1) & 0x3
+		inst.Rd = 31
+		return
+	}
+
+	if n >= 8 {
+		inst.Op = OpPAC
+		if n&0x4 != 0 {
+			inst.Op = OpAUT
+		}
+		inst.PACKey = pacKeys[(n>>1)&0x1]
+	}
+}
+
+// isPointerAuthDP1 checks for the data-processing (1 source) pointer
+// authentication instructions.
+// Format: 1 | 1 | 0 | 11010110 | 00001 | opcode | Rn | Rd
+func (d *Decoder) isPointerAuthDP1(word uint32) bool {
+	return word&0xFFFF8000 == 0xDAC10000
+}
+
+// decodePointerAuthDP1 decodes PAC, AUT and XPAC.
+// opcode[15:10]: 000ZDB=PAC, 001ZDB=AUT with key D:B and zero modifier Z
+// (Rn must be 31), 010000=XPACI, 010001=XPACD (Rn must be 31)
+func (d *Decoder) decodePointerAuthDP1(word uint32, inst *Instruction) {
+	inst.Format = FormatDataProc1Src
+	inst.Is64Bit = true
+
+	opcode := (word >> 10) & 0x3F // bits [15:10]
+	rn := uint8((word >> 5) & 0x1F)
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = rn
+
+	switch {
+	case opcode < 0b010000:
+		zero := opcode&0x8 != 0
+		if zero && rn != 31 {
+			return
+		}
+		inst.Op = OpPAC
+		if opcode&0x4 != 0 {
+			inst.Op = OpAUT
+		}
+		inst.PACKey = pacKeys[opcode&0x3]
+		inst.ZeroModifier = zero
+	case opcode <= 0b010001 && rn == 31:
+		inst.Op = OpXPAC
+		inst.PACKey = [2]PACKey{PACKeyIA, PACKeyDA}[opcode&0x1]
+	}
+}
+
+// isBranchRegAuth checks for the authenticated branches to register.
+// Format: 1101011 | Z | 0 | op | 11111 | 0000 | 1 | M | Rn | Rm
+func (d *Decoder) isBranchRegAuth(word uint32) bool {
+	return word&0xFE9FF800 == 0xD61F0800
+}
+
+// decodeBranchRegAuth decodes BRAA, BRAAZ, BLRAA, BLRAAZ, RETAA and their
+// key B forms as BR, BLR and RET with PACKey set.
+// Z=0: zero modifier (Rm must be 31); RET uses X30 with SP as the modifier.
+// Z=1: modifier in Rm, 31 meaning SP.
+// op[22:21]: 00=BR, 01=BLR, 10=RET
+// M[10]: 0=key A, 1=key B
+func (d *Decoder) decodeBranchRegAuth(word uint32, inst *Instruction) {
+	inst.Format = FormatBranchReg
+
+	z := (word >> 24) & 0x1
+	op := (word >> 21) & 0x3
+	rn := uint8((word >> 5) & 0x1F)
+	rm := uint8(word & 0x1F)
+
+	inst.Rn = rn
+	inst.Rm = rm
+	inst.PACKey = pacKeys[(word>>10)&0x1]
+
+	switch {
+	case op == 0b10 && z == 0 && rn == 31 && rm == 31:
+		inst.Op = OpRET
+		inst.Rn = 30
+	case op < 0b10 && z == 1:
+		inst.Op = [2]Op{OpBR, OpBLR}[op]
+	case op < 0b10 && rm == 31:
+		inst.Op = [2]Op{OpBR, OpBLR}[op]
+		inst.ZeroModifier = true
+	}
+}
@@ -0,0 +1,529 @@
+package insts
+
+import "math/bits"
+
+// isSIMDTwoReg checks for SIMD two-register miscellaneous instructions.
+// Format: 0 | Q | U | 01110 | size | 10000 | opcode | 10 | Rn | Rd
+func (d *Decoder) isSIMDTwoReg(word uint32) bool {
+	return word&0x9F3E0C00 == 0x0E200800
+}
+
+// decodeSIMDTwoReg decodes the integer two-register miscellaneous
+// instructions (element reverse, compare against zero, ABS, NEG, NOT, CNT,
+// the add long pairwise family and the extract narrow family), and through
+// decodeSIMDFloatTwoReg the floating-point ones.
+// Q[30]: 0=64-bit, 1=128-bit; for the narrowing forms, 1 selects the "2"
+// variant that writes the upper half of Rd
+// opcode[16:12] and U[29] select the operation
+// The narrowing and pairwise long forms set Arrangement to the destination
+// arrangement.
+func (d *Decoder) decodeSIMDTwoReg(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDTwoReg
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1       // bit 30
+	u := (word >> 29) & 0x1       // bit 29
+	size := (word >> 22) & 0x3    // bits [23:22]
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+	rn := (word >> 5) & 0x1F      // bits [9:5]
+	rd := word & 0x1F             // bits [4:0]
+
+	inst.Rd = uint8(rd)
+	inst.Rn = uint8(rn)
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+
+	switch opcode {
+	case 0b00000: // REV64, REV32
+		if size < 3-u {
+			inst.Op = [2]Op{OpVREV64, OpVREV32}[u]
+		}
+	case 0b00001: // REV16
+		if size == 0 && u == 0 {
+			inst.Op = OpVREV16
+		}
+	case 0b00010, 0b00110: // SADDLP, UADDLP / SADALP, UADALP
+		if size != 3 {
+			inst.Arrangement = d.getSIMDArrangement(q == 1, size+1)
+			if opcode == 0b00010 {
+				inst.Op = [2]Op{OpVSADDLP, OpVUADDLP}[u]
+			} else {
+				inst.Op = [2]Op{OpVSADALP, OpVUADALP}[u]
+			}
+		}
+	case 0b00101: // CNT, NOT (byte elements only)
+		if size == 0 {
+			inst.Op = [2]Op{OpVCNT, OpVNOT}[u]
+		}
+	case 0b01000, 0b01001, 0b01010, 0b01011:
+		if size != 3 || q == 1 {
+			inst.Op = twoRegCompareOps[opcode-0b01000][u]
+		}
+	case 0b10010, 0b10100: // XTN, SQXTUN / SQXTN, UQXTN
+		if size != 3 {
+			if opcode == 0b10010 {
+				inst.Op = [2]Op{OpVXTN, OpVSQXTUN}[u]
+			} else {
+				inst.Op = [2]Op{OpVSQXTN, OpVUQXTN}[u]
+			}
+		}
+	default:
+		d.decodeSIMDFloatTwoReg(inst, q, u, size>>1, size&0x1, opcode)
+	}
+}
+
+// twoRegCompareOps maps two-register opcodes 01000-01011 to their U=0 and
+// U=1 operations.
+var twoRegCompareOps = [4][2]Op{
+	{OpVCMGTZ, OpVCMGEZ},
+	{OpVCMEQZ, OpVCMLEZ},
+	{OpVCMLTZ, OpUnknown},
+	{OpVABS, OpVNEG},
+}
+
+// isSIMDAcross checks for SIMD across lanes instructions.
+// Format: 0 | Q | U | 01110 | size | 11000 | opcode | 10 | Rn | Rd
+func (d *Decoder) isSIMDAcross(word uint32) bool {
+	return word&0x9F3E0C00 == 0x0E300800
+}
+
+// decodeSIMDAcross decodes the integer reductions across lanes (ADDV,
+// SADDLV, UADDLV, SMAXV, UMAXV, SMINV, UMINV). Arrangement is that of the
+// source vector; Rd is a scalar of the element size, or twice it for the
+// long forms. There are no 2S or 2D forms.
+func (d *Decoder) decodeSIMDAcross(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDAcross
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1       // bit 30
+	u := (word >> 29) & 0x1       // bit 29
+	size := (word >> 22) & 0x3    // bits [23:22]
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Is64Bit = q == 1
+	if opcode == 0b01100 || opcode == 0b01111 {
+		d.decodeSIMDFloatAcross(inst, q, u, size, opcode)
+		return
+	}
+	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+	if size == 3 || (size == 2 && q == 0) {
+		return
+	}
+
+	switch opcode {
+	case 0b00011:
+		inst.Op = [2]Op{OpVSADDLV, OpVUADDLV}[u]
+	case 0b01010:
+		inst.Op = [2]Op{OpVSMAXV, OpVUMAXV}[u]
+	case 0b11010:
+		inst.Op = [2]Op{OpVSMINV, OpVUMINV}[u]
+	case 0b11011:
+		inst.Op = [2]Op{OpVADDV, OpUnknown}[u]
+	}
+}
+
+// isSIMDThreeDiff checks for SIMD three-different instructions.
+// Format: 0 | Q | U | 01110 | size | 1 | Rm | opcode | 00 | Rn | Rd
+func (d *Decoder) isSIMDThreeDiff(word uint32) bool {
+	return word&0x9F200C00 == 0x0E200000
+}
+
+// threeDiffOps maps the three-different opcodes to their signed (U=0) and
+// unsigned (U=1) operations.
+var threeDiffOps = [16][2]Op{
+	0b0000: {OpVSADDL, OpVUADDL},
+	0b0001: {OpVSADDW, OpVUADDW},
+	0b0010: {OpVSSUBL, OpVUSUBL},
+	0b0011: {OpVSSUBW, OpVUSUBW},
+	0b0101: {OpVSABAL, OpVUABAL},
+	0b0111: {OpVSABDL, OpVUABDL},
+	0b1000: {OpVSMLAL, OpVUMLAL},
+	0b1010: {OpVSMLSL, OpVUMLSL},
+	0b1100: {OpVSMULL, OpVUMULL},
+}
+
+// decodeSIMDThreeDiff decodes the long and wide three-different
+// instructions.
+// Q[30]: 1 selects the "2" variant, which reads the upper halves of the
+// narrow sources
+// size[23:22]: element size of the narrow sources
+// Arrangement is the (128-bit) destination arrangement. PMULL multiplies
+// bytes (size 00) into 8H or doublewords (size 11) into 1Q.
+func (d *Decoder) decodeSIMDThreeDiff(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDThreeDiff
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1      // bit 30
+	u := (word >> 29) & 0x1      // bit 29
+	size := (word >> 22) & 0x3   // bits [23:22]
+	opcode := (word >> 12) & 0xF // bits [15:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Is64Bit = q == 1
+	switch {
+	case opcode == 0b1110 && u == 0 && size == 0:
+		inst.Arrangement = Arr8H
+		inst.Op = OpVPMULL
+	case opcode == 0b1110 && u == 0 && size == 3:
+		inst.Arrangement = Arr1Q
+		inst.Op = OpVPMULL
+	case size != 3:
+		inst.Arrangement = d.getSIMDArrangement(true, size+1)
+		inst.Op = threeDiffOps[opcode][u]
+	}
+}
+
+// isSIMDModImm checks for SIMD modified immediate instructions.
+// Format: 0 | Q | op | 0111100000 | a:b:c | cmode | o2 | 1 | d:e:f:g:h | Rd
+func (d *Decoder) isSIMDModImm(word uint32) bool {
+	return word&0x9FF80400 == 0x0F000400
+}
+
+// decodeSIMDModImm decodes MOVI, MVNI, the vector ORR and BIC (immediate)
+// and FMOV (vector, immediate).
+// cmode[15:12] selects the element size and shift of imm8 (a:b:c:d:e:f:g:h)
+// Imm holds imm8 expanded to a 64-bit pattern, before the inversion applied
+// by MVNI and BIC, and ShiftAmount the shift. A shifting-ones (MSL) shift
+// fills the bits below imm8 with ones. The 64-bit MOVI with Q=0 writes the
+// scalar D register and uses the 1D arrangement. FMOV (cmode 1111) expands
+// imm8 as a floating-point value of half (o2=1), single (op=0) or double
+// (op=1) precision.
+func (d *Decoder) decodeSIMDModImm(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDModImm
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1     // bit 30
+	op := (word >> 29) & 0x1    // bit 29
+	cmode := (word >> 12) & 0xF // bits [15:12]
+	o2 := (word >> 11) & 0x1    // bit 11
+	abc := (word >> 16) & 0x7   // bits [18:16]
+	defgh := (word >> 5) & 0x1F // bits [9:5]
+	imm8 := uint64(abc<<5 | defgh)
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Is64Bit = q == 1
+	if o2 == 1 && (cmode != 0b1111 || op == 1) {
+		return
+	}
+
+	var elem uint64 // One element of the pattern
+	var size uint32 // log2 of the element size in bytes
+	switch {
+	case cmode&0b1000 == 0: // 32-bit, LSL #0, 8, 16 or 24
+		inst.ShiftAmount = uint8(8 * (cmode >> 1))
+		elem, size = imm8<<inst.ShiftAmount, 2
+		inst.Op = modImmOp(op, cmode&0x1 == 1)
+	case cmode&0b1100 == 0b1000: // 16-bit, LSL #0 or 8
+		inst.ShiftAmount = uint8(8 * ((cmode >> 1) & 0x1))
+		elem, size = imm8<<inst.ShiftAmount, 1
+		inst.Op = modImmOp(op, cmode&0x1 == 1)
+	case cmode&0b1110 == 0b1100: // 32-bit, MSL #8 or 16
+		inst.ShiftAmount = uint8(8 * (cmode&0x1 + 1))
+		elem, size = imm8<<inst.ShiftAmount|(1<<inst.ShiftAmount-1), 2
+		inst.Op = modImmOp(op, false)
+	case cmode == 0b1110 && op == 0: // 8-bit
+		elem, size = imm8, 0
+		inst.Op = OpVMOVI
+	case cmode == 0b1110: // 64-bit, each bit of imm8 selects a byte
+		for i := 0; i < 8; i++ {
+			if imm8>>i&0x1 == 1 {
+				elem |= 0xFF << (8 * i)
+			}
+		}
+		size = 3
+		inst.Op = OpVMOVI
+	case o2 == 1: // FMOV, half precision
+		elem, size = ExpandFPImm(uint8(imm8), FPHalf), 1
+		inst.Op = OpVFMOVImm
+	case op == 0: // FMOV, single precision
+		elem, size = ExpandFPImm(uint8(imm8), FPSingle), 2
+		inst.Op = OpVFMOVImm
+	case q == 1: // FMOV, double precision
+		elem, size = ExpandFPImm(uint8(imm8), FPDouble), 3
+		inst.Op = OpVFMOVImm
+	default:
+		return
+	}
+	inst.IsFloat = inst.Op == OpVFMOVImm
+
+	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+	if size == 3 && q == 0 {
+		inst.Arrangement = Arr1D
+	}
+	for width := uint(8) << size; width < 64; width *= 2 {
+		elem |= elem << width
+	}
+	inst.Imm = elem
+}
+
+// modImmOp returns the modified immediate operation for op and whether
+// cmode selects the ORR/BIC forms.
+func modImmOp(op uint32, logical bool) Op {
+	switch {
+	case logical && op == 1:
+		return OpVBICImm
+	case logical:
+		return OpVORRImm
+	case op == 1:
+		return OpVMVNI
+	default:
+		return OpVMOVI
+	}
+}
+
+// isSIMDShiftImm checks for SIMD shift by immediate instructions and
+// their scalar forms.
+// Format: 0 | Q | U | 011110 | immh | immb | opcode | 1 | Rn | Rd
+// Scalar: 01 | U | 111110 | immh | immb | opcode | 1 | Rn | Rd
+// immh[22:19] must be nonzero (zero selects modified immediate)
+func (d *Decoder) isSIMDShiftImm(word uint32) bool {
+	return (word&0x9F800400 == 0x0F000400 || word&0xDF800400 == 0x5F000400) &&
+		(word>>19)&0xF != 0
+}
+
+// decodeSIMDShiftImm decodes SHL, SSHR, USHR, SSRA, USRA, SHRN, RSHRN,
+// SSHLL and USHLL.
+// immh[22:19]: the highest set bit gives the element size (esize)
+// immh:immb[22:16]: right shifts are 2*esize - immh:immb, left shifts
+// immh:immb - esize
+// Q[30]: for SHRN, RSHRN, SSHLL and USHLL, 1 selects the "2" variant that
+// uses the upper half of the narrow vector
+// Imm holds the shift amount. The narrowing and widening forms set
+// Arrangement to the destination arrangement.
+// The scalar forms (bit 28 set) of SHL and the right shifts operate on a
+// D register.
+func (d *Decoder) decodeSIMDShiftImm(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDShiftImm
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1       // bit 30
+	u := (word >> 29) & 0x1       // bit 29
+	scalar := (word >> 28) & 0x1  // bit 28
+	immh := (word >> 19) & 0xF    // bits [22:19]
+	immhb := (word >> 16) & 0x7F  // bits [22:16]
+	opcode := (word >> 11) & 0x1F // bits [15:11]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+
+	size := uint32(bits.Len32(immh) - 1) // log2 of the element size in bytes
+	esize := uint32(8) << size
+	if scalar == 1 {
+		q = 0
+		inst.IsScalar = true
+		if size != 3 {
+			return // Only the doubleword shifts have scalar forms here
+		}
+	}
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+	if inst.IsScalar {
+		inst.Arrangement = Arr1D
+	}
+
+	switch opcode {
+	case 0b00000, 0b00010, 0b01010: // SSHR/USHR, SSRA/USRA, SHL
+		if size == 3 && q == 0 && !inst.IsScalar {
+			return
+		}
+		switch {
+		case opcode == 0b00000:
+			inst.Op = [2]Op{OpVSSHR, OpVUSHR}[u]
+		case opcode == 0b00010:
+			inst.Op = [2]Op{OpVSSRA, OpVUSRA}[u]
+		case u == 0:
+			inst.Op = OpVSHL
+			inst.Imm = uint64(immhb - esize)
+			return
+		default:
+			return // SLI
+		}
+		inst.Imm = uint64(2*esize - immhb)
+	case 0b10000, 0b10001: // SHRN, RSHRN
+		if size != 3 && u == 0 {
+			inst.Op = [2]Op{OpVSHRN, OpVRSHRN}[opcode&0x1]
+			inst.Imm = uint64(2*esize - immhb)
+		}
+	case 0b10100: // SSHLL, USHLL
+		if size != 3 {
+			inst.Op = [2]Op{OpVSSHLL, OpVUSHLL}[u]
+			inst.Imm = uint64(immhb - esize)
+			inst.Arrangement = d.getSIMDArrangement(true, size+1)
+		}
+	}
+}
+
+// isSIMDPermute checks for SIMD permute instructions.
+// Format: 0 | Q | 001110 | size | 0 | Rm | 0 | opcode | 10 | Rn | Rd
+func (d *Decoder) isSIMDPermute(word uint32) bool {
+	return word&0xBF208C00 == 0x0E000800
+}
+
+// decodeSIMDPermute decodes ZIP1, ZIP2, UZP1, UZP2, TRN1 and TRN2.
+// opcode[14:12]: 001=UZP1, 010=TRN1, 011=ZIP1, 101=UZP2, 110=TRN2, 111=ZIP2
+func (d *Decoder) decodeSIMDPermute(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDPermute
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1      // bit 30
+	size := (word >> 22) & 0x3   // bits [23:22]
+	opcode := (word >> 12) & 0x7 // bits [14:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+	if size == 3 && q == 0 {
+		return
+	}
+
+	inst.Op = permuteOps[opcode]
+}
+
+// permuteOps maps the permute opcodes to their operations.
+var permuteOps = [8]Op{
+	0b001: OpVUZP1, 0b010: OpVTRN1, 0b011: OpVZIP1,
+	0b101: OpVUZP2, 0b110: OpVTRN2, 0b111: OpVZIP2,
+}
+
+// isSIMDExtract checks for the SIMD extract instruction (EXT).
+// Format: 0 | Q | 101110 | 00 | 0 | Rm | 0 | imm4 | 0 | Rn | Rd
+func (d *Decoder) isSIMDExtract(word uint32) bool {
+	return word&0xBFE08400 == 0x2E000000
+}
+
+// decodeSIMDExtract decodes EXT, which extracts a vector from the byte
+// pair Rm:Rn starting at byte imm4 of Rn. The 64-bit form needs imm4 < 8.
+func (d *Decoder) decodeSIMDExtract(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDExtract
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1    // bit 30
+	imm4 := (word >> 11) & 0xF // bits [14:11]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, 0)
+	inst.Imm = uint64(imm4)
+	if q == 1 || imm4 < 8 {
+		inst.Op = OpVEXT
+	}
+}
+
+// isSIMDTableLookup checks for SIMD table lookup instructions.
+// Format: 0 | Q | 001110 | 00 | 0 | Rm | 0 | len | op | 00 | Rn | Rd
+func (d *Decoder) isSIMDTableLookup(word uint32) bool {
+	return word&0xBFE08C00 == 0x0E000000
+}
+
+// decodeSIMDTableLookup decodes TBL and TBX.
+// len[14:13]: the table is len+1 consecutive 16-byte registers starting at
+// Rn, wrapping from V31 to V0, stored in RegCount
+// op[12]: 0=TBL, 1=TBX
+// Rm holds the byte indices; Arrangement (8B or 16B) is that of Rd and Rm.
+func (d *Decoder) decodeSIMDTableLookup(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDTableLookup
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1      // bit 30
+	length := (word >> 13) & 0x3 // bits [14:13]
+	op := (word >> 12) & 0x1     // bit 12
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, 0)
+	inst.RegCount = uint8(length + 1)
+	inst.Op = [2]Op{OpVTBL, OpVTBX}[op]
+}
+
+// scalarArrangement returns the one-element arrangement of an Advanced
+// SIMD scalar form with the given size field.
+func scalarArrangement(size uint32) SIMDArrangement {
+	return [4]SIMDArrangement{Arr1B, Arr1H, Arr1S, Arr1D}[size]
+}
+
+// isSIMDScalarThreeSame checks for SIMD scalar three-same instructions.
+// Format: 01 | U | 11110 | size | 1 | Rm | opcode | 1 | Rn | Rd
+func (d *Decoder) isSIMDScalarThreeSame(word uint32) bool {
+	return word&0xDF200400 == 0x5E200400
+}
+
+// decodeSIMDScalarThreeSame decodes the scalar three-same instructions,
+// which operate on element 0 of their registers and zero the rest of Rd.
+// Arrangement is one of the one-element arrangements. The saturating adds
+// and subtracts take any element size, SQDMULH and SQRDMULH halfwords and
+// words, and the other integer operations doublewords only.
+func (d *Decoder) decodeSIMDScalarThreeSame(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDReg
+	inst.IsSIMD = true
+	inst.IsScalar = true
+
+	u := (word >> 29) & 0x1       // bit 29
+	size := (word >> 22) & 0x3    // bits [23:22]
+	opcode := (word >> 11) & 0x1F // bits [15:11]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Arrangement = scalarArrangement(size)
+
+	switch opcode {
+	case 0b00001, 0b00101: // SQADD, UQADD / SQSUB, UQSUB
+		inst.Op = threeSameOps[opcode][u]
+	case 0b00110, 0b00111, 0b01000, 0b10000, 0b10001: // Compares, SSHL, USHL, ADD, SUB
+		if size == 3 {
+			inst.Op = threeSameOps[opcode][u]
+		}
+	case 0b10110: // SQDMULH, SQRDMULH
+		if size == 1 || size == 2 {
+			inst.Op = [2]Op{OpVSQDMULH, OpVSQRDMULH}[u]
+		}
+	default:
+		if opcode&0b11000 == 0b11000 { // Floating-point; size[1] is part of the opcode
+			d.decodeSIMDScalarFloatThreeSame(inst, u, size>>1, size&0x1, opcode&0x7)
+		}
+	}
+}
+
+// isSIMDScalarTwoReg checks for SIMD scalar two-register miscellaneous
+// instructions.
+// Format: 01 | U | 11110 | size | 10000 | opcode | 10 | Rn | Rd
+func (d *Decoder) isSIMDScalarTwoReg(word uint32) bool {
+	return word&0xDF3E0C00 == 0x5E200800
+}
+
+// decodeSIMDScalarTwoReg decodes the scalar two-register miscellaneous
+// instructions, which operate on element 0 of their registers and zero
+// the rest of Rd. The integer compares against zero, ABS and NEG operate
+// on doublewords only.
+func (d *Decoder) decodeSIMDScalarTwoReg(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDTwoReg
+	inst.IsSIMD = true
+	inst.IsScalar = true
+
+	u := (word >> 29) & 0x1       // bit 29
+	size := (word >> 22) & 0x3    // bits [23:22]
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Arrangement = scalarArrangement(size)
+
+	switch opcode {
+	case 0b01000, 0b01001, 0b01010, 0b01011:
+		if size == 3 {
+			inst.Op = twoRegCompareOps[opcode-0b01000][u]
+		}
+	default:
+		d.decodeSIMDScalarFloatTwoReg(inst, u, size>>1, size&0x1, opcode)
+	}
+}
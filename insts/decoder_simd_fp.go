@@ -0,0 +1,332 @@
+package insts
+
+// floatArrangement returns the arrangement of single (sz=0) or double
+// (sz=1) precision elements, and false for the unallocated 1D form.
+func (d *Decoder) floatArrangement(q, sz uint32) (SIMDArrangement, bool) {
+	if sz == 1 {
+		return Arr2D, q == 1
+	}
+	return d.getSIMDArrangement(q == 1, 2), true
+}
+
+// setFloatOp sets a floating-point SIMD operation, which may be OpUnknown.
+func setFloatOp(inst *Instruction, op Op) {
+	inst.Op = op
+	inst.IsFloat = op != OpUnknown
+}
+
+// floatThreeSameOps maps the low three bits of the floating-point
+// three-same opcodes to their operations, indexed by U:a where a is
+// size[1].
+var floatThreeSameOps = [8][4]Op{
+	0b000: {OpVFMAXNM, OpVFMINNM, OpVFMAXNMP, OpVFMINNMP},
+	0b001: {OpVFMLA, OpVFMLS, OpUnknown, OpUnknown},
+	0b010: {OpVFADD, OpVFSUB, OpVFADDP, OpVFABD},
+	0b011: {OpUnknown, OpUnknown, OpVFMUL, OpUnknown},
+	0b100: {OpVFCMEQ, OpUnknown, OpVFCMGE, OpVFCMGT},
+	0b101: {OpUnknown, OpUnknown, OpVFACGE, OpVFACGT},
+	0b110: {OpVFMAX, OpVFMIN, OpVFMAXP, OpVFMINP},
+	0b111: {OpVFRECPS, OpVFRSQRTS, OpVFDIV, OpUnknown},
+}
+
+// decodeSIMDFloatThreeSame decodes the single and double precision
+// three-same instructions (opcode 11xxx).
+// a[23] is part of the opcode and sz[22] selects the precision
+func (d *Decoder) decodeSIMDFloatThreeSame(inst *Instruction, q, u, a, sz, opcode uint32) {
+	arr, ok := d.floatArrangement(q, sz)
+	inst.Arrangement = arr
+	if ok {
+		setFloatOp(inst, floatThreeSameOps[opcode][u<<1|a])
+	}
+}
+
+// isSIMDThreeSameFP16 checks for SIMD half-precision three-same
+// instructions.
+// Format: 0 | Q | U | 01110 | a | 10 | Rm | 00 | opcode | 1 | Rn | Rd
+func (d *Decoder) isSIMDThreeSameFP16(word uint32) bool {
+	return word&0x9F60C400 == 0x0E400400
+}
+
+// decodeSIMDThreeSameFP16 decodes the half-precision three-same
+// instructions, whose opcode[13:11] matches the low bits of the single and
+// double precision opcodes.
+func (d *Decoder) decodeSIMDThreeSameFP16(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDReg
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1      // bit 30
+	u := (word >> 29) & 0x1      // bit 29
+	a := (word >> 23) & 0x1      // bit 23
+	opcode := (word >> 11) & 0x7 // bits [13:11]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Rm = uint8((word >> 16) & 0x1F)
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, 1)
+	setFloatOp(inst, floatThreeSameOps[opcode][u<<1|a])
+}
+
+// floatTwoRegOps maps the floating-point two-register opcodes to their
+// operations, indexed by a:U where a is size[1].
+var floatTwoRegOps = [32][4]Op{
+	0b01100: {OpUnknown, OpUnknown, OpVFCMGTZ, OpVFCMGEZ},
+	0b01101: {OpUnknown, OpUnknown, OpVFCMEQZ, OpVFCMLEZ},
+	0b01110: {OpUnknown, OpUnknown, OpVFCMLTZ, OpUnknown},
+	0b01111: {OpUnknown, OpUnknown, OpVFABS, OpVFNEG},
+	0b11000: {OpVFRINTN, OpVFRINTA, OpVFRINTP, OpUnknown},
+	0b11001: {OpVFRINTM, OpVFRINTX, OpVFRINTZ, OpVFRINTI},
+	0b11010: {OpVFCVTNS, OpVFCVTNU, OpVFCVTPS, OpVFCVTPU},
+	0b11011: {OpVFCVTMS, OpVFCVTMU, OpVFCVTZS, OpVFCVTZU},
+	0b11100: {OpVFCVTAS, OpVFCVTAU, OpUnknown, OpUnknown},
+	0b11101: {OpVSCVTF, OpVUCVTF, OpVFRECPE, OpVFRSQRTE},
+	0b11111: {OpUnknown, OpUnknown, OpUnknown, OpVFSQRT},
+}
+
+// decodeSIMDFloatTwoReg decodes the single and double precision
+// two-register miscellaneous instructions.
+// a[23] is part of the opcode and sz[22] selects the precision
+// FCVTN and FCVTL convert between half and single (sz=0) or single and
+// double (sz=1) precision. Like the integer narrowing and widening forms,
+// they set Arrangement to the destination arrangement, and Q selects the
+// "2" variant that uses the upper half of the narrow vector.
+func (d *Decoder) decodeSIMDFloatTwoReg(inst *Instruction, q, u, a, sz, opcode uint32) {
+	switch {
+	case opcode == 0b10110 && u == 0 && a == 0: // FCVTN
+		inst.Arrangement = d.getSIMDArrangement(q == 1, 1+sz)
+		setFloatOp(inst, OpVFCVTN)
+	case opcode == 0b10111 && u == 0 && a == 0: // FCVTL
+		inst.Arrangement = d.getSIMDArrangement(true, 2+sz)
+		setFloatOp(inst, OpVFCVTL)
+	default:
+		arr, ok := d.floatArrangement(q, sz)
+		inst.Arrangement = arr
+		if ok {
+			setFloatOp(inst, floatTwoRegOps[opcode][a<<1|u])
+		}
+	}
+}
+
+// isSIMDTwoRegFP16 checks for SIMD half-precision two-register
+// miscellaneous instructions.
+// Format: 0 | Q | U | 01110 | a | 1111 | 00 | opcode | 10 | Rn | Rd
+func (d *Decoder) isSIMDTwoRegFP16(word uint32) bool {
+	return word&0x9F7E0C00 == 0x0E780800
+}
+
+// decodeSIMDTwoRegFP16 decodes the half-precision two-register
+// miscellaneous instructions, which share the opcodes of the single and
+// double precision forms.
+func (d *Decoder) decodeSIMDTwoRegFP16(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDTwoReg
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1       // bit 30
+	u := (word >> 29) & 0x1       // bit 29
+	a := (word >> 23) & 0x1       // bit 23
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	inst.Is64Bit = q == 1
+	inst.Arrangement = d.getSIMDArrangement(q == 1, 1)
+	setFloatOp(inst, floatTwoRegOps[opcode][a<<1|u])
+}
+
+// decodeSIMDFloatAcross decodes FMAXNMV, FMINNMV (opcode 01100), FMAXV and
+// FMINV (opcode 01111).
+// size[23] selects the minimum and size[22] must be 0
+// U[29]: 1=single precision (4S only), 0=half precision
+func (d *Decoder) decodeSIMDFloatAcross(inst *Instruction, q, u, size, opcode uint32) {
+	switch {
+	case size&0x1 != 0:
+		return
+	case u == 1 && q == 0:
+		return
+	case u == 1:
+		inst.Arrangement = Arr4S
+	default:
+		inst.Arrangement = d.getSIMDArrangement(q == 1, 1)
+	}
+
+	ops := [2][2]Op{{OpVFMAXNMV, OpVFMINNMV}, {OpVFMAXV, OpVFMINV}}
+	setFloatOp(inst, ops[opcode&0x1][size>>1])
+}
+
+// isSIMDElem checks for SIMD vector x indexed element instructions and
+// their scalar forms.
+// Format: 0 | Q | U | 01111 | size | L | M | Rm | opcode | H | 0 | Rn | Rd
+// Scalar: 01 | U | 11111 | size | L | M | Rm | opcode | H | 0 | Rn | Rd
+func (d *Decoder) isSIMDElem(word uint32) bool {
+	return word&0x9F000400 == 0x0F000000 || word&0xDF000400 == 0x5F000000
+}
+
+// decodeSIMDElem decodes FMLA, FMLS and FMUL (by element), and through
+// decodeSIMDIntElem the integer by element instructions.
+// size[23:22]: 00=half, 10=single, 11=double precision
+// The element index in Lane is H:L:M for half precision, H:L for single
+// and H for double precision. Otherwise M is the high bit of Rm.
+// opcode[15:12]: 0001=FMLA, 0101=FMLS, 1001=FMUL
+// The scalar forms (bit 28 set) have no Q bit and operate on element 0.
+func (d *Decoder) decodeSIMDElem(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDElem
+	inst.IsSIMD = true
+
+	q := (word >> 30) & 0x1      // bit 30
+	u := (word >> 29) & 0x1      // bit 29
+	scalar := (word >> 28) & 0x1 // bit 28
+	size := (word >> 22) & 0x3   // bits [23:22]
+	l := (word >> 21) & 0x1      // bit 21
+	m := (word >> 20) & 0x1      // bit 20
+	rm := (word >> 16) & 0xF     // bits [19:16]
+	opcode := (word >> 12) & 0xF // bits [15:12]
+	h := (word >> 11) & 0x1      // bit 11
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	if scalar == 1 {
+		q = 0
+		inst.IsScalar = true
+	}
+	inst.Is64Bit = q == 1
+	if op := elemIntOps[u<<4|opcode]; op != OpUnknown {
+		d.decodeSIMDIntElem(inst, op, q, size, l, m, rm, h)
+		return
+	}
+
+	switch {
+	case size == 0:
+		inst.Lane = uint8(h<<2 | l<<1 | m)
+		inst.Rm = uint8(rm)
+		inst.Arrangement = d.getSIMDArrangement(q == 1, 1)
+	case size == 2:
+		inst.Lane = uint8(h<<1 | l)
+		inst.Rm = uint8(m<<4 | rm)
+		inst.Arrangement = d.getSIMDArrangement(q == 1, 2)
+	case size == 3 && l == 0 && (q == 1 || inst.IsScalar):
+		inst.Lane = uint8(h)
+		inst.Rm = uint8(m<<4 | rm)
+		inst.Arrangement = Arr2D
+	default:
+		return
+	}
+	if inst.IsScalar {
+		inst.Arrangement = scalarArrangement(size)
+	}
+	if u != 0 {
+		return
+	}
+
+	switch opcode {
+	case 0b0001:
+		setFloatOp(inst, OpVFMLA)
+	case 0b0101:
+		setFloatOp(inst, OpVFMLS)
+	case 0b1001:
+		setFloatOp(inst, OpVFMUL)
+	}
+}
+
+// elemIntOps maps U:opcode of the integer by element instructions to their
+// operations.
+var elemIntOps = [32]Op{
+	0b0_1000: OpVMUL, 0b0_1100: OpVSQDMULH, 0b0_1101: OpVSQRDMULH,
+	0b1_0000: OpVMLA, 0b1_0100: OpVMLS,
+}
+
+// decodeSIMDIntElem decodes MUL, MLA, MLS, SQDMULH and SQRDMULH (by
+// element), which have halfword (size 01) and word (size 10) elements.
+// The element index in Lane is H:L:M for halfwords, whose Rm is V0-V15,
+// and H:L for words. Only SQDMULH and SQRDMULH have scalar forms.
+func (d *Decoder) decodeSIMDIntElem(inst *Instruction, op Op, q, size, l, m, rm, h uint32) {
+	switch size {
+	case 1:
+		inst.Lane = uint8(h<<2 | l<<1 | m)
+		inst.Rm = uint8(rm)
+	case 2:
+		inst.Lane = uint8(h<<1 | l)
+		inst.Rm = uint8(m<<4 | rm)
+	default:
+		return
+	}
+	inst.Arrangement = d.getSIMDArrangement(q == 1, size)
+	if inst.IsScalar {
+		if op != OpVSQDMULH && op != OpVSQRDMULH {
+			return
+		}
+		inst.Arrangement = scalarArrangement(size)
+	}
+	inst.Op = op
+}
+
+// isSIMDScalarPairwise checks for SIMD scalar pairwise instructions.
+// Format: 01 | U | 11110 | size | 11000 | opcode | 10 | Rn | Rd
+func (d *Decoder) isSIMDScalarPairwise(word uint32) bool {
+	return word&0xDF3E0C00 == 0x5E300800
+}
+
+// decodeSIMDScalarPairwise decodes the single and double precision scalar
+// pairwise instructions (FADDP, FMAXP, FMINP, FMAXNMP, FMINNMP) and the
+// integer ADDP, which combine the two elements of a vector into a scalar.
+// size[23] selects the minimum; size[22]: 0=single (2S), 1=double (2D)
+// opcode[16:12]: 01100=FMAXNMP/FMINNMP, 01101=FADDP, 01111=FMAXP/FMINP;
+// with U=0, 11011=ADDP (2D only)
+// Arrangement is that of the source vector.
+func (d *Decoder) decodeSIMDScalarPairwise(word uint32, inst *Instruction) {
+	inst.Format = FormatSIMDScalarPairwise
+	inst.IsSIMD = true
+
+	u := (word >> 29) & 0x1       // bit 29
+	a := (word >> 23) & 0x1       // bit 23
+	sz := (word >> 22) & 0x1      // bit 22
+	opcode := (word >> 12) & 0x1F // bits [16:12]
+
+	inst.Rd = uint8(word & 0x1F)
+	inst.Rn = uint8((word >> 5) & 0x1F)
+	if u == 0 {
+		if a == 1 && sz == 1 && opcode == 0b11011 {
+			inst.Arrangement = Arr2D
+			inst.Op = OpVADDP
+		}
+		return // The half-precision forms
+	}
+	inst.Arrangement = [2]SIMDArrangement{Arr2S, Arr2D}[sz]
+
+	switch opcode {
+	case 0b01100:
+		setFloatOp(inst, [2]Op{OpVFMAXNMP, OpVFMINNMP}[a])
+	case 0b01101:
+		setFloatOp(inst, [2]Op{OpVFADDP, OpUnknown}[a])
+	case 0b01111:
+		setFloatOp(inst, [2]Op{OpVFMAXP, OpVFMINP}[a])
+	}
+}
+
+// scalarFloatThreeSame holds the floating-point three-same operations
+// that have an Advanced SIMD scalar form.
+var scalarFloatThreeSame = map[Op]bool{
+	OpVFABD: true, OpVFCMEQ: true, OpVFCMGE: true, OpVFCMGT: true,
+	OpVFACGE: true, OpVFACGT: true, OpVFRECPS: true, OpVFRSQRTS: true,
+}
+
+// decodeSIMDScalarFloatThreeSame decodes the single and double precision
+// scalar three-same instructions (opcode 11xxx), which share the opcodes
+// of the vector forms.
+func (d *Decoder) decodeSIMDScalarFloatThreeSame(inst *Instruction, u, a, sz, opcode uint32) {
+	inst.Arrangement = [2]SIMDArrangement{Arr1S, Arr1D}[sz]
+	if op := floatThreeSameOps[opcode][u<<1|a]; scalarFloatThreeSame[op] {
+		setFloatOp(inst, op)
+	}
+}
+
+// decodeSIMDScalarFloatTwoReg decodes the single and double precision
+// scalar two-register miscellaneous instructions: the compares against
+// zero (opcodes 01100-01110), the conversions and the estimates (opcodes
+// 11010-11101).
+func (d *Decoder) decodeSIMDScalarFloatTwoReg(inst *Instruction, u, a, sz, opcode uint32) {
+	inst.Arrangement = [2]SIMDArrangement{Arr1S, Arr1D}[sz]
+	switch opcode {
+	case 0b01100, 0b01101, 0b01110, 0b11010, 0b11011, 0b11100, 0b11101:
+		setFloatOp(inst, floatTwoRegOps[opcode][a<<1|u])
+	}
+}
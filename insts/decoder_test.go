@@ -868,14 +868,13 @@ var _ = Describe("Decoder", func() {
 		})
 
 		// LDR D0, [X1]       -> 0xFD400020
-		// 64-bit SIMD load (D register) - decoder sets Arr16B as default
+		// 64-bit SIMD load (D register)
 		It("should decode LDR D0, [X1] (64-bit vector load)", func() {
 			inst := decoder.Decode(0xFD400020)
 
 			Expect(inst.Op).To(Equal(insts.OpLDRQ))
 			Expect(inst.IsSIMD).To(BeTrue())
-			// Note: decoder defaults to Arr16B for this encoding
-			Expect(inst.Arrangement).To(Equal(insts.Arr16B))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
 		})
 
 		// LDR S0, [X1]       -> 0xBD400020
@@ -885,7 +884,293 @@ var _ = Describe("Decoder", func() {
 
 			Expect(inst.Op).To(Equal(insts.OpLDRQ))
 			Expect(inst.IsSIMD).To(BeTrue())
-			Expect(inst.Arrangement).To(Equal(insts.Arr2S))
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+		})
+
+		// STR H1, [X2, #-2]! -> 0x7C1FEC41
+		It("should decode pre-indexed SIMD&FP stores", func() {
+			inst := decoder.Decode(0x7C1FEC41)
+
+			Expect(inst.Op).To(Equal(insts.OpSTRQ))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDLoadStore))
+			Expect(inst.AccessSize).To(Equal(uint8(2)))
+			Expect(inst.IndexMode).To(Equal(insts.IndexPre))
+			Expect(inst.SignedImm).To(Equal(int64(-2)))
+		})
+
+		// STR Q4, [X5, W6, SXTW #4] -> 0x3CA6D8A4
+		It("should scale Q register offsets by 16", func() {
+			inst := decoder.Decode(0x3CA6D8A4)
+
+			Expect(inst.Op).To(Equal(insts.OpSTRQ))
+			Expect(inst.AccessSize).To(Equal(uint8(16)))
+			Expect(inst.IndexMode).To(Equal(insts.IndexRegBase))
+			Expect(inst.Rm).To(Equal(uint8(6)))
+			Expect(inst.ShiftType).To(Equal(insts.ShiftType(0b110)))
+			Expect(inst.ShiftAmount).To(Equal(uint8(4)))
+		})
+
+		// LDUR B0, [X1, #-1] -> 0x3C5FF020
+		It("should decode unscaled SIMD&FP loads", func() {
+			inst := decoder.Decode(0x3C5FF020)
+
+			Expect(inst.Op).To(Equal(insts.OpLDRQ))
+			Expect(inst.AccessSize).To(Equal(uint8(1)))
+			Expect(inst.IndexMode).To(Equal(insts.IndexSigned))
+			Expect(inst.SignedImm).To(Equal(int64(-1)))
+		})
+
+		// LDR Q1, <pc+32> -> 0x9C000101
+		It("should decode SIMD&FP literal loads", func() {
+			inst := decoder.Decode(0x9C000101)
+
+			Expect(inst.Op).To(Equal(insts.OpLDRLit))
+			Expect(inst.IsSIMD).To(BeTrue())
+			Expect(inst.AccessSize).To(Equal(uint8(16)))
+			Expect(inst.BranchOffset).To(Equal(int64(32)))
+		})
+
+		It("should reject Q-sized opc with a non-zero size field", func() {
+			// size=01, opc=11 is unallocated
+			Expect(decoder.Decode(0x7DC00020).Op).To(Equal(insts.OpUnknown))
+		})
+
+		It("should not treat SIMD data processing as a load/store", func() {
+			// USHR D0, D1, #1
+			Expect(decoder.Decode(0x7F7F0420).Format).NotTo(Equal(insts.FormatSIMDLoadStore))
+		})
+	})
+
+	Describe("Unscaled, Unprivileged and Prefetch Instructions", func() {
+		// LDUR X0, [X1, #-8] -> 0xF85F8020
+		It("should decode LDUR as a signed offset", func() {
+			inst := decoder.Decode(0xF85F8020)
+
+			Expect(inst.Op).To(Equal(insts.OpLDR))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStore))
+			Expect(inst.IndexMode).To(Equal(insts.IndexSigned))
+			Expect(inst.SignedImm).To(Equal(int64(-8)))
+			Expect(inst.Unprivileged).To(BeFalse())
+		})
+
+		// LDTRSW X3, [X4] -> 0xB8800883
+		It("should decode LDTRSW", func() {
+			inst := decoder.Decode(0xB8800883)
+
+			Expect(inst.Op).To(Equal(insts.OpLDRSW))
+			Expect(inst.IndexMode).To(Equal(insts.IndexSigned))
+			Expect(inst.Unprivileged).To(BeTrue())
+			Expect(inst.Is64Bit).To(BeTrue())
+		})
+
+		// LDRH W0, [X1, #4094] -> 0x795FFC20
+		It("should scale halfword unsigned offsets by 2", func() {
+			inst := decoder.Decode(0x795FFC20)
+
+			Expect(inst.Op).To(Equal(insts.OpLDRH))
+			Expect(inst.AccessSize).To(Equal(uint8(2)))
+			Expect(inst.Imm).To(Equal(uint64(4094)))
+		})
+
+		// LDRSB X0, [X1, #1] -> 0x39800420
+		It("should decode sign-extending byte loads with unsigned offsets", func() {
+			inst := decoder.Decode(0x39800420)
+
+			Expect(inst.Op).To(Equal(insts.OpLDRSB))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.Imm).To(Equal(uint64(1)))
+		})
+
+		// PRFM PSTL2STRM, [X1, #64] -> 0xF9802033
+		It("should decode PRFM with the operation in Rd", func() {
+			inst := decoder.Decode(0xF9802033)
+
+			Expect(inst.Op).To(Equal(insts.OpPRFM))
+			Expect(inst.Rd).To(Equal(uint8(0b10011)))
+			Expect(inst.Imm).To(Equal(uint64(64)))
+		})
+
+		// PRFUM PLDL2KEEP, [X0, #-8] -> 0xF89F8002
+		It("should decode PRFUM", func() {
+			inst := decoder.Decode(0xF89F8002)
+
+			Expect(inst.Op).To(Equal(insts.OpPRFM))
+			Expect(inst.IndexMode).To(Equal(insts.IndexSigned))
+			Expect(inst.SignedImm).To(Equal(int64(-8)))
+		})
+
+		// PRFM PLDL1STRM, <pc+16> -> 0xD8000081
+		It("should decode PRFM (literal)", func() {
+			inst := decoder.Decode(0xD8000081)
+
+			Expect(inst.Op).To(Equal(insts.OpPRFM))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStoreLit))
+			Expect(inst.BranchOffset).To(Equal(int64(16)))
+		})
+
+		// LDRSW X0, <pc+8> -> 0x98000040
+		It("should decode LDRSW (literal)", func() {
+			inst := decoder.Decode(0x98000040)
+
+			Expect(inst.Op).To(Equal(insts.OpLDRSW))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStoreLit))
+			Expect(inst.BranchOffset).To(Equal(int64(8)))
+		})
+
+		It("should reject unallocated forms", func() {
+			// Pre-indexed PRFM
+			Expect(decoder.Decode(0xF8808C00).Op).To(Equal(insts.OpUnknown))
+			// Unprivileged SIMD&FP load
+			Expect(decoder.Decode(0xFC400820).Op).To(Equal(insts.OpUnknown))
+			// Register offset with a UXTB extend
+			Expect(decoder.Decode(0xF8620820).Op).To(Equal(insts.OpUnknown))
+			// LDR with size=10, opc=11
+			Expect(decoder.Decode(0xB9C00020).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
+	Describe("Load/Store Pair Instructions", func() {
+		// LDPSW X0, X1, [X2], #8 -> 0x68C10440
+		It("should decode LDPSW", func() {
+			inst := decoder.Decode(0x68C10440)
+
+			Expect(inst.Op).To(Equal(insts.OpLDPSW))
+			Expect(inst.Format).To(Equal(insts.FormatLoadStorePair))
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+			Expect(inst.IndexMode).To(Equal(insts.IndexPost))
+			Expect(inst.SignedImm).To(Equal(int64(8)))
+		})
+
+		// LDNP X0, X1, [SP, #16] -> 0xA84107E0
+		It("should decode LDNP as a non-temporal signed offset", func() {
+			inst := decoder.Decode(0xA84107E0)
+
+			Expect(inst.Op).To(Equal(insts.OpLDP))
+			Expect(inst.NonTemporal).To(BeTrue())
+			Expect(inst.IndexMode).To(Equal(insts.IndexSigned))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.SignedImm).To(Equal(int64(16)))
+		})
+
+		// STP D0, D1, [SP, #-16]! -> 0x6DBF07E0
+		It("should scale D register pair offsets by 8", func() {
+			inst := decoder.Decode(0x6DBF07E0)
+
+			Expect(inst.Op).To(Equal(insts.OpSTP))
+			Expect(inst.IsSIMD).To(BeTrue())
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.IndexMode).To(Equal(insts.IndexPre))
+			Expect(inst.SignedImm).To(Equal(int64(-16)))
+		})
+
+		// LDP Q0, Q1, [X1], #32 -> 0xACC10420
+		It("should scale Q register pair offsets by 16", func() {
+			inst := decoder.Decode(0xACC10420)
+
+			Expect(inst.Op).To(Equal(insts.OpLDP))
+			Expect(inst.AccessSize).To(Equal(uint8(16)))
+			Expect(inst.SignedImm).To(Equal(int64(32)))
+		})
+
+		It("should reject unallocated forms", func() {
+			// opc=11
+			Expect(decoder.Decode(0xE9400440).Op).To(Equal(insts.OpUnknown))
+			// LDNP with opc=01 (no non-temporal LDPSW)
+			Expect(decoder.Decode(0x68400440).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
+	Describe("SIMD Structure Load/Store Instructions", func() {
+		// LD4 {V0.4S-V3.4S}, [X1] -> 0x4C400820
+		It("should decode a multiple-structure load", func() {
+			inst := decoder.Decode(0x4C400820)
+
+			Expect(inst.Op).To(Equal(insts.OpVLDN))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDLoadStoreStruct))
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.RegCount).To(Equal(uint8(4)))
+			Expect(inst.StructElems).To(Equal(uint8(4)))
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+			Expect(inst.IndexMode).To(Equal(insts.IndexNone))
+		})
+
+		// ST1 {V5.2D-V8.2D}, [X9], X10 -> 0x4C8A2D25
+		It("should decode LD1/ST1 register counts and register post-index", func() {
+			inst := decoder.Decode(0x4C8A2D25)
+
+			Expect(inst.Op).To(Equal(insts.OpVSTN))
+			Expect(inst.RegCount).To(Equal(uint8(4)))
+			Expect(inst.StructElems).To(Equal(uint8(1)))
+			Expect(inst.IndexMode).To(Equal(insts.IndexPost))
+			Expect(inst.Rm).To(Equal(uint8(10)))
+		})
+
+		// LD1 {V0.16B, V1.16B}, [X0], #32 -> 0x4CDFA000
+		It("should set the post-index immediate to the transfer size", func() {
+			inst := decoder.Decode(0x4CDFA000)
+
+			Expect(inst.IndexMode).To(Equal(insts.IndexPost))
+			Expect(inst.Rm).To(Equal(uint8(31)))
+			Expect(inst.SignedImm).To(Equal(int64(32)))
+		})
+
+		// LD1 {V0.1D, V1.1D, V2.1D}, [X2] -> 0x0C406C40
+		It("should decode the 1D arrangement of LD1", func() {
+			inst := decoder.Decode(0x0C406C40)
+
+			Expect(inst.Op).To(Equal(insts.OpVLDN))
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+			Expect(inst.RegCount).To(Equal(uint8(3)))
+		})
+
+		// LD4 {V0.B-V3.B}[9], [X0], #4 -> 0x4DFF2400
+		// ST4 {V0.S-V3.S}[1], [X0], #16 -> 0x0DBFB000
+		// LD2 {V0.D, V1.D}[1], [X0], #16 -> 0x4DFF8400
+		It("should decode the lane index and element size of single structures", func() {
+			inst := decoder.Decode(0x4DFF2400)
+			Expect(inst.Op).To(Equal(insts.OpVLDNLane))
+			Expect(inst.StructElems).To(Equal(uint8(4)))
+			Expect(inst.AccessSize).To(Equal(uint8(1)))
+			Expect(inst.Lane).To(Equal(uint8(9)))
+			Expect(inst.SignedImm).To(Equal(int64(4)))
+
+			inst = decoder.Decode(0x0DBFB000)
+			Expect(inst.Op).To(Equal(insts.OpVSTNLane))
+			Expect(inst.AccessSize).To(Equal(uint8(4)))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+			Expect(inst.SignedImm).To(Equal(int64(16)))
+
+			inst = decoder.Decode(0x4DFF8400)
+			Expect(inst.StructElems).To(Equal(uint8(2)))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+		})
+
+		// LD3R {V0.1D, V1.1D, V2.1D}, [X0], #24 -> 0x0DDFEC00
+		It("should decode load and replicate", func() {
+			inst := decoder.Decode(0x0DDFEC00)
+
+			Expect(inst.Op).To(Equal(insts.OpVLDNR))
+			Expect(inst.StructElems).To(Equal(uint8(3)))
+			Expect(inst.RegCount).To(Equal(uint8(3)))
+			Expect(inst.AccessSize).To(Equal(uint8(8)))
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+			Expect(inst.SignedImm).To(Equal(int64(24)))
+		})
+
+		It("should reject unallocated forms", func() {
+			for _, word := range []uint32{
+				0x0C408C00, // LD2 with the 1D arrangement
+				0x4C40B000, // multiple structures, opcode 1011
+				0x4C41A000, // Rm set without post-index
+				0x0D409400, // D lane with S set
+				0x0D404400, // H lane with size<0> set
+				0x4D00C800, // store and replicate
+			} {
+				Expect(decoder.Decode(word).Op).To(Equal(insts.OpUnknown), "word %#08x", word)
+			}
 		})
 	})
 
@@ -939,10 +1224,10 @@ var _ = Describe("Decoder", func() {
 			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
 		})
 
-		// FADD V0.4S, V1.4S, V2.4S -> 0x4EA2D420
-		// Encoding: 0 | Q=1 | U=0 | 01110 | size=10 | 1 | Rm=2 | opcode=11010 | 1 | Rn=1 | Rd=0
+		// FADD V0.4S, V1.4S, V2.4S -> 0x4E22D420
+		// Encoding: 0 | Q=1 | U=0 | 01110 | a=0 | sz=0 | 1 | Rm=2 | opcode=11010 | 1 | Rn=1 | Rd=0
 		It("should decode VFADD V0.4S, V1.4S, V2.4S (floating-point add)", func() {
-			inst := decoder.Decode(0x4EA2D420)
+			inst := decoder.Decode(0x4E22D420)
 
 			Expect(inst.Op).To(Equal(insts.OpVFADD))
 			Expect(inst.IsSIMD).To(BeTrue())
@@ -950,20 +1235,20 @@ var _ = Describe("Decoder", func() {
 			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
 		})
 
-		// FSUB V0.4S, V1.4S, V2.4S -> 0x6EA2D420
-		// U=1 for FSUB
+		// FSUB V0.4S, V1.4S, V2.4S -> 0x4EA2D420
+		// a=1 (size[1]) for FSUB
 		It("should decode VFSUB V0.4S, V1.4S, V2.4S (floating-point sub)", func() {
-			inst := decoder.Decode(0x6EA2D420)
+			inst := decoder.Decode(0x4EA2D420)
 
 			Expect(inst.Op).To(Equal(insts.OpVFSUB))
 			Expect(inst.IsSIMD).To(BeTrue())
 			Expect(inst.IsFloat).To(BeTrue())
 		})
 
-		// FMUL V0.4S, V1.4S, V2.4S -> 0x6EA2DC20
+		// FMUL V0.4S, V1.4S, V2.4S -> 0x6E22DC20
 		// opcode=11011, U=1
 		It("should decode VFMUL V0.4S, V1.4S, V2.4S (floating-point mul)", func() {
-			inst := decoder.Decode(0x6EA2DC20)
+			inst := decoder.Decode(0x6E22DC20)
 
 			Expect(inst.Op).To(Equal(insts.OpVFMUL))
 			Expect(inst.IsSIMD).To(BeTrue())
@@ -999,6 +1284,556 @@ var _ = Describe("Decoder", func() {
 		})
 	})
 
+	Describe("SIMD Integer Data Processing Instructions", func() {
+		// SQADD V0.16B, V1.16B, V2.16B -> 0x4E220C20
+		// CMGT V0.2D, V1.2D, V2.2D -> 0x4EE23420
+		// BSL V0.16B, V1.16B, V2.16B -> 0x6E621C20
+		It("should decode the integer and logical three-same operations", func() {
+			inst := decoder.Decode(0x4E220C20)
+			Expect(inst.Op).To(Equal(insts.OpVSQADD))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDReg))
+			Expect(inst.Arrangement).To(Equal(insts.Arr16B))
+
+			inst = decoder.Decode(0x4EE23420)
+			Expect(inst.Op).To(Equal(insts.OpVCMGT))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+
+			inst = decoder.Decode(0x6E621C20)
+			Expect(inst.Op).To(Equal(insts.OpVBSL))
+			Expect(inst.Arrangement).To(Equal(insts.Arr16B))
+		})
+
+		// XTN2 V0.8H, V1.4S -> 0x4E612820
+		It("should decode narrowing with the destination arrangement", func() {
+			inst := decoder.Decode(0x4E612820)
+
+			Expect(inst.Op).To(Equal(insts.OpVXTN))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDTwoReg))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+			Expect(inst.Is64Bit).To(BeTrue())
+		})
+
+		// UADDL2 V0.4S, V1.8H, V2.8H -> 0x6E620020
+		It("should decode long operations with the wide arrangement", func() {
+			inst := decoder.Decode(0x6E620020)
+
+			Expect(inst.Op).To(Equal(insts.OpVUADDL))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDThreeDiff))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.Rm).To(Equal(uint8(2)))
+		})
+
+		// USRA V0.2D, V1.2D, #64 -> 0x6F401420
+		// SSHLL V0.8H, V1.8B, #3 -> 0x0F0BA420
+		It("should decode shift amounts from immh:immb", func() {
+			inst := decoder.Decode(0x6F401420)
+			Expect(inst.Op).To(Equal(insts.OpVUSRA))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDShiftImm))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+			Expect(inst.Imm).To(Equal(uint64(64)))
+
+			inst = decoder.Decode(0x0F0BA420)
+			Expect(inst.Op).To(Equal(insts.OpVSSHLL))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+			Expect(inst.Imm).To(Equal(uint64(3)))
+		})
+
+		// MOVI V0.4S, #0x21, MSL #16 -> 0x4F01D420
+		// MOVI V0.2D, #0xFF00FF00FF00FF00 -> 0x6F05E540
+		// BIC V0.2S, #0xF, LSL #8 -> 0x2F0035E0
+		It("should expand modified immediates to 64-bit patterns", func() {
+			inst := decoder.Decode(0x4F01D420)
+			Expect(inst.Op).To(Equal(insts.OpVMOVI))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDModImm))
+			Expect(inst.Imm).To(Equal(uint64(0x0021FFFF0021FFFF)))
+			Expect(inst.ShiftAmount).To(Equal(uint8(16)))
+
+			inst = decoder.Decode(0x6F05E540)
+			Expect(inst.Op).To(Equal(insts.OpVMOVI))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+			Expect(inst.Imm).To(Equal(uint64(0xFF00FF00FF00FF00)))
+
+			inst = decoder.Decode(0x2F0035E0)
+			Expect(inst.Op).To(Equal(insts.OpVBICImm))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2S))
+			Expect(inst.Imm).To(Equal(uint64(0x00000F0000000F00)))
+		})
+
+		// DUP V0.4S, V1.S[1] -> 0x4E0C0420
+		// INS V0.S[1], W1 -> 0x4E0C1C20
+		// SMOV X0, V1.H[2] -> 0x4E0A2C20
+		It("should decode element indices of copies", func() {
+			inst := decoder.Decode(0x4E0C0420)
+			Expect(inst.Op).To(Equal(insts.OpDUPElem))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDCopy))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+
+			inst = decoder.Decode(0x4E0C1C20)
+			Expect(inst.Op).To(Equal(insts.OpINS))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+
+			inst = decoder.Decode(0x4E0A2C20)
+			Expect(inst.Op).To(Equal(insts.OpSMOV))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+			Expect(inst.Lane).To(Equal(uint8(2)))
+			Expect(inst.Is64Bit).To(BeTrue())
+		})
+
+		// SADDLV D0, V1.4S -> 0x4EB03820
+		// UZP2 V0.16B, V1.16B, V2.16B -> 0x4E025820
+		It("should decode reductions and permutes", func() {
+			inst := decoder.Decode(0x4EB03820)
+			Expect(inst.Op).To(Equal(insts.OpVSADDLV))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDAcross))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+
+			inst = decoder.Decode(0x4E025820)
+			Expect(inst.Op).To(Equal(insts.OpVUZP2))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDPermute))
+		})
+
+		// ZIP1 V0.4S, V1.4S, V2.4S -> 0x4E823820
+		// TRN2 V0.8H, V1.8H, V2.8H -> 0x4E426820
+		// EXT V0.8B, V1.8B, V2.8B, #7 -> 0x2E023820
+		// REV32 V0.8H, V1.8H -> 0x6E600820
+		It("should decode zips, transposes, extracts and reverses", func() {
+			inst := decoder.Decode(0x4E823820)
+			Expect(inst.Op).To(Equal(insts.OpVZIP1))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDPermute))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+
+			inst = decoder.Decode(0x4E426820)
+			Expect(inst.Op).To(Equal(insts.OpVTRN2))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+
+			inst = decoder.Decode(0x2E023820)
+			Expect(inst.Op).To(Equal(insts.OpVEXT))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDExtract))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8B))
+			Expect(inst.Imm).To(Equal(uint64(7)))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+
+			inst = decoder.Decode(0x6E600820)
+			Expect(inst.Op).To(Equal(insts.OpVREV32))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDTwoReg))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+		})
+
+		// TBX V0.16B, { V30.16B, V31.16B, V0.16B }, V4.16B -> 0x4E0453C0
+		// MOV V0.S[1], V1.S[3] -> 0x6E0C6420
+		It("should decode table lookups and element inserts", func() {
+			inst := decoder.Decode(0x4E0453C0)
+			Expect(inst.Op).To(Equal(insts.OpVTBX))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDTableLookup))
+			Expect(inst.Rn).To(Equal(uint8(30)))
+			Expect(inst.RegCount).To(Equal(uint8(3)))
+			Expect(inst.Rm).To(Equal(uint8(4)))
+			Expect(inst.Arrangement).To(Equal(insts.Arr16B))
+
+			inst = decoder.Decode(0x6E0C6420)
+			Expect(inst.Op).To(Equal(insts.OpINSElem))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+			Expect(inst.Imm2).To(Equal(uint64(3)))
+		})
+
+		// ADDP D0, V1.2D -> 0x5EF1B820
+		// CMEQ D0, D1, D2 -> 0x7EE28C20
+		// USHR D0, D1, #3 -> 0x7F7D0420
+		// MOV D0, V1.D[1] -> 0x5E180420
+		It("should decode scalar integer operations on doublewords", func() {
+			inst := decoder.Decode(0x5EF1B820)
+			Expect(inst.Op).To(Equal(insts.OpVADDP))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDScalarPairwise))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+
+			inst = decoder.Decode(0x7EE28C20)
+			Expect(inst.Op).To(Equal(insts.OpVCMEQ))
+			Expect(inst.IsScalar).To(BeTrue())
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+
+			inst = decoder.Decode(0x7F7D0420)
+			Expect(inst.Op).To(Equal(insts.OpVUSHR))
+			Expect(inst.IsScalar).To(BeTrue())
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+			Expect(inst.Imm).To(Equal(uint64(3)))
+
+			inst = decoder.Decode(0x5E180420)
+			Expect(inst.Op).To(Equal(insts.OpDUPElem))
+			Expect(inst.IsScalar).To(BeTrue())
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+		})
+
+		// MUL V0.4S, V1.4S, V2.S[1] -> 0x4FA28020
+		// MLA V0.8H, V1.8H, V15.H[7] -> 0x6F7F0820
+		// SQDMULH S0, S1, V2.S[1] -> 0x5FA2C020
+		It("should decode integer multiplies by element", func() {
+			inst := decoder.Decode(0x4FA28020)
+			Expect(inst.Op).To(Equal(insts.OpVMUL))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDElem))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+
+			inst = decoder.Decode(0x6F7F0820)
+			Expect(inst.Op).To(Equal(insts.OpVMLA))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+			Expect(inst.Rm).To(Equal(uint8(15)))
+			Expect(inst.Lane).To(Equal(uint8(7)))
+
+			inst = decoder.Decode(0x5FA2C020)
+			Expect(inst.Op).To(Equal(insts.OpVSQDMULH))
+			Expect(inst.IsScalar).To(BeTrue())
+			Expect(inst.Arrangement).To(Equal(insts.Arr1S))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+		})
+
+		// USHL V0.2D, V1.2D, V2.2D -> 0x6EE24420
+		// SQRDMULH V0.4H, V1.4H, V2.4H -> 0x2E62B420
+		// RSHRN V0.8B, V1.8H, #3 -> 0x0F0D8C20
+		// UADDLP V0.8H, V1.16B -> 0x6E202820
+		// SADALP V0.2D, V1.4S -> 0x4EA06820
+		It("should decode register shifts, rounding narrows and pairwise long adds", func() {
+			inst := decoder.Decode(0x6EE24420)
+			Expect(inst.Op).To(Equal(insts.OpVUSHL))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+
+			inst = decoder.Decode(0x2E62B420)
+			Expect(inst.Op).To(Equal(insts.OpVSQRDMULH))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4H))
+
+			inst = decoder.Decode(0x0F0D8C20)
+			Expect(inst.Op).To(Equal(insts.OpVRSHRN))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8B))
+			Expect(inst.Imm).To(Equal(uint64(3)))
+
+			inst = decoder.Decode(0x6E202820)
+			Expect(inst.Op).To(Equal(insts.OpVUADDLP))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+
+			inst = decoder.Decode(0x4EA06820)
+			Expect(inst.Op).To(Equal(insts.OpVSADALP))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+		})
+
+		It("should reject unallocated forms", func() {
+			for _, word := range []uint32{
+				0x4EE26420, // SMAX with the 2D arrangement
+				0x0EE08820, // CMGT (zero) with the 1D arrangement
+				0x4EE12820, // XTN from 128-bit elements
+				0x0EB1B820, // ADDV with the 2S arrangement
+				0x6F00FC20, // FMOV (vector, immediate), 64-bit
+				0x4E0C3C20, // UMOV of a word to an X register
+				0x4E182C20, // SMOV of a doubleword
+				0x2E024020, // EXT with an index beyond an 8B vector
+				0x6EA00820, // REV32 of words
+				0x4E601820, // REV16 of halfwords
+				0x4EE00820, // REV64 of doublewords
+				0x5EA28420, // ADD (scalar) of words
+				0x7F3D0420, // USHR (scalar) of words
+				0x0E22B420, // SQDMULH of bytes
+				0x4FE28020, // MUL (by element) of doublewords
+				0x6EE02820, // UADDLP of doublewords
+			} {
+				Expect(decoder.Decode(word).Op).To(Equal(insts.OpUnknown), "0x%08X", word)
+			}
+		})
+	})
+
+	Describe("SIMD Floating-Point Instructions", func() {
+		// FADD V0.8H, V1.8H, V2.8H -> 0x4E421420
+		// FMINP V0.2S, V1.2S, V2.2S -> 0x2EA2F420
+		// FRINTI V0.4S, V1.4S -> 0x6EA19820
+		It("should decode half, single and double precision arithmetic", func() {
+			inst := decoder.Decode(0x4E421420)
+			Expect(inst.Op).To(Equal(insts.OpVFADD))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDReg))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+			Expect(inst.IsFloat).To(BeTrue())
+
+			inst = decoder.Decode(0x2EA2F420)
+			Expect(inst.Op).To(Equal(insts.OpVFMINP))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2S))
+
+			inst = decoder.Decode(0x6EA19820)
+			Expect(inst.Op).To(Equal(insts.OpVFRINTI))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDTwoReg))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+		})
+
+		// FCVTL2 V0.4S, V1.8H -> 0x4E217820
+		// FCVTN V0.4H, V1.4S -> 0x0E216820
+		It("should decode precision conversions with the destination arrangement", func() {
+			inst := decoder.Decode(0x4E217820)
+			Expect(inst.Op).To(Equal(insts.OpVFCVTL))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+			Expect(inst.Is64Bit).To(BeTrue())
+
+			inst = decoder.Decode(0x0E216820)
+			Expect(inst.Op).To(Equal(insts.OpVFCVTN))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4H))
+			Expect(inst.Is64Bit).To(BeFalse())
+		})
+
+		// FMLA V0.8H, V1.8H, V2.H[7] -> 0x4F321820
+		// FMUL V0.2D, V1.2D, V2.D[1] -> 0x4FC29820
+		It("should decode the element index of by element operations", func() {
+			inst := decoder.Decode(0x4F321820)
+			Expect(inst.Op).To(Equal(insts.OpVFMLA))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDElem))
+			Expect(inst.Arrangement).To(Equal(insts.Arr8H))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Lane).To(Equal(uint8(7)))
+
+			inst = decoder.Decode(0x4FC29820)
+			Expect(inst.Op).To(Equal(insts.OpVFMUL))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+		})
+
+		// FMLA S0, S1, V2.S[2] -> 0x5F821820
+		// FMUL D0, D1, V2.D[1] -> 0x5FC29820
+		It("should decode the scalar by element operations", func() {
+			inst := decoder.Decode(0x5F821820)
+			Expect(inst.Op).To(Equal(insts.OpVFMLA))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDElem))
+			Expect(inst.IsScalar).To(BeTrue())
+			Expect(inst.Arrangement).To(Equal(insts.Arr1S))
+			Expect(inst.Lane).To(Equal(uint8(2)))
+
+			inst = decoder.Decode(0x5FC29820)
+			Expect(inst.Op).To(Equal(insts.OpVFMUL))
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+			Expect(inst.Lane).To(Equal(uint8(1)))
+		})
+
+		// SCVTF D0, D1 -> 0x5E61D820
+		// FCVTZS S0, S1 -> 0x5EA1B820
+		// FRSQRTE D0, D1 -> 0x7EE1D820
+		// FRECPS D0, D1, D2 -> 0x5E62FC20
+		// FADD D0, D1, D2 has no scalar form -> 0x5E62D420
+		It("should decode scalar conversions, estimates and steps", func() {
+			inst := decoder.Decode(0x5E61D820)
+			Expect(inst.Op).To(Equal(insts.OpVSCVTF))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDTwoReg))
+			Expect(inst.IsScalar).To(BeTrue())
+			Expect(inst.Arrangement).To(Equal(insts.Arr1D))
+
+			inst = decoder.Decode(0x5EA1B820)
+			Expect(inst.Op).To(Equal(insts.OpVFCVTZS))
+			Expect(inst.Arrangement).To(Equal(insts.Arr1S))
+
+			inst = decoder.Decode(0x7EE1D820)
+			Expect(inst.Op).To(Equal(insts.OpVFRSQRTE))
+
+			inst = decoder.Decode(0x5E62FC20)
+			Expect(inst.Op).To(Equal(insts.OpVFRECPS))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDReg))
+			Expect(inst.IsScalar).To(BeTrue())
+
+			inst = decoder.Decode(0x5E62D420)
+			Expect(inst.Op).To(Equal(insts.OpUnknown))
+		})
+
+		// FADDP S0, V1.2S -> 0x7E30D820
+		// FMAXNMV S0, V1.4S -> 0x6E30C820
+		It("should decode scalar pairwise and across-lane reductions", func() {
+			inst := decoder.Decode(0x7E30D820)
+			Expect(inst.Op).To(Equal(insts.OpVFADDP))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDScalarPairwise))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2S))
+
+			inst = decoder.Decode(0x6E30C820)
+			Expect(inst.Op).To(Equal(insts.OpVFMAXNMV))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDAcross))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+		})
+
+		// FMOV V0.2D, #-0.5 -> 0x6F07F400
+		It("should expand the FMOV immediate", func() {
+			inst := decoder.Decode(0x6F07F400)
+
+			Expect(inst.Op).To(Equal(insts.OpVFMOVImm))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDModImm))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+			Expect(inst.Imm).To(Equal(uint64(0xBFE0000000000000)))
+		})
+
+		It("should reject unallocated forms", func() {
+			for _, word := range []uint32{
+				0x0E62D420, // FADD with the 1D arrangement
+				0x6EA18820, // FRINT opcode 11000 with U=1, a=1
+				0x0FC29820, // FMUL (by element) of doubles, 64-bit
+				0x5E30D820, // FADDP (scalar) with U=0
+			} {
+				Expect(decoder.Decode(word).Op).To(Equal(insts.OpUnknown), "0x%08X", word)
+			}
+		})
+	})
+
+	Describe("Cryptographic Instructions", func() {
+		// AESD V2.16B, V3.16B -> 0x4E285862
+		// SHA1C Q0, S1, V2.4S -> 0x5E020020
+		// SHA256SU0 V0.4S, V1.4S -> 0x5E282820
+		It("should decode AES and SHA-1/SHA-256 instructions", func() {
+			inst := decoder.Decode(0x4E285862)
+			Expect(inst.Op).To(Equal(insts.OpAESD))
+			Expect(inst.Format).To(Equal(insts.FormatCryptoTwoReg))
+			Expect(inst.Rd).To(Equal(uint8(2)))
+			Expect(inst.Rn).To(Equal(uint8(3)))
+			Expect(inst.IsSIMD).To(BeTrue())
+
+			inst = decoder.Decode(0x5E020020)
+			Expect(inst.Op).To(Equal(insts.OpSHA1C))
+			Expect(inst.Format).To(Equal(insts.FormatCryptoThreeReg))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Arrangement).To(Equal(insts.Arr4S))
+
+			inst = decoder.Decode(0x5E282820)
+			Expect(inst.Op).To(Equal(insts.OpSHA256SU0))
+			Expect(inst.Format).To(Equal(insts.FormatCryptoTwoReg))
+		})
+
+		// SHA512SU1 V0.2D, V1.2D, V2.2D -> 0xCE628820
+		// BCAX V0.16B, V1.16B, V2.16B, V3.16B -> 0xCE220C20
+		// XAR V0.2D, V1.2D, V2.2D, #10 -> 0xCE822820
+		It("should decode SHA-512 and SHA3 instructions", func() {
+			inst := decoder.Decode(0xCE628820)
+			Expect(inst.Op).To(Equal(insts.OpSHA512SU1))
+			Expect(inst.Format).To(Equal(insts.FormatCryptoThreeReg))
+			Expect(inst.Arrangement).To(Equal(insts.Arr2D))
+
+			inst = decoder.Decode(0xCE220C20)
+			Expect(inst.Op).To(Equal(insts.OpBCAX))
+			Expect(inst.Format).To(Equal(insts.FormatCryptoFourReg))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Rt2).To(Equal(uint8(3)))
+
+			inst = decoder.Decode(0xCE822820)
+			Expect(inst.Op).To(Equal(insts.OpXAR))
+			Expect(inst.Imm).To(Equal(uint64(10)))
+		})
+
+		// PMULL2 V0.1Q, V1.2D, V2.2D -> 0x4EE2E020
+		It("should decode polynomial long multiplies", func() {
+			inst := decoder.Decode(0x4EE2E020)
+			Expect(inst.Op).To(Equal(insts.OpVPMULL))
+			Expect(inst.Format).To(Equal(insts.FormatSIMDThreeDiff))
+			Expect(inst.Arrangement).To(Equal(insts.Arr1Q))
+			Expect(inst.Is64Bit).To(BeTrue())
+		})
+
+		It("should reject unallocated forms", func() {
+			for _, word := range []uint32{
+				0x4E684820, // AESE with size 01
+				0x5E420020, // SHA1C with size 01
+				0x5E027020, // three-register SHA opcode 111
+				0xCE62C020, // SM3PARTW1
+				0xCEC08420, // SM4E
+				0xCE420C20, // SM3SS1
+				0x0E62E020, // PMULL of halfwords
+			} {
+				Expect(decoder.Decode(word).Op).To(Equal(insts.OpUnknown), "0x%08X", word)
+			}
+		})
+	})
+
+	Describe("Pointer Authentication and BTI", func() {
+		// PACIASP -> 0xD503233F
+		// AUTIB1716 -> 0xD50321DF
+		// XPACLRI -> 0xD50320FF
+		It("should decode the hint forms", func() {
+			inst := decoder.Decode(0xD503233F)
+			Expect(inst.Op).To(Equal(insts.OpPAC))
+			Expect(inst.Format).To(Equal(insts.FormatBarrier))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyIA))
+			Expect(inst.Rd).To(Equal(uint8(30)))
+			Expect(inst.Rn).To(Equal(uint8(31)))
+			Expect(inst.ZeroModifier).To(BeFalse())
+			Expect(inst.Imm).To(Equal(uint64(25)))
+
+			inst = decoder.Decode(0xD50321DF)
+			Expect(inst.Op).To(Equal(insts.OpAUT))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyIB))
+			Expect(inst.Rd).To(Equal(uint8(17)))
+			Expect(inst.Rn).To(Equal(uint8(16)))
+
+			inst = decoder.Decode(0xD50320FF)
+			Expect(inst.Op).To(Equal(insts.OpXPAC))
+			Expect(inst.Rd).To(Equal(uint8(30)))
+		})
+
+		// BTI -> 0xD503241F
+		// BTI JC -> 0xD50324DF
+		It("should decode BTI with its targets", func() {
+			inst := decoder.Decode(0xD503241F)
+			Expect(inst.Op).To(Equal(insts.OpBTI))
+			Expect(inst.Imm).To(BeZero())
+
+			inst = decoder.Decode(0xD50324DF)
+			Expect(inst.Op).To(Equal(insts.OpBTI))
+			Expect(inst.Imm).To(Equal(uint64(insts.BTITargetCall | insts.BTITargetJump)))
+		})
+
+		// PACDB X1, X2 -> 0xDAC10C41
+		// AUTIZA X3 -> 0xDAC133E3
+		// XPACD X4 -> 0xDAC147E4
+		// PACGA X1, X2, SP -> 0x9ADF3041
+		It("should decode the data-processing forms", func() {
+			inst := decoder.Decode(0xDAC10C41)
+			Expect(inst.Op).To(Equal(insts.OpPAC))
+			Expect(inst.Format).To(Equal(insts.FormatDataProc1Src))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyDB))
+			Expect(inst.PACKey.IsData()).To(BeTrue())
+			Expect(inst.Rd).To(Equal(uint8(1)))
+			Expect(inst.Rn).To(Equal(uint8(2)))
+
+			inst = decoder.Decode(0xDAC133E3)
+			Expect(inst.Op).To(Equal(insts.OpAUT))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyIA))
+			Expect(inst.ZeroModifier).To(BeTrue())
+
+			inst = decoder.Decode(0xDAC147E4)
+			Expect(inst.Op).To(Equal(insts.OpXPAC))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyDA))
+
+			inst = decoder.Decode(0x9ADF3041)
+			Expect(inst.Op).To(Equal(insts.OpPACGA))
+			Expect(inst.Format).To(Equal(insts.FormatDataProc2Src))
+			Expect(inst.Rm).To(Equal(uint8(31)))
+
+			Expect(decoder.Decode(0xDAC12041).Op).To(Equal(insts.OpUnknown)) // PACIZA with Rn != 31
+		})
+
+		// RETAB -> 0xD65F0FFF
+		// BRAAZ X5 -> 0xD61F08BF
+		// BLRAA X5, X6 -> 0xD73F08A6
+		It("should decode authenticated branches", func() {
+			inst := decoder.Decode(0xD65F0FFF)
+			Expect(inst.Op).To(Equal(insts.OpRET))
+			Expect(inst.Format).To(Equal(insts.FormatBranchReg))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyIB))
+			Expect(inst.Rn).To(Equal(uint8(30)))
+			Expect(inst.Rm).To(Equal(uint8(31)))
+
+			inst = decoder.Decode(0xD61F08BF)
+			Expect(inst.Op).To(Equal(insts.OpBR))
+			Expect(inst.PACKey).To(Equal(insts.PACKeyIA))
+			Expect(inst.ZeroModifier).To(BeTrue())
+			Expect(inst.Rn).To(Equal(uint8(5)))
+
+			inst = decoder.Decode(0xD73F08A6)
+			Expect(inst.Op).To(Equal(insts.OpBLR))
+			Expect(inst.ZeroModifier).To(BeFalse())
+			Expect(inst.Rm).To(Equal(uint8(6)))
+
+			Expect(decoder.Decode(0xD65F03C0).PACKey).To(Equal(insts.PACKeyNone)) // RET
+		})
+	})
+
 	Describe("PC-Relative Addressing (ADR, ADRP)", func() {
 		// ADRP X0, 0x93000 (from CoreMark startup)
 		// Encoding: 1 | immlo | 10000 | immhi | Rd
@@ -1286,6 +2121,132 @@ var _ = Describe("Decoder", func() {
 		})
 	})
 
+	Describe("Long and High Multiply Instructions", func() {
+		// SMADDL X0, W1, W2, X3 -> 0x9B220C20
+		// Format: sf=1, op54=00, 11011, op31=001, Rm=2, o0=0, Ra=3, Rn=1, Rd=0
+		It("should decode SMADDL X0, W1, W2, X3", func() {
+			inst := decoder.Decode(0x9B220C20)
+
+			Expect(inst.Op).To(Equal(insts.OpSMADDL))
+			Expect(inst.Format).To(Equal(insts.FormatDataProc3Src))
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+			Expect(inst.Rm).To(Equal(uint8(2)))
+			Expect(inst.Rt2).To(Equal(uint8(3))) // Ra reuses Rt2 field
+		})
+
+		It("should decode UMSUBL, SMULH and UMULH", func() {
+			Expect(decoder.Decode(0x9BA28C20).Op).To(Equal(insts.OpUMSUBL)) // UMSUBL X0, W1, W2, X3
+			Expect(decoder.Decode(0x9B427C20).Op).To(Equal(insts.OpSMULH))  // SMULH X0, X1, X2
+			Expect(decoder.Decode(0x9BC27C20).Op).To(Equal(insts.OpUMULH))  // UMULH X0, X1, X2
+		})
+
+		It("should reject the unallocated 32-bit forms", func() {
+			// UMADDL with sf=0 -> 0x1BA20C20
+			Expect(decoder.Decode(0x1BA20C20).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
+	Describe("Data Processing (1 source) Instructions", func() {
+		// REV X0, X1 -> 0xDAC00C20 (previously mistaken for SDIV)
+		It("should decode REV X0, X1", func() {
+			inst := decoder.Decode(0xDAC00C20)
+
+			Expect(inst.Op).To(Equal(insts.OpREV))
+			Expect(inst.Format).To(Equal(insts.FormatDataProc1Src))
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.Rd).To(Equal(uint8(0)))
+			Expect(inst.Rn).To(Equal(uint8(1)))
+		})
+
+		It("should decode the 32-bit REV from the REV32 opcode", func() {
+			Expect(decoder.Decode(0x5AC00820).Op).To(Equal(insts.OpREV))   // REV W0, W1
+			Expect(decoder.Decode(0xDAC00820).Op).To(Equal(insts.OpREV32)) // REV32 X0, X1
+		})
+
+		It("should decode RBIT, REV16, CLZ and CLS", func() {
+			Expect(decoder.Decode(0xDAC00020).Op).To(Equal(insts.OpRBIT))  // RBIT X0, X1
+			Expect(decoder.Decode(0x5AC00420).Op).To(Equal(insts.OpREV16)) // REV16 W0, W1
+			Expect(decoder.Decode(0xDAC01020).Op).To(Equal(insts.OpCLZ))   // CLZ X0, X1
+			Expect(decoder.Decode(0x5AC01420).Op).To(Equal(insts.OpCLS))   // CLS W0, W1
+		})
+	})
+
+	Describe("Add/Subtract with Carry Instructions", func() {
+		// ADCS X0, X1, X2 -> 0xBA020020
+		It("should decode ADCS X0, X1, X2", func() {
+			inst := decoder.Decode(0xBA020020)
+
+			Expect(inst.Op).To(Equal(insts.OpADC))
+			Expect(inst.Format).To(Equal(insts.FormatAddSubCarry))
+			Expect(inst.SetFlags).To(BeTrue())
+			Expect(inst.Is64Bit).To(BeTrue())
+			Expect(inst.Rm).To(Equal(uint8(2)))
+		})
+
+		// SBC W4, W5, W6 -> 0x5A0600A4
+		It("should decode SBC W4, W5, W6", func() {
+			inst := decoder.Decode(0x5A0600A4)
+
+			Expect(inst.Op).To(Equal(insts.OpSBC))
+			Expect(inst.SetFlags).To(BeFalse())
+			Expect(inst.Is64Bit).To(BeFalse())
+		})
+	})
+
+	Describe("Add/Subtract (Extended Register) Instructions", func() {
+		// ADD X0, SP, W1, UXTW #2 -> 0x8B214BE0
+		// Format: sf=1, op=0, S=0, 01011, 00, 1, Rm=1, option=010, imm3=2, Rn=31, Rd=0
+		It("should decode ADD X0, SP, W1, UXTW #2", func() {
+			inst := decoder.Decode(0x8B214BE0)
+
+			Expect(inst.Op).To(Equal(insts.OpADD))
+			Expect(inst.Format).To(Equal(insts.FormatAddSubExt))
+			Expect(inst.Rn).To(Equal(uint8(31)))
+			Expect(inst.Rm).To(Equal(uint8(1)))
+			Expect(inst.ShiftType).To(Equal(insts.ShiftType(0b010)))
+			Expect(inst.ShiftAmount).To(Equal(uint8(2)))
+		})
+
+		// CMP W11, W3, SXTH -> SUBS WZR, W11, W3, SXTH
+		It("should decode SUBS with a sign-extended operand", func() {
+			inst := decoder.Decode(0x6B23A17F)
+
+			Expect(inst.Op).To(Equal(insts.OpSUB))
+			Expect(inst.SetFlags).To(BeTrue())
+			Expect(inst.ShiftType).To(Equal(insts.ShiftType(0b101)))
+			Expect(inst.ShiftAmount).To(Equal(uint8(0)))
+		})
+
+		It("should reject shifts greater than 4", func() {
+			// ADD X0, X1, X2, UXTX #5
+			Expect(decoder.Decode(0x8B227420).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
+	Describe("CRC32 Instructions", func() {
+		// CRC32CX W0, W1, X2 -> 0x9AC25C20
+		It("should decode CRC32CX W0, W1, X2", func() {
+			inst := decoder.Decode(0x9AC25C20)
+
+			Expect(inst.Op).To(Equal(insts.OpCRC32C))
+			Expect(inst.Format).To(Equal(insts.FormatDataProc2Src))
+			Expect(inst.Imm).To(Equal(uint64(8)))
+		})
+
+		// CRC32B W3, W4, W5 -> 0x1AC54083
+		It("should decode CRC32B W3, W4, W5", func() {
+			inst := decoder.Decode(0x1AC54083)
+
+			Expect(inst.Op).To(Equal(insts.OpCRC32))
+			Expect(inst.Imm).To(Equal(uint64(1)))
+		})
+
+		It("should reject a 64-bit data size without sf", func() {
+			Expect(decoder.Decode(0x1AC24C20).Op).To(Equal(insts.OpUnknown))
+		})
+	})
+
 	Describe("Test and Branch Instructions", func() {
 		// TBZ X0, #5, .+8 -> 0x36280020
 		// Format: b5=0, 011011, op=0, b40=00101, imm14=2, Rt=0
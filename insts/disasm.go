@@ -0,0 +1,1407 @@
+package insts
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Disassemble renders inst in GNU assembler syntax, using the preferred alias
+// (MOV, CMP, LSL, CSET, ...) where the encoding has one. pc is the address of
+// the instruction; branch and PC-relative targets are printed as absolute
+// addresses. The text reflects the decoded fields, so it shows what the
+// simulator executes. Instructions the decoder does not recognize are
+// rendered as ".inst 0x<word>".
+func Disassemble(inst *Instruction, pc uint64) string {
+	d := disassembler{inst: inst, pc: pc, hasPC: true}
+	return d.text()
+}
+
+// String renders the instruction like Disassemble, with PC-relative targets
+// shown as offsets from the instruction (".+0x10").
+func (inst *Instruction) String() string {
+	d := disassembler{inst: inst}
+	return d.text()
+}
+
+// disassembler formats a single instruction.
+type disassembler struct {
+	inst  *Instruction
+	pc    uint64
+	hasPC bool
+}
+
+// text returns the mnemonic followed by the comma-separated operands.
+func (d *disassembler) text() string {
+	mnemonic, operands := d.format()
+	if mnemonic == "" {
+		return fmt.Sprintf(".inst 0x%08x", d.inst.Word)
+	}
+	if len(operands) == 0 {
+		return mnemonic
+	}
+	return mnemonic + " " + strings.Join(operands, ", ")
+}
+
+// format returns the mnemonic and operands, or an empty mnemonic if the
+// instruction cannot be rendered.
+func (d *disassembler) format() (string, []string) {
+	switch d.inst.Op {
+	case OpUnknown:
+		return "", nil
+	case OpNOP:
+		return "nop", nil
+	}
+
+	switch d.inst.Format {
+	case FormatDPImm:
+		return d.addSubImm()
+	case FormatDPReg:
+		return d.dpReg()
+	case FormatLogicalImm:
+		return d.logicalImm()
+	case FormatMoveWide:
+		return d.moveWide()
+	case FormatBitfield:
+		return d.bitfield()
+	case FormatExtract:
+		return d.extract()
+	case FormatPCRel:
+		return d.pcRel()
+	case FormatBranch, FormatBranchCond, FormatBranchReg,
+		FormatTestBranch, FormatCompareBranch:
+		return d.branch()
+	case FormatException:
+		return d.exception()
+	case FormatCondSelect:
+		return d.condSelect()
+	case FormatCondCmp:
+		return d.condCmp()
+	case FormatDataProc2Src:
+		return d.dataProc2Src()
+	case FormatDataProc3Src:
+		return d.dataProc3Src()
+	case FormatDataProc1Src:
+		return d.dataProc1Src()
+	case FormatAddSubCarry:
+		return d.addSubCarry()
+	case FormatAddSubExt:
+		return d.addSubExt()
+	case FormatLoadStore, FormatSIMDLoadStore:
+		return d.loadStore()
+	case FormatLoadStoreLit:
+		return d.loadStoreLit()
+	case FormatLoadStorePair:
+		return d.loadStorePair()
+	case FormatSIMDLoadStoreStruct:
+		return d.loadStoreStruct()
+	case FormatSIMDReg:
+		return d.simdReg()
+	case FormatSIMDCopy:
+		return d.simdCopy()
+	case FormatSIMDTwoReg:
+		return d.simdTwoReg()
+	case FormatSIMDThreeDiff:
+		return d.simdThreeDiff()
+	case FormatSIMDShiftImm:
+		return d.simdShiftImm()
+	case FormatSIMDModImm:
+		return d.simdModImm()
+	case FormatSIMDAcross:
+		return d.simdAcross()
+	case FormatSIMDPermute:
+		return d.simdPermute()
+	case FormatSIMDElem:
+		return d.simdElem()
+	case FormatSIMDScalarPairwise:
+		return d.simdScalarPairwise()
+	case FormatSIMDExtract:
+		return d.simdExtract()
+	case FormatSIMDTableLookup:
+		return d.simdTableLookup()
+	case FormatCryptoTwoReg, FormatCryptoThreeReg, FormatCryptoFourReg:
+		return d.crypto()
+	case FormatSystemReg:
+		return d.systemReg()
+	case FormatFPDataProc:
+		return d.fpDataProc()
+	case FormatFPConvert:
+		return d.fpConvert()
+	case FormatLoadStoreExclusive:
+		return d.exclusive()
+	case FormatAtomic:
+		return d.atomic()
+	case FormatBarrier:
+		return d.barrier()
+	default:
+		return "", nil
+	}
+}
+
+// condNames are the GNU names of the condition codes.
+var condNames = [16]string{
+	"eq", "ne", "cs", "cc", "mi", "pl", "vs", "vc",
+	"hi", "ls", "ge", "lt", "gt", "le", "al", "nv",
+}
+
+// shiftNames are the names of the register shift types.
+var shiftNames = [4]string{"lsl", "lsr", "asr", "ror"}
+
+// extendNames are the names of the register extend options.
+var extendNames = [8]string{"uxtb", "uxth", "uxtw", "uxtx", "sxtb", "sxth", "sxtw", "sxtx"}
+
+// arrangementNames are the names of the SIMD arrangement specifiers.
+var arrangementNames = [...]string{
+	Arr8B: "8b", Arr16B: "16b", Arr4H: "4h", Arr8H: "8h",
+	Arr2S: "2s", Arr4S: "4s", Arr2D: "2d", Arr1D: "1d", Arr1Q: "1q",
+}
+
+// reg names general-purpose register n, where register 31 is the zero
+// register.
+func reg(n uint8, is64 bool) string {
+	switch {
+	case n == 31 && is64:
+		return "xzr"
+	case n == 31:
+		return "wzr"
+	case is64:
+		return fmt.Sprintf("x%d", n)
+	default:
+		return fmt.Sprintf("w%d", n)
+	}
+}
+
+// regOrSP names general-purpose register n, where register 31 is the stack
+// pointer.
+func regOrSP(n uint8, is64 bool) string {
+	switch {
+	case n == 31 && is64:
+		return "sp"
+	case n == 31:
+		return "wsp"
+	default:
+		return reg(n, is64)
+	}
+}
+
+// fpReg names scalar floating-point register n of precision t.
+func fpReg(n uint8, t FPType) string {
+	switch t {
+	case FPSingle:
+		return fmt.Sprintf("s%d", n)
+	case FPHalf:
+		return fmt.Sprintf("h%d", n)
+	default:
+		return fmt.Sprintf("d%d", n)
+	}
+}
+
+// vecReg names vector register n with the given arrangement.
+func vecReg(n uint8, arr SIMDArrangement) string {
+	return fmt.Sprintf("v%d.%s", n, arrangementNames[arr])
+}
+
+// operandReg names SIMD&FP register n as an operand of i: a scalar of the
+// element size for the Advanced SIMD scalar forms, otherwise a vector.
+func operandReg(n uint8, i *Instruction) string {
+	if i.IsScalar {
+		return scalarReg(n, arrangementSize(i.Arrangement))
+	}
+	return vecReg(n, i.Arrangement)
+}
+
+// elemNames are the element size specifiers by size in bytes.
+var elemNames = [...]string{1: "b", 2: "h", 4: "s", 8: "d"}
+
+// elemReg names element lane of vector register n with elements of size
+// bytes.
+func elemReg(n, size, lane uint8) string {
+	return fmt.Sprintf("v%d.%s[%d]", n, elemNames[size], lane)
+}
+
+// arrangementSize returns the element size in bytes of an arrangement.
+func arrangementSize(arr SIMDArrangement) uint8 {
+	return [...]uint8{1, 1, 2, 2, 4, 4, 8, 8, 16, 1, 2, 4}[arr]
+}
+
+// arrangementOf returns the arrangement of size-byte elements in a 64-bit
+// or, if q is set, 128-bit vector.
+func arrangementOf(size uint8, q bool) SIMDArrangement {
+	arr := [...]SIMDArrangement{1: Arr8B, 2: Arr4H, 4: Arr2S, 8: Arr1D}[size]
+	switch {
+	case !q:
+		return arr
+	case size == 8:
+		return Arr2D
+	default:
+		return arr + 1
+	}
+}
+
+// hexImm formats an immediate the way GNU prints data values.
+func hexImm(v uint64) string {
+	return fmt.Sprintf("#0x%x", v)
+}
+
+// decImm formats an immediate the way GNU prints shift amounts, bit
+// positions and address offsets.
+func decImm(v int64) string {
+	return fmt.Sprintf("#%d", v)
+}
+
+// target formats the destination of a PC-relative instruction.
+func (d *disassembler) target(offset int64) string {
+	if d.hasPC {
+		return fmt.Sprintf("0x%x", uint64(int64(d.pc)+offset))
+	}
+	if offset < 0 {
+		return fmt.Sprintf(".-0x%x", uint64(-offset))
+	}
+	return fmt.Sprintf(".+0x%x", uint64(offset))
+}
+
+// addSubImm formats ADD/SUB (immediate) and the MOV (to/from SP), CMP and
+// CMN aliases.
+func (d *disassembler) addSubImm() (string, []string) {
+	i := d.inst
+	name := "add"
+	if i.Op == OpSUB {
+		name = "sub"
+	}
+	rn := regOrSP(i.Rn, i.Is64Bit)
+	imm := []string{hexImm(i.Imm)}
+	if i.Shift != 0 {
+		imm = append(imm, fmt.Sprintf("lsl #%d", i.Shift))
+	}
+
+	if i.SetFlags {
+		if i.Rd == 31 {
+			alias := "cmn"
+			if i.Op == OpSUB {
+				alias = "cmp"
+			}
+			return alias, append([]string{rn}, imm...)
+		}
+		return name + "s", append([]string{reg(i.Rd, i.Is64Bit), rn}, imm...)
+	}
+
+	rd := regOrSP(i.Rd, i.Is64Bit)
+	if i.Op == OpADD && i.Imm == 0 && i.Shift == 0 && (i.Rd == 31 || i.Rn == 31) {
+		return "mov", []string{rd, rn}
+	}
+	return name, append([]string{rd, rn}, imm...)
+}
+
+// shiftedReg formats Rm with its optional shift.
+func (d *disassembler) shiftedReg() []string {
+	i := d.inst
+	ops := []string{reg(i.Rm, i.Is64Bit)}
+	if i.ShiftAmount != 0 {
+		ops = append(ops, fmt.Sprintf("%s #%d", shiftNames[i.ShiftType&0x3], i.ShiftAmount))
+	}
+	return ops
+}
+
+// dpReg formats the shifted-register add/subtract and logical instructions
+// and the CMP, CMN, NEG, MOV, MVN and TST aliases.
+func (d *disassembler) dpReg() (string, []string) {
+	i := d.inst
+	rd := reg(i.Rd, i.Is64Bit)
+	rn := reg(i.Rn, i.Is64Bit)
+	rm := d.shiftedReg()
+
+	switch i.Op {
+	case OpADD, OpSUB:
+		name := "add"
+		if i.Op == OpSUB {
+			name = "sub"
+		}
+		switch {
+		case i.SetFlags && i.Rd == 31:
+			alias := "cmn"
+			if i.Op == OpSUB {
+				alias = "cmp"
+			}
+			return alias, append([]string{rn}, rm...)
+		case i.Op == OpSUB && i.Rn == 31:
+			alias := "neg"
+			if i.SetFlags {
+				alias = "negs"
+			}
+			return alias, append([]string{rd}, rm...)
+		case i.SetFlags:
+			name += "s"
+		}
+		return name, append([]string{rd, rn}, rm...)
+	}
+
+	names := map[Op]string{
+		OpAND: "and", OpBIC: "bic", OpORR: "orr",
+		OpORN: "orn", OpEOR: "eor", OpEON: "eon",
+	}
+	name, ok := names[i.Op]
+	if !ok {
+		return "", nil
+	}
+
+	switch {
+	case i.Op == OpAND && i.SetFlags && i.Rd == 31:
+		return "tst", append([]string{rn}, rm...)
+	case i.Op == OpORR && i.Rn == 31 && i.ShiftAmount == 0:
+		return "mov", []string{rd, rm[0]}
+	case i.Op == OpORN && i.Rn == 31:
+		return "mvn", append([]string{rd}, rm...)
+	case i.SetFlags:
+		name += "s"
+	}
+	return name, append([]string{rd, rn}, rm...)
+}
+
+// addSubExt formats ADD/SUB (extended register) and the CMP and CMN
+// aliases. The extend is shown as LSL when Rd or Rn is the stack pointer
+// and the extend does not change the value, and omitted if the shift is 0.
+func (d *disassembler) addSubExt() (string, []string) {
+	i := d.inst
+	name := "add"
+	if i.Op == OpSUB {
+		name = "sub"
+	}
+	rn := regOrSP(i.Rn, i.Is64Bit)
+	rm := []string{reg(i.Rm, i.ShiftType&0x3 == 0x3)}
+
+	// UXTX (64-bit) and UXTW (32-bit) leave Rm unchanged.
+	identity := ShiftType(0b010)
+	if i.Is64Bit {
+		identity = 0b011
+	}
+	spForm := i.Rn == 31 || (i.Rd == 31 && !i.SetFlags)
+	switch {
+	case spForm && i.ShiftType == identity && i.ShiftAmount == 0:
+	case spForm && i.ShiftType == identity:
+		rm = append(rm, fmt.Sprintf("lsl #%d", i.ShiftAmount))
+	case i.ShiftAmount == 0:
+		rm = append(rm, extendNames[i.ShiftType&0x7])
+	default:
+		rm = append(rm, fmt.Sprintf("%s #%d", extendNames[i.ShiftType&0x7], i.ShiftAmount))
+	}
+
+	if i.SetFlags {
+		if i.Rd == 31 {
+			alias := "cmn"
+			if i.Op == OpSUB {
+				alias = "cmp"
+			}
+			return alias, append([]string{rn}, rm...)
+		}
+		return name + "s", append([]string{reg(i.Rd, i.Is64Bit), rn}, rm...)
+	}
+	return name, append([]string{regOrSP(i.Rd, i.Is64Bit), rn}, rm...)
+}
+
+// addSubCarry formats ADC/ADCS/SBC/SBCS and the NGC and NGCS aliases.
+func (d *disassembler) addSubCarry() (string, []string) {
+	i := d.inst
+	suffix := ""
+	if i.SetFlags {
+		suffix = "s"
+	}
+	rd := reg(i.Rd, i.Is64Bit)
+	rm := reg(i.Rm, i.Is64Bit)
+	switch {
+	case i.Op == OpADC:
+		return "adc" + suffix, []string{rd, reg(i.Rn, i.Is64Bit), rm}
+	case i.Rn == 31:
+		return "ngc" + suffix, []string{rd, rm}
+	default:
+		return "sbc" + suffix, []string{rd, reg(i.Rn, i.Is64Bit), rm}
+	}
+}
+
+// logicalImm formats AND/ORR/EOR/ANDS (immediate) and the MOV and TST
+// aliases.
+func (d *disassembler) logicalImm() (string, []string) {
+	i := d.inst
+	rn := reg(i.Rn, i.Is64Bit)
+	imm := hexImm(i.Imm)
+
+	if i.SetFlags {
+		if i.Rd == 31 {
+			return "tst", []string{rn, imm}
+		}
+		return "ands", []string{reg(i.Rd, i.Is64Bit), rn, imm}
+	}
+
+	rd := regOrSP(i.Rd, i.Is64Bit)
+	switch i.Op {
+	case OpAND:
+		return "and", []string{rd, rn, imm}
+	case OpORR:
+		if i.Rn == 31 {
+			return "mov", []string{rd, imm}
+		}
+		return "orr", []string{rd, rn, imm}
+	default:
+		return "eor", []string{rd, rn, imm}
+	}
+}
+
+// moveWide formats MOVZ, MOVN and MOVK, using MOV where the value can be
+// shown as a single immediate.
+func (d *disassembler) moveWide() (string, []string) {
+	i := d.inst
+	rd := reg(i.Rd, i.Is64Bit)
+	raw := []string{rd, hexImm(i.Imm)}
+	if i.Shift != 0 {
+		raw = append(raw, fmt.Sprintf("lsl #%d", i.Shift))
+	}
+	shiftedZero := i.Imm == 0 && i.Shift != 0
+
+	switch i.Op {
+	case OpMOVZ:
+		if shiftedZero {
+			return "movz", raw
+		}
+		return "mov", []string{rd, hexImm(i.Imm << i.Shift)}
+	case OpMOVN:
+		if shiftedZero || (!i.Is64Bit && i.Imm == 0xFFFF) {
+			return "movn", raw
+		}
+		value := ^(i.Imm << i.Shift)
+		if !i.Is64Bit {
+			value &= 0xFFFFFFFF
+		}
+		return "mov", []string{rd, hexImm(value)}
+	default:
+		return "movk", raw
+	}
+}
+
+// bitfield formats SBFM, BFM and UBFM with their shift, extend, insert and
+// extract aliases.
+func (d *disassembler) bitfield() (string, []string) {
+	i := d.inst
+	width := uint64(32)
+	if i.Is64Bit {
+		width = 64
+	}
+	immr, imms := i.Imm, i.Imm2
+	rd := reg(i.Rd, i.Is64Bit)
+	rn := reg(i.Rn, i.Is64Bit)
+	insert := func(name string) (string, []string) {
+		return name, []string{rd, rn, decImm(int64(width - immr)), decImm(int64(imms + 1))}
+	}
+	extract := func(name string) (string, []string) {
+		return name, []string{rd, rn, decImm(int64(immr)), decImm(int64(imms - immr + 1))}
+	}
+
+	switch i.Op {
+	case OpSBFM:
+		switch {
+		case imms == width-1:
+			return "asr", []string{rd, rn, decImm(int64(immr))}
+		case immr == 0 && imms == 7:
+			return "sxtb", []string{rd, reg(i.Rn, false)}
+		case immr == 0 && imms == 15:
+			return "sxth", []string{rd, reg(i.Rn, false)}
+		case immr == 0 && imms == 31:
+			return "sxtw", []string{rd, reg(i.Rn, false)}
+		case imms < immr:
+			return insert("sbfiz")
+		default:
+			return extract("sbfx")
+		}
+	case OpUBFM:
+		switch {
+		case imms != width-1 && imms+1 == immr:
+			return "lsl", []string{rd, rn, decImm(int64(width - 1 - imms))}
+		case imms == width-1:
+			return "lsr", []string{rd, rn, decImm(int64(immr))}
+		case !i.Is64Bit && immr == 0 && imms == 7:
+			return "uxtb", []string{rd, rn}
+		case !i.Is64Bit && immr == 0 && imms == 15:
+			return "uxth", []string{rd, rn}
+		case imms < immr:
+			return insert("ubfiz")
+		default:
+			return extract("ubfx")
+		}
+	default:
+		switch {
+		case imms < immr && i.Rn == 31:
+			return "bfc", []string{rd, decImm(int64(width - immr)), decImm(int64(imms + 1))}
+		case imms < immr:
+			return insert("bfi")
+		default:
+			return extract("bfxil")
+		}
+	}
+}
+
+// extract formats EXTR and its ROR alias.
+func (d *disassembler) extract() (string, []string) {
+	i := d.inst
+	rd := reg(i.Rd, i.Is64Bit)
+	rn := reg(i.Rn, i.Is64Bit)
+	lsb := decImm(int64(i.Imm))
+	if i.Rn == i.Rm {
+		return "ror", []string{rd, rn, lsb}
+	}
+	return "extr", []string{rd, rn, reg(i.Rm, i.Is64Bit), lsb}
+}
+
+// pcRel formats ADR and ADRP.
+func (d *disassembler) pcRel() (string, []string) {
+	i := d.inst
+	rd := reg(i.Rd, true)
+	if i.Op == OpADR {
+		return "adr", []string{rd, d.target(i.BranchOffset)}
+	}
+	if !d.hasPC {
+		return "adrp", []string{rd, d.target(i.BranchOffset)}
+	}
+	page := d.pc &^ 0xFFF
+	return "adrp", []string{rd, fmt.Sprintf("0x%x", uint64(int64(page)+i.BranchOffset))}
+}
+
+// branch formats the immediate, conditional, register, compare and test
+// branches.
+func (d *disassembler) branch() (string, []string) {
+	i := d.inst
+	target := d.target(i.BranchOffset)
+
+	switch i.Op {
+	case OpB:
+		return "b", []string{target}
+	case OpBL:
+		return "bl", []string{target}
+	case OpBCond:
+		return "b." + condNames[i.Cond&0xF], []string{target}
+	case OpBR, OpBLR, OpRET:
+		if i.PACKey != PACKeyNone {
+			return d.branchRegAuth()
+		}
+	}
+
+	switch i.Op {
+	case OpBR:
+		return "br", []string{reg(i.Rn, true)}
+	case OpBLR:
+		return "blr", []string{reg(i.Rn, true)}
+	case OpRET:
+		if i.Rn == 30 {
+			return "ret", nil
+		}
+		return "ret", []string{reg(i.Rn, true)}
+	case OpCBZ:
+		return "cbz", []string{reg(i.Rd, i.Is64Bit), target}
+	case OpCBNZ:
+		return "cbnz", []string{reg(i.Rd, i.Is64Bit), target}
+	case OpTBZ:
+		return "tbz", []string{reg(i.Rd, i.Is64Bit), decImm(int64(i.Imm)), target}
+	case OpTBNZ:
+		return "tbnz", []string{reg(i.Rd, i.Is64Bit), decImm(int64(i.Imm)), target}
+	default:
+		return "", nil
+	}
+}
+
+// pacKeyLetters are the key suffixes of the pointer authentication
+// mnemonics.
+var pacKeyLetters = [...]string{PACKeyIA: "a", PACKeyIB: "b", PACKeyDA: "a", PACKeyDB: "b"}
+
+// branchRegAuth formats BRAA, BRAAZ, BLRAA, BLRAAZ, RETAA and their key B
+// forms.
+func (d *disassembler) branchRegAuth() (string, []string) {
+	i := d.inst
+	name := map[Op]string{OpBR: "bra", OpBLR: "blra", OpRET: "reta"}[i.Op] + pacKeyLetters[i.PACKey]
+	switch {
+	case i.Op == OpRET:
+		return name, nil
+	case i.ZeroModifier:
+		return name + "z", []string{reg(i.Rn, true)}
+	default:
+		return name, []string{reg(i.Rn, true), regOrSP(i.Rm, true)}
+	}
+}
+
+// exception formats SVC and BRK.
+func (d *disassembler) exception() (string, []string) {
+	name := "svc"
+	if d.inst.Op == OpBRK {
+		name = "brk"
+	}
+	return name, []string{hexImm(d.inst.Imm)}
+}
+
+// condSelect formats CSEL, CSINC, CSINV and CSNEG with the CSET, CSETM,
+// CINC, CINV and CNEG aliases.
+func (d *disassembler) condSelect() (string, []string) {
+	i := d.inst
+	rd := reg(i.Rd, i.Is64Bit)
+	rn := reg(i.Rn, i.Is64Bit)
+	cond := condNames[i.Cond&0xF]
+	inverted := condNames[(i.Cond^1)&0xF]
+	aliased := i.Rn == i.Rm && i.Cond < CondAL
+	full := []string{rd, rn, reg(i.Rm, i.Is64Bit), cond}
+
+	switch i.Op {
+	case OpCSEL:
+		return "csel", full
+	case OpCSINC:
+		switch {
+		case aliased && i.Rn == 31:
+			return "cset", []string{rd, inverted}
+		case aliased:
+			return "cinc", []string{rd, rn, inverted}
+		}
+		return "csinc", full
+	case OpCSINV:
+		switch {
+		case aliased && i.Rn == 31:
+			return "csetm", []string{rd, inverted}
+		case aliased:
+			return "cinv", []string{rd, rn, inverted}
+		}
+		return "csinv", full
+	default:
+		if aliased {
+			return "cneg", []string{rd, rn, inverted}
+		}
+		return "csneg", full
+	}
+}
+
+// condCmp formats CCMP and CCMN.
+func (d *disassembler) condCmp() (string, []string) {
+	i := d.inst
+	name := "ccmn"
+	if i.Op == OpCCMP {
+		name = "ccmp"
+	}
+	operand := hexImm(i.Imm2)
+	if i.Rm != 0xFF {
+		operand = reg(i.Rm, i.Is64Bit)
+	}
+	return name, []string{reg(i.Rn, i.Is64Bit), operand, hexImm(i.Imm), condNames[i.Cond&0xF]}
+}
+
+// dataProc1Src formats RBIT, REV16, REV32, REV, CLZ, CLS and the pointer
+// authentication instructions.
+func (d *disassembler) dataProc1Src() (string, []string) {
+	i := d.inst
+	if i.PACKey != PACKeyNone {
+		return d.pointerAuth()
+	}
+	names := map[Op]string{
+		OpRBIT: "rbit", OpREV16: "rev16", OpREV32: "rev32", OpREV: "rev",
+		OpCLZ: "clz", OpCLS: "cls",
+	}
+	return names[i.Op], []string{reg(i.Rd, i.Is64Bit), reg(i.Rn, i.Is64Bit)}
+}
+
+// pacOpNames are the mnemonic prefixes of PAC and AUT.
+var pacOpNames = map[Op]string{OpPAC: "pac", OpAUT: "aut"}
+
+// pointerAuth formats PAC, AUT and XPAC (data processing).
+func (d *disassembler) pointerAuth() (string, []string) {
+	i := d.inst
+	class := "i"
+	if i.PACKey.IsData() {
+		class = "d"
+	}
+	rd := reg(i.Rd, true)
+	switch {
+	case i.Op == OpXPAC:
+		return "xpac" + class, []string{rd}
+	case i.ZeroModifier:
+		return pacOpNames[i.Op] + class + "z" + pacKeyLetters[i.PACKey], []string{rd}
+	default:
+		return pacOpNames[i.Op] + class + pacKeyLetters[i.PACKey], []string{rd, regOrSP(i.Rn, true)}
+	}
+}
+
+// dataProc2Src formats the divides, variable shifts, PACGA and CRC32
+// checksums. The shifts use their preferred LSL/LSR/ASR/ROR names.
+func (d *disassembler) dataProc2Src() (string, []string) {
+	i := d.inst
+	if i.Op == OpPACGA {
+		return "pacga", []string{reg(i.Rd, true), reg(i.Rn, true), regOrSP(i.Rm, true)}
+	}
+	if i.Op == OpCRC32 || i.Op == OpCRC32C {
+		name := "crc32"
+		if i.Op == OpCRC32C {
+			name = "crc32c"
+		}
+		name += map[uint64]string{1: "b", 2: "h", 4: "w", 8: "x"}[i.Imm]
+		return name, []string{reg(i.Rd, false), reg(i.Rn, false), reg(i.Rm, i.Imm == 8)}
+	}
+	names := map[Op]string{
+		OpUDIV: "udiv", OpSDIV: "sdiv",
+		OpLSLV: "lsl", OpLSRV: "lsr", OpASRV: "asr", OpRORV: "ror",
+	}
+	return names[i.Op], []string{reg(i.Rd, i.Is64Bit), reg(i.Rn, i.Is64Bit), reg(i.Rm, i.Is64Bit)}
+}
+
+// longMulNames are the mnemonics of the long multiplies and, when Ra is
+// the zero register, their preferred aliases.
+var longMulNames = map[Op][2]string{
+	OpSMADDL: {"smaddl", "smull"}, OpSMSUBL: {"smsubl", "smnegl"},
+	OpUMADDL: {"umaddl", "umull"}, OpUMSUBL: {"umsubl", "umnegl"},
+}
+
+// dataProc3Src formats MADD and MSUB with the MUL and MNEG aliases, the
+// long multiplies with the SMULL, UMULL, SMNEGL and UMNEGL aliases, and
+// SMULH and UMULH.
+func (d *disassembler) dataProc3Src() (string, []string) {
+	i := d.inst
+	switch i.Op {
+	case OpSMULH, OpUMULH:
+		name := "smulh"
+		if i.Op == OpUMULH {
+			name = "umulh"
+		}
+		return name, []string{reg(i.Rd, true), reg(i.Rn, true), reg(i.Rm, true)}
+	case OpSMADDL, OpSMSUBL, OpUMADDL, OpUMSUBL:
+		names := longMulNames[i.Op]
+		ops := []string{reg(i.Rd, true), reg(i.Rn, false), reg(i.Rm, false)}
+		if i.Rt2 == 31 {
+			return names[1], ops
+		}
+		return names[0], append(ops, reg(i.Rt2, true))
+	}
+
+	ops := []string{reg(i.Rd, i.Is64Bit), reg(i.Rn, i.Is64Bit), reg(i.Rm, i.Is64Bit)}
+	if i.Rt2 == 31 {
+		if i.Op == OpMSUB {
+			return "mneg", ops
+		}
+		return "mul", ops
+	}
+	if i.Op == OpMSUB {
+		return "msub", append(ops, reg(i.Rt2, i.Is64Bit))
+	}
+	return "madd", append(ops, reg(i.Rt2, i.Is64Bit))
+}
+
+// loadStoreNames are the mnemonics of the single-register loads and stores.
+var loadStoreNames = map[Op]string{
+	OpLDR: "ldr", OpSTR: "str",
+	OpLDRB: "ldrb", OpSTRB: "strb", OpLDRSB: "ldrsb",
+	OpLDRH: "ldrh", OpSTRH: "strh", OpLDRSH: "ldrsh",
+	OpLDRSW: "ldrsw", OpPRFM: "prfm",
+	OpLDRQ: "ldr", OpSTRQ: "str",
+}
+
+// loadStore formats the single-register loads, stores and prefetches in all
+// addressing modes, using the LDUR/STUR forms for unscaled offsets and the
+// LDTR/STTR forms for unprivileged accesses.
+func (d *disassembler) loadStore() (string, []string) {
+	i := d.inst
+	name, ok := loadStoreNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+
+	var rt string
+	switch i.Op {
+	case OpLDRB, OpSTRB, OpLDRH, OpSTRH:
+		rt = reg(i.Rd, false)
+	case OpLDRSW:
+		rt = reg(i.Rd, true)
+	case OpPRFM:
+		rt = prfop(i.Rd)
+	case OpLDRQ, OpSTRQ:
+		rt = scalarReg(i.Rd, i.AccessSize)
+	default:
+		rt = reg(i.Rd, i.Is64Bit)
+	}
+
+	if i.IndexMode == IndexSigned {
+		// Unscaled offset: ldr -> ldur, strb -> sturb, prfm -> prfum
+		// Unprivileged: ldr -> ldtr, ldrsw -> ldtrsw, ...
+		switch {
+		case i.Op == OpPRFM:
+			name = "prfum"
+		case i.Unprivileged:
+			name = name[:2] + "t" + name[2:]
+		default:
+			name = name[:2] + "u" + name[2:]
+		}
+	}
+	return name, append([]string{rt}, d.address()...)
+}
+
+// address formats the addressing-mode operands of a single-register or pair
+// load or store.
+func (d *disassembler) address() []string {
+	i := d.inst
+	base := regOrSP(i.Rn, true)
+
+	switch i.IndexMode {
+	case IndexPre:
+		return []string{fmt.Sprintf("[%s, #%d]!", base, i.SignedImm)}
+	case IndexPost:
+		return []string{"[" + base + "]", decImm(i.SignedImm)}
+	case IndexRegBase:
+		return []string{d.regOffset(base)}
+	case IndexSigned:
+		return []string{offsetAddr(base, i.SignedImm)}
+	default:
+		return []string{offsetAddr(base, int64(i.Imm))}
+	}
+}
+
+// scalarReg names SIMD&FP register n as the B, H, S, D or Q register of the
+// given size in bytes.
+func scalarReg(n uint8, size uint8) string {
+	var prefix byte
+	switch size {
+	case 1:
+		prefix = 'b'
+	case 2:
+		prefix = 'h'
+	case 4:
+		prefix = 's'
+	case 8:
+		prefix = 'd'
+	default:
+		prefix = 'q'
+	}
+	return fmt.Sprintf("%c%d", prefix, n)
+}
+
+// prfTypes and prfPolicies name the fields of a prefetch operation.
+var (
+	prfTypes    = [3]string{"pld", "pli", "pst"}
+	prfPolicies = [2]string{"keep", "strm"}
+)
+
+// prfop names the prefetch operation held in the Rt field of PRFM, falling
+// back to the raw value for unallocated operations.
+func prfop(op uint8) string {
+	typ, target, policy := op>>3, (op>>1)&0x3, op&0x1
+	if typ > 2 || target > 2 {
+		return hexImm(uint64(op))
+	}
+	return fmt.Sprintf("%sl%d%s", prfTypes[typ], target+1, prfPolicies[policy])
+}
+
+// regOffset formats a register-offset address. The extend option is held in
+// ShiftType.
+func (d *disassembler) regOffset(base string) string {
+	i := d.inst
+	var rm, extend string
+	switch i.ShiftType {
+	case 0b010:
+		rm, extend = reg(i.Rm, false), "uxtw"
+	case 0b110:
+		rm, extend = reg(i.Rm, false), "sxtw"
+	case 0b111:
+		rm, extend = reg(i.Rm, true), "sxtx"
+	default:
+		rm = reg(i.Rm, true)
+		if i.ShiftAmount != 0 {
+			extend = "lsl"
+		}
+	}
+	if extend != "" && i.ShiftAmount != 0 {
+		extend += fmt.Sprintf(" #%d", i.ShiftAmount)
+	}
+	if extend == "" {
+		return fmt.Sprintf("[%s, %s]", base, rm)
+	}
+	return fmt.Sprintf("[%s, %s, %s]", base, rm, extend)
+}
+
+// offsetAddr formats an immediate-offset address, omitting a zero offset.
+func offsetAddr(base string, offset int64) string {
+	if offset == 0 {
+		return "[" + base + "]"
+	}
+	return fmt.Sprintf("[%s, #%d]", base, offset)
+}
+
+// loadStoreLit formats LDR, LDRSW and PRFM (literal).
+func (d *disassembler) loadStoreLit() (string, []string) {
+	i := d.inst
+	name := "ldr"
+	var rt string
+	switch {
+	case i.Op == OpPRFM:
+		name, rt = "prfm", prfop(i.Rd)
+	case i.Op == OpLDRSW:
+		name, rt = "ldrsw", reg(i.Rd, true)
+	case i.IsSIMD:
+		rt = scalarReg(i.Rd, i.AccessSize)
+	default:
+		rt = reg(i.Rd, i.Is64Bit)
+	}
+	return name, []string{rt, d.target(i.BranchOffset)}
+}
+
+// loadStorePair formats LDP, STP, LDPSW, LDNP and STNP.
+func (d *disassembler) loadStorePair() (string, []string) {
+	i := d.inst
+	var name string
+	switch i.Op {
+	case OpLDP:
+		name = "ldp"
+	case OpSTP:
+		name = "stp"
+	case OpLDPSW:
+		name = "ldpsw"
+	default:
+		return "", nil
+	}
+	if i.NonTemporal {
+		name = name[:2] + "n" + name[2:]
+	}
+
+	ops := []string{reg(i.Rd, i.Is64Bit), reg(i.Rt2, i.Is64Bit)}
+	if i.IsSIMD {
+		ops = []string{scalarReg(i.Rd, i.AccessSize), scalarReg(i.Rt2, i.AccessSize)}
+	}
+	return name, append(ops, d.address()...)
+}
+
+// loadStoreStruct formats LD1-LD4, ST1-ST4 and LD1R-LD4R.
+func (d *disassembler) loadStoreStruct() (string, []string) {
+	i := d.inst
+	var name string
+	switch i.Op {
+	case OpVLDN, OpVLDNLane, OpVLDNR:
+		name = "ld"
+	case OpVSTN, OpVSTNLane:
+		name = "st"
+	default:
+		return "", nil
+	}
+	name += fmt.Sprint(i.StructElems)
+	if i.Op == OpVLDNR {
+		name += "r"
+	}
+
+	regs := make([]string, i.RegCount)
+	for n := range regs {
+		num := (i.Rd + uint8(n)) % 32
+		if i.Op == OpVLDNLane || i.Op == OpVSTNLane {
+			regs[n] = fmt.Sprintf("v%d.%s", num, elemNames[i.AccessSize])
+		} else {
+			regs[n] = vecReg(num, i.Arrangement)
+		}
+	}
+	list := "{ " + strings.Join(regs, ", ") + " }"
+	if i.Op == OpVLDNLane || i.Op == OpVSTNLane {
+		list += fmt.Sprintf("[%d]", i.Lane)
+	}
+
+	ops := []string{list, "[" + regOrSP(i.Rn, true) + "]"}
+	switch {
+	case i.IndexMode != IndexPost:
+	case i.Rm == 31:
+		ops = append(ops, decImm(i.SignedImm))
+	default:
+		ops = append(ops, reg(i.Rm, true))
+	}
+	return name, ops
+}
+
+// simdRegNames are the mnemonics of the vector three-same operations.
+var simdRegNames = map[Op]string{
+	OpVADD: "add", OpVSUB: "sub", OpVMUL: "mul",
+	OpVFADD: "fadd", OpVFSUB: "fsub", OpVFMUL: "fmul",
+	OpVSQADD: "sqadd", OpVUQADD: "uqadd", OpVSQSUB: "sqsub", OpVUQSUB: "uqsub",
+	OpVCMEQ: "cmeq", OpVCMGT: "cmgt", OpVCMGE: "cmge", OpVCMHI: "cmhi",
+	OpVCMHS: "cmhs", OpVCMTST: "cmtst",
+	OpVSMAX: "smax", OpVUMAX: "umax", OpVSMIN: "smin", OpVUMIN: "umin",
+	OpVSABD: "sabd", OpVUABD: "uabd", OpVSABA: "saba", OpVUABA: "uaba",
+	OpVMLA: "mla", OpVMLS: "mls",
+	OpVADDP: "addp", OpVSMAXP: "smaxp", OpVUMAXP: "umaxp", OpVSMINP: "sminp", OpVUMINP: "uminp",
+	OpVAND: "and", OpVBIC: "bic", OpVORR: "orr", OpVORN: "orn",
+	OpVEOR: "eor", OpVBSL: "bsl", OpVBIT: "bit", OpVBIF: "bif",
+	OpVFDIV: "fdiv", OpVFMLA: "fmla", OpVFMLS: "fmls",
+	OpVFMAX: "fmax", OpVFMIN: "fmin", OpVFMAXNM: "fmaxnm", OpVFMINNM: "fminnm", OpVFABD: "fabd",
+	OpVFADDP: "faddp", OpVFMAXP: "fmaxp", OpVFMINP: "fminp", OpVFMAXNMP: "fmaxnmp", OpVFMINNMP: "fminnmp",
+	OpVFCMEQ: "fcmeq", OpVFCMGE: "fcmge", OpVFCMGT: "fcmgt", OpVFACGE: "facge", OpVFACGT: "facgt",
+	OpVFRECPS: "frecps", OpVFRSQRTS: "frsqrts",
+	OpVSSHL: "sshl", OpVUSHL: "ushl", OpVSQDMULH: "sqdmulh", OpVSQRDMULH: "sqrdmulh",
+}
+
+// simdReg formats the vector three-same operations, using MOV for ORR with
+// identical sources.
+func (d *disassembler) simdReg() (string, []string) {
+	i := d.inst
+	name, ok := simdRegNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	if i.Op == OpVORR && i.Rn == i.Rm {
+		return "mov", []string{vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, i.Arrangement)}
+	}
+	return name, []string{operandReg(i.Rd, i), operandReg(i.Rn, i), operandReg(i.Rm, i)}
+}
+
+// simdCopy formats DUP, INS (general) and UMOV/SMOV, using MOV for INS,
+// for UMOV of word and doubleword elements and for the scalar DUP.
+func (d *disassembler) simdCopy() (string, []string) {
+	i := d.inst
+	size := arrangementSize(i.Arrangement)
+	switch i.Op {
+	case OpDUP:
+		return "dup", []string{vecReg(i.Rd, i.Arrangement), reg(i.Rn, i.Arrangement == Arr2D)}
+	case OpDUPElem:
+		if i.IsScalar {
+			return "mov", []string{operandReg(i.Rd, i), elemReg(i.Rn, size, i.Lane)}
+		}
+		return "dup", []string{vecReg(i.Rd, i.Arrangement), elemReg(i.Rn, size, i.Lane)}
+	case OpINS:
+		return "mov", []string{elemReg(i.Rd, size, i.Lane), reg(i.Rn, size == 8)}
+	case OpINSElem:
+		return "mov", []string{elemReg(i.Rd, size, i.Lane), elemReg(i.Rn, size, uint8(i.Imm2))}
+	case OpUMOV:
+		name := "umov"
+		if size >= 4 {
+			name = "mov"
+		}
+		return name, []string{reg(i.Rd, size == 8), elemReg(i.Rn, size, i.Lane)}
+	case OpSMOV:
+		return "smov", []string{reg(i.Rd, i.Is64Bit), elemReg(i.Rn, size, i.Lane)}
+	default:
+		return "", nil
+	}
+}
+
+// sysRegNames are the names of common system registers by their
+// o0:op1:CRn:CRm:op2 encoding.
+var sysRegNames = map[uint16]string{
+	0x4000: "midr_el1",
+	0x4005: "mpidr_el1",
+	0x4006: "revidr_el1",
+	0x4020: "id_aa64pfr0_el1",
+	0x4021: "id_aa64pfr1_el1",
+	0x4024: "id_aa64zfr0_el1",
+	0x4028: "id_aa64dfr0_el1",
+	0x4029: "id_aa64dfr1_el1",
+	0x4030: "id_aa64isar0_el1",
+	0x4031: "id_aa64isar1_el1",
+	0x4032: "id_aa64isar2_el1",
+	0x4038: "id_aa64mmfr0_el1",
+	0x4039: "id_aa64mmfr1_el1",
+	0x403A: "id_aa64mmfr2_el1",
+	0x5801: "ctr_el0",
+	0x5807: "dczid_el0",
+	0x5A10: "nzcv",
+	0x5A11: "daif",
+	0x5A20: "fpcr",
+	0x5A21: "fpsr",
+	0x5E82: "tpidr_el0",
+	0x5E83: "tpidrro_el0",
+	0x5F00: "cntfrq_el0",
+	0x5F01: "cntpct_el0",
+	0x5F02: "cntvct_el0",
+}
+
+// SysRegName names a system register by its o0:op1:CRn:CRm:op2 encoding,
+// falling back to the generic s<op0>_<op1>_c<CRn>_c<CRm>_<op2> form.
+func SysRegName(enc uint16) string {
+	if name, ok := sysRegNames[enc]; ok {
+		return name
+	}
+	return fmt.Sprintf("s%d_%d_c%d_c%d_%d",
+		2|(enc>>14)&0x1, (enc>>11)&0x7, (enc>>7)&0xF, (enc>>3)&0xF, enc&0x7)
+}
+
+// ParseSysReg returns the o0:op1:CRn:CRm:op2 encoding of a system register
+// given by name or in the generic s<op0>_<op1>_c<CRn>_c<CRm>_<op2> form.
+func ParseSysReg(name string) (uint16, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for enc, n := range sysRegNames {
+		if n == name {
+			return enc, true
+		}
+	}
+	var op0, op1, crn, crm, op2 uint16
+	if _, err := fmt.Sscanf(name, "s%d_%d_c%d_c%d_%d", &op0, &op1, &crn, &crm, &op2); err != nil {
+		return 0, false
+	}
+	if op0 < 2 || op0 > 3 || op1 > 7 || crn > 15 || crm > 15 || op2 > 7 ||
+		name != fmt.Sprintf("s%d_%d_c%d_c%d_%d", op0, op1, crn, crm, op2) {
+		return 0, false
+	}
+	return (op0&1)<<14 | op1<<11 | crn<<7 | crm<<3 | op2, true
+}
+
+// cacheOpNames are the names of the DC and IC operations.
+var cacheOpNames = map[uint16]string{
+	DCZVA: "zva", DCCVAC: "cvac", DCCVAU: "cvau", DCCVAP: "cvap",
+	DCCVADP: "cvadp", DCCIVAC: "civac", ICIVAU: "ivau",
+}
+
+// systemReg formats MRS, MSR, DC and IC.
+func (d *disassembler) systemReg() (string, []string) {
+	i := d.inst
+	switch i.Op {
+	case OpMRS:
+		return "mrs", []string{reg(i.Rd, true), SysRegName(i.SysReg)}
+	case OpDC:
+		return "dc", []string{cacheOpNames[i.SysReg], reg(i.Rn, true)}
+	case OpIC:
+		return "ic", []string{cacheOpNames[i.SysReg], reg(i.Rn, true)}
+	default:
+		return "msr", []string{SysRegName(i.SysReg), reg(i.Rn, true)}
+	}
+}
+
+// fpDataProc formats scalar floating-point data processing.
+func (d *disassembler) fpDataProc() (string, []string) {
+	i := d.inst
+	t := i.FPType
+	rd, rn, rm := fpReg(i.Rd, t), fpReg(i.Rn, t), fpReg(i.Rm, t)
+	cond := condNames[i.Cond&0xF]
+
+	switch i.Op {
+	case OpFADD, OpFSUB, OpFMUL, OpFDIV, OpFNMUL, OpFMAX, OpFMIN, OpFMAXNM, OpFMINNM:
+		names := map[Op]string{OpFADD: "fadd", OpFSUB: "fsub", OpFMUL: "fmul", OpFDIV: "fdiv",
+			OpFNMUL: "fnmul", OpFMAX: "fmax", OpFMIN: "fmin", OpFMAXNM: "fmaxnm", OpFMINNM: "fminnm"}
+		return names[i.Op], []string{rd, rn, rm}
+	case OpFSQRT, OpFABS, OpFNEG, OpFMOV,
+		OpFRINTN, OpFRINTA, OpFRINTP, OpFRINTM, OpFRINTZ, OpFRINTX, OpFRINTI:
+		names := map[Op]string{OpFSQRT: "fsqrt", OpFABS: "fabs", OpFNEG: "fneg", OpFMOV: "fmov",
+			OpFRINTN: "frintn", OpFRINTA: "frinta", OpFRINTP: "frintp", OpFRINTM: "frintm",
+			OpFRINTZ: "frintz", OpFRINTX: "frintx", OpFRINTI: "frinti"}
+		return names[i.Op], []string{rd, rn}
+	case OpFCVT:
+		return "fcvt", []string{fpReg(i.Rd, i.FPDstType), rn}
+	case OpFMADD, OpFMSUB, OpFNMADD, OpFNMSUB:
+		names := map[Op]string{OpFMADD: "fmadd", OpFMSUB: "fmsub", OpFNMADD: "fnmadd", OpFNMSUB: "fnmsub"}
+		return names[i.Op], []string{rd, rn, rm, fpReg(i.Rt2, t)}
+	case OpFCMP, OpFCMPE:
+		name := "fcmp"
+		if i.Op == OpFCMPE {
+			name = "fcmpe"
+		}
+		if i.Rm == 0xFF {
+			return name, []string{rn, "#0.0"}
+		}
+		return name, []string{rn, rm}
+	case OpFCCMP, OpFCCMPE:
+		name := "fccmp"
+		if i.Op == OpFCCMPE {
+			name = "fccmpe"
+		}
+		return name, []string{rn, rm, hexImm(i.Imm), cond}
+	case OpFCSEL:
+		return "fcsel", []string{rd, rn, rm, cond}
+	case OpFMOVImm:
+		return "fmov", []string{rd, fmt.Sprintf("#%.18e", fpImmValue(i.Imm, t))}
+	default:
+		return "", nil
+	}
+}
+
+// fpImmValue converts the bit pattern of an FMOV immediate to its value.
+func fpImmValue(bits uint64, t FPType) float64 {
+	switch t {
+	case FPSingle:
+		return float64(math.Float32frombits(uint32(bits)))
+	case FPHalf:
+		exp := int(bits>>10) & 0x1F
+		value := math.Ldexp(1+float64(bits&0x3FF)/1024, exp-15)
+		if bits&0x8000 != 0 {
+			value = -value
+		}
+		return value
+	default:
+		return math.Float64frombits(bits)
+	}
+}
+
+// fpConvertNames are the mnemonics of the floating-point conversions.
+var fpConvertNames = map[Op]string{
+	OpSCVTF: "scvtf", OpUCVTF: "ucvtf",
+	OpFCVTNS: "fcvtns", OpFCVTNU: "fcvtnu", OpFCVTPS: "fcvtps", OpFCVTPU: "fcvtpu",
+	OpFCVTMS: "fcvtms", OpFCVTMU: "fcvtmu", OpFCVTZS: "fcvtzs", OpFCVTZU: "fcvtzu",
+	OpFCVTAS: "fcvtas", OpFCVTAU: "fcvtau",
+}
+
+// fpConvert formats conversions between floating-point and integer or
+// fixed-point values and FMOV (general).
+func (d *disassembler) fpConvert() (string, []string) {
+	i := d.inst
+	gp := func(n uint8) string { return reg(n, i.Is64Bit) }
+	fp := func(n uint8) string { return fpReg(n, i.FPType) }
+
+	switch i.Op {
+	case OpFMOVToGP:
+		if i.Imm == 1 {
+			return "fmov", []string{gp(i.Rd), fmt.Sprintf("v%d.d[1]", i.Rn)}
+		}
+		return "fmov", []string{gp(i.Rd), fp(i.Rn)}
+	case OpFMOVFromGP:
+		if i.Imm == 1 {
+			return "fmov", []string{fmt.Sprintf("v%d.d[1]", i.Rd), gp(i.Rn)}
+		}
+		return "fmov", []string{fp(i.Rd), gp(i.Rn)}
+	}
+
+	name, ok := fpConvertNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	var ops []string
+	if i.Op == OpSCVTF || i.Op == OpUCVTF {
+		ops = []string{fp(i.Rd), gp(i.Rn)}
+	} else {
+		ops = []string{gp(i.Rd), fp(i.Rn)}
+	}
+	if i.Imm != 0 {
+		ops = append(ops, decImm(int64(i.Imm))) // Fixed-point fraction bits
+	}
+	return name, ops
+}
+
+// sizeSuffix returns the b/h mnemonic suffix of a byte or halfword access.
+func sizeSuffix(size uint8) string {
+	switch size {
+	case 1:
+		return "b"
+	case 2:
+		return "h"
+	default:
+		return ""
+	}
+}
+
+// orderSuffix returns the acquire/release mnemonic suffix ("a", "l", "al").
+func orderSuffix(acquire, release bool) string {
+	s := ""
+	if acquire {
+		s += "a"
+	}
+	if release {
+		s += "l"
+	}
+	return s
+}
+
+// exclusive formats the load/store exclusive, load-acquire/store-release and
+// compare-and-swap instructions.
+func (d *disassembler) exclusive() (string, []string) {
+	i := d.inst
+	addr := "[" + regOrSP(i.Rn, true) + "]"
+	size := sizeSuffix(i.AccessSize)
+	rt := reg(i.Rd, i.Is64Bit)
+
+	switch i.Op {
+	case OpLDXR:
+		return "ld" + orderSuffix(i.Acquire, false) + "xr" + size, []string{rt, addr}
+	case OpSTXR:
+		return "st" + orderSuffix(false, i.Release) + "xr" + size,
+			[]string{reg(i.Rm, false), rt, addr}
+	case OpLDXP:
+		return "ld" + orderSuffix(i.Acquire, false) + "xp",
+			[]string{rt, reg(i.Rt2, i.Is64Bit), addr}
+	case OpSTXP:
+		return "st" + orderSuffix(false, i.Release) + "xp",
+			[]string{reg(i.Rm, false), rt, reg(i.Rt2, i.Is64Bit), addr}
+	case OpLDAR:
+		return "ldar" + size, []string{rt, addr}
+	case OpSTLR:
+		return "stlr" + size, []string{rt, addr}
+	case OpCAS:
+		return "cas" + orderSuffix(i.Acquire, i.Release) + size,
+			[]string{reg(i.Rm, i.Is64Bit), rt, addr}
+	case OpCASP:
+		return "casp" + orderSuffix(i.Acquire, i.Release), []string{
+			reg(i.Rm, i.Is64Bit), reg(i.Rm+1, i.Is64Bit),
+			rt, reg(i.Rt2, i.Is64Bit), addr,
+		}
+	default:
+		return "", nil
+	}
+}
+
+// atomicNames are the LSE atomic operation names without the LD/ST prefix.
+var atomicNames = map[Op]string{
+	OpLDADD: "add", OpLDCLR: "clr", OpLDEOR: "eor", OpLDSET: "set",
+	OpLDSMAX: "smax", OpLDSMIN: "smin", OpLDUMAX: "umax", OpLDUMIN: "umin",
+}
+
+// atomic formats the LSE atomic memory operations, using the ST<op> aliases
+// when the loaded value is discarded, and LDAPR.
+func (d *disassembler) atomic() (string, []string) {
+	i := d.inst
+	addr := "[" + regOrSP(i.Rn, true) + "]"
+	size := sizeSuffix(i.AccessSize)
+	rs := reg(i.Rm, i.Is64Bit)
+	rt := reg(i.Rd, i.Is64Bit)
+
+	switch i.Op {
+	case OpLDAPR:
+		return "ldapr" + size, []string{rt, addr}
+	case OpSWP:
+		return "swp" + orderSuffix(i.Acquire, i.Release) + size, []string{rs, rt, addr}
+	}
+
+	op, ok := atomicNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	if i.Rd == 31 && !i.Acquire {
+		return "st" + op + orderSuffix(false, i.Release) + size, []string{rs, addr}
+	}
+	return "ld" + op + orderSuffix(i.Acquire, i.Release) + size, []string{rs, rt, addr}
+}
+
+// barrierOptions are the names of the DMB/DSB CRm options.
+var barrierOptions = map[uint64]string{
+	1: "oshld", 2: "oshst", 3: "osh",
+	5: "nshld", 6: "nshst", 7: "nsh",
+	9: "ishld", 10: "ishst", 11: "ish",
+	13: "ld", 14: "st", 15: "sy",
+}
+
+// hintNames are the names of the allocated hints by CRm:op2.
+var hintNames = map[uint64]string{
+	1: "yield", 2: "wfe", 3: "wfi", 4: "sev", 5: "sevl",
+	7: "xpaclri", 8: "pacia1716", 10: "pacib1716", 12: "autia1716", 14: "autib1716",
+	16: "esb", 20: "csdb",
+	24: "paciaz", 25: "paciasp", 26: "pacibz", 27: "pacibsp",
+	28: "autiaz", 29: "autiasp", 30: "autibz", 31: "autibsp",
+	32: "bti", 34: "bti c", 36: "bti j", 38: "bti jc",
+}
+
+// barrier formats barriers, CLREX and hints, including the pointer
+// authentication hints and BTI.
+func (d *disassembler) barrier() (string, []string) {
+	i := d.inst
+	switch i.Op {
+	case OpDMB, OpDSB:
+		name := "dmb"
+		if i.Op == OpDSB {
+			name = "dsb"
+		}
+		if option, ok := barrierOptions[i.Imm]; ok {
+			return name, []string{option}
+		}
+		return name, []string{hexImm(i.Imm)}
+	case OpISB, OpCLREX:
+		name := "isb"
+		if i.Op == OpCLREX {
+			name = "clrex"
+		}
+		if i.Imm == 15 {
+			return name, nil
+		}
+		return name, []string{hexImm(i.Imm)}
+	case OpBTI:
+		return hintNames[32+2*i.Imm], nil
+	case OpHINT, OpPAC, OpAUT, OpXPAC:
+		if name, ok := hintNames[i.Imm]; ok {
+			return name, nil
+		}
+		return "hint", []string{hexImm(i.Imm)}
+	default:
+		return "", nil
+	}
+}
@@ -0,0 +1,339 @@
+package insts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// simdTwoRegNames are the mnemonics of the two-register miscellaneous
+// operations.
+var simdTwoRegNames = map[Op]string{
+	OpVCMEQZ: "cmeq", OpVCMGTZ: "cmgt", OpVCMGEZ: "cmge", OpVCMLEZ: "cmle", OpVCMLTZ: "cmlt",
+	OpVABS: "abs", OpVNEG: "neg", OpVNOT: "mvn", OpVCNT: "cnt",
+	OpVREV64: "rev64", OpVREV32: "rev32", OpVREV16: "rev16",
+	OpVXTN: "xtn", OpVSQXTN: "sqxtn", OpVUQXTN: "uqxtn", OpVSQXTUN: "sqxtun",
+	OpVFCMEQZ: "fcmeq", OpVFCMGEZ: "fcmge", OpVFCMGTZ: "fcmgt", OpVFCMLEZ: "fcmle", OpVFCMLTZ: "fcmlt",
+	OpVFABS: "fabs", OpVFNEG: "fneg", OpVFSQRT: "fsqrt",
+	OpVFRINTN: "frintn", OpVFRINTA: "frinta", OpVFRINTP: "frintp", OpVFRINTM: "frintm",
+	OpVFRINTZ: "frintz", OpVFRINTX: "frintx", OpVFRINTI: "frinti",
+	OpVSCVTF: "scvtf", OpVUCVTF: "ucvtf",
+	OpVFCVTNS: "fcvtns", OpVFCVTNU: "fcvtnu", OpVFCVTPS: "fcvtps", OpVFCVTPU: "fcvtpu",
+	OpVFCVTMS: "fcvtms", OpVFCVTMU: "fcvtmu", OpVFCVTZS: "fcvtzs", OpVFCVTZU: "fcvtzu",
+	OpVFCVTAS: "fcvtas", OpVFCVTAU: "fcvtau",
+	OpVFCVTL: "fcvtl", OpVFCVTN: "fcvtn",
+	OpVFRECPE: "frecpe", OpVFRSQRTE: "frsqrte",
+	OpVSADDLP: "saddlp", OpVUADDLP: "uaddlp", OpVSADALP: "sadalp", OpVUADALP: "uadalp",
+}
+
+// simdTwoReg formats the two-register miscellaneous operations. The
+// compares against zero take a #0 (#0.0 for floating point) operand, the
+// narrowing and widening forms append "2" when using the upper half, and
+// the pairwise long forms read elements of half the destination's size.
+func (d *disassembler) simdTwoReg() (string, []string) {
+	i := d.inst
+	name, ok := simdTwoRegNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	switch i.Op {
+	case OpVCMEQZ, OpVCMGTZ, OpVCMGEZ, OpVCMLEZ, OpVCMLTZ:
+		return name, []string{operandReg(i.Rd, i), operandReg(i.Rn, i), "#0"}
+	case OpVFCMEQZ, OpVFCMGEZ, OpVFCMGTZ, OpVFCMLEZ, OpVFCMLTZ:
+		return name, []string{operandReg(i.Rd, i), operandReg(i.Rn, i), "#0.0"}
+	case OpVXTN, OpVSQXTN, OpVUQXTN, OpVSQXTUN, OpVFCVTN:
+		wide := arrangementOf(2*arrangementSize(i.Arrangement), true)
+		return name + upperSuffix(i.Is64Bit), []string{vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, wide)}
+	case OpVFCVTL:
+		narrow := arrangementOf(arrangementSize(i.Arrangement)/2, i.Is64Bit)
+		return name + upperSuffix(i.Is64Bit), []string{vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, narrow)}
+	case OpVSADDLP, OpVUADDLP, OpVSADALP, OpVUADALP:
+		narrow := arrangementOf(arrangementSize(i.Arrangement)/2, i.Is64Bit)
+		return name, []string{vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, narrow)}
+	}
+	return name, []string{operandReg(i.Rd, i), operandReg(i.Rn, i)}
+}
+
+// upperSuffix returns the "2" suffix of the variants that use the upper
+// half of a narrow vector.
+func upperSuffix(upper bool) string {
+	if upper {
+		return "2"
+	}
+	return ""
+}
+
+// simdThreeDiffNames are the mnemonics of the three-different operations.
+var simdThreeDiffNames = map[Op]string{
+	OpVSADDL: "saddl", OpVUADDL: "uaddl", OpVSADDW: "saddw", OpVUADDW: "uaddw",
+	OpVSSUBL: "ssubl", OpVUSUBL: "usubl", OpVSSUBW: "ssubw", OpVUSUBW: "usubw",
+	OpVSABAL: "sabal", OpVUABAL: "uabal", OpVSABDL: "sabdl", OpVUABDL: "uabdl",
+	OpVSMLAL: "smlal", OpVUMLAL: "umlal", OpVSMLSL: "smlsl", OpVUMLSL: "umlsl",
+	OpVSMULL: "smull", OpVUMULL: "umull", OpVPMULL: "pmull",
+}
+
+// simdThreeDiff formats the long and wide operations.
+func (d *disassembler) simdThreeDiff() (string, []string) {
+	i := d.inst
+	name, ok := simdThreeDiffNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	narrow := arrangementOf(arrangementSize(i.Arrangement)/2, i.Is64Bit)
+	first := narrow
+	switch i.Op {
+	case OpVSADDW, OpVUADDW, OpVSSUBW, OpVUSUBW:
+		first = i.Arrangement
+	}
+	return name + upperSuffix(i.Is64Bit), []string{
+		vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, first), vecReg(i.Rm, narrow),
+	}
+}
+
+// simdShiftNames are the mnemonics of the shifts by immediate.
+var simdShiftNames = map[Op]string{
+	OpVSHL: "shl", OpVSSHR: "sshr", OpVUSHR: "ushr", OpVSSRA: "ssra", OpVUSRA: "usra",
+	OpVSHRN: "shrn", OpVRSHRN: "rshrn", OpVSSHLL: "sshll", OpVUSHLL: "ushll",
+}
+
+// simdShiftImm formats the shifts by immediate, using SXTL and UXTL for
+// long shifts by zero.
+func (d *disassembler) simdShiftImm() (string, []string) {
+	i := d.inst
+	name, ok := simdShiftNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	shift := decImm(int64(i.Imm))
+	switch i.Op {
+	case OpVSHRN, OpVRSHRN:
+		wide := arrangementOf(2*arrangementSize(i.Arrangement), true)
+		return name + upperSuffix(i.Is64Bit), []string{vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, wide), shift}
+	case OpVSSHLL, OpVUSHLL:
+		ops := []string{
+			vecReg(i.Rd, i.Arrangement),
+			vecReg(i.Rn, arrangementOf(arrangementSize(i.Arrangement)/2, i.Is64Bit)),
+		}
+		if i.Imm == 0 {
+			return name[:1] + "xtl" + upperSuffix(i.Is64Bit), ops
+		}
+		return name + upperSuffix(i.Is64Bit), append(ops, shift)
+	}
+	return name, []string{operandReg(i.Rd, i), operandReg(i.Rn, i), shift}
+}
+
+// simdModImm formats MOVI, MVNI, the vector ORR and BIC (immediate) and
+// FMOV. The 8-, 16- and 32-bit forms print imm8 with its shift; the 64-bit
+// form prints the whole pattern.
+func (d *disassembler) simdModImm() (string, []string) {
+	i := d.inst
+	var name string
+	switch i.Op {
+	case OpVFMOVImm:
+		size := arrangementSize(i.Arrangement)
+		elem := i.Imm & (^uint64(0) >> (64 - 8*uint(size)))
+		value := fpImmValue(elem, [...]FPType{2: FPHalf, 4: FPSingle, 8: FPDouble}[size])
+		return "fmov", []string{vecReg(i.Rd, i.Arrangement), fmt.Sprintf("#%.18e", value)}
+	case OpVMOVI:
+		name = "movi"
+	case OpVMVNI:
+		name = "mvni"
+	case OpVORRImm:
+		name = "orr"
+	case OpVBICImm:
+		name = "bic"
+	default:
+		return "", nil
+	}
+
+	size := arrangementSize(i.Arrangement)
+	if size == 8 {
+		dest := vecReg(i.Rd, i.Arrangement)
+		if i.Arrangement == Arr1D {
+			dest = scalarReg(i.Rd, 8)
+		}
+		return name, []string{dest, hexImm(i.Imm)}
+	}
+
+	elem := i.Imm & (uint64(1)<<(8*size) - 1)
+	ops := []string{vecReg(i.Rd, i.Arrangement), hexImm(elem >> i.ShiftAmount & 0xFF)}
+	switch {
+	case i.ShiftAmount == 0:
+	case elem&0x1 == 1: // Ones shifted in
+		ops = append(ops, fmt.Sprintf("msl %s", decImm(int64(i.ShiftAmount))))
+	default:
+		ops = append(ops, fmt.Sprintf("lsl %s", decImm(int64(i.ShiftAmount))))
+	}
+	return name, ops
+}
+
+// simdAcross formats the reductions across lanes, whose destination is a
+// scalar of the element size or, for the long forms, twice it.
+func (d *disassembler) simdAcross() (string, []string) {
+	i := d.inst
+	size := arrangementSize(i.Arrangement)
+	var name string
+	switch i.Op {
+	case OpVADDV:
+		name = "addv"
+	case OpVSADDLV:
+		name, size = "saddlv", 2*size
+	case OpVUADDLV:
+		name, size = "uaddlv", 2*size
+	case OpVSMAXV:
+		name = "smaxv"
+	case OpVUMAXV:
+		name = "umaxv"
+	case OpVSMINV:
+		name = "sminv"
+	case OpVUMINV:
+		name = "uminv"
+	case OpVFMAXV:
+		name = "fmaxv"
+	case OpVFMINV:
+		name = "fminv"
+	case OpVFMAXNMV:
+		name = "fmaxnmv"
+	case OpVFMINNMV:
+		name = "fminnmv"
+	default:
+		return "", nil
+	}
+	return name, []string{scalarReg(i.Rd, size), vecReg(i.Rn, i.Arrangement)}
+}
+
+// simdPermuteNames are the mnemonics of the permute operations.
+var simdPermuteNames = map[Op]string{
+	OpVUZP1: "uzp1", OpVUZP2: "uzp2", OpVTRN1: "trn1", OpVTRN2: "trn2",
+	OpVZIP1: "zip1", OpVZIP2: "zip2",
+}
+
+// simdPermute formats ZIP1, ZIP2, UZP1, UZP2, TRN1 and TRN2.
+func (d *disassembler) simdPermute() (string, []string) {
+	i := d.inst
+	name, ok := simdPermuteNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	return name, []string{
+		vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, i.Arrangement), vecReg(i.Rm, i.Arrangement),
+	}
+}
+
+// simdExtract formats EXT.
+func (d *disassembler) simdExtract() (string, []string) {
+	i := d.inst
+	if i.Op != OpVEXT {
+		return "", nil
+	}
+	return "ext", []string{
+		vecReg(i.Rd, i.Arrangement), vecReg(i.Rn, i.Arrangement), vecReg(i.Rm, i.Arrangement),
+		decImm(int64(i.Imm)),
+	}
+}
+
+// simdTableLookup formats TBL and TBX, whose table registers are always
+// 16B.
+func (d *disassembler) simdTableLookup() (string, []string) {
+	i := d.inst
+	var name string
+	switch i.Op {
+	case OpVTBL:
+		name = "tbl"
+	case OpVTBX:
+		name = "tbx"
+	default:
+		return "", nil
+	}
+	regs := make([]string, i.RegCount)
+	for n := range regs {
+		regs[n] = vecReg((i.Rn+uint8(n))%32, Arr16B)
+	}
+	return name, []string{
+		vecReg(i.Rd, i.Arrangement), "{ " + strings.Join(regs, ", ") + " }", vecReg(i.Rm, i.Arrangement),
+	}
+}
+
+// simdElem formats the vector and scalar by element operations.
+func (d *disassembler) simdElem() (string, []string) {
+	i := d.inst
+	var name string
+	switch i.Op {
+	case OpVFMLA:
+		name = "fmla"
+	case OpVFMLS:
+		name = "fmls"
+	case OpVFMUL:
+		name = "fmul"
+	case OpVMUL:
+		name = "mul"
+	case OpVMLA:
+		name = "mla"
+	case OpVMLS:
+		name = "mls"
+	case OpVSQDMULH:
+		name = "sqdmulh"
+	case OpVSQRDMULH:
+		name = "sqrdmulh"
+	default:
+		return "", nil
+	}
+	return name, []string{
+		operandReg(i.Rd, i), operandReg(i.Rn, i), elemReg(i.Rm, arrangementSize(i.Arrangement), i.Lane),
+	}
+}
+
+// simdScalarPairwiseNames are the mnemonics of the scalar pairwise
+// operations.
+var simdScalarPairwiseNames = map[Op]string{
+	OpVADDP: "addp", OpVFADDP: "faddp", OpVFMAXP: "fmaxp", OpVFMINP: "fminp",
+	OpVFMAXNMP: "fmaxnmp", OpVFMINNMP: "fminnmp",
+}
+
+// simdScalarPairwise formats the scalar pairwise operations, whose
+// destination is a scalar of the element size.
+func (d *disassembler) simdScalarPairwise() (string, []string) {
+	i := d.inst
+	name, ok := simdScalarPairwiseNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	return name, []string{scalarReg(i.Rd, arrangementSize(i.Arrangement)), vecReg(i.Rn, i.Arrangement)}
+}
+
+// cryptoNames are the mnemonics of the cryptographic operations.
+var cryptoNames = map[Op]string{
+	OpAESE: "aese", OpAESD: "aesd", OpAESMC: "aesmc", OpAESIMC: "aesimc",
+	OpSHA1C: "sha1c", OpSHA1P: "sha1p", OpSHA1M: "sha1m", OpSHA1H: "sha1h",
+	OpSHA1SU0: "sha1su0", OpSHA1SU1: "sha1su1",
+	OpSHA256H: "sha256h", OpSHA256H2: "sha256h2", OpSHA256SU0: "sha256su0", OpSHA256SU1: "sha256su1",
+	OpSHA512H: "sha512h", OpSHA512H2: "sha512h2", OpSHA512SU0: "sha512su0", OpSHA512SU1: "sha512su1",
+	OpEOR3: "eor3", OpBCAX: "bcax", OpRAX1: "rax1", OpXAR: "xar",
+}
+
+// crypto formats the cryptographic operations. The hash updates name their
+// state operands as Q registers (and SHA1C, SHA1P and SHA1M the hash
+// element as an S register), and SHA1H rotates an S register.
+func (d *disassembler) crypto() (string, []string) {
+	i := d.inst
+	name, ok := cryptoNames[i.Op]
+	if !ok {
+		return "", nil
+	}
+	arr := i.Arrangement
+	switch i.Op {
+	case OpSHA1H:
+		return name, []string{scalarReg(i.Rd, 4), scalarReg(i.Rn, 4)}
+	case OpSHA1C, OpSHA1P, OpSHA1M:
+		return name, []string{scalarReg(i.Rd, 16), scalarReg(i.Rn, 4), vecReg(i.Rm, arr)}
+	case OpSHA256H, OpSHA256H2, OpSHA512H, OpSHA512H2:
+		return name, []string{scalarReg(i.Rd, 16), scalarReg(i.Rn, 16), vecReg(i.Rm, arr)}
+	case OpEOR3, OpBCAX:
+		return name, []string{vecReg(i.Rd, arr), vecReg(i.Rn, arr), vecReg(i.Rm, arr), vecReg(i.Rt2, arr)}
+	case OpXAR:
+		return name, []string{vecReg(i.Rd, arr), vecReg(i.Rn, arr), vecReg(i.Rm, arr), decImm(int64(i.Imm))}
+	}
+	if i.Format == FormatCryptoTwoReg {
+		return name, []string{vecReg(i.Rd, arr), vecReg(i.Rn, arr)}
+	}
+	return name, []string{vecReg(i.Rd, arr), vecReg(i.Rn, arr), vecReg(i.Rm, arr)}
+}
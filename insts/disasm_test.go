@@ -0,0 +1,471 @@
+package insts_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/insts"
+)
+
+var _ = Describe("Disassembler", func() {
+	const pc = 0x1000
+
+	decoder := insts.NewDecoder()
+
+	expectText := func(texts map[uint32]string) {
+		for word, text := range texts {
+			Expect(insts.Disassemble(decoder.Decode(word), pc)).To(Equal(text), "word 0x%08x", word)
+		}
+	}
+
+	It("should render arithmetic and logical instructions with their aliases", func() {
+		expectText(map[uint32]string{
+			0x91004020: "add x0, x1, #0x10",
+			0x91400420: "add x0, x1, #0x1, lsl #12",
+			0x31000c20: "adds w0, w1, #0x3",
+			0xd10083ff: "sub sp, sp, #0x20",
+			0xf100141f: "cmp x0, #0x5",
+			0x3100043f: "cmn w1, #0x1",
+			0x910003fd: "mov x29, sp",
+			0x9100003f: "mov sp, x1",
+			0x8b020020: "add x0, x1, x2",
+			0x8b020c20: "add x0, x1, x2, lsl #3",
+			0xeb820820: "subs x0, x1, x2, asr #2",
+			0xeb01001f: "cmp x0, x1",
+			0x2b01081f: "cmn w0, w1, lsl #2",
+			0xcb0103e0: "neg x0, x1",
+			0x6b0103e0: "negs w0, w1",
+			0x8a020020: "and x0, x1, x2",
+			0xea020020: "ands x0, x1, x2",
+			0xea01001f: "tst x0, x1",
+			0x0a621020: "bic w0, w1, w2, lsr #4",
+			0xea220020: "bics x0, x1, x2",
+			0xaa020020: "orr x0, x1, x2",
+			0xaa0103e0: "mov x0, x1",
+			0x2a0103e0: "mov w0, w1",
+			0xaa2103e0: "mvn x0, x1",
+			0xaa220020: "orn x0, x1, x2",
+			0xcac21420: "eor x0, x1, x2, ror #5",
+			0xca220020: "eon x0, x1, x2",
+			0x92401c20: "and x0, x1, #0xff",
+			0x721c0c20: "ands w0, w1, #0xf0",
+			0xf240001f: "tst x0, #0x1",
+			0xb2703c20: "orr x0, x1, #0xffff0000",
+			0xd240003f: "eor sp, x1, #0x1",
+			0xd2800540: "mov x0, #0x2a",
+		})
+	})
+
+	It("should render extended-register and carry arithmetic", func() {
+		expectText(map[uint32]string{
+			0x8b214be0: "add x0, sp, w1, uxtw #2",
+			0x8b2163ff: "add sp, sp, x1",
+			0x8b226420: "add x0, x1, x2, uxtx #1",
+			0x0b220020: "add w0, w1, w2, uxtb",
+			0xab216fe0: "adds x0, sp, x1, lsl #3",
+			0xeb2163ff: "cmp sp, x1",
+			0xeb21c01f: "cmp x0, w1, sxtw",
+			0x2b21301f: "cmn w0, w1, uxth #4",
+			0xcb2263ff: "sub sp, sp, x2",
+			0xeb228020: "subs x0, x1, w2, sxtb",
+			0x0b2143e0: "add w0, wsp, w1",
+			0x8b22e020: "add x0, x1, x2, sxtx",
+			0xda0103e0: "ngc x0, x1",
+			0x7a0103e0: "ngcs w0, w1",
+			0xda020020: "sbc x0, x1, x2",
+			0x3a020020: "adcs w0, w1, w2",
+		})
+	})
+
+	It("should render long multiplies, bit manipulation and CRC32", func() {
+		expectText(map[uint32]string{
+			0x9b227c20: "smull x0, w1, w2",
+			0x9b22fc20: "smnegl x0, w1, w2",
+			0x9ba27c20: "umull x0, w1, w2",
+			0x9ba2fc20: "umnegl x0, w1, w2",
+			0x9ba20c20: "umaddl x0, w1, w2, x3",
+			0x9b228c20: "smsubl x0, w1, w2, x3",
+			0x9b427c20: "smulh x0, x1, x2",
+			0xdac00c20: "rev x0, x1",
+			0xdac00820: "rev32 x0, x1",
+			0x5ac00420: "rev16 w0, w1",
+			0x5ac00820: "rev w0, w1",
+			0xdac00020: "rbit x0, x1",
+			0x5ac01420: "cls w0, w1",
+			0x9ac25c20: "crc32cx w0, w1, x2",
+			0x1ac24420: "crc32h w0, w1, w2",
+		})
+	})
+
+	It("should render moves, bitfield and extract aliases", func() {
+		expectText(map[uint32]string{
+			0x52a00020: "mov w0, #0x10000",
+			0xd2a00000: "movz x0, #0x0, lsl #16",
+			0x92800000: "mov x0, #0xffffffffffffffff",
+			0x12a00020: "mov w0, #0xfffeffff",
+			0x129fffe0: "movn w0, #0xffff",
+			0xf2a24680: "movk x0, #0x1234, lsl #16",
+			0x72800020: "movk w0, #0x1",
+			0x9343fc20: "asr x0, x1, #3",
+			0x93401c20: "sxtb x0, w1",
+			0x13003c20: "sxth w0, w1",
+			0x93407c20: "sxtw x0, w1",
+			0x937c1c20: "sbfiz x0, x1, #4, #8",
+			0x93442c20: "sbfx x0, x1, #4, #8",
+			0xd37df020: "lsl x0, x1, #3",
+			0x53010020: "lsl w0, w1, #31",
+			0x53057c20: "lsr w0, w1, #5",
+			0x53001c20: "uxtb w0, w1",
+			0x53003c20: "uxth w0, w1",
+			0xd37e2420: "ubfiz x0, x1, #2, #10",
+			0xd3442c20: "ubfx x0, x1, #4, #8",
+			0xb3780c20: "bfi x0, x1, #8, #4",
+			0x33031c20: "bfxil w0, w1, #3, #5",
+			0xb3780fe0: "bfc x0, #8, #4",
+			0x93c23020: "extr x0, x1, x2, #12",
+			0x93c13020: "ror x0, x1, #12",
+		})
+	})
+
+	It("should render branches with absolute targets", func() {
+		expectText(map[uint32]string{
+			0x10000200: "adr x0, 0x1040",
+			0xf0000000: "adrp x0, 0x4000",
+			0x14000040: "b 0x1100",
+			0x97fffffe: "bl 0xff8",
+			0x54000101: "b.ne 0x1020",
+			0x54ffffe0: "b.eq 0xffc",
+			0xd61f0200: "br x16",
+			0xd63f0100: "blr x8",
+			0xd65f03c0: "ret",
+			0xd65f0020: "ret x1",
+			0xb4000080: "cbz x0, 0x1010",
+			0x35ffff83: "cbnz w3, 0xff0",
+			0x36180040: "tbz w0, #3, 0x1008",
+			0xb7400040: "tbnz x0, #40, 0x1008",
+			0xd4000001: "svc #0x0",
+			0xd4207d00: "brk #0x3e8",
+		})
+	})
+
+	It("should render conditional, multiply and divide instructions", func() {
+		expectText(map[uint32]string{
+			0x9a820020: "csel x0, x1, x2, eq",
+			0x9a821420: "csinc x0, x1, x2, ne",
+			0x1a9f17e0: "cset w0, eq",
+			0x9a81a420: "cinc x0, x1, lt",
+			0xda9f93e0: "csetm x0, hi",
+			0x5a81b020: "cinv w0, w1, ge",
+			0xda82a020: "csinv x0, x1, x2, ge",
+			0xda815420: "cneg x0, x1, mi",
+			0xda82d420: "csneg x0, x1, x2, le",
+			0xfa431804: "ccmp x0, #0x3, #0x4, ne",
+			0x3a410000: "ccmn w0, w1, #0x0, eq",
+			0x9ac20820: "udiv x0, x1, x2",
+			0x1ac20c20: "sdiv w0, w1, w2",
+			0x9ac22020: "lsl x0, x1, x2",
+			0x9ac22420: "lsr x0, x1, x2",
+			0x1ac22820: "asr w0, w1, w2",
+			0x9ac22c20: "ror x0, x1, x2",
+			0x9b027c20: "mul x0, x1, x2",
+			0x9b02fc20: "mneg x0, x1, x2",
+			0x9b020c20: "madd x0, x1, x2, x3",
+			0x1b028c20: "msub w0, w1, w2, w3",
+		})
+	})
+
+	It("should render loads and stores in every addressing mode", func() {
+		expectText(map[uint32]string{
+			0xf9400020: "ldr x0, [x1]",
+			0xf9400820: "ldr x0, [x1, #16]",
+			0xb94007e0: "ldr w0, [sp, #4]",
+			0xf90007e0: "str x0, [sp, #8]",
+			0xb9800420: "ldrsw x0, [x1, #4]",
+			0xf8408c20: "ldr x0, [x1, #8]!",
+			0xf81f0fe0: "str x0, [sp, #-16]!",
+			0xf8408420: "ldr x0, [x1], #8",
+			0x38401420: "ldrb w0, [x1], #1",
+			0x381ffc20: "strb w0, [x1, #-1]!",
+			0x38801420: "ldrsb x0, [x1], #1",
+			0x78c02420: "ldrsh w0, [x1], #2",
+			0x78002420: "strh w0, [x1], #2",
+			0xf85f8020: "ldur x0, [x1, #-8]",
+			0x38003020: "sturb w0, [x1, #3]",
+			0xb89fc020: "ldursw x0, [x1, #-4]",
+			0xf8626820: "ldr x0, [x1, x2]",
+			0xf8627820: "ldr x0, [x1, x2, lsl #3]",
+			0xb8624820: "ldr w0, [x1, w2, uxtw]",
+			0xb862d820: "ldr w0, [x1, w2, sxtw #2]",
+			0x38626820: "ldrb w0, [x1, x2]",
+			0x78227820: "strh w0, [x1, x2, lsl #1]",
+			0xb8a2f820: "ldrsw x0, [x1, x2, sxtx #2]",
+			0x58000200: "ldr x0, 0x1040",
+			0x18ffffe0: "ldr w0, 0xffc",
+			0x5c000040: "ldr d0, 0x1008",
+			0xa8c17bfd: "ldp x29, x30, [sp], #16",
+			0xa9be7bfd: "stp x29, x30, [sp, #-32]!",
+			0x29410440: "ldp w0, w1, [x2, #8]",
+			0xa9000440: "stp x0, x1, [x2]",
+		})
+	})
+
+	It("should render structure loads and stores", func() {
+		expectText(map[uint32]string{
+			0x4cdfa000: "ld1 { v0.16b, v1.16b }, [x0], #32",
+			0x4c400820: "ld4 { v0.4s, v1.4s, v2.4s, v3.4s }, [x1]",
+			0x0c406c5f: "ld1 { v31.1d, v0.1d, v1.1d }, [x2]",
+			0x4c8a2d25: "st1 { v5.2d, v6.2d, v7.2d, v8.2d }, [x9], x10",
+			0x0c9f4801: "st3 { v1.2s, v2.2s, v3.2s }, [x0], #24",
+			0x4dc49062: "ld1 { v2.s }[3], [x3], x4",
+			0x4d205be0: "st2 { v0.h, v1.h }[7], [sp]",
+			0x4dff2400: "ld4 { v0.b, v1.b, v2.b, v3.b }[9], [x0], #4",
+			0x4dff8400: "ld2 { v0.d, v1.d }[1], [x0], #16",
+			0x4d40c800: "ld1r { v0.4s }, [x0]",
+			0x0ddfec00: "ld3r { v0.1d, v1.1d, v2.1d }, [x0], #24",
+			0x0c408c00: ".inst 0x0c408c00",
+		})
+	})
+
+	It("should render unprivileged, prefetch, non-temporal and SIMD&FP accesses", func() {
+		expectText(map[uint32]string{
+			0xf85f8820: "ldtr x0, [x1, #-8]",
+			0x38003be2: "sttrb w2, [sp, #3]",
+			0xb8800883: "ldtrsw x3, [x4]",
+			0x78c028c5: "ldtrsh w5, [x6, #2]",
+			0xf9800000: "prfm pldl1keep, [x0]",
+			0xf9802033: "prfm pstl2strm, [x1, #64]",
+			0xf8a3784c: "prfm plil3keep, [x2, x3, lsl #3]",
+			0xf89f8002: "prfum pldl2keep, [x0, #-8]",
+			0xf9800018: "prfm #0x18, [x0]",
+			0xd8000081: "prfm pldl1strm, 0x1010",
+			0x98000040: "ldrsw x0, 0x1008",
+			0x9c000101: "ldr q1, 0x1020",
+			0x1cffff82: "ldr s2, 0xff0",
+			0xa84107e0: "ldnp x0, x1, [sp, #16]",
+			0x283f0c82: "stnp w2, w3, [x4, #-8]",
+			0xac410440: "ldnp q0, q1, [x2, #32]",
+			0x69410440: "ldpsw x0, x1, [x2, #8]",
+			0x68c10440: "ldpsw x0, x1, [x2], #8",
+			0x2d408400: "ldp s0, s1, [x0, #4]",
+			0x6dbf07e0: "stp d0, d1, [sp, #-16]!",
+			0xacc10420: "ldp q0, q1, [x1], #32",
+			0x3d400420: "ldr b0, [x1, #1]",
+			0x7c1fec41: "str h1, [x2, #-2]!",
+			0xbc404462: "ldr s2, [x3], #4",
+			0xfc657883: "ldr d3, [x4, x5, lsl #3]",
+			0x3ca6d8a4: "str q4, [x5, w6, sxtw #4]",
+			0x3cdf0020: "ldur q0, [x1, #-16]",
+			0xfc400062: "ldur d2, [x3]",
+			0x79c00420: "ldrsh w0, [x1, #2]",
+			0x795ffc20: "ldrh w0, [x1, #4094]",
+		})
+	})
+
+	It("should render SIMD instructions", func() {
+		expectText(map[uint32]string{
+			0x4ea28420: "add v0.4s, v1.4s, v2.4s",
+			0x2e228420: "sub v0.8b, v1.8b, v2.8b",
+			0x4e629c20: "mul v0.8h, v1.8h, v2.8h",
+			0x3dc00820: "ldr q0, [x1, #32]",
+			0x3d8003e1: "str q1, [sp]",
+			0x4e040c20: "dup v0.4s, w1",
+			0x4e080c20: "dup v0.2d, x1",
+			0x4e010c40: "dup v0.16b, w2",
+		})
+	})
+
+	It("should render NEON integer instructions", func() {
+		expectText(map[uint32]string{
+			0x4e220c20: "sqadd v0.16b, v1.16b, v2.16b",
+			0x4ea11c20: "mov v0.16b, v1.16b",
+			0x4ea09820: "cmeq v0.4s, v1.4s, #0",
+			0x6e205820: "mvn v0.16b, v1.16b",
+			0x4e612820: "xtn2 v0.8h, v1.4s",
+			0x6e620020: "uaddl2 v0.4s, v1.8h, v2.8h",
+			0x0ea21020: "saddw v0.2d, v1.2d, v2.2s",
+			0x4f108420: "shrn2 v0.8h, v1.4s, #16",
+			0x0f10a420: "sxtl v0.4s, v1.4h",
+			0x4f01d420: "movi v0.4s, #0x21, msl #16",
+			0x2f00e7e0: "movi d0, #0xffffffffff",
+			0x2f0035e0: "bic v0.2s, #0xf, lsl #8",
+			0x4e0c0420: "dup v0.4s, v1.s[1]",
+			0x4e0c1c20: "mov v0.s[1], w1",
+			0x4e183c20: "mov x0, v1.d[1]",
+			0x0e073c20: "umov w0, v1.b[3]",
+			0x4e0a2c20: "smov x0, v1.h[2]",
+			0x4eb03820: "saddlv d0, v1.4s",
+			0x4e025820: "uzp2 v0.16b, v1.16b, v2.16b",
+			0x0e027820: "zip2 v0.8b, v1.8b, v2.8b",
+			0x4ec22820: "trn1 v0.2d, v1.2d, v2.2d",
+			0x6e021820: "ext v0.16b, v1.16b, v2.16b, #3",
+			0x4ea00820: "rev64 v0.4s, v1.4s",
+			0x4e201820: "rev16 v0.16b, v1.16b",
+			0x0e032020: "tbl v0.8b, { v1.16b, v2.16b }, v3.8b",
+			0x4e0453c0: "tbx v0.16b, { v30.16b, v31.16b, v0.16b }, v4.16b",
+			0x6e0a5420: "mov v0.h[2], v1.h[5]",
+		})
+	})
+
+	It("should render NEON floating-point instructions", func() {
+		expectText(map[uint32]string{
+			0x4e421420: "fadd v0.8h, v1.8h, v2.8h",
+			0x6ee2ec20: "facgt v0.2d, v1.2d, v2.2d",
+			0x4ea0d820: "fcmeq v0.4s, v1.4s, #0.0",
+			0x6ea19820: "frinti v0.4s, v1.4s",
+			0x4e217820: "fcvtl2 v0.4s, v1.8h",
+			0x0e216820: "fcvtn v0.4h, v1.4s",
+			0x4fa21820: "fmla v0.4s, v1.4s, v2.s[3]",
+			0x7e30d820: "faddp s0, v1.2s",
+			0x5f821820: "fmla s0, s1, v2.s[2]",
+			0x5e61d820: "scvtf d0, d1",
+			0x5ea0d820: "fcmeq s0, s1, #0.0",
+			0x4ea1d820: "frecpe v0.4s, v1.4s",
+			0x7ee2d420: "fabd d0, d1, d2",
+			0x4e30f820: "fmaxv h0, v1.8h",
+			0x4f03f600: "fmov v0.4s, #1.000000000000000000e+00",
+		})
+	})
+
+	It("should render cryptographic instructions", func() {
+		expectText(map[uint32]string{
+			0x4e284820: "aese v0.16b, v1.16b",
+			0x4e2878e6: "aesimc v6.16b, v7.16b",
+			0x5e020020: "sha1c q0, s1, v2.4s",
+			0x5e280820: "sha1h s0, s1",
+			0x5e024020: "sha256h q0, q1, v2.4s",
+			0x5e023020: "sha1su0 v0.4s, v1.4s, v2.4s",
+			0xce628420: "sha512h2 q0, q1, v2.2d",
+			0xcec08020: "sha512su0 v0.2d, v1.2d",
+			0xce020c20: "eor3 v0.16b, v1.16b, v2.16b, v3.16b",
+			0xce628c20: "rax1 v0.2d, v1.2d, v2.2d",
+			0xce822820: "xar v0.2d, v1.2d, v2.2d, #10",
+			0x0e22e020: "pmull v0.8h, v1.8b, v2.8b",
+			0x4ee2e020: "pmull2 v0.1q, v1.2d, v2.2d",
+		})
+	})
+
+	It("should render system instructions", func() {
+		expectText(map[uint32]string{
+			0xd503201f: "nop",
+			0xd503203f: "yield",
+			0xd503205f: "wfe",
+			0xd503245f: "bti c",
+			0xd503233f: "paciasp",
+			0xd5032fff: "hint #0x7f",
+			0xd50323bf: "autiasp",
+			0xd50320ff: "xpaclri",
+			0xd50324df: "bti jc",
+			0xd503211f: "pacia1716",
+			0xdac107e1: "pacib x1, sp",
+			0xdac13fe3: "autdzb x3",
+			0xdac147e4: "xpacd x4",
+			0x9adf3041: "pacga x1, x2, sp",
+			0xd63f0cbf: "blrabz x5",
+			0xd65f0bff: "retaa",
+			0xd71f08a6: "braa x5, x6",
+			0xd73f0ca6: "blrab x5, x6",
+			0xd5033bbf: "dmb ish",
+			0xd5033f9f: "dsb sy",
+			0xd50339bf: "dmb ishld",
+			0xd5033fdf: "isb",
+			0xd5033f5f: "clrex",
+			0xd53bd040: "mrs x0, tpidr_el0",
+			0xd51b4401: "msr fpcr, x1",
+			0xd53bf020: "mrs x0, s3_3_c15_c0_1",
+			0xd53b00e1: "mrs x1, dczid_el0",
+			0xd5380600: "mrs x0, id_aa64isar0_el1",
+			0xd51b4205: "msr nzcv, x5",
+			0xd50b7420: "dc zva, x0",
+			0xd50b7e23: "dc civac, x3",
+			0xd50b7522: "ic ivau, x2",
+		})
+	})
+
+	It("should render scalar floating-point instructions", func() {
+		expectText(map[uint32]string{
+			0x1e622820: "fadd d0, d1, d2",
+			0x1e223820: "fsub s0, s1, s2",
+			0x1ee20820: "fmul h0, h1, h2",
+			0x1e621820: "fdiv d0, d1, d2",
+			0x1e61c020: "fsqrt d0, d1",
+			0x1e20c020: "fabs s0, s1",
+			0x1e614020: "fneg d0, d1",
+			0x1e604020: "fmov d0, d1",
+			0x1e628820: "fnmul d0, d1, d2",
+			0x1e224820: "fmax s0, s1, s2",
+			0x1e627820: "fminnm d0, d1, d2",
+			0x1e654020: "frintm d0, d1",
+			0x1e25c020: "frintz s0, s1",
+			0x1e22c020: "fcvt d0, s1",
+			0x1e624020: "fcvt s0, d1",
+			0x1e63c020: "fcvt h0, d1",
+			0x1f420c20: "fmadd d0, d1, d2, d3",
+			0x1f228c20: "fnmsub s0, s1, s2, s3",
+			0x1e612000: "fcmp d0, d1",
+			0x1e202018: "fcmpe s0, #0.0",
+			0x1e611404: "fccmp d0, d1, #0x4, ne",
+			0x1e62cc20: "fcsel d0, d1, d2, gt",
+			0x1e6e1000: "fmov d0, #1.000000000000000000e+00",
+			0x1e309000: "fmov s0, #-2.500000000000000000e+00",
+			0x1eef1000: "fmov h0, #1.500000000000000000e+00",
+			0x9e620020: "scvtf d0, x1",
+			0x1e230020: "ucvtf s0, w1",
+			0x1e42c020: "scvtf d0, w1, #16",
+			0x9e780020: "fcvtzs x0, d1",
+			0x1e19e020: "fcvtzu w0, s1, #8",
+			0x9e600020: "fcvtns x0, d1",
+			0x1e700020: "fcvtms w0, d1",
+			0x9e640020: "fcvtas x0, d1",
+			0x9e660020: "fmov x0, d1",
+			0x9e670020: "fmov d0, x1",
+			0x1e260020: "fmov w0, s1",
+			0x9eae0020: "fmov x0, v1.d[1]",
+			0x9eaf0020: "fmov v0.d[1], x1",
+		})
+	})
+
+	It("should render exclusive and atomic instructions", func() {
+		expectText(map[uint32]string{
+			0xc85f7c20: "ldxr x0, [x1]",
+			0x885fffe2: "ldaxr w2, [sp]",
+			0x085f7c20: "ldxrb w0, [x1]",
+			0xc8037c20: "stxr w3, x0, [x1]",
+			0x4803fc20: "stlxrh w3, w0, [x1]",
+			0xc87f0440: "ldxp x0, x1, [x2]",
+			0xc8248440: "stlxp w4, x0, x1, [x2]",
+			0xc8dffc20: "ldar x0, [x1]",
+			0x089ffc20: "stlrb w0, [x1]",
+			0xc8a07c41: "cas x0, x1, [x2]",
+			0x88e0fc41: "casal w0, w1, [x2]",
+			0x08e07c41: "casab w0, w1, [x2]",
+			0x48207c82: "casp x0, x1, x2, x3, [x4]",
+			0x0820fc82: "caspl w0, w1, w2, w3, [x4]",
+			0xf8200041: "ldadd x0, x1, [x2]",
+			0xb8e00041: "ldaddal w0, w1, [x2]",
+			0x38e00041: "ldaddalb w0, w1, [x2]",
+			0xf820005f: "stadd x0, [x2]",
+			0xb860005f: "staddl w0, [x2]",
+			0x78201041: "ldclrh w0, w1, [x2]",
+			0xf8204041: "ldsmax x0, x1, [x2]",
+			0xf820705f: "stumin x0, [x2]",
+			0xf8208041: "swp x0, x1, [x2]",
+			0xb8e08041: "swpal w0, w1, [x2]",
+			0x38208041: "swpb w0, w1, [x2]",
+			0xf8bfc020: "ldapr x0, [x1]",
+			0x38bfc020: "ldaprb w0, [x1]",
+		})
+	})
+
+	It("should render instructions it cannot decode as raw words", func() {
+		expectText(map[uint32]string{
+			0x00000000: ".inst 0x00000000",
+			0x04000000: ".inst 0x04000000",
+		})
+	})
+
+	It("should show PC-relative targets as offsets without a PC", func() {
+		Expect(decoder.Decode(0x94000040).String()).To(Equal("bl .+0x100"))
+		Expect(decoder.Decode(0x54ffffe0).String()).To(Equal("b.eq .-0x4"))
+		Expect(decoder.Decode(0x91004020).String()).To(Equal("add x0, x1, #0x10"))
+	})
+})
@@ -3,8 +3,12 @@ package loader
 
 import (
 	"debug/elf"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strings"
 )
 
 // SegmentFlags represents memory protection flags for a segment.
@@ -19,6 +23,22 @@ const (
 	SegmentFlagRead
 )
 
+// Prot returns the flags as mmap protection bits (PROT_READ = 1,
+// PROT_WRITE = 2, PROT_EXEC = 4).
+func (f SegmentFlags) Prot() int {
+	prot := 0
+	if f&SegmentFlagRead != 0 {
+		prot |= 0x1
+	}
+	if f&SegmentFlagWrite != 0 {
+		prot |= 0x2
+	}
+	if f&SegmentFlagExecute != 0 {
+		prot |= 0x4
+	}
+	return prot
+}
+
 // DefaultStackTop is the default stack top address for ARM64 Linux user space.
 // This is a conventional high address in the user space address range.
 const DefaultStackTop = 0x7ffffffff000
@@ -40,23 +60,126 @@ type Segment struct {
 
 // Program represents a loaded ELF program ready for execution.
 type Program struct {
+	// Path is the path the program was loaded from.
+	Path string
 	// EntryPoint is the virtual address where execution should begin.
 	EntryPoint uint64
 	// Segments contains all loadable segments from the ELF file.
 	Segments []Segment
-	// InitialSP is the initial stack pointer value.
+	// InitialSP is the initial stack pointer value. SetupStack builds the
+	// process stack below it.
 	InitialSP uint64
+	// PHdrAddr is the virtual address of the program headers, or 0 if no
+	// loaded segment contains them.
+	PHdrAddr uint64
+	// PHdrEntSize is the size of one program header entry.
+	PHdrEntSize uint64
+	// PHdrNum is the number of program headers.
+	PHdrNum uint64
+	// Symbols contains the code symbols from the ELF symbol table, sorted by
+	// address. It is empty for stripped binaries.
+	Symbols []Symbol
+	// HWCap is the AT_HWCAP value SetupStack reports. Load sets it to
+	// DefaultHWCap; callers add the bits of optional features they enable.
+	HWCap uint64
+}
+
+// Symbol is a named code address from the ELF symbol table.
+type Symbol struct {
+	// Name is the symbol name.
+	Name string
+	// Addr is the symbol's virtual address.
+	Addr uint64
+	// Size is the size of the symbol in bytes, or 0 if unknown.
+	Size uint64
+}
+
+// SymbolAt returns the symbol at or nearest below addr.
+func (p *Program) SymbolAt(addr uint64) (Symbol, bool) {
+	i := sort.Search(len(p.Symbols), func(i int) bool {
+		return p.Symbols[i].Addr > addr
+	})
+	if i == 0 {
+		return Symbol{}, false
+	}
+	return p.Symbols[i-1], true
+}
+
+// Symbolize names addr as symbol+offset, or returns "" if no symbol precedes
+// it.
+func (p *Program) Symbolize(addr uint64) string {
+	sym, ok := p.SymbolAt(addr)
+	if !ok {
+		return ""
+	}
+	if addr == sym.Addr {
+		return sym.Name
+	}
+	return fmt.Sprintf("%s+0x%x", sym.Name, addr-sym.Addr)
+}
+
+// Memory is the memory a program is loaded into. *emu.Memory implements it.
+type Memory interface {
+	WriteBytes(addr uint64, data []byte)
+}
+
+// LoadInto writes the program's segments into mem and zero-fills their BSS
+// portions.
+func (p *Program) LoadInto(mem Memory) {
+	var zeros [4096]byte
+	for _, seg := range p.Segments {
+		mem.WriteBytes(seg.VirtAddr, seg.Data)
+		for addr := seg.VirtAddr + uint64(len(seg.Data)); addr < seg.VirtAddr+seg.MemSize; {
+			n := min(seg.VirtAddr+seg.MemSize-addr, uint64(len(zeros)))
+			mem.WriteBytes(addr, zeros[:n])
+			addr += n
+		}
+	}
+}
+
+// Mapper records the protection of mapped memory. *emu.Memory implements it.
+type Mapper interface {
+	Map(addr, size uint64, prot int)
+}
+
+// NamedMapper is a Mapper that also names mappings, as /proc/self/maps
+// shows them. *emu.AddressSpace implements it.
+type NamedMapper interface {
+	Mapper
+	MapNamed(addr, size uint64, prot int, name string)
+}
+
+// MapInto maps the program's segments with their protection, and the stack
+// read-write, in mem. A NamedMapper names the segments after the program's
+// path and the stack [stack].
+func (p *Program) MapInto(mem Mapper) {
+	mapNamed := func(addr, size uint64, prot int, name string) {
+		mem.Map(addr, size, prot)
+	}
+	if named, ok := mem.(NamedMapper); ok {
+		mapNamed = named.MapNamed
+	}
+
+	for _, seg := range p.Segments {
+		mapNamed(seg.VirtAddr, seg.MemSize, seg.Flags.Prot(), p.Path)
+	}
+	mapNamed(p.InitialSP-DefaultStackSize, DefaultStackSize, (SegmentFlagRead | SegmentFlagWrite).Prot(), "[stack]")
 }
 
 // Load parses an ARM64 ELF binary and returns a Program struct ready for
 // loading into the emulator's memory.
 func Load(path string) (*Program, error) {
 	// Open the ELF file
-	f, err := elf.Open(path)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ELF file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	f, err := elf.NewFile(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open ELF file: %w", err)
 	}
-	defer func() { _ = f.Close() }()
 
 	// Validate ELF class (must be 64-bit)
 	if f.Class != elf.ELFCLASS64 {
@@ -70,10 +193,21 @@ func Load(path string) (*Program, error) {
 
 	// Create the program structure
 	prog := &Program{
-		EntryPoint: f.Entry,
-		InitialSP:  DefaultStackTop,
+		Path:        path,
+		EntryPoint:  f.Entry,
+		InitialSP:   DefaultStackTop,
+		PHdrEntSize: 56, // sizeof(Elf64_Phdr)
+		PHdrNum:     uint64(len(f.Progs)),
+		HWCap:       DefaultHWCap,
 	}
 
+	phoff, err := phdrOffset(file)
+	if err != nil {
+		return nil, err
+	}
+	prog.PHdrAddr = phdrAddr(f.Progs, phoff)
+	prog.Symbols = codeSymbols(f)
+
 	// Load all PT_LOAD segments
 	for _, phdr := range f.Progs {
 		if phdr.Type != elf.PT_LOAD {
@@ -117,3 +251,60 @@ func Load(path string) (*Program, error) {
 
 	return prog, nil
 }
+
+// codeSymbols returns the function and untyped symbols, such as assembly
+// labels, in the executable sections of f, sorted by address. AArch64 mapping
+// symbols ($x, $d) are skipped.
+func codeSymbols(f *elf.File) []Symbol {
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil
+	}
+
+	var out []Symbol
+	for _, sym := range syms {
+		typ := elf.ST_TYPE(sym.Info)
+		if typ != elf.STT_FUNC && typ != elf.STT_NOTYPE {
+			continue
+		}
+		if sym.Name == "" || strings.HasPrefix(sym.Name, "$") {
+			continue
+		}
+		if int(sym.Section) >= len(f.Sections) || f.Sections[sym.Section].Flags&elf.SHF_EXECINSTR == 0 {
+			continue
+		}
+		out = append(out, Symbol{Name: sym.Name, Addr: sym.Value, Size: sym.Size})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Addr < out[j].Addr
+	})
+	return out
+}
+
+// phdrOffset reads e_phoff, the file offset of the program headers, from the
+// 64-bit ELF header.
+func phdrOffset(r io.ReaderAt) (uint64, error) {
+	var field [8]byte
+	if _, err := r.ReadAt(field[:], 32); err != nil {
+		return 0, fmt.Errorf("failed to read ELF header: %w", err)
+	}
+	return binary.LittleEndian.Uint64(field[:]), nil
+}
+
+// phdrAddr returns the virtual address of the program headers: the PT_PHDR
+// address if present, otherwise their address within the PT_LOAD segment
+// that maps file offset phoff.
+func phdrAddr(progs []*elf.Prog, phoff uint64) uint64 {
+	for _, phdr := range progs {
+		if phdr.Type == elf.PT_PHDR {
+			return phdr.Vaddr
+		}
+	}
+	for _, phdr := range progs {
+		if phdr.Type == elf.PT_LOAD && phoff >= phdr.Off && phoff < phdr.Off+phdr.Filesz {
+			return phdr.Vaddr + phoff - phdr.Off
+		}
+	}
+	return 0
+}
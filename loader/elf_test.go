@@ -1,6 +1,7 @@
 package loader_test
 
 import (
+	"bytes"
 	"encoding/binary"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/sarchlab/m2sim/emu"
 	"github.com/sarchlab/m2sim/loader"
 )
 
@@ -223,6 +225,55 @@ var _ = Describe("ELF Loader", func() {
 			Expect(dataSeg.Data).To(Equal(dataData))
 			Expect(dataSeg.Flags & loader.SegmentFlagWrite).NotTo(BeZero())
 		})
+
+		It("should map segments with their protection and the stack read-write", func() {
+			elfPath := filepath.Join(tempDir, "multi-segment.elf")
+			codeData := []byte{0x40, 0x05, 0x80, 0xd2, 0xc0, 0x03, 0x5f, 0xd6}
+			dataData := []byte{0x01, 0x02, 0x03, 0x04}
+			createMultiSegmentARM64ELF(elfPath, 0x400000, 0x400000, codeData, 0x600000, dataData)
+
+			prog, err := loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			memory := emu.NewMemory()
+			prog.MapInto(memory)
+
+			prot, mapped := memory.Protection(0x400000)
+			Expect(mapped).To(BeTrue())
+			Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_EXEC))
+
+			prot, mapped = memory.Protection(0x600000)
+			Expect(mapped).To(BeTrue())
+			Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+
+			prot, mapped = memory.Protection(prog.InitialSP - 8)
+			Expect(mapped).To(BeTrue())
+			Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+
+			_, mapped = memory.Protection(0x500000)
+			Expect(mapped).To(BeFalse())
+		})
+
+		It("should name the mappings in an address space", func() {
+			elfPath := filepath.Join(tempDir, "multi-segment.elf")
+			codeData := []byte{0x40, 0x05, 0x80, 0xd2, 0xc0, 0x03, 0x5f, 0xd6}
+			dataData := []byte{0x01, 0x02, 0x03, 0x04}
+			createMultiSegmentARM64ELF(elfPath, 0x400000, 0x400000, codeData, 0x600000, dataData)
+
+			prog, err := loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prog.Path).To(Equal(elfPath))
+
+			space := emu.NewAddressSpace(emu.NewMemory())
+			prog.MapInto(space)
+
+			vmas := space.VMAs()
+			Expect(vmas).To(HaveLen(3))
+			Expect(vmas[0].Name).To(Equal(elfPath))
+			Expect(vmas[1].Name).To(Equal(elfPath))
+			Expect(vmas[2].Name).To(Equal("[stack]"))
+			Expect(vmas[2].End).To(Equal(prog.InitialSP))
+		})
 	})
 
 	Describe("BSS segments", func() {
@@ -249,6 +300,25 @@ var _ = Describe("ELF Loader", func() {
 			Expect(bssSeg.MemSize).To(Equal(memSize))
 			Expect(bssSeg.MemSize).To(BeNumerically(">", uint64(len(bssSeg.Data))))
 		})
+
+		It("should zero-fill the BSS when loaded into memory", func() {
+			elfPath := filepath.Join(tempDir, "bss.elf")
+			initialData := []byte{0x01, 0x02, 0x03, 0x04}
+			memSize := uint64(3 * 4096)
+			createBSSSegmentELF(elfPath, 0x600000, 0x400000, initialData, memSize)
+
+			prog, err := loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			memory := emu.NewMemory()
+			memory.WriteBytes(0x600000, bytes.Repeat([]byte{0xFF}, int(memSize)))
+			prog.LoadInto(memory)
+
+			buf := make([]byte, memSize)
+			memory.ReadBytes(0x600000, buf)
+			Expect(buf[:4]).To(Equal(initialData))
+			Expect(buf[4:]).To(Equal(make([]byte, memSize-4)))
+		})
 	})
 
 	Describe("Zero Filesz segments", func() {
@@ -275,6 +345,51 @@ var _ = Describe("ELF Loader", func() {
 		})
 	})
 
+	Describe("Symbols", func() {
+		var prog *loader.Program
+
+		BeforeEach(func() {
+			elfPath := filepath.Join(tempDir, "symbols.elf")
+			createSymbolsARM64ELF(elfPath, 0x400000)
+
+			var err error
+			prog, err = loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should read the code symbols sorted by address", func() {
+			Expect(prog.Symbols).To(Equal([]loader.Symbol{
+				{Name: "_start", Addr: 0x400078, Size: 8},
+				{Name: "helper", Addr: 0x400080},
+			}))
+		})
+
+		It("should find the nearest symbol below an address", func() {
+			sym, ok := prog.SymbolAt(0x40007C)
+			Expect(ok).To(BeTrue())
+			Expect(sym.Name).To(Equal("_start"))
+
+			_, ok = prog.SymbolAt(0x400000)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should name addresses as symbol+offset", func() {
+			Expect(prog.Symbolize(0x400078)).To(Equal("_start"))
+			Expect(prog.Symbolize(0x400084)).To(Equal("helper+0x4"))
+			Expect(prog.Symbolize(0x1000)).To(BeEmpty())
+		})
+
+		It("should have no symbols for a stripped binary", func() {
+			elfPath := filepath.Join(tempDir, "test.elf")
+			createMinimalARM64ELF(elfPath, 0x400000, 0x400000, []byte{0xc0, 0x03, 0x5f, 0xd6})
+
+			stripped, err := loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stripped.Symbols).To(BeEmpty())
+			Expect(stripped.Symbolize(0x400000)).To(BeEmpty())
+		})
+	})
+
 	Describe("ELFs with no loadable segments", func() {
 		It("should return empty segments list for ELF with no PT_LOAD", func() {
 			elfPath := filepath.Join(tempDir, "no-load.elf")
@@ -547,3 +662,98 @@ func createNoLoadableSegmentsELF(path string, entryPoint uint64) {
 	_, _ = file.Write(elfHeader)
 	_, _ = file.Write(progHeader)
 }
+
+// createSymbolsARM64ELF creates an ARM64 ELF with a .text section at
+// loadAddr+0x78 and a symbol table holding two code symbols, a mapping
+// symbol and an absolute symbol.
+func createSymbolsARM64ELF(path string, loadAddr uint64) {
+	code := []byte{
+		0x1f, 0x20, 0x03, 0xd5, // _start: nop
+		0xc0, 0x03, 0x5f, 0xd6, //         ret
+		0x1f, 0x20, 0x03, 0xd5, // helper: nop
+		0xc0, 0x03, 0x5f, 0xd6, //         ret
+	}
+	strtab := []byte("\x00_start\x00helper\x00$x\x00abs\x00")
+	shstrtab := []byte("\x00.text\x00.symtab\x00.strtab\x00.shstrtab\x00")
+
+	const textOff = 64 + 56
+	textAddr := loadAddr + textOff
+
+	symbol := func(name uint32, info byte, shndx uint16, value, size uint64) []byte {
+		sym := make([]byte, 24)
+		binary.LittleEndian.PutUint32(sym[0:4], name)
+		sym[4] = info
+		binary.LittleEndian.PutUint16(sym[6:8], shndx)
+		binary.LittleEndian.PutUint64(sym[8:16], value)
+		binary.LittleEndian.PutUint64(sym[16:24], size)
+		return sym
+	}
+	var symtab []byte
+	symtab = append(symtab, symbol(0, 0, 0, 0, 0)...)
+	symtab = append(symtab, symbol(15, 0x00, 1, textAddr, 0)...)    // $x (local notype)
+	symtab = append(symtab, symbol(1, 0x12, 1, textAddr, 8)...)     // _start (global func)
+	symtab = append(symtab, symbol(8, 0x10, 1, textAddr+8, 0)...)   // helper (global notype)
+	symtab = append(symtab, symbol(18, 0x10, 0xfff1, 0x1234, 0)...) // abs (global notype, SHN_ABS)
+
+	symtabOff := uint64(textOff + len(code))
+	strtabOff := symtabOff + uint64(len(symtab))
+	shstrtabOff := strtabOff + uint64(len(strtab))
+	shOff := shstrtabOff + uint64(len(shstrtab))
+
+	elfHeader := make([]byte, 64)
+	copy(elfHeader[0:4], []byte{0x7f, 'E', 'L', 'F'})
+	elfHeader[4] = 2                                          // 64-bit
+	elfHeader[5] = 1                                          // little endian
+	elfHeader[6] = 1                                          // version
+	binary.LittleEndian.PutUint16(elfHeader[16:18], 2)        // executable
+	binary.LittleEndian.PutUint16(elfHeader[18:20], 183)      // AArch64
+	binary.LittleEndian.PutUint32(elfHeader[20:24], 1)        // version
+	binary.LittleEndian.PutUint64(elfHeader[24:32], textAddr) // entry
+	binary.LittleEndian.PutUint64(elfHeader[32:40], 64)       // phoff
+	binary.LittleEndian.PutUint64(elfHeader[40:48], shOff)    // shoff
+	binary.LittleEndian.PutUint16(elfHeader[52:54], 64)       // ehsize
+	binary.LittleEndian.PutUint16(elfHeader[54:56], 56)       // phentsize
+	binary.LittleEndian.PutUint16(elfHeader[56:58], 1)        // phnum
+	binary.LittleEndian.PutUint16(elfHeader[58:60], 64)       // shentsize
+	binary.LittleEndian.PutUint16(elfHeader[60:62], 5)        // shnum
+	binary.LittleEndian.PutUint16(elfHeader[62:64], 4)        // shstrndx
+
+	progHeader := make([]byte, 56)
+	binary.LittleEndian.PutUint32(progHeader[0:4], 1)           // PT_LOAD
+	binary.LittleEndian.PutUint32(progHeader[4:8], 0x5)         // PF_R | PF_X
+	binary.LittleEndian.PutUint64(progHeader[16:24], loadAddr)  // vaddr
+	binary.LittleEndian.PutUint64(progHeader[24:32], loadAddr)  // paddr
+	binary.LittleEndian.PutUint64(progHeader[32:40], symtabOff) // filesz
+	binary.LittleEndian.PutUint64(progHeader[40:48], symtabOff) // memsz
+	binary.LittleEndian.PutUint64(progHeader[48:56], 0x1000)    // align
+
+	section := func(name, typ uint32, flags, addr, off, size uint64, link, info uint32, entsize uint64) []byte {
+		sh := make([]byte, 64)
+		binary.LittleEndian.PutUint32(sh[0:4], name)
+		binary.LittleEndian.PutUint32(sh[4:8], typ)
+		binary.LittleEndian.PutUint64(sh[8:16], flags)
+		binary.LittleEndian.PutUint64(sh[16:24], addr)
+		binary.LittleEndian.PutUint64(sh[24:32], off)
+		binary.LittleEndian.PutUint64(sh[32:40], size)
+		binary.LittleEndian.PutUint32(sh[40:44], link)
+		binary.LittleEndian.PutUint32(sh[44:48], info)
+		binary.LittleEndian.PutUint64(sh[48:56], 4)
+		binary.LittleEndian.PutUint64(sh[56:64], entsize)
+		return sh
+	}
+
+	file, _ := os.Create(path)
+	defer func() { _ = file.Close() }()
+
+	_, _ = file.Write(elfHeader)
+	_, _ = file.Write(progHeader)
+	_, _ = file.Write(code)
+	_, _ = file.Write(symtab)
+	_, _ = file.Write(strtab)
+	_, _ = file.Write(shstrtab)
+	_, _ = file.Write(make([]byte, 64))                                                  // null section
+	_, _ = file.Write(section(1, 1, 0x6, textAddr, textOff, uint64(len(code)), 0, 0, 0)) // .text: PROGBITS, ALLOC|EXECINSTR
+	_, _ = file.Write(section(7, 2, 0, 0, symtabOff, uint64(len(symtab)), 3, 2, 24))     // .symtab: SYMTAB
+	_, _ = file.Write(section(15, 3, 0, 0, strtabOff, uint64(len(strtab)), 0, 0, 0))     // .strtab: STRTAB
+	_, _ = file.Write(section(23, 3, 0, 0, shstrtabOff, uint64(len(shstrtab)), 0, 0, 0)) // .shstrtab: STRTAB
+}
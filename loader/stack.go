@@ -0,0 +1,148 @@
+package loader
+
+import "encoding/binary"
+
+// Auxiliary vector entry types (see <elf.h> and the Linux ABI).
+const (
+	AuxNull     = 0  // AT_NULL: end of vector
+	AuxPHDR     = 3  // AT_PHDR: program headers in memory
+	AuxPHENT    = 4  // AT_PHENT: size of a program header entry
+	AuxPHNUM    = 5  // AT_PHNUM: number of program headers
+	AuxPAGESZ   = 6  // AT_PAGESZ: system page size
+	AuxBASE     = 7  // AT_BASE: interpreter base address
+	AuxFLAGS    = 8  // AT_FLAGS: flags
+	AuxENTRY    = 9  // AT_ENTRY: program entry point
+	AuxUID      = 11 // AT_UID: real user ID
+	AuxEUID     = 12 // AT_EUID: effective user ID
+	AuxGID      = 13 // AT_GID: real group ID
+	AuxEGID     = 14 // AT_EGID: effective group ID
+	AuxPLATFORM = 15 // AT_PLATFORM: platform string
+	AuxHWCAP    = 16 // AT_HWCAP: hardware capabilities
+	AuxCLKTCK   = 17 // AT_CLKTCK: clock ticks per second
+	AuxSECURE   = 23 // AT_SECURE: secure mode
+	AuxRANDOM   = 25 // AT_RANDOM: address of 16 random bytes
+	AuxHWCAP2   = 26 // AT_HWCAP2: more hardware capabilities
+	AuxEXECFN   = 31 // AT_EXECFN: file name of the program
+)
+
+// AT_HWCAP bits for AArch64 Linux.
+const (
+	HWCapFP      = 1 << 0
+	HWCapASIMD   = 1 << 1
+	HWCapAES     = 1 << 3
+	HWCapPMULL   = 1 << 4
+	HWCapSHA1    = 1 << 5
+	HWCapSHA2    = 1 << 6
+	HWCapCRC32   = 1 << 7
+	HWCapATOMICS = 1 << 8
+	HWCapFPHP    = 1 << 9
+	HWCapASIMDHP = 1 << 10
+	HWCapLRCPC   = 1 << 15
+	HWCapPACA    = 1 << 30
+	HWCapPACG    = 1 << 31
+)
+
+// DefaultHWCap is the AT_HWCAP value reported to programs: the Apple M2
+// features the emulator implements. Libraries select code paths by these
+// bits, so only implemented features may be advertised. Pointer
+// authentication is left out, since it is only implemented with keys in one
+// of the execution modes; see Program.HWCap.
+const DefaultHWCap = HWCapFP | HWCapASIMD | HWCapAES | HWCapPMULL | HWCapSHA1 |
+	HWCapSHA2 | HWCapCRC32 | HWCapATOMICS | HWCapFPHP | HWCapLRCPC
+
+// PageSize is the page size reported in AT_PAGESZ. It matches the page
+// granularity of the emulator's mmap; glibc checks mmapped chunks against it.
+const PageSize = 4096
+
+// Platform is the AT_PLATFORM string.
+const Platform = "aarch64"
+
+// atRandom is the AT_RANDOM data. It is fixed so that simulations are
+// deterministic; glibc seeds the stack protector and pointer guard from it.
+var atRandom = [16]byte{
+	0x4d, 0x32, 0x53, 0x69, 0x6d, 0x2d, 0x72, 0x61,
+	0x6e, 0x64, 0x6f, 0x6d, 0x2d, 0x61, 0x74, 0x00,
+}
+
+// SetupStack writes the initial Linux process stack below InitialSP and
+// returns the stack pointer to start the program with.
+//
+// The layout follows the AArch64 Linux ABI. At the returned (16-byte
+// aligned) stack pointer are argc, the argv pointers, a NULL, the envp
+// pointers, a NULL and the auxiliary vector. The strings they point to, the
+// AT_RANDOM bytes and the platform string sit above them. args[0]
+// conventionally names the program and is also reported as AT_EXECFN.
+func (p *Program) SetupStack(mem Memory, args, env []string) uint64 {
+	top := p.InitialSP - 8 // NULL end marker
+
+	writeString := func(s string) uint64 {
+		top -= uint64(len(s)) + 1
+		mem.WriteBytes(top, append([]byte(s), 0))
+		return top
+	}
+
+	execFn := uint64(0)
+	envPtrs := make([]uint64, len(env))
+	for i := len(env) - 1; i >= 0; i-- {
+		envPtrs[i] = writeString(env[i])
+	}
+	argPtrs := make([]uint64, len(args))
+	for i := len(args) - 1; i >= 0; i-- {
+		argPtrs[i] = writeString(args[i])
+	}
+	if len(args) > 0 {
+		execFn = argPtrs[0]
+	}
+	platform := writeString(Platform)
+
+	top -= uint64(len(atRandom))
+	random := top
+	mem.WriteBytes(random, atRandom[:])
+
+	auxv := [][2]uint64{
+		{AuxPHDR, p.PHdrAddr},
+		{AuxPHENT, p.PHdrEntSize},
+		{AuxPHNUM, p.PHdrNum},
+		{AuxPAGESZ, PageSize},
+		{AuxBASE, 0},
+		{AuxFLAGS, 0},
+		{AuxENTRY, p.EntryPoint},
+		{AuxUID, 0},
+		{AuxEUID, 0},
+		{AuxGID, 0},
+		{AuxEGID, 0},
+		{AuxPLATFORM, platform},
+		{AuxHWCAP, p.HWCap},
+		{AuxHWCAP2, 0},
+		{AuxCLKTCK, 100},
+		{AuxSECURE, 0},
+		{AuxRANDOM, random},
+		{AuxEXECFN, execFn},
+		{AuxNull, 0},
+	}
+
+	words := 1 + len(args) + 1 + len(env) + 1 + 2*len(auxv)
+	sp := (top - uint64(words)*8) &^ 15
+
+	block := make([]byte, 0, words*8)
+	push := func(value uint64) {
+		block = binary.LittleEndian.AppendUint64(block, value)
+	}
+
+	push(uint64(len(args)))
+	for _, ptr := range argPtrs {
+		push(ptr)
+	}
+	push(0)
+	for _, ptr := range envPtrs {
+		push(ptr)
+	}
+	push(0)
+	for _, entry := range auxv {
+		push(entry[0])
+		push(entry[1])
+	}
+	mem.WriteBytes(sp, block)
+
+	return sp
+}
@@ -0,0 +1,197 @@
+package loader_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/loader"
+)
+
+var _ = Describe("Process Stack", func() {
+	var (
+		tempDir string
+		memory  *emu.Memory
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = os.MkdirTemp("", "stack-test")
+		Expect(err).NotTo(HaveOccurred())
+		memory = emu.NewMemory()
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(tempDir)
+	})
+
+	readString := func(addr uint64) string {
+		var s []byte
+		for b := memory.Read8(addr); b != 0; b = memory.Read8(addr) {
+			s = append(s, b)
+			addr++
+		}
+		return string(s)
+	}
+
+	// readAuxv returns the auxiliary vector that starts at addr.
+	readAuxv := func(addr uint64) map[uint64]uint64 {
+		auxv := map[uint64]uint64{}
+		for {
+			key, value := memory.Read64(addr), memory.Read64(addr+8)
+			if key == loader.AuxNull {
+				return auxv
+			}
+			auxv[key] = value
+			addr += 16
+		}
+	}
+
+	Describe("Program headers", func() {
+		It("should locate the program headers in a loaded segment", func() {
+			elfPath := filepath.Join(tempDir, "phdr.elf")
+			createPHdrMappedARM64ELF(elfPath, 0x400000)
+
+			prog, err := loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prog.PHdrAddr).To(Equal(uint64(0x400040)))
+			Expect(prog.PHdrEntSize).To(Equal(uint64(56)))
+			Expect(prog.PHdrNum).To(Equal(uint64(1)))
+		})
+
+		It("should report no address when no segment maps them", func() {
+			elfPath := filepath.Join(tempDir, "test.elf")
+			createMinimalARM64ELF(elfPath, 0x400000, 0x400000, []byte{0xc0, 0x03, 0x5f, 0xd6})
+
+			prog, err := loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prog.PHdrAddr).To(BeZero())
+			Expect(prog.PHdrNum).To(Equal(uint64(1)))
+		})
+	})
+
+	Describe("SetupStack", func() {
+		var prog *loader.Program
+
+		BeforeEach(func() {
+			elfPath := filepath.Join(tempDir, "phdr.elf")
+			createPHdrMappedARM64ELF(elfPath, 0x400000)
+
+			var err error
+			prog, err = loader.Load(elfPath)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should report the program's hardware capabilities", func() {
+			prog.HWCap |= loader.HWCapPACA | loader.HWCapPACG
+			sp := prog.SetupStack(memory, []string{"prog"}, nil)
+
+			auxv := readAuxv(sp + 32)
+			Expect(auxv[loader.AuxHWCAP]).To(Equal(uint64(loader.DefaultHWCap |
+				loader.HWCapPACA | loader.HWCapPACG)))
+		})
+
+		It("should place argc, argv and envp at the stack pointer", func() {
+			sp := prog.SetupStack(memory, []string{"prog", "-n", "42"}, []string{"HOME=/", "LANG=C"})
+
+			Expect(sp % 16).To(BeZero())
+			Expect(sp).To(BeNumerically("<", prog.InitialSP))
+
+			Expect(memory.Read64(sp)).To(Equal(uint64(3)))
+			Expect(readString(memory.Read64(sp + 8))).To(Equal("prog"))
+			Expect(readString(memory.Read64(sp + 16))).To(Equal("-n"))
+			Expect(readString(memory.Read64(sp + 24))).To(Equal("42"))
+			Expect(memory.Read64(sp + 32)).To(BeZero())
+			Expect(readString(memory.Read64(sp + 40))).To(Equal("HOME=/"))
+			Expect(readString(memory.Read64(sp + 48))).To(Equal("LANG=C"))
+			Expect(memory.Read64(sp + 56)).To(BeZero())
+		})
+
+		It("should build the auxiliary vector", func() {
+			sp := prog.SetupStack(memory, []string{"prog"}, nil)
+
+			// argc, argv[0], NULL, NULL
+			auxv := readAuxv(sp + 32)
+
+			Expect(auxv[loader.AuxPHDR]).To(Equal(uint64(0x400040)))
+			Expect(auxv[loader.AuxPHENT]).To(Equal(uint64(56)))
+			Expect(auxv[loader.AuxPHNUM]).To(Equal(uint64(1)))
+			Expect(auxv[loader.AuxPAGESZ]).To(Equal(uint64(loader.PageSize)))
+			Expect(auxv[loader.AuxENTRY]).To(Equal(prog.EntryPoint))
+			Expect(auxv[loader.AuxHWCAP]).To(Equal(uint64(loader.DefaultHWCap)))
+			Expect(auxv[loader.AuxHWCAP] & loader.HWCapFP).NotTo(BeZero())
+			Expect(auxv[loader.AuxHWCAP] & loader.HWCapAES).NotTo(BeZero())
+			Expect(auxv[loader.AuxHWCAP] & loader.HWCapSHA2).NotTo(BeZero())
+			Expect(auxv[loader.AuxHWCAP] & loader.HWCapCRC32).NotTo(BeZero())
+			Expect(readString(auxv[loader.AuxPLATFORM])).To(Equal("aarch64"))
+			Expect(readString(auxv[loader.AuxEXECFN])).To(Equal("prog"))
+			Expect(auxv[loader.AuxHWCAP] & loader.HWCapPACA).To(BeZero())
+
+			random := auxv[loader.AuxRANDOM]
+			Expect(random).NotTo(BeZero())
+			Expect(random + 16).To(BeNumerically("<=", prog.InitialSP))
+		})
+
+		It("should handle an empty argument list", func() {
+			sp := prog.SetupStack(memory, nil, nil)
+
+			Expect(memory.Read64(sp)).To(BeZero())
+			Expect(memory.Read64(sp + 8)).To(BeZero())
+			Expect(memory.Read64(sp + 16)).To(BeZero())
+			Expect(readAuxv(sp + 24)).To(HaveKeyWithValue(uint64(loader.AuxEXECFN), uint64(0)))
+		})
+
+		It("should be deterministic", func() {
+			other := emu.NewMemory()
+
+			sp := prog.SetupStack(memory, []string{"prog", "x"}, []string{"A=B"})
+			Expect(prog.SetupStack(other, []string{"prog", "x"}, []string{"A=B"})).To(Equal(sp))
+
+			for addr := sp; addr < prog.InitialSP; addr += 8 {
+				Expect(other.Read64(addr)).To(Equal(memory.Read64(addr)))
+			}
+		})
+	})
+})
+
+// createPHdrMappedARM64ELF creates an ARM64 ELF whose single PT_LOAD segment
+// maps the whole file, including the program headers, at loadAddr.
+func createPHdrMappedARM64ELF(path string, loadAddr uint64) {
+	code := []byte{0xc0, 0x03, 0x5f, 0xd6} // ret
+	fileSize := uint64(64 + 56 + len(code))
+
+	elfHeader := make([]byte, 64)
+	copy(elfHeader[0:4], []byte{0x7f, 'E', 'L', 'F'})
+	elfHeader[4] = 2                                              // 64-bit
+	elfHeader[5] = 1                                              // little endian
+	elfHeader[6] = 1                                              // version
+	binary.LittleEndian.PutUint16(elfHeader[16:18], 2)            // executable
+	binary.LittleEndian.PutUint16(elfHeader[18:20], 183)          // AArch64
+	binary.LittleEndian.PutUint32(elfHeader[20:24], 1)            // version
+	binary.LittleEndian.PutUint64(elfHeader[24:32], loadAddr+120) // entry
+	binary.LittleEndian.PutUint64(elfHeader[32:40], 64)           // phoff
+	binary.LittleEndian.PutUint16(elfHeader[52:54], 64)           // ehsize
+	binary.LittleEndian.PutUint16(elfHeader[54:56], 56)           // phentsize
+	binary.LittleEndian.PutUint16(elfHeader[56:58], 1)            // phnum
+
+	progHeader := make([]byte, 56)
+	binary.LittleEndian.PutUint32(progHeader[0:4], 1)          // PT_LOAD
+	binary.LittleEndian.PutUint32(progHeader[4:8], 0x5)        // PF_R | PF_X
+	binary.LittleEndian.PutUint64(progHeader[8:16], 0)         // offset
+	binary.LittleEndian.PutUint64(progHeader[16:24], loadAddr) // vaddr
+	binary.LittleEndian.PutUint64(progHeader[24:32], loadAddr) // paddr
+	binary.LittleEndian.PutUint64(progHeader[32:40], fileSize) // filesz
+	binary.LittleEndian.PutUint64(progHeader[40:48], fileSize) // memsz
+	binary.LittleEndian.PutUint64(progHeader[48:56], 0x1000)   // align
+
+	file, _ := os.Create(path)
+	defer func() { _ = file.Close() }()
+
+	_, _ = file.Write(elfHeader)
+	_, _ = file.Write(progHeader)
+	_, _ = file.Write(code)
+}
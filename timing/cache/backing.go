@@ -18,17 +18,13 @@ func NewMemoryBacking(memory *emu.Memory) *MemoryBacking {
 // Read fetches data from the backing memory.
 func (m *MemoryBacking) Read(addr uint64, size int) []byte {
 	data := make([]byte, size)
-	for i := 0; i < size; i++ {
-		data[i] = m.memory.Read8(addr + uint64(i))
-	}
+	m.memory.ReadBytes(addr, data)
 	return data
 }
 
 // Write stores data to the backing memory.
 func (m *MemoryBacking) Write(addr uint64, data []byte) {
-	for i, b := range data {
-		m.memory.Write8(addr+uint64(i), b)
-	}
+	m.memory.WriteBytes(addr, data)
 }
 
 // CacheBacking wraps a Cache as a BackingStore.
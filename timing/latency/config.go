@@ -46,11 +46,20 @@ type TimingConfig struct {
 	SyscallLatency uint64 `json:"syscall_latency"`
 
 	// SIMDIntLatency is the execution latency for SIMD integer operations
-	// (VADD, VSUB, VMUL). Default: 2 cycles.
+	// (VADD, VSUB, compares, shifts, bitwise and element moves).
+	// Default: 2 cycles.
 	SIMDIntLatency uint64 `json:"simd_int_latency"`
 
-	// SIMDFloatLatency is the execution latency for SIMD floating-point operations
-	// (VFADD, VFSUB, VFMUL). Default: 3 cycles.
+	// SIMDMulLatency is the execution latency for SIMD integer multiplies,
+	// including multiply-accumulate and long multiplies. Default: 4 cycles.
+	SIMDMulLatency uint64 `json:"simd_mul_latency"`
+
+	// SIMDReduceLatency is the execution latency for SIMD reductions across
+	// lanes (ADDV, SMAXV, UADDLV, ...). Default: 3 cycles.
+	SIMDReduceLatency uint64 `json:"simd_reduce_latency"`
+
+	// SIMDFloatLatency is the execution latency for SIMD floating-point
+	// arithmetic (VFADD, VFSUB, VFMUL, VFMAX, VFADDP, ...). Default: 3 cycles.
 	SIMDFloatLatency uint64 `json:"simd_float_latency"`
 
 	// SIMDLoadLatency is the latency for SIMD load operations (128-bit).
@@ -61,6 +70,85 @@ type TimingConfig struct {
 	// Default: 1 cycle (fire-and-forget to LSQ).
 	SIMDStoreLatency uint64 `json:"simd_store_latency"`
 
+	// FPAddLatency is the execution latency for scalar FP add and subtract
+	// (FADD, FSUB). Default: 3 cycles.
+	FPAddLatency uint64 `json:"fp_add_latency"`
+
+	// FPMulLatency is the execution latency for scalar FP multiply (FMUL).
+	// Default: 4 cycles.
+	FPMulLatency uint64 `json:"fp_mul_latency"`
+
+	// FPFMALatency is the execution latency for scalar fused multiply-add
+	// (FMADD, FMSUB, FNMADD, FNMSUB). Default: 4 cycles.
+	FPFMALatency uint64 `json:"fp_fma_latency"`
+
+	// FPDivLatency is the execution latency for scalar FP divide (FDIV).
+	// Default: 10 cycles.
+	FPDivLatency uint64 `json:"fp_div_latency"`
+
+	// FPSqrtLatency is the execution latency for scalar FP square root (FSQRT).
+	// Default: 13 cycles.
+	FPSqrtLatency uint64 `json:"fp_sqrt_latency"`
+
+	// FPConvertLatency is the execution latency for FP precision and integer
+	// conversions (FCVT, SCVTF, UCVTF, FCVTZS, ...). Default: 3 cycles.
+	FPConvertLatency uint64 `json:"fp_convert_latency"`
+
+	// FPSimpleLatency is the execution latency for simple scalar FP operations
+	// (FABS, FNEG, FMOV, FCMP, FCCMP, FCSEL). Default: 2 cycles.
+	FPSimpleLatency uint64 `json:"fp_simple_latency"`
+
+	// ExclusiveLatency is the execution latency for load/store exclusive
+	// instructions (LDXR, STXR, LDXP, STXP), including the exclusive monitor
+	// check. Default: 5 cycles.
+	ExclusiveLatency uint64 `json:"exclusive_latency"`
+
+	// AtomicLatency is the execution latency for LSE atomic read-modify-write
+	// instructions (LDADD, SWP, CAS, ...). Default: 8 cycles.
+	AtomicLatency uint64 `json:"atomic_latency"`
+
+	// OrderingPenalty is the additional latency for each acquire or release
+	// ordering constraint an instruction carries (LDAXR, STLXR, CASAL, ...).
+	// Default: 2 cycles.
+	OrderingPenalty uint64 `json:"ordering_penalty"`
+
+	// BarrierLatency is the execution latency for DMB, DSB and ISB when no
+	// stores are outstanding. The pipeline adds the time to drain the store
+	// buffer (DMB, DSB) and to refetch younger instructions (ISB).
+	// Default: 2 cycles.
+	BarrierLatency uint64 `json:"barrier_latency"`
+
+	// CRCLatency is the execution latency for the CRC32 and CRC32C
+	// instructions. Default: 3 cycles.
+	CRCLatency uint64 `json:"crc_latency"`
+
+	// AESLatency is the execution latency for the AES round and mix columns
+	// instructions (AESE, AESD, AESMC, AESIMC). Default: 3 cycles.
+	AESLatency uint64 `json:"aes_latency"`
+
+	// SHAHashLatency is the execution latency for the SHA hash update
+	// instructions (SHA1C, SHA256H, SHA512H, ...). Default: 4 cycles.
+	SHAHashLatency uint64 `json:"sha_hash_latency"`
+
+	// SHAScheduleLatency is the execution latency for the SHA message
+	// schedule instructions and SHA1H (SHA1SU0, SHA256SU1, SHA512SU0, ...).
+	// Default: 2 cycles.
+	SHAScheduleLatency uint64 `json:"sha_schedule_latency"`
+
+	// SHA3Latency is the execution latency for the SHA3 instructions (EOR3,
+	// BCAX, RAX1, XAR). Default: 2 cycles.
+	SHA3Latency uint64 `json:"sha3_latency"`
+
+	// PMULLLatency is the execution latency for the polynomial long
+	// multiplies (PMULL, PMULL2). Default: 3 cycles.
+	PMULLLatency uint64 `json:"pmull_latency"`
+
+	// PACLatency is the execution latency for computing a pointer
+	// authentication code (PAC, AUT, PACGA) when pointer authentication is
+	// enabled. Authenticated branches add it to BranchLatency. Default: 3
+	// cycles.
+	PACLatency uint64 `json:"pac_latency"`
+
 	// Note: Memory hierarchy latencies (L1/L2/L3/DRAM) are configured in
 	// cache.Config.HitLatency and cache.Config.MissLatency, not here.
 	// This table provides instruction execution latencies only.
@@ -79,9 +167,29 @@ func DefaultTimingConfig() *TimingConfig {
 		DivideLatencyMax:        15,
 		SyscallLatency:          1,
 		SIMDIntLatency:          2,
+		SIMDMulLatency:          4,
+		SIMDReduceLatency:       3,
 		SIMDFloatLatency:        3,
 		SIMDLoadLatency:         5,
 		SIMDStoreLatency:        1,
+		FPAddLatency:            3,
+		FPMulLatency:            4,
+		FPFMALatency:            4,
+		FPDivLatency:            10,
+		FPSqrtLatency:           13,
+		FPConvertLatency:        3,
+		FPSimpleLatency:         2,
+		ExclusiveLatency:        5,
+		AtomicLatency:           8,
+		OrderingPenalty:         2,
+		BarrierLatency:          2,
+		CRCLatency:              3,
+		AESLatency:              3,
+		SHAHashLatency:          4,
+		SHAScheduleLatency:      2,
+		SHA3Latency:             2,
+		PMULLLatency:            3,
+		PACLatency:              3,
 	}
 }
 
@@ -150,8 +258,28 @@ func (c *TimingConfig) Clone() *TimingConfig {
 		DivideLatencyMax:        c.DivideLatencyMax,
 		SyscallLatency:          c.SyscallLatency,
 		SIMDIntLatency:          c.SIMDIntLatency,
+		SIMDMulLatency:          c.SIMDMulLatency,
+		SIMDReduceLatency:       c.SIMDReduceLatency,
 		SIMDFloatLatency:        c.SIMDFloatLatency,
 		SIMDLoadLatency:         c.SIMDLoadLatency,
 		SIMDStoreLatency:        c.SIMDStoreLatency,
+		FPAddLatency:            c.FPAddLatency,
+		FPMulLatency:            c.FPMulLatency,
+		FPFMALatency:            c.FPFMALatency,
+		FPDivLatency:            c.FPDivLatency,
+		FPSqrtLatency:           c.FPSqrtLatency,
+		FPConvertLatency:        c.FPConvertLatency,
+		FPSimpleLatency:         c.FPSimpleLatency,
+		ExclusiveLatency:        c.ExclusiveLatency,
+		AtomicLatency:           c.AtomicLatency,
+		OrderingPenalty:         c.OrderingPenalty,
+		BarrierLatency:          c.BarrierLatency,
+		CRCLatency:              c.CRCLatency,
+		AESLatency:              c.AESLatency,
+		SHAHashLatency:          c.SHAHashLatency,
+		SHAScheduleLatency:      c.SHAScheduleLatency,
+		SHA3Latency:             c.SHA3Latency,
+		PMULLLatency:            c.PMULLLatency,
+		PACLatency:              c.PACLatency,
 	}
 }
@@ -11,6 +11,10 @@ import (
 // Table provides instruction latency lookups.
 type Table struct {
 	config *TimingConfig
+
+	// pointerAuth is set when the core computes pointer authentication
+	// codes rather than executing PAC and AUT as NOPs.
+	pointerAuth bool
 }
 
 // NewTable creates a new latency table with default M2 timing values.
@@ -27,6 +31,14 @@ func NewTableWithConfig(config *TimingConfig) *Table {
 	}
 }
 
+// SetPointerAuth sets whether pointer authentication instructions compute
+// authentication codes. When they do not, PAC, AUT and PACGA take the
+// latency of a simple ALU operation and authenticated branches that of a
+// plain branch.
+func (t *Table) SetPointerAuth(enabled bool) {
+	t.pointerAuth = enabled
+}
+
 // GetLatency returns the execution latency in cycles for the given instruction.
 // For variable-latency operations, returns the typical/expected latency.
 func (t *Table) GetLatency(inst *insts.Instruction) uint64 {
@@ -40,41 +52,200 @@ func (t *Table) GetLatency(inst *insts.Instruction) uint64 {
 		return t.config.ALULatency
 
 	case insts.OpB, insts.OpBL, insts.OpBCond, insts.OpBR, insts.OpBLR, insts.OpRET:
+		if t.pointerAuth && inst.PACKey != insts.PACKeyNone {
+			return t.config.BranchLatency + t.config.PACLatency
+		}
 		return t.config.BranchLatency
 
 	case insts.OpLDR, insts.OpLDP, insts.OpLDRB, insts.OpLDRSB,
-		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDRLit:
+		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDPSW, insts.OpLDRLit:
 		return t.config.LoadLatency
 
 	case insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH:
 		return t.config.StoreLatency
 
-	case insts.OpMADD, insts.OpMSUB:
+	// Bit manipulation, add/subtract with carry and extended-register
+	// add/subtract (FormatAddSubExt decodes to OpADD/OpSUB) are simple ALU
+	// operations.
+	case insts.OpRBIT, insts.OpREV16, insts.OpREV32, insts.OpREV,
+		insts.OpCLZ, insts.OpCLS, insts.OpADC, insts.OpSBC:
+		return t.config.ALULatency
+
+	case insts.OpMADD, insts.OpMSUB,
+		insts.OpSMADDL, insts.OpSMSUBL, insts.OpUMADDL, insts.OpUMSUBL,
+		insts.OpSMULH, insts.OpUMULH:
 		return t.config.MultiplyLatency
 
+	case insts.OpCRC32, insts.OpCRC32C:
+		return t.config.CRCLatency
+
 	case insts.OpSVC:
 		return t.config.SyscallLatency
 
+	// Pointer authentication. Stripping a PAC is a simple bit operation.
+	case insts.OpPAC, insts.OpAUT, insts.OpPACGA:
+		if t.pointerAuth {
+			return t.config.PACLatency
+		}
+		return t.config.ALULatency
+
+	case insts.OpXPAC:
+		return t.config.ALULatency
+
 	// SIMD integer operations
-	case insts.OpVADD, insts.OpVSUB, insts.OpVMUL, insts.OpVMOV:
+	case insts.OpVADD, insts.OpVSUB, insts.OpVMOV:
 		return t.config.SIMDIntLatency
 
-	// SIMD floating-point operations
-	case insts.OpVFADD, insts.OpVFSUB, insts.OpVFMUL:
+	case insts.OpVMUL, insts.OpVMLA, insts.OpVMLS, insts.OpVSQDMULH, insts.OpVSQRDMULH,
+		insts.OpVSMLAL, insts.OpVUMLAL, insts.OpVSMLSL, insts.OpVUMLSL,
+		insts.OpVSMULL, insts.OpVUMULL:
+		return t.config.SIMDMulLatency
+
+	case insts.OpVADDV, insts.OpVSADDLV, insts.OpVUADDLV,
+		insts.OpVSMAXV, insts.OpVUMAXV, insts.OpVSMINV, insts.OpVUMINV:
+		return t.config.SIMDReduceLatency
+
+	// SIMD floating-point operations. Multiply-add, divide, square root,
+	// conversions and simple operations take the latency of their scalar
+	// counterparts.
+	case insts.OpVFADD, insts.OpVFSUB, insts.OpVFMUL, insts.OpVFABD,
+		insts.OpVFMAX, insts.OpVFMIN, insts.OpVFMAXNM, insts.OpVFMINNM,
+		insts.OpVFADDP, insts.OpVFMAXP, insts.OpVFMINP, insts.OpVFMAXNMP, insts.OpVFMINNMP:
 		return t.config.SIMDFloatLatency
 
+	case insts.OpVFMLA, insts.OpVFMLS, insts.OpVFRECPS, insts.OpVFRSQRTS:
+		return t.config.FPFMALatency
+
+	case insts.OpVFDIV:
+		return t.config.FPDivLatency
+
+	case insts.OpVFSQRT:
+		return t.config.FPSqrtLatency
+
+	case insts.OpVFRINTN, insts.OpVFRINTA, insts.OpVFRINTP, insts.OpVFRINTM,
+		insts.OpVFRINTZ, insts.OpVFRINTX, insts.OpVFRINTI,
+		insts.OpVSCVTF, insts.OpVUCVTF,
+		insts.OpVFCVTNS, insts.OpVFCVTNU, insts.OpVFCVTPS, insts.OpVFCVTPU,
+		insts.OpVFCVTMS, insts.OpVFCVTMU, insts.OpVFCVTZS, insts.OpVFCVTZU,
+		insts.OpVFCVTAS, insts.OpVFCVTAU, insts.OpVFCVTL, insts.OpVFCVTN,
+		insts.OpVFRECPE, insts.OpVFRSQRTE:
+		return t.config.FPConvertLatency
+
+	case insts.OpVFCMEQ, insts.OpVFCMGE, insts.OpVFCMGT, insts.OpVFACGE, insts.OpVFACGT,
+		insts.OpVFCMEQZ, insts.OpVFCMGEZ, insts.OpVFCMGTZ, insts.OpVFCMLEZ, insts.OpVFCMLTZ,
+		insts.OpVFABS, insts.OpVFNEG, insts.OpVFMOVImm:
+		return t.config.FPSimpleLatency
+
+	case insts.OpVFMAXV, insts.OpVFMINV, insts.OpVFMAXNMV, insts.OpVFMINNMV:
+		return t.config.SIMDReduceLatency
+
+	// Cryptographic extension
+	case insts.OpAESE, insts.OpAESD, insts.OpAESMC, insts.OpAESIMC:
+		return t.config.AESLatency
+
+	case insts.OpSHA1C, insts.OpSHA1P, insts.OpSHA1M,
+		insts.OpSHA256H, insts.OpSHA256H2, insts.OpSHA512H, insts.OpSHA512H2:
+		return t.config.SHAHashLatency
+
+	case insts.OpSHA1H, insts.OpSHA1SU0, insts.OpSHA1SU1,
+		insts.OpSHA256SU0, insts.OpSHA256SU1, insts.OpSHA512SU0, insts.OpSHA512SU1:
+		return t.config.SHAScheduleLatency
+
+	case insts.OpEOR3, insts.OpBCAX, insts.OpRAX1, insts.OpXAR:
+		return t.config.SHA3Latency
+
+	case insts.OpVPMULL:
+		return t.config.PMULLLatency
+
 	// SIMD load/store
-	case insts.OpLDRQ:
+	case insts.OpLDRQ, insts.OpVLDN, insts.OpVLDNLane, insts.OpVLDNR:
 		return t.config.SIMDLoadLatency
 
-	case insts.OpSTRQ:
+	case insts.OpSTRQ, insts.OpVSTN, insts.OpVSTNLane:
 		return t.config.SIMDStoreLatency
 
+	// Scalar floating-point operations
+	case insts.OpFADD, insts.OpFSUB:
+		return t.config.FPAddLatency
+
+	case insts.OpFMUL, insts.OpFNMUL:
+		return t.config.FPMulLatency
+
+	case insts.OpFMADD, insts.OpFMSUB, insts.OpFNMADD, insts.OpFNMSUB:
+		return t.config.FPFMALatency
+
+	case insts.OpFDIV:
+		return t.config.FPDivLatency
+
+	case insts.OpFSQRT:
+		return t.config.FPSqrtLatency
+
+	case insts.OpFMAX, insts.OpFMIN, insts.OpFMAXNM, insts.OpFMINNM:
+		return t.config.FPAddLatency
+
+	case insts.OpFRINTN, insts.OpFRINTA, insts.OpFRINTP, insts.OpFRINTM,
+		insts.OpFRINTZ, insts.OpFRINTX, insts.OpFRINTI,
+		insts.OpFCVT, insts.OpSCVTF, insts.OpUCVTF,
+		insts.OpFCVTNS, insts.OpFCVTNU, insts.OpFCVTPS, insts.OpFCVTPU,
+		insts.OpFCVTMS, insts.OpFCVTMU, insts.OpFCVTZS, insts.OpFCVTZU,
+		insts.OpFCVTAS, insts.OpFCVTAU:
+		return t.config.FPConvertLatency
+
+	case insts.OpFABS, insts.OpFNEG, insts.OpFCMP, insts.OpFCMPE,
+		insts.OpFCCMP, insts.OpFCCMPE, insts.OpFCSEL,
+		insts.OpFMOV, insts.OpFMOVImm, insts.OpFMOVToGP, insts.OpFMOVFromGP:
+		return t.config.FPSimpleLatency
+
+	// Exclusive and atomic memory operations
+	case insts.OpLDXR, insts.OpSTXR, insts.OpLDXP, insts.OpSTXP:
+		return t.config.ExclusiveLatency + t.orderingPenalty(inst)
+
+	case insts.OpCAS, insts.OpCASP, insts.OpSWP,
+		insts.OpLDADD, insts.OpLDCLR, insts.OpLDEOR, insts.OpLDSET,
+		insts.OpLDSMAX, insts.OpLDSMIN, insts.OpLDUMAX, insts.OpLDUMIN:
+		return t.config.AtomicLatency + t.orderingPenalty(inst)
+
+	// Load-acquire and store-release
+	case insts.OpLDAR:
+		return t.config.LoadLatency + t.orderingPenalty(inst)
+
+	case insts.OpSTLR:
+		return t.config.StoreLatency + t.orderingPenalty(inst)
+
+	case insts.OpLDAPR:
+		// RCpc: need not wait for earlier store-releases to complete.
+		return t.config.LoadLatency
+
+	// Barriers. Draining outstanding stores and refetching after an ISB
+	// are modeled by the pipeline on top of this.
+	case insts.OpDMB, insts.OpDSB, insts.OpISB:
+		return t.config.BarrierLatency
+
+	// Cache maintenance by address issues to the store pipeline.
+	case insts.OpDC, insts.OpIC:
+		return t.config.StoreLatency
+
 	default:
+		if isSIMDIntOp(inst.Op) {
+			return t.config.SIMDIntLatency
+		}
 		return 1
 	}
 }
 
+// orderingPenalty returns the extra latency for the acquire and release
+// semantics of the instruction.
+func (t *Table) orderingPenalty(inst *insts.Instruction) uint64 {
+	var penalty uint64
+	if inst.Acquire {
+		penalty += t.config.OrderingPenalty
+	}
+	if inst.Release {
+		penalty += t.config.OrderingPenalty
+	}
+	return penalty
+}
+
 // GetMinLatency returns the minimum execution latency for variable-latency operations.
 func (t *Table) GetMinLatency(inst *insts.Instruction) uint64 {
 	if inst == nil {
@@ -103,8 +274,29 @@ func (t *Table) IsMemoryOp(inst *insts.Instruction) bool {
 	}
 	switch inst.Op {
 	case insts.OpLDR, insts.OpLDP, insts.OpLDRB, insts.OpLDRSB,
-		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDRLit, insts.OpLDRQ,
-		insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH, insts.OpSTRQ:
+		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDPSW, insts.OpLDRLit, insts.OpLDRQ,
+		insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH, insts.OpSTRQ,
+		insts.OpVLDN, insts.OpVSTN, insts.OpVLDNLane, insts.OpVSTNLane, insts.OpVLDNR:
+		return true
+	default:
+		return t.IsAtomicOp(inst)
+	}
+}
+
+// IsAtomicOp returns true for exclusive, LSE atomic and load-acquire/
+// store-release memory operations. They are not plain loads or stores: their
+// execute latency covers the exclusive monitor or read-modify-write and any
+// ordering constraints.
+func (t *Table) IsAtomicOp(inst *insts.Instruction) bool {
+	if inst == nil {
+		return false
+	}
+	switch inst.Op {
+	case insts.OpLDXR, insts.OpSTXR, insts.OpLDXP, insts.OpSTXP,
+		insts.OpCAS, insts.OpCASP, insts.OpSWP,
+		insts.OpLDADD, insts.OpLDCLR, insts.OpLDEOR, insts.OpLDSET,
+		insts.OpLDSMAX, insts.OpLDSMIN, insts.OpLDUMAX, insts.OpLDUMIN,
+		insts.OpLDAR, insts.OpSTLR, insts.OpLDAPR:
 		return true
 	default:
 		return false
@@ -118,7 +310,8 @@ func (t *Table) IsLoadOp(inst *insts.Instruction) bool {
 	}
 	switch inst.Op {
 	case insts.OpLDR, insts.OpLDP, insts.OpLDRB, insts.OpLDRSB,
-		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDRLit, insts.OpLDRQ:
+		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDPSW, insts.OpLDRLit, insts.OpLDRQ,
+		insts.OpVLDN, insts.OpVLDNLane, insts.OpVLDNR:
 		return true
 	default:
 		return false
@@ -131,7 +324,8 @@ func (t *Table) IsStoreOp(inst *insts.Instruction) bool {
 		return false
 	}
 	switch inst.Op {
-	case insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH, insts.OpSTRQ:
+	case insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH, insts.OpSTRQ,
+		insts.OpVSTN, insts.OpVSTNLane:
 		return true
 	default:
 		return false
@@ -158,15 +352,97 @@ func (t *Table) IsSIMDOp(inst *insts.Instruction) bool {
 	}
 	switch inst.Op {
 	case insts.OpVADD, insts.OpVSUB, insts.OpVMUL, insts.OpVMOV,
-		insts.OpVFADD, insts.OpVFSUB, insts.OpVFMUL,
-		insts.OpLDRQ, insts.OpSTRQ:
+		insts.OpLDRQ, insts.OpSTRQ,
+		insts.OpVLDN, insts.OpVSTN, insts.OpVLDNLane, insts.OpVSTNLane, insts.OpVLDNR:
 		return true
-	default:
-		return false
 	}
+	return isSIMDIntOp(inst.Op) || isSIMDFloatOp(inst.Op) || isCryptoOp(inst.Op)
 }
 
 // Config returns the current timing configuration.
 func (t *Table) Config() *TimingConfig {
 	return t.config
 }
+
+// isSIMDIntOp reports whether op is one of the NEON integer compare, shift,
+// widening, saturating, bitwise, immediate, element move, reduction,
+// permute or table lookup operations.
+func isSIMDIntOp(op insts.Op) bool {
+	switch op {
+	case insts.OpVSQADD, insts.OpVUQADD, insts.OpVSQSUB, insts.OpVUQSUB,
+		insts.OpVCMEQ, insts.OpVCMGT, insts.OpVCMGE, insts.OpVCMHI, insts.OpVCMHS, insts.OpVCMTST,
+		insts.OpVSMAX, insts.OpVUMAX, insts.OpVSMIN, insts.OpVUMIN,
+		insts.OpVSABD, insts.OpVUABD, insts.OpVSABA, insts.OpVUABA,
+		insts.OpVMLA, insts.OpVMLS, insts.OpVSQDMULH, insts.OpVSQRDMULH,
+		insts.OpVADDP, insts.OpVSMAXP, insts.OpVUMAXP, insts.OpVSMINP, insts.OpVUMINP,
+		insts.OpVAND, insts.OpVBIC, insts.OpVORR, insts.OpVORN, insts.OpVEOR,
+		insts.OpVBSL, insts.OpVBIT, insts.OpVBIF, insts.OpVSSHL, insts.OpVUSHL:
+		return true
+	case insts.OpVCMEQZ, insts.OpVCMGTZ, insts.OpVCMGEZ, insts.OpVCMLEZ, insts.OpVCMLTZ,
+		insts.OpVABS, insts.OpVNEG, insts.OpVNOT, insts.OpVCNT,
+		insts.OpVREV16, insts.OpVREV32, insts.OpVREV64,
+		insts.OpVXTN, insts.OpVSQXTN, insts.OpVUQXTN, insts.OpVSQXTUN,
+		insts.OpVSADDLP, insts.OpVUADDLP, insts.OpVSADALP, insts.OpVUADALP:
+		return true
+	case insts.OpVSADDL, insts.OpVUADDL, insts.OpVSADDW, insts.OpVUADDW,
+		insts.OpVSSUBL, insts.OpVUSUBL, insts.OpVSSUBW, insts.OpVUSUBW,
+		insts.OpVSABAL, insts.OpVUABAL, insts.OpVSABDL, insts.OpVUABDL,
+		insts.OpVSMLAL, insts.OpVUMLAL, insts.OpVSMLSL, insts.OpVUMLSL,
+		insts.OpVSMULL, insts.OpVUMULL:
+		return true
+	case insts.OpVSHL, insts.OpVSSHR, insts.OpVUSHR, insts.OpVSSRA, insts.OpVUSRA,
+		insts.OpVSHRN, insts.OpVRSHRN, insts.OpVSSHLL, insts.OpVUSHLL:
+		return true
+	case insts.OpVMOVI, insts.OpVMVNI, insts.OpVORRImm, insts.OpVBICImm,
+		insts.OpDUPElem, insts.OpINS, insts.OpINSElem, insts.OpUMOV, insts.OpSMOV,
+		insts.OpVUZP1, insts.OpVUZP2, insts.OpVZIP1, insts.OpVZIP2, insts.OpVTRN1, insts.OpVTRN2,
+		insts.OpVEXT, insts.OpVTBL, insts.OpVTBX:
+		return true
+	case insts.OpVADDV, insts.OpVSADDLV, insts.OpVUADDLV,
+		insts.OpVSMAXV, insts.OpVUMAXV, insts.OpVSMINV, insts.OpVUMINV:
+		return true
+	default:
+		return false
+	}
+}
+
+// isSIMDFloatOp reports whether op is a NEON floating-point operation.
+func isSIMDFloatOp(op insts.Op) bool {
+	switch op {
+	case insts.OpVFADD, insts.OpVFSUB, insts.OpVFMUL, insts.OpVFDIV,
+		insts.OpVFMLA, insts.OpVFMLS, insts.OpVFABD,
+		insts.OpVFMAX, insts.OpVFMIN, insts.OpVFMAXNM, insts.OpVFMINNM,
+		insts.OpVFADDP, insts.OpVFMAXP, insts.OpVFMINP, insts.OpVFMAXNMP, insts.OpVFMINNMP,
+		insts.OpVFCMEQ, insts.OpVFCMGE, insts.OpVFCMGT, insts.OpVFACGE, insts.OpVFACGT,
+		insts.OpVFRECPS, insts.OpVFRSQRTS:
+		return true
+	case insts.OpVFCMEQZ, insts.OpVFCMGEZ, insts.OpVFCMGTZ, insts.OpVFCMLEZ, insts.OpVFCMLTZ,
+		insts.OpVFABS, insts.OpVFNEG, insts.OpVFSQRT,
+		insts.OpVFRINTN, insts.OpVFRINTA, insts.OpVFRINTP, insts.OpVFRINTM,
+		insts.OpVFRINTZ, insts.OpVFRINTX, insts.OpVFRINTI,
+		insts.OpVSCVTF, insts.OpVUCVTF,
+		insts.OpVFCVTNS, insts.OpVFCVTNU, insts.OpVFCVTPS, insts.OpVFCVTPU,
+		insts.OpVFCVTMS, insts.OpVFCVTMU, insts.OpVFCVTZS, insts.OpVFCVTZU,
+		insts.OpVFCVTAS, insts.OpVFCVTAU, insts.OpVFCVTL, insts.OpVFCVTN,
+		insts.OpVFRECPE, insts.OpVFRSQRTE:
+		return true
+	case insts.OpVFMAXV, insts.OpVFMINV, insts.OpVFMAXNMV, insts.OpVFMINNMV, insts.OpVFMOVImm:
+		return true
+	default:
+		return false
+	}
+}
+
+// isCryptoOp reports whether op is a cryptographic extension operation.
+func isCryptoOp(op insts.Op) bool {
+	switch op {
+	case insts.OpAESE, insts.OpAESD, insts.OpAESMC, insts.OpAESIMC,
+		insts.OpSHA1C, insts.OpSHA1P, insts.OpSHA1M, insts.OpSHA1H, insts.OpSHA1SU0, insts.OpSHA1SU1,
+		insts.OpSHA256H, insts.OpSHA256H2, insts.OpSHA256SU0, insts.OpSHA256SU1,
+		insts.OpSHA512H, insts.OpSHA512H2, insts.OpSHA512SU0, insts.OpSHA512SU1,
+		insts.OpEOR3, insts.OpBCAX, insts.OpRAX1, insts.OpXAR, insts.OpVPMULL:
+		return true
+	default:
+		return false
+	}
+}
@@ -101,6 +101,156 @@ var _ = Describe("Latency", func() {
 			Expect(inst.Op).To(Equal(insts.OpMSUB))
 			Expect(table.GetLatency(inst)).To(Equal(uint64(3)))
 		})
+
+		It("should return MultiplyLatency for long and high multiplies", func() {
+			// SMADDL X0, W1, W2, X3 -> 0x9B220C20
+			Expect(table.GetLatency(decoder.Decode(0x9B220C20))).To(Equal(uint64(3)))
+			// UMULH X0, X1, X2 -> 0x9BC27C20
+			Expect(table.GetLatency(decoder.Decode(0x9BC27C20))).To(Equal(uint64(3)))
+		})
+	})
+
+	Describe("Bit Manipulation, Carry and CRC Latencies", func() {
+		It("should return ALULatency for CLZ, REV, ADCS and SBC", func() {
+			// CLZ X0, X1 -> 0xDAC01020
+			Expect(table.GetLatency(decoder.Decode(0xDAC01020))).To(Equal(uint64(1)))
+			// REV W2, W3 -> 0x5AC00862
+			Expect(table.GetLatency(decoder.Decode(0x5AC00862))).To(Equal(uint64(1)))
+			// ADCS X0, X1, X2 -> 0xBA020020
+			Expect(table.GetLatency(decoder.Decode(0xBA020020))).To(Equal(uint64(1)))
+			// SBC W4, W5, W6 -> 0x5A0600A4
+			Expect(table.GetLatency(decoder.Decode(0x5A0600A4))).To(Equal(uint64(1)))
+		})
+
+		It("should return ALULatency for extended-register ADD", func() {
+			// ADD X0, SP, W1, UXTW #2 -> 0x8B214BE0
+			Expect(table.GetLatency(decoder.Decode(0x8B214BE0))).To(Equal(uint64(1)))
+		})
+
+		It("should return CRCLatency for CRC32 and CRC32C", func() {
+			// CRC32B W3, W4, W5 -> 0x1AC54083
+			Expect(table.GetLatency(decoder.Decode(0x1AC54083))).To(Equal(uint64(3)))
+			// CRC32CX W0, W1, X2 -> 0x9AC25C20
+			Expect(table.GetLatency(decoder.Decode(0x9AC25C20))).To(Equal(uint64(3)))
+		})
+	})
+
+	Describe("Scalar Floating-Point Latencies", func() {
+		It("should return FPAddLatency for FADD", func() {
+			// FADD D0, D1, D2 -> 0x1E622820
+			inst := decoder.Decode(0x1E622820)
+			Expect(inst.Op).To(Equal(insts.OpFADD))
+			Expect(table.GetLatency(inst)).To(Equal(uint64(3)))
+		})
+
+		It("should return FPFMALatency for FMADD", func() {
+			// FMADD D0, D1, D2, D3 -> 0x1F420C20
+			inst := decoder.Decode(0x1F420C20)
+			Expect(inst.Op).To(Equal(insts.OpFMADD))
+			Expect(table.GetLatency(inst)).To(Equal(uint64(4)))
+		})
+
+		It("should return FPDivLatency for FDIV", func() {
+			// FDIV D7, D8, D9 -> 0x1E691907
+			inst := decoder.Decode(0x1E691907)
+			Expect(inst.Op).To(Equal(insts.OpFDIV))
+			Expect(table.GetLatency(inst)).To(Equal(uint64(10)))
+		})
+
+		It("should return FPSqrtLatency for FSQRT", func() {
+			// FSQRT D1, D2 -> 0x1E61C041
+			inst := decoder.Decode(0x1E61C041)
+			Expect(inst.Op).To(Equal(insts.OpFSQRT))
+			Expect(table.GetLatency(inst)).To(Equal(uint64(13)))
+		})
+
+		It("should return FPConvertLatency for FCVTZS", func() {
+			// FCVTZS X0, D1 -> 0x9E780020
+			inst := decoder.Decode(0x9E780020)
+			Expect(inst.Op).To(Equal(insts.OpFCVTZS))
+			Expect(table.GetLatency(inst)).To(Equal(uint64(3)))
+		})
+
+		It("should return FPSimpleLatency for FCMP", func() {
+			// FCMP D1, D2 -> 0x1E622020
+			inst := decoder.Decode(0x1E622020)
+			Expect(inst.Op).To(Equal(insts.OpFCMP))
+			Expect(table.GetLatency(inst)).To(Equal(uint64(2)))
+		})
+	})
+
+	Describe("Exclusive and Atomic Latencies", func() {
+		It("should return ExclusiveLatency for LDXR and STXR", func() {
+			// LDXR X0, [X1] -> 0xC85F7C20
+			ldxr := decoder.Decode(0xC85F7C20)
+			Expect(ldxr.Op).To(Equal(insts.OpLDXR))
+			Expect(table.GetLatency(ldxr)).To(Equal(uint64(5)))
+
+			// STXR W5, X6, [X7] -> 0xC8057CE6
+			stxr := decoder.Decode(0xC8057CE6)
+			Expect(table.GetLatency(stxr)).To(Equal(uint64(5)))
+		})
+
+		It("should return AtomicLatency for LDADD and CAS", func() {
+			// LDADD X0, X1, [X2] -> 0xF8200041
+			Expect(table.GetLatency(decoder.Decode(0xF8200041))).To(Equal(uint64(8)))
+			// CAS X0, X1, [X2] -> 0xC8A07C41
+			Expect(table.GetLatency(decoder.Decode(0xC8A07C41))).To(Equal(uint64(8)))
+		})
+
+		It("should add the ordering penalty for acquire and release", func() {
+			// LDAXR W2, [SP] -> 0x885FFFE2
+			Expect(table.GetLatency(decoder.Decode(0x885FFFE2))).To(Equal(uint64(7)))
+			// LDSETA X0, X1, [X2] -> 0xF8A03041
+			Expect(table.GetLatency(decoder.Decode(0xF8A03041))).To(Equal(uint64(10)))
+			// LDADDAL W0, W1, [X2] -> 0xB8E00041
+			Expect(table.GetLatency(decoder.Decode(0xB8E00041))).To(Equal(uint64(12)))
+		})
+
+		It("should classify them as atomic memory operations", func() {
+			ldadd := decoder.Decode(0xF8200041)
+			ldr := decoder.Decode(0xF9400420)
+
+			Expect(table.IsAtomicOp(ldadd)).To(BeTrue())
+			Expect(table.IsMemoryOp(ldadd)).To(BeTrue())
+			Expect(table.IsLoadOp(ldadd)).To(BeFalse())
+			Expect(table.IsAtomicOp(ldr)).To(BeFalse())
+		})
+	})
+
+	Describe("Ordering and Barrier Latencies", func() {
+		It("should add the ordering penalty to LDAR and STLR", func() {
+			// LDAR X0, [X1] -> 0xC8DFFC20
+			Expect(table.GetLatency(decoder.Decode(0xC8DFFC20))).To(Equal(uint64(6)))
+			// STLR X5, [X6] -> 0xC89FFCC5
+			Expect(table.GetLatency(decoder.Decode(0xC89FFCC5))).To(Equal(uint64(3)))
+		})
+
+		It("should not add the ordering penalty to LDAPR", func() {
+			// LDAPR X0, [X1] -> 0xF8BFC020
+			Expect(table.GetLatency(decoder.Decode(0xF8BFC020))).To(Equal(uint64(4)))
+		})
+
+		It("should return BarrierLatency for DMB, DSB and ISB", func() {
+			// DMB ISH -> 0xD5033BBF
+			Expect(table.GetLatency(decoder.Decode(0xD5033BBF))).To(Equal(uint64(2)))
+			// DSB SY -> 0xD5033F9F
+			Expect(table.GetLatency(decoder.Decode(0xD5033F9F))).To(Equal(uint64(2)))
+			// ISB -> 0xD5033FDF
+			Expect(table.GetLatency(decoder.Decode(0xD5033FDF))).To(Equal(uint64(2)))
+		})
+
+		It("should return 1 cycle for hints", func() {
+			// YIELD -> 0xD503203F
+			Expect(table.GetLatency(decoder.Decode(0xD503203F))).To(Equal(uint64(1)))
+		})
+
+		It("should return StoreLatency for cache maintenance", func() {
+			// DC ZVA, X0 -> 0xD50B7420
+			Expect(table.GetLatency(decoder.Decode(0xD50B7420))).To(Equal(uint64(1)))
+			// IC IVAU, X2 -> 0xD50B7522
+			Expect(table.GetLatency(decoder.Decode(0xD50B7522))).To(Equal(uint64(1)))
+		})
 	})
 
 	Describe("Branch Instruction Latencies", func() {
@@ -135,6 +285,39 @@ var _ = Describe("Latency", func() {
 		})
 	})
 
+	Describe("Pointer Authentication Latencies", func() {
+		// PACIASP -> 0xD503233F
+		// PACIA X1, X2 -> 0xDAC10041
+		// XPACI X4 -> 0xDAC143E4
+		// RETAA -> 0xD65F0BFF
+		var paciasp, pacia, xpaci, retaa *insts.Instruction
+
+		BeforeEach(func() {
+			paciasp = decoder.Decode(0xD503233F)
+			pacia = decoder.Decode(0xDAC10041)
+			xpaci = decoder.Decode(0xDAC143E4)
+			retaa = decoder.Decode(0xD65F0BFF)
+		})
+
+		It("should cost ALU latency when pointer authentication is a NOP", func() {
+			config := table.Config()
+			Expect(table.GetLatency(paciasp)).To(Equal(config.ALULatency))
+			Expect(table.GetLatency(pacia)).To(Equal(config.ALULatency))
+			Expect(table.GetLatency(retaa)).To(Equal(config.BranchLatency))
+		})
+
+		It("should add PAC latency when pointer authentication is enabled", func() {
+			table.SetPointerAuth(true)
+			config := table.Config()
+
+			Expect(table.GetLatency(paciasp)).To(Equal(config.PACLatency))
+			Expect(table.GetLatency(pacia)).To(Equal(config.PACLatency))
+			Expect(table.GetLatency(xpaci)).To(Equal(config.ALULatency))
+			Expect(table.GetLatency(retaa)).To(Equal(config.BranchLatency + config.PACLatency))
+			Expect(table.IsBranchOp(retaa)).To(BeTrue())
+		})
+	})
+
 	Describe("Memory Instruction Latencies", func() {
 		It("should return 4 cycles for LDR (L1 hit)", func() {
 			// LDR X0, [X1, #8] -> 0xF9400420
@@ -179,6 +362,89 @@ var _ = Describe("Latency", func() {
 			Expect(table.IsLoadOp(str)).To(BeFalse())
 		})
 
+		It("should treat LDPSW as a load", func() {
+			// LDPSW X0, X1, [X2, #8] -> 0x69410440
+			ldpsw := decoder.Decode(0x69410440)
+
+			Expect(table.IsLoadOp(ldpsw)).To(BeTrue())
+			Expect(table.IsMemoryOp(ldpsw)).To(BeTrue())
+			Expect(table.GetLatency(ldpsw)).To(Equal(table.Config().LoadLatency))
+		})
+
+		It("should treat structure loads and stores as SIMD memory operations", func() {
+			// LD4 {V0.4S-V3.4S}, [X1] -> 0x4C400820
+			// ST2 {V0.H, V1.H}[7], [SP] -> 0x4D205BE0
+			ld4 := decoder.Decode(0x4C400820)
+			st2 := decoder.Decode(0x4D205BE0)
+
+			Expect(table.IsLoadOp(ld4)).To(BeTrue())
+			Expect(table.IsStoreOp(st2)).To(BeTrue())
+			Expect(table.IsSIMDOp(ld4)).To(BeTrue())
+			Expect(table.IsMemoryOp(st2)).To(BeTrue())
+			Expect(table.GetLatency(ld4)).To(Equal(table.Config().SIMDLoadLatency))
+			Expect(table.GetLatency(st2)).To(Equal(table.Config().SIMDStoreLatency))
+		})
+
+		It("should classify NEON integer operations", func() {
+			// CMEQ V0.16B, V1.16B, V2.16B -> 0x6E228C20
+			// MLA V0.4S, V1.4S, V2.4S -> 0x4EA29420
+			// ADDV S0, V1.4S -> 0x4EB1B820
+			// UMOV W0, V1.B[1] -> 0x0E033C20
+			cmeq := decoder.Decode(0x6E228C20)
+			mla := decoder.Decode(0x4EA29420)
+			addv := decoder.Decode(0x4EB1B820)
+			umov := decoder.Decode(0x0E033C20)
+
+			for _, inst := range []*insts.Instruction{cmeq, mla, addv, umov} {
+				Expect(table.IsSIMDOp(inst)).To(BeTrue())
+			}
+			Expect(table.GetLatency(cmeq)).To(Equal(table.Config().SIMDIntLatency))
+			Expect(table.GetLatency(mla)).To(Equal(table.Config().SIMDMulLatency))
+			Expect(table.GetLatency(addv)).To(Equal(table.Config().SIMDReduceLatency))
+			Expect(table.GetLatency(umov)).To(Equal(table.Config().SIMDIntLatency))
+		})
+
+		It("should classify NEON floating-point operations", func() {
+			// FMLA V0.4S, V1.4S, V2.S[3] -> 0x4FA21820
+			// FCMEQ V0.4S, V1.4S, #0.0 -> 0x4EA0D820
+			// SCVTF V0.4S, V1.4S -> 0x4E21D820
+			// FMAXV H0, V1.8H -> 0x4E30F820
+			fmla := decoder.Decode(0x4FA21820)
+			fcmeq := decoder.Decode(0x4EA0D820)
+			scvtf := decoder.Decode(0x4E21D820)
+			fmaxv := decoder.Decode(0x4E30F820)
+
+			for _, inst := range []*insts.Instruction{fmla, fcmeq, scvtf, fmaxv} {
+				Expect(table.IsSIMDOp(inst)).To(BeTrue())
+			}
+			Expect(table.GetLatency(fmla)).To(Equal(table.Config().FPFMALatency))
+			Expect(table.GetLatency(fcmeq)).To(Equal(table.Config().FPSimpleLatency))
+			Expect(table.GetLatency(scvtf)).To(Equal(table.Config().FPConvertLatency))
+			Expect(table.GetLatency(fmaxv)).To(Equal(table.Config().SIMDReduceLatency))
+		})
+
+		It("should classify cryptographic operations", func() {
+			// AESE V0.16B, V1.16B -> 0x4E284820
+			// SHA256H Q0, Q1, V2.4S -> 0x5E024020
+			// SHA512SU0 V0.2D, V1.2D -> 0xCEC08020
+			// EOR3 V0.16B, V1.16B, V2.16B, V3.16B -> 0xCE020C20
+			// PMULL2 V0.1Q, V1.2D, V2.2D -> 0x4EE2E020
+			aese := decoder.Decode(0x4E284820)
+			sha256h := decoder.Decode(0x5E024020)
+			sha512su0 := decoder.Decode(0xCEC08020)
+			eor3 := decoder.Decode(0xCE020C20)
+			pmull2 := decoder.Decode(0x4EE2E020)
+
+			for _, inst := range []*insts.Instruction{aese, sha256h, sha512su0, eor3, pmull2} {
+				Expect(table.IsSIMDOp(inst)).To(BeTrue())
+			}
+			Expect(table.GetLatency(aese)).To(Equal(table.Config().AESLatency))
+			Expect(table.GetLatency(sha256h)).To(Equal(table.Config().SHAHashLatency))
+			Expect(table.GetLatency(sha512su0)).To(Equal(table.Config().SHAScheduleLatency))
+			Expect(table.GetLatency(eor3)).To(Equal(table.Config().SHA3Latency))
+			Expect(table.GetLatency(pmull2)).To(Equal(table.Config().PMULLLatency))
+		})
+
 		It("should detect store operations", func() {
 			ldr := decoder.Decode(0xF9400420)
 			str := decoder.Decode(0xF9000420)
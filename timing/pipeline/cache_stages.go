@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
 	"github.com/sarchlab/m2sim/timing/cache"
 )
 
@@ -99,23 +100,68 @@ type CachedMemoryStage struct {
 	storeIssuedPC   uint64 // PC of last fire-and-forget store issued
 	storeIssuedAddr uint64 // Address of last fire-and-forget store issued
 	storeIssued     bool   // True if store already written to cache for current (PC, addr)
+
+	loadDonePC   uint64 // PC of last completed load
+	loadDoneAddr uint64 // Address of last completed load
+	loadDoneData uint64 // Data returned by last completed load
+	loadDone     bool   // True if load already completed for current (PC, addr)
+
+	stores *storeBuffer // Stores issued but not yet complete
 }
 
 type memResult struct {
 	data uint64
 }
 
+// storeBuffer tracks stores from issue to completion in the D-cache. Memory
+// ports that share a buffer see each other's stores, so a barrier on one
+// port waits for all of them.
+type storeBuffer struct {
+	cycle   uint64 // Cycles elapsed, advanced once per pipeline tick
+	drainAt uint64 // Cycle by which every issued store has completed
+}
+
+// tick advances the buffer by one cycle.
+func (b *storeBuffer) tick() {
+	b.cycle++
+}
+
+// issue records a store that completes latency cycles from now.
+func (b *storeBuffer) issue(latency uint64) {
+	if done := b.cycle + latency; done > b.drainAt {
+		b.drainAt = done
+	}
+}
+
+// drained reports whether every issued store has completed.
+func (b *storeBuffer) drained() bool {
+	return b.cycle >= b.drainAt
+}
+
 // NewCachedMemoryStage creates a new cached memory stage.
 func NewCachedMemoryStage(dcache *cache.Cache, memory *emu.Memory) *CachedMemoryStage {
 	return &CachedMemoryStage{
 		cache:  dcache,
 		memory: memory,
+		stores: &storeBuffer{},
 	}
 }
 
+// shareStoreBuffer makes s track its stores in other's store buffer.
+func (s *CachedMemoryStage) shareStoreBuffer(other *CachedMemoryStage) {
+	s.stores = other.stores
+}
+
+// drainsStores returns true for the barriers that complete only after all
+// earlier stores have completed.
+func drainsStores(inst *insts.Instruction) bool {
+	return inst != nil && (inst.Op == insts.OpDMB || inst.Op == insts.OpDSB)
+}
+
 // Access performs memory read or write through D-cache.
 // Returns result and whether the operation is stalling.
-// Both cache hits and misses cause pipeline stalls based on their latencies.
+// Both cache hits and misses cause pipeline stalls based on their latencies,
+// and DMB/DSB stall until all outstanding stores have completed.
 func (s *CachedMemoryStage) Access(exmem *EXMEMRegister) (MemoryResult, bool) {
 	result := MemoryResult{}
 
@@ -125,6 +171,11 @@ func (s *CachedMemoryStage) Access(exmem *EXMEMRegister) (MemoryResult, bool) {
 		return result, false
 	}
 
+	if drainsStores(exmem.Inst) {
+		s.pending = false
+		return result, !s.stores.drained()
+	}
+
 	// If not a memory operation, no stall
 	if !exmem.MemRead && !exmem.MemWrite {
 		s.pending = false
@@ -150,19 +201,21 @@ func (s *CachedMemoryStage) Access(exmem *EXMEMRegister) (MemoryResult, bool) {
 		s.pending = false
 		if s.result != nil && exmem.MemRead {
 			result.MemData = s.result.data
+			s.completeLoad(exmem.PC, addr, s.result.data)
 		}
 		return result, false
 	}
 
-	// Determine access size
-	size := 8
-	if exmem.Inst != nil && !exmem.Inst.Is64Bit {
-		size = 4
+	// Idempotency: when another port's stall holds this load in place,
+	// do not access the cache again.
+	if exmem.MemRead && s.loadDone && s.loadDonePC == exmem.PC && s.loadDoneAddr == addr {
+		result.MemData = s.loadDoneData
+		return result, false
 	}
 
 	if exmem.MemRead {
 		// Load through D-cache
-		cacheResult := s.cache.Read(addr, size)
+		cacheResult := s.read(addr, exmem.Inst)
 
 		// Both hits and misses have latency - set up pending state
 		s.pending = true
@@ -179,6 +232,7 @@ func (s *CachedMemoryStage) Access(exmem *EXMEMRegister) (MemoryResult, bool) {
 		// Single-cycle latency (latency=1)
 		s.pending = false
 		result.MemData = cacheResult.Data
+		s.completeLoad(exmem.PC, addr, cacheResult.Data)
 		return result, false
 	}
 
@@ -192,7 +246,7 @@ func (s *CachedMemoryStage) Access(exmem *EXMEMRegister) (MemoryResult, bool) {
 		// Idempotency: when another port's stall replays this cycle,
 		// skip the duplicate cache.Write to avoid inflating stats.
 		if !s.storeIssued || s.storeIssuedPC != exmem.PC || s.storeIssuedAddr != addr {
-			s.cache.Write(addr, size, exmem.StoreValue)
+			s.write(addr, exmem.Inst, exmem.StoreValue)
 			s.storeIssued = true
 			s.storeIssuedPC = exmem.PC
 			s.storeIssuedAddr = addr
@@ -213,6 +267,11 @@ func (s *CachedMemoryStage) AccessSlot(slot MemorySlot) (MemoryResult, bool) {
 		return result, false
 	}
 
+	if drainsStores(slot.GetInst()) {
+		s.pending = false
+		return result, !s.stores.drained()
+	}
+
 	if !slot.GetMemRead() && !slot.GetMemWrite() {
 		s.pending = false
 		return result, false
@@ -235,18 +294,18 @@ func (s *CachedMemoryStage) AccessSlot(slot MemorySlot) (MemoryResult, bool) {
 		s.pending = false
 		if s.result != nil && slot.GetMemRead() {
 			result.MemData = s.result.data
+			s.completeLoad(pc, addr, s.result.data)
 		}
 		return result, false
 	}
 
-	inst := slot.GetInst()
-	size := 8
-	if inst != nil && !inst.Is64Bit {
-		size = 4
+	if slot.GetMemRead() && s.loadDone && s.loadDonePC == pc && s.loadDoneAddr == addr {
+		result.MemData = s.loadDoneData
+		return result, false
 	}
 
 	if slot.GetMemRead() {
-		cacheResult := s.cache.Read(addr, size)
+		cacheResult := s.read(addr, slot.GetInst())
 		s.pending = true
 		s.pendingPC = pc
 		s.pendingAddr = addr
@@ -259,6 +318,7 @@ func (s *CachedMemoryStage) AccessSlot(slot MemorySlot) (MemoryResult, bool) {
 		}
 		s.pending = false
 		result.MemData = cacheResult.Data
+		s.completeLoad(pc, addr, cacheResult.Data)
 		return result, false
 	}
 
@@ -266,7 +326,7 @@ func (s *CachedMemoryStage) AccessSlot(slot MemorySlot) (MemoryResult, bool) {
 		// Store through D-cache — fire-and-forget to store buffer.
 		// Idempotency guard: skip duplicate writes on stall replays.
 		if !s.storeIssued || s.storeIssuedPC != pc || s.storeIssuedAddr != addr {
-			s.cache.Write(addr, size, slot.GetStoreValue())
+			s.write(addr, slot.GetInst(), slot.GetStoreValue())
 			s.storeIssued = true
 			s.storeIssuedPC = pc
 			s.storeIssuedAddr = addr
@@ -278,6 +338,60 @@ func (s *CachedMemoryStage) AccessSlot(slot MemorySlot) (MemoryResult, bool) {
 	return result, false
 }
 
+// accessParts returns the number of D-cache accesses a memory instruction
+// makes and the size of each. Multiple-structure loads and stores access
+// one vector register at a time; other instructions make a single access.
+func accessParts(inst *insts.Instruction) (count, size int) {
+	switch {
+	case inst == nil:
+		return 1, 8
+	case inst.Op == insts.OpVLDN || inst.Op == insts.OpVSTN:
+		if inst.Is64Bit { // 128-bit vectors
+			return int(inst.RegCount), 16
+		}
+		return int(inst.RegCount), 8
+	case inst.Format == insts.FormatSIMDLoadStoreStruct:
+		// Single-structure forms access one contiguous structure
+		return 1, int(inst.StructElems) * int(inst.AccessSize)
+	case !inst.Is64Bit:
+		return 1, 4
+	default:
+		return 1, 8
+	}
+}
+
+// read performs the D-cache reads of a load at addr. The accesses of a
+// multi-part load issue on consecutive cycles, so the load completes with
+// its last-finishing access and hits only if every access hits.
+func (s *CachedMemoryStage) read(addr uint64, inst *insts.Instruction) cache.AccessResult {
+	count, size := accessParts(inst)
+	result := s.cache.Read(addr, size)
+	for part := 1; part < count; part++ {
+		r := s.cache.Read(addr+uint64(part*size), size)
+		result.Hit = result.Hit && r.Hit
+		result.Latency = max(result.Latency, uint64(part)+r.Latency)
+	}
+	return result
+}
+
+// write performs the D-cache writes of a store at addr, issuing one access
+// per cycle to the store buffer.
+func (s *CachedMemoryStage) write(addr uint64, inst *insts.Instruction, value uint64) {
+	count, size := accessParts(inst)
+	for part := 0; part < count; part++ {
+		r := s.cache.Write(addr+uint64(part*size), size, value)
+		s.stores.issue(uint64(part) + r.Latency)
+	}
+}
+
+// completeLoad records a finished load for the idempotency check.
+func (s *CachedMemoryStage) completeLoad(pc, addr, data uint64) {
+	s.loadDone = true
+	s.loadDonePC = pc
+	s.loadDoneAddr = addr
+	s.loadDoneData = data
+}
+
 // Reset clears pending state.
 func (s *CachedMemoryStage) Reset() {
 	s.pending = false
@@ -285,6 +399,8 @@ func (s *CachedMemoryStage) Reset() {
 	s.result = nil
 	s.isHit = false
 	s.storeIssued = false
+	s.loadDone = false
+	*s.stores = storeBuffer{}
 }
 
 // CacheStats returns the underlying cache statistics.
@@ -296,3 +412,17 @@ func (s *CachedMemoryStage) CacheStats() cache.Statistics {
 func (s *CachedFetchStage) CacheStats() cache.Statistics {
 	return s.cache.Stats()
 }
+
+// dataCacheBacking backs the D-cache with emulator memory but drops
+// write-backs. The execution core commits stores to memory directly, so the
+// cache only models timing and must not overwrite newer data on eviction.
+type dataCacheBacking struct {
+	*cache.MemoryBacking
+}
+
+func newDataCacheBacking(memory *emu.Memory) *dataCacheBacking {
+	return &dataCacheBacking{MemoryBacking: cache.NewMemoryBacking(memory)}
+}
+
+// Write discards dirty block data evicted from the D-cache.
+func (b *dataCacheBacking) Write(addr uint64, data []byte) {}
@@ -317,6 +317,59 @@ var _ = Describe("CachedMemoryStage", func() {
 		})
 	})
 
+	Describe("Structure loads and stores", func() {
+		ld4 := &insts.Instruction{
+			Op: insts.OpVLDN, Format: insts.FormatSIMDLoadStoreStruct,
+			Is64Bit: true, RegCount: 4, StructElems: 4, AccessSize: 4,
+		}
+
+		It("should access the cache once per register of a multiple-structure load", func() {
+			// 64 bytes from 0x2020 span two cache lines: the second
+			// line's first access issues two cycles after the first.
+			exmem := &pipeline.EXMEMRegister{
+				Valid: true, PC: 0x1000, ALUResult: 0x2020, MemRead: true, Inst: ld4,
+			}
+
+			cycles := 1
+			for _, stall := memStage.Access(exmem); stall; _, stall = memStage.Access(exmem) {
+				cycles++
+			}
+			Expect(cycles).To(Equal(12)) // miss latency 10 + 2
+			stats := memStage.CacheStats()
+			Expect(stats.Reads).To(Equal(uint64(4)))
+			Expect(stats.Misses).To(Equal(uint64(2)))
+		})
+
+		It("should access a single structure once", func() {
+			exmem := &pipeline.EXMEMRegister{
+				Valid: true, PC: 0x1000, ALUResult: 0x2000, MemRead: true,
+				Inst: &insts.Instruction{
+					Op: insts.OpVLDNLane, Format: insts.FormatSIMDLoadStoreStruct,
+					RegCount: 4, StructElems: 4, AccessSize: 8,
+				},
+			}
+
+			memStage.Access(exmem)
+			Expect(memStage.CacheStats().Reads).To(Equal(uint64(1)))
+		})
+
+		It("should issue one write per register of a multiple-structure store", func() {
+			exmem := &pipeline.EXMEMRegister{
+				Valid: true, PC: 0x1000, ALUResult: 0x3000, MemWrite: true,
+				Inst: &insts.Instruction{
+					Op: insts.OpVSTN, Format: insts.FormatSIMDLoadStoreStruct,
+					RegCount: 3, StructElems: 1, AccessSize: 1,
+				},
+			}
+
+			for i := 0; i < 3; i++ {
+				_, stall := memStage.Access(exmem)
+				Expect(stall).To(BeFalse())
+			}
+			Expect(memStage.CacheStats().Writes).To(Equal(uint64(3)))
+		})
+	})
+
 	Describe("Store operations", func() {
 		Context("Store buffer model (fire-and-forget)", func() {
 			It("should not stall on store miss (store buffer absorbs latency)", func() {
@@ -0,0 +1,428 @@
+package pipeline_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/timing/latency"
+	"github.com/sarchlab/m2sim/timing/pipeline"
+)
+
+const (
+	coreTestEntry = uint64(0x1000)
+	coreTestStack = uint64(0x80000)
+)
+
+// mixedProgram exercises instructions that the pipeline used to model only
+// partially: wide moves, multiply-add, bitfields, EXTR, conditional
+// select/compare, SP-relative pairs with writeback, compare/test branches,
+// MRS and byte accesses. It exits with status 0.
+var mixedProgram = []uint32{
+	0xd2824680, // mov   x0, #0x1234
+	0xf2b579a0, // movk  x0, #0xabcd, lsl #16
+	0xd28000e1, // mov   x1, #7
+	0xd2800062, // mov   x2, #3
+	0x9b020023, // madd  x3, x1, x2, x0
+	0x9b028c24, // msub  x4, x1, x2, x3
+	0xd3442c05, // ubfx  x5, x0, #4, #8
+	0x934c4c06, // sbfx  x6, x0, #12, #8
+	0xd37be827, // lsl   x7, x1, #5
+	0x93c14013, // extr  x19, x0, x1, #16
+	0xeb02003f, // cmp   x1, x2
+	0x9a82c029, // csel  x9, x1, x2, gt
+	0x9a82b42a, // csinc x10, x1, x2, lt
+	0xfa471820, // ccmp  x1, #7, #0, ne
+	0x9a9f17eb, // cset  x11, eq
+	0xd10083ff, // sub   sp, sp, #32
+	0xa90107e0, // stp   x0, x1, [sp, #16]
+	0xf81f07e2, // str   x2, [sp], #-16
+	0xf9400bec, // ldr   x12, [sp, #16]
+	0xa9423bed, // ldp   x13, x14, [sp, #32]
+	0x9100c3ff, // add   sp, sp, #48
+	0xd280000f, // mov   x15, #0
+	0x910005ef, // loop: add x15, x15, #1
+	0xb500002f, // cbnz  x15, +4
+	0x3617ffcf, // tbz   w15, #2, loop
+	0xd53b4210, // mrs   x16, nzcv
+	0xb4000082, // cbz   x2, end
+	0x9ac10811, // udiv  x17, x0, x1
+	0x381fffe1, // strb  w1, [sp, #-1]!
+	0x384017f2, // ldrb  w18, [sp], #1
+	0xd2800ba8, // end: mov x8, #93
+	0xd2800000, // mov   x0, #0
+	0xd4000001, // svc   #0
+}
+
+// sumProgram sums 0..9 through a stack slot in a CMP+B.NE loop, which the
+// wide pipelines fuse, and exits with the sum (45).
+var sumProgram = []uint32{
+	0xd2800000, // mov  x0, #0
+	0xd2800001, // mov  x1, #0
+	0xd10043ff, // sub  sp, sp, #16
+	0x8b010000, // loop: add x0, x0, x1
+	0xf90007e0, // str  x0, [sp, #8]
+	0xf94007e2, // ldr  x2, [sp, #8]
+	0x91000421, // add  x1, x1, #1
+	0xf100283f, // cmp  x1, #10
+	0x54ffff61, // b.ne loop
+	0x910043ff, // add  sp, sp, #16
+	0xd2800ba8, // mov  x8, #93
+	0xd4000001, // svc  #0
+}
+
+// atomicProgram updates a stack slot with an LDAXR/STLXR retry loop, LDADDAL,
+// CASAL and SWP, and exits with the sum of the values observed (21).
+var atomicProgram = []uint32{
+	0xd10043ff, // sub     sp, sp, #16
+	0xf90007ff, // str     xzr, [sp, #8]
+	0x910023e1, // add     x1, sp, #8
+	0xd28000a3, // mov     x3, #5
+	0xc85ffc20, // retry: ldaxr x0, [x1]
+	0x8b030000, // add     x0, x0, x3
+	0xc802fc20, // stlxr   w2, x0, [x1]
+	0x35ffffa2, // cbnz    w2, retry
+	0xd2800044, // mov     x4, #2
+	0xf8e40025, // ldaddal x4, x5, [x1]
+	0xf9400020, // ldr     x0, [x1]
+	0xd28000e6, // mov     x6, #7
+	0xd2800127, // mov     x7, #9
+	0xc8e6fc27, // casal   x6, x7, [x1]
+	0xf8268029, // swp     x6, x9, [x1]
+	0x8b050000, // add     x0, x0, x5
+	0x8b090000, // add     x0, x0, x9
+	0x910043ff, // add     sp, sp, #16
+	0xd2800ba8, // mov     x8, #93
+	0xd4000001, // svc     #0
+}
+
+// barrierProgram mixes plain, store-release and load-acquire accesses with
+// DMB, DSB, ISB and a hint, and exits with the sum of the loaded values (10).
+var barrierProgram = []uint32{
+	0xd10043ff, // sub   sp, sp, #16
+	0x910003e1, // mov   x1, sp
+	0xd2800062, // mov   x2, #3
+	0xf9000022, // str   x2, [x1]
+	0xd5033bbf, // dmb   ish
+	0xd2800083, // mov   x3, #4
+	0x910023e4, // add   x4, sp, #8
+	0xc89ffc83, // stlr  x3, [x4]
+	0xc8dffc25, // ldar  x5, [x1]
+	0xf8bfc086, // ldapr x6, [x4]
+	0xd5033f9f, // dsb   sy
+	0xd5033fdf, // isb
+	0xd503203f, // yield
+	0x8b0600a0, // add   x0, x5, x6
+	0x8b020000, // add   x0, x0, x2
+	0x910043ff, // add   sp, sp, #16
+	0xd2800ba8, // mov   x8, #93
+	0xd4000001, // svc   #0
+}
+
+func loadCoreTestProgram(memory *emu.Memory, program []uint32) {
+	for i, word := range program {
+		memory.Write32(coreTestEntry+uint64(i*4), word)
+	}
+}
+
+// runReference executes the program on the functional emulator.
+func runReference(program []uint32) (*emu.RegFile, *emu.Memory, int64) {
+	e := emu.NewEmulator(emu.WithStackPointer(coreTestStack))
+	loadCoreTestProgram(e.Memory(), program)
+	e.RegFile().PC = coreTestEntry
+	exitCode := e.Run()
+	return e.RegFile(), e.Memory(), exitCode
+}
+
+func expectSameArchState(actual, expected *emu.RegFile) {
+	for i := 0; i < 31; i++ {
+		Expect(actual.X[i]).To(Equal(expected.X[i]), "X%d", i)
+	}
+	Expect(actual.SP).To(Equal(expected.SP))
+	Expect(actual.PSTATE).To(Equal(expected.PSTATE))
+}
+
+var _ = Describe("Shared execution core", func() {
+	programs := map[string][]uint32{
+		"mixed":   mixedProgram,
+		"sum":     sumProgram,
+		"atomic":  atomicProgram,
+		"barrier": barrierProgram,
+	}
+
+	type pipelineConfig struct {
+		name string
+		opts []pipeline.PipelineOption
+	}
+
+	configs := []pipelineConfig{
+		{"single-issue", nil},
+		{"dual-issue", []pipeline.PipelineOption{pipeline.WithDualIssue()}},
+		{"quad-issue", []pipeline.PipelineOption{pipeline.WithQuadIssue()}},
+		{"6-wide", []pipeline.PipelineOption{pipeline.WithSextupleIssue()}},
+		{"8-wide", []pipeline.PipelineOption{pipeline.WithOctupleIssue()}},
+		{"8-wide with caches", []pipeline.PipelineOption{
+			pipeline.WithOctupleIssue(), pipeline.WithDefaultCaches(),
+		}},
+		{"single-issue with caches and latencies", []pipeline.PipelineOption{
+			pipeline.WithDefaultCaches(),
+			pipeline.WithLatencyTable(latency.NewTable()),
+		}},
+	}
+
+	for name, program := range programs {
+		name, program := name, program
+
+		for _, config := range configs {
+			config := config
+
+			It("should match the emulator on the "+name+" program ("+config.name+")", func() {
+				expectedRegs, expectedMem, expectedExit := runReference(program)
+
+				regFile := &emu.RegFile{SP: coreTestStack}
+				memory := emu.NewMemory()
+				loadCoreTestProgram(memory, program)
+
+				pipe := pipeline.NewPipeline(regFile, memory, config.opts...)
+				pipe.SetPC(coreTestEntry)
+				pipe.RunCycles(10000)
+
+				Expect(pipe.Halted()).To(BeTrue())
+				Expect(pipe.Err()).NotTo(HaveOccurred())
+				Expect(pipe.ExitCode()).To(Equal(expectedExit))
+				expectSameArchState(regFile, expectedRegs)
+				Expect(memory.Read64(coreTestStack - 8)).To(Equal(expectedMem.Read64(coreTestStack - 8)))
+			})
+		}
+
+		It("should match the emulator on the "+name+" program (fast timing)", func() {
+			expectedRegs, _, expectedExit := runReference(program)
+
+			regFile := &emu.RegFile{SP: coreTestStack}
+			memory := emu.NewMemory()
+			loadCoreTestProgram(memory, program)
+
+			handler := emu.NewDefaultSyscallHandler(regFile, memory, nil, nil)
+			ft := pipeline.NewFastTiming(regFile, memory, latency.NewTable(), handler)
+			ft.SetPC(coreTestEntry)
+
+			Expect(ft.Run()).To(Equal(expectedExit))
+			Expect(ft.UnhandledCount()).To(BeZero())
+			expectSameArchState(regFile, expectedRegs)
+		})
+	}
+
+	Describe("Barrier timing", func() {
+		// run executes body followed by an exit and returns the pipeline.
+		run := func(body []uint32, opts ...pipeline.PipelineOption) *pipeline.Pipeline {
+			program := append(append([]uint32{}, body...),
+				0xd2800000, // mov x0, #0
+				0xd2800ba8, // mov x8, #93
+				0xd4000001, // svc #0
+			)
+			regFile := &emu.RegFile{SP: coreTestStack}
+			memory := emu.NewMemory()
+			loadCoreTestProgram(memory, program)
+
+			pipe := pipeline.NewPipeline(regFile, memory, opts...)
+			pipe.SetPC(coreTestEntry)
+			pipe.RunCycles(10000)
+			Expect(pipe.Halted()).To(BeTrue())
+			Expect(pipe.Err()).NotTo(HaveOccurred())
+			return pipe
+		}
+
+		stores := []uint32{
+			0xf81f0fe2, // str x2, [sp, #-16]!
+			0xf81f0fe2, // str x2, [sp, #-16]!
+			0xf81f0fe2, // str x2, [sp, #-16]!
+		}
+
+		It("should make DMB wait for outstanding stores", func() {
+			withNOP := run(append(append([]uint32{}, stores...), 0xd503201f), pipeline.WithDefaultCaches())
+			withDMB := run(append(append([]uint32{}, stores...), 0xd5033bbf), pipeline.WithDefaultCaches())
+			dmbOnly := run([]uint32{0xd503201f, 0xd5033bbf}, pipeline.WithDefaultCaches())
+			nopOnly := run([]uint32{0xd503201f, 0xd503201f}, pipeline.WithDefaultCaches())
+
+			storeDrain := withDMB.Stats().Cycles - withNOP.Stats().Cycles
+			Expect(storeDrain).To(BeNumerically(">", dmbOnly.Stats().Cycles-nopOnly.Stats().Cycles))
+		})
+
+		It("should make DSB wait for stores from every memory port", func() {
+			opts := []pipeline.PipelineOption{pipeline.WithOctupleIssue(), pipeline.WithDefaultCaches()}
+			withNOP := run(append(append([]uint32{}, stores...), 0xd503201f), opts...)
+			withDSB := run(append(append([]uint32{}, stores...), 0xd5033f9f), opts...)
+			dsbOnly := run([]uint32{0xd503201f, 0xd5033f9f}, opts...)
+			nopOnly := run([]uint32{0xd503201f, 0xd503201f}, opts...)
+
+			storeDrain := withDSB.Stats().Cycles - withNOP.Stats().Cycles
+			Expect(storeDrain).To(BeNumerically(">", dsbOnly.Stats().Cycles-nopOnly.Stats().Cycles))
+		})
+
+		It("should refetch the instructions after an ISB", func() {
+			for _, opts := range [][]pipeline.PipelineOption{
+				nil,
+				{pipeline.WithOctupleIssue()},
+			} {
+				withISB := run([]uint32{0xd5033fdf}, opts...) // isb
+				withNOP := run([]uint32{0xd503201f}, opts...) // nop
+
+				Expect(withISB.Stats().Flushes).To(Equal(withNOP.Stats().Flushes + 1))
+				Expect(withISB.Stats().Instructions).To(Equal(withNOP.Stats().Instructions))
+				Expect(withISB.Stats().Cycles).To(BeNumerically(">", withNOP.Stats().Cycles))
+			}
+		})
+	})
+
+	It("should count simulated cycles in the generic timer", func() {
+		program := []uint32{
+			0xd2817700, // mov  x0, #3000
+			0xd53be041, // mrs  x1, cntvct_el0
+			0xf1000400, // loop: subs x0, x0, #1
+			0x54ffffe1, // b.ne loop
+			0xd53be042, // mrs  x2, cntvct_el0
+			0xd2800ba8, // mov  x8, #93
+			0xd4000001, // svc  #0
+		}
+		// ticks converts cycles at 3.5 GHz to 24 MHz counter ticks.
+		ticks := func(cycles uint64) uint64 {
+			return cycles * emu.CounterFrequency / emu.CoreFrequency
+		}
+
+		regFile := &emu.RegFile{SP: coreTestStack}
+		memory := emu.NewMemory()
+		loadCoreTestProgram(memory, program)
+		pipe := pipeline.NewPipeline(regFile, memory, pipeline.WithLatencyTable(latency.NewTable()))
+		pipe.SetPC(coreTestEntry)
+		pipe.RunCycles(100000)
+
+		Expect(pipe.Halted()).To(BeTrue())
+		cycles := pipe.Stats().Cycles
+		Expect(regFile.X[1]).To(BeNumerically("<=", 1))
+		Expect(regFile.X[2]).To(BeNumerically("<=", ticks(cycles)))
+		Expect(regFile.X[2]).To(BeNumerically(">=", ticks(cycles-20)))
+
+		regFile = &emu.RegFile{SP: coreTestStack}
+		memory = emu.NewMemory()
+		loadCoreTestProgram(memory, program)
+		handler := emu.NewDefaultSyscallHandler(regFile, memory, nil, nil)
+		ft := pipeline.NewFastTiming(regFile, memory, latency.NewTable(), handler)
+		ft.SetPC(coreTestEntry)
+		ft.Run()
+
+		cycles = ft.Stats().Cycles
+		Expect(regFile.X[2]).To(BeNumerically("<=", ticks(cycles)))
+		Expect(regFile.X[2]).To(BeNumerically(">=", ticks(cycles-20)))
+	})
+
+	It("should derive guest time from simulated cycles at the core frequency", func() {
+		program := []uint32{
+			0xd2817700, // mov  x0, #3000
+			0xf1000400, // loop: subs x0, x0, #1
+			0x54ffffe1, // b.ne loop
+			0xd2800020, // mov  x0, #1 (CLOCK_MONOTONIC)
+			0xd2840001, // mov  x1, #0x2000
+			0xd2800e28, // mov  x8, #113 (clock_gettime)
+			0xd4000001, // svc  #0
+			0xd2800ba8, // mov  x8, #93
+			0xd4000001, // svc  #0
+		}
+
+		// At 1 GHz, a cycle is a nanosecond.
+		regFile := &emu.RegFile{SP: coreTestStack}
+		memory := emu.NewMemory()
+		loadCoreTestProgram(memory, program)
+		pipe := pipeline.NewPipeline(regFile, memory,
+			pipeline.WithLatencyTable(latency.NewTable()),
+			pipeline.WithCoreFrequency(1_000_000_000))
+		pipe.SetPC(coreTestEntry)
+		pipe.RunCycles(100000)
+
+		Expect(pipe.Halted()).To(BeTrue())
+		cycles := pipe.Stats().Cycles
+		Expect(memory.Read64(0x2000)).To(BeZero())
+		Expect(memory.Read64(0x2008)).To(BeNumerically("<=", cycles))
+		Expect(memory.Read64(0x2008)).To(BeNumerically(">=", cycles-20))
+	})
+
+	It("should charge pointer authentication only when it is enabled", func() {
+		program := []uint32{
+			0xd2801900, // mov  x0, #200
+			0x1000009e, // loop: adr x30, next
+			0xd503233f, // paciasp
+			0xd503201f, // nop
+			0xd65f0bff, // retaa
+			0xf1000400, // next: subs x0, x0, #1
+			0x54ffff61, // b.ne loop
+			0xd2800ba8, // mov  x8, #93
+			0xd4000001, // svc  #0
+		}
+		run := func(opts ...pipeline.PipelineOption) *pipeline.Pipeline {
+			regFile := &emu.RegFile{SP: coreTestStack}
+			memory := emu.NewMemory()
+			loadCoreTestProgram(memory, program)
+			pipe := pipeline.NewPipeline(regFile, memory,
+				append([]pipeline.PipelineOption{pipeline.WithLatencyTable(latency.NewTable())}, opts...)...)
+			pipe.SetPC(coreTestEntry)
+			pipe.RunCycles(100000)
+
+			Expect(pipe.Halted()).To(BeTrue())
+			Expect(pipe.Err()).To(BeNil())
+			Expect(regFile.X[0]).To(BeZero())
+			return pipe
+		}
+
+		nop := run()
+		enabled := run(pipeline.WithPointerAuth(emu.PointerAuthEnabled), pipeline.WithBTI())
+		Expect(enabled.Stats().Cycles).To(BeNumerically(">", nop.Stats().Cycles))
+	})
+
+	It("should halt with an error on an instruction the core cannot execute", func() {
+		regFile := &emu.RegFile{}
+		memory := emu.NewMemory()
+		memory.Write32(coreTestEntry, 0xd2800020)   // mov x0, #1
+		memory.Write32(coreTestEntry+4, 0x00000000) // udf
+
+		pipe := pipeline.NewPipeline(regFile, memory)
+		pipe.SetPC(coreTestEntry)
+		pipe.RunCycles(100)
+
+		Expect(pipe.Halted()).To(BeTrue())
+		Expect(pipe.Err()).To(HaveOccurred())
+		Expect(pipe.ExitCode()).To(Equal(int64(-1)))
+		Expect(regFile.X[0]).To(Equal(uint64(1)))
+		Expect(pipe.Stats().Instructions).To(Equal(uint64(2)))
+	})
+
+	It("should halt with a segmentation fault when protection is enforced", func() {
+		for _, opts := range [][]pipeline.PipelineOption{
+			nil,
+			{pipeline.WithOctupleIssue(), pipeline.WithDefaultCaches()},
+		} {
+			regFile := &emu.RegFile{}
+			memory := emu.NewMemory(emu.WithProtection())
+			memory.Map(coreTestEntry, 0x1000, emu.PROT_READ|emu.PROT_EXEC)
+			loadCoreTestProgram(memory, []uint32{
+				0xd2800201, // mov x1, #16
+				0xf9400020, // ldr x0, [x1]
+				0xd28000a2, // mov x2, #5
+			})
+
+			pipe := pipeline.NewPipeline(regFile, memory, opts...)
+			pipe.SetPC(coreTestEntry)
+			pipe.RunCycles(100)
+
+			Expect(pipe.Halted()).To(BeTrue())
+			Expect(pipe.ExitCode()).To(Equal(int64(-1)))
+
+			var fault *emu.MemoryFault
+			Expect(errors.As(pipe.Err(), &fault)).To(BeTrue())
+			Expect(fault.Addr).To(Equal(uint64(16)))
+			Expect(fault.PC).To(Equal(coreTestEntry + 4))
+			Expect(fault.Access).To(Equal(emu.AccessRead))
+			Expect(regFile.X[2]).To(BeZero())
+		}
+	})
+})
@@ -1,6 +1,8 @@
 package pipeline_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -97,6 +99,41 @@ var _ = Describe("FastTiming", func() {
 			exitCode := ft.Run()
 			Expect(exitCode).To(Equal(int64(-1)))
 		})
+
+		It("should report the unknown instruction that halted it", func() {
+			ft := pipeline.NewFastTiming(regFile, memory, table, syscallHandler)
+			ft.SetPC(0x1000)
+			memory.Write32(0x1000, 0x04000000) // SVE
+
+			Expect(ft.Run()).To(Equal(int64(-1)))
+
+			var err *emu.UnimplementedInstructionError
+			Expect(ft.Err()).To(BeAssignableToTypeOf(err))
+			err = ft.Err().(*emu.UnimplementedInstructionError)
+			Expect(err.PC).To(Equal(uint64(0x1000)))
+			Expect(err.Class).To(Equal("SVE"))
+		})
+	})
+
+	Describe("Faults", func() {
+		It("should stop at a load from an unmapped page", func() {
+			memory = emu.NewMemory(emu.WithProtection())
+			memory.Map(0x1000, 0x1000, emu.PROT_READ|emu.PROT_EXEC)
+			memory.Write32(0x1000, 0xF9400020) // LDR X0, [X1]
+			memory.Write32(0x1004, 0xD2800540) // MOVZ X0, #42
+			regFile.WriteReg(1, 0x900000)
+
+			ft := pipeline.NewFastTiming(regFile, memory, table, syscallHandler)
+			ft.SetPC(0x1000)
+
+			Expect(ft.Run()).To(Equal(int64(-1)))
+			var fault *emu.MemoryFault
+			Expect(errors.As(ft.Err(), &fault)).To(BeTrue())
+			Expect(fault.PC).To(Equal(uint64(0x1000)))
+			Expect(fault.Addr).To(Equal(uint64(0x900000)))
+			Expect(ft.UnhandledCount()).To(BeZero())
+			Expect(regFile.ReadReg(0)).NotTo(Equal(uint64(42)))
+		})
 	})
 
 	Describe("Instruction Execution", func() {
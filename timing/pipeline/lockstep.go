@@ -0,0 +1,318 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/insts"
+)
+
+// maxSkippedBranches bounds how many unconditional branches the reference
+// emulator may step over to catch up with a pipeline that eliminated them
+// at fetch.
+const maxSkippedBranches = 16
+
+// Mismatch is one architectural value that differs between the pipeline and
+// the reference emulator.
+type Mismatch struct {
+	What     string // e.g. "X3", "PSTATE", "V0", "mem write 2"
+	Expected string // value from the reference emulator
+	Actual   string // value from the pipeline
+}
+
+// Divergence describes the first instruction at which the pipeline and the
+// reference emulator disagree.
+type Divergence struct {
+	Cycle       uint64 // pipeline cycle at which the instruction committed
+	Instruction uint64 // number of instructions committed before this one
+	PC          uint64
+	Word        uint32
+	Disasm      string
+	Mismatches  []Mismatch
+}
+
+// Error formats the divergence as a multi-line report.
+func (d *Divergence) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "lockstep divergence at cycle %d, instruction %d\n",
+		d.Cycle, d.Instruction)
+	fmt.Fprintf(&b, "  PC 0x%X: %08x  %s\n", d.PC, d.Word, d.Disasm)
+	for _, m := range d.Mismatches {
+		fmt.Fprintf(&b, "  %-12s expected %s, actual %s\n", m.What+":", m.Expected, m.Actual)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// memWrite is a single memory write observed during one instruction.
+type memWrite struct {
+	addr uint64
+	data []byte
+}
+
+func (w memWrite) String() string {
+	return fmt.Sprintf("[0x%X] <- %x", w.addr, w.data)
+}
+
+// Lockstep runs a functional emulator alongside a Pipeline and checks,
+// every time the pipeline commits an instruction, that PC, X registers, SP,
+// PSTATE, TPIDR_EL0, SIMD registers, FPCR/FPSR and memory writes match the
+// emulator. It stops the pipeline at the first divergence.
+//
+// The reference emulator must start from the same architectural state and
+// memory contents as the pipeline, but use its own RegFile and Memory.
+// Syscalls are not re-executed on the reference: their register result,
+// memory writes and process state (program break, mappings, page
+// protection) are copied from the pipeline, as are generic timer reads.
+type Lockstep struct {
+	pipe *Pipeline
+	ref  *emu.Emulator
+
+	actualWrites   []memWrite
+	expectedWrites []memWrite
+
+	committed  uint64
+	divergence *Divergence
+}
+
+// NewLockstep attaches a reference emulator to the pipeline.
+func NewLockstep(pipe *Pipeline, ref *emu.Emulator) *Lockstep {
+	l := &Lockstep{
+		pipe: pipe,
+		ref:  ref,
+	}
+
+	pipe.memory.SetWriteObserver(func(addr uint64, data []byte) {
+		l.actualWrites = append(l.actualWrites, memWrite{addr, bytes.Clone(data)})
+	})
+	ref.Memory().SetWriteObserver(func(addr uint64, data []byte) {
+		l.expectedWrites = append(l.expectedWrites, memWrite{addr, bytes.Clone(data)})
+	})
+	pipe.executeStage.onCommit = l.onCommit
+
+	return l
+}
+
+// Run runs the pipeline until it halts or diverges from the reference.
+// Returns the exit code (-1 on divergence).
+func (l *Lockstep) Run() int64 {
+	return l.pipe.Run()
+}
+
+// Reference returns the reference emulator.
+func (l *Lockstep) Reference() *emu.Emulator {
+	return l.ref
+}
+
+// Divergence returns the first divergence found, or nil.
+func (l *Lockstep) Divergence() *Divergence {
+	return l.divergence
+}
+
+// Committed returns the number of instructions checked so far.
+func (l *Lockstep) Committed() uint64 {
+	return l.committed
+}
+
+// onCommit checks one committed pipeline instruction against the reference.
+func (l *Lockstep) onCommit(pc uint64, inst *insts.Instruction) {
+	if l.divergence != nil {
+		return
+	}
+
+	refRegs := l.ref.RegFile()
+	d := &Divergence{
+		Cycle:       l.pipe.stats.Cycles,
+		Instruction: l.committed,
+		PC:          pc,
+		Word:        l.pipe.memory.Read32(pc),
+	}
+
+	l.skipEliminatedBranches(pc)
+	if refRegs.PC != pc {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "PC",
+			Expected: fmt.Sprintf("0x%X", refRegs.PC),
+			Actual:   fmt.Sprintf("0x%X", pc),
+		})
+		l.diverge(d)
+		return
+	}
+	if refWord := l.ref.Memory().Read32(pc); refWord != d.Word {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "instruction",
+			Expected: fmt.Sprintf("%08x", refWord),
+			Actual:   fmt.Sprintf("%08x", d.Word),
+		})
+		l.diverge(d)
+		return
+	}
+
+	if inst.Op == insts.OpSVC {
+		// The pipeline already handled the syscall; mirror its effects.
+		for _, w := range l.actualWrites {
+			l.ref.Memory().LoadProgram(w.addr, w.data)
+		}
+		refRegs.X[0] = l.pipe.regFile.X[0]
+		refRegs.PC += 4
+		l.copyProcessState()
+	} else {
+		l.ref.Step()
+		if readsCounter(inst) {
+			// The generic timer counts pipeline cycles, which the
+			// reference does not model.
+			refRegs.WriteReg(inst.Rd, l.pipe.regFile.ReadReg(inst.Rd))
+		}
+	}
+
+	l.compareState(d)
+	if len(d.Mismatches) > 0 {
+		l.diverge(d)
+		return
+	}
+
+	l.actualWrites = l.actualWrites[:0]
+	l.expectedWrites = l.expectedWrites[:0]
+	l.committed++
+}
+
+// copyProcessState gives the reference's syscall handler the process state
+// the pipeline's syscalls built, such as the program break, the mappings and
+// the page protection, when both use the default handler.
+func (l *Lockstep) copyProcessState() {
+	src, ok := l.pipe.syscallHandler.(*emu.DefaultSyscallHandler)
+	if !ok {
+		return
+	}
+	if dst, ok := l.ref.SyscallHandler().(*emu.DefaultSyscallHandler); ok {
+		dst.CopyProcessState(src)
+	}
+}
+
+// readsCounter reports whether inst reads the generic timer count.
+func readsCounter(inst *insts.Instruction) bool {
+	return inst.Op == insts.OpMRS &&
+		(inst.SysReg == insts.SysRegCNTVCT || inst.SysReg == insts.SysRegCNTPCT)
+}
+
+// skipEliminatedBranches steps the reference over unconditional branches
+// that the pipeline removed at fetch and never committed.
+func (l *Lockstep) skipEliminatedBranches(pc uint64) {
+	refRegs := l.ref.RegFile()
+	for i := 0; i < maxSkippedBranches && refRegs.PC != pc; i++ {
+		word := l.ref.Memory().Read32(refRegs.PC)
+		if !isEliminableBranch(word) {
+			return
+		}
+		l.ref.Step()
+	}
+}
+
+// compareState records every architectural difference after an instruction.
+func (l *Lockstep) compareState(d *Divergence) {
+	actual := l.pipe.regFile
+	expected := l.ref.RegFile()
+
+	for i := 0; i < 31; i++ {
+		if actual.X[i] != expected.X[i] {
+			d.Mismatches = append(d.Mismatches, Mismatch{
+				What:     fmt.Sprintf("X%d", i),
+				Expected: fmt.Sprintf("0x%X", expected.X[i]),
+				Actual:   fmt.Sprintf("0x%X", actual.X[i]),
+			})
+		}
+	}
+	if actual.SP != expected.SP {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "SP",
+			Expected: fmt.Sprintf("0x%X", expected.SP),
+			Actual:   fmt.Sprintf("0x%X", actual.SP),
+		})
+	}
+	if actual.PSTATE != expected.PSTATE {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "PSTATE",
+			Expected: formatNZCV(expected.PSTATE),
+			Actual:   formatNZCV(actual.PSTATE),
+		})
+	}
+
+	if actualTP, expectedTP := l.pipe.core.SysRegFile().TPIDR, l.ref.SysRegFile().TPIDR; actualTP != expectedTP {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "TPIDR_EL0",
+			Expected: fmt.Sprintf("0x%X", expectedTP),
+			Actual:   fmt.Sprintf("0x%X", actualTP),
+		})
+	}
+
+	actualV := l.pipe.SIMDRegFile()
+	expectedV := l.ref.SIMDRegFile()
+	for i := range actualV.V {
+		if actualV.V[i] != expectedV.V[i] {
+			d.Mismatches = append(d.Mismatches, Mismatch{
+				What:     fmt.Sprintf("V%d", i),
+				Expected: fmt.Sprintf("0x%016X%016X", expectedV.V[i][1], expectedV.V[i][0]),
+				Actual:   fmt.Sprintf("0x%016X%016X", actualV.V[i][1], actualV.V[i][0]),
+			})
+		}
+	}
+	if actualV.FPCR != expectedV.FPCR {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "FPCR",
+			Expected: fmt.Sprintf("0x%X", expectedV.FPCR),
+			Actual:   fmt.Sprintf("0x%X", actualV.FPCR),
+		})
+	}
+	if actualV.FPSR != expectedV.FPSR {
+		d.Mismatches = append(d.Mismatches, Mismatch{
+			What:     "FPSR",
+			Expected: fmt.Sprintf("0x%X", expectedV.FPSR),
+			Actual:   fmt.Sprintf("0x%X", actualV.FPSR),
+		})
+	}
+
+	n := max(len(l.actualWrites), len(l.expectedWrites))
+	for i := 0; i < n; i++ {
+		expectedWrite, actualWrite := "none", "none"
+		if i < len(l.expectedWrites) {
+			expectedWrite = l.expectedWrites[i].String()
+		}
+		if i < len(l.actualWrites) {
+			actualWrite = l.actualWrites[i].String()
+		}
+		if expectedWrite != actualWrite {
+			d.Mismatches = append(d.Mismatches, Mismatch{
+				What:     fmt.Sprintf("mem write %d", i),
+				Expected: expectedWrite,
+				Actual:   actualWrite,
+			})
+		}
+	}
+}
+
+// diverge records the divergence and stops the pipeline.
+func (l *Lockstep) diverge(d *Divergence) {
+	d.Disasm = insts.Disassemble(insts.NewDecoder().Decode(d.Word), d.PC)
+	l.divergence = d
+	l.pipe.executeStage.stop()
+	l.pipe.halted = true
+	l.pipe.exitCode = -1
+	l.pipe.err = d
+}
+
+// formatNZCV formats condition flags as in "nZCv" (upper case = set),
+// followed by the branch type if one is pending.
+func formatNZCV(p emu.PSTATE) string {
+	flag := func(set bool, name byte) byte {
+		if set {
+			return name
+		}
+		return name + ('a' - 'A')
+	}
+	s := string([]byte{flag(p.N, 'N'), flag(p.Z, 'Z'), flag(p.C, 'C'), flag(p.V, 'V')})
+	if p.BTYPE != 0 {
+		s += fmt.Sprintf(" BTYPE=%d", p.BTYPE)
+	}
+	return s
+}
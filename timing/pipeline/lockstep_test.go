@@ -0,0 +1,216 @@
+package pipeline_test
+
+import (
+	"errors"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/sarchlab/m2sim/emu"
+	"github.com/sarchlab/m2sim/timing/pipeline"
+)
+
+// storeProgram stores a computed value, reads it back and exits with it.
+var storeProgram = []uint32{
+	0xd10043ff, // sub  sp, sp, #16
+	0xd2800541, // mov  x1, #42
+	0xf90007e1, // str  x1, [sp, #8]
+	0xf94007e0, // ldr  x0, [sp, #8]
+	0x910043ff, // add  sp, sp, #16
+	0xd2800ba8, // mov  x8, #93
+	0xd4000001, // svc  #0
+}
+
+var _ = Describe("Lockstep", func() {
+	// newLockstep builds a pipeline running pipeProgram and a reference
+	// emulator running refProgram from identical initial state. It also
+	// returns the pipeline's register file.
+	newLockstep := func(pipeProgram, refProgram []uint32,
+		opts ...pipeline.PipelineOption,
+	) (*pipeline.Pipeline, *pipeline.Lockstep, *emu.RegFile) {
+		regFile := &emu.RegFile{SP: coreTestStack}
+		memory := emu.NewMemory()
+		loadCoreTestProgram(memory, pipeProgram)
+
+		refMemory := emu.NewMemory()
+		loadCoreTestProgram(refMemory, refProgram)
+		ref := emu.NewEmulator(
+			emu.WithMemory(refMemory),
+			emu.WithStackPointer(coreTestStack),
+			emu.WithStdout(io.Discard),
+		)
+		ref.RegFile().PC = coreTestEntry
+
+		pipe := pipeline.NewPipeline(regFile, memory, opts...)
+		pipe.SetPC(coreTestEntry)
+
+		return pipe, pipeline.NewLockstep(pipe, ref), regFile
+	}
+
+	It("should run to completion when the pipeline matches the emulator", func() {
+		_, checker, _ := newLockstep(sumProgram, sumProgram,
+			pipeline.WithOctupleIssue(), pipeline.WithDefaultCaches())
+
+		Expect(checker.Run()).To(Equal(int64(45)))
+		Expect(checker.Divergence()).To(BeNil())
+		Expect(checker.Committed()).To(BeNumerically(">", 50))
+	})
+
+	It("should step over branches eliminated at fetch", func() {
+		program := []uint32{
+			0xd2800020, // mov x0, #1
+			0x14000002, // b   +8
+			0xd2800040, // mov x0, #2 (skipped)
+			0xd2800ba8, // mov x8, #93
+			0xd4000001, // svc #0
+		}
+		_, checker, _ := newLockstep(program, program, pipeline.WithQuadIssue())
+
+		Expect(checker.Run()).To(Equal(int64(1)))
+		Expect(checker.Divergence()).To(BeNil())
+	})
+
+	It("should copy generic timer reads and compare the thread pointer", func() {
+		program := []uint32{
+			0xd2817700, // mov  x0, #3000
+			0x9b010421, // loop: madd x1, x1, x1, x1
+			0x9b010421, // madd x1, x1, x1, x1
+			0x9b010421, // madd x1, x1, x1, x1
+			0xf1000400, // subs x0, x0, #1
+			0x54ffff81, // b.ne loop
+			0xd53be042, // mrs  x2, cntvct_el0
+			0xd51bd042, // msr  tpidr_el0, x2
+			0xd53bd043, // mrs  x3, tpidr_el0
+			0xd2800ba8, // mov  x8, #93
+			0xd4000001, // svc  #0
+		}
+		_, checker, regFile := newLockstep(program, program, pipeline.WithDefaultCaches())
+
+		Expect(checker.Run()).To(Equal(int64(0)))
+		Expect(checker.Divergence()).To(BeNil())
+		Expect(regFile.X[3]).NotTo(BeZero())
+		Expect(checker.Reference().SysRegFile().TPIDR).To(Equal(regFile.X[3]))
+	})
+
+	It("should give the reference the process state syscalls build", func() {
+		program := []uint32{
+			0xd2800000, // mov x0, #0
+			0xd2820001, // mov x1, #0x1000
+			0xd2800062, // mov x2, #3 (PROT_READ | PROT_WRITE)
+			0xd2800443, // mov x3, #0x22 (MAP_PRIVATE | MAP_ANONYMOUS)
+			0x92800004, // mov x4, #-1
+			0xd2800005, // mov x5, #0
+			0xd2801bc8, // mov x8, #222 (mmap)
+			0xd4000001, // svc #0
+			0xd2800000, // mov x0, #0
+			0xd2801ac8, // mov x8, #214 (brk)
+			0xd4000001, // svc #0
+			0x91400800, // add x0, x0, #2, lsl #12
+			0xd4000001, // svc #0
+			0xd2800000, // mov x0, #0
+			0xd2800ba8, // mov x8, #93
+			0xd4000001, // svc #0
+		}
+		_, checker, _ := newLockstep(program, program)
+
+		Expect(checker.Run()).To(Equal(int64(0)))
+		Expect(checker.Divergence()).To(BeNil())
+
+		ref := checker.Reference()
+		handler := ref.SyscallHandler().(*emu.DefaultSyscallHandler)
+		Expect(handler.GetProgramBreak()).To(Equal(emu.DefaultProgramBreak + 0x2000))
+		vma, ok := handler.AddressSpace().Find(emu.DefaultMmapBase)
+		Expect(ok).To(BeTrue())
+		Expect(vma.Prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+		prot, ok := ref.Memory().Protection(emu.DefaultMmapBase)
+		Expect(ok).To(BeTrue())
+		Expect(prot).To(Equal(emu.PROT_READ | emu.PROT_WRITE))
+	})
+
+	It("should stop at the first register divergence", func() {
+		// The literal loaded into X1 differs between the two memories.
+		program := []uint32{
+			0xd2800020, // mov x0, #1
+			0x58000061, // ldr x1, literal
+			0xd2800ba8, // mov x8, #93
+			0xd4000001, // svc #0
+			42, 0,      // literal
+		}
+		refProgram := append([]uint32(nil), program...)
+		refProgram[4] = 43
+
+		pipe, checker, _ := newLockstep(program, refProgram)
+
+		Expect(checker.Run()).To(Equal(int64(-1)))
+		d := checker.Divergence()
+		Expect(d).NotTo(BeNil())
+		Expect(d.PC).To(Equal(coreTestEntry + 4))
+		Expect(d.Word).To(Equal(program[1]))
+		Expect(d.Disasm).To(Equal("ldr x1, 0x1010"))
+		Expect(d.Instruction).To(Equal(uint64(1)))
+		Expect(d.Cycle).To(BeNumerically(">", 0))
+		Expect(d.Mismatches).To(Equal([]pipeline.Mismatch{
+			{What: "X1", Expected: "0x2B", Actual: "0x2A"},
+		}))
+
+		Expect(pipe.Halted()).To(BeTrue())
+		var err *pipeline.Divergence
+		Expect(errors.As(pipe.Err(), &err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("X1:"))
+	})
+
+	It("should report a diverging memory write", func() {
+		_, checker, regFile := newLockstep(storeProgram[2:], storeProgram[2:])
+		regFile.X[1] = 42
+		checker.Reference().RegFile().X[1] = 43
+
+		checker.Run()
+
+		d := checker.Divergence()
+		Expect(d).NotTo(BeNil())
+		Expect(d.PC).To(Equal(coreTestEntry))
+		Expect(d.Mismatches).To(ContainElement(pipeline.Mismatch{
+			What:     "mem write 0",
+			Expected: "[0x80008] <- 2b00000000000000",
+			Actual:   "[0x80008] <- 2a00000000000000",
+		}))
+	})
+
+	It("should report a diverging instruction stream", func() {
+		refProgram := append([]uint32(nil), storeProgram...)
+		refProgram[1] = 0x14000002 // b +8
+
+		_, checker, _ := newLockstep(storeProgram, refProgram, pipeline.WithDualIssue())
+		checker.Run()
+
+		d := checker.Divergence()
+		Expect(d).NotTo(BeNil())
+		Expect(d.PC).To(Equal(coreTestEntry + 4))
+		Expect(d.Mismatches).To(Equal([]pipeline.Mismatch{
+			{What: "instruction", Expected: "14000002", Actual: "d2800541"},
+		}))
+	})
+
+	It("should report a diverging PC", func() {
+		regFile := &emu.RegFile{SP: coreTestStack}
+		memory := emu.NewMemory()
+		loadCoreTestProgram(memory, storeProgram)
+		pipe := pipeline.NewPipeline(regFile, memory)
+		pipe.SetPC(coreTestEntry)
+
+		ref := emu.NewEmulator(emu.WithStackPointer(coreTestStack))
+		loadCoreTestProgram(ref.Memory(), storeProgram)
+		ref.RegFile().PC = coreTestEntry + 4
+
+		checker := pipeline.NewLockstep(pipe, ref)
+		checker.Run()
+
+		d := checker.Divergence()
+		Expect(d).NotTo(BeNil())
+		Expect(d.PC).To(Equal(coreTestEntry))
+		Expect(d.Mismatches).To(Equal([]pipeline.Mismatch{
+			{What: "PC", Expected: "0x1004", Actual: "0x1000"},
+		}))
+	})
+})
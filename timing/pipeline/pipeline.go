@@ -151,7 +151,7 @@ type Statistics struct {
 	Instructions uint64
 	// Stalls is the number of stall cycles.
 	Stalls uint64
-	// Flushes is the number of pipeline flushes (due to branch mispredictions).
+	// Flushes is the number of pipeline flushes (due to branch mispredictions and ISB).
 	Flushes uint64
 	// ExecStalls is the number of stalls due to multi-cycle execution.
 	ExecStalls uint64
@@ -195,6 +195,47 @@ func WithSyscallHandler(handler emu.SyscallHandler) PipelineOption {
 	}
 }
 
+// WithSymbolizer names the location of unimplemented instructions in the
+// errors the pipeline halts with.
+func WithSymbolizer(symbolizer emu.Symbolizer) PipelineOption {
+	return func(p *Pipeline) {
+		p.coreOpts = append(p.coreOpts, emu.WithSymbolizer(symbolizer))
+	}
+}
+
+// WithUnimplementedReport records unimplemented instructions in report and
+// executes them as NOPs instead of halting.
+func WithUnimplementedReport(report *emu.UnimplementedReport) PipelineOption {
+	return func(p *Pipeline) {
+		p.coreOpts = append(p.coreOpts, emu.WithUnimplementedReport(report))
+	}
+}
+
+// WithPointerAuth selects how the pipeline's core executes pointer
+// authentication instructions. With emu.PointerAuthEnabled, the latency
+// table charges PAC computation.
+func WithPointerAuth(mode emu.PointerAuthMode) PipelineOption {
+	return func(p *Pipeline) {
+		p.coreOpts = append(p.coreOpts, emu.WithPointerAuth(mode))
+	}
+}
+
+// WithBTI makes the pipeline's core enforce branch target identification.
+func WithBTI() PipelineOption {
+	return func(p *Pipeline) {
+		p.coreOpts = append(p.coreOpts, emu.WithBTI())
+	}
+}
+
+// WithCoreFrequency sets the core frequency in Hz at which the program's
+// clock, read by the generic timer and the time syscalls, converts
+// simulated cycles to time.
+func WithCoreFrequency(hz uint64) PipelineOption {
+	return func(p *Pipeline) {
+		p.coreOpts = append(p.coreOpts, emu.WithCoreFrequency(hz))
+	}
+}
+
 // WithLatencyTable sets a custom latency table for instruction timing.
 // When set, multi-cycle operations will stall the pipeline appropriately.
 func WithLatencyTable(table *latency.Table) PipelineOption {
@@ -216,14 +257,7 @@ func WithICache(config cache.Config) PipelineOption {
 // WithDCache enables L1 data cache with the given configuration.
 func WithDCache(config cache.Config) PipelineOption {
 	return func(p *Pipeline) {
-		backing := cache.NewMemoryBacking(p.memory)
-		dcache := cache.New(config, backing)
-		// Share one D-cache across all 3 memory ports (coherent).
-		// Each CachedMemoryStage tracks its own pending/stall state.
-		p.cachedMemoryStage = NewCachedMemoryStage(dcache, p.memory)
-		p.cachedMemoryStage2 = NewCachedMemoryStage(dcache, p.memory)
-		p.cachedMemoryStage3 = NewCachedMemoryStage(dcache, p.memory)
-		p.useDCache = true
+		p.attachDCache(cache.New(config, newDataCacheBacking(p.memory)))
 	}
 }
 
@@ -237,14 +271,22 @@ func WithDefaultCaches() PipelineOption {
 		p.useICache = true
 
 		// Initialize D-cache — single shared cache, 3 port stages (coherent)
-		dcache := cache.New(cache.DefaultL1DConfig(), backing)
-		p.cachedMemoryStage = NewCachedMemoryStage(dcache, p.memory)
-		p.cachedMemoryStage2 = NewCachedMemoryStage(dcache, p.memory)
-		p.cachedMemoryStage3 = NewCachedMemoryStage(dcache, p.memory)
-		p.useDCache = true
+		p.attachDCache(cache.New(cache.DefaultL1DConfig(), newDataCacheBacking(p.memory)))
 	}
 }
 
+// attachDCache sets up the memory ports on the given D-cache.
+func (p *Pipeline) attachDCache(dcache *cache.Cache) {
+	// Share one D-cache and store buffer across all 3 memory ports
+	// (coherent). Each CachedMemoryStage tracks its own pending/stall state.
+	p.cachedMemoryStage = NewCachedMemoryStage(dcache, p.memory)
+	p.cachedMemoryStage2 = NewCachedMemoryStage(dcache, p.memory)
+	p.cachedMemoryStage3 = NewCachedMemoryStage(dcache, p.memory)
+	p.cachedMemoryStage2.shareStoreBuffer(p.cachedMemoryStage)
+	p.cachedMemoryStage3.shareStoreBuffer(p.cachedMemoryStage)
+	p.useDCache = true
+}
+
 // WithBranchPredictorConfig sets a custom branch predictor configuration.
 // This allows tuning BTB size, BHT size, global history length, etc.
 func WithBranchPredictorConfig(config BranchPredictorConfig) PipelineOption {
@@ -351,6 +393,10 @@ type Pipeline struct {
 	regFile *emu.RegFile
 	memory  *emu.Memory
 
+	// Execution core shared with the functional emulator
+	core     *emu.Emulator
+	coreOpts []emu.EmulatorOption
+
 	// Syscall handling
 	syscallHandler emu.SyscallHandler
 
@@ -370,6 +416,7 @@ type Pipeline struct {
 	// Execution state
 	halted   bool
 	exitCode int64
+	err      error
 }
 
 // NewPipeline creates a new 5-stage pipeline.
@@ -398,6 +445,21 @@ func NewPipeline(regFile *emu.RegFile, memory *emu.Memory, opts ...PipelineOptio
 		p.syscallHandler = emu.NewDefaultSyscallHandler(regFile, memory, nil, nil)
 	}
 
+	// Architectural results come from the emulator's execution core, which
+	// commits them in the execute stage. The memory and writeback stages then
+	// only model timing.
+	p.core = emu.NewEmulator(append([]emu.EmulatorOption{
+		emu.WithRegFile(regFile),
+		emu.WithMemory(memory),
+		emu.WithSyscallHandler(p.syscallHandler),
+		emu.WithCycleCounter(func() uint64 { return p.stats.Cycles }),
+	}, p.coreOpts...)...)
+	attachInstructionCounter(p.syscallHandler, func() uint64 { return p.stats.Instructions })
+	p.executeStage.attachCore(p.core, p.decodeStage)
+	p.SetLatencyTable(p.latencyTable)
+	p.memoryStage.timingOnly = true
+	p.writebackStage.timingOnly = true
+
 	return p
 }
 
@@ -447,6 +509,16 @@ func (p *Pipeline) ExitCode() int64 {
 	return p.exitCode
 }
 
+// Err returns the error that halted the pipeline, if any.
+func (p *Pipeline) Err() error {
+	return p.err
+}
+
+// SIMDRegFile returns the SIMD register file used by the execution core.
+func (p *Pipeline) SIMDRegFile() *emu.SIMDRegFile {
+	return p.core.SIMDRegFile()
+}
+
 // Run executes the pipeline until it halts.
 // Returns the exit code.
 func (p *Pipeline) Run() int64 {
@@ -523,27 +595,82 @@ func (p *Pipeline) Tick() {
 	}
 
 	p.stats.Cycles++
+	if p.cachedMemoryStage != nil {
+		p.cachedMemoryStage.stores.tick()
+	}
 
 	// Use superscalar tick if multi-issue is enabled
-	if p.superscalarConfig.IssueWidth >= 8 {
+	switch {
+	case p.superscalarConfig.IssueWidth >= 8:
 		p.tickOctupleIssue()
-		return
-	}
-	if p.superscalarConfig.IssueWidth >= 6 {
+	case p.superscalarConfig.IssueWidth >= 6:
 		p.tickSextupleIssue()
-		return
-	}
-	if p.superscalarConfig.IssueWidth >= 4 {
+	case p.superscalarConfig.IssueWidth >= 4:
 		p.tickQuadIssue()
-		return
-	}
-	if p.superscalarConfig.IssueWidth >= 2 {
+	case p.superscalarConfig.IssueWidth >= 2:
 		p.tickSuperscalar()
-		return
+	default:
+		// Single-issue tick (original implementation)
+		p.tickSingleIssue()
+	}
+
+	// An ISB refetches the instructions after it once it has left execute.
+	if pc, ok := p.executeStage.pendingSync(); ok && p.exmem.Valid && p.exmem.PC == pc {
+		p.executeStage.clearSync()
+		p.pc = pc + 4
+		p.flushAllIFID()
+		p.flushAllIDEX()
+		p.stats.Flushes++
 	}
 
-	// Single-issue tick (original implementation)
-	p.tickSingleIssue()
+	// A BRK trap or an instruction the core cannot execute stops the
+	// pipeline, matching Emulator.Run.
+	if result, pc, ok := p.executeStage.exitResult(); ok && !p.halted {
+		p.retireInFlight(pc)
+		p.halted = true
+		p.exitCode = -1
+		if result.Exited {
+			p.exitCode = result.ExitCode
+		}
+		p.err = result.Err
+	}
+}
+
+// retireInFlight counts the instructions up to and including the trapping
+// one at trapPC as retired when the pipeline halts on a trap. They have
+// already executed on the core but have not reached writeback yet.
+func (p *Pipeline) retireInFlight(trapPC uint64) {
+	memwb := []WritebackSlot{&p.memwb, &p.memwb2, &p.memwb3, &p.memwb4,
+		&p.memwb5, &p.memwb6, &p.memwb7, &p.memwb8}
+	exmem := []MemorySlot{&p.exmem, &p.exmem2, &p.exmem3, &p.exmem4,
+		&p.exmem5, &p.exmem6, &p.exmem7, &p.exmem8}
+
+	slots := 1
+	switch {
+	case p.superscalarConfig.IssueWidth >= 8:
+		slots = 8
+	case p.superscalarConfig.IssueWidth >= 6:
+		slots = 6
+	case p.superscalarConfig.IssueWidth >= 4:
+		slots = 4
+	case p.superscalarConfig.IssueWidth >= 2:
+		slots = 2
+	}
+
+	for _, slot := range memwb[:slots] {
+		if slot.IsValid() {
+			p.stats.Instructions++
+		}
+	}
+	for _, slot := range exmem[:slots] {
+		if !slot.IsValid() {
+			continue
+		}
+		p.stats.Instructions++
+		if slot.GetPC() == trapPC {
+			break
+		}
+	}
 }
 
 // tickSingleIssue is the original single-issue pipeline tick.
@@ -612,8 +739,10 @@ func (p *Pipeline) tickSingleIssue() {
 				if result.Exited {
 					p.halted = true
 					p.exitCode = result.ExitCode
+					p.executeStage.stop()
 				}
 			}
+			p.executeStage.commit(p.exmem.PC, p.exmem.Inst)
 		}
 
 		var memResult MemoryResult
@@ -938,8 +1067,10 @@ func (p *Pipeline) tickSuperscalar() {
 				if result.Exited {
 					p.halted = true
 					p.exitCode = result.ExitCode
+					p.executeStage.stop()
 				}
 			}
+			p.executeStage.commit(p.exmem.PC, p.exmem.Inst)
 		}
 
 		var memResult MemoryResult
@@ -1285,6 +1416,9 @@ func (p *Pipeline) tickSuperscalar() {
 
 	stallResult := p.hazardUnit.ComputeStalls(loadUseHazard || execStall || memStall, false)
 
+	// Decodes are discarded if fetch stalls below; remember the pool position.
+	decodeMark := p.decodeStage.checkpoint()
+
 	// Stage 2: Decode (both slots)
 	var nextIDEX IDEXRegister
 	var nextIDEX2 SecondaryIDEXRegister
@@ -1528,6 +1662,7 @@ func (p *Pipeline) tickSuperscalar() {
 					}
 				}
 			} else if fetchStall {
+				p.decodeStage.rewind(decodeMark)
 				nextIFID = p.ifid
 				nextIFID2 = p.ifid2
 				// When fetch stalls, we must stall the entire pipeline to prevent
@@ -1608,8 +1743,10 @@ func (p *Pipeline) tickQuadIssue() {
 				if result.Exited {
 					p.halted = true
 					p.exitCode = result.ExitCode
+					p.executeStage.stop()
 				}
 			}
+			p.executeStage.commit(p.exmem.PC, p.exmem.Inst)
 		}
 
 		var memResult MemoryResult
@@ -2077,6 +2214,9 @@ func (p *Pipeline) tickQuadIssue() {
 
 	stallResult := p.hazardUnit.ComputeStalls(loadUseHazard || execStall || memStall, false)
 
+	// Decodes are discarded if fetch stalls below; remember the pool position.
+	decodeMark := p.decodeStage.checkpoint()
+
 	// Stage 2: Decode (all 4 slots)
 	var nextIDEX IDEXRegister
 	var nextIDEX2 SecondaryIDEXRegister
@@ -2242,17 +2382,8 @@ func (p *Pipeline) tickQuadIssue() {
 			}
 			switch slotIdx {
 			case 0:
-				// Apply branch prediction when placing in primary slot
-				isUncondBranch, uncondTarget := isUnconditionalBranch(pending.Word, pending.PC)
-				pred := p.branchPredictor.Predict(pending.PC)
-				earlyResolved := false
-				if isUncondBranch {
-					pred.Taken = true
-					pred.Target = uncondTarget
-					pred.TargetKnown = true
-					earlyResolved = true
-				}
-				enrichPredictionWithEncodedTarget(&pred, pending.Word, pending.PC)
+				pred := pending.prediction()
+				earlyResolved := pending.EarlyResolved
 				nextIFID = IFIDRegister{
 					Valid:           true,
 					PC:              pending.PC,
@@ -2267,16 +2398,8 @@ func (p *Pipeline) tickQuadIssue() {
 					branchPredictedTaken = true
 				}
 			default:
-				isUncondBranch, uncondTarget := isUnconditionalBranch(pending.Word, pending.PC)
-				pred := p.branchPredictor.Predict(pending.PC)
-				earlyResolved := false
-				if isUncondBranch {
-					pred.Taken = true
-					pred.Target = uncondTarget
-					pred.TargetKnown = true
-					earlyResolved = true
-				}
-				enrichPredictionWithEncodedTarget(&pred, pending.Word, pending.PC)
+				pred := pending.prediction()
+				earlyResolved := pending.EarlyResolved
 				switch slotIdx {
 				case 1:
 					nextIFID2 = SecondaryIFIDRegister{Valid: true, PC: pending.PC, InstructionWord: pending.Word, PredictedTaken: pred.Taken, PredictedTarget: pred.Target, EarlyResolved: earlyResolved}
@@ -2294,6 +2417,7 @@ func (p *Pipeline) tickQuadIssue() {
 		}
 
 		// Fetch new instructions to fill remaining slots
+		eliminated := 0
 		for slotIdx < 4 {
 			var word uint32
 			var ok bool
@@ -2315,6 +2439,12 @@ func (p *Pipeline) tickQuadIssue() {
 			// Branch elimination: unconditional B (not BL) instructions are
 			// eliminated at fetch time. They never enter the pipeline.
 			if isEliminableBranch(word) {
+				// A loop of eliminated branches (e.g. "b .") never fills a
+				// slot; stop following them once the group is exhausted.
+				if eliminated == 4 {
+					break
+				}
+				eliminated++
 				_, uncondTarget := isUnconditionalBranch(word, fetchPC)
 				fetchPC = uncondTarget
 				p.stats.EliminatedBranches++
@@ -2379,9 +2509,10 @@ func (p *Pipeline) tickQuadIssue() {
 			fetchPC += 4
 			slotIdx++
 		}
-		p.pc = fetchPC
-
 		if fetchStall {
+			// The held IF/ID registers still describe the fetch group, so
+			// the fetch PC must not move past it.
+			p.decodeStage.rewind(decodeMark)
 			// Preserve all pipeline state on fetch stall
 			nextIFID = p.ifid
 			nextIFID2 = p.ifid2
@@ -2395,6 +2526,8 @@ func (p *Pipeline) tickQuadIssue() {
 			nextEXMEM2 = p.exmem2
 			nextEXMEM3 = p.exmem3
 			nextEXMEM4 = p.exmem4
+		} else {
+			p.pc = fetchPC
 		}
 	} else if (stallResult.StallIF || memStall || execStall) && !stallResult.FlushIF {
 		nextIFID = p.ifid
@@ -2445,6 +2578,23 @@ func (p *Pipeline) tickQuadIssue() {
 type pendingFetchInst struct {
 	PC   uint64
 	Word uint32
+
+	// The prediction made when the instruction was fetched. The
+	// instructions fetched after it followed this prediction, so it must
+	// not be re-evaluated while the instruction waits to issue.
+	PredictedTaken  bool
+	PredictedTarget uint64
+	EarlyResolved   bool
+}
+
+// prediction rebuilds the fetch-time prediction. Fetch only follows a taken
+// prediction whose target is known, and an unknown target is recorded as 0.
+func (f pendingFetchInst) prediction() Prediction {
+	return Prediction{
+		Taken:       f.PredictedTaken,
+		Target:      f.PredictedTarget,
+		TargetKnown: f.PredictedTarget != 0,
+	}
 }
 
 // collectPendingFetchInstructions returns unissued instructions that need to remain in fetch.
@@ -2457,19 +2607,19 @@ func (p *Pipeline) collectPendingFetchInstructions(issueCount int) ([8]pendingFe
 	count := 0
 
 	if p.ifid.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid.PC, Word: p.ifid.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid.PC, Word: p.ifid.InstructionWord, PredictedTaken: p.ifid.PredictedTaken, PredictedTarget: p.ifid.PredictedTarget, EarlyResolved: p.ifid.EarlyResolved}
 		count++
 	}
 	if p.ifid2.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid2.PC, Word: p.ifid2.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid2.PC, Word: p.ifid2.InstructionWord, PredictedTaken: p.ifid2.PredictedTaken, PredictedTarget: p.ifid2.PredictedTarget, EarlyResolved: p.ifid2.EarlyResolved}
 		count++
 	}
 	if p.ifid3.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid3.PC, Word: p.ifid3.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid3.PC, Word: p.ifid3.InstructionWord, PredictedTaken: p.ifid3.PredictedTaken, PredictedTarget: p.ifid3.PredictedTarget, EarlyResolved: p.ifid3.EarlyResolved}
 		count++
 	}
 	if p.ifid4.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid4.PC, Word: p.ifid4.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid4.PC, Word: p.ifid4.InstructionWord, PredictedTaken: p.ifid4.PredictedTaken, PredictedTarget: p.ifid4.PredictedTarget, EarlyResolved: p.ifid4.EarlyResolved}
 		count++
 	}
 
@@ -2635,8 +2785,10 @@ func (p *Pipeline) tickSextupleIssue() {
 				if result.Exited {
 					p.halted = true
 					p.exitCode = result.ExitCode
+					p.executeStage.stop()
 				}
 			}
+			p.executeStage.commit(p.exmem.PC, p.exmem.Inst)
 		}
 
 		var memResult MemoryResult
@@ -3255,6 +3407,9 @@ func (p *Pipeline) tickSextupleIssue() {
 
 	stallResult := p.hazardUnit.ComputeStalls(loadUseHazard || execStall || memStall, false)
 
+	// Decodes are discarded if fetch stalls below; remember the pool position.
+	decodeMark := p.decodeStage.checkpoint()
+
 	// Stage 2: Decode (all 6 slots)
 	var nextIDEX IDEXRegister
 	var nextIDEX2 SecondaryIDEXRegister
@@ -3294,6 +3449,7 @@ func (p *Pipeline) tickSextupleIssue() {
 					EarlyResolved:   p.ifid2.EarlyResolved,
 					// Fusion fields from CMP
 					IsFused:    true,
+					FusedInst:  decResult.Inst,
 					FusedRnVal: decResult.RnValue,
 					FusedRmVal: decResult.RmValue,
 					FusedIs64:  decResult.Inst.Is64Bit,
@@ -3544,16 +3700,8 @@ func (p *Pipeline) tickSextupleIssue() {
 			}
 			switch slotIdx {
 			case 0:
-				isUncondBranch, uncondTarget := isUnconditionalBranch(pending.Word, pending.PC)
-				pred := p.branchPredictor.Predict(pending.PC)
-				earlyResolved := false
-				if isUncondBranch {
-					pred.Taken = true
-					pred.Target = uncondTarget
-					pred.TargetKnown = true
-					earlyResolved = true
-				}
-				enrichPredictionWithEncodedTarget(&pred, pending.Word, pending.PC)
+				pred := pending.prediction()
+				earlyResolved := pending.EarlyResolved
 				nextIFID = IFIDRegister{
 					Valid:           true,
 					PC:              pending.PC,
@@ -3567,16 +3715,8 @@ func (p *Pipeline) tickSextupleIssue() {
 					branchPredictedTaken = true
 				}
 			default:
-				isUncondBranch, uncondTarget := isUnconditionalBranch(pending.Word, pending.PC)
-				pred := p.branchPredictor.Predict(pending.PC)
-				earlyResolved := false
-				if isUncondBranch {
-					pred.Taken = true
-					pred.Target = uncondTarget
-					pred.TargetKnown = true
-					earlyResolved = true
-				}
-				enrichPredictionWithEncodedTarget(&pred, pending.Word, pending.PC)
+				pred := pending.prediction()
+				earlyResolved := pending.EarlyResolved
 				switch slotIdx {
 				case 1:
 					nextIFID2 = SecondaryIFIDRegister{Valid: true, PC: pending.PC, InstructionWord: pending.Word, PredictedTaken: pred.Taken, PredictedTarget: pred.Target, EarlyResolved: earlyResolved}
@@ -3598,6 +3738,7 @@ func (p *Pipeline) tickSextupleIssue() {
 		}
 
 		// Fetch new instructions to fill remaining slots
+		eliminated := 0
 		for slotIdx < 6 {
 			var word uint32
 			var ok bool
@@ -3619,6 +3760,12 @@ func (p *Pipeline) tickSextupleIssue() {
 			// Branch elimination: unconditional B (not BL) instructions are
 			// eliminated at fetch time. They never enter the pipeline.
 			if isEliminableBranch(word) {
+				// A loop of eliminated branches (e.g. "b .") never fills a
+				// slot; stop following them once the group is exhausted.
+				if eliminated == 6 {
+					break
+				}
+				eliminated++
 				_, uncondTarget := isUnconditionalBranch(word, fetchPC)
 				fetchPC = uncondTarget
 				p.stats.EliminatedBranches++
@@ -3683,9 +3830,10 @@ func (p *Pipeline) tickSextupleIssue() {
 			fetchPC += 4
 			slotIdx++
 		}
-		p.pc = fetchPC
-
 		if fetchStall {
+			// The held IF/ID registers still describe the fetch group, so
+			// the fetch PC must not move past it.
+			p.decodeStage.rewind(decodeMark)
 			nextIFID = p.ifid
 			nextIFID2 = p.ifid2
 			nextIFID3 = p.ifid3
@@ -3704,6 +3852,8 @@ func (p *Pipeline) tickSextupleIssue() {
 			nextEXMEM4 = p.exmem4
 			nextEXMEM5 = p.exmem5
 			nextEXMEM6 = p.exmem6
+		} else {
+			p.pc = fetchPC
 		}
 	} else if (stallResult.StallIF || memStall || execStall) && !stallResult.FlushIF {
 		nextIFID = p.ifid
@@ -3769,27 +3919,27 @@ func (p *Pipeline) collectPendingFetchInstructions6(issueCount int) ([8]pendingF
 	count := 0
 
 	if p.ifid.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid.PC, Word: p.ifid.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid.PC, Word: p.ifid.InstructionWord, PredictedTaken: p.ifid.PredictedTaken, PredictedTarget: p.ifid.PredictedTarget, EarlyResolved: p.ifid.EarlyResolved}
 		count++
 	}
 	if p.ifid2.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid2.PC, Word: p.ifid2.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid2.PC, Word: p.ifid2.InstructionWord, PredictedTaken: p.ifid2.PredictedTaken, PredictedTarget: p.ifid2.PredictedTarget, EarlyResolved: p.ifid2.EarlyResolved}
 		count++
 	}
 	if p.ifid3.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid3.PC, Word: p.ifid3.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid3.PC, Word: p.ifid3.InstructionWord, PredictedTaken: p.ifid3.PredictedTaken, PredictedTarget: p.ifid3.PredictedTarget, EarlyResolved: p.ifid3.EarlyResolved}
 		count++
 	}
 	if p.ifid4.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid4.PC, Word: p.ifid4.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid4.PC, Word: p.ifid4.InstructionWord, PredictedTaken: p.ifid4.PredictedTaken, PredictedTarget: p.ifid4.PredictedTarget, EarlyResolved: p.ifid4.EarlyResolved}
 		count++
 	}
 	if p.ifid5.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid5.PC, Word: p.ifid5.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid5.PC, Word: p.ifid5.InstructionWord, PredictedTaken: p.ifid5.PredictedTaken, PredictedTarget: p.ifid5.PredictedTarget, EarlyResolved: p.ifid5.EarlyResolved}
 		count++
 	}
 	if p.ifid6.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid6.PC, Word: p.ifid6.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid6.PC, Word: p.ifid6.InstructionWord, PredictedTaken: p.ifid6.PredictedTaken, PredictedTarget: p.ifid6.PredictedTarget, EarlyResolved: p.ifid6.EarlyResolved}
 		count++
 	}
 
@@ -3841,6 +3991,9 @@ func (p *Pipeline) Reset() {
 	p.pc = 0
 	p.stats = Statistics{}
 	p.halted = false
+	p.err = nil
+	p.executeStage.clearExit()
+	p.executeStage.clearSync()
 	p.exLatency = 0
 	p.exLatency2 = 0
 	p.exLatency3 = 0
@@ -3874,6 +4027,9 @@ func (p *Pipeline) LatencyTable() *latency.Table {
 // SetLatencyTable sets the latency table for instruction timing.
 func (p *Pipeline) SetLatencyTable(table *latency.Table) {
 	p.latencyTable = table
+	if table != nil {
+		table.SetPointerAuth(p.core.PointerAuth() == emu.PointerAuthEnabled)
+	}
 }
 
 // ICacheStats returns I-cache statistics, or empty if I-cache not enabled.
@@ -3944,8 +4100,10 @@ func (p *Pipeline) tickOctupleIssue() {
 				if result.Exited {
 					p.halted = true
 					p.exitCode = result.ExitCode
+					p.executeStage.stop()
 				}
 			}
+			p.executeStage.commit(p.exmem.PC, p.exmem.Inst)
 		}
 
 		var memResult MemoryResult
@@ -5411,6 +5569,9 @@ func (p *Pipeline) tickOctupleIssue() {
 
 	stallResult := p.hazardUnit.ComputeStalls(loadUseHazard || execStall || memStall, false)
 
+	// Decodes are discarded if fetch stalls below; remember the pool position.
+	decodeMark := p.decodeStage.checkpoint()
+
 	// Stage 2: Decode (all 8 slots)
 	var nextIDEX IDEXRegister
 	var nextIDEX2 SecondaryIDEXRegister
@@ -5452,6 +5613,7 @@ func (p *Pipeline) tickOctupleIssue() {
 					EarlyResolved:   p.ifid2.EarlyResolved,
 					// Fusion fields from CMP
 					IsFused:    true,
+					FusedInst:  decResult.Inst,
 					FusedRnVal: decResult.RnValue,
 					FusedRmVal: decResult.RmValue,
 					FusedIs64:  decResult.Inst.Is64Bit,
@@ -5764,16 +5926,8 @@ func (p *Pipeline) tickOctupleIssue() {
 			}
 			switch slotIdx {
 			case 0:
-				isUncondBranch, uncondTarget := isUnconditionalBranch(pending.Word, pending.PC)
-				pred := p.branchPredictor.Predict(pending.PC)
-				earlyResolved := false
-				if isUncondBranch {
-					pred.Taken = true
-					pred.Target = uncondTarget
-					pred.TargetKnown = true
-					earlyResolved = true
-				}
-				enrichPredictionWithEncodedTarget(&pred, pending.Word, pending.PC)
+				pred := pending.prediction()
+				earlyResolved := pending.EarlyResolved
 				nextIFID = IFIDRegister{
 					Valid:           true,
 					PC:              pending.PC,
@@ -5787,16 +5941,8 @@ func (p *Pipeline) tickOctupleIssue() {
 					branchPredictedTaken = true
 				}
 			default:
-				isUncondBranch, uncondTarget := isUnconditionalBranch(pending.Word, pending.PC)
-				pred := p.branchPredictor.Predict(pending.PC)
-				earlyResolved := false
-				if isUncondBranch {
-					pred.Taken = true
-					pred.Target = uncondTarget
-					pred.TargetKnown = true
-					earlyResolved = true
-				}
-				enrichPredictionWithEncodedTarget(&pred, pending.Word, pending.PC)
+				pred := pending.prediction()
+				earlyResolved := pending.EarlyResolved
 				switch slotIdx {
 				case 1:
 					nextIFID2 = SecondaryIFIDRegister{Valid: true, PC: pending.PC, InstructionWord: pending.Word, PredictedTaken: pred.Taken, PredictedTarget: pred.Target, EarlyResolved: earlyResolved}
@@ -5822,6 +5968,7 @@ func (p *Pipeline) tickOctupleIssue() {
 		}
 
 		// Fetch new instructions to fill remaining slots
+		eliminated := 0
 		for slotIdx < 8 {
 			var word uint32
 			var ok bool
@@ -5843,6 +5990,12 @@ func (p *Pipeline) tickOctupleIssue() {
 			// Branch elimination: unconditional B (not BL) instructions are
 			// eliminated at fetch time. They never enter the pipeline.
 			if isEliminableBranch(word) {
+				// A loop of eliminated branches (e.g. "b .") never fills a
+				// slot; stop following them once the group is exhausted.
+				if eliminated == 8 {
+					break
+				}
+				eliminated++
 				_, uncondTarget := isUnconditionalBranch(word, fetchPC)
 				fetchPC = uncondTarget
 				p.stats.EliminatedBranches++
@@ -5924,9 +6077,10 @@ func (p *Pipeline) tickOctupleIssue() {
 			fetchPC += 4
 			slotIdx++
 		}
-		p.pc = fetchPC
-
 		if fetchStall {
+			// The held IF/ID registers still describe the fetch group, so
+			// the fetch PC must not move past it.
+			p.decodeStage.rewind(decodeMark)
 			nextIFID = p.ifid
 			nextIFID2 = p.ifid2
 			nextIFID3 = p.ifid3
@@ -5951,6 +6105,8 @@ func (p *Pipeline) tickOctupleIssue() {
 			nextEXMEM6 = p.exmem6
 			nextEXMEM7 = p.exmem7
 			nextEXMEM8 = p.exmem8
+		} else {
+			p.pc = fetchPC
 		}
 	} else if (stallResult.StallIF || memStall || execStall) && !stallResult.FlushIF {
 		nextIFID = p.ifid
@@ -6030,35 +6186,35 @@ func (p *Pipeline) collectPendingFetchInstructions8(issueCount int) ([8]pendingF
 	count := 0
 
 	if p.ifid.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid.PC, Word: p.ifid.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid.PC, Word: p.ifid.InstructionWord, PredictedTaken: p.ifid.PredictedTaken, PredictedTarget: p.ifid.PredictedTarget, EarlyResolved: p.ifid.EarlyResolved}
 		count++
 	}
 	if p.ifid2.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid2.PC, Word: p.ifid2.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid2.PC, Word: p.ifid2.InstructionWord, PredictedTaken: p.ifid2.PredictedTaken, PredictedTarget: p.ifid2.PredictedTarget, EarlyResolved: p.ifid2.EarlyResolved}
 		count++
 	}
 	if p.ifid3.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid3.PC, Word: p.ifid3.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid3.PC, Word: p.ifid3.InstructionWord, PredictedTaken: p.ifid3.PredictedTaken, PredictedTarget: p.ifid3.PredictedTarget, EarlyResolved: p.ifid3.EarlyResolved}
 		count++
 	}
 	if p.ifid4.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid4.PC, Word: p.ifid4.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid4.PC, Word: p.ifid4.InstructionWord, PredictedTaken: p.ifid4.PredictedTaken, PredictedTarget: p.ifid4.PredictedTarget, EarlyResolved: p.ifid4.EarlyResolved}
 		count++
 	}
 	if p.ifid5.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid5.PC, Word: p.ifid5.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid5.PC, Word: p.ifid5.InstructionWord, PredictedTaken: p.ifid5.PredictedTaken, PredictedTarget: p.ifid5.PredictedTarget, EarlyResolved: p.ifid5.EarlyResolved}
 		count++
 	}
 	if p.ifid6.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid6.PC, Word: p.ifid6.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid6.PC, Word: p.ifid6.InstructionWord, PredictedTaken: p.ifid6.PredictedTaken, PredictedTarget: p.ifid6.PredictedTarget, EarlyResolved: p.ifid6.EarlyResolved}
 		count++
 	}
 	if p.ifid7.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid7.PC, Word: p.ifid7.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid7.PC, Word: p.ifid7.InstructionWord, PredictedTaken: p.ifid7.PredictedTaken, PredictedTarget: p.ifid7.PredictedTarget, EarlyResolved: p.ifid7.EarlyResolved}
 		count++
 	}
 	if p.ifid8.Valid {
-		allFetched[count] = pendingFetchInst{PC: p.ifid8.PC, Word: p.ifid8.InstructionWord}
+		allFetched[count] = pendingFetchInst{PC: p.ifid8.PC, Word: p.ifid8.InstructionWord, PredictedTaken: p.ifid8.PredictedTaken, PredictedTarget: p.ifid8.PredictedTarget, EarlyResolved: p.ifid8.EarlyResolved}
 		count++
 	}
 
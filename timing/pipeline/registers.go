@@ -71,6 +71,9 @@ type IDEXRegister struct {
 	FusedIs64   bool   // CMP was 64-bit operation
 	FusedIsImm  bool   // CMP used immediate operand
 	FusedImmVal uint64 // CMP's immediate value (if FusedIsImm)
+
+	// FusedInst is the decoded CMP, executed together with the B.cond.
+	FusedInst *insts.Instruction
 }
 
 // Clear resets the ID/EX register to empty state.
@@ -22,14 +22,24 @@ func (s *FetchStage) Fetch(pc uint64) (uint32, bool) {
 	return word, true
 }
 
+// decodePoolSize is the number of pre-allocated decoded instructions. An
+// entry must not be recycled while its instruction is still in flight, so the
+// pool covers the ID/EX, EX/MEM and MEM/WB slots of an 8-wide pipeline plus
+// the extra decodes done for fusion and issue checks.
+const decodePoolSize = 32
+
 // DecodeStage decodes instructions and reads register values.
 type DecodeStage struct {
 	regFile *emu.RegFile
 	decoder *insts.Decoder
 	// Pool of pre-allocated instructions to avoid heap allocations during decode
-	// Supports up to 8 concurrent decode operations (for 8-wide superscalar pipelines)
-	instPool  [8]insts.Instruction
+	instPool  [decodePoolSize]insts.Instruction
 	poolIndex int
+
+	// Decode-order sequence number of each pool entry, used by the execute
+	// stage to recognize an instruction it has already executed.
+	poolSeq [decodePoolSize]uint64
+	nextSeq uint64
 }
 
 // NewDecodeStage creates a new decode stage.
@@ -60,6 +70,8 @@ type DecodeResult struct {
 func (s *DecodeStage) Decode(word uint32, pc uint64) DecodeResult {
 	// Get next available pre-allocated instruction from pool
 	inst := &s.instPool[s.poolIndex]
+	s.nextSeq++
+	s.poolSeq[s.poolIndex] = s.nextSeq
 	s.poolIndex = (s.poolIndex + 1) % len(s.instPool)
 
 	// Use DecodeInto with pre-allocated instruction to eliminate heap allocation
@@ -77,9 +89,26 @@ func (s *DecodeStage) Decode(word uint32, pc uint64) DecodeResult {
 		result.Rd = 30
 	}
 
+	// CBZ/CBNZ/TBZ/TBNZ test Rt (decoded into Rd) and write no register.
+	// Present it as the Rn source so hazard detection and forwarding see it.
+	switch inst.Op {
+	case insts.OpCBZ, insts.OpCBNZ, insts.OpTBZ, insts.OpTBNZ:
+		result.Rn = inst.Rd
+		result.Rd = 31
+	}
+
+	// Store-exclusive writes its status, and CAS/CASP the old memory value,
+	// to Rs (decoded into Rm).
+	switch inst.Op {
+	case insts.OpSTXR, insts.OpSTXP, insts.OpCAS, insts.OpCASP:
+		result.Rd = inst.Rm
+	}
+
 	// Read register values
-	result.RnValue = s.regFile.ReadReg(inst.Rn)
-	result.RmValue = s.regFile.ReadReg(inst.Rm)
+	result.RnValue = s.regFile.ReadReg(result.Rn)
+	if inst.Rm <= 31 { // CCMP/CCMN immediate forms mark Rm as unused
+		result.RmValue = s.regFile.ReadReg(inst.Rm)
+	}
 
 	// Determine control signals based on instruction type
 	result.RegWrite = s.isRegWriteInst(inst)
@@ -92,21 +121,71 @@ func (s *DecodeStage) Decode(word uint32, pc uint64) DecodeResult {
 	return result
 }
 
+// decodeCheckpoint is a position in the decode pool.
+type decodeCheckpoint struct {
+	poolIndex int
+	nextSeq   uint64
+}
+
+// checkpoint returns the current decode pool position.
+func (s *DecodeStage) checkpoint() decodeCheckpoint {
+	return decodeCheckpoint{poolIndex: s.poolIndex, nextSeq: s.nextSeq}
+}
+
+// rewind releases the pool entries used since checkpoint c. Callers use it
+// when they discard decode results, so that held instructions are not
+// overwritten by repeated decodes during long stalls.
+func (s *DecodeStage) rewind(c decodeCheckpoint) {
+	s.poolIndex = c.poolIndex
+	s.nextSeq = c.nextSeq
+}
+
+// sequence returns the pool index and decode-order sequence number of an
+// instruction returned by Decode. ok is false for instructions that did not
+// come from this stage's pool.
+func (s *DecodeStage) sequence(inst *insts.Instruction) (index int, seq uint64, ok bool) {
+	for i := range s.instPool {
+		if &s.instPool[i] == inst {
+			return i, s.poolSeq[i], true
+		}
+	}
+	return 0, 0, false
+}
+
 // isLoadOp returns true if the opcode is a load operation.
 func (s *DecodeStage) isLoadOp(op insts.Op) bool {
 	switch op {
 	case insts.OpLDR, insts.OpLDP, insts.OpLDRB, insts.OpLDRSB,
-		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRLit, insts.OpLDRQ:
+		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDPSW,
+		insts.OpLDRLit, insts.OpLDRQ, insts.OpVLDN, insts.OpVLDNLane, insts.OpVLDNR:
+		return true
+	case insts.OpLDXR, insts.OpLDXP, insts.OpLDAR, insts.OpLDAPR:
 		return true
 	default:
-		return false
+		return isAtomicRMWOp(op)
 	}
 }
 
 // isStoreOp returns true if the opcode is a store operation.
 func (s *DecodeStage) isStoreOp(op insts.Op) bool {
 	switch op {
-	case insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH, insts.OpSTRQ:
+	case insts.OpSTR, insts.OpSTP, insts.OpSTRB, insts.OpSTRH, insts.OpSTRQ,
+		insts.OpVSTN, insts.OpVSTNLane:
+		return true
+	case insts.OpSTXR, insts.OpSTXP, insts.OpSTLR:
+		return true
+	default:
+		return isAtomicRMWOp(op)
+	}
+}
+
+// isAtomicRMWOp returns true for the atomic read-modify-write operations,
+// which both load and store.
+func isAtomicRMWOp(op insts.Op) bool {
+	switch op {
+	case insts.OpCAS, insts.OpCASP, insts.OpSWP,
+		insts.OpLDADD, insts.OpLDCLR, insts.OpLDEOR, insts.OpLDSET,
+		insts.OpLDSMAX, insts.OpLDSMIN, insts.OpLDUMAX, insts.OpLDUMIN:
 		return true
 	default:
 		return false
@@ -115,6 +194,12 @@ func (s *DecodeStage) isStoreOp(op insts.Op) bool {
 
 // isRegWriteInst determines if the instruction writes to a register.
 func (s *DecodeStage) isRegWriteInst(inst *insts.Instruction) bool {
+	// Store-exclusive and compare-and-swap write Rs (decoded into Rm)
+	switch inst.Op {
+	case insts.OpSTXR, insts.OpSTXP, insts.OpCAS, insts.OpCASP:
+		return inst.Rm != 31
+	}
+
 	// Don't write if destination is XZR (register 31)
 	if inst.Rd == 31 && inst.Op != insts.OpBL && inst.Op != insts.OpBLR {
 		return false
@@ -124,8 +209,38 @@ func (s *DecodeStage) isRegWriteInst(inst *insts.Instruction) bool {
 	case insts.OpADD, insts.OpSUB, insts.OpAND, insts.OpORR, insts.OpEOR,
 		insts.OpBIC, insts.OpORN, insts.OpEON:
 		return true
-	case insts.OpLDR, insts.OpLDP, insts.OpLDRB, insts.OpLDRSB,
-		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRLit, insts.OpLDRQ:
+	case insts.OpADR, insts.OpADRP, insts.OpMOVZ, insts.OpMOVN, insts.OpMOVK:
+		return true
+	case insts.OpCSEL, insts.OpCSINC, insts.OpCSINV, insts.OpCSNEG:
+		return true
+	case insts.OpUDIV, insts.OpSDIV, insts.OpLSLV, insts.OpLSRV, insts.OpASRV, insts.OpRORV,
+		insts.OpCRC32, insts.OpCRC32C:
+		return true
+	case insts.OpRBIT, insts.OpREV16, insts.OpREV32, insts.OpREV, insts.OpCLZ, insts.OpCLS,
+		insts.OpADC, insts.OpSBC:
+		return true
+	case insts.OpSMADDL, insts.OpSMSUBL, insts.OpUMADDL, insts.OpUMSUBL,
+		insts.OpSMULH, insts.OpUMULH:
+		return true
+	case insts.OpSBFM, insts.OpBFM, insts.OpUBFM, insts.OpEXTR,
+		insts.OpMADD, insts.OpMSUB, insts.OpMRS:
+		return true
+	case insts.OpLDR, insts.OpLDRB, insts.OpLDRSB,
+		insts.OpLDRH, insts.OpLDRSH, insts.OpLDRSW, insts.OpLDPSW:
+		return true
+	case insts.OpLDP, insts.OpLDRLit:
+		return !inst.IsSIMD // SIMD&FP forms write V registers
+	case insts.OpLDXR, insts.OpLDXP, insts.OpLDAR, insts.OpLDAPR, insts.OpSWP,
+		insts.OpLDADD, insts.OpLDCLR, insts.OpLDEOR, insts.OpLDSET,
+		insts.OpLDSMAX, insts.OpLDSMIN, insts.OpLDUMAX, insts.OpLDUMIN:
+		return true
+	case insts.OpFMOVToGP, insts.OpFCVTNS, insts.OpFCVTNU, insts.OpFCVTPS, insts.OpFCVTPU,
+		insts.OpFCVTMS, insts.OpFCVTMU, insts.OpFCVTZS, insts.OpFCVTZU,
+		insts.OpFCVTAS, insts.OpFCVTAU:
+		return true // FP to general-purpose register moves and conversions
+	case insts.OpUMOV, insts.OpSMOV:
+		return true // Vector element to general-purpose register moves
+	case insts.OpPAC, insts.OpAUT, insts.OpXPAC, insts.OpPACGA:
 		return true
 	case insts.OpBL, insts.OpBLR:
 		return true // BL/BLR write to X30
@@ -137,7 +252,8 @@ func (s *DecodeStage) isRegWriteInst(inst *insts.Instruction) bool {
 // isBranchInst determines if the instruction is a branch.
 func (s *DecodeStage) isBranchInst(inst *insts.Instruction) bool {
 	switch inst.Op {
-	case insts.OpB, insts.OpBL, insts.OpBCond, insts.OpBR, insts.OpBLR, insts.OpRET:
+	case insts.OpB, insts.OpBL, insts.OpBCond, insts.OpBR, insts.OpBLR, insts.OpRET,
+		insts.OpCBZ, insts.OpCBNZ, insts.OpTBZ, insts.OpTBNZ:
 		return true
 	default:
 		return false
@@ -145,8 +261,37 @@ func (s *DecodeStage) isBranchInst(inst *insts.Instruction) bool {
 }
 
 // ExecuteStage performs ALU operations.
+//
+// When an execution core is attached (see attachCore), every instruction is
+// executed by the functional emulator's core, which updates the architectural
+// state directly. The pipeline registers then only carry the information the
+// timing model needs: effective addresses, branch outcomes and the values
+// used for forwarding bookkeeping.
 type ExecuteStage struct {
 	regFile *emu.RegFile
+
+	core        *emu.Emulator
+	decodeStage *DecodeStage
+
+	// Replay guard. Stalls can hold an already executed instruction in ID/EX
+	// and execute it again; the core must apply its side effects only once,
+	// so later executions return the recorded result instead.
+	replaySeq [decodePoolSize]uint64
+	replay    [decodePoolSize]ExecuteResult
+
+	// exit records the first core result that stops execution: a BRK trap
+	// or an instruction the core could not execute.
+	exit    emu.StepResult
+	exitPC  uint64
+	exiting bool
+
+	// syncPC is the PC of an executed ISB; the pipeline refetches the
+	// instructions after it.
+	syncPC      uint64
+	syncPending bool
+
+	// onCommit, if set, is called after the core commits an instruction.
+	onCommit func(pc uint64, inst *insts.Instruction)
 }
 
 // NewExecuteStage creates a new execute stage.
@@ -189,6 +334,10 @@ func (s *ExecuteStage) ExecuteWithFlags(idex *IDEXRegister, rnValue, rmValue uin
 		return result
 	}
 
+	if s.core != nil {
+		return s.executeOnCore(idex)
+	}
+
 	inst := idex.Inst
 
 	// Apply shift to Rm for data-processing register instructions.
@@ -344,6 +493,156 @@ func (s *ExecuteStage) ExecuteWithFlags(idex *IDEXRegister, rnValue, rmValue uin
 	return result
 }
 
+// attachCore makes the stage execute instructions on the given emulator core.
+// decodeStage identifies instructions held in ID/EX across stalls so that
+// they are not executed twice.
+func (s *ExecuteStage) attachCore(core *emu.Emulator, decodeStage *DecodeStage) {
+	s.core = core
+	s.decodeStage = decodeStage
+}
+
+// executeOnCore executes the instruction on the shared execution core and
+// derives the pipeline-visible result from the architectural state it leaves.
+func (s *ExecuteStage) executeOnCore(idex *IDEXRegister) ExecuteResult {
+	inst := idex.Inst
+
+	index, seq, pooled := s.decodeStage.sequence(inst)
+	if pooled && s.replaySeq[index] == seq {
+		return s.replay[index]
+	}
+
+	result := ExecuteResult{}
+
+	// Syscalls are handled when the instruction reaches the memory stage.
+	// Nothing younger than a trap or an exit executes.
+	if inst.Op != insts.OpSVC && !s.exiting {
+		// A fused CMP never enters the pipeline on its own; apply its flags
+		// before evaluating the B.cond that carries it.
+		if idex.IsFused && idex.FusedInst != nil {
+			s.regFile.PC = idex.PC - 4
+			s.core.Execute(idex.FusedInst)
+			s.commit(idex.PC-4, idex.FusedInst)
+		}
+
+		if idex.MemRead || idex.MemWrite {
+			result.ALUResult = s.effectiveAddress(inst, idex.PC)
+		}
+
+		s.regFile.PC = idex.PC
+		step := s.core.Execute(inst)
+		if step.Exited || step.Err != nil {
+			s.exit = step
+			s.exitPC = idex.PC
+			s.exiting = true
+		} else {
+			s.commit(idex.PC, inst)
+			if inst.Op == insts.OpISB {
+				s.syncPC = idex.PC
+				s.syncPending = true
+			}
+		}
+
+		if nextPC := s.regFile.PC; nextPC != idex.PC+4 {
+			result.BranchTaken = true
+			result.BranchTarget = nextPC
+		}
+
+		if !idex.MemRead && !idex.MemWrite {
+			result.ALUResult = s.regFile.ReadReg(idex.Rd)
+		}
+		if idex.MemWrite {
+			result.StoreValue = s.regFile.ReadReg(inst.Rd)
+		}
+
+		if inst.SetFlags || inst.Format == insts.FormatCondCmp {
+			result.SetsFlags = true
+			result.FlagN = s.regFile.PSTATE.N
+			result.FlagZ = s.regFile.PSTATE.Z
+			result.FlagC = s.regFile.PSTATE.C
+			result.FlagV = s.regFile.PSTATE.V
+		}
+	}
+
+	if pooled {
+		s.replaySeq[index] = seq
+		s.replay[index] = result
+	}
+
+	return result
+}
+
+// effectiveAddress computes the data address of a load or store from the
+// architectural state before the instruction executes. It only feeds the
+// data cache model; the core performs the access itself.
+func (s *ExecuteStage) effectiveAddress(inst *insts.Instruction, pc uint64) uint64 {
+	if inst.Format == insts.FormatLoadStoreLit {
+		return uint64(int64(pc) + inst.BranchOffset)
+	}
+
+	base := s.regFile.ReadReg(inst.Rn)
+	if inst.Rn == 31 {
+		base = s.regFile.SP
+	}
+
+	switch inst.IndexMode {
+	case insts.IndexPost:
+		return base
+	case insts.IndexPre, insts.IndexSigned:
+		return uint64(int64(base) + inst.SignedImm)
+	case insts.IndexRegBase:
+		rm := s.regFile.ReadReg(inst.Rm)
+		switch inst.ShiftType {
+		case 0b010: // UXTW
+			rm = uint64(uint32(rm))
+		case 0b110: // SXTW
+			rm = uint64(int64(int32(rm)))
+		}
+		return base + rm<<inst.ShiftAmount
+	default:
+		if inst.Format == insts.FormatLoadStorePair {
+			return uint64(int64(base) + inst.SignedImm)
+		}
+		return base + inst.Imm
+	}
+}
+
+// commit reports an instruction whose results the core has committed.
+func (s *ExecuteStage) commit(pc uint64, inst *insts.Instruction) {
+	if s.onCommit != nil {
+		s.onCommit(pc, inst)
+	}
+}
+
+// stop keeps instructions younger than an exiting syscall from executing.
+func (s *ExecuteStage) stop() {
+	s.exiting = true
+}
+
+// clearExit forgets a recorded trap so that execution can restart.
+func (s *ExecuteStage) clearExit() {
+	s.exit = emu.StepResult{}
+	s.exitPC = 0
+	s.exiting = false
+}
+
+// pendingSync reports an executed ISB, by PC, whose younger instructions
+// have not been refetched yet.
+func (s *ExecuteStage) pendingSync() (uint64, bool) {
+	return s.syncPC, s.syncPending
+}
+
+// clearSync forgets a pending ISB.
+func (s *ExecuteStage) clearSync() {
+	s.syncPC = 0
+	s.syncPending = false
+}
+
+// exitResult reports whether the core stopped execution (BRK or an
+// instruction it cannot execute), with which result and at which PC.
+func (s *ExecuteStage) exitResult() (emu.StepResult, uint64, bool) {
+	return s.exit, s.exitPC, s.exiting
+}
+
 // checkCondition evaluates a branch condition based on PSTATE flags.
 func (s *ExecuteStage) checkCondition(cond insts.Cond) bool {
 	pstate := s.regFile.PSTATE
@@ -523,6 +822,10 @@ func (s *ExecuteStage) computeSubFlags(inst *insts.Instruction, op1, op2, result
 // MemoryStage handles memory reads and writes.
 type MemoryStage struct {
 	memory *emu.Memory
+
+	// timingOnly is set when the execute stage's core already performed the
+	// access; the stage then only models the access slot.
+	timingOnly bool
 }
 
 // NewMemoryStage creates a new memory stage.
@@ -539,7 +842,7 @@ type MemoryResult struct {
 func (s *MemoryStage) Access(exmem *EXMEMRegister) MemoryResult {
 	result := MemoryResult{}
 
-	if !exmem.Valid {
+	if !exmem.Valid || s.timingOnly {
 		return result
 	}
 
@@ -583,7 +886,7 @@ type MemorySlot interface {
 func (s *MemoryStage) MemorySlot(slot MemorySlot) MemoryResult {
 	result := MemoryResult{}
 
-	if !slot.IsValid() {
+	if !slot.IsValid() || s.timingOnly {
 		return result
 	}
 
@@ -614,6 +917,10 @@ func (s *MemoryStage) MemorySlot(slot MemorySlot) MemoryResult {
 // WritebackStage writes results back to the register file.
 type WritebackStage struct {
 	regFile *emu.RegFile
+
+	// timingOnly is set when the execute stage's core already committed the
+	// results; the stage then only retires instructions.
+	timingOnly bool
 }
 
 // NewWritebackStage creates a new writeback stage.
@@ -623,7 +930,7 @@ func NewWritebackStage(regFile *emu.RegFile) *WritebackStage {
 
 // Writeback writes the result to the destination register.
 func (s *WritebackStage) Writeback(memwb *MEMWBRegister) {
-	if !memwb.Valid || !memwb.RegWrite {
+	if !memwb.Valid || !memwb.RegWrite || s.timingOnly {
 		return
 	}
 
@@ -657,7 +964,7 @@ type WritebackSlot interface {
 // writebackSlot performs writeback for any MEMWB slot.
 // Returns true if an instruction was retired.
 func (s *WritebackStage) WritebackSlot(slot WritebackSlot) bool {
-	if !slot.IsValid() || !slot.GetRegWrite() {
+	if !slot.IsValid() || !slot.GetRegWrite() || s.timingOnly {
 		return slot.IsValid() // Valid but no regwrite still counts as retired
 	}
 
@@ -692,7 +999,7 @@ func (s *WritebackStage) WritebackSlots(slots []WritebackSlot) uint64 {
 		retired++
 
 		// Skip register write operations
-		if !slot.GetRegWrite() || slot.GetRd() == 31 {
+		if !slot.GetRegWrite() || slot.GetRd() == 31 || s.timingOnly {
 			continue
 		}
 
@@ -104,6 +104,11 @@ func canDualIssue(first, second *IDEXRegister) bool {
 		return false
 	}
 
+	// Barriers issue alone
+	if isBarrier(first) || isBarrier(second) {
+		return false
+	}
+
 	// Cannot co-issue a load after a store (no store-to-load forwarding)
 	if first.MemWrite && second.MemRead {
 		return false
@@ -973,6 +978,19 @@ func isALUOp(inst *IDEXRegister) bool {
 	return true
 }
 
+// isBarrier returns true for DMB, DSB and ISB.
+func isBarrier(inst *IDEXRegister) bool {
+	if inst.Inst == nil {
+		return false
+	}
+	switch inst.Inst.Op {
+	case insts.OpDMB, insts.OpDSB, insts.OpISB:
+		return true
+	default:
+		return false
+	}
+}
+
 // canIssueWith checks if a new instruction can be issued with a set of previously issued instructions.
 // Uses a fixed-size array to avoid heap allocation per tick cycle.
 func canIssueWith(newInst *IDEXRegister, earlier *[8]*IDEXRegister, earlierCount int) bool {
@@ -990,6 +1008,11 @@ func canIssueWith(newInst *IDEXRegister, earlier *[8]*IDEXRegister, earlierCount
 		return false
 	}
 
+	// Barriers only issue in slot 0
+	if isBarrier(newInst) {
+		return false
+	}
+
 	// Memory operations can only execute in slots with memory ports (first maxMemPorts slots).
 	// The new instruction would go into slot earlierCount, so reject memory ops in slots >= maxMemPorts.
 	newAccessesMem := newInst.MemRead || newInst.MemWrite
@@ -1025,6 +1048,18 @@ func canIssueWith(newInst *IDEXRegister, earlier *[8]*IDEXRegister, earlierCount
 			continue
 		}
 
+		// Nothing issues alongside a syscall: younger instructions must
+		// observe its results.
+		if prev.Inst != nil && prev.Inst.Op == insts.OpSVC {
+			return false
+		}
+
+		// Nothing issues alongside a barrier either: younger instructions
+		// must wait until it has taken effect.
+		if isBarrier(prev) {
+			return false
+		}
+
 		// Cannot co-issue a load after a store (no store-to-load forwarding)
 		if prev.MemWrite && newInst.MemRead {
 			return false